@@ -0,0 +1,95 @@
+package dkg
+
+import (
+	"math/big"
+	"testing"
+)
+
+// adversary collects the state of the nodes it corrupts, possibly a
+// different node in every epoch.
+type adversary struct {
+	leaked []*KeyShare
+}
+
+func (a *adversary) corrupt(share *KeyShare) {
+	a.leaked = append(a.leaked, share)
+}
+
+// reconstructs tells whether the leaked shares interpolate to the key.
+func (a *adversary) reconstructs(group GroupKey) bool {
+	curve := group.PublicKey.Curve
+	n := curve.Params().N
+	xs := make([]*big.Int, len(a.leaked))
+	for i, s := range a.leaked {
+		xs[i] = s.ID
+	}
+	secret := new(big.Int)
+	for _, s := range a.leaked {
+		secret.Add(secret, new(big.Int).Mul(lagrangeCoefficient(s.ID, xs, n), s.Share))
+	}
+	x, y := curve.ScalarBaseMult(secret.Mod(secret, n).Bytes())
+	return x.Cmp(group.PublicKey.X) == 0 && y.Cmp(group.PublicKey.Y) == 0
+}
+
+func TestAdaptiveCorruption(t *testing.T) {
+	const size, threshold = 5, 2
+	run := func(threshold int) []*KeyShare {
+		nodes, participants := getCeremonyNodesForTesting(t, size, threshold)
+		results := runCeremonyForTesting(t, nodes, participants)
+		for i, result := range results {
+			if result == nil {
+				t.Fatalf("Node %v did not finish", nodes[i].ID())
+			}
+		}
+		return results
+	}
+
+	t.Run("Corruption across refreshes", func(t *testing.T) {
+		shares := run(threshold)
+		static, adaptive := &adversary{}, &adversary{}
+		for i := 0; i <= threshold; i++ {
+			static.corrupt(shares[i])
+		}
+		if !static.reconstructs(shares[0].Group()) {
+			t.Fatalf("Threshold+1 shares of one epoch don't reconstruct the key")
+		}
+
+		// one more node per epoch, the previous ones recover in between
+		for epoch := 0; epoch <= threshold; epoch++ {
+			adaptive.corrupt(shares[epoch])
+			updates := run(threshold - 1)
+			for i := range shares {
+				refreshed, err := RefreshShare(shares[i], updates[i])
+				if err != nil {
+					t.Fatal(err)
+				}
+				shares[i] = refreshed
+			}
+		}
+		if adaptive.reconstructs(shares[0].Group()) {
+			t.Errorf("Shares leaked in different epochs reconstruct the key")
+		}
+	})
+
+	t.Run("Corruption before extraction", func(t *testing.T) {
+		// a dealer turning malicious after qualifying can't bias the key:
+		// the honest nodes reconstruct its coefficients from their shares
+		nodes, participants := getCeremonyNodesForTesting(t, size, threshold)
+		dealer := nodes[0].ID()
+		results := runCeremonyForTesting(t, nodes, participants,
+			tamperWith(dealer, PublicCoefficientsMessage, func(_ *big.Int, payload Hashable) Hashable {
+				pts := append(PointTuple(nil), payload.(PointTuple)...)
+				pts[0], pts[1] = pts[1], pts[0]
+				return pts
+			}))
+		for i, result := range results[1:] {
+			if result == nil {
+				t.Fatalf("Node %v did not finish", nodes[i+1].ID())
+			}
+			if !containsID(result.Qualified, dealer) {
+				t.Errorf("Node %v left out the corrupted dealer: %v", nodes[i+1].ID(), result.Qualified)
+			}
+		}
+		checkCeremonyResultsForTesting(t, results[1:])
+	})
+}
@@ -0,0 +1,239 @@
+package dkg
+
+import "bytes"
+import "crypto"
+import "crypto/ecdsa"
+import "crypto/rand"
+import "crypto/sha256"
+import "hash"
+import "math/big"
+import "sync"
+
+// AuditLog is an append-only transcript of a ceremony's broadcasts as its
+// recorder, one of the participants, saw them. Every entry extends a hash
+// chain over the ceremony's parameters and the entries before it, and the
+// recorder signs the chain's head, so that no entry can be altered, dropped
+// or reordered afterwards without invalidating the signature. Record one
+// with an AuditTransport and check it with a TranscriptVerifier.
+type AuditLog struct {
+	// Ceremony holds the parameters of the ceremony and the entries, its
+	// broadcasts.
+	Ceremony  CeremonyBundle
+	Recorder  *big.Int
+	Signature []byte
+
+	mu   sync.Mutex
+	head []byte
+}
+
+// NewAuditLog starts the log of the ceremony with the parameters in
+// ceremony, whose broadcasts become the first entries.
+func NewAuditLog(ceremony CeremonyBundle, recorder *big.Int) *AuditLog {
+	l := &AuditLog{Ceremony: ceremony, Recorder: recorder}
+	l.Ceremony.Broadcasts = nil
+	l.head = l.genesis()
+	for _, m := range ceremony.Broadcasts {
+		l.Append(m)
+	}
+	return l
+}
+
+// genesis returns the head of the empty log, a digest of the parameters.
+func (l *AuditLog) genesis() []byte {
+	params := l.Ceremony
+	params.Broadcasts = nil
+	w := NewTranscriptWriter(sha256.New())
+	w.WriteTag("dkg/audit-log")
+	params.writeBundle(w)
+	return w.Sum()
+}
+
+func chainAuditEntry(head []byte, m Message) []byte {
+	w := NewTranscriptWriter(sha256.New())
+	w.WriteTag("dkg/audit-entry")
+	w.WriteBytes(head)
+	w.Write(m)
+	return w.Sum()
+}
+
+// Append adds a broadcast to the log. Messages to single participants are
+// left out, they aren't public.
+func (l *AuditLog) Append(m Message) {
+	if m.To != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Ceremony.Broadcasts = append(l.Ceremony.Broadcasts, m)
+	l.head = chainAuditEntry(l.head, m)
+	l.Signature = nil
+}
+
+// Head returns the head of the hash chain.
+func (l *AuditLog) Head() []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]byte(nil), l.head...)
+}
+
+func (l *AuditLog) statement(head []byte) []byte {
+	w := NewTranscriptWriter(sha256.New())
+	w.WriteTag("dkg/audit-signature")
+	w.WriteInt(l.Recorder)
+	w.WriteUint(uint64(len(l.Ceremony.Broadcasts)))
+	w.WriteBytes(head)
+	return w.Sum()
+}
+
+// Sign signs the log's head with the recorder's identity key. Appending
+// afterwards removes the signature.
+func (l *AuditLog) Sign(identity Identity) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sig, err := identity.Sign(rand.Reader, l.statement(l.head), crypto.Hash(0))
+	if err != nil {
+		return err
+	}
+	l.Signature = sig
+	return nil
+}
+
+func (l *AuditLog) MarshalBinary() ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ceremony, err := l.Ceremony.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if l.Recorder == nil {
+		return nil, InvalidEncodingError{"audit log without a recorder"}
+	}
+	return encodeBinary(func(w *TranscriptWriter) {
+		w.WriteTag("dkg/audit-log")
+		w.WriteBytes(ceremony)
+		w.WriteInt(l.Recorder)
+		w.WriteBytes(l.Signature)
+	}), nil
+}
+
+// UnmarshalBinary decodes a log; it is checked by a TranscriptVerifier.
+func (l *AuditLog) UnmarshalBinary(data []byte) error {
+	var ceremony CeremonyBundle
+	var recorder *big.Int
+	var signature []byte
+	err := unmarshalBinary(data, func(r *transcriptReader) {
+		r.expectTag("dkg/audit-log")
+		if b := r.readBytes(); r.err == nil {
+			if err := ceremony.UnmarshalBinary(b); err != nil {
+				r.fail("invalid ceremony")
+			}
+		}
+		recorder = r.readInt()
+		signature = r.readBytes()
+	})
+	if err != nil {
+		return err
+	}
+	decoded := NewAuditLog(ceremony, recorder)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Ceremony, l.Recorder, l.Signature, l.head = decoded.Ceremony, recorder, signature, decoded.head
+	return nil
+}
+
+// AuditTransport appends the broadcasts a node sends and receives through
+// it to an AuditLog. Beneath an EnvelopeTransport, it records the sealed
+// broadcasts, and the log's ceremony should name the session, so that the
+// log also proves who sent each broadcast.
+type AuditTransport struct {
+	transport Transport
+	log       *AuditLog
+	inbox     *mailbox
+}
+
+func NewAuditTransport(log *AuditLog, transport Transport) *AuditTransport {
+	t := &AuditTransport{transport, log, newMailbox()}
+	go t.run()
+	return t
+}
+
+func (t *AuditTransport) run() {
+	defer t.inbox.close()
+	for m := range t.transport.Receive() {
+		t.log.Append(m)
+		t.inbox.put(m)
+	}
+}
+
+func (t *AuditTransport) Send(to *big.Int, m Message) error {
+	return t.transport.Send(to, m)
+}
+
+func (t *AuditTransport) Broadcast(m Message) error {
+	t.log.Append(m)
+	return t.transport.Broadcast(m)
+}
+
+func (t *AuditTransport) Receive() <-chan Message {
+	return t.inbox.out
+}
+
+func (t *AuditTransport) Close() error {
+	err := t.transport.Close()
+	t.inbox.close()
+	return err
+}
+
+// TranscriptVerifier checks audit logs for relying parties outside the
+// ceremony, such as auditors: that each log is signed by its recorder, a
+// participant, over its whole hash chain, and that its broadcasts produce
+// the group key, like VerifyCeremonyBundle. Hash must be the participants'
+// hash.
+type TranscriptVerifier struct {
+	Hash hash.Hash
+}
+
+// Verify checks the logs, which must all be of the same ceremony and agree
+// on its outcome, and returns the outcome.
+func (v TranscriptVerifier) Verify(logs ...*AuditLog) (Attestation, error) {
+	if len(logs) == 0 {
+		return Attestation{}, InvalidAuditLogError{0, "no logs"}
+	}
+	var outcome Attestation
+	var genesis []byte
+	for i, l := range logs {
+		l.mu.Lock()
+		ceremony, recorder, signature := l.Ceremony, l.Recorder, l.Signature
+		l.mu.Unlock()
+		if ceremony.Curve == nil || ceremony.ZKParam == nil || recorder == nil {
+			return Attestation{}, InvalidAuditLogError{i, "no ceremony"}
+		}
+		replayed := NewAuditLog(ceremony, recorder)
+		if i == 0 {
+			genesis = replayed.genesis()
+		} else if !bytes.Equal(replayed.genesis(), genesis) {
+			return Attestation{}, InvalidAuditLogError{i, "of another ceremony"}
+		}
+		var key *ecdsa.PublicKey
+		for _, p := range ceremony.Participants {
+			if p.ID != nil && p.ID.Cmp(recorder) == 0 {
+				key = &p.Key
+			}
+		}
+		if key == nil {
+			return Attestation{}, InvalidAuditLogError{i, "not recorded by a participant"}
+		}
+		if !ecdsa.VerifyASN1(key, replayed.statement(replayed.head), signature) {
+			return Attestation{}, InvalidAuditLogError{i, "not signed by its recorder"}
+		}
+		a, err := VerifyCeremonyBundle(v.Hash, ceremony)
+		if err != nil {
+			return Attestation{}, err
+		}
+		if i > 0 && (a.PublicKey.X.Cmp(outcome.PublicKey.X) != 0 || a.PublicKey.Y.Cmp(outcome.PublicKey.Y) != 0) {
+			return Attestation{}, InvalidAuditLogError{i, "disagrees on the group key"}
+		}
+		outcome = a
+	}
+	return outcome, nil
+}
@@ -0,0 +1,79 @@
+package dkg
+
+import (
+	"crypto/sha512"
+	"reflect"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	curve, _, g2x, g2y, zkParam, _, _, _, _, _ := getValidNodeParamsForTesting(t)
+	nodes, participants := getCeremonyNodesForTesting(t, 4, 1)
+	set, _ := NewParticipantSet(curve, 1, participants)
+	ceremony := CeremonyBundle{curve, g2x, g2y, false, zkParam, 1, participants, "audited", nil, nil}
+	logs := make(map[string]*AuditLog)
+	results := runCeremonyForTesting(t, nodes, participants,
+		func(n *Node, t Transport) Transport {
+			logs[n.ID().String()] = NewAuditLog(ceremony, n.ID())
+			return NewAuditTransport(logs[n.ID().String()], t)
+		},
+		func(n *Node, t Transport) Transport {
+			return NewEnvelopeTransport(n, set, "audited", t)
+		})
+	checkCeremonyResultsForTesting(t, results)
+
+	var signed []*AuditLog
+	for _, node := range nodes {
+		l := logs[node.ID().String()]
+		if err := l.Sign(node.identity); err != nil {
+			t.Fatal(err)
+		}
+		data, err := l.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded AuditLog
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatalf("Could not decode log: %v", err)
+		}
+		signed = append(signed, &decoded)
+	}
+	verifier := TranscriptVerifier{sha512.New512_256()}
+	outcome, err := verifier.Verify(signed...)
+	if err != nil {
+		t.Fatalf("Could not verify logs: %v", err)
+	}
+	if outcome.PublicKey.X.Cmp(results[0].PublicKey.X) != 0 || !reflect.DeepEqual(outcome.Qualified, results[0].Qualified) {
+		t.Errorf("Logs and participants disagree on the outcome")
+	}
+
+	first := signed[0]
+	dropped := NewAuditLog(first.Ceremony, first.Recorder)
+	dropped.Ceremony.Broadcasts = dropped.Ceremony.Broadcasts[1:]
+	dropped.Signature = first.Signature
+	reordered := NewAuditLog(first.Ceremony, first.Recorder)
+	b := reordered.Ceremony.Broadcasts
+	b[0], b[1] = b[1], b[0]
+	reordered.Signature = first.Signature
+	forged := NewAuditLog(first.Ceremony, nodes[1].ID())
+	forged.Signature = first.Signature
+	other := ceremony
+	other.Session = "other"
+	foreign := NewAuditLog(other, nodes[1].ID())
+	foreign.Sign(nodes[1].identity)
+	for _, c := range []struct {
+		name string
+		logs []*AuditLog
+		err  error
+	}{
+		{"Dropped entry", []*AuditLog{dropped}, InvalidAuditLogError{0, "not signed by its recorder"}},
+		{"Reordered entries", []*AuditLog{reordered}, InvalidAuditLogError{0, "not signed by its recorder"}},
+		{"Another recorder", []*AuditLog{forged}, InvalidAuditLogError{0, "not signed by its recorder"}},
+		{"Another ceremony", []*AuditLog{first, foreign}, InvalidAuditLogError{1, "of another ceremony"}},
+		{"No logs", nil, InvalidAuditLogError{0, "no logs"}},
+	} {
+		if _, err := verifier.Verify(c.logs...); !reflect.DeepEqual(err, c.err) {
+			t.Errorf("%v: got unexpected error %v", c.name, err)
+		}
+	}
+}
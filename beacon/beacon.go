@@ -0,0 +1,103 @@
+// Package beacon runs a randomness beacon on a threshold key of package
+// dkg. Every period starts a round, in which the participants evaluate the
+// group's threshold VRF on the round number; threshold+1 of their partials
+// give the round's random value, which nobody can predict or bias without
+// threshold+1 participants, and which anyone can verify against the group
+// key.
+package beacon
+
+import "bytes"
+import "encoding/binary"
+import "io"
+import "time"
+
+import "github.com/mikalv/dkg"
+
+// Beacon is the schedule of a group's beacon: round 1 starts at Genesis,
+// and each following round one Period later.
+type Beacon struct {
+	Group   dkg.GroupKey
+	Genesis time.Time
+	Period  time.Duration
+}
+
+// Round returns the latest round started at t, 0 before genesis.
+func (b *Beacon) Round(t time.Time) uint64 {
+	if t.Before(b.Genesis) {
+		return 0
+	}
+	return uint64(t.Sub(b.Genesis)/b.Period) + 1
+}
+
+// RoundTime returns when round starts.
+func (b *Beacon) RoundTime(round uint64) time.Time {
+	if round == 0 {
+		return time.Time{}
+	}
+	return b.Genesis.Add(time.Duration(round-1) * b.Period)
+}
+
+// Partial is a participant's partial evaluation for a round.
+type Partial struct {
+	Round uint64
+	dkg.VRFPartial
+}
+
+// Randomness is the output of a round: Value, proven by Output.
+type Randomness struct {
+	Round  uint64
+	Value  []byte
+	Output *dkg.VRFOutput
+}
+
+func input(round uint64) []byte {
+	return binary.BigEndian.AppendUint64([]byte("dkg/beacon/"), round)
+}
+
+// Evaluate returns the participant's partial evaluation for round, which
+// must have started: evaluating ahead of time would let threshold+1
+// participants learn the value early.
+func (b *Beacon) Evaluate(key *dkg.KeyShare, round uint64, random io.Reader) (Partial, error) {
+	if round == 0 || round > b.Round(time.Now()) {
+		return Partial{}, RoundNotStartedError{round}
+	}
+	p, err := dkg.EvaluateVRF(key, input(round), random)
+	if err != nil {
+		return Partial{}, err
+	}
+	return Partial{round, p}, nil
+}
+
+// VerifyPartial checks a partial evaluation against the participant's
+// public share.
+func (b *Beacon) VerifyPartial(p Partial) error {
+	return dkg.VerifyVRFPartial(b.Group, input(p.Round), p.VRFPartial)
+}
+
+// Aggregate combines threshold+1 valid partials of round into its
+// randomness, ignoring the partials of other rounds, invalid ones and the
+// rest.
+func (b *Beacon) Aggregate(round uint64, partials []Partial) (*Randomness, error) {
+	var vrf []dkg.VRFPartial
+	for _, p := range partials {
+		if p.Round == round {
+			vrf = append(vrf, p.VRFPartial)
+		}
+	}
+	out, err := dkg.CombineVRF(b.Group, input(round), vrf)
+	if err != nil {
+		return nil, err
+	}
+	return &Randomness{round, out.Value(), out}, nil
+}
+
+// Verify checks that r is the randomness of its round.
+func (b *Beacon) Verify(r *Randomness) error {
+	if r.Output == nil || !bytes.Equal(r.Output.Input, input(r.Round)) || !bytes.Equal(r.Value, r.Output.Value()) {
+		return InvalidRandomnessError{r.Round}
+	}
+	if err := dkg.VerifyVRF(b.Group, r.Output); err != nil {
+		return InvalidRandomnessError{r.Round}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package beacon
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mikalv/dkg"
+)
+
+func TestBeacon(t *testing.T) {
+	sim, err := dkg.NewSimulator(elliptic.P256(), 3, 1, 2*time.Second, []byte("beacon test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := sim.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		if key == nil {
+			t.Fatal("Ceremony did not finish")
+		}
+	}
+	b := &Beacon{keys[0].Group(), time.Now().Add(-time.Minute), 30 * time.Second}
+	if round := b.Round(time.Now()); round != 3 {
+		t.Errorf("Got round %v, expected 3", round)
+	}
+	if !b.RoundTime(3).Equal(b.Genesis.Add(time.Minute)) {
+		t.Errorf("Round 3 starts at %v", b.RoundTime(3))
+	}
+	if _, err := b.Evaluate(keys[0], 4, rand.Reader); !reflect.DeepEqual(err, RoundNotStartedError{4}) {
+		t.Errorf("Got unexpected error for a future round: %v", err)
+	}
+
+	var partials []Partial
+	for _, round := range []uint64{1, 2} {
+		for _, key := range keys {
+			p, err := b.Evaluate(key, round, rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := b.VerifyPartial(p); err != nil {
+				t.Errorf("Partial doesn't verify: %v", err)
+			}
+			partials = append(partials, p)
+		}
+	}
+	first, err := b.Aggregate(1, partials)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := b.Aggregate(2, partials)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(first.Value, second.Value) {
+		t.Errorf("Rounds have the same value")
+	}
+	if err := b.Verify(second); err != nil {
+		t.Errorf("Randomness doesn't verify: %v", err)
+	}
+	replayed := *first
+	replayed.Round = 2
+	if err := b.Verify(&replayed); !reflect.DeepEqual(err, InvalidRandomnessError{2}) {
+		t.Errorf("Got unexpected error for a replayed round: %v", err)
+	}
+}
@@ -0,0 +1,19 @@
+package beacon
+
+import "fmt"
+
+type RoundNotStartedError struct {
+	round uint64
+}
+
+func (e RoundNotStartedError) Error() string {
+	return fmt.Sprintf("beacon: round %v has not started", e.round)
+}
+
+type InvalidRandomnessError struct {
+	round uint64
+}
+
+func (e InvalidRandomnessError) Error() string {
+	return fmt.Sprintf("beacon: invalid randomness for round %v", e.round)
+}
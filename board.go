@@ -0,0 +1,140 @@
+package dkg
+
+import "math/big"
+import "sync"
+import "time"
+
+// Board is a public, append-only posting board, such as a smart contract,
+// on which a ceremony anchors its commitments and complaints: every reader
+// sees the same posts in the same order, and none is ever withdrawn. A
+// Board must authenticate posters, rejecting messages whose From isn't
+// the poster, as a contract does with the sending account.
+type Board interface {
+	// PublishCommitments posts a VerificationPointsMessage.
+	PublishCommitments(m Message) error
+	// PublishComplaint posts a ComplaintsMessage.
+	PublishComplaint(m Message) error
+	// ReadPhase returns the messages posted for phase, in posting order.
+	ReadPhase(phase Phase) ([]Message, error)
+}
+
+// MemoryBoard is a Board in process memory, for simulations and tests. It
+// trusts the From of posted messages.
+type MemoryBoard struct {
+	mu    sync.Mutex
+	posts map[Phase][]Message
+}
+
+func NewMemoryBoard() *MemoryBoard {
+	return &MemoryBoard{posts: make(map[Phase][]Message)}
+}
+
+func (b *MemoryBoard) PublishCommitments(m Message) error {
+	return b.publish(PhaseDealing, VerificationPointsMessage, m)
+}
+
+func (b *MemoryBoard) PublishComplaint(m Message) error {
+	return b.publish(PhaseComplaining, ComplaintsMessage, m)
+}
+
+func (b *MemoryBoard) publish(phase Phase, t MessageType, m Message) error {
+	if m.Type != t {
+		return UnexpectedMessageError{phase, m.Type}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	m.To = nil
+	b.posts[phase] = append(b.posts[phase], m)
+	return nil
+}
+
+func (b *MemoryBoard) ReadPhase(phase Phase) ([]Message, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Message(nil), b.posts[phase]...), nil
+}
+
+// boardPhases are the phases whose broadcasts go through a board.
+var boardPhases = []Phase{PhaseDealing, PhaseComplaining}
+
+// BoardTransport publishes the broadcasts of commitments and complaints
+// on a board, and delivers those of the other participants as it reads
+// them from the board every interval. Other messages go through the
+// underlying transport, which drops the board's types since they bypass
+// the board.
+type BoardTransport struct {
+	id        *big.Int
+	board     Board
+	transport Transport
+	inbox     *mailbox
+	stop      chan struct{}
+	once      sync.Once
+}
+
+func NewBoardTransport(id *big.Int, board Board, transport Transport, interval time.Duration) *BoardTransport {
+	t := &BoardTransport{id, board, transport, newMailbox(), make(chan struct{}), sync.Once{}}
+	go t.run()
+	go t.poll(interval)
+	return t
+}
+
+func (t *BoardTransport) run() {
+	defer t.inbox.close()
+	for m := range t.transport.Receive() {
+		if m.Type != VerificationPointsMessage && m.Type != ComplaintsMessage {
+			t.inbox.put(m)
+		}
+	}
+}
+
+func (t *BoardTransport) poll(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	read := make(map[Phase]int)
+	for {
+		// a failed read is retried at the next tick
+		for _, phase := range boardPhases {
+			posts, err := t.board.ReadPhase(phase)
+			if err != nil {
+				continue
+			}
+			for _, m := range posts[read[phase]:] {
+				// the sender doesn't receive its own broadcasts
+				if m.From != nil && m.From.Cmp(t.id) != 0 {
+					t.inbox.put(m)
+				}
+			}
+			read[phase] = len(posts)
+		}
+		select {
+		case <-ticker.C:
+		case <-t.stop:
+			return
+		}
+	}
+}
+
+func (t *BoardTransport) Send(to *big.Int, m Message) error {
+	return t.transport.Send(to, m)
+}
+
+func (t *BoardTransport) Broadcast(m Message) error {
+	switch m.Type {
+	case VerificationPointsMessage:
+		return t.board.PublishCommitments(m)
+	case ComplaintsMessage:
+		return t.board.PublishComplaint(m)
+	}
+	return t.transport.Broadcast(m)
+}
+
+func (t *BoardTransport) Receive() <-chan Message {
+	return t.inbox.out
+}
+
+func (t *BoardTransport) Close() error {
+	t.once.Do(func() { close(t.stop) })
+	err := t.transport.Close()
+	t.inbox.close()
+	return err
+}
@@ -0,0 +1,41 @@
+package dkg
+
+import (
+	"crypto/elliptic"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBoardTransport(t *testing.T) {
+	board := NewMemoryBoard()
+	sim, err := NewSimulator(elliptic.P256(), 4, 1, 5*time.Second, []byte("dkg board test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := sim.Run(func(node *Node, transport Transport) Transport {
+		return NewBoardTransport(node.ID(), board, transport, 5*time.Millisecond)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, result := range results {
+		if result == nil {
+			t.Fatalf("Node %v did not finish", sim.Nodes[i].ID())
+		}
+	}
+	checkCeremonyResultsForTesting(t, results)
+
+	commitments, _ := board.ReadPhase(PhaseDealing)
+	if len(commitments) != len(sim.Nodes) {
+		t.Errorf("Board has %v commitments, expected %v", len(commitments), len(sim.Nodes))
+	}
+	for _, m := range commitments {
+		if m.Type != VerificationPointsMessage {
+			t.Errorf("Board has a %v among commitments", m.Type)
+		}
+	}
+	if err := board.PublishComplaint(commitments[0]); !reflect.DeepEqual(err, UnexpectedMessageError{PhaseComplaining, VerificationPointsMessage}) {
+		t.Errorf("Got unexpected error for commitments posted as a complaint: %v", err)
+	}
+}
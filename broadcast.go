@@ -0,0 +1,220 @@
+package dkg
+
+import "crypto/sha256"
+import "encoding/gob"
+import "math/big"
+import "sync"
+
+func init() {
+	gob.RegisterName("dkg.broadcastPayload", broadcastPayload{})
+}
+
+// Broadcast is a broadcast channel. Plain transports broadcast by sending
+// to every participant, which lets a faulty sender send different
+// messages to different participants; EchoBroadcastTransport rules that
+// out.
+type Broadcast interface {
+	Broadcast(m Message) error
+	Receive() <-chan Message
+}
+
+// unicastTypes are the message types the protocol sends point to point.
+var unicastTypes = map[MessageType]bool{
+	SecretSharesMessage: true,
+	HelloMessage:        true,
+	RetryMessage:        true,
+}
+
+type broadcastStep int
+
+const (
+	broadcastSend broadcastStep = iota
+	broadcastEcho
+	broadcastReady
+)
+
+var broadcastStepNames = []string{"send", "echo", "ready"}
+
+// broadcastPayload carries a step of the echo broadcast of Payload by
+// Origin, in a message of the broadcast's type.
+type broadcastPayload struct {
+	Step    broadcastStep
+	Origin  *big.Int
+	Payload Hashable
+}
+
+func (b broadcastPayload) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/broadcast")
+	w.WriteUint(uint64(b.Step))
+	w.WriteInt(b.Origin)
+	if b.Payload == nil {
+		w.WriteTag("")
+		return
+	}
+	w.Write(b.Payload)
+}
+
+// echoInstance is the state of one broadcast, identified by its origin and
+// message type.
+type echoInstance struct {
+	echoed, readied bool
+	delivered       bool
+	messages        map[string]Message
+	echoes, readies map[string]int
+	voted           map[broadcastStep]map[string]bool
+}
+
+// EchoBroadcastTransport broadcasts with Bracha's reliable broadcast over
+// point-to-point messages: participants echo the message they received from
+// its sender, declare themselves ready to deliver it once enough of them
+// echoed it, and deliver it once 2f+1 of them are ready, tolerating f < n/3
+// faulty participants. Honest participants thus deliver the same message
+// per sender and message type, or none; each sender must broadcast at most
+// one message of each type. The underlying transport must authenticate
+// senders, like EnvelopeTransport. Broadcasts that bypass the echo are
+// dropped, and so are unicasts of the types the protocol broadcasts.
+type EchoBroadcastTransport struct {
+	transport    Transport
+	id           *big.Int
+	participants []*big.Int
+	faulty       int
+	inbox        *mailbox
+
+	mu        sync.Mutex
+	instances map[string]*echoInstance
+}
+
+func NewEchoBroadcastTransport(id *big.Int, participants []*big.Int, transport Transport) *EchoBroadcastTransport {
+	t := &EchoBroadcastTransport{
+		transport:    transport,
+		id:           id,
+		participants: append([]*big.Int(nil), participants...),
+		faulty:       (len(participants) - 1) / 3,
+		inbox:        newMailbox(),
+		instances:    make(map[string]*echoInstance),
+	}
+	go t.run()
+	return t
+}
+
+func (t *EchoBroadcastTransport) run() {
+	defer t.inbox.close()
+	for m := range t.transport.Receive() {
+		b, ok := m.Payload.(broadcastPayload)
+		if !ok {
+			if m.To != nil && unicastTypes[m.Type] {
+				t.inbox.put(m)
+			}
+			continue
+		}
+		t.step(m, b)
+	}
+}
+
+func (t *EchoBroadcastTransport) member(id *big.Int) bool {
+	for _, p := range t.participants {
+		if p.Cmp(id) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// step handles a step of a broadcast, received from m.From or taken by
+// this participant itself.
+func (t *EchoBroadcastTransport) step(m Message, b broadcastPayload) {
+	if m.From == nil || b.Origin == nil || !t.member(m.From) || !t.member(b.Origin) {
+		return
+	}
+	if b.Step == broadcastSend && m.From.Cmp(b.Origin) != 0 {
+		return
+	}
+	broadcast := Message{m.Type, b.Origin, nil, b.Payload}
+	digest := string(HashOf(sha256.New(), broadcast))
+
+	t.mu.Lock()
+	key := m.Type.String() + "/" + b.Origin.String()
+	instance, ok := t.instances[key]
+	if !ok {
+		instance = &echoInstance{
+			messages: make(map[string]Message),
+			echoes:   make(map[string]int),
+			readies:  make(map[string]int),
+			voted:    make(map[broadcastStep]map[string]bool),
+		}
+		t.instances[key] = instance
+	}
+	// every participant has one say per step of a broadcast
+	if instance.voted[b.Step] == nil {
+		instance.voted[b.Step] = make(map[string]bool)
+	}
+	if instance.voted[b.Step][m.From.String()] {
+		t.mu.Unlock()
+		return
+	}
+	instance.voted[b.Step][m.From.String()] = true
+	instance.messages[digest] = broadcast
+
+	var next []broadcastStep
+	switch b.Step {
+	case broadcastSend:
+		if !instance.echoed {
+			instance.echoed = true
+			next = append(next, broadcastEcho)
+		}
+	case broadcastEcho:
+		instance.echoes[digest]++
+	case broadcastReady:
+		instance.readies[digest]++
+	}
+	n := len(t.participants)
+	if !instance.readied && (instance.echoes[digest] >= (n+t.faulty+2)/2 || instance.readies[digest] > t.faulty) {
+		instance.readied = true
+		next = append(next, broadcastReady)
+	}
+	deliver := !instance.delivered && instance.readies[digest] > 2*t.faulty
+	if deliver {
+		instance.delivered = true
+	}
+	t.mu.Unlock()
+
+	for _, s := range next {
+		t.relay(Message{m.Type, t.id, nil, broadcastPayload{s, b.Origin, b.Payload}})
+	}
+	// the sender doesn't receive its own broadcasts
+	if deliver && b.Origin.Cmp(t.id) != 0 {
+		t.inbox.put(broadcast)
+	}
+}
+
+// relay sends a step to the other participants and takes it itself.
+// Transport errors surface as missing steps on the receiving side.
+func (t *EchoBroadcastTransport) relay(m Message) {
+	for _, p := range t.participants {
+		if p.Cmp(t.id) != 0 {
+			m.To = p
+			t.transport.Send(p, m)
+		}
+	}
+	m.To = nil
+	t.step(m, m.Payload.(broadcastPayload))
+}
+
+func (t *EchoBroadcastTransport) Send(to *big.Int, m Message) error {
+	return t.transport.Send(to, m)
+}
+
+func (t *EchoBroadcastTransport) Broadcast(m Message) error {
+	t.relay(Message{m.Type, t.id, nil, broadcastPayload{broadcastSend, t.id, m.Payload}})
+	return nil
+}
+
+func (t *EchoBroadcastTransport) Receive() <-chan Message {
+	return t.inbox.out
+}
+
+func (t *EchoBroadcastTransport) Close() error {
+	err := t.transport.Close()
+	t.inbox.close()
+	return err
+}
@@ -0,0 +1,85 @@
+package dkg
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEchoBroadcastTransport(t *testing.T) {
+	t.Run("Ceremony", func(t *testing.T) {
+		nodes, participants := getCeremonyNodesForTesting(t, 4, 1)
+		ids := make([]*big.Int, len(nodes))
+		for i, node := range nodes {
+			ids[i] = node.ID()
+		}
+		results := runCeremonyForTesting(t, nodes, participants, func(node *Node, transport Transport) Transport {
+			return NewEchoBroadcastTransport(node.ID(), ids, transport)
+		})
+		for i, result := range results {
+			if result == nil || len(result.Qualified) != len(nodes) {
+				t.Errorf("Node %v did not finish with all dealers qualified", nodes[i].ID())
+			}
+		}
+		checkCeremonyResultsForTesting(t, results)
+	})
+
+	ids := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4)}
+	network := NewMemoryNetwork()
+	// the first participant equivocates
+	attacker := network.Transport(ids[0])
+	defer attacker.Close()
+	honest := make([]*EchoBroadcastTransport, 3)
+	for i := range honest {
+		honest[i] = NewEchoBroadcastTransport(ids[i+1], ids, network.Transport(ids[i+1]))
+		defer honest[i].Close()
+	}
+	receive := func(t *EchoBroadcastTransport) (Message, bool) {
+		select {
+		case m := <-t.Receive():
+			return m, true
+		case <-time.After(200 * time.Millisecond):
+			return Message{}, false
+		}
+	}
+
+	t.Run("Equivocation", func(t *testing.T) {
+		a := Complaints{Accused: []*big.Int{ids[1]}}
+		b := Complaints{Accused: []*big.Int{ids[2]}}
+		send := func(to *big.Int, step broadcastStep, c Complaints) {
+			attacker.Send(to, Message{ComplaintsMessage, ids[0], to, broadcastPayload{step, ids[0], c}})
+		}
+		send(ids[1], broadcastSend, a)
+		send(ids[2], broadcastSend, a)
+		send(ids[3], broadcastSend, b)
+		for _, to := range ids[1:] {
+			send(to, broadcastEcho, a)
+			send(to, broadcastEcho, b)
+		}
+		for i, h := range honest {
+			m, ok := receive(h)
+			if !ok {
+				t.Fatalf("Participant %v delivered nothing", ids[i+1])
+			}
+			if !reflect.DeepEqual(m, Message{ComplaintsMessage, ids[0], nil, a}) {
+				t.Errorf("Participant %v delivered %+v", ids[i+1], m)
+			}
+		}
+	})
+
+	t.Run("Plain messages", func(t *testing.T) {
+		attacker.Broadcast(Message{ComplaintsMessage, ids[0], nil, Complaints{}})
+		if m, ok := receive(honest[0]); ok {
+			t.Errorf("Delivered a plain broadcast: %+v", m)
+		}
+		attacker.Send(ids[1], Message{ComplaintsMessage, ids[0], ids[1], Complaints{}})
+		if m, ok := receive(honest[0]); ok {
+			t.Errorf("Delivered a unicast complaint: %+v", m)
+		}
+		attacker.Send(ids[1], Message{SecretSharesMessage, ids[0], ids[1], EncryptedShares{}})
+		if m, ok := receive(honest[0]); !ok || m.Type != SecretSharesMessage {
+			t.Errorf("Unicast didn't pass through: %+v", m)
+		}
+	})
+}
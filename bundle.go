@@ -0,0 +1,226 @@
+package dkg
+
+import "crypto/elliptic"
+import "crypto/sha256"
+import "hash"
+import "math/big"
+import "sort"
+import "time"
+
+// CeremonyBundle is the public record of a ceremony, for relying parties
+// that took no part in it: its parameters, participants and broadcasts, as
+// collected by a participant or an Observer. A nil second generator is
+// derived like NewNodeWithOptions does. With a Session, the broadcasts must be sealed
+// by their senders' EnvelopeTransport for that session, which binds them to
+// the participants' identity keys; otherwise the bundle is only as
+// trustworthy as its source. Anchors holds the receipts of the bundle's
+// TranscriptDigest from TranscriptAnchors, added by Anchor.
+type CeremonyBundle struct {
+	Curve        elliptic.Curve
+	G2X, G2Y     *big.Int
+	Feldman      bool
+	ZKParam      *big.Int
+	Threshold    int
+	Participants []Participant
+	Session      string
+	Broadcasts   []Message
+	Anchors      []AnchorReceipt
+}
+
+// Broadcasts returns the broadcasts the observer counted, in participant and
+// message type order, for a CeremonyBundle.
+func (o *Observer) Broadcasts() []Message {
+	var broadcasts []Message
+	for _, p := range o.participants {
+		types := make([]int, 0, len(p.received))
+		for t := range p.received {
+			types = append(types, int(t))
+		}
+		sort.Ints(types)
+		for _, t := range types {
+			broadcasts = append(broadcasts, p.received[MessageType(t)])
+		}
+	}
+	return broadcasts
+}
+
+// VerifyCeremonyBundle replays the bundle's broadcasts like an Observer and
+// returns the outcome, unsigned: the group key and the qualified dealers.
+// It fails unless every participant complained, if only with no complaints,
+// and every qualified dealer revealed its public coefficients. h must be
+// the participants' hash. Like an Observer, it can't check the encrypted
+// shares, which only their recipients can.
+func VerifyCeremonyBundle(h hash.Hash, bundle CeremonyBundle) (Attestation, error) {
+	set, err := NewParticipantSet(bundle.Curve, bundle.Threshold, bundle.Participants)
+	if err != nil {
+		return Attestation{}, err
+	}
+	var opts []NodeOption
+	if bundle.Feldman {
+		opts = append(opts, WithFeldmanVSS())
+	}
+	o, err := NewObserver(bundle.Curve, h, bundle.G2X, bundle.G2Y, bundle.ZKParam, set, opts...)
+	if err != nil {
+		return Attestation{}, err
+	}
+
+	spec := ProtocolSpec()
+	for i, m := range bundle.Broadcasts {
+		if bundle.Session != "" {
+			sender, ok := set.Participant(m.From)
+			if !ok {
+				return Attestation{}, InvalidCeremonyBundleError{i, "unknown sender"}
+			}
+			if m, _, ok = openEnvelope(spec, bundle.Session, sender, m); !ok {
+				return Attestation{}, InvalidCeremonyBundleError{i, "not sealed by its sender"}
+			}
+		}
+		o.Observe(m)
+	}
+	if !o.complete() {
+		return Attestation{}, InvalidCeremonyBundleError{len(bundle.Broadcasts), "incomplete"}
+	}
+	return o.outcome()
+}
+
+// TranscriptDigest returns the SHA-256 digest of the bundle's encoding,
+// without its anchors, which anchors commit to.
+func (b CeremonyBundle) TranscriptDigest() []byte {
+	w := NewTranscriptWriter(sha256.New())
+	b.writeBundle(w)
+	return w.Sum()
+}
+
+// Anchor anchors the bundle's transcript with anchor and adds the receipt
+// to the bundle's anchors.
+func (b *CeremonyBundle) Anchor(anchor TranscriptAnchor) error {
+	receipt, err := anchor.Anchor(b.TranscriptDigest())
+	if err != nil {
+		return err
+	}
+	b.Anchors = append(b.Anchors, receipt)
+	return nil
+}
+
+// VerifyAnchor checks the bundle's receipts of anchor's kind and returns
+// the earliest time they attest to. It fails if one doesn't verify, or if
+// there are none.
+func (b CeremonyBundle) VerifyAnchor(anchor TranscriptAnchor) (time.Time, error) {
+	digest := b.TranscriptDigest()
+	kind := anchor.Kind()
+	var earliest time.Time
+	for _, receipt := range b.Anchors {
+		if receipt.Kind != kind {
+			continue
+		}
+		t, err := anchor.Verify(digest, receipt)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if earliest.IsZero() || t.Before(earliest) {
+			earliest = t
+		}
+	}
+	if earliest.IsZero() {
+		return time.Time{}, InvalidTimestampError{"no " + kind + " receipt"}
+	}
+	return earliest, nil
+}
+
+func (b CeremonyBundle) writeBundle(w *TranscriptWriter) {
+	w.WriteTag("dkg/ceremony-bundle")
+	w.WriteTag(b.Curve.Params().Name)
+	if b.G2X == nil || b.G2Y == nil {
+		w.WriteUint(0)
+	} else {
+		w.WriteUint(1)
+		w.WriteInt(b.G2X)
+		w.WriteInt(b.G2Y)
+	}
+	if b.Feldman {
+		w.WriteUint(1)
+	} else {
+		w.WriteUint(0)
+	}
+	w.WriteInt(b.ZKParam)
+	w.WriteUint(uint64(b.Threshold))
+	w.WriteUint(uint64(len(b.Participants)))
+	for _, p := range b.Participants {
+		w.WriteInt(p.ID)
+		w.WriteInt(p.Key.X)
+		w.WriteInt(p.Key.Y)
+	}
+	w.WriteBytes([]byte(b.Session))
+	w.WriteUint(uint64(len(b.Broadcasts)))
+	for _, m := range b.Broadcasts {
+		w.Write(m)
+	}
+}
+
+func (b CeremonyBundle) MarshalBinary() ([]byte, error) {
+	if b.Curve == nil || b.ZKParam == nil {
+		return nil, InvalidEncodingError{"bundle without parameters"}
+	}
+	return encodeBinary(func(w *TranscriptWriter) {
+		b.writeBundle(w)
+		// left out without anchors, which older bundles lack
+		if len(b.Anchors) > 0 {
+			w.WriteTag("dkg/anchors")
+			w.WriteUint(uint64(len(b.Anchors)))
+			for _, a := range b.Anchors {
+				w.WriteBytes([]byte(a.Kind))
+				w.WriteBytes(a.Receipt)
+			}
+		}
+	}), nil
+}
+
+// UnmarshalBinary decodes a bundle; the points are checked by
+// VerifyCeremonyBundle.
+func (b *CeremonyBundle) UnmarshalBinary(data []byte) error {
+	var out CeremonyBundle
+	err := unmarshalBinary(data, func(r *transcriptReader) {
+		r.expectTag("dkg/ceremony-bundle")
+		out.Curve = r.readCurve()
+		switch r.readUint() {
+		case 0:
+		case 1:
+			out.G2X, out.G2Y = r.readInt(), r.readInt()
+		default:
+			r.fail("invalid second generator")
+		}
+		switch r.readUint() {
+		case 0:
+		case 1:
+			out.Feldman = true
+		default:
+			r.fail("invalid mode")
+		}
+		out.ZKParam = r.readInt()
+		out.Threshold = int(r.readUint())
+		out.Participants = make([]Participant, r.readCount())
+		for i := range out.Participants {
+			out.Participants[i].ID = r.readInt()
+			out.Participants[i].Key.Curve = out.Curve
+			out.Participants[i].Key.X, out.Participants[i].Key.Y = r.readInt(), r.readInt()
+		}
+		out.Session = string(r.readBytes())
+		out.Broadcasts = make([]Message, r.readCount())
+		for i := range out.Broadcasts {
+			r.expectTag("dkg/message")
+			out.Broadcasts[i] = r.readMessage()
+		}
+		if len(r.b) > 0 {
+			r.expectTag("dkg/anchors")
+			out.Anchors = make([]AnchorReceipt, r.readCount())
+			for i := range out.Anchors {
+				out.Anchors[i] = AnchorReceipt{string(r.readBytes()), r.readBytes()}
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	*b = out
+	return nil
+}
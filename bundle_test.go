@@ -0,0 +1,107 @@
+package dkg
+
+import (
+	"context"
+	"crypto/sha512"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestVerifyCeremonyBundle(t *testing.T) {
+	curve, _, g2x, g2y, zkParam, _, _, _, _, _ := getValidNodeParamsForTesting(t)
+
+	t.Run("Observed", func(t *testing.T) {
+		nodes, participants := getCeremonyNodesForTesting(t, 4, 1)
+		set, _ := NewParticipantSet(curve, 1, participants)
+		observer, _ := NewObserver(curve, sha512.New512_256(), g2x, g2y, zkParam, set)
+		observed := NewMemoryNetwork()
+		watching := observed.Transport(big.NewInt(100))
+		defer watching.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		watched := make(chan error)
+		go func() { watched <- observer.Watch(ctx, watching) }()
+		results := runCeremonyForTesting(t, nodes, participants, func(n *Node, t Transport) Transport {
+			return copyingTransport{t, observed.Transport(n.ID())}
+		})
+		if err := <-watched; err != nil {
+			t.Fatal(err)
+		}
+
+		bundle := CeremonyBundle{curve, g2x, g2y, false, zkParam, 1, participants, "", observer.Broadcasts(), nil}
+		data, err := bundle.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded CeremonyBundle
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatalf("Could not decode bundle: %v", err)
+		}
+		outcome, err := VerifyCeremonyBundle(sha512.New512_256(), decoded)
+		if err != nil {
+			t.Fatalf("Could not verify bundle: %v", err)
+		}
+		if outcome.PublicKey.X.Cmp(results[0].PublicKey.X) != 0 || !reflect.DeepEqual(outcome.Qualified, results[0].Qualified) {
+			t.Errorf("Bundle and participants disagree on the outcome")
+		}
+
+		var withheld []Message
+		for _, m := range decoded.Broadcasts {
+			if m.Type != ComplaintsMessage || m.From.Cmp(nodes[0].ID()) != 0 {
+				withheld = append(withheld, m)
+			}
+		}
+		decoded.Broadcasts = withheld
+		if _, err := VerifyCeremonyBundle(sha512.New512_256(), decoded); reflect.TypeOf(err) != reflect.TypeOf(InvalidCeremonyBundleError{}) {
+			t.Errorf("Got unexpected error for an incomplete bundle: %v", err)
+		}
+	})
+
+	t.Run("Sealed", func(t *testing.T) {
+		nodes, participants := getCeremonyNodesForTesting(t, 4, 1)
+		set, _ := NewParticipantSet(curve, 1, participants)
+		var recorders []*recordingTransport
+		results := runCeremonyForTesting(t, nodes, participants,
+			func(n *Node, t Transport) Transport {
+				recorder := &recordingTransport{Transport: t}
+				recorders = append(recorders, recorder)
+				return recorder
+			},
+			func(n *Node, t Transport) Transport {
+				return NewEnvelopeTransport(n, set, "ceremony", t)
+			})
+		checkCeremonyResultsForTesting(t, results)
+		var broadcasts []Message
+		for _, recorder := range recorders {
+			for _, m := range recorder.sent {
+				if m.To == nil {
+					broadcasts = append(broadcasts, m)
+				}
+			}
+		}
+
+		bundle := CeremonyBundle{curve, g2x, g2y, false, zkParam, 1, participants, "ceremony", broadcasts, nil}
+		outcome, err := VerifyCeremonyBundle(sha512.New512_256(), bundle)
+		if err != nil {
+			t.Fatalf("Could not verify bundle: %v", err)
+		}
+		if outcome.PublicKey.X.Cmp(results[0].PublicKey.X) != 0 || outcome.PublicKey.Y.Cmp(results[0].PublicKey.Y) != 0 {
+			t.Errorf("Bundle and participants disagree on the group key")
+		}
+
+		bundle.Session = "other"
+		if _, err := VerifyCeremonyBundle(sha512.New512_256(), bundle); !reflect.DeepEqual(err, InvalidCeremonyBundleError{0, "not sealed by its sender"}) {
+			t.Errorf("Got unexpected error for another session: %v", err)
+		}
+		bundle.Session = "ceremony"
+		bundle.Broadcasts = append([]Message(nil), broadcasts...)
+		bundle.Broadcasts[0].From = bundle.Broadcasts[1].From
+		if bundle.Broadcasts[0].From.Cmp(broadcasts[0].From) != 0 {
+			if _, err := VerifyCeremonyBundle(sha512.New512_256(), bundle); !reflect.DeepEqual(err, InvalidCeremonyBundleError{0, "not sealed by its sender"}) {
+				t.Errorf("Got unexpected error for a forged sender: %v", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package dkg
+
+import "encoding/binary"
+import "io"
+import "math/big"
+import "time"
+
+// TimeoutPolicy derives the timeout of each phase from the round trip
+// times measured in a handshake: the slowest participant's round trip
+// time, times the phase's multiplier, but no less than Floor. Phases
+// without a multiplier keep the node's timeout.
+type TimeoutPolicy struct {
+	Multipliers map[Phase]float64
+	Floor       time.Duration
+}
+
+// DefaultTimeoutPolicy leaves dealing, in which every participant encrypts
+// shares for and verifies proofs of all the others, the most time.
+func DefaultTimeoutPolicy() TimeoutPolicy {
+	return TimeoutPolicy{
+		Multipliers: map[Phase]float64{
+			PhaseDealing:        8,
+			PhaseComplaining:    4,
+			PhaseJustifying:     4,
+			PhaseExtracting:     4,
+			PhaseContesting:     4,
+			PhaseReconstructing: 4,
+		},
+		Floor: time.Second,
+	}
+}
+
+// CalibrateTimeouts has the runner exchange hellos with the other
+// participants for up to handshake before the ceremony starts, and time the
+// phases per policy instead of with the node's timeout. Participants that
+// don't answer in time count as taking the whole handshake. It must be
+// called before Run; a resumed runner keeps the timeouts of its state.
+func (r *ProtocolRunner) CalibrateTimeouts(policy TimeoutPolicy, handshake time.Duration) {
+	r.policy = &policy
+	r.handshake = handshake
+}
+
+// calibrate runs the handshake, if the timeouts are to be calibrated.
+// Runners answer hellos whether they calibrate or not.
+func (r *ProtocolRunner) calibrate() error {
+	if r.policy == nil || !r.start.IsZero() {
+		return nil
+	}
+	var nonce [8]byte
+	if _, err := io.ReadFull(r.random, nonce[:]); err != nil {
+		return err
+	}
+	r.nonce = binary.BigEndian.Uint64(nonce[:])
+	r.pinged = time.Now()
+	r.send(nil, HelloMessage, Hello{r.nonce, false})
+	r.await(r.pinged.Add(r.handshake), r.participants, HelloMessage)
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+
+	var slowest time.Duration
+	for _, p := range r.participants {
+		switch {
+		case p == r.self:
+		case p.received[HelloMessage]:
+			slowest = max(slowest, p.rtt)
+		default:
+			slowest = max(slowest, r.handshake)
+		}
+	}
+	timeouts := make([]time.Duration, PhaseFinished)
+	for phase := range timeouts {
+		timeouts[phase] = r.node.timeout
+		if m, ok := r.policy.Multipliers[Phase(phase)]; ok && slowest > 0 {
+			timeouts[phase] = max(r.policy.Floor, time.Duration(m*float64(slowest)))
+		}
+	}
+	r.mu.Lock()
+	r.timeouts = timeouts
+	r.mu.Unlock()
+	return nil
+}
+
+// hello answers p's hellos, and records p's round trip time from its echo
+// of this runner's hello.
+func (r *ProtocolRunner) hello(p *participant, m Message) {
+	h, ok := m.Payload.(Hello)
+	switch {
+	case !ok:
+	case !h.Echo:
+		r.send(p.id, HelloMessage, Hello{h.Nonce, true})
+	case h.Nonce == r.nonce && !r.pinged.IsZero() && r.timeouts == nil && !p.received[HelloMessage]:
+		p.received[HelloMessage] = true
+		r.mu.Lock()
+		p.rtt = time.Since(r.pinged)
+		r.mu.Unlock()
+	}
+}
+
+// deadline is the end of phase: the start of the ceremony plus the
+// timeouts of the phases up to it, one per attempt. Deadlines are fixed relative to the
+// start: a node that completes a phase early gives a node that waited out a
+// full timeout the same time to catch up in the next phase.
+func (r *ProtocolRunner) deadline(phase Phase) time.Time {
+	d := r.start
+	for p := PhaseDealing; p <= phase; p++ {
+		d = d.Add(time.Duration(r.retries+1) * r.timeout(p))
+	}
+	return d
+}
+
+func (r *ProtocolRunner) timeout(phase Phase) time.Duration {
+	if int(phase) >= len(r.timeouts) {
+		return r.node.timeout
+	}
+	return r.timeouts[phase]
+}
+
+// Timeout returns how long the runner waits for the messages of phase.
+func (r *ProtocolRunner) Timeout(phase Phase) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.timeout(phase)
+}
+
+// RTT returns the round trip time to participant id measured in the
+// handshake, if it answered.
+func (r *ProtocolRunner) RTT(id *big.Int) (time.Duration, bool) {
+	p, ok := r.byID[r.key(id)]
+	if !ok {
+		return 0, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return p.rtt, p.rtt > 0
+}
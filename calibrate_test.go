@@ -0,0 +1,92 @@
+package dkg
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+)
+
+func runCalibratedCeremonyForTesting(t *testing.T, nodes []*Node, participants []Participant, policy TimeoutPolicy, handshake time.Duration, wrap ...func(*Node, Transport) Transport) []*ProtocolRunner {
+	network := NewMemoryNetwork()
+	runners := make([]*ProtocolRunner, len(nodes))
+	for i, node := range nodes {
+		transport := network.Transport(node.ID())
+		defer transport.Close()
+		for _, w := range wrap {
+			transport = w(node, transport)
+		}
+		set, _ := NewParticipantSet(node.curve, node.Threshold(), participants)
+		runner, err := NewProtocolRunner(node, set, transport)
+		if err != nil {
+			t.Fatal(err)
+		}
+		runner.CalibrateTimeouts(policy, handshake)
+		runners[i] = runner
+	}
+	var wg sync.WaitGroup
+	for _, runner := range runners {
+		wg.Add(1)
+		go func(r *ProtocolRunner) {
+			defer wg.Done()
+			r.Run()
+		}(runner)
+	}
+	wg.Wait()
+	return runners
+}
+
+func TestCalibrateTimeouts(t *testing.T) {
+	policy := TimeoutPolicy{map[Phase]float64{PhaseDealing: 8, PhaseComplaining: 4}, 150 * time.Millisecond}
+
+	t.Run("Timeouts from round trip times", func(t *testing.T) {
+		nodes, participants := getCeremonyNodesForTesting(t, 4, 2)
+		runners := runCalibratedCeremonyForTesting(t, nodes, participants, policy, time.Second)
+		results := make([]*KeyShare, len(runners))
+		for i, r := range runners {
+			results[i], _ = r.Result()
+			for _, p := range participants {
+				if _, ok := r.RTT(p.ID); !ok && p.ID.Cmp(nodes[i].ID()) != 0 {
+					t.Errorf("Node %v has no round trip time to %v", nodes[i].ID(), p.ID)
+				}
+			}
+			// in-memory round trips are well below the floor
+			if d := r.Timeout(PhaseDealing); d != policy.Floor {
+				t.Errorf("Node %v times dealing at %v", nodes[i].ID(), d)
+			}
+			if d := r.Timeout(PhaseExtracting); d != nodes[i].timeout {
+				t.Errorf("Node %v times extracting at %v, not the node's timeout", nodes[i].ID(), d)
+			}
+		}
+		checkCeremonyResultsForTesting(t, results)
+
+		var state RunnerState
+		data, _ := runners[0].State().MarshalBinary()
+		if err := state.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if len(state.Timeouts) == 0 || state.Timeouts[PhaseDealing] != policy.Floor {
+			t.Errorf("State lost the calibrated timeouts: %v", state.Timeouts)
+		}
+	})
+
+	t.Run("Silent participant counts as the whole handshake", func(t *testing.T) {
+		nodes, participants := getCeremonyNodesForTesting(t, 4, 2)
+		silent := nodes[3].ID()
+		handshake := 50 * time.Millisecond
+		runners := runCalibratedCeremonyForTesting(t, nodes, participants, policy, handshake,
+			tamperWith(silent, HelloMessage, func(_ *big.Int, _ Hashable) Hashable { return nil }))
+		if _, ok := runners[0].RTT(silent); ok {
+			t.Errorf("Got a round trip time to the silent participant")
+		}
+		if d := runners[0].Timeout(PhaseDealing); d != 8*handshake {
+			t.Errorf("Dealing timed at %v, want %v", d, 8*handshake)
+		}
+		var results []*KeyShare
+		for _, r := range runners {
+			result, _ := r.Result()
+			results = append(results, result)
+		}
+		checkCeremonyResultsForTesting(t, results)
+	})
+}
@@ -0,0 +1,101 @@
+package dkg
+
+import "crypto/sha256"
+import "crypto/subtle"
+import "io"
+import "math/big"
+
+// ChallengeSource supplies the challenge of an interactive proof once the
+// prover committed to T, for deployments that prefer interactive soundness
+// to Fiat-Shamir in disputes.
+type ChallengeSource interface {
+	Challenge(prover *big.Int, commitX, commitY *big.Int) (*big.Int, error)
+}
+
+// ProveSecretKnowledgeInteractive proves knowledge of the node's secrets
+// like ProveSecretKnowledge, with the challenge from source, which it
+// returns along with the proof.
+func (n *Node) ProveSecretKnowledgeInteractive(random io.Reader, source ChallengeSource) (SecretKnowledgeProof, *big.Int, error) {
+	prover, err := n.BeginSecretKnowledgeProof(random)
+	if err != nil {
+		return SecretKnowledgeProof{}, nil, err
+	}
+	c, err := source.Challenge(n.id, prover.CommitX, prover.CommitY)
+	if err != nil {
+		return SecretKnowledgeProof{}, nil, err
+	}
+	proof, err := prover.Respond(c)
+	return proof, c, err
+}
+
+// DesignatedVerifier draws challenges from Random, convincing only the
+// verifier that controls it.
+type DesignatedVerifier struct {
+	N      *big.Int
+	Random io.Reader
+}
+
+func (v DesignatedVerifier) Challenge(prover *big.Int, commitX, commitY *big.Int) (*big.Int, error) {
+	return randomScalar(v.N, v.Random)
+}
+
+// CoinContribution is a participant's share of a coin toss, committed to
+// before any contribution is revealed.
+type CoinContribution struct {
+	Value []byte
+}
+
+func NewCoinContribution(random io.Reader) (CoinContribution, error) {
+	value := make([]byte, 32)
+	if _, err := io.ReadFull(random, value); err != nil {
+		return CoinContribution{}, err
+	}
+	return CoinContribution{value}, nil
+}
+
+// Commitment returns the commitment to publish ahead of the reveal.
+func (c CoinContribution) Commitment() []byte {
+	return HashOf(sha256.New(), coinStatement(c.Value))
+}
+
+type coinStatement []byte
+
+func (s coinStatement) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/coin-contribution")
+	w.WriteBytes(s)
+}
+
+type coinOutcome []CoinContribution
+
+func (o coinOutcome) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/coin-toss")
+	w.WriteUint(uint64(len(o)))
+	for _, c := range o {
+		w.WriteBytes(c.Value)
+	}
+}
+
+// FlipCoin checks the revealed contributions against the commitments
+// published before, in the same order, and returns the outcome, a scalar
+// mod n. It is unpredictable to each party as long as one contributor is
+// honest; a party that withholds its reveal can only abort the toss.
+func FlipCoin(n *big.Int, commitments [][]byte, reveals []CoinContribution) (*big.Int, error) {
+	if len(reveals) != len(commitments) || len(reveals) == 0 {
+		return nil, CoinTossError{len(reveals)}
+	}
+	for i, c := range reveals {
+		if subtle.ConstantTimeCompare(c.Commitment(), commitments[i]) != 1 {
+			return nil, CoinTossError{i}
+		}
+	}
+	outcome := new(big.Int).SetBytes(HashOf(sha256.New(), coinOutcome(reveals)))
+	return outcome.Mod(outcome, n), nil
+}
+
+// ChallengeFunc adapts a function, such as one running a coin toss among
+// the verifiers, to a ChallengeSource.
+type ChallengeFunc func(prover *big.Int, commitX, commitY *big.Int) (*big.Int, error)
+
+func (f ChallengeFunc) Challenge(prover *big.Int, commitX, commitY *big.Int) (*big.Int, error) {
+	return f(prover, commitX, commitY)
+}
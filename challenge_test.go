@@ -0,0 +1,77 @@
+package dkg
+
+import (
+	"crypto/rand"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestInteractiveSecretKnowledgeProof(t *testing.T) {
+	nodes, _ := getCeremonyNodesForTesting(t, 2, 2)
+	prover, verifier := nodes[0], nodes[1]
+	vpts := prover.VerificationPoints()
+	n := prover.curve.Params().N
+
+	t.Run("Designated verifier", func(t *testing.T) {
+		proof, c, err := prover.ProveSecretKnowledgeInteractive(rand.Reader, DesignatedVerifier{n, rand.Reader})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !verifier.VerifySecretKnowledgeChallenge(vpts, proof, c) {
+			t.Errorf("Valid proof didn't verify")
+		}
+		if verifier.VerifySecretKnowledgeChallenge(vpts, proof, new(big.Int).Add(c, one)) {
+			t.Errorf("Proof verified for another challenge")
+		}
+		if verifier.VerifySecretKnowledgeChallenge(verifier.VerificationPoints(), proof, c) {
+			t.Errorf("Proof verified for other verification points")
+		}
+	})
+
+	t.Run("Coin toss", func(t *testing.T) {
+		var contributions []CoinContribution
+		var commitments [][]byte
+		for i := 0; i < 3; i++ {
+			c, err := NewCoinContribution(rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			contributions = append(contributions, c)
+			commitments = append(commitments, c.Commitment())
+		}
+		source := ChallengeFunc(func(_, _, _ *big.Int) (*big.Int, error) {
+			return FlipCoin(n, commitments, contributions)
+		})
+		proof, c, err := prover.ProveSecretKnowledgeInteractive(rand.Reader, source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !verifier.VerifySecretKnowledgeChallenge(vpts, proof, c) {
+			t.Errorf("Valid proof didn't verify")
+		}
+
+		changed := append([]CoinContribution(nil), contributions...)
+		changed[1] = CoinContribution{append([]byte(nil), changed[1].Value...)}
+		changed[1].Value[0] ^= 1
+		if _, err := FlipCoin(n, commitments, changed); !reflect.DeepEqual(err, CoinTossError{1}) {
+			t.Errorf("Got unexpected error for a changed contribution: %v", err)
+		}
+		if _, err := FlipCoin(n, commitments, contributions[:2]); reflect.TypeOf(err) != reflect.TypeOf(CoinTossError{}) {
+			t.Errorf("Got unexpected error for a withheld contribution: %v", err)
+		}
+	})
+
+	t.Run("One challenge per prover", func(t *testing.T) {
+		p, err := prover.BeginSecretKnowledgeProof(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.Respond(big.NewInt(1)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.Respond(big.NewInt(2)); reflect.TypeOf(err) != reflect.TypeOf(ProverReusedError{}) {
+			t.Errorf("Got unexpected error answering a second challenge: %v", err)
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package main
+
+import "crypto"
+import "crypto/ecdsa"
+import "crypto/rand"
+import "crypto/sha256"
+import "encoding/hex"
+import "encoding/json"
+import "errors"
+import "flag"
+import "fmt"
+import "io"
+import "math/big"
+import "os"
+import "slices"
+import "time"
+
+import "github.com/mikalv/dkg"
+
+// AuditReport is the health of a participant's key share as dkg audit
+// found it.
+type AuditReport struct {
+	Time      time.Time    `json:"time"`
+	ID        string       `json:"id"`
+	Epoch     uint64       `json:"epoch"`
+	PublicKey string       `json:"publicKey,omitempty"`
+	Checks    []AuditCheck `json:"checks"`
+	Healthy   bool         `json:"healthy"`
+}
+
+// AuditCheck is the outcome of one of the checks of an audit, with what
+// failed if it failed.
+type AuditCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// SignedAuditReport is an AuditReport, as encoded, signed with the
+// participant's identity key over its SHA-256 digest, so that the
+// coordinator can tell which participant reported it.
+type SignedAuditReport struct {
+	Report    json.RawMessage `json:"report"`
+	Signer    string          `json:"signer"`
+	Signature string          `json:"signature"`
+}
+
+func (r *AuditReport) check(name string, err error) bool {
+	c := AuditCheck{Name: name, OK: err == nil}
+	if err != nil {
+		c.Detail = err.Error()
+	}
+	r.Checks = append(r.Checks, c)
+	return c.OK
+}
+
+// audit checks the key share sealed by run without changing it: that the
+// keystore's MAC authenticates it, that it's the configured participant's
+// and consistent with the group's public coefficients, and that the
+// ceremony's audit log verifies and produced the same group key. It prints
+// the report signed with the identity key, and fails if a check did.
+func audit(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("audit", flag.ExitOnError)
+	configPath := flags.String("config", "", "participant configuration file")
+	sharePath := flags.String("share", "", "sealed key share file, the configured output if empty")
+	transcriptPath := flags.String("transcript", "", "audit log of the ceremony, the configured transcript if empty")
+	flags.Parse(args)
+	if *configPath == "" {
+		usage()
+	}
+	config, err := readConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	c, err := config.resolve()
+	if err != nil {
+		return err
+	}
+	defer c.node.Zeroize()
+	if *sharePath == "" {
+		*sharePath = c.output
+	}
+	if *transcriptPath == "" {
+		*transcriptPath = config.Transcript
+	}
+	if *transcriptPath == "" {
+		return errors.New("dkg: no audit log to check the share against, set -transcript")
+	}
+
+	report := AuditReport{Time: time.Now().UTC(), ID: c.node.ID().String()}
+	share, err := readShare(*sharePath)
+	if report.check("keystore", err) {
+		defer share.Zeroize()
+		report.Epoch = share.Epoch
+		report.PublicKey = encodePublicKey(&share.PublicKey)
+		report.check("participant", checkParticipant(c, share))
+		report.check("commitments", checkCommitments(share))
+		report.check("transcript", checkTranscript(*transcriptPath, share))
+		report.check("usable", checkUsable(share))
+	}
+	report.Healthy = !slices.ContainsFunc(report.Checks, func(c AuditCheck) bool { return !c.OK })
+
+	signed, err := signReport(report, c.identity)
+	if err != nil {
+		return err
+	}
+	// not indented, which would change the signed report
+	b, err := json.Marshal(signed)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%s\n", b)
+	if !report.Healthy {
+		return errors.New("dkg: the key share failed its audit")
+	}
+	return nil
+}
+
+func checkParticipant(c *ceremony, share *dkg.KeyShare) error {
+	if share.ID == nil || share.ID.Cmp(c.node.ID()) != 0 {
+		return fmt.Errorf("share of participant %v", share.ID)
+	}
+	if share.PublicKey.Curve != c.bundle.Curve {
+		return fmt.Errorf("share on %v", share.PublicKey.Curve.Params().Name)
+	}
+	if share.Threshold != c.bundle.Threshold {
+		return fmt.Errorf("share with threshold %v", share.Threshold)
+	}
+	return nil
+}
+
+// checkCommitments checks that the share, times the generator, is the
+// participant's public share, the public coefficients evaluated at its ID,
+// and that the group key is their constant term.
+func checkCommitments(share *dkg.KeyShare) error {
+	if len(share.PublicCoefficients) != share.Threshold+1 {
+		return fmt.Errorf("%v public coefficients for threshold %v", len(share.PublicCoefficients), share.Threshold)
+	}
+	c := share.PublicCoefficients[0]
+	if c.X.Cmp(share.PublicKey.X) != 0 || c.Y.Cmp(share.PublicKey.Y) != 0 {
+		return errors.New("group key isn't the constant public coefficient")
+	}
+	if share.Share == nil {
+		return errors.New("share destroyed")
+	}
+	curve := share.PublicKey.Curve
+	b := share.Share.FillBytes(make([]byte, (curve.Params().N.BitLen()+7)/8))
+	defer clear(b)
+	x, y := curve.ScalarBaseMult(b)
+	public := share.PublicShare(share.ID)
+	if x.Cmp(public.X) != 0 || y.Cmp(public.Y) != 0 {
+		return errors.New("share doesn't match the public coefficients")
+	}
+	return nil
+}
+
+// checkTranscript checks the ceremony's audit log and that its outcome is
+// the share's group.
+func checkTranscript(path string, share *dkg.KeyShare) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var log dkg.AuditLog
+	if err := log.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	outcome, err := dkg.TranscriptVerifier{Hash: newHash()}.Verify(&log)
+	if err != nil {
+		return err
+	}
+	if !outcome.PublicKey.Equal(&share.PublicKey) {
+		return errors.New("transcript produced another group key")
+	}
+	if !slices.EqualFunc(outcome.Qualified, share.Qualified, func(a, b *big.Int) bool { return a.Cmp(b) == 0 }) {
+		return errors.New("transcript qualified other dealers")
+	}
+	return nil
+}
+
+func checkUsable(share *dkg.KeyShare) error {
+	if share.Revoked != nil {
+		return errors.New("group key revoked")
+	}
+	return nil
+}
+
+// signReport encodes report and signs it with key.
+func signReport(report AuditReport, key *ecdsa.PrivateKey) (SignedAuditReport, error) {
+	b, err := json.Marshal(report)
+	if err != nil {
+		return SignedAuditReport{}, err
+	}
+	digest := sha256.Sum256(b)
+	sig, err := dkg.SoftwareIdentity(key).Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return SignedAuditReport{}, err
+	}
+	return SignedAuditReport{b, encodePublicKey(&key.PublicKey), hex.EncodeToString(sig)}, nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import "crypto/ecdsa"
+import "crypto/tls"
+import "crypto/x509"
+import "encoding/hex"
+import "encoding/json"
+import "fmt"
+import "math/big"
+import "os"
+import "time"
+
+import "github.com/mikalv/dkg"
+
+// Config describes a participant and its ceremony. All participants must
+// agree on everything but ID, Listen, Identity, TLS, Output, Metrics and
+// Transcript. With a Handshake, the phase timeouts are derived from the
+// round trip times measured in a handshake that long, and Timeout only
+// serves as fallback.
+// VSS is "pedersen", the default, or "feldman", which needs no G2. Without
+// G2, Pedersen VSS uses the second generator derived by the library.
+// With Metrics, an address, Prometheus metrics of the ceremony are served
+// there while it runs. With Transcript, a file, the broadcasts of the
+// ceremony are recorded there as an audit log signed with the identity key,
+// for dkg audit.
+type Config struct {
+	Curve      string       `json:"curve"`
+	Threshold  int          `json:"threshold"`
+	G2         string       `json:"g2,omitempty"`
+	VSS        string       `json:"vss,omitempty"`
+	ZKParam    string       `json:"zkParam"`
+	Timeout    string       `json:"timeout"`
+	Handshake  string       `json:"handshake,omitempty"`
+	ID         string       `json:"id"`
+	Listen     string       `json:"listen"`
+	Identity   string       `json:"identity"`
+	Peers      []PeerConfig `json:"peers"`
+	TLS        *TLSConfig   `json:"tls,omitempty"`
+	Output     string       `json:"output"`
+	Metrics    string       `json:"metrics,omitempty"`
+	Transcript string       `json:"transcript,omitempty"`
+}
+
+// PeerConfig is a participant as known to the others, including this one.
+type PeerConfig struct {
+	ID   string `json:"id"`
+	Addr string `json:"addr"`
+	Key  string `json:"key"`
+}
+
+// TLSConfig names the PEM files for mutual TLS between participants, with
+// the DKG RPC service. The certificate must be for the identity key.
+// Without it, participants authenticate with self-signed certificates for
+// their identity keys, which must then be on a curve crypto/x509 supports.
+type TLSConfig struct {
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+	CA   string `json:"ca"`
+}
+
+func readConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("dkg: %v: %v", path, err)
+	}
+	return &c, nil
+}
+
+func parseInt(field, s string) (*big.Int, error) {
+	x, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return nil, fmt.Errorf("dkg: invalid %v %q", field, s)
+	}
+	return x, nil
+}
+
+// ceremony is a configuration resolved into its values.
+type ceremony struct {
+	set          *dkg.ParticipantSet
+	node         *dkg.Node
+	peers        []dkg.Peer
+	timeout      time.Duration
+	handshake    time.Duration
+	tls          *tls.Config
+	listen       string
+	output       string
+	participants []dkg.Participant
+	identity     *ecdsa.PrivateKey
+	// the parameters of the ceremony, for its audit log
+	bundle dkg.CeremonyBundle
+}
+
+func (c *Config) resolve() (*ceremony, error) {
+	curve, err := curveByName(c.Curve)
+	if err != nil {
+		return nil, err
+	}
+	var g2x, g2y *big.Int
+	var opts []dkg.NodeOption
+	switch c.VSS {
+	case "", "pedersen":
+		if c.G2 != "" {
+			if g2x, g2y, err = decodePoint(curve, c.G2); err != nil {
+				return nil, err
+			}
+		}
+	case "feldman":
+		opts = append(opts, dkg.WithFeldmanVSS())
+	default:
+		return nil, fmt.Errorf("dkg: unknown vss %q", c.VSS)
+	}
+	zkParam, err := hex.DecodeString(c.ZKParam)
+	if err != nil {
+		return nil, fmt.Errorf("dkg: invalid zkParam %q", c.ZKParam)
+	}
+	timeout, err := time.ParseDuration(c.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	var handshake time.Duration
+	if c.Handshake != "" {
+		if handshake, err = time.ParseDuration(c.Handshake); err != nil {
+			return nil, err
+		}
+	}
+	id, err := parseInt("id", c.ID)
+	if err != nil {
+		return nil, err
+	}
+	key, err := readIdentity(c.Identity)
+	if err != nil {
+		return nil, err
+	}
+	if key.Curve != curve {
+		return nil, fmt.Errorf("dkg: identity key is on %v, not %v", key.Curve.Params().Name, c.Curve)
+	}
+
+	out := &ceremony{timeout: timeout, handshake: handshake, listen: c.Listen, output: c.Output, identity: key}
+	for _, p := range c.Peers {
+		pid, err := parseInt("peer id", p.ID)
+		if err != nil {
+			return nil, err
+		}
+		x, y, err := decodePoint(curve, p.Key)
+		if err != nil {
+			return nil, err
+		}
+		out.peers = append(out.peers, dkg.Peer{ID: pid, Addr: p.Addr})
+		out.participants = append(out.participants, dkg.Participant{ID: pid, Key: ecdsa.PublicKey{Curve: curve, X: x, Y: y}})
+	}
+	if out.set, err = dkg.NewParticipantSet(curve, c.Threshold, out.participants); err != nil {
+		return nil, err
+	}
+	opts = append(opts,
+		dkg.WithCurve(curve), dkg.WithHash(newHash()), dkg.WithGenerator2(g2x, g2y),
+		dkg.WithZKParam(new(big.Int).SetBytes(zkParam)), dkg.WithTimeout(timeout),
+		dkg.WithID(id), dkg.WithKey(*key), dkg.WithThreshold(c.Threshold),
+	)
+	if out.node, err = dkg.NewNodeWithOptions(opts...); err != nil {
+		return nil, err
+	}
+	out.bundle = dkg.CeremonyBundle{
+		Curve: curve, G2X: g2x, G2Y: g2y, Feldman: c.VSS == "feldman",
+		ZKParam: new(big.Int).SetBytes(zkParam), Threshold: c.Threshold, Participants: out.participants,
+	}
+	if c.TLS != nil {
+		if out.tls, err = c.TLS.load(&key.PublicKey); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// load loads the TLS configuration, whose certificate must be for
+// identity.
+func (c *TLSConfig) load(identity *ecdsa.PublicKey) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.Cert, c.Key)
+	if err != nil {
+		return nil, err
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	if key, ok := leaf.PublicKey.(*ecdsa.PublicKey); !ok || !key.Equal(identity) {
+		return nil, fmt.Errorf("dkg: certificate %v is not for the identity key", c.Cert)
+	}
+	ca, err := os.ReadFile(c.CA)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("dkg: no certificates in %v", c.CA)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS13,
+	}, nil
+}
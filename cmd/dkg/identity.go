@@ -0,0 +1,92 @@
+package main
+
+import "crypto/ecdsa"
+import "crypto/elliptic"
+import "crypto/rand"
+import "encoding/hex"
+import "encoding/pem"
+import "fmt"
+import "math/big"
+import "os"
+
+import "github.com/mikalv/dkg"
+
+const identityBlockType = "DKG IDENTITY KEY"
+
+func curveByName(name string) (elliptic.Curve, error) {
+	curve, ok := dkg.CurveByName(name)
+	if !ok {
+		return nil, fmt.Errorf("dkg: unknown curve %q", name)
+	}
+	return curve, nil
+}
+
+// generateIdentity returns a new identity key; ecdsa.GenerateKey only
+// supports the NIST curves.
+func generateIdentity(curveName string) (*ecdsa.PrivateKey, error) {
+	curve, err := curveByName(curveName)
+	if err != nil {
+		return nil, err
+	}
+	n := curve.Params().N
+	for {
+		b := make([]byte, (n.BitLen()+7)/8)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		d := new(big.Int).SetBytes(b)
+		if d.Sign() == 0 || d.Cmp(n) >= 0 {
+			continue
+		}
+		x, y := curve.ScalarBaseMult(b)
+		return &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: curve, X: x, Y: y}, D: d}, nil
+	}
+}
+
+// writeIdentity stores key as a PEM block holding its scalar, with the curve
+// name in a header.
+func writeIdentity(path string, key *ecdsa.PrivateKey) error {
+	block := &pem.Block{
+		Type:    identityBlockType,
+		Headers: map[string]string{"Curve": key.Curve.Params().Name},
+		Bytes:   key.D.Bytes(),
+	}
+	return os.WriteFile(path, pem.EncodeToMemory(block), 0600)
+}
+
+func readIdentity(path string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != identityBlockType {
+		return nil, fmt.Errorf("dkg: %v holds no identity key", path)
+	}
+	curve, err := curveByName(block.Headers["Curve"])
+	if err != nil {
+		return nil, err
+	}
+	d := new(big.Int).SetBytes(block.Bytes)
+	if d.Sign() == 0 || d.Cmp(curve.Params().N) >= 0 {
+		return nil, fmt.Errorf("dkg: invalid identity key in %v", path)
+	}
+	x, y := curve.ScalarBaseMult(block.Bytes)
+	return &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: curve, X: x, Y: y}, D: d}, nil
+}
+
+func encodePublicKey(key *ecdsa.PublicKey) string {
+	return hex.EncodeToString(elliptic.Marshal(key.Curve, key.X, key.Y))
+}
+
+func decodePoint(curve elliptic.Curve, s string) (*big.Int, *big.Int, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	x, y := elliptic.Unmarshal(curve, b)
+	if dkg.ValidatePoint(curve, x, y) != nil {
+		return nil, nil, fmt.Errorf("dkg: invalid %v point %q", curve.Params().Name, s)
+	}
+	return x, y, nil
+}
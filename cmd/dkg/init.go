@@ -0,0 +1,196 @@
+package main
+
+import "bufio"
+import "crypto/ecdsa"
+import "crypto/rand"
+import "crypto/sha256"
+import "encoding/hex"
+import "encoding/json"
+import "errors"
+import "flag"
+import "fmt"
+import "io"
+import "net"
+import "os"
+import "strings"
+import "time"
+
+import "github.com/mikalv/dkg"
+
+// dialTimeout bounds each connectivity check of init.
+const dialTimeout = 3 * time.Second
+
+// Registration is a participant's signed request to join a ceremony, for
+// the coordinator: the PeerConfig to hand out, signed with the identity key
+// it names, so that it can't have been altered on its way.
+type Registration struct {
+	Curve     string `json:"curve"`
+	ID        string `json:"id"`
+	Addr      string `json:"addr"`
+	Key       string `json:"key"`
+	Signature string `json:"signature"`
+}
+
+func (r *Registration) digest() []byte {
+	w := dkg.NewTranscriptWriter(sha256.New())
+	w.WriteTag("dkg/registration")
+	w.WriteTag(r.Curve)
+	w.WriteBytes([]byte(r.ID))
+	w.WriteBytes([]byte(r.Addr))
+	w.WriteBytes([]byte(r.Key))
+	return w.Sum()
+}
+
+func (r *Registration) sign(key *ecdsa.PrivateKey) error {
+	sig, err := ecdsa.SignASN1(rand.Reader, key, r.digest())
+	if err != nil {
+		return err
+	}
+	r.Signature = hex.EncodeToString(sig)
+	return nil
+}
+
+// verify checks the signature and returns the registered key.
+func (r *Registration) verify() (*ecdsa.PublicKey, error) {
+	curve, err := curveByName(r.Curve)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parseInt("id", r.ID); err != nil {
+		return nil, err
+	}
+	x, y, err := decodePoint(curve, r.Key)
+	if err != nil {
+		return nil, err
+	}
+	key := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	sig, err := hex.DecodeString(r.Signature)
+	if err != nil || !ecdsa.VerifyASN1(key, r.digest(), sig) {
+		return nil, fmt.Errorf("dkg: invalid signature on the registration of %v", r.ID)
+	}
+	return key, nil
+}
+
+// initParticipant generates an identity key and a signed registration,
+// checks that the peers are reachable and prints the key's fingerprint.
+// Values missing from the flags are asked for on in, unless batch.
+func initParticipant(args []string, in io.Reader, out io.Writer) error {
+	flags := flag.NewFlagSet("init", flag.ExitOnError)
+	curveName := flags.String("curve", "P-256", "curve of the ceremony")
+	id := flags.String("id", "", "participant ID")
+	addr := flags.String("addr", "", "address the other participants reach this one at")
+	keyPath := flags.String("key", "node.key", "identity key file to write")
+	registrationPath := flags.String("registration", "registration.json", "registration file to write")
+	peers := flags.String("peers", "", "comma-separated addresses of the other participants to check")
+	batch := flags.Bool("batch", false, "fail instead of asking for missing values")
+	flags.Parse(args)
+
+	prompt := bufio.NewReader(in)
+	ask := func(value *string, name string, required bool) error {
+		if *value == "" && !*batch {
+			fmt.Fprintf(out, "%v: ", name)
+			line, _ := prompt.ReadString('\n')
+			*value = strings.TrimSpace(line)
+		}
+		if required && *value == "" {
+			return fmt.Errorf("dkg: init needs -%v", name)
+		}
+		return nil
+	}
+	if err := ask(id, "id", true); err != nil {
+		return err
+	}
+	if err := ask(addr, "addr", true); err != nil {
+		return err
+	}
+	if err := ask(peers, "peers", false); err != nil {
+		return err
+	}
+	if _, err := parseInt("id", *id); err != nil {
+		return err
+	}
+	if _, _, err := net.SplitHostPort(*addr); err != nil {
+		return fmt.Errorf("dkg: invalid addr %q: %v", *addr, err)
+	}
+	if _, err := os.Stat(*keyPath); err == nil {
+		return fmt.Errorf("dkg: %v exists, not overwriting it", *keyPath)
+	}
+
+	key, err := generateIdentity(*curveName)
+	if err != nil {
+		return err
+	}
+	if err := writeIdentity(*keyPath, key); err != nil {
+		return err
+	}
+	r := Registration{Curve: *curveName, ID: *id, Addr: *addr, Key: encodePublicKey(&key.PublicKey)}
+	if err := r.sign(key); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*registrationPath, append(b, '\n'), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "wrote %v and %v\n", *keyPath, *registrationPath)
+	fmt.Fprintf(out, "fingerprint %v\n", dkg.Fingerprint(key.PublicKey))
+
+	var unreachable []string
+	for _, peer := range strings.Split(*peers, ",") {
+		if peer = strings.TrimSpace(peer); peer == "" {
+			continue
+		}
+		conn, err := net.DialTimeout("tcp", peer, dialTimeout)
+		if err != nil {
+			fmt.Fprintf(out, "peer %v unreachable: %v\n", peer, err)
+			unreachable = append(unreachable, peer)
+			continue
+		}
+		conn.Close()
+		fmt.Fprintf(out, "peer %v reachable\n", peer)
+	}
+	if len(unreachable) > 0 {
+		return errors.New("dkg: unreachable peers " + strings.Join(unreachable, ", "))
+	}
+	return nil
+}
+
+// register checks the registrations in the files and prints the peers of
+// the ceremony configuration to out, and their fingerprints to compare with
+// those the participants report out of band to log, so that out holds only
+// the configuration.
+func register(args []string, out, log io.Writer) error {
+	if len(args) == 0 {
+		usage()
+	}
+	var peers []PeerConfig
+	var curve string
+	for _, path := range args {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var r Registration
+		if err := json.Unmarshal(data, &r); err != nil {
+			return fmt.Errorf("dkg: %v: %v", path, err)
+		}
+		key, err := r.verify()
+		if err != nil {
+			return fmt.Errorf("dkg: %v: %v", path, err)
+		}
+		if len(peers) > 0 && r.Curve != curve {
+			return fmt.Errorf("dkg: %v: registered on %v, not %v", path, r.Curve, curve)
+		}
+		curve = r.Curve
+		fmt.Fprintf(log, "%v %v fingerprint %v\n", path, r.ID, dkg.Fingerprint(*key))
+		peers = append(peers, PeerConfig{r.ID, r.Addr, r.Key})
+	}
+	b, err := json.MarshalIndent(peers, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, string(b))
+	return err
+}
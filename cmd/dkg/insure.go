@@ -0,0 +1,167 @@
+package main
+
+import "crypto/rand"
+import "encoding/json"
+import "errors"
+import "flag"
+import "fmt"
+import "io"
+import "os"
+
+import "github.com/mikalv/dkg"
+
+// CustodianConfig is a custodian of an insurance backup: its identity key,
+// in the format of PeerConfig.Key, and what it's to do with its shard.
+type CustodianConfig struct {
+	Name         string `json:"name"`
+	Curve        string `json:"curve"`
+	Key          string `json:"key"`
+	Instructions string `json:"instructions"`
+}
+
+// readShare opens the key share sealed by run.
+func readShare(path string) (*dkg.KeyShare, error) {
+	passphrase := os.Getenv("DKG_PASSPHRASE")
+	if passphrase == "" {
+		return nil, errors.New("dkg: set DKG_PASSPHRASE to open the key share with")
+	}
+	sealed, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := dkg.OpenWithPassphrase(sealed, []byte(passphrase))
+	if err != nil {
+		return nil, err
+	}
+	defer clear(plaintext)
+	var share dkg.KeyShare
+	if err := share.UnmarshalBinary(plaintext); err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// insure splits a sealed key share into an insurance backup for the
+// custodians in the file.
+func insure(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("insure", flag.ExitOnError)
+	sharePath := flags.String("share", "", "sealed key share file")
+	custodiansPath := flags.String("custodians", "", "custodians file")
+	required := flags.Int("required", 0, "number of custodians needed to recover the share")
+	backupPath := flags.String("out", "", "backup file to write")
+	flags.Parse(args)
+	if *sharePath == "" || *custodiansPath == "" || *backupPath == "" {
+		usage()
+	}
+
+	data, err := os.ReadFile(*custodiansPath)
+	if err != nil {
+		return err
+	}
+	var configs []CustodianConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return fmt.Errorf("dkg: %v: %v", *custodiansPath, err)
+	}
+	var custodians []dkg.Custodian
+	for _, c := range configs {
+		curve, err := curveByName(c.Curve)
+		if err != nil {
+			return err
+		}
+		x, y, err := decodePoint(curve, c.Key)
+		if err != nil {
+			return err
+		}
+		custodian := dkg.Custodian{Name: c.Name, Instructions: c.Instructions}
+		custodian.Key.Curve, custodian.Key.X, custodian.Key.Y = curve, x, y
+		custodians = append(custodians, custodian)
+	}
+
+	share, err := readShare(*sharePath)
+	if err != nil {
+		return err
+	}
+	defer share.Zeroize()
+	backup, err := share.Insure(custodians, *required, rand.Reader)
+	if err != nil {
+		return err
+	}
+	b, err := backup.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*backupPath, b, 0644); err != nil {
+		return err
+	}
+	for _, shard := range backup.Shards {
+		fmt.Fprintf(out, "shard %v for %v fingerprint %v\n", shard.Index, shard.Custodian.Name, dkg.Fingerprint(shard.Custodian.Key))
+	}
+	return nil
+}
+
+// recoverShare reassembles the key share in a backup from the shards the
+// custodians open with their identity keys, and seals it like run.
+func recoverShare(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("recover", flag.ExitOnError)
+	backupPath := flags.String("backup", "", "backup file")
+	sharePath := flags.String("out", "", "sealed key share file to write")
+	flags.Parse(args)
+	if *backupPath == "" || *sharePath == "" || flags.NArg() == 0 {
+		usage()
+	}
+	passphrase := os.Getenv("DKG_PASSPHRASE")
+	if passphrase == "" {
+		return errors.New("dkg: set DKG_PASSPHRASE to seal the key share with")
+	}
+
+	data, err := os.ReadFile(*backupPath)
+	if err != nil {
+		return err
+	}
+	var backup dkg.InsuranceBackup
+	if err := backup.UnmarshalBinary(data); err != nil {
+		return fmt.Errorf("dkg: %v: %v", *backupPath, err)
+	}
+	var shards []dkg.DealtShare
+	for _, path := range flags.Args() {
+		key, err := readIdentity(path)
+		if err != nil {
+			return err
+		}
+		opened := false
+		for _, shard := range backup.Shards {
+			if !shard.Custodian.Key.Equal(&key.PublicKey) {
+				continue
+			}
+			s, err := backup.OpenShard(shard.Index, dkg.SoftwareIdentity(key))
+			if err != nil {
+				return fmt.Errorf("dkg: %v: %v", path, err)
+			}
+			shards = append(shards, s)
+			opened = true
+		}
+		if !opened {
+			return fmt.Errorf("dkg: %v is no custodian of the backup", path)
+		}
+	}
+
+	share, err := backup.Recover(shards)
+	if err != nil {
+		return err
+	}
+	defer share.Zeroize()
+	plaintext, err := share.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	defer clear(plaintext)
+	sealed, err := dkg.SealWithPassphrase(plaintext, []byte(passphrase), rand.Reader)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*sharePath, sealed, 0600); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "recovered share %v of group key %v\n", share.ID, dkg.Fingerprint(share.PublicKey))
+	return nil
+}
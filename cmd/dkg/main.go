@@ -0,0 +1,109 @@
+// Command dkg runs a participant of a distributed key generation ceremony.
+//
+//	dkg init -id 1 -addr host:port -peers host2:port,host3:port
+//	dkg register registration1.json registration2.json
+//	dkg keygen -curve P-256 -out node.key
+//	dkg run [-insecure] -config node.json
+//	dkg insure -share share -custodians custodians.json -required 2 -out backup
+//	dkg recover -backup backup -out share custodian1.key custodian2.key
+//	dkg rewrap -share share
+//	dkg audit -config node.json
+//	dkg selftest
+//	dkg version
+//
+// init onboards a participant: it asks for the values missing from its
+// flags, unless -batch, writes a new identity key and a registration signed
+// with it for the coordinator, checks that the peers are reachable and
+// prints the key's fingerprint, to be confirmed with the coordinator out of
+// band. register checks registrations and prints the peers of the ceremony
+// configuration, with the fingerprints. keygen only writes a new identity
+// key and prints its public key, for the other participants'
+// configurations. run takes part in the ceremony described by
+// the configuration file, prints a summary of the outcome including the
+// group public key, and writes the local key share, sealed with the
+// passphrase in $DKG_PASSPHRASE. Its peers authenticate with their identity
+// keys; only with -insecure, for demonstrations, do messages travel over
+// plain TCP. insure splits such a share into a backup
+// encrypted to custodians, any -required of whom recover it with their
+// identity keys through recover. rewrap seals a share again with the
+// passphrase in $DKG_NEW_PASSPHRASE, without writing it unsealed. audit
+// checks a share without changing it, against its keystore's MAC, the
+// group's public coefficients and the audit log run records with a
+// transcript in its configuration, and prints a report signed with the
+// identity key. version prints how the binary was built and its SHA-256
+// digest, to check against the checksums of the release artifacts built
+// reproducibly by internal/release.
+package main
+
+import "flag"
+import "fmt"
+import "os"
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "init":
+		err = initParticipant(os.Args[2:], os.Stdin, os.Stdout)
+	case "register":
+		err = register(os.Args[2:], os.Stdout, os.Stderr)
+	case "keygen":
+		err = keygen(os.Args[2:])
+	case "run":
+		err = run(os.Args[2:])
+	case "insure":
+		err = insure(os.Args[2:], os.Stdout)
+	case "recover":
+		err = recoverShare(os.Args[2:], os.Stdout)
+	case "rewrap":
+		err = rewrap(os.Args[2:], os.Stdout)
+	case "audit":
+		err = audit(os.Args[2:], os.Stdout)
+	case "selftest":
+		err = selftest()
+	case "version":
+		err = printVersion(os.Stdout)
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: dkg init [-batch] [-curve name] [-id id] [-addr host:port] [-peers addrs] [-key file] [-registration file]")
+	fmt.Fprintln(os.Stderr, "       dkg register registration...")
+	fmt.Fprintln(os.Stderr, "       dkg keygen [-curve name] -out file")
+	fmt.Fprintln(os.Stderr, "       dkg run [-insecure] -config file")
+	fmt.Fprintln(os.Stderr, "       dkg insure -share file -custodians file -required n -out file")
+	fmt.Fprintln(os.Stderr, "       dkg recover -backup file -out file identity...")
+	fmt.Fprintln(os.Stderr, "       dkg rewrap -share file")
+	fmt.Fprintln(os.Stderr, "       dkg audit -config file [-share file] [-transcript file]")
+	fmt.Fprintln(os.Stderr, "       dkg selftest")
+	fmt.Fprintln(os.Stderr, "       dkg version")
+	os.Exit(2)
+}
+
+func keygen(args []string) error {
+	flags := flag.NewFlagSet("keygen", flag.ExitOnError)
+	curveName := flags.String("curve", "P-256", "curve of the ceremony")
+	out := flags.String("out", "", "identity key file to write")
+	flags.Parse(args)
+	if *out == "" {
+		usage()
+	}
+
+	key, err := generateIdentity(*curveName)
+	if err != nil {
+		return err
+	}
+	if err := writeIdentity(*out, key); err != nil {
+		return err
+	}
+	fmt.Println(encodePublicKey(&key.PublicKey))
+	return nil
+}
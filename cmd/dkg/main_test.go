@@ -0,0 +1,339 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mikalv/dkg"
+)
+
+func freeAddrForTesting(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func TestIdentity(t *testing.T) {
+	for _, name := range []string{"P-256", "secp256k1", "edwards25519"} {
+		key, err := generateIdentity(name)
+		if err != nil {
+			t.Fatalf("Could not generate %v identity: %v", name, err)
+		}
+		path := filepath.Join(t.TempDir(), "node.key")
+		if err := writeIdentity(path, key); err != nil {
+			t.Fatal(err)
+		}
+		read, err := readIdentity(path)
+		if err != nil {
+			t.Fatalf("Could not read %v identity: %v", name, err)
+		}
+		if read.Curve != key.Curve || read.D.Cmp(key.D) != 0 || read.X.Cmp(key.X) != 0 {
+			t.Errorf("%v identity changed on disk", name)
+		}
+		if _, _, err := decodePoint(key.Curve, encodePublicKey(&key.PublicKey)); err != nil {
+			t.Errorf("Could not decode %v public key: %v", name, err)
+		}
+	}
+	if _, err := generateIdentity("P-255"); err == nil {
+		t.Errorf("Generated identity on unknown curve")
+	}
+}
+
+func TestInit(t *testing.T) {
+	dir := t.TempDir()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	var registrations []string
+	for i, name := range []string{"P-256", "secp256k1", "edwards25519"} {
+		key := filepath.Join(dir, fmt.Sprintf("node%v.key", i+1))
+		registration := filepath.Join(dir, fmt.Sprintf("registration%v.json", i+1))
+		args := []string{"-curve", name, "-key", key, "-registration", registration}
+		var out strings.Builder
+		if err := initParticipant(args, strings.NewReader(fmt.Sprintf("%v\n127.0.0.1:%v\n%v\n", i+1, 7000+i, l.Addr())), &out); err != nil {
+			t.Fatalf("Could not init %v participant: %v", name, err)
+		}
+		if !strings.Contains(out.String(), "fingerprint ") || !strings.Contains(out.String(), "reachable") {
+			t.Errorf("Unexpected init output:\n%v", out.String())
+		}
+		if _, err := readIdentity(key); err != nil {
+			t.Errorf("Could not read %v identity: %v", name, err)
+		}
+		registrations = append(registrations, registration)
+	}
+
+	args := []string{"-batch", "-id", "4", "-addr", "127.0.0.1:7003", "-key", filepath.Join(dir, "node1.key")}
+	if err := initParticipant(args, strings.NewReader(""), io.Discard); err == nil {
+		t.Errorf("Overwrote an identity key")
+	}
+	args = []string{"-batch", "-addr", "127.0.0.1:7003", "-key", filepath.Join(dir, "node4.key")}
+	if err := initParticipant(args, strings.NewReader("4\n"), io.Discard); err == nil {
+		t.Errorf("Asked for an ID in batch mode")
+	}
+	args = []string{"-batch", "-id", "4", "-addr", "127.0.0.1:7003", "-key", filepath.Join(dir, "node4.key"),
+		"-registration", filepath.Join(dir, "registration4.json"), "-peers", freeAddrForTesting(t)}
+	if err := initParticipant(args, strings.NewReader(""), io.Discard); err == nil {
+		t.Errorf("Unreachable peer went unnoticed")
+	}
+
+	for _, registration := range registrations {
+		var out, log strings.Builder
+		if err := register([]string{registration}, &out, &log); err != nil {
+			t.Errorf("Could not register: %v", err)
+		}
+		var peers []PeerConfig
+		if err := json.Unmarshal([]byte(out.String()), &peers); err != nil || len(peers) != 1 {
+			t.Errorf("Printed configuration %q (%v)", out.String(), err)
+		}
+		if !strings.HasPrefix(log.String(), registration+" ") || !strings.Contains(log.String(), " fingerprint ") {
+			t.Errorf("Printed fingerprints %q", log.String())
+		}
+	}
+	if err := register(registrations, io.Discard, io.Discard); err == nil {
+		t.Errorf("Registered participants on different curves")
+	}
+	var r Registration
+	data, _ := os.ReadFile(registrations[0])
+	json.Unmarshal(data, &r)
+	r.Addr = "127.0.0.1:1"
+	data, _ = json.Marshal(r)
+	os.WriteFile(registrations[0], data, 0644)
+	if err := register(registrations[:1], io.Discard, io.Discard); err == nil {
+		t.Errorf("Registered an altered registration")
+	}
+}
+
+func TestRun(t *testing.T) {
+	const size = 3
+	dir := t.TempDir()
+	os.Setenv("DKG_PASSPHRASE", "correct horse battery staple")
+	defer os.Unsetenv("DKG_PASSPHRASE")
+
+	g2, _ := generateIdentity("P-256")
+	peers := make([]PeerConfig, size)
+	for i := range peers {
+		key, err := generateIdentity("P-256")
+		if err != nil {
+			t.Fatal(err)
+		}
+		identity := filepath.Join(dir, fmt.Sprintf("node%v.key", i+1))
+		if err := writeIdentity(identity, key); err != nil {
+			t.Fatal(err)
+		}
+		peers[i] = PeerConfig{fmt.Sprint(i + 1), freeAddrForTesting(t), encodePublicKey(&key.PublicKey)}
+	}
+
+	configs := make([]string, size)
+	for i, peer := range peers {
+		config := Config{
+			Curve:     "P-256",
+			Threshold: 1,
+			G2:        encodePublicKey(&g2.PublicKey),
+			ZKParam:   hex.EncodeToString([]byte("dkg test")),
+			Timeout:   "2s",
+			ID:        peer.ID,
+			Listen:    peer.Addr,
+			Identity:  filepath.Join(dir, fmt.Sprintf("node%v.key", i+1)),
+			Peers:     peers,
+			Output:    filepath.Join(dir, fmt.Sprintf("share%v", i+1)),
+		}
+		config.Transcript = filepath.Join(dir, fmt.Sprintf("transcript%v", i+1))
+		if i == 0 {
+			config.Metrics = freeAddrForTesting(t)
+		}
+		b, _ := json.Marshal(config)
+		configs[i] = filepath.Join(dir, fmt.Sprintf("node%v.json", i+1))
+		if err := os.WriteFile(configs[i], b, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	errs := make([]error, size)
+	var wg sync.WaitGroup
+	for i := range configs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = run([]string{"-config", configs[i]})
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Node %v failed: %v", i+1, err)
+		}
+		sealed, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("share%v", i+1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		plaintext, err := dkg.OpenWithPassphrase(sealed, []byte("correct horse battery staple"))
+		if err != nil {
+			t.Fatalf("Could not open share of node %v: %v", i+1, err)
+		}
+		var share dkg.KeyShare
+		if err := share.UnmarshalBinary(plaintext); err != nil {
+			t.Errorf("Could not decode share of node %v: %v", i+1, err)
+		}
+	}
+
+	var report strings.Builder
+	if err := audit([]string{"-config", configs[1]}, &report); err != nil {
+		t.Errorf("Share failed its audit: %v\n%v", err, report.String())
+	}
+	var signed SignedAuditReport
+	if err := json.Unmarshal([]byte(report.String()), &signed); err != nil {
+		t.Fatalf("Could not decode the audit report: %v", err)
+	}
+	identity, _ := readIdentity(filepath.Join(dir, "node2.key"))
+	sig, _ := hex.DecodeString(signed.Signature)
+	digest := sha256.Sum256(signed.Report)
+	if !ecdsa.VerifyASN1(&identity.PublicKey, digest[:], sig) {
+		t.Errorf("Audit report not signed by the identity key")
+	}
+	var healthy AuditReport
+	if err := json.Unmarshal(signed.Report, &healthy); err != nil || !healthy.Healthy || len(healthy.Checks) != 5 {
+		t.Errorf("Got unexpected audit report %+v (%v)", healthy, err)
+	}
+	sealed, _ := os.ReadFile(filepath.Join(dir, "share2"))
+	sealed[len(sealed)-1] ^= 1
+	corrupted := filepath.Join(dir, "corrupted")
+	if err := os.WriteFile(corrupted, sealed, 0600); err != nil {
+		t.Fatal(err)
+	}
+	transcript, _ := os.ReadFile(filepath.Join(dir, "transcript2"))
+	transcript[len(transcript)/2] ^= 1
+	forged := filepath.Join(dir, "forged")
+	if err := os.WriteFile(forged, transcript, 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"-share", corrupted},
+		{"-transcript", forged},
+		{"-share", filepath.Join(dir, "share1")},
+	} {
+		if err := audit(append([]string{"-config", configs[1]}, args...), io.Discard); err == nil {
+			t.Errorf("Audit with %v passed", args)
+		}
+	}
+
+	var custodians []CustodianConfig
+	var identities []string
+	for _, name := range []string{"lawyer", "hsm", "vault"} {
+		key, _ := generateIdentity("secp256k1")
+		identity := filepath.Join(dir, name+".key")
+		if err := writeIdentity(identity, key); err != nil {
+			t.Fatal(err)
+		}
+		identities = append(identities, identity)
+		custodians = append(custodians, CustodianConfig{name, "secp256k1", encodePublicKey(&key.PublicKey), "hand to node 1"})
+	}
+	b, _ := json.Marshal(custodians)
+	custodiansPath := filepath.Join(dir, "custodians.json")
+	if err := os.WriteFile(custodiansPath, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	backup := filepath.Join(dir, "backup")
+	if err := insure([]string{"-share", filepath.Join(dir, "share1"), "-custodians", custodiansPath, "-required", "2", "-out", backup}, io.Discard); err != nil {
+		t.Fatalf("Could not insure share: %v", err)
+	}
+	recovered := filepath.Join(dir, "recovered")
+	if err := recoverShare([]string{"-backup", backup, "-out", recovered, identities[0]}, io.Discard); err == nil {
+		t.Errorf("Recovered share from one custodian")
+	}
+	if err := recoverShare([]string{"-backup", backup, "-out", recovered, identities[0], identities[2]}, io.Discard); err != nil {
+		t.Fatalf("Could not recover share: %v", err)
+	}
+	original, _ := readShare(filepath.Join(dir, "share1"))
+	share, err := readShare(recovered)
+	if err != nil || share.Share.Cmp(original.Share) != 0 {
+		t.Errorf("Recovered another share: %v", err)
+	}
+
+	os.Setenv("DKG_NEW_PASSPHRASE", "battery staple correct horse")
+	defer os.Unsetenv("DKG_NEW_PASSPHRASE")
+	if err := rewrap([]string{"-share", filepath.Join(dir, "share1")}, io.Discard); err != nil {
+		t.Fatalf("Could not rewrap share: %v", err)
+	}
+	if _, err := readShare(filepath.Join(dir, "share1")); err == nil {
+		t.Errorf("Opened rewrapped share with the old passphrase")
+	}
+	os.Setenv("DKG_PASSPHRASE", "battery staple correct horse")
+	if share, err := readShare(filepath.Join(dir, "share1")); err != nil || share.Share.Cmp(original.Share) != 0 {
+		t.Errorf("Rewrapped another share: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); slices.ContainsFunc(entries, func(e os.DirEntry) bool { return strings.HasPrefix(e.Name(), ".rewrap-") }) {
+		t.Errorf("Left a temporary file behind")
+	}
+}
+
+func TestTLSConfigIdentity(t *testing.T) {
+	dir := t.TempDir()
+	key, _ := generateIdentity("P-256")
+	other, _ := generateIdentity("P-256")
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "node 1"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &TLSConfig{filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), filepath.Join(dir, "cert.pem")}
+	os.WriteFile(config.Cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	os.WriteFile(config.Key, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+
+	if _, err := config.load(&key.PublicKey); err != nil {
+		t.Errorf("Could not load the certificate of the identity key: %v", err)
+	}
+	if _, err := config.load(&other.PublicKey); err == nil {
+		t.Errorf("Loaded a certificate for another key")
+	}
+}
+
+func TestVersion(t *testing.T) {
+	var out strings.Builder
+	if err := printVersion(&out); err != nil {
+		t.Fatal(err)
+	}
+	executable, _ := os.Executable()
+	data, err := os.ReadFile(executable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), "dkg devel\n") || !strings.Contains(out.String(), fmt.Sprintf("sha256 %x\n", sha256.Sum256(data))) {
+		t.Errorf("Got version %q", out.String())
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import "crypto/rand"
+import "errors"
+import "flag"
+import "fmt"
+import "io"
+import "os"
+import "path/filepath"
+
+import "github.com/mikalv/dkg"
+
+// rewrap seals a key share sealed by run again with a new passphrase, in
+// memory, and replaces the file atomically, so that a daemon reading it
+// finds either sealing.
+func rewrap(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("rewrap", flag.ExitOnError)
+	sharePath := flags.String("share", "", "sealed key share file")
+	flags.Parse(args)
+	if *sharePath == "" {
+		usage()
+	}
+	passphrase, next := os.Getenv("DKG_PASSPHRASE"), os.Getenv("DKG_NEW_PASSPHRASE")
+	if passphrase == "" || next == "" {
+		return errors.New("dkg: set DKG_PASSPHRASE and DKG_NEW_PASSPHRASE to the old and new passphrases")
+	}
+
+	sealed, err := os.ReadFile(*sharePath)
+	if err != nil {
+		return err
+	}
+	rewrapped, err := dkg.Rewrap(sealed,
+		dkg.PassphraseSealer([]byte(passphrase), rand.Reader), dkg.PassphraseSealer([]byte(next), rand.Reader))
+	if err != nil {
+		return err
+	}
+	if err := replaceFile(*sharePath, rewrapped); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "rewrapped %v\n", *sharePath)
+	return nil
+}
+
+// replaceFile replaces the file at path with data, readable by the user
+// only, by renaming a synced temporary file over it.
+func replaceFile(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".rewrap-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,143 @@
+package main
+
+import "crypto/rand"
+import "crypto/sha512"
+import "errors"
+import "flag"
+import "fmt"
+import "hash"
+import "net"
+import "net/http"
+import "os"
+
+import "github.com/mikalv/dkg"
+import "github.com/mikalv/dkg/dkgrpc"
+
+func newHash() hash.Hash {
+	return sha512.New512_256()
+}
+
+func run(args []string) error {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	configPath := flags.String("config", "", "participant configuration file")
+	insecure := flags.Bool("insecure", false, "exchange messages over plain TCP, without authenticating peers")
+	flags.Parse(args)
+	if *configPath == "" {
+		usage()
+	}
+	passphrase := os.Getenv("DKG_PASSPHRASE")
+	if passphrase == "" {
+		return errors.New("dkg: set DKG_PASSPHRASE to seal the key share with")
+	}
+
+	if err := dkg.SelfTest(); err != nil {
+		return err
+	}
+	config, err := readConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	c, err := config.resolve()
+	if err != nil {
+		return err
+	}
+
+	var transport dkg.Transport
+	switch {
+	case *insecure:
+		t, err := dkg.ListenTCP(c.node.ID(), c.listen, c.peers...)
+		if err != nil {
+			return err
+		}
+		transport = t
+	case c.tls != nil:
+		t, err := dkgrpc.ListenTLS(c.node.ID(), c.set, c.listen, c.tls, c.peers...)
+		if err != nil {
+			return err
+		}
+		transport = t
+	default:
+		t, err := dkg.ListenTLS(c.node, c.set, c.listen, c.peers...)
+		if err != nil {
+			return err
+		}
+		transport = t
+	}
+	defer transport.Close()
+	service, _ := transport.(*dkgrpc.Transport)
+	var log *dkg.AuditLog
+	if config.Transcript != "" {
+		log = dkg.NewAuditLog(c.bundle, c.node.ID())
+		transport = dkg.NewAuditTransport(log, transport)
+	}
+
+	runner, err := dkg.NewProtocolRunner(c.node, c.set, transport)
+	if err != nil {
+		return err
+	}
+	defer c.node.Zeroize()
+	if service != nil {
+		service.SetRunner(runner)
+	}
+	if config.Metrics != "" {
+		l, err := net.Listen("tcp", config.Metrics)
+		if err != nil {
+			return err
+		}
+		metrics := dkg.NewPrometheusMetrics()
+		runner.Instrument(metrics)
+		server := &http.Server{Handler: metrics}
+		go server.Serve(l)
+		defer server.Close()
+	}
+	if c.handshake > 0 {
+		runner.CalibrateTimeouts(dkg.DefaultTimeoutPolicy(), c.handshake)
+	}
+	if err := runner.Run(); err != nil {
+		return err
+	}
+	share, err := runner.Result()
+	if err != nil {
+		return err
+	}
+	defer share.Zeroize()
+
+	plaintext, err := share.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	defer clear(plaintext)
+	sealed, err := dkg.SealWithPassphrase(plaintext, []byte(passphrase), rand.Reader)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(c.output, sealed, 0600); err != nil {
+		return err
+	}
+	if log != nil {
+		if err := log.Sign(dkg.SoftwareIdentity(c.identity)); err != nil {
+			return err
+		}
+		transcript, err := log.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(config.Transcript, transcript, 0644); err != nil {
+			return err
+		}
+	}
+	summary, err := runner.Summary()
+	if err != nil {
+		return err
+	}
+	fmt.Print(summary)
+	return nil
+}
+
+func selftest() error {
+	if err := dkg.SelfTest(); err != nil {
+		return err
+	}
+	fmt.Println("ok")
+	return nil
+}
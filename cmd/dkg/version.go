@@ -0,0 +1,42 @@
+package main
+
+import "crypto/sha256"
+import "fmt"
+import "io"
+import "os"
+import "runtime/debug"
+
+// version is set by the release builds, with -ldflags "-X main.version=v".
+var version = "devel"
+
+// printVersion prints the version of the binary, how it was built, and the
+// SHA-256 digest of the running executable, to compare with the checksums
+// of the release artifacts.
+func printVersion(out io.Writer) error {
+	fmt.Fprintf(out, "dkg %v\n", version)
+	if info, ok := debug.ReadBuildInfo(); ok {
+		fmt.Fprintf(out, "go %v\n", info.GoVersion)
+		fmt.Fprintf(out, "module %v %v\n", info.Main.Path, info.Main.Version)
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision", "vcs.time", "vcs.modified", "-trimpath", "CGO_ENABLED", "GOOS", "GOARCH":
+				fmt.Fprintf(out, "%v %v\n", s.Key, s.Value)
+			}
+		}
+	}
+	path, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "sha256 %x\n", h.Sum(nil))
+	return nil
+}
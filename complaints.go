@@ -0,0 +1,40 @@
+package dkg
+
+import "crypto"
+import "crypto/ecdsa"
+import "hash"
+import "math/big"
+
+// complaintsBody is the part of Complaints covered by the signature.
+type complaintsBody struct {
+	accuser *big.Int
+	accused []*big.Int
+}
+
+func (c complaintsBody) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/complaints-body")
+	w.WriteInt(c.accuser)
+	w.WriteUint(uint64(len(c.accused)))
+	for _, id := range c.accused {
+		w.WriteInt(id)
+	}
+}
+
+func (n *Node) signComplaints(accused []*big.Int) (Complaints, error) {
+	digest := HashOf(n.hash, complaintsBody{n.id, accused})
+	sig, err := n.identity.Sign(n.random, digest, crypto.Hash(0))
+	if err != nil {
+		return Complaints{}, err
+	}
+	return Complaints{accused, sig}, nil
+}
+
+// VerifyComplaints checks that c was signed by accuser, so that
+// complaints can't be raised in another participant's name.
+func VerifyComplaints(h hash.Hash, accuser Participant, c Complaints) bool {
+	if accuser.Key.Curve == nil || accuser.Key.X == nil {
+		return false
+	}
+	digest := HashOf(h, complaintsBody{accuser.ID, c.Accused})
+	return ecdsa.VerifyASN1(&accuser.Key, digest, c.Signature)
+}
@@ -0,0 +1,115 @@
+package dkg
+
+import (
+	"math/big"
+	"testing"
+)
+
+// tamperingTransport rewrites the payloads of outgoing messages of one type.
+type tamperingTransport struct {
+	Transport
+	mType  MessageType
+	tamper func(to *big.Int, payload Hashable) Hashable
+}
+
+func (t tamperingTransport) Send(to *big.Int, m Message) error {
+	if m.Type == t.mType {
+		m.Payload = t.tamper(to, m.Payload)
+	}
+	return t.Transport.Send(to, m)
+}
+
+func (t tamperingTransport) Broadcast(m Message) error {
+	if m.Type == t.mType {
+		m.Payload = t.tamper(nil, m.Payload)
+	}
+	return t.Transport.Broadcast(m)
+}
+
+func tamperWith(id *big.Int, mType MessageType, tamper func(to *big.Int, payload Hashable) Hashable) func(*Node, Transport) Transport {
+	return func(n *Node, t Transport) Transport {
+		if n.ID().Cmp(id) != 0 {
+			return t
+		}
+		return tamperingTransport{t, mType, tamper}
+	}
+}
+
+func corruptShareTo(victim *big.Int) func(*big.Int, Hashable) Hashable {
+	return func(to *big.Int, payload Hashable) Hashable {
+		shares := payload.(EncryptedShares)
+		if to.Cmp(victim) == 0 {
+			shares.Ciphertext = append([]byte(nil), shares.Ciphertext...)
+			shares.Ciphertext[len(shares.Ciphertext)-1] ^= 1
+		}
+		return shares
+	}
+}
+
+func TestComplaints(t *testing.T) {
+	t.Run("Justified dealer stays qualified", func(t *testing.T) {
+		nodes, participants := getCeremonyNodesForTesting(t, 5, 2)
+		dealer, victim := nodes[0].ID(), nodes[1].ID()
+		results := runCeremonyForTesting(t, nodes, participants,
+			tamperWith(dealer, SecretSharesMessage, corruptShareTo(victim)))
+		for i, result := range results {
+			if result == nil {
+				t.Fatalf("Node %v did not finish", nodes[i].ID())
+			}
+			if len(result.Qualified) != len(nodes) {
+				t.Errorf("Node %v qualified %v", nodes[i].ID(), result.Qualified)
+			}
+		}
+		checkCeremonyResultsForTesting(t, results)
+	})
+
+	t.Run("Unjustified dealer is disqualified", func(t *testing.T) {
+		nodes, participants := getCeremonyNodesForTesting(t, 5, 2)
+		dealer, victim := nodes[0].ID(), nodes[1].ID()
+		results := runCeremonyForTesting(t, nodes, participants,
+			tamperWith(dealer, SecretSharesMessage, corruptShareTo(victim)),
+			tamperWith(dealer, JustificationMessage, func(_ *big.Int, payload Hashable) Hashable {
+				j := payload.(Justification)
+				j.Revealed = append([]RevealedShares(nil), j.Revealed...)
+				for i := range j.Revealed {
+					j.Revealed[i].Share2 = new(big.Int).Add(j.Revealed[i].Share2, one)
+				}
+				return j
+			}))
+		// the dealer itself can't tell its justification was tampered with
+		for i, result := range results[1:] {
+			if result == nil {
+				t.Fatalf("Node %v did not finish", nodes[i+1].ID())
+			}
+			for _, id := range result.Qualified {
+				if id.Cmp(dealer) == 0 {
+					t.Errorf("Node %v qualified misbehaving dealer", nodes[i+1].ID())
+				}
+			}
+			if len(result.Qualified) != len(nodes)-1 {
+				t.Errorf("Node %v qualified %v", nodes[i+1].ID(), result.Qualified)
+			}
+		}
+		checkCeremonyResultsForTesting(t, results[1:])
+	})
+
+	t.Run("Forged complaints are ignored", func(t *testing.T) {
+		nodes, participants := getCeremonyNodesForTesting(t, 5, 2)
+		accuser, framed := nodes[0].ID(), nodes[1].ID()
+		results := runCeremonyForTesting(t, nodes, participants,
+			tamperWith(accuser, ComplaintsMessage, func(_ *big.Int, payload Hashable) Hashable {
+				c := payload.(Complaints)
+				c.Accused = append(c.Accused, framed)
+				return c
+			}))
+		for i, result := range results {
+			if result == nil {
+				t.Fatalf("Node %v did not finish", nodes[i].ID())
+			}
+			if len(result.Qualified) != len(nodes) {
+				t.Errorf("Node %v qualified %v", nodes[i].ID(), result.Qualified)
+			}
+		}
+		checkCeremonyResultsForTesting(t, results)
+	})
+}
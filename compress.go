@@ -0,0 +1,119 @@
+package dkg
+
+import "crypto/elliptic"
+import "math/big"
+import "runtime"
+
+// wireVersionCompressed marks binary encodings whose points are compressed:
+// every vector of points names its curve, followed by the compressed
+// encoding of each point, roughly halving the size of commitments. Decoders
+// accept both versions; the canonical encoding that is hashed stays the
+// uncompressed one.
+const wireVersionCompressed = 2
+
+// Curves with a compressed encoding of their own, such as edwards25519,
+// implement this; the others get compressed SEC1 encodings.
+type compressingCurve interface {
+	MarshalCompressed(x, y *big.Int) []byte
+	UnmarshalCompressed(data []byte) (x, y *big.Int)
+}
+
+// MarshalCompressedPoint returns the compressed encoding of a point of
+// curve: SEC1's, the sign of y and x, unless the curve has one of its own.
+// The identity of crypto/elliptic is encoded as a single zero byte.
+func MarshalCompressedPoint(curve elliptic.Curve, x, y *big.Int) []byte {
+	if c, ok := curve.(compressingCurve); ok {
+		return c.MarshalCompressed(x, y)
+	}
+	if x == nil || y == nil || x.Sign() == 0 && y.Sign() == 0 {
+		return []byte{0}
+	}
+	size := (curve.Params().BitSize + 7) / 8
+	b := make([]byte, 1+size)
+	b[0] = 2 | byte(y.Bit(0))
+	x.FillBytes(b[1:])
+	return b
+}
+
+// UnmarshalCompressedPoint decodes a point encoded by
+// MarshalCompressedPoint. It only checks that the point is on curve;
+// ValidatePoint checks the rest.
+func UnmarshalCompressedPoint(curve elliptic.Curve, data []byte) (x, y *big.Int, err error) {
+	if c, ok := curve.(compressingCurve); ok {
+		if x, y = c.UnmarshalCompressed(data); x == nil {
+			return nil, nil, InvalidEncodingError{"invalid compressed point"}
+		}
+		return x, y, nil
+	}
+	if len(data) == 1 && data[0] == 0 {
+		return new(big.Int), new(big.Int), nil
+	}
+	size := (curve.Params().BitSize + 7) / 8
+	if len(data) != 1+size || data[0]&^1 != 2 {
+		return nil, nil, InvalidEncodingError{"invalid compressed point"}
+	}
+	x = new(big.Int).SetBytes(data[1:])
+	if x.Cmp(curve.Params().P) >= 0 {
+		return nil, nil, InvalidEncodingError{"invalid compressed point"}
+	}
+	// lift returns the point with even y
+	x, y, ok := lift(curve, x)
+	if !ok || !curve.IsOnCurve(x, y) {
+		return nil, nil, InvalidEncodingError{"invalid compressed point"}
+	}
+	if y.Bit(0) != uint(data[0]&1) {
+		y.Sub(curve.Params().P, y)
+	}
+	return x, y, nil
+}
+
+// DecompressPoints decodes a vector of compressed points of curve, in
+// parallel: each takes a modular square root.
+func DecompressPoints(curve elliptic.Curve, data [][]byte) (PointTuple, error) {
+	pts := make(PointTuple, len(data))
+	errs := make([]error, len(data))
+	parallelFor(runtime.GOMAXPROCS(0), len(data), func(i int) {
+		pts[i].X, pts[i].Y, errs[i] = UnmarshalCompressedPoint(curve, data[i])
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pts, nil
+}
+
+func (w *TranscriptWriter) writeCompressedPoints(pts PointTuple) {
+	w.WriteTag(w.compress.Params().Name)
+	for _, pt := range pts {
+		w.WriteBytes(MarshalCompressedPoint(w.compress, pt.X, pt.Y))
+	}
+}
+
+func (r *transcriptReader) readCompressedPoints(n int) PointTuple {
+	curve := r.readCurve()
+	data := make([][]byte, 0, n)
+	for len(data) < n {
+		data = append(data, r.readBytes())
+	}
+	if r.err != nil {
+		return nil
+	}
+	pts, err := DecompressPoints(curve, data)
+	if err != nil {
+		r.fail("invalid compressed point")
+	}
+	return pts
+}
+
+// MarshalCompressed returns the binary encoding of m with the points of
+// curve compressed, which only decoders of this version or later accept.
+func (m Message) MarshalCompressed(curve elliptic.Curve) ([]byte, error) {
+	return encodeCompressed(curve, m.writeCanonical), nil
+}
+
+// MarshalCompressed returns the binary encoding of pts, points of curve,
+// compressed.
+func (pts PointTuple) MarshalCompressed(curve elliptic.Curve) ([]byte, error) {
+	return encodeCompressed(curve, pts.writeCanonical), nil
+}
@@ -0,0 +1,63 @@
+package dkg
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/mikalv/dkg/edwards25519"
+	"github.com/mikalv/dkg/secp256k1"
+)
+
+func TestCompressedPoints(t *testing.T) {
+	curves := []elliptic.Curve{
+		elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521(),
+		secp256k1.S256(), edwards25519.Curve(),
+	}
+	for _, curve := range curves {
+		t.Run(curve.Params().Name, func(t *testing.T) {
+			var pts PointTuple
+			var data [][]byte
+			for i := 0; i < 8; i++ {
+				k, err := randomScalar(curve.Params().N, rand.Reader)
+				if err != nil {
+					t.Fatal(err)
+				}
+				x, y := curve.ScalarBaseMult(scalarBytes(curve, k))
+				pts = append(pts, struct{ X, Y *big.Int }{x, y})
+				data = append(data, MarshalCompressedPoint(curve, x, y))
+			}
+			decoded, err := DecompressPoints(curve, data)
+			if err != nil || !reflect.DeepEqual(decoded, pts) {
+				t.Fatalf("Points decompressed to %v (%v), expected %v", decoded, err, pts)
+			}
+
+			m := Message{VerificationPointsMessage, big.NewInt(1), nil, pts}
+			compressed, _ := m.MarshalCompressed(curve)
+			uncompressed, _ := m.MarshalBinary()
+			if len(compressed) >= len(uncompressed)*3/4 {
+				t.Errorf("Compressed message takes %v bytes, uncompressed %v", len(compressed), len(uncompressed))
+			}
+			var got Message
+			if err := got.UnmarshalBinary(compressed); err != nil || !reflect.DeepEqual(got, m) {
+				t.Errorf("Message decoded to %+v (%v)", got, err)
+			}
+			if !reflect.DeepEqual(HashOf(sha256.New(), got), HashOf(sha256.New(), m)) {
+				t.Errorf("Decoded message hashes differently")
+			}
+
+			if _, ok := curve.(compressingCurve); !ok {
+				bad := append([]byte{4}, data[0][1:]...)
+				if _, _, err := UnmarshalCompressedPoint(curve, bad); err == nil {
+					t.Errorf("Decoded invalid point %x", bad)
+				}
+			}
+			if _, _, err := UnmarshalCompressedPoint(curve, data[0][1:]); err == nil {
+				t.Errorf("Decoded truncated point")
+			}
+		})
+	}
+}
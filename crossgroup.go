@@ -0,0 +1,160 @@
+package dkg
+
+import "context"
+import "crypto/ecdsa"
+import "math/big"
+import "sync"
+
+// GroupSigner produces signatures with the threshold key of one group, for
+// instance by running the signing rounds among the group's signers in a
+// session of their transport.
+type GroupSigner interface {
+	PublicKey() ecdsa.PublicKey
+	Sign(ctx context.Context, digest []byte) (r, s *big.Int, err error)
+}
+
+// LocalGroupSigner is a GroupSigner over 2t+1 key shares of a group held in
+// one process. Nonces returns fresh signing nonces for Shares, in the same
+// order, and must never hand out the same ones twice.
+type LocalGroupSigner struct {
+	Shares []*KeyShare
+	Nonces func() ([]*SigningNonces, error)
+}
+
+func (g *LocalGroupSigner) PublicKey() ecdsa.PublicKey {
+	return g.Shares[0].PublicKey
+}
+
+func (g *LocalGroupSigner) Sign(ctx context.Context, digest []byte) (r, s *big.Int, err error) {
+	nonces, err := g.Nonces()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(nonces) != len(g.Shares) {
+		return nil, nil, InsufficientSharesError{len(nonces), len(g.Shares)}
+	}
+	products := make([]DealtShare, len(g.Shares))
+	for i, share := range g.Shares {
+		if products[i], err = ProductShare(share, nonces[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	partials := make([]PartialSignature, len(g.Shares))
+	for i, share := range g.Shares {
+		if partials[i], err = SignatureShare(share, nonces[i], products, digest); err != nil {
+			return nil, nil, err
+		}
+	}
+	return CombineSignature(g.Shares[0], nonces[0], partials, digest)
+}
+
+// CrossGroupPolicy controls an asset by several independent groups, each
+// with its own threshold key: Required of the Groups must sign.
+type CrossGroupPolicy struct {
+	Groups   []ecdsa.PublicKey
+	Required int
+}
+
+func (p CrossGroupPolicy) validate() error {
+	if p.Required < 1 || p.Required > len(p.Groups) {
+		return InvalidThresholdError{p.Required, len(p.Groups)}
+	}
+	return nil
+}
+
+// GroupSignature is the signature of the group at index Group of a policy.
+type GroupSignature struct {
+	Group int
+	R, S  *big.Int
+}
+
+// CrossGroupSignature composes the signatures of the groups of a policy, in
+// the order of the groups.
+type CrossGroupSignature []GroupSignature
+
+// SignAcrossGroups has the groups of policy sign digest concurrently, with
+// signers[i] signing for policy.Groups[i], a nil signer for groups taking no
+// part. It returns once Required groups produced valid signatures, canceling
+// the remaining signers, or with CrossGroupQuorumError once too many
+// failed.
+func SignAcrossGroups(ctx context.Context, policy CrossGroupPolicy, signers []GroupSigner, digest []byte) (CrossGroupSignature, error) {
+	if err := policy.validate(); err != nil {
+		return nil, err
+	}
+	if len(signers) != len(policy.Groups) {
+		return nil, InvalidThresholdError{len(signers), len(policy.Groups)}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan *GroupSignature)
+	var wg sync.WaitGroup
+	for i, signer := range signers {
+		if signer == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, signer GroupSigner) {
+			defer wg.Done()
+			var result *GroupSignature
+			r, s, err := signer.Sign(ctx, digest)
+			if err == nil && ecdsa.Verify(&policy.Groups[i], digest, r, s) {
+				result = &GroupSignature{i, r, s}
+			}
+			select {
+			case results <- result:
+			case <-ctx.Done():
+			}
+		}(i, signer)
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	signed := make([]*GroupSignature, len(policy.Groups))
+	count := 0
+	for result := range results {
+		if result == nil {
+			continue
+		}
+		signed[result.Group] = result
+		if count++; count == policy.Required {
+			break
+		}
+	}
+	if count < policy.Required {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, CrossGroupQuorumError{count, policy.Required}
+	}
+
+	var sig CrossGroupSignature
+	for _, s := range signed {
+		if s != nil {
+			sig = append(sig, *s)
+		}
+	}
+	return sig, nil
+}
+
+// VerifyCrossGroup checks that sig holds valid signatures of digest by
+// Required distinct groups of policy.
+func VerifyCrossGroup(policy CrossGroupPolicy, digest []byte, sig CrossGroupSignature) bool {
+	if policy.validate() != nil {
+		return false
+	}
+	seen := make(map[int]bool)
+	for _, s := range sig {
+		if s.Group < 0 || s.Group >= len(policy.Groups) || seen[s.Group] ||
+			s.R == nil || s.S == nil || !ecdsa.Verify(&policy.Groups[s.Group], digest, s.R, s.S) {
+			return false
+		}
+		seen[s.Group] = true
+	}
+	return len(seen) >= policy.Required
+}
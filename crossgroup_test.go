@@ -0,0 +1,113 @@
+package dkg
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+// keySignerForTesting signs with an ordinary ECDSA key, standing in for a
+// group.
+type keySignerForTesting struct {
+	key *ecdsa.PrivateKey
+	err error
+}
+
+func (g keySignerForTesting) PublicKey() ecdsa.PublicKey {
+	return g.key.PublicKey
+}
+
+func (g keySignerForTesting) Sign(ctx context.Context, digest []byte) (r, s *big.Int, err error) {
+	if g.err != nil {
+		return nil, nil, g.err
+	}
+	return ecdsa.Sign(rand.Reader, g.key, digest)
+}
+
+func TestSignAcrossGroups(t *testing.T) {
+	digest := sha256.Sum256([]byte("cross-group"))
+
+	t.Run("Threshold groups", func(t *testing.T) {
+		var signers []GroupSigner
+		var policy CrossGroupPolicy
+		for i := 0; i < 2; i++ {
+			keys, nonces := runSigningCeremoniesForTesting(t, 3, 1)
+			signers = append(signers, &LocalGroupSigner{keys, func() ([]*SigningNonces, error) { return nonces, nil }})
+			policy.Groups = append(policy.Groups, keys[0].PublicKey)
+		}
+		policy.Required = 2
+		sig, err := SignAcrossGroups(context.Background(), policy, signers, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sig) != 2 || !VerifyCrossGroup(policy, digest[:], sig) {
+			t.Errorf("Cross-group signature %v does not verify", sig)
+		}
+	})
+
+	var signers []GroupSigner
+	var policy CrossGroupPolicy
+	for i := 0; i < 3; i++ {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		signers = append(signers, keySignerForTesting{key, nil})
+		policy.Groups = append(policy.Groups, key.PublicKey)
+	}
+	policy.Required = 2
+
+	t.Run("Failing group", func(t *testing.T) {
+		failing := append([]GroupSigner(nil), signers...)
+		failing[0] = keySignerForTesting{signers[0].(keySignerForTesting).key, errors.New("offline")}
+		sig, err := SignAcrossGroups(context.Background(), policy, failing, digest[:])
+		if err != nil || !VerifyCrossGroup(policy, digest[:], sig) {
+			t.Errorf("Could not sign without one group: %v", err)
+		}
+
+		failing[1] = nil
+		_, err = SignAcrossGroups(context.Background(), policy, failing, digest[:])
+		if reflect.TypeOf(err) != reflect.TypeOf(CrossGroupQuorumError{}) {
+			t.Errorf("Got unexpected error without two groups: %v", err)
+		}
+
+		// a group signing with another key doesn't count
+		failing[1] = signers[2]
+		_, err = SignAcrossGroups(context.Background(), policy, failing, digest[:])
+		if reflect.TypeOf(err) != reflect.TypeOf(CrossGroupQuorumError{}) {
+			t.Errorf("Got unexpected error with a group signing for another: %v", err)
+		}
+	})
+
+	t.Run("Verify", func(t *testing.T) {
+		sig, err := SignAcrossGroups(context.Background(), policy, signers, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		other := sha256.Sum256([]byte("other"))
+		for _, test := range []struct {
+			description string
+			sig         CrossGroupSignature
+			digest      []byte
+		}{
+			{"too few", sig[:1], digest[:]},
+			{"duplicate", CrossGroupSignature{sig[0], sig[0]}, digest[:]},
+			{"unknown group", CrossGroupSignature{sig[0], {5, sig[1].R, sig[1].S}}, digest[:]},
+			{"other digest", sig, other[:]},
+		} {
+			if VerifyCrossGroup(policy, test.digest, test.sig) {
+				t.Errorf("Cross-group signature with %v verifies", test.description)
+			}
+		}
+	})
+
+	t.Run("Invalid policy", func(t *testing.T) {
+		invalid := CrossGroupPolicy{policy.Groups, 4}
+		if _, err := SignAcrossGroups(context.Background(), invalid, signers, digest[:]); reflect.TypeOf(err) != reflect.TypeOf(InvalidThresholdError{}) {
+			t.Errorf("Got unexpected error for an unsatisfiable policy: %v", err)
+		}
+	})
+}
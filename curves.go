@@ -0,0 +1,35 @@
+package dkg
+
+import "crypto/elliptic"
+import "sync"
+
+import "github.com/mikalv/dkg/edwards25519"
+import "github.com/mikalv/dkg/secp256k1"
+
+var curvesMu sync.RWMutex
+var curves = map[string]elliptic.Curve{}
+
+func init() {
+	for _, curve := range []elliptic.Curve{
+		elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521(),
+		secp256k1.S256(), edwards25519.Curve(),
+	} {
+		RegisterCurve(curve)
+	}
+}
+
+// RegisterCurve makes curve known by its name to the decoders of persisted
+// state.
+func RegisterCurve(curve elliptic.Curve) {
+	curvesMu.Lock()
+	defer curvesMu.Unlock()
+	curves[curve.Params().Name] = curve
+}
+
+// CurveByName returns the registered curve of the given name.
+func CurveByName(name string) (elliptic.Curve, bool) {
+	curvesMu.RLock()
+	defer curvesMu.RUnlock()
+	curve, ok := curves[name]
+	return curve, ok
+}
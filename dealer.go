@@ -0,0 +1,152 @@
+package dkg
+
+import "crypto/ecdsa"
+import "crypto/elliptic"
+import "io"
+import "math/big"
+
+type DealtShare struct {
+	ID    *big.Int
+	Share *big.Int
+}
+
+// SplitPrivateKey acts as a trusted dealer for an existing key, splitting it
+// into shares for ids of which any threshold+1 reconstruct it. The returned
+// Feldman commitments start with the unchanged public key, so recipients can
+// check their shares with VerifyDealtShare. The dealer sees the whole key;
+// it should run once, on an isolated machine, and be discarded.
+func SplitPrivateKey(
+	key *ecdsa.PrivateKey,
+	threshold int,
+	ids []*big.Int,
+	random io.Reader,
+) ([]DealtShare, PointTuple, error) {
+	curve := key.Curve
+	n := curve.Params().N
+
+	if key.D == nil || key.D.Sign() == 0 || !isNormalizedScalar(key.D, n) {
+		return nil, nil, InvalidCurveScalarError{curve, key.D}
+	}
+	return dealSecret(curve, key.D, threshold, ids, random)
+}
+
+// ImportPrivateKey migrates an existing key into threshold custody: it
+// splits the key among participants, at their threshold, as KeyShares in
+// epoch 0 that nodes can sign with, refresh and reshare like the results of
+// a ceremony. An imported share has no qualified dealers. The key's holder
+// should refresh the shares right after the import, so that the dealer's
+// view of them is useless, and then destroy the key.
+func ImportPrivateKey(key *ecdsa.PrivateKey, participants *ParticipantSet, random io.Reader) ([]*KeyShare, error) {
+	if key.Curve != participants.curve {
+		return nil, CurveMismatchError{participants.curve, key.Curve}
+	}
+	dealt, commitments, err := SplitPrivateKey(key, participants.threshold, participants.IDs(), random)
+	if err != nil {
+		return nil, err
+	}
+	shares := make([]*KeyShare, len(dealt))
+	for i, d := range dealt {
+		shares[i] = &KeyShare{
+			ID:                 d.ID,
+			Threshold:          participants.threshold,
+			PublicKey:          ecdsa.PublicKey{Curve: key.Curve, X: new(big.Int).Set(key.X), Y: new(big.Int).Set(key.Y)},
+			PublicCoefficients: commitments,
+			Share:              d.Share,
+		}
+	}
+	return shares, nil
+}
+
+// dealSecret splits secret into Shamir shares for ids with a random
+// polynomial of degree threshold, returning the shares and the polynomial's
+// Feldman commitments.
+func dealSecret(
+	curve elliptic.Curve,
+	secret *big.Int,
+	threshold int,
+	ids []*big.Int,
+	random io.Reader,
+) ([]DealtShare, PointTuple, error) {
+	n := curve.Params().N
+	if err := validateIDs(curve, threshold, ids); err != nil {
+		return nil, nil, err
+	}
+
+	poly := make(ScalarPolynomial, threshold+1)
+	poly[0] = new(big.Int).Set(secret)
+	for i := 1; i < len(poly); i++ {
+		c, err := randomScalar(n, random)
+		if err != nil {
+			return nil, nil, err
+		}
+		poly[i] = c
+	}
+	defer zeroize(poly...)
+
+	shares := make([]DealtShare, len(ids))
+	for i, id := range ids {
+		shares[i] = DealtShare{id, poly.evaluate(id, n)}
+	}
+
+	commitments := make(PointTuple, len(poly))
+	for i, c := range poly {
+		commitments[i].X, commitments[i].Y = curve.ScalarBaseMult(scalarBytes(curve, c))
+	}
+	return shares, commitments, nil
+}
+
+// VerifyDealtShare checks share * G against the dealer's Feldman commitments
+// evaluated at the share's ID.
+func VerifyDealtShare(curve elliptic.Curve, share DealtShare, commitments PointTuple) bool {
+	if len(commitments) == 0 || !validCommitments(curve, commitments) || !isNormalizedScalar(share.Share, curve.Params().N) {
+		return false
+	}
+	sx, sy := curve.ScalarBaseMult(scalarBytes(curve, share.Share))
+	ex, ey := evaluateCommitments(curve, commitments, share.ID)
+	return sx.Cmp(ex) == 0 && sy.Cmp(ey) == 0
+}
+
+// evaluateCommitments returns sum(C_k * x^k), by one multi-scalar
+// multiplication.
+func evaluateCommitments(curve elliptic.Curve, commitments PointTuple, x *big.Int) (*big.Int, *big.Int) {
+	if len(commitments) == 1 {
+		return commitments[0].X, commitments[0].Y
+	}
+	n := curve.Params().N
+	ks := make([]*big.Int, len(commitments)-1)
+	xk := new(big.Int).Mod(x, n)
+	for i := range ks {
+		ks[i] = new(big.Int).Set(xk)
+		xk.Mul(xk, x).Mod(xk, n)
+	}
+	tx, ty := multiScalarMult(curve, commitments[1:], ks)
+	return curve.Add(commitments[0].X, commitments[0].Y, tx, ty)
+}
+
+// validateIDs checks that there are more than threshold participant IDs, all
+// distinct and nonzero mod N.
+// validParticipantID reports whether id may name a participant: positive,
+// since encodings drop the sign, and not a multiple of n, where shares
+// would be the secret itself.
+func validParticipantID(id, n *big.Int) bool {
+	return id != nil && id.Sign() > 0 && new(big.Int).Mod(id, n).Sign() != 0
+}
+
+func validateIDs(curve elliptic.Curve, threshold int, ids []*big.Int) error {
+	n := curve.Params().N
+	if threshold < 0 || len(ids) <= threshold {
+		return InvalidThresholdError{threshold, len(ids)}
+	}
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if !validParticipantID(id, n) {
+			return InvalidParticipantIDError{id}
+		}
+		key := new(big.Int).Mod(id, n).String()
+		if seen[key] {
+			return DuplicateParticipantIDError{id}
+		}
+		seen[key] = true
+	}
+	return nil
+}
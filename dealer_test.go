@@ -0,0 +1,110 @@
+package dkg
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestSplitPrivateKey(t *testing.T) {
+	curve := elliptic.P256()
+	n := curve.Params().N
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4), big.NewInt(5)}
+
+	shares, commitments, err := SplitPrivateKey(key, 2, ids, rand.Reader)
+	if err != nil {
+		t.Fatalf("Could not split key: %v", err)
+	}
+	if commitments[0].X.Cmp(key.X) != 0 || commitments[0].Y.Cmp(key.Y) != 0 {
+		t.Errorf("Commitments don't start with the public key")
+	}
+	for _, share := range shares {
+		if !VerifyDealtShare(curve, share, commitments) {
+			t.Errorf("Share for %v doesn't verify", share.ID)
+		}
+	}
+	tampered := DealtShare{shares[0].ID, new(big.Int).Add(shares[0].Share, one)}
+	if VerifyDealtShare(curve, tampered, commitments) {
+		t.Errorf("Tampered share verifies")
+	}
+
+	quorum := shares[1:4]
+	xs := make([]*big.Int, len(quorum))
+	for i, share := range quorum {
+		xs[i] = share.ID
+	}
+	secret := new(big.Int)
+	for _, share := range quorum {
+		secret.Add(secret, new(big.Int).Mul(lagrangeCoefficient(share.ID, xs, n), share.Share))
+	}
+	if secret.Mod(secret, n).Cmp(key.D) != 0 {
+		t.Errorf("Shares of quorum %v don't reconstruct the key", xs)
+	}
+
+	badSplits := []struct {
+		threshold int
+		ids       []*big.Int
+		err       error
+	}{
+		{5, ids, InvalidThresholdError{}},
+		{-1, ids, InvalidThresholdError{}},
+		{1, []*big.Int{big.NewInt(1), n}, InvalidParticipantIDError{}},
+		{1, []*big.Int{big.NewInt(1), new(big.Int).Add(n, one)}, DuplicateParticipantIDError{}},
+	}
+	for _, bad := range badSplits {
+		if _, _, err := SplitPrivateKey(key, bad.threshold, bad.ids, rand.Reader); reflect.TypeOf(err) != reflect.TypeOf(bad.err) {
+			t.Errorf("Got unexpected error splitting with threshold %v among %v: %v", bad.threshold, bad.ids, err)
+		}
+	}
+}
+
+func TestImportPrivateKey(t *testing.T) {
+	const size, threshold = 5, 2
+	curve := elliptic.P256()
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes, participants := getCeremonyNodesForTesting(t, size, threshold-1)
+	set, err := NewParticipantSet(curve, threshold, participants)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shares, err := ImportPrivateKey(key, set, rand.Reader)
+	if err != nil {
+		t.Fatalf("Could not import key: %v", err)
+	}
+	updates := runCeremonyForTesting(t, nodes, participants)
+	refreshed := make([]*KeyShare, size)
+	for i, share := range shares {
+		if share.PublicKey.X.Cmp(key.X) != 0 || share.PublicKey.Y.Cmp(key.Y) != 0 {
+			t.Errorf("Share of %v has the wrong public key", share.ID)
+		}
+		if refreshed[i], err = RefreshShare(share, updates[i]); err != nil {
+			t.Fatalf("Could not refresh imported share of %v: %v", share.ID, err)
+		}
+	}
+	checkCeremonyResultsForTesting(t, refreshed)
+
+	dealt := make([]DealtShare, threshold+1)
+	for i, r := range refreshed[1 : threshold+2] {
+		dealt[i] = DealtShare{r.ID, r.Share}
+	}
+	secret, err := RecoverSecret(curve, threshold, dealt)
+	if err != nil || secret.Cmp(key.D) != 0 {
+		t.Errorf("Refreshed shares don't reconstruct the imported key: %v", err)
+	}
+
+	other, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if _, err := ImportPrivateKey(other, set, rand.Reader); reflect.TypeOf(err) != reflect.TypeOf(CurveMismatchError{}) {
+		t.Errorf("Got unexpected error importing a key on another curve: %v", err)
+	}
+}
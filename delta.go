@@ -0,0 +1,86 @@
+package dkg
+
+import "crypto/elliptic"
+import "math/big"
+
+// CommitmentDelta is the change of a group's public coefficients in a
+// refresh from Epoch to Epoch+1: the commitments to the update ceremony's
+// coefficients, by which the refreshed coefficients 1..t differ from the
+// previous ones. It is one point shorter than the coefficients themselves,
+// and lets holders of the previous group key and public shares follow a
+// refresh without recomputing them.
+type CommitmentDelta struct {
+	Epoch  uint64
+	Points PointTuple
+}
+
+// RefreshDelta returns the delta that RefreshShare applies to share's public
+// coefficients with update.
+func RefreshDelta(share, update *KeyShare) (CommitmentDelta, error) {
+	if share.Threshold < 1 || update.Threshold != share.Threshold-1 {
+		return CommitmentDelta{}, InvalidThresholdError{update.Threshold, len(share.Qualified)}
+	}
+	if update.PublicKey.Curve != share.PublicKey.Curve {
+		return CommitmentDelta{}, CurveMismatchError{share.PublicKey.Curve, update.PublicKey.Curve}
+	}
+	return CommitmentDelta{share.Epoch, update.PublicCoefficients}, nil
+}
+
+// ApplyDelta returns the group key after the refresh described by delta.
+func (g GroupKey) ApplyDelta(delta CommitmentDelta) (GroupKey, error) {
+	curve := g.PublicKey.Curve
+	if delta.Epoch != g.Epoch {
+		return GroupKey{}, MixedEpochError{g.Epoch, delta.Epoch}
+	}
+	if len(delta.Points) != g.Threshold || len(g.PublicCoefficients) != g.Threshold+1 {
+		return GroupKey{}, InvalidThresholdError{len(delta.Points), g.Threshold}
+	}
+	if !validCommitments(curve, delta.Points) {
+		return GroupKey{}, InvalidEncodingError{"invalid commitment delta points"}
+	}
+
+	coefficients := make(PointTuple, len(g.PublicCoefficients))
+	coefficients[0] = g.PublicCoefficients[0]
+	for k := 1; k < len(coefficients); k++ {
+		c, d := g.PublicCoefficients[k], delta.Points[k-1]
+		coefficients[k].X, coefficients[k].Y = curve.Add(c.X, c.Y, d.X, d.Y)
+	}
+	return GroupKey{g.Epoch + 1, g.Threshold, g.PublicKey, coefficients}, nil
+}
+
+// PublicShareDelta returns the change of participant id's public share in
+// the refresh: id * sum(D_k * id^k)
+func (d CommitmentDelta) PublicShareDelta(curve elliptic.Curve, id *big.Int) (*big.Int, *big.Int) {
+	x, y := evaluateCommitments(curve, d.Points, id)
+	k := new(big.Int).Mod(id, curve.Params().N)
+	return curve.ScalarMult(x, y, scalarBytes(curve, k))
+}
+
+// VerifyRefreshedShare checks a refreshed share against the participant's
+// public share px, py before the refresh, evaluating only the delta.
+func VerifyRefreshedShare(curve elliptic.Curve, px, py *big.Int, share DealtShare, delta CommitmentDelta) bool {
+	if !isValidPoint(curve, px, py) || !isNormalizedScalar(share.Share, curve.Params().N) || !validCommitments(curve, delta.Points) {
+		return false
+	}
+	dx, dy := delta.PublicShareDelta(curve, share.ID)
+	ex, ey := curve.Add(px, py, dx, dy)
+	sx, sy := curve.ScalarBaseMult(scalarBytes(curve, share.Share))
+	return sx.Cmp(ex) == 0 && sy.Cmp(ey) == 0
+}
+
+func (d CommitmentDelta) MarshalBinary() ([]byte, error) {
+	return encodeBinary(func(w *TranscriptWriter) {
+		w.WriteTag("dkg/commitment-delta")
+		w.WriteUint(d.Epoch)
+		w.Write(d.Points)
+	}), nil
+}
+
+func (d *CommitmentDelta) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, func(r *transcriptReader) {
+		r.expectTag("dkg/commitment-delta")
+		epoch := r.readUint()
+		r.expectTag("dkg/points")
+		*d = CommitmentDelta{epoch, r.readPoints()}
+	})
+}
@@ -0,0 +1,99 @@
+package dkg
+
+import "crypto/elliptic"
+import "crypto/hmac"
+import "crypto/sha256"
+import "crypto/sha512"
+import "encoding/binary"
+import "math/big"
+
+// HardenedIndex is the first hardened child index of BIP32, which needs the
+// whole private key and so can't be derived from a shared key.
+const HardenedIndex = 1 << 31
+
+// ChainCode returns the chain code the group key's children are derived
+// with, a digest of the group key: anyone who knows the group key can
+// derive its children's public keys, and link them to it.
+func (g GroupKey) ChainCode() []byte {
+	w := NewTranscriptWriter(sha256.New())
+	w.WriteTag("dkg/chain-code")
+	w.WriteTag(g.PublicKey.Curve.Params().Name)
+	w.WriteInt(g.PublicKey.X)
+	w.WriteInt(g.PublicKey.Y)
+	return w.Sum()
+}
+
+// deriveTweak follows the non-hardened BIP32 derivation path from the
+// public key (x, y) with chain code chain, and returns the sum of the
+// tweaks added to the key along it and the child key. On curves with
+// orders beyond 256 bits, the tweaks remain 256-bit numbers.
+func deriveTweak(curve elliptic.Curve, x, y *big.Int, chain []byte, path []uint32) (tweak, cx, cy *big.Int, err error) {
+	n := curve.Params().N
+	tweak = new(big.Int)
+	for depth, index := range path {
+		if index >= HardenedIndex {
+			return nil, nil, nil, InvalidDerivationError{depth, index}
+		}
+		mac := hmac.New(sha512.New, chain)
+		mac.Write(elliptic.MarshalCompressed(curve, x, y))
+		binary.Write(mac, binary.BigEndian, index)
+		i := mac.Sum(nil)
+		il := new(big.Int).SetBytes(i[:32])
+		if il.Cmp(n) >= 0 {
+			return nil, nil, nil, InvalidDerivationError{depth, index}
+		}
+		tx, ty := curve.ScalarBaseMult(scalarBytes(curve, il))
+		x, y = curve.Add(x, y, tx, ty)
+		if !isValidPoint(curve, x, y) {
+			return nil, nil, nil, InvalidDerivationError{depth, index}
+		}
+		tweak.Add(tweak, il).Mod(tweak, n)
+		chain = i[32:]
+	}
+	return tweak, x, y, nil
+}
+
+// DeriveChildPublic returns the group key's child at path, BIP32-style,
+// with non-hardened indices only. Its public coefficients are those of the
+// key shares derived along the same path with KeyShare.DeriveChild. As
+// each key's chain code is a digest of the key, deriving a path in steps
+// gives other keys than deriving it at once.
+func (g GroupKey) DeriveChildPublic(path []uint32) (GroupKey, error) {
+	child, _, err := g.deriveChild(path)
+	return child, err
+}
+
+func (g GroupKey) deriveChild(path []uint32) (GroupKey, *big.Int, error) {
+	curve := g.PublicKey.Curve
+	tweak, x, y, err := deriveTweak(curve, g.PublicKey.X, g.PublicKey.Y, g.ChainCode(), path)
+	if err != nil {
+		return GroupKey{}, nil, err
+	}
+	child := g
+	child.PublicKey.X, child.PublicKey.Y = x, y
+	child.PublicCoefficients = append(PointTuple(nil), g.PublicCoefficients...)
+	if len(child.PublicCoefficients) > 0 {
+		tx, ty := curve.ScalarBaseMult(scalarBytes(curve, tweak))
+		c := &child.PublicCoefficients[0]
+		c.X, c.Y = curve.Add(c.X, c.Y, tx, ty)
+	}
+	return child, tweak, nil
+}
+
+// DeriveChild returns the share of the group key's child at path: every
+// participant adds the same tweak to its share, which adds it to the
+// shared secret.
+func (s *KeyShare) DeriveChild(path []uint32) (*KeyShare, error) {
+	if err := s.usable(); err != nil {
+		return nil, err
+	}
+	group, tweak, err := s.Group().deriveChild(path)
+	if err != nil {
+		return nil, err
+	}
+	child := *s
+	child.PublicKey = group.PublicKey
+	child.PublicCoefficients = group.PublicCoefficients
+	child.Share = tweak.Add(tweak, s.Share).Mod(tweak, s.PublicKey.Curve.Params().N)
+	return &child, nil
+}
@@ -0,0 +1,57 @@
+package dkg
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestDeriveChild(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+	results := runCeremonyForTesting(t, nodes, participants)
+	checkCeremonyResultsForTesting(t, results)
+	curve := results[0].PublicKey.Curve
+	path := []uint32{0, 7, HardenedIndex - 1}
+
+	group, err := results[0].Group().DeriveChildPublic(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group.PublicKey.X.Cmp(results[0].PublicKey.X) == 0 {
+		t.Errorf("Child has its parent's public key")
+	}
+	var ids []*big.Int
+	var children []*KeyShare
+	for _, result := range results[:2] {
+		child, err := result.DeriveChild(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(child.Group(), group) {
+			t.Errorf("Child of share %v disagrees on the group key", result.ID)
+		}
+		public := child.PublicShare(result.ID)
+		if x, y := curve.ScalarBaseMult(child.Share.Bytes()); x.Cmp(public.X) != 0 || y.Cmp(public.Y) != 0 {
+			t.Errorf("Child of share %v doesn't match its public share", result.ID)
+		}
+		ids = append(ids, result.ID)
+		children = append(children, child)
+	}
+
+	secret := new(big.Int)
+	for _, child := range children {
+		l, err := LagrangeCoefficient(curve, child.ID, ids)
+		if err != nil {
+			t.Fatal(err)
+		}
+		secret.Add(secret, l.Mul(l, child.Share))
+	}
+	secret.Mod(secret, curve.Params().N)
+	if x, y := curve.ScalarBaseMult(secret.Bytes()); x.Cmp(group.PublicKey.X) != 0 || y.Cmp(group.PublicKey.Y) != 0 {
+		t.Errorf("Child shares interpolate another secret")
+	}
+
+	if _, err := results[0].DeriveChild([]uint32{1, HardenedIndex}); !reflect.DeepEqual(err, InvalidDerivationError{1, HardenedIndex}) {
+		t.Errorf("Got unexpected error for a hardened index: %v", err)
+	}
+}
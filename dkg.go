@@ -2,9 +2,13 @@ package dkg
 
 import "errors"
 import "hash"
+import "io"
+import "log/slog"
+import "sync"
 import "time"
 import "crypto/ecdsa"
 import "crypto/elliptic"
+import "crypto/rand"
 import "math/big"
 
 type ScalarPolynomial []*big.Int
@@ -23,35 +27,194 @@ func (p ScalarPolynomial) validate(curve elliptic.Curve) []error {
 	return errors
 }
 
-type node struct {
+type Node struct {
 	curve    elliptic.Curve
 	hash     hash.Hash
 	g2x, g2y *big.Int
 	zkParam  *big.Int
 	timeout  time.Duration
+	feldman  bool
+	tables   *generatorTables
+	logger   *slog.Logger
 
 	id          *big.Int
-	key         ecdsa.PrivateKey
+	key         ecdsa.PrivateKey // only the public key with an external identity
+	identity    Identity
+	random      io.Reader
 	secretPoly1 ScalarPolynomial
 	secretPoly2 ScalarPolynomial
+	inMemory    bool
 
-	broadcast chan Message
-
-	otherParticipants []struct {
-		id                 *big.Int
-		key                ecdsa.PublicKey
-		secretShare1       *big.Int
-		secretShare2       *big.Int
-		verificationPoints pointTuple
+	outbox      *Outbox
+	commitments *commitmentCache
+	dealing     *dealing
+}
 
-		private chan Message
-	}
+// commitmentCache holds a node's commitments to its secret polynomials.
+type commitmentCache struct {
+	mu           sync.Mutex
+	vpts         PointTuple // VerificationPoints
+	coefficients PointTuple // PublicCoefficients
 }
 
 func isNormalizedScalar(x, n *big.Int) bool {
 	return x != nil && x.Sign() >= 0 && x.Cmp(n) < 0
 }
 
+// NodeConfig holds the parameters of a node, set by the With options or
+// all at once by WithConfig. A nil G2X and G2Y stand for the second
+// generator derived with DeriveSecondGenerator from DefaultGeneratorDomain;
+// a Feldman node has no second generator, so it can't set them. Without
+// SecretPoly1 and SecretPoly2, the secret polynomials of degree Threshold
+// are sampled from Random; a node can't set only one of them. With
+// an Identity, only the public part of Key is used, if any, and the
+// node can't be saved with SaveNode.
+// A Domain other than empty separates Hash with DomainSeparatedHash, once
+// all options are applied.
+type NodeConfig struct {
+	Curve    elliptic.Curve
+	Hash     hash.Hash
+	Domain   string
+	G2X, G2Y *big.Int
+	ZKParam  *big.Int
+	Timeout  time.Duration
+	Feldman  bool
+
+	ID       *big.Int
+	Key      ecdsa.PrivateKey
+	Identity Identity
+
+	Threshold   int
+	SecretPoly1 ScalarPolynomial
+	SecretPoly2 ScalarPolynomial
+
+	Precompute bool
+	// keeps the node's secrets off disk, see WithInMemoryOnly
+	InMemoryOnly bool
+	// the source of all the node's randomness, crypto/rand if nil
+	Random io.Reader
+	// the logger of the node's protocol runners, which log nothing if nil
+	Logger *slog.Logger
+}
+
+// NodeOption configures a node beyond its NodeConfig.
+type NodeOption func(*NodeConfig)
+
+// applyNodeOptions applies opts to config in order, then separates the
+// hash under the config's Domain, whichever option set either.
+func applyNodeOptions(config NodeConfig, opts []NodeOption) NodeConfig {
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.Domain != "" && config.Hash != nil {
+		config.Hash = DomainSeparatedHash(config.Hash, config.Domain)
+	}
+	return config
+}
+
+// newNode returns the node described by config, with all options applied.
+func newNode(config NodeConfig) (*Node, error) {
+	switch {
+	case config.Curve == nil:
+		return nil, MissingNodeParameterError{"Curve"}
+	case config.Hash == nil:
+		return nil, MissingNodeParameterError{"Hash"}
+	case config.ZKParam == nil:
+		return nil, MissingNodeParameterError{"ZKParam"}
+	case config.ID == nil:
+		return nil, MissingNodeParameterError{"ID"}
+	case config.Feldman && (config.G2X != nil || config.G2Y != nil):
+		return nil, ConflictingNodeParametersError{"G2X and G2Y", "Feldman"}
+	case config.SecretPoly1 == nil && config.SecretPoly2 != nil:
+		return nil, MissingNodeParameterError{"SecretPoly1"}
+	case config.SecretPoly1 != nil && config.SecretPoly2 == nil && !config.Feldman:
+		return nil, MissingNodeParameterError{"SecretPoly2"}
+	}
+	curve := config.Curve
+	if !validParticipantID(config.ID, curve.Params().N) {
+		return nil, InvalidParticipantIDError{config.ID}
+	}
+	g2x, g2y := config.G2X, config.G2Y
+	if !config.Feldman && g2x == nil && g2y == nil {
+		var err error
+		if g2x, g2y, err = DeriveSecondGenerator(curve, []byte(DefaultGeneratorDomain)); err != nil {
+			return nil, err
+		}
+	}
+
+	random := config.Random
+	if random == nil {
+		random = rand.Reader
+	}
+	secretPoly1, secretPoly2 := config.SecretPoly1, config.SecretPoly2
+	if secretPoly1 == nil {
+		var err error
+		if secretPoly1, err = GenerateScalarPolynomial(curve, config.Threshold, random); err != nil {
+			return nil, err
+		}
+		if !config.Feldman {
+			if secretPoly2, err = GenerateScalarPolynomial(curve, config.Threshold, random); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	key := config.Key
+	if config.Identity != nil {
+		pub, ok := config.Identity.Public().(*ecdsa.PublicKey)
+		if !ok || pub.Curve == nil || !isValidPoint(pub.Curve, pub.X, pub.Y) {
+			return nil, InvalidParticipantKeyError{config.ID}
+		}
+		key = ecdsa.PrivateKey{PublicKey: *pub}
+	}
+	n := &Node{
+		curve, config.Hash, g2x, g2y, config.ZKParam, config.Timeout, config.Feldman, nil, nil,
+		config.ID, key, config.Identity, random, secretPoly1, secretPoly2, config.InMemoryOnly,
+		NewOutbox(defaultOutboxCapacity, BlockOnOverflow, nil), new(commitmentCache), new(dealing),
+	}
+	if n.identity == nil {
+		n.identity = softwareIdentity{&n.key}
+	}
+	if config.Logger != nil {
+		n.logger = config.Logger.With("node", config.ID.String())
+	}
+
+	if !n.feldman && !isValidPoint(curve, g2x, g2y) {
+		return nil, InvalidCurvePointError{curve, g2x, g2y}
+	}
+	if config.Precompute {
+		n.tables = newGeneratorTables(curve, g2x, g2y)
+	}
+
+	var polyErrors []error = nil
+	polyErrors = secretPoly1.validate(curve)
+	if !n.feldman && len(secretPoly1) != len(secretPoly2) || n.feldman && len(secretPoly2) != 0 {
+		polyErrors = append(polyErrors, InvalidScalarPolynomialLengthError{secretPoly1, secretPoly2})
+	}
+	if polyErrors != nil {
+		return nil, InvalidCurveScalarPolynomialError{curve, secretPoly1, polyErrors}
+	}
+
+	if !n.feldman {
+		if polyErrors = secretPoly2.validate(curve); polyErrors != nil {
+			return nil, InvalidCurveScalarPolynomialError{curve, secretPoly2, polyErrors}
+		}
+	}
+	if n.inMemory {
+		secrets := append(append([]*big.Int{n.key.D}, secretPoly1...), secretPoly2...)
+		if err := lockMemory(secrets...); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// NewNode derives a nil second generator with DeriveSecondGenerator from
+// DefaultGeneratorDomain.
+//
+// Deprecated: NewNode is kept for existing callers and translates its
+// parameters into a NodeConfig; use NewNodeWithOptions, which new
+// parameters are added to.
 func NewNode(
 	curve elliptic.Curve,
 	hash hash.Hash,
@@ -63,49 +226,107 @@ func NewNode(
 	key ecdsa.PrivateKey,
 	secretPoly1 ScalarPolynomial,
 	secretPoly2 ScalarPolynomial,
-) (*node, error) {
-
-	if !isNormalizedScalar(g2x, curve.Params().P) ||
-		!isNormalizedScalar(g2y, curve.Params().P) ||
-		!curve.IsOnCurve(g2x, g2y) {
-		return nil, InvalidCurvePointError{curve, g2x, g2y}
+	opts ...NodeOption,
+) (*Node, error) {
+	if secretPoly1 == nil {
+		// NodeConfig samples missing polynomials
+		secretPoly1 = ScalarPolynomial{}
 	}
+	return newNode(applyNodeOptions(NodeConfig{
+		Curve:       curve,
+		Hash:        hash,
+		G2X:         g2x,
+		G2Y:         g2y,
+		ZKParam:     zkParam,
+		Timeout:     timeout,
+		ID:          id,
+		Key:         key,
+		SecretPoly1: secretPoly1,
+		SecretPoly2: secretPoly2,
+	}, opts))
+}
 
-	var polyErrors []error = nil
-	polyErrors = secretPoly1.validate(curve)
-	if len(secretPoly1) != len(secretPoly2) {
-		polyErrors = append(polyErrors, InvalidScalarPolynomialLengthError{secretPoly1, secretPoly2})
+func (n *Node) PublicKeyPart() (x, y *big.Int) {
+	return n.params().baseMult(n.secretPoly1[0])
+}
+
+func (n *Node) Outbox() *Outbox {
+	return n.outbox
+}
+
+// PointTuple is a vector of curve points, such as a dealer's commitments.
+type PointTuple []struct{ X, Y *big.Int }
+
+func (n *Node) ID() *big.Int {
+	return n.id
+}
+
+func (n *Node) Threshold() int {
+	return len(n.secretPoly1) - 1
+}
+
+// VerificationPoints are the dealer's commitments to its secret
+// polynomials, computed once and cached, since they are broadcast again on
+// every retry. Zeroize drops the cache; changing the coefficients of the
+// polynomials set by WithPolynomials doesn't.
+func (n *Node) VerificationPoints() PointTuple {
+	if n.feldman {
+		return n.PublicCoefficients()
 	}
-	if polyErrors != nil {
-		return nil, InvalidCurveScalarPolynomialError{curve, secretPoly1, polyErrors}
+	c := n.commitments
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.vpts == nil {
+		// [c1 * G + c2 * G2 for c1, c2 in zip(spoly1, spoly2)]
+		params := n.params()
+		c.vpts = make(PointTuple, len(n.secretPoly1))
+		for i, c1 := range n.secretPoly1 {
+			c.vpts[i].X, c.vpts[i].Y = params.commit(c1, n.secretPoly2[i])
+		}
 	}
+	return append(PointTuple(nil), c.vpts...)
+}
 
-	polyErrors = secretPoly2.validate(curve)
-	if polyErrors != nil {
-		return nil, InvalidCurveScalarPolynomialError{curve, secretPoly2, polyErrors}
+// PublicCoefficients are the Feldman commitments [c1 * G for c1 in spoly1],
+// revealed for the public key assembly once the dealer is qualified. They
+// are cached like VerificationPoints.
+func (n *Node) PublicCoefficients() PointTuple {
+	c := n.commitments
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.coefficients == nil {
+		params := n.params()
+		c.coefficients = make(PointTuple, len(n.secretPoly1))
+		for i, c1 := range n.secretPoly1 {
+			c.coefficients[i].X, c.coefficients[i].Y = params.baseMult(c1)
+		}
 	}
-
-	return &node{
-		curve, hash, g2x, g2y, zkParam, timeout,
-		id, key, secretPoly1, secretPoly2,
-		nil, nil,
-	}, nil
+	return append(PointTuple(nil), c.coefficients...)
 }
 
-func (n *node) PublicKeyPart() (x, y *big.Int) {
-	return n.curve.ScalarBaseMult(n.secretPoly1[0].Bytes())
+// invalidateCommitments drops the cached commitments once the secret
+// polynomials changed.
+func (n *Node) invalidateCommitments() {
+	c := n.commitments
+	c.mu.Lock()
+	c.vpts, c.coefficients = nil, nil
+	c.mu.Unlock()
 }
 
-type pointTuple []struct{ X, Y *big.Int }
+// SecretShareFor returns the shares the node deals to the participant with
+// ID recipientID, the evaluations of its secret polynomials there. With
+// Feldman VSS, Share2 is zero.
+func (n *Node) SecretShareFor(recipientID *big.Int) (SecretShares, error) {
+	if !validParticipantID(recipientID, n.curve.Params().N) {
+		return SecretShares{}, InvalidParticipantIDError{recipientID}
+	}
+	return n.sharesFor(recipientID), nil
+}
 
-func (n *node) VerificationPoints() pointTuple {
-	// [c1 * G + c2 * G2 for c1, c2 in zip(spoly1, spoly2)]
-	vpts := make(pointTuple, len(n.secretPoly1))
-	for i, c1 := range n.secretPoly1 {
-		c2 := n.secretPoly2[i]
-		ax, ay := n.curve.ScalarBaseMult(c1.Bytes())
-		bx, by := n.curve.ScalarMult(n.g2x, n.g2y, c2.Bytes())
-		vpts[i].X, vpts[i].Y = n.curve.Add(ax, ay, bx, by)
+func (n *Node) sharesFor(id *big.Int) SecretShares {
+	return SecretShares{
+		n.secretPoly1.evaluate(id, n.curve.Params().N),
+		n.secretPoly2.evaluate(id, n.curve.Params().N),
+		n.curve,
 	}
-	return vpts
 }
@@ -8,9 +8,12 @@ import (
 	"encoding/base64"
 	"hash"
 	"math/big"
+	"math/rand/v2"
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/mikalv/dkg/dkgtest"
 )
 
 // import "bytes"
@@ -47,8 +50,8 @@ func getValidNodeParamsForTesting(t *testing.T) (
 	pubx, puby := curve.ScalarBaseMult(privd.Bytes())
 
 	key = ecdsa.PrivateKey{
-		ecdsa.PublicKey{curve, pubx, puby},
-		privd,
+		PublicKey: ecdsa.PublicKey{Curve: curve, X: pubx, Y: puby},
+		D:         privd,
 	}
 	secretPoly1 = ScalarPolynomial{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4)}
 	secretPoly2 = ScalarPolynomial{big.NewInt(5), big.NewInt(6), big.NewInt(7), big.NewInt(8)}
@@ -61,25 +64,15 @@ func serializePoint(curve elliptic.Curve, x, y *big.Int) string {
 
 func TestInvalidNodeConstruction(t *testing.T) {
 	curve, hash, g2x, g2y, zkParam, timeout, id, key, secretPoly1, secretPoly2 := getValidNodeParamsForTesting(t)
-	zero := big.NewInt(0)
 
 	t.Run("Invalid g2", func(t *testing.T) {
-		badPoints := []struct {
-			x, y *big.Int
-		}{
-			// identity rep can't be generator
-			{zero, zero},
-			// shouldn't allow unnormalized representations
-			{g2x, new(big.Int).Add(new(big.Int).Neg(curve.Params().P), g2y)},
-			{g2x, new(big.Int).Add(curve.Params().P, g2y)},
-			// shouldn't allow non-curve points
-			{big.NewInt(1), big.NewInt(1)},
-			{big.NewInt(31546753643215432), big.NewInt(2345436543254564)},
-		}
-
-		for _, bad := range badPoints {
+		for _, bad := range dkgtest.MaliciousPoints(curve) {
+			if bad.X == nil && bad.Y == nil {
+				// derived
+				continue
+			}
 			node, err := NewNode(
-				curve, hash, bad.x, bad.y, zkParam, timeout,
+				curve, hash, bad.X, bad.Y, zkParam, timeout,
 				id, key, secretPoly1, secretPoly2,
 			)
 			if node != nil && err == nil {
@@ -87,21 +80,21 @@ func TestInvalidNodeConstruction(t *testing.T) {
 					"Able to create node with invalid g2:\n"+
 						"curve: %v\n"+
 						"id: %T\n"+
-						"g2: %v, %v\n"+
+						"g2: %v, %v (%v)\n"+
 						"secretPoly1: %v\n"+
 						"secretPoly2: %v\n",
-					curve.Params().Name, id, bad.x, bad.y, secretPoly1, secretPoly2,
+					curve.Params().Name, id, bad.X, bad.Y, bad.Description, secretPoly1, secretPoly2,
 				)
 			} else if reflect.TypeOf(err) != reflect.TypeOf((*InvalidCurvePointError)(nil)).Elem() {
 				t.Errorf(
 					"Got unexpected error from construction with invalid g2:\n"+
 						"curve: %v\n"+
 						"id: %T\n"+
-						"g2: %x %x\n"+
+						"g2: %x %x (%v)\n"+
 						"secretPoly1: %v\n"+
 						"secretPoly2: %v\n"+
 						"%v\n",
-					curve.Params().Name, id, bad.x, bad.y, secretPoly1, secretPoly2, err,
+					curve.Params().Name, id, bad.X, bad.Y, bad.Description, secretPoly1, secretPoly2, err,
 				)
 			}
 		}
@@ -194,6 +187,224 @@ func TestValidNode(t *testing.T) {
 			if vptsb64 != "BBRPCyOypp95ucbYOZTBcfoFklBEE2Hi3aFplbHeTmth17kAicWtDqV1IW/pqP0lEvv7ryW6ChH1Tw3V9I6WZOwEUyCd5oet8nQmjgHXn7uDW4wrnH23de/fVm9aO6Te4CfrhI3o0b0KFY/E7Z+gEGtLhE3zNFOwhEM5nQC/NNr4hQSgtaBOX63vRhZF3vZS5PdwaH2gDHY2cEBz2iETYHeliziLq1WGn10XqAmdT4vOtvYuFlxWUiHpJFILbi4LpMwNBFW0kj8eA8IieBQBqaU/eHALCS1QvAVW8zOriM+ZnlhxDkE6sX8aDPoQsCZ8EjAKt9N52qKsf8+YF8tSG403rxM=" {
 				t.Errorf("Got unexpected verification points %v", vptsb64)
 			}
+			vpts[0].X = nil
+			if again := node.VerificationPoints(); again[0].X == nil || !reflect.DeepEqual(again[1:], vpts[1:]) {
+				t.Errorf("Cached verification points changed: %v", again)
+			}
+		})
+
+		t.Run("Zeroize", func(t *testing.T) {
+			node.Zeroize()
+			for _, vpt := range node.VerificationPoints() {
+				if !isIdentity(curve, vpt.X, vpt.Y) {
+					t.Errorf("Verification points outlived the polynomials: %v", vpt)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkVerificationPoints(b *testing.B) {
+	node, _ := getPrecomputedNodesForTesting(b, elliptic.P256(), 30)
+	b.Run("Recomputed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			node.invalidateCommitments()
+			node.VerificationPoints()
+		}
+	})
+	b.Run("Cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			node.VerificationPoints()
+		}
+	})
+}
+
+func TestNodeWithRandomSecrets(t *testing.T) {
+	curve, hash, g2x, g2y, zkParam, timeout, id, key, _, _ := getValidNodeParamsForTesting(t)
+
+	config := NodeConfig{Curve: curve, Hash: hash, G2X: g2x, G2Y: g2y, ZKParam: zkParam, Timeout: timeout, ID: id, Key: key, Threshold: 3}
+	node, err := NewNodeWithOptions(WithConfig(config))
+	if err != nil {
+		t.Fatalf("Could not create node with random secrets: %v", err)
+	}
+	if node.Threshold() != 3 {
+		t.Errorf("Got threshold %v, expected 3", node.Threshold())
+	}
+	for _, poly := range []ScalarPolynomial{node.secretPoly1, node.secretPoly2} {
+		if errs := poly.validate(curve); errs != nil {
+			t.Errorf("Generated invalid polynomial %v: %v", poly, errs)
+		}
+	}
+	if node.secretPoly1[0].Cmp(node.secretPoly2[0]) == 0 {
+		t.Errorf("Secret polynomials share their constant term")
+	}
+
+	config.Threshold = -1
+	if _, err := NewNodeWithOptions(WithConfig(config)); reflect.TypeOf(err) != reflect.TypeOf(InvalidThresholdError{}) {
+		t.Errorf("Got unexpected error for negative threshold: %v", err)
+	}
+}
+
+func TestNodeEntropy(t *testing.T) {
+	curve, hash, g2x, g2y, zkParam, timeout, id, key, _, _ := getValidNodeParamsForTesting(t)
+	seeded := func(seed byte) *Node {
+		config := NodeConfig{Curve: curve, Hash: hash, G2X: g2x, G2Y: g2y, ZKParam: zkParam, Timeout: timeout, ID: id, Key: key, Threshold: 2}
+		node, err := NewNodeWithOptions(WithConfig(config), WithEntropy(rand.NewChaCha8([32]byte{seed})))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return node
+	}
+	first, again, other := seeded(1), seeded(1), seeded(2)
+	if !reflect.DeepEqual(first.VerificationPoints(), again.VerificationPoints()) {
+		t.Errorf("Nodes with the same entropy have different polynomials")
+	}
+	if reflect.DeepEqual(first.VerificationPoints(), other.VerificationPoints()) {
+		t.Errorf("Nodes with different entropy have the same polynomials")
+	}
+
+	set, _ := NewParticipantSet(curve, 2, []Participant{{id, key.PublicKey}, {big.NewInt(2), key.PublicKey}, {big.NewInt(3), key.PublicKey}})
+	runner, err := NewProtocolRunner(first, set, NewMemoryNetwork().Transport(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runner.random != first.random {
+		t.Errorf("Runner doesn't default to the node's entropy")
+	}
+}
+
+func TestNodeConfig(t *testing.T) {
+	curve, hash, g2x, g2y, zkParam, timeout, id, key, secretPoly1, secretPoly2 := getValidNodeParamsForTesting(t)
+	config := NodeConfig{
+		Curve:       curve,
+		Hash:        hash,
+		G2X:         g2x,
+		G2Y:         g2y,
+		ZKParam:     zkParam,
+		Timeout:     timeout,
+		ID:          id,
+		Key:         key,
+		SecretPoly1: secretPoly1,
+		SecretPoly2: secretPoly2,
+	}
+
+	// the legacy constructors must behave exactly like the config they
+	// translate to
+	for _, c := range []struct {
+		name   string
+		legacy func() (*Node, error)
+		config func(*NodeConfig)
+		opts   []NodeOption
+	}{
+		{"Valid", func() (*Node, error) {
+			return NewNode(curve, hash, g2x, g2y, zkParam, timeout, id, key, secretPoly1, secretPoly2)
+		}, func(*NodeConfig) {}, nil},
+		{"Derived g2", func() (*Node, error) {
+			return NewNode(curve, hash, nil, nil, zkParam, timeout, id, key, secretPoly1, secretPoly2)
+		}, func(c *NodeConfig) { c.G2X, c.G2Y = nil, nil }, nil},
+		{"Invalid g2", func() (*Node, error) {
+			return NewNode(curve, hash, g2y, g2x, zkParam, timeout, id, key, secretPoly1, secretPoly2)
+		}, func(c *NodeConfig) { c.G2X, c.G2Y = g2y, g2x }, nil},
+		{"Feldman", func() (*Node, error) {
+			return NewNode(curve, hash, g2x, g2y, zkParam, timeout, id, key, secretPoly1, nil, WithFeldmanVSS())
+		}, func(c *NodeConfig) { c.SecretPoly2 = nil }, []NodeOption{WithFeldmanVSS()}},
+		{"Missing polynomial", func() (*Node, error) {
+			return NewNode(curve, hash, g2x, g2y, zkParam, timeout, id, key, nil, secretPoly2)
+		}, func(c *NodeConfig) { c.SecretPoly1 = ScalarPolynomial{} }, nil},
+		{"Mismatched polynomials", func() (*Node, error) {
+			return NewNode(curve, hash, g2x, g2y, zkParam, timeout, id, key, secretPoly1, secretPoly2[1:])
+		}, func(c *NodeConfig) { c.SecretPoly2 = secretPoly2[1:] }, nil},
+		{"Config", func() (*Node, error) {
+			return NewNodeWithOptions(WithConfig(config))
+		}, func(*NodeConfig) {}, nil},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			legacy, legacyErr := c.legacy()
+			cfg := config
+			c.config(&cfg)
+			node, err := NewNodeWithOptions(append([]NodeOption{WithConfig(cfg)}, c.opts...)...)
+			if !reflect.DeepEqual(err, legacyErr) {
+				t.Fatalf("Got error %v, legacy constructor %v", err, legacyErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(node.VerificationPoints(), legacy.VerificationPoints()) ||
+				!reflect.DeepEqual(node.PublicCoefficients(), legacy.PublicCoefficients()) ||
+				!reflect.DeepEqual(node.identity.Public(), legacy.identity.Public()) ||
+				node.feldman != legacy.feldman || node.timeout != legacy.timeout {
+				t.Errorf("Node differs from the legacy constructor's")
+			}
 		})
 	}
+
+	t.Run("Options", func(t *testing.T) {
+		node, err := NewNodeWithOptions(
+			WithCurve(curve), WithHash(hash), WithGenerator2(g2x, g2y), WithZKParam(zkParam),
+			WithTimeout(timeout), WithID(id), WithKey(key), WithPolynomials(secretPoly1, secretPoly2),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, _ := NewNodeWithOptions(WithConfig(config))
+		if !reflect.DeepEqual(node.VerificationPoints(), expected.VerificationPoints()) || node.timeout != expected.timeout {
+			t.Errorf("Node differs from the config's")
+		}
+		identity, err := NewNodeWithOptions(
+			WithCurve(curve), WithHash(hash), WithZKParam(zkParam), WithID(id), WithIdentity(SoftwareIdentity(&key)), WithThreshold(2),
+		)
+		if err != nil || identity.Threshold() != 2 || !reflect.DeepEqual(identity.identity.Public(), &key.PublicKey) {
+			t.Errorf("Got node %v (%v)", identity, err)
+		}
+		if _, err := NewNodeWithOptions(); !reflect.DeepEqual(err, MissingNodeParameterError{"Curve"}) {
+			t.Errorf("Got unexpected error without options: %v", err)
+		}
+	})
+
+	t.Run("Random secrets", func(t *testing.T) {
+		cfg := config
+		cfg.SecretPoly1, cfg.SecretPoly2, cfg.Threshold = nil, nil, 2
+		node, err := NewNodeWithOptions(WithConfig(cfg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.Threshold() != 2 || len(node.secretPoly2) != 3 {
+			t.Errorf("Got threshold %v", node.Threshold())
+		}
+		cfg.Threshold = -1
+		if _, err := NewNodeWithOptions(WithConfig(cfg)); reflect.TypeOf(err) != reflect.TypeOf(InvalidThresholdError{}) {
+			t.Errorf("Got unexpected error for negative threshold: %v", err)
+		}
+	})
+
+	t.Run("Missing parameter", func(t *testing.T) {
+		cfg := config
+		cfg.ZKParam = nil
+		if _, err := NewNodeWithOptions(WithConfig(cfg)); !reflect.DeepEqual(err, MissingNodeParameterError{"ZKParam"}) {
+			t.Errorf("Got unexpected error for a config without ZKParam: %v", err)
+		}
+		cfg = config
+		cfg.SecretPoly1 = nil
+		if _, err := NewNodeWithOptions(WithConfig(cfg)); !reflect.DeepEqual(err, MissingNodeParameterError{"SecretPoly1"}) {
+			t.Errorf("Got unexpected error for a config without SecretPoly1: %v", err)
+		}
+	})
+
+	t.Run("Conflicting parameters", func(t *testing.T) {
+		cfg := config
+		cfg.Feldman, cfg.SecretPoly2 = true, nil
+		if _, err := NewNodeWithOptions(WithConfig(cfg)); reflect.TypeOf(err) != reflect.TypeOf(ConflictingNodeParametersError{}) {
+			t.Errorf("Got unexpected error for a Feldman config with a second generator: %v", err)
+		}
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		for _, id := range []*big.Int{big.NewInt(-1), new(big.Int).Set(config.Curve.Params().N)} {
+			cfg := config
+			cfg.ID = id
+			if _, err := NewNodeWithOptions(WithConfig(cfg)); !reflect.DeepEqual(err, InvalidParticipantIDError{id}) {
+				t.Errorf("Got unexpected error for ID %v: %v", id, err)
+			}
+		}
+	})
 }
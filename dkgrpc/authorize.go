@@ -0,0 +1,153 @@
+package dkgrpc
+
+import "context"
+import "crypto/ecdsa"
+import "crypto/sha256"
+import "crypto/subtle"
+import "crypto/x509"
+import "strings"
+
+import "google.golang.org/grpc"
+import "google.golang.org/grpc/codes"
+import "google.golang.org/grpc/credentials"
+import "google.golang.org/grpc/metadata"
+import "google.golang.org/grpc/peer"
+import "google.golang.org/grpc/status"
+
+import "github.com/mikalv/dkg"
+
+// ParticipantRole is the role of callers whose certificate is for the
+// identity key of a participant.
+const ParticipantRole = "participant"
+
+// apiKeyHeader is the metadata key an API key is sent in.
+const apiKeyHeader = "x-api-key"
+
+// Authenticator grants roles to the callers of the service. Roles returns
+// the roles the credentials of the call ctx carries grant, none if it
+// carries none the authenticator checks, and an error if they're invalid.
+type Authenticator interface {
+	Roles(ctx context.Context) ([]string, error)
+}
+
+// Policy maps roles to the service methods their callers may call, by
+// name such as "GetStatus", or "*" for all of them.
+type Policy map[string][]string
+
+// permits reports whether any of roles may call method.
+func (p Policy) permits(roles []string, method string) bool {
+	for _, role := range roles {
+		for _, m := range p[role] {
+			if m == "*" || m == method {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Authorize restricts the service to callers whose roles policy permits
+// the method they call. A caller has ParticipantRole if its certificate is
+// for a participant's identity key, and the roles the authenticators of
+// chain grant it, in order; a call with credentials an authenticator
+// rejects fails. Without Authorize, participants may call every method
+// and others only GetStatus. It must be called before the transport is
+// used.
+func (t *Transport) Authorize(policy Policy, chain ...Authenticator) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.policy = policy
+	t.chain = chain
+}
+
+// authorize is the server's interceptor, checking calls against the policy
+// set with Authorize, if any.
+func (t *Transport) authorize(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	t.mu.Lock()
+	policy, chain := t.policy, t.chain
+	t.mu.Unlock()
+	if policy == nil {
+		return handler(ctx, req)
+	}
+	var roles []string
+	if _, err := t.peerID(ctx); err == nil {
+		roles = append(roles, ParticipantRole)
+	}
+	for _, a := range chain {
+		granted, err := a.Roles(ctx)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		roles = append(roles, granted...)
+	}
+	method := strings.TrimPrefix(info.FullMethod, fullMethod(""))
+	if !policy.permits(roles, method) {
+		return nil, status.Error(codes.PermissionDenied, UnauthorizedCallError{method, roles}.Error())
+	}
+	return handler(ctx, req)
+}
+
+// peerCertificate returns the leaf certificate the caller of ctx
+// authenticated with.
+func peerCertificate(ctx context.Context) (*x509.Certificate, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.PeerCertificates) == 0 {
+		return nil, false
+	}
+	return info.State.PeerCertificates[0], true
+}
+
+// CertificateRoles grants roles to callers by the dkg.Fingerprint of the
+// ECDSA key of their client certificate, such as the operators'.
+type CertificateRoles map[string][]string
+
+func (c CertificateRoles) Roles(ctx context.Context) ([]string, error) {
+	cert, ok := peerCertificate(ctx)
+	if !ok {
+		return nil, nil
+	}
+	key, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, nil
+	}
+	return c[dkg.Fingerprint(*key)], nil
+}
+
+// APIKeys grants roles to callers by the static API key they send, as set
+// by WithAPIKey. An unknown key is rejected.
+type APIKeys map[string][]string
+
+func (k APIKeys) Roles(ctx context.Context) ([]string, error) {
+	values := metadata.ValueFromIncomingContext(ctx, apiKeyHeader)
+	if len(values) == 0 {
+		return nil, nil
+	}
+	if len(values) > 1 {
+		return nil, InvalidCredentialsError{"several API keys"}
+	}
+	// compare digests, so that every comparison takes as long
+	digest := sha256.Sum256([]byte(values[0]))
+	var roles []string
+	found := 0
+	for key, granted := range k {
+		d := sha256.Sum256([]byte(key))
+		if subtle.ConstantTimeCompare(digest[:], d[:]) == 1 {
+			roles = granted
+			found = 1
+		}
+	}
+	if found == 0 {
+		return nil, InvalidCredentialsError{"unknown API key"}
+	}
+	return roles, nil
+}
+
+// WithAPIKey returns ctx, sending key with the calls made with it to a
+// service authorizing APIKeys.
+func WithAPIKey(ctx context.Context, key string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, apiKeyHeader, key)
+}
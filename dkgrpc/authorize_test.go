@@ -0,0 +1,143 @@
+package dkgrpc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/mikalv/dkg"
+)
+
+// tokenForTesting returns a JWT with claims, signed with ES256 by key.
+func tokenForTesting(t *testing.T, key *ecdsa.PrivateKey, kid string, claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "ES256", "kid": kid})
+	body, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+	digest := sha256.Sum256([]byte(input))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestAuthorize(t *testing.T) {
+	curve := elliptic.P256()
+	keys := make([]*ecdsa.PrivateKey, 4)
+	for i := range keys {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = key
+	}
+	// participants 1 and 2, an operator and an outsider
+	participants := []dkg.Participant{{ID: big.NewInt(1), Key: keys[0].PublicKey}, {ID: big.NewInt(2), Key: keys[1].PublicKey}}
+	set, err := dkg.NewParticipantSet(curve, 1, participants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configs := tlsConfigsForTesting(t, keys...)
+	transport, err := ListenTLS(big.NewInt(1), set, "127.0.0.1:0", configs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer transport.Close()
+
+	issuer, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	transport.Authorize(
+		Policy{ParticipantRole: {"*"}, "operator": {"GetStatus"}},
+		CertificateRoles{dkg.Fingerprint(keys[2].PublicKey): {"operator"}},
+		APIKeys{"secret": {"operator"}},
+		&OIDC{
+			Issuer: "https://issuer.example", Audience: "dkg",
+			Keys: map[string]crypto.PublicKey{"k1": &issuer.PublicKey},
+			Now:  func() time.Time { return now },
+		},
+	)
+	token := func(claims map[string]any) string {
+		c := map[string]any{"iss": "https://issuer.example", "aud": "dkg", "exp": now.Add(time.Minute).Unix(), "roles": []string{"operator"}}
+		for k, v := range claims {
+			c[k] = v
+		}
+		return tokenForTesting(t, issuer, "k1", c)
+	}
+
+	participant, err := Dial(transport.Addr().String(), configs[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer participant.Close()
+	operator, err := Dial(transport.Addr().String(), configs[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer operator.Close()
+	outsider, err := Dial(transport.Addr().String(), configs[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer outsider.Close()
+
+	m := dkg.Message{Type: dkg.ComplaintsMessage, From: big.NewInt(2), Payload: dkg.Complaints{}}
+	if err := submitForTesting(participant, "SubmitComplaint", m); err != nil {
+		t.Errorf("Participant's submission was rejected: %v", err)
+	}
+	if _, err := GetStatus(context.Background(), participant); err != nil {
+		t.Errorf("Participant could not get the status: %v", err)
+	}
+	if _, err := GetStatus(context.Background(), operator); err != nil {
+		t.Errorf("Operator could not get the status: %v", err)
+	}
+	m.From = big.NewInt(1)
+	expected := UnauthorizedCallError{"SubmitComplaint", []string{"operator"}}.Error()
+	if err := submitForTesting(operator, "SubmitComplaint", m); !isStatus(err, codes.PermissionDenied, expected) {
+		t.Errorf("Got unexpected error for an operator's submission: %v", err)
+	}
+
+	for _, c := range []struct {
+		name string
+		ctx  context.Context
+		code codes.Code
+	}{
+		{"No credentials", context.Background(), codes.PermissionDenied},
+		{"API key", WithAPIKey(context.Background(), "secret"), codes.OK},
+		{"Unknown API key", WithAPIKey(context.Background(), "guess"), codes.Unauthenticated},
+		{"Token", WithBearerToken(context.Background(), token(nil)), codes.OK},
+		{"Token without roles", WithBearerToken(context.Background(), token(map[string]any{"roles": nil})), codes.PermissionDenied},
+		{"Expired token", WithBearerToken(context.Background(), token(map[string]any{"exp": now.Unix()})), codes.Unauthenticated},
+		{"Other audience", WithBearerToken(context.Background(), token(map[string]any{"aud": []string{"other"}})), codes.Unauthenticated},
+		{"Other issuer", WithBearerToken(context.Background(), token(map[string]any{"iss": "https://other.example"})), codes.Unauthenticated},
+		{"Forged token", WithBearerToken(context.Background(), tokenForTesting(t, keys[3], "k1", map[string]any{
+			"iss": "https://issuer.example", "aud": "dkg", "exp": now.Add(time.Minute).Unix(), "roles": []string{"operator"},
+		})), codes.Unauthenticated},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := GetStatus(c.ctx, outsider)
+			if code := status.Code(err); code != c.code {
+				t.Errorf("Got %v (%v), expected %v", code, err, c.code)
+			}
+		})
+	}
+}
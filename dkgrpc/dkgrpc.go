@@ -0,0 +1,470 @@
+// Package dkgrpc runs DKG participants as network daemons, exchanging
+// ceremony messages through a gRPC service over TLS.
+//
+// The service is defined on protobuf's well-known types, so that clients
+// need no generated code:
+//
+//	service DKG {
+//	  rpc SubmitShare(google.protobuf.BytesValue) returns (google.protobuf.Empty);
+//	  rpc SubmitCommitments(google.protobuf.BytesValue) returns (google.protobuf.Empty);
+//	  rpc SubmitComplaint(google.protobuf.BytesValue) returns (google.protobuf.Empty);
+//	  rpc SubmitHello(google.protobuf.BytesValue) returns (google.protobuf.Empty);
+//	  rpc SubmitRetry(google.protobuf.BytesValue) returns (google.protobuf.Empty);
+//	  rpc GetStatus(google.protobuf.Empty) returns (google.protobuf.Struct);
+//	}
+//
+// in package dkg. Its methods accept:
+//
+//   - SubmitShare: encrypted secret shares
+//   - SubmitCommitments: verification points, knowledge proofs and public
+//     coefficients
+//   - SubmitComplaint: complaints, justifications, qualified sets and the
+//     shares revealed to contest or reconstruct public coefficients
+//   - SubmitHello: the hellos measuring round trip times before the
+//     ceremony
+//   - SubmitRetry: requests to send the messages of a phase again
+//   - GetStatus: the daemon's ID and the phase of its ceremony, as the
+//     string fields "id" and "phase", and the bytes it exchanged with each
+//     peer by phase, as the list "traffic" of structs with the string
+//     fields "peer" and "phase" and the number fields "sent" and
+//     "received"
+//
+// Submissions carry a message in its dkg binary encoding. Peers
+// authenticate with certificates for their identity keys: a submission is
+// only accepted from the participant it names as its sender, and a daemon
+// only sends to a peer whose certificate is for that peer's identity key.
+// Transport.Authorize further restricts the methods to the roles an
+// Authenticator chain grants the callers, such as operators by their
+// client certificates, OIDC tokens or API keys.
+package dkgrpc
+
+import "context"
+import "crypto/ecdsa"
+import "crypto/tls"
+import "math/big"
+import "net"
+import "sync"
+import "time"
+
+import "google.golang.org/grpc"
+import "google.golang.org/grpc/codes"
+import "google.golang.org/grpc/credentials"
+import "google.golang.org/grpc/status"
+import "google.golang.org/protobuf/types/known/emptypb"
+import "google.golang.org/protobuf/types/known/structpb"
+import "google.golang.org/protobuf/types/known/wrapperspb"
+
+import "github.com/mikalv/dkg"
+
+type Status struct {
+	ID      string
+	Phase   string
+	Traffic []Traffic
+}
+
+// Traffic is the bytes a daemon exchanged with a peer in a phase.
+type Traffic struct {
+	Peer     string
+	Phase    string
+	Sent     uint64
+	Received uint64
+}
+
+// methods maps message types to the service method accepting them.
+var methods = map[dkg.MessageType]string{
+	dkg.VerificationPointsMessage:    "SubmitCommitments",
+	dkg.SecretKnowledgeMessage:       "SubmitCommitments",
+	dkg.PublicCoefficientsMessage:    "SubmitCommitments",
+	dkg.SecretSharesMessage:          "SubmitShare",
+	dkg.ComplaintsMessage:            "SubmitComplaint",
+	dkg.JustificationMessage:         "SubmitComplaint",
+	dkg.HelloMessage:                 "SubmitHello",
+	dkg.RetryMessage:                 "SubmitRetry",
+	dkg.QualifiedSetMessage:          "SubmitComplaint",
+	dkg.CoefficientComplaintsMessage: "SubmitComplaint",
+	dkg.ReconstructionMessage:        "SubmitComplaint",
+}
+
+const (
+	// handshakeTimeout bounds the TLS handshake of an inbound connection.
+	handshakeTimeout = 10 * time.Second
+	// dialTimeout bounds connecting to a peer.
+	dialTimeout = 5 * time.Second
+	// callTimeout bounds a submission to a peer, connecting included.
+	callTimeout = 10 * time.Second
+)
+
+// server is what the service's handlers call.
+type server interface {
+	submit(ctx context.Context, method string, in *wrapperspb.BytesValue) (*emptypb.Empty, error)
+	getStatus(ctx context.Context, in *emptypb.Empty) (*structpb.Struct, error)
+}
+
+const serviceName = "dkg.DKG"
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*server)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "SubmitShare", Handler: submitHandler("SubmitShare")},
+		{MethodName: "SubmitCommitments", Handler: submitHandler("SubmitCommitments")},
+		{MethodName: "SubmitComplaint", Handler: submitHandler("SubmitComplaint")},
+		{MethodName: "SubmitHello", Handler: submitHandler("SubmitHello")},
+		{MethodName: "SubmitRetry", Handler: submitHandler("SubmitRetry")},
+		{MethodName: "GetStatus", Handler: getStatusHandler},
+	},
+	Metadata: "dkg.proto",
+}
+
+func fullMethod(method string) string {
+	return "/" + serviceName + "/" + method
+}
+
+func submitHandler(method string) grpc.MethodHandler {
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		in := new(wrapperspb.BytesValue)
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		handle := func(ctx context.Context, req any) (any, error) {
+			return srv.(server).submit(ctx, method, req.(*wrapperspb.BytesValue))
+		}
+		if interceptor == nil {
+			return handle(ctx, in)
+		}
+		return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod(method)}, handle)
+	}
+}
+
+func getStatusHandler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	handle := func(ctx context.Context, req any) (any, error) {
+		return srv.(server).getStatus(ctx, req.(*emptypb.Empty))
+	}
+	if interceptor == nil {
+		return handle(ctx, in)
+	}
+	return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod("GetStatus")}, handle)
+}
+
+// Transport is a dkg.Transport serving the DKG service to its peers and
+// calling theirs. The TLS configuration must verify client certificates;
+// a peer is the participant whose identity key its certificate carries,
+// and the messages it submits must name that participant as their sender.
+// Peers without such a certificate may only query the status.
+type Transport struct {
+	id           *big.Int
+	participants *dkg.ParticipantSet
+	listener     net.Listener
+	server       *grpc.Server
+	config       *tls.Config
+	inbox        chan dkg.Message
+	done         chan struct{}
+	once         sync.Once
+
+	mu      sync.Mutex
+	peers   map[string]string
+	clients map[string]*grpc.ClientConn
+	runner  *dkg.ProtocolRunner
+	queue   []dkg.Message
+	wake    chan struct{}
+	policy  Policy
+	chain   []Authenticator
+}
+
+// ListenTLS starts serving the DKG service for participant id of
+// participants on addr. config is used both for accepting connections and
+// for dialing peers, and its certificate must be for the identity key of
+// id.
+func ListenTLS(id *big.Int, participants *dkg.ParticipantSet, addr string, config *tls.Config, peers ...dkg.Peer) (*Transport, error) {
+	if _, ok := participants.Participant(id); !ok {
+		return nil, UnknownPeerError{id}
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	t := &Transport{
+		id:           new(big.Int).Set(id),
+		participants: participants,
+		listener:     listener,
+		config:       config,
+		inbox:        make(chan dkg.Message),
+		done:         make(chan struct{}),
+		peers:        make(map[string]string),
+		clients:      make(map[string]*grpc.ClientConn),
+		wake:         make(chan struct{}, 1),
+	}
+	t.server = grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(config)), grpc.ConnectionTimeout(handshakeTimeout),
+		grpc.UnaryInterceptor(t.authorize),
+	)
+	t.server.RegisterService(&serviceDesc, t)
+	for _, peer := range peers {
+		t.AddPeer(peer)
+	}
+	go t.server.Serve(listener)
+	go t.deliver()
+	return t, nil
+}
+
+// peerID returns the ID of the participant whose identity key is in the
+// certificate the caller of ctx authenticated with.
+func (t *Transport) peerID(ctx context.Context) (*big.Int, error) {
+	cert, ok := peerCertificate(ctx)
+	if !ok {
+		return nil, UnauthenticatedPeerError{}
+	}
+	for _, p := range t.participants.Participants() {
+		if certifiesKey(cert.PublicKey, p.Key) {
+			return p.ID, nil
+		}
+	}
+	return nil, UnauthenticatedPeerError{}
+}
+
+// certifiesKey reports whether a certificate's public key is key.
+func certifiesKey(public any, key ecdsa.PublicKey) bool {
+	certified, ok := public.(*ecdsa.PublicKey)
+	return ok && key.Curve != nil && certified.Curve.Params().Name == key.Curve.Params().Name &&
+		certified.X.Cmp(key.X) == 0 && certified.Y.Cmp(key.Y) == 0
+}
+
+func (t *Transport) Addr() net.Addr {
+	return t.listener.Addr()
+}
+
+func (t *Transport) AddPeer(peer dkg.Peer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.peers[peer.ID.String()] = peer.Addr
+}
+
+// SetRunner makes GetStatus report runner's phase.
+func (t *Transport) SetRunner(runner *dkg.ProtocolRunner) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.runner = runner
+}
+
+func (t *Transport) getStatus(ctx context.Context, in *emptypb.Empty) (*structpb.Struct, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	phase := dkg.PhaseIdle
+	traffic := []any{}
+	if t.runner != nil {
+		phase = t.runner.Phase()
+		for _, c := range t.runner.Traffic() {
+			traffic = append(traffic, map[string]any{
+				"peer":     c.Peer.String(),
+				"phase":    c.Phase.String(),
+				"sent":     c.Sent,
+				"received": c.Received,
+			})
+		}
+	}
+	return structpb.NewStruct(map[string]any{"id": t.id.String(), "phase": phase.String(), "traffic": traffic})
+}
+
+// submit queues a message received from the authenticated caller of ctx,
+// if it arrived through the method for its type and names the caller as
+// its sender.
+func (t *Transport) submit(ctx context.Context, method string, in *wrapperspb.BytesValue) (*emptypb.Empty, error) {
+	from, err := t.peerID(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	var m dkg.Message
+	if err := m.UnmarshalBinary(in.GetValue()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if methods[m.Type] != method {
+		return nil, status.Error(codes.InvalidArgument, UnexpectedMethodError{method, m.Type}.Error())
+	}
+	if p, ok := t.participants.Participant(m.From); m.From == nil || !ok || p.ID.Cmp(from) != 0 {
+		return nil, status.Error(codes.PermissionDenied, SenderMismatchError{from, m.From}.Error())
+	}
+	t.mu.Lock()
+	t.queue = append(t.queue, m)
+	t.mu.Unlock()
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// deliver hands queued messages to Receive, so that submissions never block
+// on a slow receiver.
+func (t *Transport) deliver() {
+	defer close(t.inbox)
+	for {
+		t.mu.Lock()
+		queue := t.queue
+		t.queue = nil
+		t.mu.Unlock()
+		for _, m := range queue {
+			select {
+			case t.inbox <- m:
+			case <-t.done:
+				return
+			}
+		}
+		select {
+		case <-t.wake:
+		case <-t.done:
+			return
+		}
+	}
+}
+
+// client returns the connection to participant to, creating it outside
+// the lock: the connection only dials on its first call, and only trusts a
+// certificate for to's identity key.
+func (t *Transport) client(to *big.Int) (*grpc.ClientConn, error) {
+	key := to.String()
+	t.mu.Lock()
+	c, ok := t.clients[key]
+	addr, known := t.peers[key]
+	t.mu.Unlock()
+	if ok {
+		return c, nil
+	}
+	p, ok := t.participants.Participant(to)
+	if !known || !ok {
+		return nil, UnknownPeerError{to}
+	}
+	c, err := dial(addr, pinned(t.config, p.Key))
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if existing, ok := t.clients[key]; ok {
+		c.Close()
+		return existing, nil
+	}
+	t.clients[key] = c
+	return c, nil
+}
+
+// pinned returns config, only accepting a server certificate for key.
+func pinned(config *tls.Config, key ecdsa.PublicKey) *tls.Config {
+	config = config.Clone()
+	verify := config.VerifyConnection
+	config.VerifyConnection = func(cs tls.ConnectionState) error {
+		if verify != nil {
+			if err := verify(cs); err != nil {
+				return err
+			}
+		}
+		if len(cs.PeerCertificates) == 0 || !certifiesKey(cs.PeerCertificates[0].PublicKey, key) {
+			return UnauthenticatedPeerError{}
+		}
+		return nil
+	}
+	return config
+}
+
+func (t *Transport) Send(to *big.Int, m dkg.Message) error {
+	method, ok := methods[m.Type]
+	if !ok {
+		return UnexpectedMethodError{"", m.Type}
+	}
+	b, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	c, err := t.client(to)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	return c.Invoke(ctx, fullMethod(method), wrapperspb.Bytes(b), &emptypb.Empty{})
+}
+
+// Broadcast sends m to every peer concurrently, so that an unreachable
+// peer doesn't hold up the others, and returns the first error.
+func (t *Transport) Broadcast(m dkg.Message) error {
+	t.mu.Lock()
+	ids := make([]*big.Int, 0, len(t.peers))
+	for key := range t.peers {
+		id, _ := new(big.Int).SetString(key, 10)
+		if id.Cmp(t.id) != 0 {
+			ids = append(ids, id)
+		}
+	}
+	t.mu.Unlock()
+
+	errs := make([]error, len(ids))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id *big.Int) {
+			defer wg.Done()
+			errs[i] = t.Send(id, m)
+		}(i, id)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Transport) Receive() <-chan dkg.Message {
+	return t.inbox
+}
+
+func (t *Transport) Close() error {
+	t.server.Stop()
+	t.mu.Lock()
+	for key, c := range t.clients {
+		c.Close()
+		delete(t.clients, key)
+	}
+	t.mu.Unlock()
+	t.once.Do(func() { close(t.done) })
+	return nil
+}
+
+// Dial returns a connection to the DKG service at addr, for instance to
+// query its status. It connects on the first call.
+func Dial(addr string, config *tls.Config) (*grpc.ClientConn, error) {
+	return dial(addr, config)
+}
+
+func dial(addr string, config *tls.Config) (*grpc.ClientConn, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	return grpc.NewClient(addr,
+		grpc.WithTransportCredentials(credentials.NewTLS(config)),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp", addr)
+		}))
+}
+
+// GetStatus queries the status of the daemon conn is connected to.
+func GetStatus(ctx context.Context, conn grpc.ClientConnInterface) (Status, error) {
+	out := new(structpb.Struct)
+	if err := conn.Invoke(ctx, fullMethod("GetStatus"), &emptypb.Empty{}, out); err != nil {
+		return Status{}, err
+	}
+	fields := out.GetFields()
+	var traffic []Traffic
+	for _, v := range fields["traffic"].GetListValue().GetValues() {
+		c := v.GetStructValue().GetFields()
+		traffic = append(traffic, Traffic{
+			c["peer"].GetStringValue(),
+			c["phase"].GetStringValue(),
+			uint64(c["sent"].GetNumberValue()),
+			uint64(c["received"].GetNumberValue()),
+		})
+	}
+	return Status{fields["id"].GetStringValue(), fields["phase"].GetStringValue(), traffic}, nil
+}
@@ -0,0 +1,305 @@
+package dkgrpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/mikalv/dkg"
+)
+
+// submitForTesting submits m through method of the service conn is
+// connected to.
+func submitForTesting(conn *grpc.ClientConn, method string, m dkg.Message) error {
+	b, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return conn.Invoke(context.Background(), fullMethod(method), wrapperspb.Bytes(b), &emptypb.Empty{})
+}
+
+// isStatus reports whether err is a gRPC status with code and message.
+func isStatus(err error, code codes.Code, message string) bool {
+	s, ok := status.FromError(err)
+	return err != nil && ok && s.Code() == code && s.Message() == message
+}
+
+// tlsConfigsForTesting returns a TLS configuration for each of keys, with
+// a certificate for the key issued by a common CA.
+func tlsConfigsForTesting(t *testing.T, keys ...*ecdsa.PrivateKey) []*tls.Config {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "dkgrpc test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, template, template, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	configs := make([]*tls.Config, len(keys))
+	for i, key := range keys {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(int64(i + 2)),
+			Subject:      pkix.Name{CommonName: "dkgrpc test"},
+			IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		configs[i] = &tls.Config{
+			Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+			RootCAs:      pool,
+			ClientCAs:    pool,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		}
+	}
+	return configs
+}
+
+func TestCeremonyOverRPC(t *testing.T) {
+	const size, threshold = 3, 1
+	curve := elliptic.P256()
+	g2x, g2y := curve.ScalarBaseMult(big.NewInt(0x1234567).Bytes())
+
+	keys := make([]*ecdsa.PrivateKey, size)
+	nodes := make([]*dkg.Node, size)
+	participants := make([]dkg.Participant, size)
+	for i := range nodes {
+		id := big.NewInt(int64(i + 1))
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes[i], err = dkg.NewNodeWithOptions(
+			dkg.WithCurve(curve), dkg.WithHash(sha512.New512_256()), dkg.WithGenerator2(g2x, g2y),
+			dkg.WithZKParam(big.NewInt(42)), dkg.WithTimeout(2*time.Second), dkg.WithID(id), dkg.WithKey(*key),
+			dkg.WithThreshold(threshold),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = key
+		participants[i] = dkg.Participant{ID: id, Key: key.PublicKey}
+	}
+	set, err := dkg.NewParticipantSet(curve, threshold, participants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configs := tlsConfigsForTesting(t, keys...)
+	transports := make([]*Transport, size)
+	for i, node := range nodes {
+		if transports[i], err = ListenTLS(node.ID(), set, "127.0.0.1:0", configs[i]); err != nil {
+			t.Fatal(err)
+		}
+		defer transports[i].Close()
+	}
+	for _, transport := range transports {
+		for i, peer := range transports {
+			transport.AddPeer(dkg.Peer{ID: nodes[i].ID(), Addr: peer.Addr().String()})
+		}
+	}
+
+	runners := make([]*dkg.ProtocolRunner, size)
+	for i, node := range nodes {
+		if runners[i], err = dkg.NewProtocolRunner(node, set, transports[i]); err != nil {
+			t.Fatal(err)
+		}
+		transports[i].SetRunner(runners[i])
+	}
+	var wg sync.WaitGroup
+	for _, runner := range runners {
+		wg.Add(1)
+		go func(r *dkg.ProtocolRunner) {
+			defer wg.Done()
+			r.Run()
+		}(runner)
+	}
+	wg.Wait()
+
+	var first *dkg.KeyShare
+	for i, runner := range runners {
+		result, err := runner.Result()
+		if err != nil {
+			t.Fatalf("Node %v did not finish: %v", nodes[i].ID(), err)
+		}
+		if len(result.Qualified) != size {
+			t.Errorf("Node %v qualified %v", nodes[i].ID(), result.Qualified)
+		}
+		if first == nil {
+			first = result
+		} else if result.PublicKey.X.Cmp(first.PublicKey.X) != 0 {
+			t.Errorf("Nodes %v and %v disagree on the group key", first.ID, result.ID)
+		}
+	}
+
+	// connected as participant 2
+	client, err := Dial(transports[0].Addr().String(), configs[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	got, err := GetStatus(context.Background(), client)
+	if err != nil || got.ID != "1" || got.Phase != dkg.PhaseFinished.String() {
+		t.Errorf("Got unexpected status %+v (%v)", got, err)
+	}
+	if len(got.Traffic) != len(runners[0].Traffic()) {
+		t.Errorf("Got traffic %+v, expected %+v", got.Traffic, runners[0].Traffic())
+	}
+	for _, c := range got.Traffic {
+		if c.Peer == "2" && c.Phase == dkg.PhaseDealing.String() && (c.Sent == 0 || c.Received == 0) {
+			t.Errorf("Got no dealing traffic with participant 2: %+v", c)
+		}
+	}
+
+	// messages must arrive through the method for their type
+	m := dkg.Message{Type: dkg.ComplaintsMessage, From: big.NewInt(2), Payload: dkg.Complaints{}}
+	if err := submitForTesting(client, "SubmitShare", m); !isStatus(err, codes.InvalidArgument, UnexpectedMethodError{"SubmitShare", dkg.ComplaintsMessage}.Error()) {
+		t.Errorf("Got unexpected error for complaints submitted as a share: %v", err)
+	}
+	if err := submitForTesting(client, "SubmitComplaint", m); err != nil {
+		t.Errorf("Complaints of participant 2 were rejected: %v", err)
+	}
+}
+
+func TestForgedSender(t *testing.T) {
+	curve := elliptic.P256()
+	keys := make([]*ecdsa.PrivateKey, 3)
+	participants := make([]dkg.Participant, len(keys))
+	for i := range keys {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = key
+		participants[i] = dkg.Participant{ID: big.NewInt(int64(i + 1)), Key: key.PublicKey}
+	}
+	set, err := dkg.NewParticipantSet(curve, 1, participants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outsider, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configs := tlsConfigsForTesting(t, append(keys, outsider)...)
+	transport, err := ListenTLS(big.NewInt(1), set, "127.0.0.1:0", configs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer transport.Close()
+
+	forged := dkg.Message{Type: dkg.ComplaintsMessage, From: big.NewInt(3), Payload: dkg.Complaints{}}
+	// participant 2 submits a message claiming to be from participant 3
+	client, err := Dial(transport.Addr().String(), configs[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	expected := SenderMismatchError{big.NewInt(2), big.NewInt(3)}.Error()
+	if err := submitForTesting(client, "SubmitComplaint", forged); !isStatus(err, codes.PermissionDenied, expected) {
+		t.Errorf("Got unexpected error for a forged sender: %v", err)
+	}
+
+	// a peer whose certificate is for no participant's identity key may
+	// only query the status
+	outside, err := Dial(transport.Addr().String(), configs[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer outside.Close()
+	if _, err := GetStatus(context.Background(), outside); err != nil {
+		t.Errorf("Could not get the status as an outsider: %v", err)
+	}
+	if err := submitForTesting(outside, "SubmitComplaint", forged); !isStatus(err, codes.Unauthenticated, UnauthenticatedPeerError{}.Error()) {
+		t.Errorf("Got unexpected error for an outsider's submission: %v", err)
+	}
+	select {
+	case m := <-transport.Receive():
+		t.Errorf("Received %+v", m)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestPinnedPeer(t *testing.T) {
+	curve := elliptic.P256()
+	keys := make([]*ecdsa.PrivateKey, 3)
+	participants := make([]dkg.Participant, len(keys))
+	for i := range keys {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = key
+		participants[i] = dkg.Participant{ID: big.NewInt(int64(i + 1)), Key: key.PublicKey}
+	}
+	set, err := dkg.NewParticipantSet(curve, 1, participants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configs := tlsConfigsForTesting(t, keys...)
+	transports := make([]*Transport, len(keys))
+	for i := range transports {
+		if transports[i], err = ListenTLS(big.NewInt(int64(i+1)), set, "127.0.0.1:0", configs[i]); err != nil {
+			t.Fatal(err)
+		}
+		defer transports[i].Close()
+	}
+	// participant 1 believes participant 2 listens where participant 3
+	// does, whose certificate is issued by the same CA
+	transports[0].AddPeer(dkg.Peer{ID: big.NewInt(2), Addr: transports[2].Addr().String()})
+	transports[0].AddPeer(dkg.Peer{ID: big.NewInt(3), Addr: transports[2].Addr().String()})
+
+	m := dkg.Message{Type: dkg.ComplaintsMessage, From: big.NewInt(1), Payload: dkg.Complaints{}}
+	if err := transports[0].Send(big.NewInt(2), m); err == nil {
+		t.Error("Sent to participant 2 through participant 3's certificate")
+	}
+	if err := transports[0].Send(big.NewInt(3), m); err != nil {
+		t.Errorf("Could not send to participant 3: %v", err)
+	}
+	select {
+	case received := <-transports[2].Receive():
+		if received.From.Cmp(big.NewInt(1)) != 0 {
+			t.Errorf("Received %+v", received)
+		}
+	case <-time.After(time.Second):
+		t.Error("Participant 3 received nothing")
+	}
+	select {
+	case received := <-transports[2].Receive():
+		t.Errorf("Received %+v", received)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
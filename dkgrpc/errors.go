@@ -0,0 +1,62 @@
+package dkgrpc
+
+import "fmt"
+import "math/big"
+
+import "github.com/mikalv/dkg"
+
+type UnexpectedMethodError struct {
+	method string
+	mType  dkg.MessageType
+}
+
+func (e UnexpectedMethodError) Error() string {
+	return fmt.Sprintf("dkgrpc: %v message submitted to %q", e.mType, e.method)
+}
+
+type UnknownPeerError struct {
+	id *big.Int
+}
+
+func (e UnknownPeerError) Error() string {
+	return fmt.Sprintf("dkgrpc: unknown peer %v", e.id)
+}
+
+// UnauthenticatedPeerError is returned for submissions over a connection
+// whose peer certificate isn't for a participant's identity key.
+type UnauthenticatedPeerError struct{}
+
+func (e UnauthenticatedPeerError) Error() string {
+	return "dkgrpc: peer is not an authenticated participant"
+}
+
+// SenderMismatchError is returned for a submission whose sender isn't the
+// participant the connection authenticated.
+type SenderMismatchError struct {
+	peer, from *big.Int
+}
+
+func (e SenderMismatchError) Error() string {
+	return fmt.Sprintf("dkgrpc: participant %v submitted a message from %v", e.peer, e.from)
+}
+
+// InvalidCredentialsError is returned for a call whose credentials an
+// Authenticator rejects.
+type InvalidCredentialsError struct {
+	reason string
+}
+
+func (e InvalidCredentialsError) Error() string {
+	return "dkgrpc: invalid credentials: " + e.reason
+}
+
+// UnauthorizedCallError is returned for a call of a method the policy
+// doesn't permit any of the caller's roles.
+type UnauthorizedCallError struct {
+	method string
+	roles  []string
+}
+
+func (e UnauthorizedCallError) Error() string {
+	return fmt.Sprintf("dkgrpc: roles %q may not call %v", e.roles, e.method)
+}
@@ -0,0 +1,161 @@
+package dkgrpc
+
+import "context"
+import "crypto"
+import "crypto/ecdsa"
+import "crypto/ed25519"
+import "crypto/rsa"
+import "crypto/sha256"
+import "encoding/base64"
+import "encoding/json"
+import "math/big"
+import "strings"
+import "time"
+
+import "google.golang.org/grpc/metadata"
+
+// OIDC grants roles to callers by the OpenID Connect ID token they send as
+// a bearer token, as set by WithBearerToken. The token must be a JWT
+// signed with RS256, ES256 or EdDSA by one of Keys, by key ID, as
+// published in the issuer's JWKS; issued by Issuer for Audience; and
+// valid at the time. Its RolesClaim, "roles" if empty, lists the roles it
+// grants. Fetching the keys is left to the caller, who must update them
+// when the issuer rotates its keys.
+type OIDC struct {
+	Issuer     string
+	Audience   string
+	Keys       map[string]crypto.PublicKey
+	RolesClaim string
+	// the current time, time.Now if nil
+	Now func() time.Time
+}
+
+// jwtHeader is the part of a JWT header OIDC checks.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+func (o *OIDC) Roles(ctx context.Context) ([]string, error) {
+	values := metadata.ValueFromIncomingContext(ctx, "authorization")
+	if len(values) == 0 {
+		return nil, nil
+	}
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if len(values) > 1 || !ok {
+		return nil, InvalidCredentialsError{"malformed authorization"}
+	}
+	claims, err := o.verify(token)
+	if err != nil {
+		return nil, err
+	}
+	claim := o.RolesClaim
+	if claim == "" {
+		claim = "roles"
+	}
+	var roles []string
+	if raw, ok := claims[claim]; ok && json.Unmarshal(raw, &roles) != nil {
+		return nil, InvalidCredentialsError{"malformed " + claim + " claim"}
+	}
+	return roles, nil
+}
+
+// verify checks token and returns its claims.
+func (o *OIDC) verify(token string) (map[string]json.RawMessage, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, InvalidCredentialsError{"malformed token"}
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, InvalidCredentialsError{"malformed token"}
+	}
+	key, ok := o.Keys[header.Kid]
+	if !ok {
+		return nil, InvalidCredentialsError{"unknown key " + header.Kid}
+	}
+	if !verifyJWS(header.Alg, key, []byte(parts[0]+"."+parts[1]), sig) {
+		return nil, InvalidCredentialsError{"invalid token signature"}
+	}
+
+	var claims map[string]json.RawMessage
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	var iss string
+	var exp, nbf float64
+	if json.Unmarshal(claims["iss"], &iss) != nil || iss != o.Issuer {
+		return nil, InvalidCredentialsError{"token not issued by " + o.Issuer}
+	}
+	if !hasAudience(claims["aud"], o.Audience) {
+		return nil, InvalidCredentialsError{"token not issued for " + o.Audience}
+	}
+	now := time.Now
+	if o.Now != nil {
+		now = o.Now
+	}
+	t := float64(now().Unix())
+	if json.Unmarshal(claims["exp"], &exp) != nil || t >= exp {
+		return nil, InvalidCredentialsError{"token expired"}
+	}
+	if raw, ok := claims["nbf"]; ok && (json.Unmarshal(raw, &nbf) != nil || t < nbf) {
+		return nil, InvalidCredentialsError{"token not yet valid"}
+	}
+	return claims, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil || json.Unmarshal(b, v) != nil {
+		return InvalidCredentialsError{"malformed token"}
+	}
+	return nil
+}
+
+// hasAudience reports whether the aud claim, a string or a list of them,
+// includes audience.
+func hasAudience(aud json.RawMessage, audience string) bool {
+	var one string
+	if json.Unmarshal(aud, &one) == nil {
+		return one == audience
+	}
+	var many []string
+	if json.Unmarshal(aud, &many) != nil {
+		return false
+	}
+	for _, a := range many {
+		if a == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyJWS reports whether sig is a signature of input with alg by key.
+func verifyJWS(alg string, key crypto.PublicKey, input, sig []byte) bool {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(input)
+		return alg == "RS256" && rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(input)
+		if alg != "ES256" || key.Curve.Params().Name != "P-256" || len(sig) != 64 {
+			return false
+		}
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(key, digest[:], r, s)
+	case ed25519.PublicKey:
+		return alg == "EdDSA" && ed25519.Verify(key, input, sig)
+	}
+	return false
+}
+
+// WithBearerToken returns ctx, sending token with the calls made with it
+// to a service authorizing OIDC.
+func WithBearerToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
@@ -0,0 +1,76 @@
+// Package dkgtest provides adversarial inputs for testing code built on
+// package dkg.
+package dkgtest
+
+import "crypto/elliptic"
+import "math/big"
+
+type MaliciousPoint struct {
+	X, Y        *big.Int
+	Description string
+}
+
+// MaliciousPoints returns points every validating consumer of curve must
+// reject: missing and identity encodings, unnormalized coordinates, points
+// off the curve, and points on the quadratic twist and on other curves
+// sharing the same a coefficient. The twist and invalid-curve generators
+// assume the short Weierstrass form y^2 = x^3 - 3x + b of crypto/elliptic.
+func MaliciousPoints(curve elliptic.Curve) []MaliciousPoint {
+	params := curve.Params()
+	p, gx, gy := params.P, params.Gx, params.Gy
+
+	points := []MaliciousPoint{
+		{nil, nil, "missing coordinates"},
+		{new(big.Int), new(big.Int), "identity"},
+		{gx, new(big.Int).Sub(gy, p), "y coordinate below zero"},
+		{gx, new(big.Int).Add(gy, p), "y coordinate not reduced mod p"},
+		{new(big.Int).Add(gx, p), gy, "x coordinate not reduced mod p"},
+		{gx, new(big.Int).Mod(new(big.Int).Add(gy, big.NewInt(1)), p), "off the curve"},
+		{big.NewInt(1), big.NewInt(1), "small coordinates off the curve"},
+	}
+
+	// (x, y) with y^2 = x^3 - 3x + b' for b' != b is on another curve with the
+	// same addition formulas
+	x := big.NewInt(2)
+	y := big.NewInt(3)
+	if !curve.IsOnCurve(x, y) {
+		points = append(points, MaliciousPoint{x, y, "point on an invalid curve"})
+	}
+
+	if x, y := twistPoint(params); x != nil && !curve.IsOnCurve(x, y) {
+		points = append(points, MaliciousPoint{x, y, "point on the quadratic twist"})
+	}
+	return points
+}
+
+func rhs(params *elliptic.CurveParams, x *big.Int) *big.Int {
+	x3 := new(big.Int).Exp(x, big.NewInt(3), params.P)
+	x3.Sub(x3, new(big.Int).Mul(big.NewInt(3), x))
+	x3.Add(x3, params.B)
+	return x3.Mod(x3, params.P)
+}
+
+// twistPoint finds a point of d*y^2 = x^3 - 3x + b for a quadratic
+// non-residue d.
+func twistPoint(params *elliptic.CurveParams) (x, y *big.Int) {
+	p := params.P
+	d := big.NewInt(2)
+	for big.Jacobi(d, p) != -1 {
+		d.Add(d, one)
+	}
+	dinv := new(big.Int).ModInverse(d, p)
+
+	for x := big.NewInt(1); x.Cmp(big.NewInt(1000)) < 0; x.Add(x, one) {
+		r := rhs(params, x)
+		if big.Jacobi(r, p) != -1 {
+			continue
+		}
+		y2 := new(big.Int).Mul(r, dinv)
+		if y := new(big.Int).ModSqrt(y2.Mod(y2, p), p); y != nil {
+			return x, y
+		}
+	}
+	return nil, nil
+}
+
+var one = big.NewInt(1)
@@ -0,0 +1,26 @@
+package dkgtest
+
+import (
+	"crypto/elliptic"
+	"testing"
+)
+
+func TestMaliciousPoints(t *testing.T) {
+	for _, curve := range []elliptic.Curve{elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		points := MaliciousPoints(curve)
+		descriptions := make(map[string]bool)
+		for _, pt := range points {
+			descriptions[pt.Description] = true
+			if pt.X != nil && pt.X.Sign() >= 0 && pt.X.Cmp(curve.Params().P) < 0 &&
+				pt.Y.Sign() >= 0 && pt.Y.Cmp(curve.Params().P) < 0 &&
+				(pt.X.Sign() != 0 || pt.Y.Sign() != 0) && curve.IsOnCurve(pt.X, pt.Y) {
+				t.Errorf("%v: %v is a valid point", curve.Params().Name, pt.Description)
+			}
+		}
+		for _, expected := range []string{"point on the quadratic twist", "point on an invalid curve"} {
+			if !descriptions[expected] {
+				t.Errorf("%v: missing %v", curve.Params().Name, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,340 @@
+// Package dkgtransit serves threshold signing keys behind an HTTP API
+// compatible with the sign, verify and key read endpoints of Vault's transit
+// secrets engine, so that applications written against Vault can switch to
+// threshold-backed signing by pointing VAULT_ADDR elsewhere.
+//
+// Supported are:
+//
+//   - POST /v1/<mount>/sign/<name>[/<hash_algorithm>]
+//   - POST /v1/<mount>/verify/<name>[/<hash_algorithm>]
+//   - GET /v1/<mount>/keys/<name>
+//
+// with the input, prehashed, hash_algorithm and marshaling_algorithm
+// parameters. Batch input, key management and the other transit endpoints
+// are not. Vault tokens are not checked: put the server behind something
+// that authenticates its clients.
+package dkgtransit
+
+import "context"
+import "crypto/ecdsa"
+import "crypto/elliptic"
+import "crypto/sha256"
+import "crypto/sha512"
+import "crypto/x509"
+import "encoding/asn1"
+import "encoding/base64"
+import "encoding/json"
+import "encoding/pem"
+import "hash"
+import "math/big"
+import "net/http"
+import "strings"
+import "sync"
+
+import "github.com/mikalv/dkg"
+
+// Key is a threshold signing key.
+type Key interface {
+	PublicKey() *ecdsa.PublicKey
+	// Sign returns an ECDSA signature of digest by the group key.
+	Sign(digest []byte) (r, s *big.Int, err error)
+}
+
+// LocalKey is a Key whose signers all run in this process, for instance an
+// operator holding 2t+1 shares for an offline signing session.
+type LocalKey struct {
+	Shares []*dkg.KeyShare
+	// Nonces returns fresh signing nonces for Shares, in the same order.
+	// They must never be handed out twice.
+	Nonces func() ([]*dkg.SigningNonces, error)
+}
+
+func (k *LocalKey) PublicKey() *ecdsa.PublicKey {
+	return &k.Shares[0].PublicKey
+}
+
+func (k *LocalKey) Sign(digest []byte) (r, s *big.Int, err error) {
+	signer := &dkg.LocalGroupSigner{Shares: k.Shares, Nonces: k.Nonces}
+	return signer.Sign(context.Background(), digest)
+}
+
+// the group key stays the same across refreshes, so there is only ever one
+// key version
+const (
+	keyVersion      = 1
+	signaturePrefix = "vault:v1:"
+)
+
+var hashes = map[string]func() hash.Hash{
+	"sha2-224":     sha256.New224,
+	"sha2-256":     sha256.New,
+	"sha2-384":     sha512.New384,
+	"sha2-512":     sha512.New,
+	"sha2-512/256": sha512.New512_256,
+}
+
+var keyTypes = map[string]string{
+	"P-256": "ecdsa-p256",
+	"P-384": "ecdsa-p384",
+	"P-521": "ecdsa-p521",
+}
+
+// Server is an http.Handler serving the keys added to it.
+type Server struct {
+	prefix string
+
+	mu   sync.RWMutex
+	keys map[string]Key
+}
+
+// NewServer returns a server for the engine mounted at mount, "transit"
+// by default in Vault.
+func NewServer(mount string) *Server {
+	return &Server{
+		prefix: "/v1/" + strings.Trim(mount, "/") + "/",
+		keys:   make(map[string]Key),
+	}
+}
+
+// AddKey serves key under name, replacing any key of that name.
+func (s *Server) AddKey(name string, key Key) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[name] = key
+}
+
+// ServeHTTP routes requests by hand rather than with ServeMux patterns,
+// which GOPATH builds don't enable.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path, ok := strings.CutPrefix(r.URL.Path, s.prefix)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	// the hash algorithm may contain a slash, as in sha2-512/256
+	parts := strings.SplitN(path, "/", 3)
+	if len(parts) < 2 || parts[1] == "" {
+		http.NotFound(w, r)
+		return
+	}
+	name, hashAlgorithm := parts[1], ""
+	if len(parts) == 3 {
+		hashAlgorithm = parts[2]
+	}
+
+	var handler func(http.ResponseWriter, *http.Request, string, string)
+	method := http.MethodPost
+	switch parts[0] {
+	case "sign":
+		handler = s.sign
+	case "verify":
+		handler = s.verify
+	case "keys":
+		if len(parts) == 3 {
+			http.NotFound(w, r)
+			return
+		}
+		handler, method = s.readKey, http.MethodGet
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	handler(w, r, name, hashAlgorithm)
+}
+
+func (s *Server) key(name string) (Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[name]
+	if !ok {
+		return nil, UnknownKeyError{name}
+	}
+	return key, nil
+}
+
+type request struct {
+	Input               string `json:"input"`
+	Signature           string `json:"signature"`
+	Prehashed           bool   `json:"prehashed"`
+	HashAlgorithm       string `json:"hash_algorithm"`
+	MarshalingAlgorithm string `json:"marshaling_algorithm"`
+}
+
+// parse reads the request body and returns the named key and the digest to
+// sign or verify.
+func (s *Server) parse(r *http.Request, name, hashAlgorithm string) (Key, *request, []byte, error) {
+	key, err := s.key(name)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, nil, nil, InvalidRequestError{"malformed JSON body"}
+	}
+	if hashAlgorithm != "" {
+		req.HashAlgorithm = hashAlgorithm
+	}
+	if req.HashAlgorithm == "" {
+		req.HashAlgorithm = "sha2-256"
+	}
+	switch req.MarshalingAlgorithm {
+	case "", "asn1", "jws":
+	default:
+		return nil, nil, nil, InvalidRequestError{"unsupported marshaling algorithm " + req.MarshalingAlgorithm}
+	}
+	input, err := base64.StdEncoding.DecodeString(req.Input)
+	if err != nil {
+		return nil, nil, nil, InvalidRequestError{"input is not base64"}
+	}
+	if req.Prehashed || req.HashAlgorithm == "none" {
+		return key, &req, input, nil
+	}
+	newHash, ok := hashes[req.HashAlgorithm]
+	if !ok {
+		return nil, nil, nil, InvalidRequestError{"unsupported hash algorithm " + req.HashAlgorithm}
+	}
+	h := newHash()
+	h.Write(input)
+	return key, &req, h.Sum(nil), nil
+}
+
+func (s *Server) sign(w http.ResponseWriter, r *http.Request, name, hashAlgorithm string) {
+	key, req, digest, err := s.parse(r, name, hashAlgorithm)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	rs, ss, err := key.Sign(digest)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	sig, err := marshalSignature(req.MarshalingAlgorithm, key.PublicKey().Curve, rs, ss)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, map[string]any{
+		"signature":   signaturePrefix + sig,
+		"key_version": keyVersion,
+	})
+}
+
+func (s *Server) verify(w http.ResponseWriter, r *http.Request, name, hashAlgorithm string) {
+	key, req, digest, err := s.parse(r, name, hashAlgorithm)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	sig, ok := strings.CutPrefix(req.Signature, signaturePrefix)
+	if !ok {
+		writeError(w, InvalidRequestError{"signature is not a v1 transit signature"})
+		return
+	}
+	rs, ss, err := unmarshalSignature(req.MarshalingAlgorithm, key.PublicKey().Curve, sig)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, map[string]any{"valid": ecdsa.Verify(key.PublicKey(), digest, rs, ss)})
+}
+
+func (s *Server) readKey(w http.ResponseWriter, r *http.Request, name, _ string) {
+	key, err := s.key(name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	der, err := x509.MarshalPKIXPublicKey(key.PublicKey())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	curve := key.PublicKey().Curve.Params().Name
+	keyType, ok := keyTypes[curve]
+	if !ok {
+		keyType = "ecdsa-" + strings.ToLower(curve)
+	}
+	writeData(w, map[string]any{
+		"name":                   name,
+		"type":                   keyType,
+		"keys":                   map[string]any{"1": map[string]any{"public_key": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))}},
+		"latest_version":         keyVersion,
+		"min_decryption_version": keyVersion,
+		"min_encryption_version": 0,
+		"supports_signing":       true,
+		"supports_encryption":    false,
+		"supports_decryption":    false,
+		"supports_derivation":    false,
+		"exportable":             false,
+		"deletion_allowed":       false,
+	})
+}
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// marshalSignature encodes a signature as Vault does: ASN.1 DER by default,
+// or the fixed-size concatenation of r and s of JWS.
+func marshalSignature(algorithm string, curve elliptic.Curve, r, s *big.Int) (string, error) {
+	switch algorithm {
+	case "", "asn1":
+		der, err := asn1.Marshal(ecdsaSignature{r, s})
+		return base64.StdEncoding.EncodeToString(der), err
+	case "jws":
+		size := (curve.Params().N.BitLen() + 7) / 8
+		b := make([]byte, 2*size)
+		r.FillBytes(b[:size])
+		s.FillBytes(b[size:])
+		return base64.RawURLEncoding.EncodeToString(b), nil
+	}
+	return "", InvalidRequestError{"unsupported marshaling algorithm " + algorithm}
+}
+
+func unmarshalSignature(algorithm string, curve elliptic.Curve, sig string) (r, s *big.Int, err error) {
+	switch algorithm {
+	case "", "asn1":
+		der, err := base64.StdEncoding.DecodeString(sig)
+		if err != nil {
+			return nil, nil, InvalidRequestError{"signature is not base64"}
+		}
+		var parsed ecdsaSignature
+		if rest, err := asn1.Unmarshal(der, &parsed); err != nil || len(rest) != 0 {
+			return nil, nil, InvalidRequestError{"signature is not ASN.1 DER"}
+		}
+		return parsed.R, parsed.S, nil
+	case "jws":
+		b, err := base64.RawURLEncoding.DecodeString(sig)
+		size := (curve.Params().N.BitLen() + 7) / 8
+		if err != nil || len(b) != 2*size {
+			return nil, nil, InvalidRequestError{"signature is not a JWS signature"}
+		}
+		return new(big.Int).SetBytes(b[:size]), new(big.Int).SetBytes(b[size:]), nil
+	}
+	return nil, nil, InvalidRequestError{"unsupported marshaling algorithm " + algorithm}
+}
+
+func writeData(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+// writeError responds as Vault does, with a list of error messages.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch err.(type) {
+	case UnknownKeyError:
+		status = http.StatusNotFound
+	case InvalidRequestError:
+		status = http.StatusBadRequest
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{"errors": []string{err.Error()}})
+}
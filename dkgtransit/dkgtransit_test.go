@@ -0,0 +1,164 @@
+package dkgtransit
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mikalv/dkg"
+)
+
+// localKeyForTesting returns a key of 3 participants with threshold 1 and
+// nonces for signatures signatures.
+func localKeyForTesting(t *testing.T, signatures int) *LocalKey {
+	const size, threshold = 3, 1
+	run := func(threshold int, label string) []*dkg.KeyShare {
+		sim, err := dkg.NewSimulator(elliptic.P256(), size, threshold, 2*time.Second, []byte(label))
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares, err := sim.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, share := range shares {
+			if share == nil {
+				t.Fatalf("Ceremony %v did not finish", label)
+			}
+		}
+		return shares
+	}
+	key := &LocalKey{Shares: run(threshold, "key")}
+	var nonces [][]*dkg.SigningNonces
+	for i := 0; i < signatures; i++ {
+		label := fmt.Sprint("nonces ", i)
+		ks, as := run(threshold, label+" k"), run(threshold, label+" a")
+		zks, zss := run(2*threshold-1, label+" zk"), run(2*threshold-1, label+" zs")
+		set := make([]*dkg.SigningNonces, size)
+		for j := range set {
+			set[j] = &dkg.SigningNonces{K: ks[j], A: as[j], ZeroK: zks[j], ZeroS: zss[j]}
+		}
+		nonces = append(nonces, set)
+	}
+	key.Nonces = func() ([]*dkg.SigningNonces, error) {
+		if len(nonces) == 0 {
+			return nil, fmt.Errorf("out of nonces")
+		}
+		next := nonces[0]
+		nonces = nonces[1:]
+		return next, nil
+	}
+	return key
+}
+
+func call(t *testing.T, server http.Handler, method, path string, body any) (int, map[string]any) {
+	var b []byte
+	if body != nil {
+		b, _ = json.Marshal(body)
+	}
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewReader(b)))
+	var response map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("%v %v returned invalid JSON %q", method, path, w.Body.String())
+	}
+	return w.Code, response
+}
+
+func TestServer(t *testing.T) {
+	key := localKeyForTesting(t, 2)
+	server := NewServer("transit")
+	server.AddKey("group", key)
+	input := base64.StdEncoding.EncodeToString([]byte("threshold transit"))
+
+	t.Run("Read key", func(t *testing.T) {
+		code, response := call(t, server, "GET", "/v1/transit/keys/group", nil)
+		data, _ := response["data"].(map[string]any)
+		if code != http.StatusOK || data["type"] != "ecdsa-p256" {
+			t.Fatalf("Got unexpected response %v %v", code, response)
+		}
+		keys, _ := data["keys"].(map[string]any)
+		version, _ := keys["1"].(map[string]any)
+		encoded, _ := version["public_key"].(string)
+		block, _ := pem.Decode([]byte(encoded))
+		if block == nil {
+			t.Fatalf("No PEM public key in %v", data)
+		}
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil || !key.PublicKey().Equal(pub) {
+			t.Errorf("Got unexpected public key %v (%v)", pub, err)
+		}
+	})
+
+	for _, marshaling := range []string{"asn1", "jws"} {
+		t.Run("Sign "+marshaling, func(t *testing.T) {
+			code, response := call(t, server, "POST", "/v1/transit/sign/group/sha2-256", map[string]any{
+				"input":                input,
+				"marshaling_algorithm": marshaling,
+			})
+			data, _ := response["data"].(map[string]any)
+			signature, _ := data["signature"].(string)
+			if code != http.StatusOK || data["key_version"] != float64(1) {
+				t.Fatalf("Got unexpected response %v %v", code, response)
+			}
+
+			verify := func(input, signature string) bool {
+				code, response := call(t, server, "POST", "/v1/transit/verify/group", map[string]any{
+					"input":                input,
+					"signature":            signature,
+					"marshaling_algorithm": marshaling,
+				})
+				data, _ := response["data"].(map[string]any)
+				if code != http.StatusOK {
+					t.Fatalf("Got unexpected response %v %v", code, response)
+				}
+				return data["valid"] == true
+			}
+			if !verify(input, signature) {
+				t.Errorf("Signature %v does not verify", signature)
+			}
+			if verify(base64.StdEncoding.EncodeToString([]byte("other input")), signature) {
+				t.Errorf("Signature %v verifies for other input", signature)
+			}
+
+			// the digest was signed with the group key
+			if marshaling == "asn1" {
+				der, _ := base64.StdEncoding.DecodeString(signature[len(signaturePrefix):])
+				digest := sha256.Sum256([]byte("threshold transit"))
+				if !ecdsa.VerifyASN1(key.PublicKey(), digest[:], der) {
+					t.Errorf("Signature does not verify with the group key")
+				}
+			}
+		})
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		for _, test := range []struct {
+			method, path string
+			body         any
+			code         int
+		}{
+			{"POST", "/v1/transit/sign/other", map[string]any{"input": input}, http.StatusNotFound},
+			{"GET", "/v1/transit/keys/other", nil, http.StatusNotFound},
+			{"POST", "/v1/transit/sign/group", map[string]any{"input": "not base64!"}, http.StatusBadRequest},
+			{"POST", "/v1/transit/sign/group/md5", map[string]any{"input": input}, http.StatusBadRequest},
+			{"POST", "/v1/transit/verify/group", map[string]any{"input": input, "signature": "vault:v2:AA=="}, http.StatusBadRequest},
+			{"POST", "/v1/transit/sign/group", map[string]any{"input": input, "marshaling_algorithm": "pem"}, http.StatusBadRequest},
+		} {
+			code, response := call(t, server, test.method, test.path, test.body)
+			if code != test.code || response["errors"] == nil {
+				t.Errorf("%v %v: got unexpected response %v %v", test.method, test.path, code, response)
+			}
+		}
+	})
+}
@@ -0,0 +1,19 @@
+package dkgtransit
+
+import "fmt"
+
+type UnknownKeyError struct {
+	name string
+}
+
+func (e UnknownKeyError) Error() string {
+	return fmt.Sprintf("dkgtransit: unknown key %q", e.name)
+}
+
+type InvalidRequestError struct {
+	reason string
+}
+
+func (e InvalidRequestError) Error() string {
+	return fmt.Sprintf("dkgtransit: invalid request: %v", e.reason)
+}
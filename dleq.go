@@ -0,0 +1,58 @@
+package dkg
+
+import "crypto/elliptic"
+import "crypto/sha256"
+import "io"
+import "math/big"
+
+// Chaum-Pedersen proofs show that participant id's public share P = s * G
+// and an evaluation E = s * H share their discrete logarithm s, for a base
+// point H of the statement: the prover commits to k * G and k * H, and
+// answers the challenge c with z = k + c * s.
+
+func dleqChallenge(curve elliptic.Curve, tag string, id *big.Int, points ...*big.Int) *big.Int {
+	w := NewTranscriptWriter(sha256.New())
+	w.WriteTag(tag)
+	w.WriteTag(curve.Params().Name)
+	w.WriteInt(id)
+	for _, x := range points {
+		w.WriteInt(x)
+	}
+	c := new(big.Int).SetBytes(w.Sum())
+	return c.Mod(c, curve.Params().N)
+}
+
+// proveDLEQ returns E = s * H with a proof under tag.
+func proveDLEQ(curve elliptic.Curve, tag string, id, s, hx, hy *big.Int, random io.Reader) (ex, ey, c, z *big.Int, err error) {
+	n := curve.Params().N
+	k, err := randomScalar(n, random)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	defer zeroize(k)
+	px, py := curve.ScalarBaseMult(scalarBytes(curve, s))
+	ex, ey = curve.ScalarMult(hx, hy, scalarBytes(curve, s))
+	ax, ay := curve.ScalarBaseMult(scalarBytes(curve, k))
+	bx, by := curve.ScalarMult(hx, hy, scalarBytes(curve, k))
+	c = dleqChallenge(curve, tag, id, px, py, hx, hy, ex, ey, ax, ay, bx, by)
+	f := scalarFieldFor(n)
+	zm := f.fromBig(k)
+	defer clear(zm)
+	f.mulAdd(zm, c, s)
+	return ex, ey, c, f.toBig(zm), nil
+}
+
+// verifyDLEQ checks a proof under tag that E and public share P share
+// their discrete logarithm to H and G.
+func verifyDLEQ(curve elliptic.Curve, tag string, id, px, py, hx, hy, ex, ey, c, z *big.Int) bool {
+	n := curve.Params().N
+	if !isValidPoint(curve, ex, ey) || !isNormalizedScalar(c, n) || !isNormalizedScalar(z, n) {
+		return false
+	}
+	// z * G - c * P and z * H - c * E recover the commitments
+	zx, zy := curve.ScalarBaseMult(scalarBytes(curve, z))
+	ax, ay := subtractPoint(curve, zx, zy, c, px, py)
+	zx, zy = curve.ScalarMult(hx, hy, scalarBytes(curve, z))
+	bx, by := subtractPoint(curve, zx, zy, c, ex, ey)
+	return dleqChallenge(curve, tag, id, px, py, hx, hy, ex, ey, ax, ay, bx, by).Cmp(c) == 0
+}
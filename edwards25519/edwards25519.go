@@ -0,0 +1,274 @@
+// Package edwards25519 implements the twisted Edwards curve of Ed25519,
+// -x^2 + y^2 = 1 + d x^2 y^2 over GF(2^255 - 19), as a crypto/elliptic
+// compatible curve, so that it can back a DKG whose output is used for
+// Ed25519-style signing.
+//
+// Unlike the curves of crypto/elliptic the group has cofactor 8 and its
+// identity element is (0, 1); the curve reports both through its Cofactor
+// and IsIdentity methods. Params().B holds d.
+//
+// The arithmetic uses math/big and is not constant time.
+package edwards25519
+
+import "crypto/elliptic"
+import "errors"
+import "math/big"
+import "math/bits"
+import "sync"
+
+type curve struct {
+	params *elliptic.CurveParams
+	d2     *big.Int
+}
+
+var initOnce sync.Once
+var edwards curve
+
+func initEd25519() {
+	params := &elliptic.CurveParams{Name: "edwards25519", BitSize: 255}
+	params.P = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+	params.N, _ = new(big.Int).SetString("7237005577332262213973186563042994240857116359379907606001950938285454250989", 10)
+	// d = -121665 / 121666
+	params.B = new(big.Int).ModInverse(big.NewInt(121666), params.P)
+	params.B.Mul(params.B, big.NewInt(-121665))
+	params.B.Mod(params.B, params.P)
+	params.Gx, _ = new(big.Int).SetString("15112221349535400772501151409588531511454012693041857206046113283949847762202", 10)
+	params.Gy, _ = new(big.Int).SetString("46316835694926478169428394003475163141307993866256225615783033603165251855960", 10)
+	edwards = curve{params, new(big.Int).Mod(new(big.Int).Lsh(params.B, 1), params.P)}
+}
+
+// Curve returns edwards25519.
+func Curve() elliptic.Curve {
+	initOnce.Do(initEd25519)
+	return edwards
+}
+
+func (c curve) Params() *elliptic.CurveParams {
+	return c.params
+}
+
+func (c curve) Cofactor() *big.Int {
+	return big.NewInt(8)
+}
+
+func (c curve) IsIdentity(x, y *big.Int) bool {
+	return x.Sign() == 0 && y.Cmp(one) == 0
+}
+
+var one = big.NewInt(1)
+
+func (c curve) IsOnCurve(x, y *big.Int) bool {
+	p := c.params.P
+	if x.Sign() < 0 || x.Cmp(p) >= 0 || y.Sign() < 0 || y.Cmp(p) >= 0 {
+		return false
+	}
+	x2 := new(big.Int).Mul(x, x)
+	y2 := new(big.Int).Mul(y, y)
+	lhs := new(big.Int).Sub(y2, x2)
+	lhs.Mod(lhs, p)
+	rhs := new(big.Int).Mul(x2, y2)
+	rhs.Mul(rhs, c.params.B)
+	rhs.Add(rhs, one)
+	rhs.Mod(rhs, p)
+	return lhs.Cmp(rhs) == 0
+}
+
+// extended is the point (X/Z, Y/Z) with T = XY/Z.
+type extended struct {
+	x, y, z, t *big.Int
+}
+
+func (c curve) identity() extended {
+	return extended{new(big.Int), big.NewInt(1), big.NewInt(1), new(big.Int)}
+}
+
+func (c curve) fromAffine(x, y *big.Int) extended {
+	t := new(big.Int).Mul(x, y)
+	return extended{new(big.Int).Set(x), new(big.Int).Set(y), big.NewInt(1), t.Mod(t, c.params.P)}
+}
+
+func (c curve) toAffine(e extended) (*big.Int, *big.Int) {
+	p := c.params.P
+	zinv := new(big.Int).ModInverse(e.z, p)
+	x := new(big.Int).Mul(e.x, zinv)
+	y := new(big.Int).Mul(e.y, zinv)
+	return x.Mod(x, p), y.Mod(y, p)
+}
+
+// add uses add-2008-hwcd-3, which is complete for this curve and so also
+// serves for doubling.
+func (c curve) add(a, b extended) extended {
+	p := c.params.P
+	mul := func(x, y *big.Int) *big.Int {
+		r := new(big.Int).Mul(x, y)
+		return r.Mod(r, p)
+	}
+	A := mul(new(big.Int).Sub(a.y, a.x), new(big.Int).Sub(b.y, b.x))
+	B := mul(new(big.Int).Add(a.y, a.x), new(big.Int).Add(b.y, b.x))
+	C := mul(mul(a.t, c.d2), b.t)
+	D := mul(new(big.Int).Lsh(a.z, 1), b.z)
+	E := new(big.Int).Sub(B, A)
+	F := new(big.Int).Sub(D, C)
+	G := new(big.Int).Add(D, C)
+	H := new(big.Int).Add(B, A)
+	return extended{mul(E, F), mul(G, H), mul(F, G), mul(E, H)}
+}
+
+func (c curve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	return c.toAffine(c.add(c.fromAffine(x1, y1), c.fromAffine(x2, y2)))
+}
+
+func (c curve) Double(x1, y1 *big.Int) (*big.Int, *big.Int) {
+	a := c.fromAffine(x1, y1)
+	return c.toAffine(c.add(a, a))
+}
+
+func (c curve) ScalarMult(x1, y1 *big.Int, k []byte) (*big.Int, *big.Int) {
+	base := c.fromAffine(x1, y1)
+	acc := c.identity()
+	for _, b := range k {
+		for bit := 7; bit >= 0; bit-- {
+			acc = c.add(acc, acc)
+			if b>>uint(bit)&1 == 1 {
+				acc = c.add(acc, base)
+			}
+		}
+	}
+	return c.toAffine(acc)
+}
+
+func (c curve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	return c.ScalarMult(c.params.Gx, c.params.Gy, k)
+}
+
+// Encode returns the 32-byte RFC 8032 encoding of the point: y in little
+// endian with the low bit of x in the top bit.
+func Encode(x, y *big.Int) []byte {
+	b := y.FillBytes(make([]byte, 32))
+	reverse(b)
+	b[31] |= byte(x.Bit(0)) << 7
+	return b
+}
+
+var errInvalidEncoding = errors.New("edwards25519: invalid point encoding")
+
+// Decode parses an RFC 8032 point encoding.
+func Decode(b []byte) (x, y *big.Int, err error) {
+	c := Curve().(curve)
+	p := c.params.P
+	if len(b) != 32 {
+		return nil, nil, errInvalidEncoding
+	}
+	le := append([]byte(nil), b...)
+	sign := uint(le[31] >> 7)
+	le[31] &= 0x7f
+	reverse(le)
+	y = new(big.Int).SetBytes(le)
+	if y.Cmp(p) >= 0 {
+		return nil, nil, errInvalidEncoding
+	}
+
+	if x = c.recoverX(y, sign); x == nil {
+		return nil, nil, errInvalidEncoding
+	}
+	return x, y, nil
+}
+
+// MarshalCompressed returns the RFC 8032 encoding of the point, for the
+// compressed wire format of package dkg.
+func (c curve) MarshalCompressed(x, y *big.Int) []byte {
+	return Encode(x, y)
+}
+
+// UnmarshalCompressed parses an RFC 8032 point encoding, returning nil
+// coordinates for invalid input like elliptic.UnmarshalCompressed.
+func (c curve) UnmarshalCompressed(b []byte) (x, y *big.Int) {
+	x, y, err := Decode(b)
+	if err != nil {
+		return nil, nil
+	}
+	return x, y
+}
+
+// recoverX returns the x coordinate of the point with the given y whose low
+// bit is sign, or nil if there is none.
+func (c curve) recoverX(y *big.Int, sign uint) *big.Int {
+	p := c.params.P
+	// x^2 = (y^2 - 1) / (d y^2 + 1)
+	y2 := new(big.Int).Mul(y, y)
+	num := new(big.Int).Sub(y2, one)
+	den := new(big.Int).Mul(y2, c.params.B)
+	den.Add(den, one)
+	x2 := num.Mul(num, den.ModInverse(den.Mod(den, p), p))
+	x := new(big.Int).ModSqrt(x2.Mod(x2, p), p)
+	if x == nil || x.Sign() == 0 && sign == 1 {
+		return nil
+	}
+	if x.Bit(0) != sign {
+		x.Sub(p, x)
+	}
+	return x
+}
+
+// Lift returns the point with y coordinate t and even x, if there is one.
+func (c curve) Lift(t *big.Int) (x, y *big.Int, ok bool) {
+	if t.Sign() < 0 || t.Cmp(c.params.P) >= 0 {
+		return nil, nil, false
+	}
+	if x = c.recoverX(t, 0); x == nil {
+		return nil, nil, false
+	}
+	return x, new(big.Int).Set(t), true
+}
+
+func reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
+
+// MultiScalarMult returns the sum of k[i] * (xs[i], ys[i]), the scalars
+// big-endian, with Pippenger's bucket method: far fewer additions than
+// summing the results of ScalarMult when there are many points.
+func (c curve) MultiScalarMult(xs, ys []*big.Int, ks [][]byte) (*big.Int, *big.Int) {
+	points := make([]extended, len(xs))
+	scalars := make([]*big.Int, len(ks))
+	width := 0
+	for i := range xs {
+		points[i] = c.fromAffine(xs[i], ys[i])
+		scalars[i] = new(big.Int).SetBytes(ks[i])
+		width = max(width, scalars[i].BitLen())
+	}
+	w := max(bits.Len(uint(len(xs)))-3, 1)
+	acc := c.identity()
+	for shift := (width - 1) / w * w; shift >= 0; shift -= w {
+		for i := 0; i < w; i++ {
+			acc = c.add(acc, acc)
+		}
+		buckets := make([]extended, 1<<w)
+		for d := range buckets {
+			buckets[d] = c.identity()
+		}
+		for i, k := range scalars {
+			if d := digit(k, shift, w); d > 0 {
+				buckets[d] = c.add(buckets[d], points[i])
+			}
+		}
+		running, sum := c.identity(), c.identity()
+		for d := len(buckets) - 1; d > 0; d-- {
+			running = c.add(running, buckets[d])
+			sum = c.add(sum, running)
+		}
+		acc = c.add(acc, sum)
+	}
+	return c.toAffine(acc)
+}
+
+// digit returns the w bits of k from shift on.
+func digit(k *big.Int, shift, w int) int {
+	d := 0
+	for i := w - 1; i >= 0; i-- {
+		d = d<<1 | int(k.Bit(shift+i))
+	}
+	return d
+}
@@ -0,0 +1,104 @@
+package edwards25519
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+// publicKey derives the Ed25519 public key for seed as RFC 8032 does.
+func publicKey(seed []byte) []byte {
+	h := sha512.Sum512(seed)
+	s := h[:32]
+	s[0] &= 248
+	s[31] &= 127
+	s[31] |= 64
+	reverse(s)
+	x, y := Curve().ScalarBaseMult(s)
+	return Encode(x, y)
+}
+
+func TestRFC8032PublicKeys(t *testing.T) {
+	vectors := []struct{ seed, pub string }{
+		{"9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60",
+			"d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"},
+		{"4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb",
+			"3d4017c3e843895a92b70aa74d1b7ebc9c982ccf2ec4968cc0cd55f12af4660c"},
+	}
+	for _, v := range vectors {
+		seed, _ := hex.DecodeString(v.seed)
+		if pub := hex.EncodeToString(publicKey(seed)); pub != v.pub {
+			t.Errorf("Got public key %v for seed %v", pub, v.seed)
+		}
+	}
+
+	for i := 0; i < 4; i++ {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(publicKey(priv.Seed()), pub) {
+			t.Errorf("Public key for seed %x differs from crypto/ed25519", priv.Seed())
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	c := Curve()
+	params := c.Params()
+	if !c.IsOnCurve(params.Gx, params.Gy) {
+		t.Fatalf("Base point not on curve")
+	}
+	if x, y := c.ScalarBaseMult(params.N.Bytes()); !c.(curve).IsIdentity(x, y) {
+		t.Errorf("N * B is not the identity")
+	}
+
+	a, _ := rand.Int(rand.Reader, params.N)
+	b, _ := rand.Int(rand.Reader, params.N)
+	ax, ay := c.ScalarBaseMult(a.Bytes())
+	bx, by := c.ScalarBaseMult(b.Bytes())
+	sum := new(big.Int).Add(a, b)
+	ex, ey := c.ScalarBaseMult(sum.Mod(sum, params.N).Bytes())
+	if x, y := c.Add(ax, ay, bx, by); x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+		t.Errorf("a * B + b * B != (a + b) * B")
+	}
+	double := new(big.Int).Lsh(a, 1)
+	ex, ey = c.ScalarBaseMult(double.Mod(double, params.N).Bytes())
+	if x, y := c.Double(ax, ay); x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+		t.Errorf("2 * (a * B) != (2a) * B")
+	}
+
+	x, y, err := Decode(Encode(ax, ay))
+	if err != nil || x.Cmp(ax) != 0 || y.Cmp(ay) != 0 {
+		t.Errorf("Point encoding doesn't round-trip: %v", err)
+	}
+	if _, _, err := Decode(bytes.Repeat([]byte{0xff}, 32)); err == nil {
+		t.Errorf("Decoded out of range y")
+	}
+}
+
+func TestMultiScalarMult(t *testing.T) {
+	c := Curve()
+	n := c.Params().N
+	var xs, ys []*big.Int
+	var ks [][]byte
+	ex, ey := c.ScalarBaseMult(nil)
+	for i := 0; i < 20; i++ {
+		a, _ := rand.Int(rand.Reader, n)
+		k, _ := rand.Int(rand.Reader, n)
+		if i%5 == 0 {
+			k.SetInt64(int64(i))
+		}
+		x, y := c.ScalarBaseMult(a.Bytes())
+		xs, ys, ks = append(xs, x), append(ys, y), append(ks, k.Bytes())
+		px, py := c.ScalarMult(x, y, k.Bytes())
+		ex, ey = c.Add(ex, ey, px, py)
+	}
+	if x, y := c.(curve).MultiScalarMult(xs, ys, ks); x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+		t.Errorf("Multi-scalar multiplication differs from the sum of products")
+	}
+}
@@ -0,0 +1,103 @@
+package dkg
+
+import "io"
+import "math/big"
+
+// EncryptToGroup encrypts plaintext to group's key, authenticating ad, by
+// hybrid ElGamal: an ephemeral point R = r * G, and AES-256-GCM under a key
+// derived from r * Y, Y being the group key. Decrypting it takes the
+// decryption shares s_i * R of threshold+1 participants, which interpolate
+// to s * R = r * Y; no participant learns more than its share of the key.
+// The key is derived under its own label, so that a ciphertext to a group
+// is never mistaken for one to a participant with the same key.
+func EncryptToGroup(group GroupKey, plaintext, ad []byte, random io.Reader) ([]byte, error) {
+	return sealTo(&group.PublicKey, groupEncryptionLabel, plaintext, ad, random)
+}
+
+// DecryptionShare is participant ID's share s_i * R of the decryption of a
+// ciphertext with ephemeral point R, with a Chaum-Pedersen proof that it
+// matches the participant's public share.
+type DecryptionShare struct {
+	ID                  *big.Int
+	X, Y                *big.Int
+	Challenge, Response *big.Int
+}
+
+// PartialDecrypt returns the participant's decryption share of a
+// ciphertext of EncryptToGroup. It is safe to release: it reveals nothing
+// of the share, nor of the plaintext short of threshold+1 decryption
+// shares.
+func PartialDecrypt(key *KeyShare, ciphertext []byte, random io.Reader) (DecryptionShare, error) {
+	if err := key.use(UseDecryption); err != nil {
+		return DecryptionShare{}, err
+	}
+	curve := key.PublicKey.Curve
+	rx, ry, ok := sealedEphemeral(curve, ciphertext)
+	if !ok {
+		return DecryptionShare{}, InvalidEncodingError{"malformed ciphertext"}
+	}
+	dx, dy, c, z, err := proveDLEQ(curve, "dkg/decryption-proof", key.ID, key.Share, rx, ry, random)
+	if err != nil {
+		return DecryptionShare{}, err
+	}
+	return DecryptionShare{key.ID, dx, dy, c, z}, nil
+}
+
+// VerifyDecryptionShare checks a decryption share of ciphertext against
+// the participant's public share in group.
+func VerifyDecryptionShare(group GroupKey, ciphertext []byte, share DecryptionShare) error {
+	curve := group.PublicKey.Curve
+	rx, ry, ok := sealedEphemeral(curve, ciphertext)
+	if !ok {
+		return InvalidEncodingError{"malformed ciphertext"}
+	}
+	return verifyDecryptionShare(group, rx, ry, share)
+}
+
+func verifyDecryptionShare(group GroupKey, rx, ry *big.Int, share DecryptionShare) error {
+	curve := group.PublicKey.Curve
+	if !validParticipantID(share.ID, curve.Params().N) {
+		return InvalidParticipantIDError{share.ID}
+	}
+	px, py := evaluateCommitments(curve, group.PublicCoefficients, share.ID)
+	if !verifyDLEQ(curve, "dkg/decryption-proof", share.ID, px, py, rx, ry, share.X, share.Y, share.Challenge, share.Response) {
+		return InvalidDecryptionShareError{share.ID}
+	}
+	return nil
+}
+
+// CombineDecryption decrypts a ciphertext of EncryptToGroup with ad from
+// threshold+1 valid decryption shares of distinct participants, ignoring
+// invalid shares and the rest.
+func CombineDecryption(group GroupKey, ciphertext, ad []byte, shares []DecryptionShare) ([]byte, error) {
+	curve := group.PublicKey.Curve
+	rx, ry, ok := sealedEphemeral(curve, ciphertext)
+	if !ok {
+		return nil, InvalidEncodingError{"malformed ciphertext"}
+	}
+	seen := make(map[string]bool)
+	var valid []PublicShare
+	for _, share := range shares {
+		if len(valid) > group.Threshold {
+			break
+		}
+		if verifyDecryptionShare(group, rx, ry, share) != nil {
+			continue
+		}
+		key := new(big.Int).Mod(share.ID, curve.Params().N).String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		valid = append(valid, PublicShare{share.ID, share.X, share.Y})
+	}
+	sx, _, err := RecoverPublicPoint(curve, group.Threshold, valid)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := openShared(curve, groupEncryptionLabel, sx, ciphertext, ad)
+	if err != nil {
+		return nil, DecryptionError{}
+	}
+	return plaintext, nil
+}
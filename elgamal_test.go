@@ -0,0 +1,78 @@
+package dkg
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestThresholdElGamal(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 4, 1)
+	keys := runCeremonyForTesting(t, nodes, participants)
+	checkCeremonyResultsForTesting(t, keys)
+	group := keys[0].Group()
+	plaintext, ad := []byte("sealed bid"), []byte("auction 12")
+
+	ciphertext, err := EncryptToGroup(group, plaintext, ad, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares := make([]DecryptionShare, len(keys))
+	for i, key := range keys {
+		if shares[i], err = PartialDecrypt(key, ciphertext, rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyDecryptionShare(group, ciphertext, shares[i]); err != nil {
+			t.Errorf("Decryption share of %v doesn't verify: %v", key.ID, err)
+		}
+	}
+	bad := shares[0]
+	bad.X, bad.Y = shares[1].X, shares[1].Y
+	if err := VerifyDecryptionShare(group, ciphertext, bad); !reflect.DeepEqual(err, InvalidDecryptionShareError{bad.ID}) {
+		t.Errorf("Got unexpected error for a wrong decryption share: %v", err)
+	}
+
+	for _, quorum := range [][]DecryptionShare{shares[:2], shares[2:], {bad, shares[3], shares[3], shares[1]}} {
+		got, err := CombineDecryption(group, ciphertext, ad, quorum)
+		if err != nil || !bytes.Equal(got, plaintext) {
+			t.Errorf("Decrypted %q (%v)", got, err)
+		}
+	}
+	if _, err := CombineDecryption(group, ciphertext, []byte("auction 13"), shares); !reflect.DeepEqual(err, DecryptionError{}) {
+		t.Errorf("Got unexpected error for other additional data: %v", err)
+	}
+	if _, err := CombineDecryption(group, ciphertext, ad, []DecryptionShare{bad, shares[1]}); reflect.TypeOf(err) != reflect.TypeOf(InsufficientSharesError{}) {
+		t.Errorf("Got unexpected error with too few valid shares: %v", err)
+	}
+	if _, err := PartialDecrypt(keys[0], ciphertext[:10], rand.Reader); reflect.TypeOf(err) != reflect.TypeOf(InvalidEncodingError{}) {
+		t.Errorf("Got unexpected error for a truncated ciphertext: %v", err)
+	}
+	other := shares[0]
+	other.ID = big.NewInt(9)
+	if err := VerifyDecryptionShare(group, ciphertext, other); err == nil {
+		t.Errorf("Decryption share verified for another participant")
+	}
+}
+
+func TestGroupEncryptionLabel(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, ad := []byte("sealed bid"), []byte("auction 12")
+	ciphertext, err := EncryptToGroup(GroupKey{PublicKey: key.PublicKey}, plaintext, ad, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the holder of the whole group key can't take it for a share ciphertext
+	if _, err := openWith(SoftwareIdentity(key), shareEncryptionLabel, ciphertext, ad); err == nil {
+		t.Errorf("Opened a group ciphertext as a share ciphertext")
+	}
+	if opened, err := openWith(SoftwareIdentity(key), groupEncryptionLabel, ciphertext, ad); err != nil || !bytes.Equal(opened, plaintext) {
+		t.Errorf("Opened %q (%v), expected %q", opened, err, plaintext)
+	}
+}
@@ -0,0 +1,155 @@
+package dkg
+
+import "crypto/aes"
+import "crypto/cipher"
+import "crypto/ecdsa"
+import "crypto/elliptic"
+import "crypto/hkdf"
+import "crypto/sha256"
+import "errors"
+import "io"
+import "math/big"
+
+var errShareDecryption = errors.New("dkg: could not decrypt shares")
+
+// The HKDF labels of the keys sealTo derives, separating encryption to a
+// participant, such as of shares and insurance shards, from encryption to
+// a group.
+const (
+	shareEncryptionLabel = "dkg share encryption"
+	groupEncryptionLabel = "dkg group encryption"
+)
+
+// encryptShares encrypts shares dealt by from to the holder of key, by ECDH
+// with an ephemeral key, HKDF-SHA256 and AES-256-GCM. The sender and
+// recipient IDs are authenticated as additional data. The ciphertext is the
+// ephemeral public point, the nonce and the sealed fixed-width shares.
+func encryptShares(
+	curve elliptic.Curve,
+	key *ecdsa.PublicKey,
+	from, to *big.Int,
+	shares SecretShares,
+	random io.Reader,
+) ([]byte, error) {
+	plaintext := append(scalarBytes(curve, shares.Share1), scalarBytes(curve, shares.Share2)...)
+	defer clear(plaintext)
+	return sealTo(key, shareEncryptionLabel, plaintext, shareAD(from, to), random)
+}
+
+// sealTo encrypts plaintext to the holder of key, authenticating ad, like
+// encryptShares, under a key derived with label.
+func sealTo(key *ecdsa.PublicKey, label string, plaintext, ad []byte, random io.Reader) ([]byte, error) {
+	e, err := randomScalar(key.Curve.Params().N, random)
+	if err != nil {
+		return nil, err
+	}
+	ex, ey := key.Curve.ScalarBaseMult(e.Bytes())
+	sx, _ := key.Curve.ScalarMult(key.X, key.Y, e.Bytes())
+	defer zeroize(e, sx)
+	ephemeral := elliptic.Marshal(key.Curve, ex, ey)
+
+	aead, err := shareCipher(key.Curve, sx, ephemeral, label)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(random, nonce); err != nil {
+		return nil, err
+	}
+
+	out := append(ephemeral, nonce...)
+	return aead.Seal(out, nonce, plaintext, ad), nil
+}
+
+func decryptShares(
+	curve elliptic.Curve,
+	identity Identity,
+	from, to *big.Int,
+	ciphertext []byte,
+) (SecretShares, error) {
+	plaintext, err := openWith(identity, shareEncryptionLabel, ciphertext, shareAD(from, to))
+	defer clear(plaintext)
+	if err != nil {
+		return SecretShares{}, err
+	}
+	if len(plaintext) != 2*ScalarSize(curve) {
+		return SecretShares{}, errShareDecryption
+	}
+
+	s1, err := DecodeScalar(curve, plaintext[:ScalarSize(curve)])
+	if err != nil {
+		return SecretShares{}, err
+	}
+	s2, err := DecodeScalar(curve, plaintext[ScalarSize(curve):])
+	if err != nil {
+		return SecretShares{}, err
+	}
+	return SecretShares{s1, s2, curve}, nil
+}
+
+// openWith decrypts a ciphertext of sealTo under label with identity.
+func openWith(identity Identity, label string, ciphertext, ad []byte) ([]byte, error) {
+	key := identity.Public().(*ecdsa.PublicKey)
+	ex, ey, ok := sealedEphemeral(key.Curve, ciphertext)
+	if !ok {
+		return nil, errShareDecryption
+	}
+	sx, err := identity.ECDH(ex, ey)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroize(sx)
+	return openShared(key.Curve, label, sx, ciphertext, ad)
+}
+
+// sealedEphemeral returns the ephemeral point of a ciphertext of sealTo to
+// a key on curve.
+func sealedEphemeral(curve elliptic.Curve, ciphertext []byte) (x, y *big.Int, ok bool) {
+	pointLen := 1 + 2*((curve.Params().BitSize+7)/8)
+	if len(ciphertext) < pointLen {
+		return nil, nil, false
+	}
+	x, y = elliptic.Unmarshal(curve, ciphertext[:pointLen])
+	return x, y, x != nil && isValidPoint(curve, x, y)
+}
+
+// openShared decrypts a ciphertext of sealTo under label given the x
+// coordinate of the shared point.
+func openShared(curve elliptic.Curve, label string, sharedX *big.Int, ciphertext, ad []byte) ([]byte, error) {
+	pointLen := 1 + 2*((curve.Params().BitSize+7)/8)
+	aead, err := shareCipher(curve, sharedX, ciphertext[:pointLen], label)
+	if err != nil {
+		return nil, err
+	}
+	rest := ciphertext[pointLen:]
+	if len(rest) < aead.NonceSize() {
+		return nil, errShareDecryption
+	}
+	plaintext, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], ad)
+	if err != nil {
+		return nil, errShareDecryption
+	}
+	return plaintext, nil
+}
+
+func shareCipher(curve elliptic.Curve, sharedX *big.Int, ephemeral []byte, label string) (cipher.AEAD, error) {
+	secret := sharedX.FillBytes(make([]byte, (curve.Params().BitSize+7)/8))
+	defer clear(secret)
+	key, err := hkdf.Key(sha256.New, secret, ephemeral, label, 32)
+	defer clear(key)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func shareAD(from, to *big.Int) []byte {
+	w := &TranscriptWriter{h: sha256.New()}
+	w.WriteInt(from)
+	w.WriteInt(to)
+	return w.Sum()
+}
@@ -0,0 +1,42 @@
+package dkg
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestShareEncryption(t *testing.T) {
+	curve := elliptic.P256()
+	recipient, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from, to := big.NewInt(1), big.NewInt(2)
+	shares := SecretShares{big.NewInt(12345), new(big.Int).Sub(curve.Params().N, one), curve}
+
+	ciphertext, err := encryptShares(curve, &recipient.PublicKey, from, to, shares, rand.Reader)
+	if err != nil {
+		t.Fatalf("Could not encrypt shares: %v", err)
+	}
+	decrypted, err := decryptShares(curve, SoftwareIdentity(recipient), from, to, ciphertext)
+	if err != nil || decrypted.Share1.Cmp(shares.Share1) != 0 || decrypted.Share2.Cmp(shares.Share2) != 0 {
+		t.Errorf("Shares %v round-tripped to %v (%v)", shares, decrypted, err)
+	}
+
+	if _, err := decryptShares(curve, SoftwareIdentity(other), from, to, ciphertext); err == nil {
+		t.Errorf("Decrypted shares with another participant's key")
+	}
+	if _, err := decryptShares(curve, SoftwareIdentity(recipient), to, from, ciphertext); err == nil {
+		t.Errorf("Decrypted shares with swapped sender and recipient")
+	}
+	if _, err := decryptShares(curve, SoftwareIdentity(recipient), from, to, ciphertext[:40]); err == nil {
+		t.Errorf("Decrypted truncated ciphertext")
+	}
+}
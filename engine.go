@@ -0,0 +1,262 @@
+package dkg
+
+import "context"
+import "time"
+
+// OutgoingMessage is a message an Engine has its caller send: to
+// participant To, or to every other participant if To is nil.
+type OutgoingMessage = Message
+
+// Engine is the ceremony of a ProtocolRunner, stepped by its caller instead
+// of driving itself: it starts no goroutines, sets no timers and touches no
+// transport, so that an embedder such as a consensus engine can run it
+// deterministically in its own event loop. The caller feeds it received
+// messages and sends the messages each step returns. A phase ends when
+// every expected message arrived or when the caller calls Timeout, which
+// applies the liveness policy to the participants still missing. Engines
+// don't retry or calibrate timeouts; an Engine is not safe for concurrent
+// use.
+type Engine struct {
+	r           *ProtocolRunner
+	awaiting    []*participant
+	types       []MessageType
+	accusations map[string][]*participant
+	dealers     []*participant
+	agreeing    bool
+	exposed     []*participant
+}
+
+// NewEngine prepares the ceremony of node among participants, like
+// NewProtocolRunner.
+func NewEngine(node *Node, participants *ParticipantSet) (*Engine, error) {
+	r, err := NewProtocolRunner(node, participants, nil)
+	if err != nil {
+		return nil, err
+	}
+	r.ctx = context.Background()
+	r.stepped = true
+	return &Engine{r: r}, nil
+}
+
+// Liveness sets the engine's liveness policy. It must be called before
+// Start.
+func (e *Engine) Liveness(policy LivenessPolicy) {
+	e.r.liveness = policy
+}
+
+// AgreeOnQualified sets the engine's agreement policy. It must be called
+// before Start.
+func (e *Engine) AgreeOnQualified(policy AgreementPolicy) {
+	e.r.agreement = policy
+}
+
+// Subscribe has f called with the engine's events, synchronously.
+func (e *Engine) Subscribe(f func(Event)) {
+	e.r.Subscribe(f)
+}
+
+func (e *Engine) Phase() Phase {
+	return e.r.Phase()
+}
+
+// Result returns the node's key share once the ceremony finished, or the
+// error that aborted it.
+func (e *Engine) Result() (*KeyShare, error) {
+	return e.r.Result()
+}
+
+// Start begins dealing.
+func (e *Engine) Start() ([]OutgoingMessage, error) {
+	if phase := e.r.Phase(); phase != PhaseIdle {
+		return nil, IllegalTransitionError{phase, PhaseDealing}
+	}
+	return e.step(func() error {
+		if err := e.r.deal(); err != nil {
+			return err
+		}
+		e.await(e.r.participants, dealingTypes...)
+		return nil
+	})
+}
+
+// HandleMessage processes a message received from another participant,
+// holding on to messages of later phases, and ends the phase if it was the
+// last one expected.
+func (e *Engine) HandleMessage(m Message) ([]OutgoingMessage, error) {
+	return e.step(func() error {
+		e.r.received(m)
+		return nil
+	})
+}
+
+// Timeout ends the current phase without the messages still missing.
+func (e *Engine) Timeout() ([]OutgoingMessage, error) {
+	return e.step(func() error {
+		missing := e.r.missing(e.awaiting, e.types...)
+		if len(missing) == 0 {
+			return nil
+		}
+		err := TimeoutError{e.r.Phase(), missing, nil}
+		e.r.fault(err)
+		if err := e.r.missed(err); err != nil {
+			return err
+		}
+		return e.next()
+	})
+}
+
+// step runs f in a phase in progress, moves on through the phases whose
+// messages all arrived and returns the messages to send. An error aborts
+// the ceremony.
+func (e *Engine) step(f func() error) ([]OutgoingMessage, error) {
+	r := e.r
+	if phase := r.Phase(); phase == PhaseFinished || phase == PhaseAborted {
+		_, err := r.Result()
+		return nil, err
+	}
+	err := f()
+	for err == nil && e.types != nil && r.Phase() != PhaseFinished && len(r.missing(e.awaiting, e.types...)) == 0 {
+		err = e.next()
+	}
+	out := r.outgoing
+	r.outgoing = nil
+	if err != nil {
+		e.abort(err)
+		return out, err
+	}
+	if r.Phase() == PhaseFinished {
+		r.wipe()
+		result, _ := r.Result()
+		r.instrumentation.CeremonyDone(nil)
+		r.emit(CeremonyComplete{result, nil})
+	}
+	return out, nil
+}
+
+func (e *Engine) abort(err error) {
+	r := e.r
+	r.wipe()
+	r.mu.Lock()
+	r.err = err
+	r.checker.Transition(PhaseAborted)
+	r.mu.Unlock()
+	r.instrumentation.CeremonyDone(err)
+	r.emit(CeremonyComplete{nil, err})
+}
+
+func (e *Engine) await(ps []*participant, ts ...MessageType) {
+	e.awaiting, e.types = ps, ts
+}
+
+// next ends the current phase and starts the following one.
+func (e *Engine) next() error {
+	r := e.r
+	switch r.Phase() {
+	case PhaseDealing:
+		if err := r.complain(); err != nil {
+			return err
+		}
+		e.await(r.participants, ComplaintsMessage)
+		return nil
+	case PhaseComplaining:
+		if e.accusations = r.accusations(); len(e.accusations) > 0 {
+			dealers, err := r.justify(e.accusations)
+			if err != nil {
+				return err
+			}
+			e.dealers = dealers
+			e.await(dealers, JustificationMessage)
+			return nil
+		}
+		return e.extract(make(map[string]error))
+	case PhaseJustifying:
+		return e.extract(r.judge(e.dealers, e.accusations))
+	case PhaseExtracting:
+		if r.agreement != NoAgreement && !e.agreeing {
+			e.agreeing = true
+			e.await(r.participants, QualifiedSetMessage)
+			return nil
+		}
+		if err := r.contest(); err != nil {
+			return err
+		}
+		e.await(r.live(), CoefficientComplaintsMessage)
+		return nil
+	case PhaseContesting:
+		if r.contested() {
+			e.exposed = r.exposed()
+			if err := r.reconstruct(e.exposed); err != nil {
+				return err
+			}
+			e.await(r.live(), ReconstructionMessage)
+			return nil
+		}
+		return r.finish()
+	case PhaseReconstructing:
+		exposed, err := r.agreeOnExposed(e.exposed)
+		if err != nil {
+			return err
+		}
+		if err := r.recoverCoefficients(exposed); err != nil {
+			return err
+		}
+		return r.finish()
+	}
+	return IllegalTransitionError{r.Phase(), r.Phase() + 1}
+}
+
+func (e *Engine) extract(disqualified map[string]error) error {
+	r := e.r
+	self, err := r.qualify(disqualified)
+	if err != nil {
+		return err
+	}
+	if err := r.extract(self); err != nil {
+		return err
+	}
+	e.await(r.qualified, PublicCoefficientsMessage)
+	return nil
+}
+
+// Run drives the engine over transport until the ceremony finished or
+// aborted, ending each phase after the node's timeout at the latest, or
+// aborting the ceremony once ctx is done.
+func (e *Engine) Run(ctx context.Context, transport Transport) error {
+	deliver := func(out []OutgoingMessage) {
+		for _, m := range out {
+			e.r.sent(m)
+			if m.To == nil {
+				transport.Broadcast(m)
+			} else {
+				transport.Send(m.To, m)
+			}
+		}
+	}
+	out, err := e.Start()
+	deliver(out)
+	timer := time.NewTimer(e.r.node.timeout)
+	defer timer.Stop()
+	receive := transport.Receive()
+	for phase := e.Phase(); err == nil && phase != PhaseFinished; {
+		select {
+		case m, ok := <-receive:
+			if !ok {
+				// a closed transport leaves the phases to time out
+				receive = nil
+				continue
+			}
+			out, err = e.HandleMessage(m)
+		case <-timer.C:
+			out, err = e.Timeout()
+		case <-ctx.Done():
+			e.abort(ctx.Err())
+			return ctx.Err()
+		}
+		deliver(out)
+		if next := e.Phase(); next != phase {
+			phase = next
+			timer.Reset(e.r.node.timeout)
+		}
+	}
+	return err
+}
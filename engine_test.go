@@ -0,0 +1,120 @@
+package dkg
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+// stepEnginesForTesting delivers the messages of engines to each other in
+// order until none are left, leaving out the messages of silent ones, then
+// times out the phases of the engines still running, until all are done.
+func stepEnginesForTesting(t *testing.T, engines []*Engine, silent map[int]bool) {
+	var queue []OutgoingMessage
+	send := func(i int, out []OutgoingMessage, err error) {
+		if err != nil {
+			t.Errorf("Engine %v failed: %v", i, err)
+		}
+		if !silent[i] {
+			queue = append(queue, out...)
+		}
+	}
+	for i, e := range engines {
+		out, err := e.Start()
+		send(i, out, err)
+	}
+	for {
+		for len(queue) > 0 {
+			m := queue[0]
+			queue = queue[1:]
+			for i, e := range engines {
+				if silent[i] || e.r.node.id.Cmp(m.From) == 0 || m.To != nil && e.r.node.id.Cmp(m.To) != 0 {
+					continue
+				}
+				out, err := e.HandleMessage(m)
+				send(i, out, err)
+			}
+		}
+		running := false
+		for i, e := range engines {
+			if !silent[i] && e.Phase() != PhaseFinished && e.Phase() != PhaseAborted {
+				running = true
+				out, err := e.Timeout()
+				send(i, out, err)
+			}
+		}
+		if !running {
+			return
+		}
+	}
+}
+
+func TestEngine(t *testing.T) {
+	const size, threshold = 5, 2
+	newEngines := func() []*Engine {
+		nodes, participants := getCeremonyNodesForTesting(t, size, threshold)
+		set, _ := NewParticipantSet(nodes[0].curve, threshold, participants)
+		engines := make([]*Engine, size)
+		for i, node := range nodes {
+			e, err := NewEngine(node, set)
+			if err != nil {
+				t.Fatal(err)
+			}
+			engines[i] = e
+		}
+		return engines
+	}
+	results := func(engines []*Engine, silent map[int]bool) []*KeyShare {
+		var shares []*KeyShare
+		for i, e := range engines {
+			if silent[i] {
+				continue
+			}
+			share, err := e.Result()
+			if err != nil {
+				t.Errorf("Engine %v failed: %v", i, err)
+			}
+			shares = append(shares, share)
+		}
+		return shares
+	}
+
+	t.Run("Stepped", func(t *testing.T) {
+		engines := newEngines()
+		stepEnginesForTesting(t, engines, nil)
+		checkCeremonyResultsForTesting(t, results(engines, nil))
+		if _, err := engines[0].Start(); err == nil {
+			t.Errorf("Started a finished engine")
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		engines := newEngines()
+		silent := map[int]bool{4: true}
+		stepEnginesForTesting(t, engines, silent)
+		shares := results(engines, silent)
+		checkCeremonyResultsForTesting(t, shares)
+		if len(shares[0].Qualified) != size-1 {
+			t.Errorf("Qualified %v", shares[0].Qualified)
+		}
+	})
+
+	t.Run("Run", func(t *testing.T) {
+		engines := newEngines()
+		network := NewMemoryNetwork()
+		var wg sync.WaitGroup
+		for _, e := range engines {
+			transport := network.Transport(e.r.node.id)
+			defer transport.Close()
+			wg.Add(1)
+			go func(e *Engine) {
+				defer wg.Done()
+				if err := e.Run(context.Background(), transport); err != nil {
+					t.Errorf("Ceremony failed: %v", err)
+				}
+			}(e)
+		}
+		wg.Wait()
+		checkCeremonyResultsForTesting(t, results(engines, nil))
+	})
+}
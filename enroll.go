@@ -0,0 +1,259 @@
+package dkg
+
+import "io"
+import "math/big"
+
+// Enrollment gives a new participant a share of an existing key, at an ID
+// of its own, without a resharing ceremony and without anyone learning the
+// key or another participant's share. The share of the new participant r
+// is f(r) = sum of l_j(r) * s_j over threshold+1 helpers j, with l_j(r) the
+// Lagrange coefficient of helper j at r. Each helper splits its term
+// l_j(r) * s_j into random pieces, one per helper, so that no helper sees
+// another's term (DealEnrollment); each helper adds up the pieces it
+// received (CombineEnrollment) and the new participant adds up the sums
+// (CompleteEnrollment). The helpers publish commitments to their pieces,
+// against which each piece and sum is verified, and whose sums are checked
+// against the helpers' public shares.
+
+// EnrollmentPiece is a piece of helper Helper's term, for helper Recipient.
+type EnrollmentPiece struct {
+	Helper, Recipient *big.Int
+	Share             *big.Int
+}
+
+// EnrollmentCommitment commits to the pieces of helper Helper's term, in
+// the order of the helpers.
+type EnrollmentCommitment struct {
+	Helper *big.Int
+	Pieces PointTuple
+}
+
+// EnrollmentContribution is helper Helper's sum of the pieces it received,
+// for the new participant.
+type EnrollmentContribution struct {
+	Helper *big.Int
+	Share  *big.Int
+}
+
+// DealEnrollment splits share's term of the new participant id's share
+// among helpers, threshold+1 holders of the key including share's, and
+// commits to the pieces. The pieces are for the helpers of the same
+// index, the commitment for all of them and the new participant.
+func DealEnrollment(share *KeyShare, id *big.Int, helpers []*big.Int, random io.Reader) ([]EnrollmentPiece, EnrollmentCommitment, error) {
+	if err := share.usable(); err != nil {
+		return nil, EnrollmentCommitment{}, err
+	}
+	curve := share.PublicKey.Curve
+	n := curve.Params().N
+	xs, self, err := enrollmentHelpers(share.Group(), id, helpers, share.ID)
+	if err != nil {
+		return nil, EnrollmentCommitment{}, err
+	}
+
+	// the last piece is the term minus the random others
+	f := scalarFieldFor(n)
+	last := f.element()
+	defer clear(last)
+	f.mulAdd(last, lagrangeCoefficientAt(xs[self], new(big.Int).Mod(id, n), xs, n), share.Share)
+	pieces := make([]EnrollmentPiece, len(helpers))
+	commitment := EnrollmentCommitment{share.ID, make(PointTuple, len(helpers))}
+	for i, h := range helpers {
+		var piece *big.Int
+		if i < len(helpers)-1 {
+			if piece, err = randomScalar(n, random); err != nil {
+				return nil, EnrollmentCommitment{}, err
+			}
+			k := f.fromBig(piece)
+			f.sub(last, last, k)
+			clear(k)
+		} else {
+			piece = f.toBig(last)
+		}
+		pieces[i] = EnrollmentPiece{share.ID, h, piece}
+		commitment.Pieces[i].X, commitment.Pieces[i].Y = curve.ScalarBaseMult(scalarBytes(curve, piece))
+	}
+	return pieces, commitment, nil
+}
+
+// CombineEnrollment adds up the pieces share's holder received from the
+// helpers, after checking each against its helper's commitment, and the
+// commitments against the helpers' public shares.
+func CombineEnrollment(share *KeyShare, id *big.Int, helpers []*big.Int, commitments []EnrollmentCommitment, pieces []EnrollmentPiece) (EnrollmentContribution, error) {
+	if err := share.usable(); err != nil {
+		return EnrollmentContribution{}, err
+	}
+	group := share.Group()
+	curve := group.PublicKey.Curve
+	n := curve.Params().N
+	xs, self, err := enrollmentHelpers(group, id, helpers, share.ID)
+	if err != nil {
+		return EnrollmentContribution{}, err
+	}
+	byHelper, err := enrollmentCommitments(group, id, xs, commitments)
+	if err != nil {
+		return EnrollmentContribution{}, err
+	}
+
+	f := scalarFieldFor(n)
+	sum := f.element()
+	defer clear(sum)
+	received := make(map[string]bool)
+	for _, p := range pieces {
+		if p.Helper == nil || p.Recipient == nil || new(big.Int).Mod(p.Recipient, n).Cmp(xs[self]) != 0 {
+			return EnrollmentContribution{}, InvalidEnrollmentError{p.Helper}
+		}
+		key := new(big.Int).Mod(p.Helper, n).String()
+		c, ok := byHelper[key]
+		if !ok || received[key] || p.Share == nil || !isNormalizedScalar(p.Share, n) {
+			return EnrollmentContribution{}, InvalidEnrollmentError{p.Helper}
+		}
+		if x, y := curve.ScalarBaseMult(scalarBytes(curve, p.Share)); x.Cmp(c.Pieces[self].X) != 0 || y.Cmp(c.Pieces[self].Y) != 0 {
+			return EnrollmentContribution{}, InvalidEnrollmentError{p.Helper}
+		}
+		received[key] = true
+		f.addBig(sum, p.Share)
+	}
+	for i, x := range xs {
+		if !received[x.String()] {
+			return EnrollmentContribution{}, InvalidEnrollmentError{helpers[i]}
+		}
+	}
+	return EnrollmentContribution{share.ID, f.toBig(sum)}, nil
+}
+
+// CompleteEnrollment returns the new participant id's share of group from
+// the helpers' commitments and contributions, checking each.
+func CompleteEnrollment(group GroupKey, id *big.Int, helpers []*big.Int, commitments []EnrollmentCommitment, contributions []EnrollmentContribution) (*KeyShare, error) {
+	curve := group.PublicKey.Curve
+	n := curve.Params().N
+	xs, _, err := enrollmentHelpers(group, id, helpers, nil)
+	if err != nil {
+		return nil, err
+	}
+	byHelper, err := enrollmentCommitments(group, id, xs, commitments)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int)
+	for i, x := range xs {
+		index[x.String()] = i
+	}
+	f := scalarFieldFor(n)
+	share := f.element()
+	defer clear(share)
+	received := make(map[string]bool)
+	for _, c := range contributions {
+		if c.Helper == nil || c.Share == nil || !isNormalizedScalar(c.Share, n) {
+			return nil, InvalidEnrollmentError{c.Helper}
+		}
+		key := new(big.Int).Mod(c.Helper, n).String()
+		i, ok := index[key]
+		if !ok || received[key] {
+			return nil, InvalidEnrollmentError{c.Helper}
+		}
+		// the sum of the pieces the helper received
+		var ex, ey *big.Int
+		for _, commitment := range byHelper {
+			pt := commitment.Pieces[i]
+			if ex == nil {
+				ex, ey = pt.X, pt.Y
+			} else {
+				ex, ey = curve.Add(ex, ey, pt.X, pt.Y)
+			}
+		}
+		if x, y := curve.ScalarBaseMult(scalarBytes(curve, c.Share)); x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+			return nil, InvalidEnrollmentError{c.Helper}
+		}
+		received[key] = true
+		f.addBig(share, c.Share)
+	}
+	for i, x := range xs {
+		if !received[x.String()] {
+			return nil, InvalidEnrollmentError{helpers[i]}
+		}
+	}
+
+	result := &KeyShare{
+		ID:                 id,
+		Epoch:              group.Epoch,
+		Threshold:          group.Threshold,
+		PublicKey:          group.PublicKey,
+		PublicCoefficients: group.PublicCoefficients,
+		Share:              f.toBig(share),
+	}
+	if !VerifyDealtShare(curve, DealtShare{id, result.Share}, group.PublicCoefficients) {
+		return nil, ShareVerificationError{id, "enrolled share"}
+	}
+	return result, nil
+}
+
+// enrollmentHelpers checks that helpers are threshold+1 distinct
+// participants other than the new participant id, and returns their IDs
+// mod N and the index of self among them, if given.
+func enrollmentHelpers(group GroupKey, id *big.Int, helpers []*big.Int, self *big.Int) ([]*big.Int, int, error) {
+	curve := group.PublicKey.Curve
+	n := curve.Params().N
+	if !validParticipantID(id, n) {
+		return nil, 0, InvalidParticipantIDError{id}
+	}
+	if len(helpers) != group.Threshold+1 {
+		return nil, 0, InvalidThresholdError{group.Threshold, len(helpers)}
+	}
+	if err := validateIDs(curve, group.Threshold, append([]*big.Int{id}, helpers...)); err != nil {
+		return nil, 0, err
+	}
+	xs := make([]*big.Int, len(helpers))
+	index := -1
+	for i, h := range helpers {
+		xs[i] = new(big.Int).Mod(h, n)
+		if self != nil && xs[i].Cmp(new(big.Int).Mod(self, n)) == 0 {
+			index = i
+		}
+	}
+	if self != nil && index < 0 {
+		return nil, 0, UnknownParticipantError{self}
+	}
+	return xs, index, nil
+}
+
+// enrollmentCommitments checks that there is one commitment per helper,
+// whose pieces add up to the helper's term: its public share times its
+// Lagrange coefficient at id. It returns them by helper.
+func enrollmentCommitments(group GroupKey, id *big.Int, xs []*big.Int, commitments []EnrollmentCommitment) (map[string]EnrollmentCommitment, error) {
+	curve := group.PublicKey.Curve
+	n := curve.Params().N
+	at := new(big.Int).Mod(id, n)
+	helpers := make(map[string]bool)
+	for _, x := range xs {
+		helpers[x.String()] = true
+	}
+	byHelper := make(map[string]EnrollmentCommitment)
+	for _, c := range commitments {
+		if c.Helper == nil {
+			return nil, InvalidEnrollmentError{nil}
+		}
+		x := new(big.Int).Mod(c.Helper, n)
+		if !helpers[x.String()] || byHelper[x.String()].Helper != nil ||
+			len(c.Pieces) != len(xs) || !validCommitments(curve, c.Pieces) {
+			return nil, InvalidEnrollmentError{c.Helper}
+		}
+		sx, sy := c.Pieces[0].X, c.Pieces[0].Y
+		for _, pt := range c.Pieces[1:] {
+			sx, sy = curve.Add(sx, sy, pt.X, pt.Y)
+		}
+		px, py := evaluateCommitments(curve, group.PublicCoefficients, x)
+		l := lagrangeCoefficientAt(x, at, xs, n)
+		ex, ey := curve.ScalarMult(px, py, scalarBytes(curve, l))
+		if sx.Cmp(ex) != 0 || sy.Cmp(ey) != 0 {
+			return nil, InvalidEnrollmentError{c.Helper}
+		}
+		byHelper[x.String()] = c
+	}
+	for i, x := range xs {
+		if _, ok := byHelper[x.String()]; !ok {
+			return nil, InvalidEnrollmentError{xs[i]}
+		}
+	}
+	return byHelper, nil
+}
@@ -0,0 +1,72 @@
+package dkg
+
+import (
+	"crypto/rand"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestEnrollment(t *testing.T) {
+	const size, threshold = 4, 2
+	nodes, participants := getCeremonyNodesForTesting(t, size, threshold)
+	shares := runCeremonyForTesting(t, nodes, participants)
+	checkCeremonyResultsForTesting(t, shares)
+	curve := shares[0].PublicKey.Curve
+	group := shares[0].Group()
+	id := big.NewInt(7)
+	helpers := []*big.Int{shares[0].ID, shares[1].ID, shares[3].ID}
+	helperShares := []*KeyShare{shares[0], shares[1], shares[3]}
+
+	var commitments []EnrollmentCommitment
+	pieces := make([][]EnrollmentPiece, len(helpers)) // by recipient
+	for _, share := range helperShares {
+		dealt, commitment, err := DealEnrollment(share, id, helpers, rand.Reader)
+		if err != nil {
+			t.Fatalf("Could not deal enrollment: %v", err)
+		}
+		commitments = append(commitments, commitment)
+		for i, p := range dealt {
+			pieces[i] = append(pieces[i], p)
+		}
+	}
+	var contributions []EnrollmentContribution
+	for i, share := range helperShares {
+		c, err := CombineEnrollment(share, id, helpers, commitments, pieces[i])
+		if err != nil {
+			t.Fatalf("Could not combine pieces: %v", err)
+		}
+		contributions = append(contributions, c)
+	}
+	enrolled, err := CompleteEnrollment(group, id, helpers, commitments, contributions)
+	if err != nil {
+		t.Fatalf("Could not complete enrollment: %v", err)
+	}
+	if !reflect.DeepEqual(enrolled.Group(), group) {
+		t.Errorf("Enrolled share is of group %+v", enrolled.Group())
+	}
+
+	// the new share stands in for any other
+	secret, _ := RecoverSecret(curve, threshold, []DealtShare{{shares[0].ID, shares[0].Share}, {shares[1].ID, shares[1].Share}, {shares[2].ID, shares[2].Share}})
+	recovered, err := RecoverSecret(curve, threshold, []DealtShare{{id, enrolled.Share}, {shares[2].ID, shares[2].Share}, {shares[3].ID, shares[3].Share}})
+	if err != nil || recovered.Cmp(secret) != 0 {
+		t.Errorf("Enrolled share recovers %v (%v), expected %v", recovered, err, secret)
+	}
+
+	t.Run("Tampered", func(t *testing.T) {
+		tampered := append([]EnrollmentContribution(nil), contributions...)
+		tampered[1].Share = new(big.Int).Add(tampered[1].Share, one)
+		if _, err := CompleteEnrollment(group, id, helpers, commitments, tampered); !reflect.DeepEqual(err, InvalidEnrollmentError{helpers[1]}) {
+			t.Errorf("Got unexpected error for a tampered contribution: %v", err)
+		}
+		forged := append([]EnrollmentCommitment(nil), commitments...)
+		forged[2].Pieces = append(PointTuple(nil), forged[2].Pieces...)
+		forged[2].Pieces[0] = forged[1].Pieces[0]
+		if _, err := CombineEnrollment(shares[0], id, helpers, forged, pieces[0]); !reflect.DeepEqual(err, InvalidEnrollmentError{helpers[2]}) {
+			t.Errorf("Got unexpected error for a forged commitment: %v", err)
+		}
+		if _, _, err := DealEnrollment(shares[0], helpers[1], helpers, rand.Reader); err == nil {
+			t.Errorf("Enrolled a helper")
+		}
+	})
+}
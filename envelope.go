@@ -0,0 +1,185 @@
+package dkg
+
+import "crypto"
+import "crypto/ecdsa"
+import "crypto/sha256"
+import "encoding/gob"
+import "math/big"
+import "sync"
+
+func init() {
+	gob.RegisterName("dkg.envelopePayload", envelopePayload{})
+}
+
+// envelopePayload binds a message's payload to its sender, recipient and
+// ceremony: the sender signs the session, a sequence number unique among its
+// messages of the session, the phase of the message and the message itself,
+// hashed with SHA-256.
+type envelopePayload struct {
+	Session   string
+	Sequence  uint64
+	Phase     Phase
+	Signature []byte
+	Payload   Hashable
+}
+
+func (e envelopePayload) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/envelope")
+	w.WriteBytes([]byte(e.Session))
+	w.WriteUint(e.Sequence)
+	w.WriteUint(uint64(e.Phase))
+	w.WriteBytes(e.Signature)
+	if e.Payload == nil {
+		w.WriteTag("")
+		return
+	}
+	w.Write(e.Payload)
+}
+
+type envelopeStatement struct {
+	session  string
+	sequence uint64
+	phase    Phase
+	m        Message
+}
+
+func (s envelopeStatement) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/envelope-statement")
+	w.WriteBytes([]byte(s.session))
+	w.WriteUint(s.sequence)
+	w.WriteUint(uint64(s.phase))
+	w.Write(s.m)
+}
+
+// EnvelopeTransport signs the messages a node sends with its identity key
+// and accepts only messages signed by their senders, per the participant
+// set, for the node or for everyone, of its session, in the phase their type
+// belongs to, and not seen before. Every participant must use one, with the
+// same session name, unique to the ceremony.
+type EnvelopeTransport struct {
+	transport    Transport
+	node         *Node
+	session      string
+	participants map[string]Participant
+	spec         []PhaseSpec
+	inbox        *mailbox
+
+	mu       sync.Mutex
+	sequence uint64
+	seen     map[string]map[uint64]bool
+}
+
+func NewEnvelopeTransport(node *Node, participants *ParticipantSet, session string, transport Transport) *EnvelopeTransport {
+	t := &EnvelopeTransport{
+		transport:    transport,
+		node:         node,
+		session:      session,
+		participants: make(map[string]Participant),
+		spec:         ProtocolSpec(),
+		inbox:        newMailbox(),
+		seen:         make(map[string]map[uint64]bool),
+	}
+	for _, p := range participants.participants {
+		t.participants[t.key(p.ID)] = p
+	}
+	go t.run()
+	return t
+}
+
+func (t *EnvelopeTransport) key(id *big.Int) string {
+	return new(big.Int).Mod(id, t.node.curve.Params().N).String()
+}
+
+func (t *EnvelopeTransport) seal(m Message) (Message, error) {
+	t.mu.Lock()
+	t.sequence++
+	sequence := t.sequence
+	t.mu.Unlock()
+
+	phase := messagePhase(t.spec, m.Type)
+	digest := HashOf(sha256.New(), envelopeStatement{t.session, sequence, phase, m})
+	sig, err := t.node.identity.Sign(t.node.random, digest, crypto.Hash(0))
+	if err != nil {
+		return Message{}, err
+	}
+	m.Payload = envelopePayload{t.session, sequence, phase, sig, m.Payload}
+	return m, nil
+}
+
+// open checks and unwraps a received message.
+func (t *EnvelopeTransport) open(m Message) (Message, bool) {
+	if m.From == nil || m.To != nil && t.key(m.To) != t.key(t.node.id) {
+		return Message{}, false
+	}
+	sender, ok := t.participants[t.key(m.From)]
+	if !ok {
+		return Message{}, false
+	}
+	m, sequence, ok := openEnvelope(t.spec, t.session, sender, m)
+	if !ok {
+		return Message{}, false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	seen, ok := t.seen[t.key(m.From)]
+	if !ok {
+		seen = make(map[uint64]bool)
+		t.seen[t.key(m.From)] = seen
+	}
+	if seen[sequence] {
+		return Message{}, false
+	}
+	seen[sequence] = true
+	return m, true
+}
+
+// openEnvelope checks that m was sealed by sender for session and returns
+// it unwrapped, with its sequence number.
+func openEnvelope(spec []PhaseSpec, session string, sender Participant, m Message) (Message, uint64, bool) {
+	e, ok := m.Payload.(envelopePayload)
+	if !ok || e.Session != session || e.Phase != messagePhase(spec, m.Type) {
+		return Message{}, 0, false
+	}
+	m.Payload = e.Payload
+	digest := HashOf(sha256.New(), envelopeStatement{e.Session, e.Sequence, e.Phase, m})
+	if !ecdsa.VerifyASN1(&sender.Key, digest, e.Signature) {
+		return Message{}, 0, false
+	}
+	return m, e.Sequence, true
+}
+
+func (t *EnvelopeTransport) run() {
+	defer t.inbox.close()
+	for m := range t.transport.Receive() {
+		if opened, ok := t.open(m); ok {
+			t.inbox.put(opened)
+		}
+	}
+}
+
+func (t *EnvelopeTransport) Send(to *big.Int, m Message) error {
+	sealed, err := t.seal(m)
+	if err != nil {
+		return err
+	}
+	return t.transport.Send(to, sealed)
+}
+
+func (t *EnvelopeTransport) Broadcast(m Message) error {
+	sealed, err := t.seal(m)
+	if err != nil {
+		return err
+	}
+	return t.transport.Broadcast(sealed)
+}
+
+func (t *EnvelopeTransport) Receive() <-chan Message {
+	return t.inbox.out
+}
+
+func (t *EnvelopeTransport) Close() error {
+	err := t.transport.Close()
+	t.inbox.close()
+	return err
+}
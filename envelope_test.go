@@ -0,0 +1,83 @@
+package dkg
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestEnvelopeTransport(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+	set, _ := NewParticipantSet(nodes[0].curve, 1, participants)
+
+	t.Run("Ceremony", func(t *testing.T) {
+		results := runCeremonyForTesting(t, nodes, participants, func(node *Node, transport Transport) Transport {
+			return NewEnvelopeTransport(node, set, "ceremony", transport)
+		})
+		for i, result := range results {
+			if result == nil || len(result.Qualified) != len(nodes) {
+				t.Errorf("Node %v did not finish with all dealers qualified", nodes[i].ID())
+			}
+		}
+		checkCeremonyResultsForTesting(t, results)
+	})
+
+	network := NewMemoryNetwork()
+	sender := NewEnvelopeTransport(nodes[0], set, "ceremony", network.Transport(nodes[0].id))
+	defer sender.Close()
+	receiver := NewEnvelopeTransport(nodes[1], set, "ceremony", network.Transport(nodes[1].id))
+	defer receiver.Close()
+	// the third participant intercepts and injects messages
+	attacker := network.Transport(nodes[2].id)
+	defer attacker.Close()
+
+	m := Message{ComplaintsMessage, nodes[0].id, nil, Complaints{}}
+	if err := sender.Broadcast(m); err != nil {
+		t.Fatal(err)
+	}
+	sealed := <-attacker.Receive()
+	if received := <-receiver.Receive(); received.Payload == nil || received.From.Cmp(m.From) != 0 {
+		t.Fatalf("Received %+v", received)
+	}
+
+	tamper := func(f func(m *Message, e *envelopePayload)) Message {
+		m := sealed
+		e := m.Payload.(envelopePayload)
+		f(&m, &e)
+		m.Payload = e
+		return m
+	}
+	for _, test := range []struct {
+		description string
+		m           Message
+	}{
+		{"replayed", sealed},
+		{"unsealed", m},
+		{"other session", tamper(func(m *Message, e *envelopePayload) { e.Session = "other" })},
+		{"other sequence", tamper(func(m *Message, e *envelopePayload) { e.Sequence++ })},
+		{"other phase", tamper(func(m *Message, e *envelopePayload) { e.Phase = PhaseDealing })},
+		{"other type", tamper(func(m *Message, e *envelopePayload) { m.Type = JustificationMessage; e.Phase = PhaseJustifying })},
+		{"other sender", tamper(func(m *Message, e *envelopePayload) { m.From = nodes[2].id })},
+		{"other recipient", tamper(func(m *Message, e *envelopePayload) { m.To = nodes[2].id })},
+		{"other payload", tamper(func(m *Message, e *envelopePayload) { e.Payload = Complaints{Accused: []*big.Int{nodes[2].id}} })},
+	} {
+		attacker.Send(nodes[1].id, test.m)
+		select {
+		case received := <-receiver.Receive():
+			t.Errorf("Accepted %v message %+v", test.description, received)
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+
+	// unicasts are bound to their recipient
+	if err := sender.Send(nodes[2].id, Message{SecretSharesMessage, nodes[0].id, nodes[2].id, EncryptedShares{}}); err != nil {
+		t.Fatal(err)
+	}
+	redirected := <-attacker.Receive()
+	attacker.Send(nodes[1].id, redirected)
+	select {
+	case received := <-receiver.Receive():
+		t.Errorf("Accepted redirected message %+v", received)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
@@ -1,9 +1,63 @@
 package dkg
 
+import "errors"
 import "fmt"
 import "crypto/elliptic"
 import "math/big"
 
+// ErrorCode classifies the errors of this package, for callers that branch
+// on the kind of failure rather than on error types; see CodeOf.
+type ErrorCode int
+
+const (
+	CodeUnclassified ErrorCode = iota
+	CodeInvalidParameter
+	CodeInvalidEncoding
+	CodeInvalidShare
+	CodeComplaint
+	CodeTimeout
+	CodeProtocolViolation
+	CodeCeremonyFailed
+	CodeInsufficientShares
+	CodeVerificationFailed
+	CodeKeyUnusable
+	CodeTransport
+	CodeSelfTest
+)
+
+var errorCodeNames = []string{
+	"unclassified", "invalid-parameter", "invalid-encoding", "invalid-share", "complaint", "timeout",
+	"protocol-violation", "ceremony-failed", "insufficient-shares", "verification-failed", "key-unusable",
+	"transport", "self-test",
+}
+
+func (c ErrorCode) String() string {
+	if c < 0 || int(c) >= len(errorCodeNames) {
+		return fmt.Sprintf("ErrorCode(%d)", int(c))
+	}
+	return errorCodeNames[c]
+}
+
+// CodeOf returns the code of the first error in err's chain that has one,
+// CodeUnclassified for errors from outside the package.
+func CodeOf(err error) ErrorCode {
+	var coded interface{ Code() ErrorCode }
+	if errors.As(err, &coded) {
+		return coded.Code()
+	}
+	return CodeUnclassified
+}
+
+// ParticipantsOf returns the participants the first error in err's chain
+// that names any holds responsible.
+func ParticipantsOf(err error) []*big.Int {
+	var blamed interface{ Participants() []*big.Int }
+	if errors.As(err, &blamed) {
+		return blamed.Participants()
+	}
+	return nil
+}
+
 type InvalidCurveScalarError struct {
 	curve elliptic.Curve
 	k     *big.Int
@@ -14,6 +68,10 @@ func (e InvalidCurveScalarError) Error() string {
 		e.curve.Params().Name, e.k.Bytes())
 }
 
+func (e InvalidCurveScalarError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
 type InvalidCurveScalarPolynomialError struct {
 	curve     elliptic.Curve
 	poly      ScalarPolynomial
@@ -21,8 +79,17 @@ type InvalidCurveScalarPolynomialError struct {
 }
 
 func (e InvalidCurveScalarPolynomialError) Error() string {
-	return fmt.Sprintf("dkg: invalid %v scalar polynomial %v (%v)",
-		e.curve.Params().Name, e.poly, e.subErrors)
+	// the coefficients are secret
+	return fmt.Sprintf("dkg: invalid %v scalar polynomial of %v coefficients (%v)",
+		e.curve.Params().Name, len(e.poly), e.subErrors)
+}
+
+func (e InvalidCurveScalarPolynomialError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+func (e InvalidCurveScalarPolynomialError) Unwrap() []error {
+	return e.subErrors
 }
 
 type InvalidScalarPolynomialLengthError struct {
@@ -33,6 +100,10 @@ func (e InvalidScalarPolynomialLengthError) Error() string {
 	return fmt.Sprintf("dkg: scalar polynomial lengths don't match: %v != %v", len(e.poly1), len(e.poly2))
 }
 
+func (e InvalidScalarPolynomialLengthError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
 type InvalidCurvePointError struct {
 	curve    elliptic.Curve
 	g2x, g2y *big.Int
@@ -44,3 +115,1077 @@ func (e InvalidCurvePointError) Error() string {
 		elliptic.Marshal(e.curve, e.g2x, e.g2y),
 	)
 }
+
+func (e InvalidCurvePointError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+type InvalidScalarEncodingError struct {
+	curve  elliptic.Curve
+	length int
+}
+
+func (e InvalidScalarEncodingError) Error() string {
+	return fmt.Sprintf("dkg: invalid %v scalar encoding length %v (expected %v)",
+		e.curve.Params().Name, e.length, ScalarSize(e.curve))
+}
+
+func (e InvalidScalarEncodingError) Code() ErrorCode {
+	return CodeInvalidEncoding
+}
+
+type IllegalTransitionError struct {
+	from, to Phase
+}
+
+func (e IllegalTransitionError) Error() string {
+	return fmt.Sprintf("dkg: illegal transition from %v to %v", e.from, e.to)
+}
+
+func (e IllegalTransitionError) Code() ErrorCode {
+	return CodeProtocolViolation
+}
+
+type UnexpectedMessageError struct {
+	phase Phase
+	mType MessageType
+}
+
+func (e UnexpectedMessageError) Error() string {
+	return fmt.Sprintf("dkg: unexpected %v message in %v phase", e.mType, e.phase)
+}
+
+func (e UnexpectedMessageError) Code() ErrorCode {
+	return CodeProtocolViolation
+}
+
+type InvalidThresholdError struct {
+	threshold, participants int
+}
+
+func (e InvalidThresholdError) Error() string {
+	return fmt.Sprintf("dkg: invalid threshold %v for %v participants", e.threshold, e.participants)
+}
+
+func (e InvalidThresholdError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+type InvalidParticipantIDError struct {
+	id *big.Int
+}
+
+func (e InvalidParticipantIDError) Error() string {
+	return fmt.Sprintf("dkg: invalid participant ID %v", e.id)
+}
+
+func (e InvalidParticipantIDError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+func (e InvalidParticipantIDError) Participants() []*big.Int {
+	return []*big.Int{e.id}
+}
+
+type DuplicateParticipantIDError struct {
+	id *big.Int
+}
+
+func (e DuplicateParticipantIDError) Error() string {
+	return fmt.Sprintf("dkg: duplicate participant ID %v", e.id)
+}
+
+func (e DuplicateParticipantIDError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+func (e DuplicateParticipantIDError) Participants() []*big.Int {
+	return []*big.Int{e.id}
+}
+
+type UnknownParticipantError struct {
+	id *big.Int
+}
+
+func (e UnknownParticipantError) Error() string {
+	return fmt.Sprintf("dkg: unknown participant %v", e.id)
+}
+
+func (e UnknownParticipantError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+func (e UnknownParticipantError) Participants() []*big.Int {
+	return []*big.Int{e.id}
+}
+
+type CeremonyIncompleteError struct {
+	phase Phase
+}
+
+func (e CeremonyIncompleteError) Error() string {
+	return fmt.Sprintf("dkg: ceremony incomplete, in %v phase", e.phase)
+}
+
+func (e CeremonyIncompleteError) Code() ErrorCode {
+	return CodeCeremonyFailed
+}
+
+type NoQualifiedDealersError struct{}
+
+func (e NoQualifiedDealersError) Error() string {
+	return "dkg: no qualified dealers"
+}
+
+func (e NoQualifiedDealersError) Code() ErrorCode {
+	return CodeCeremonyFailed
+}
+
+type ExtractionError struct {
+	dealer *big.Int
+}
+
+func (e ExtractionError) Error() string {
+	return fmt.Sprintf("dkg: dealer %v revealed no or inconsistent public coefficients", e.dealer)
+}
+
+func (e ExtractionError) Code() ErrorCode {
+	return CodeCeremonyFailed
+}
+
+func (e ExtractionError) Participants() []*big.Int {
+	return []*big.Int{e.dealer}
+}
+
+type InvalidParticipantKeyError struct {
+	id *big.Int
+}
+
+func (e InvalidParticipantKeyError) Error() string {
+	return fmt.Sprintf("dkg: invalid identity key for participant %v", e.id)
+}
+
+func (e InvalidParticipantKeyError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+func (e InvalidParticipantKeyError) Participants() []*big.Int {
+	return []*big.Int{e.id}
+}
+
+type InvalidSigningNoncesError struct {
+	id *big.Int
+}
+
+func (e InvalidSigningNoncesError) Error() string {
+	return fmt.Sprintf("dkg: signing nonces of %v don't match the key share", e.id)
+}
+
+func (e InvalidSigningNoncesError) Code() ErrorCode {
+	return CodeVerificationFailed
+}
+
+func (e InvalidSigningNoncesError) Participants() []*big.Int {
+	return []*big.Int{e.id}
+}
+
+type InsufficientSharesError struct {
+	have, need int
+}
+
+func (e InsufficientSharesError) Error() string {
+	return fmt.Sprintf("dkg: %v distinct shares, need %v", e.have, e.need)
+}
+
+func (e InsufficientSharesError) Code() ErrorCode {
+	return CodeInsufficientShares
+}
+
+type InvalidSignatureError struct{}
+
+func (e InvalidSignatureError) Error() string {
+	return "dkg: combined signature does not verify"
+}
+
+func (e InvalidSignatureError) Code() ErrorCode {
+	return CodeVerificationFailed
+}
+
+type InvalidEncodingError struct {
+	reason string
+}
+
+func (e InvalidEncodingError) Error() string {
+	return fmt.Sprintf("dkg: invalid binary encoding: %v", e.reason)
+}
+
+func (e InvalidEncodingError) Code() ErrorCode {
+	return CodeInvalidEncoding
+}
+
+type InvalidMnemonicError struct {
+	reason string
+}
+
+func (e InvalidMnemonicError) Error() string {
+	return fmt.Sprintf("dkg: invalid mnemonic: %v", e.reason)
+}
+
+func (e InvalidMnemonicError) Code() ErrorCode {
+	return CodeInvalidEncoding
+}
+
+type MixedEpochError struct {
+	expected, got uint64
+}
+
+func (e MixedEpochError) Error() string {
+	return fmt.Sprintf("dkg: share of epoch %v where epoch %v was expected", e.got, e.expected)
+}
+
+func (e MixedEpochError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+type CurveMismatchError struct {
+	expected, got elliptic.Curve
+}
+
+func (e CurveMismatchError) Error() string {
+	return fmt.Sprintf("dkg: expected curve %v, got %v", e.expected.Params().Name, e.got.Params().Name)
+}
+
+func (e CurveMismatchError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+type InvalidReshareDealingError struct {
+	dealer *big.Int
+}
+
+func (e InvalidReshareDealingError) Error() string {
+	return fmt.Sprintf("dkg: invalid reshare dealing from %v", e.dealer)
+}
+
+func (e InvalidReshareDealingError) Code() ErrorCode {
+	return CodeVerificationFailed
+}
+
+func (e InvalidReshareDealingError) Participants() []*big.Int {
+	return []*big.Int{e.dealer}
+}
+
+type SealedDataError struct{}
+
+func (e SealedDataError) Error() string {
+	return "dkg: wrong passphrase or corrupted sealed data"
+}
+
+func (e SealedDataError) Code() ErrorCode {
+	return CodeVerificationFailed
+}
+
+type BroadcastQuorumError struct {
+	acked, quorum int
+}
+
+func (e BroadcastQuorumError) Error() string {
+	return fmt.Sprintf("dkg: broadcast reached %v peers, need %v", e.acked, e.quorum)
+}
+
+func (e BroadcastQuorumError) Code() ErrorCode {
+	return CodeTransport
+}
+
+type CrossGroupQuorumError struct {
+	signed, required int
+}
+
+func (e CrossGroupQuorumError) Error() string {
+	return fmt.Sprintf("dkg: %v groups signed, need %v", e.signed, e.required)
+}
+
+func (e CrossGroupQuorumError) Code() ErrorCode {
+	return CodeInsufficientShares
+}
+
+type DuplicateSessionError struct {
+	session string
+}
+
+func (e DuplicateSessionError) Error() string {
+	return fmt.Sprintf("dkg: session %q already used", e.session)
+}
+
+func (e DuplicateSessionError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+type UnknownSessionError struct {
+	session string
+}
+
+func (e UnknownSessionError) Error() string {
+	return fmt.Sprintf("dkg: no open session %q", e.session)
+}
+
+func (e UnknownSessionError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+type QuorumMismatchError struct {
+	size, needed int
+}
+
+func (e QuorumMismatchError) Error() string {
+	return fmt.Sprintf("dkg: quorum of %v signers, need exactly %v", e.size, e.needed)
+}
+
+func (e QuorumMismatchError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+type GeneratorDerivationError struct {
+	curve elliptic.Curve
+}
+
+func (e GeneratorDerivationError) Error() string {
+	return fmt.Sprintf("dkg: could not derive a second generator on %v", e.curve.Params().Name)
+}
+
+func (e GeneratorDerivationError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+type InvalidCeremonyBundleError struct {
+	broadcast int
+	reason    string
+}
+
+func (e InvalidCeremonyBundleError) Error() string {
+	return fmt.Sprintf("dkg: invalid ceremony bundle at broadcast %v: %v", e.broadcast, e.reason)
+}
+
+func (e InvalidCeremonyBundleError) Code() ErrorCode {
+	return CodeVerificationFailed
+}
+
+type InvalidAuditLogError struct {
+	log    int
+	reason string
+}
+
+func (e InvalidAuditLogError) Error() string {
+	return fmt.Sprintf("dkg: invalid audit log %v: %v", e.log, e.reason)
+}
+
+func (e InvalidAuditLogError) Code() ErrorCode {
+	return CodeVerificationFailed
+}
+
+type InvalidTimestampError struct {
+	reason string
+}
+
+func (e InvalidTimestampError) Error() string {
+	return fmt.Sprintf("dkg: invalid timestamp: %v", e.reason)
+}
+
+func (e InvalidTimestampError) Code() ErrorCode {
+	return CodeVerificationFailed
+}
+
+// TimestampRequestError is returned when a timestamp authority can't be
+// reached or refuses a request.
+type TimestampRequestError struct {
+	err error
+}
+
+func (e TimestampRequestError) Error() string {
+	return fmt.Sprintf("dkg: timestamp request failed: %v", e.err)
+}
+
+func (e TimestampRequestError) Unwrap() error {
+	return e.err
+}
+
+func (e TimestampRequestError) Code() ErrorCode {
+	return CodeTransport
+}
+
+type InvalidPayloadError struct {
+	mType MessageType
+}
+
+func (e InvalidPayloadError) Error() string {
+	return fmt.Sprintf("dkg: invalid %v payload", e.mType)
+}
+
+func (e InvalidPayloadError) Code() ErrorCode {
+	return CodeProtocolViolation
+}
+
+// InvalidShareError reports that the shares a dealer dealt to the node
+// didn't decrypt or verify, for the reason it wraps.
+type InvalidShareError struct {
+	dealer *big.Int
+	err    error
+}
+
+func (e InvalidShareError) Error() string {
+	return fmt.Sprintf("dkg: invalid shares from dealer %v: %v", e.dealer, e.err)
+}
+
+func (e InvalidShareError) Code() ErrorCode {
+	return CodeInvalidShare
+}
+
+func (e InvalidShareError) Participants() []*big.Int {
+	return []*big.Int{e.dealer}
+}
+
+func (e InvalidShareError) Unwrap() error {
+	return e.err
+}
+
+// ComplaintError reports that a dealer was disqualified on its accusers'
+// complaints, wrapping why its justification failed, if it sent one.
+type ComplaintError struct {
+	dealer   *big.Int
+	accusers []*big.Int
+	err      error
+}
+
+func (e ComplaintError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("dkg: dealer %v disqualified on the complaints of %v", e.dealer, e.accusers)
+	}
+	return fmt.Sprintf("dkg: dealer %v disqualified on the complaints of %v: %v", e.dealer, e.accusers, e.err)
+}
+
+func (e ComplaintError) Code() ErrorCode {
+	return CodeComplaint
+}
+
+// Participants returns the dealer.
+func (e ComplaintError) Participants() []*big.Int {
+	return []*big.Int{e.dealer}
+}
+
+func (e ComplaintError) Accusers() []*big.Int {
+	return e.accusers
+}
+
+func (e ComplaintError) Unwrap() error {
+	return e.err
+}
+
+// TimeoutError reports the participants whose messages of a phase were
+// still missing when it ended, wrapping the context's error if that ended
+// it.
+type TimeoutError struct {
+	phase   Phase
+	missing []*big.Int
+	err     error
+}
+
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("dkg: %v phase timed out waiting for %v", e.phase, e.missing)
+}
+
+func (e TimeoutError) Code() ErrorCode {
+	return CodeTimeout
+}
+
+func (e TimeoutError) Participants() []*big.Int {
+	return e.missing
+}
+
+func (e TimeoutError) Phase() Phase {
+	return e.phase
+}
+
+func (e TimeoutError) Unwrap() error {
+	return e.err
+}
+
+// ProtocolViolationError reports a participant that sent something the
+// protocol doesn't allow, for the reason it wraps.
+type ProtocolViolationError struct {
+	participant *big.Int
+	err         error
+}
+
+func (e ProtocolViolationError) Error() string {
+	return fmt.Sprintf("dkg: participant %v violated the protocol: %v", e.participant, e.err)
+}
+
+func (e ProtocolViolationError) Code() ErrorCode {
+	return CodeProtocolViolation
+}
+
+func (e ProtocolViolationError) Participants() []*big.Int {
+	return []*big.Int{e.participant}
+}
+
+func (e ProtocolViolationError) Unwrap() error {
+	return e.err
+}
+
+type InvalidInsuranceShardError struct {
+	index *big.Int
+}
+
+func (e InvalidInsuranceShardError) Error() string {
+	if e.index == nil {
+		return "dkg: insurance backup doesn't match its key share"
+	}
+	return fmt.Sprintf("dkg: insurance shard %v is missing or doesn't match the backup", e.index)
+}
+
+func (e InvalidInsuranceShardError) Code() ErrorCode {
+	return CodeVerificationFailed
+}
+
+type MissingNodeParameterError struct {
+	field string
+}
+
+func (e MissingNodeParameterError) Error() string {
+	return fmt.Sprintf("dkg: node config lacks %v", e.field)
+}
+
+func (e MissingNodeParameterError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+// InMemoryOnlyError is returned for persisting the secrets of a node made
+// WithInMemoryOnly, or of its key shares.
+type InMemoryOnlyError struct {
+	what string
+}
+
+func (e InMemoryOnlyError) Error() string {
+	return fmt.Sprintf("dkg: %v is in-memory only", e.what)
+}
+
+func (e InMemoryOnlyError) Code() ErrorCode {
+	return CodeKeyUnusable
+}
+
+// MemoryLockError is returned when the secrets of an in-memory only node
+// can't be locked in RAM, such as for lack of RLIMIT_MEMLOCK.
+type MemoryLockError struct {
+	err error
+}
+
+func (e MemoryLockError) Error() string {
+	return fmt.Sprintf("dkg: could not lock secrets in memory: %v", e.err)
+}
+
+func (e MemoryLockError) Unwrap() error {
+	return e.err
+}
+
+type ConflictingNodeParametersError struct {
+	field, other string
+}
+
+func (e ConflictingNodeParametersError) Error() string {
+	return fmt.Sprintf("dkg: node config sets %v with %v", e.field, e.other)
+}
+
+func (e ConflictingNodeParametersError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+type InvalidWeightError struct {
+	id     *big.Int
+	weight int
+}
+
+func (e InvalidWeightError) Error() string {
+	return fmt.Sprintf("dkg: participant %v has invalid weight %v", e.id, e.weight)
+}
+
+func (e InvalidWeightError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+func (e InvalidWeightError) Participants() []*big.Int {
+	return []*big.Int{e.id}
+}
+
+type InvalidDerivationError struct {
+	depth int
+	index uint32
+}
+
+func (e InvalidDerivationError) Error() string {
+	if e.index >= HardenedIndex {
+		return fmt.Sprintf("dkg: can't derive hardened child %v at depth %v from a shared key", e.index, e.depth)
+	}
+	return fmt.Sprintf("dkg: child %v at depth %v is invalid", e.index, e.depth)
+}
+
+func (e InvalidDerivationError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+type UnsupportedCurveError struct {
+	curve  elliptic.Curve
+	format string
+}
+
+func (e UnsupportedCurveError) Error() string {
+	return fmt.Sprintf("dkg: no %v encoding of %v keys", e.format, e.curve.Params().Name)
+}
+
+func (e UnsupportedCurveError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+type QuorumLostError struct {
+	timeout    TimeoutError
+	live, need int
+}
+
+func (e QuorumLostError) Error() string {
+	return fmt.Sprintf("dkg: %v participants met the deadlines, need %v: %v", e.live, e.need, e.timeout)
+}
+
+func (e QuorumLostError) Code() ErrorCode {
+	return CodeCeremonyFailed
+}
+
+func (e QuorumLostError) Unwrap() error {
+	return e.timeout
+}
+
+type RateLimitedError struct {
+	id *big.Int
+}
+
+func (e RateLimitedError) Error() string {
+	return fmt.Sprintf("dkg: participant %v exceeded its message rate", e.id)
+}
+
+func (e RateLimitedError) Code() ErrorCode {
+	return CodeTransport
+}
+
+func (e RateLimitedError) Participants() []*big.Int {
+	return []*big.Int{e.id}
+}
+
+type MessageTooLargeError struct {
+	id          *big.Int
+	size, limit int
+}
+
+func (e MessageTooLargeError) Error() string {
+	return fmt.Sprintf("dkg: message of %v bytes from participant %v exceeds %v", e.size, e.id, e.limit)
+}
+
+func (e MessageTooLargeError) Code() ErrorCode {
+	return CodeInvalidEncoding
+}
+
+func (e MessageTooLargeError) Participants() []*big.Int {
+	return []*big.Int{e.id}
+}
+
+// PeerAuthenticationError reports a TLS peer whose certificate key isn't
+// the identity key of participant id, or of any participant if id is nil.
+type PeerAuthenticationError struct {
+	id *big.Int
+}
+
+func (e PeerAuthenticationError) Error() string {
+	if e.id == nil {
+		return "dkg: peer didn't authenticate as a participant"
+	}
+	return fmt.Sprintf("dkg: peer didn't authenticate as participant %v", e.id)
+}
+
+func (e PeerAuthenticationError) Code() ErrorCode {
+	return CodeTransport
+}
+
+func (e PeerAuthenticationError) Participants() []*big.Int {
+	if e.id == nil {
+		return nil
+	}
+	return []*big.Int{e.id}
+}
+
+type NoncesReusedError struct {
+	id *big.Int
+}
+
+func (e NoncesReusedError) Error() string {
+	return fmt.Sprintf("dkg: signing nonces of %v were already used", e.id)
+}
+
+func (e NoncesReusedError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+func (e NoncesReusedError) Participants() []*big.Int {
+	return []*big.Int{e.id}
+}
+
+type InvalidPartialSignatureError struct {
+	id *big.Int
+}
+
+func (e InvalidPartialSignatureError) Error() string {
+	return fmt.Sprintf("dkg: partial signature of %v does not verify", e.id)
+}
+
+func (e InvalidPartialSignatureError) Code() ErrorCode {
+	return CodeVerificationFailed
+}
+
+func (e InvalidPartialSignatureError) Participants() []*big.Int {
+	return []*big.Int{e.id}
+}
+
+// SchemeMismatchError is returned for a partial passed to a ThresholdScheme
+// it doesn't belong to.
+type SchemeMismatchError struct {
+	scheme string
+}
+
+func (e SchemeMismatchError) Error() string {
+	return fmt.Sprintf("dkg: partial is not of the %v scheme", e.scheme)
+}
+
+func (e SchemeMismatchError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+// MissingRoundParameterError is returned when the SchemeRound of an
+// operation lacks what its scheme needs.
+type MissingRoundParameterError struct {
+	scheme, field string
+}
+
+func (e MissingRoundParameterError) Error() string {
+	return fmt.Sprintf("dkg: %v round lacks %v", e.scheme, e.field)
+}
+
+func (e MissingRoundParameterError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+type InvalidPVSSDealingError struct {
+	dealer *big.Int
+}
+
+func (e InvalidPVSSDealingError) Error() string {
+	return fmt.Sprintf("dkg: invalid PVSS dealing from %v", e.dealer)
+}
+
+func (e InvalidPVSSDealingError) Code() ErrorCode {
+	return CodeVerificationFailed
+}
+
+func (e InvalidPVSSDealingError) Participants() []*big.Int {
+	return []*big.Int{e.dealer}
+}
+
+type InvalidVRFPartialError struct {
+	id *big.Int
+}
+
+func (e InvalidVRFPartialError) Error() string {
+	return fmt.Sprintf("dkg: invalid VRF partial from %v", e.id)
+}
+
+func (e InvalidVRFPartialError) Code() ErrorCode {
+	return CodeVerificationFailed
+}
+
+func (e InvalidVRFPartialError) Participants() []*big.Int {
+	return []*big.Int{e.id}
+}
+
+type InvalidVRFOutputError struct{}
+
+func (e InvalidVRFOutputError) Error() string {
+	return "dkg: VRF output doesn't match its partials"
+}
+
+func (e InvalidVRFOutputError) Code() ErrorCode {
+	return CodeVerificationFailed
+}
+
+type InvalidDecryptionShareError struct {
+	id *big.Int
+}
+
+func (e InvalidDecryptionShareError) Error() string {
+	return fmt.Sprintf("dkg: invalid decryption share from %v", e.id)
+}
+
+func (e InvalidDecryptionShareError) Code() ErrorCode {
+	return CodeVerificationFailed
+}
+
+func (e InvalidDecryptionShareError) Participants() []*big.Int {
+	return []*big.Int{e.id}
+}
+
+type DecryptionError struct{}
+
+func (e DecryptionError) Error() string {
+	return "dkg: could not decrypt ciphertext"
+}
+
+func (e DecryptionError) Code() ErrorCode {
+	return CodeVerificationFailed
+}
+
+type InvalidEnrollmentError struct {
+	helper *big.Int
+}
+
+func (e InvalidEnrollmentError) Error() string {
+	return fmt.Sprintf("dkg: invalid enrollment contribution from %v", e.helper)
+}
+
+func (e InvalidEnrollmentError) Code() ErrorCode {
+	return CodeVerificationFailed
+}
+
+func (e InvalidEnrollmentError) Participants() []*big.Int {
+	return []*big.Int{e.helper}
+}
+
+type QualifiedSetMismatchError struct {
+	participants []*big.Int
+}
+
+func (e QualifiedSetMismatchError) Error() string {
+	return fmt.Sprintf("dkg: participants %v qualified different dealers", e.participants)
+}
+
+func (e QualifiedSetMismatchError) Code() ErrorCode {
+	return CodeCeremonyFailed
+}
+
+func (e QualifiedSetMismatchError) Participants() []*big.Int {
+	return e.participants
+}
+
+// ExposedSetMismatchError is returned when participants reveal shares of
+// different dealers to reconstruct their public coefficients.
+type ExposedSetMismatchError struct {
+	participants []*big.Int
+}
+
+func (e ExposedSetMismatchError) Error() string {
+	return fmt.Sprintf("dkg: participants %v exposed different dealers", e.participants)
+}
+
+func (e ExposedSetMismatchError) Code() ErrorCode {
+	return CodeCeremonyFailed
+}
+
+func (e ExposedSetMismatchError) Participants() []*big.Int {
+	return e.participants
+}
+
+type KeyUsageError struct {
+	operation KeyOperation
+	reason    string
+}
+
+func (e KeyUsageError) Error() string {
+	return fmt.Sprintf("dkg: key share can't be used for %v: %v", e.operation, e.reason)
+}
+
+func (e KeyUsageError) Code() ErrorCode {
+	return CodeKeyUnusable
+}
+
+type PolynomialsReusedError struct {
+	id *big.Int
+}
+
+func (e PolynomialsReusedError) Error() string {
+	return fmt.Sprintf("dkg: node %v already dealt its polynomials among other participants", e.id)
+}
+
+func (e PolynomialsReusedError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+type ProverReusedError struct{}
+
+func (e ProverReusedError) Error() string {
+	return "dkg: proof already answered a challenge"
+}
+
+func (e ProverReusedError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+type CoinTossError struct {
+	index int
+}
+
+func (e CoinTossError) Error() string {
+	return fmt.Sprintf("dkg: coin toss contribution %v is missing or doesn't match its commitment", e.index)
+}
+
+func (e CoinTossError) Code() ErrorCode {
+	return CodeVerificationFailed
+}
+
+type SelfTestError struct {
+	backend, check string
+}
+
+func (e SelfTestError) Error() string {
+	return fmt.Sprintf("dkg: self-test of %v failed: %v", e.backend, e.check)
+}
+
+func (e SelfTestError) Code() ErrorCode {
+	return CodeSelfTest
+}
+
+type ShareVerificationError struct {
+	dealer *big.Int
+	check  string
+}
+
+func (e ShareVerificationError) Error() string {
+	return fmt.Sprintf("dkg: shares from dealer %v fail the %v check", e.dealer, e.check)
+}
+
+func (e ShareVerificationError) Code() ErrorCode {
+	return CodeInvalidShare
+}
+
+func (e ShareVerificationError) Participants() []*big.Int {
+	return []*big.Int{e.dealer}
+}
+
+type InvalidRevocationError struct{}
+
+func (e InvalidRevocationError) Error() string {
+	return "dkg: revocation is not signed by the revoked key"
+}
+
+func (e InvalidRevocationError) Code() ErrorCode {
+	return CodeVerificationFailed
+}
+
+type ShareDestroyedError struct{}
+
+func (e ShareDestroyedError) Error() string {
+	return "dkg: secret share was destroyed"
+}
+
+func (e ShareDestroyedError) Code() ErrorCode {
+	return CodeKeyUnusable
+}
+
+type InvalidWatermarkError struct{}
+
+func (e InvalidWatermarkError) Error() string {
+	return "dkg: key share carries no valid watermark"
+}
+
+func (e InvalidWatermarkError) Code() ErrorCode {
+	return CodeVerificationFailed
+}
+
+type KeyRevokedError struct {
+	reason string
+}
+
+func (e KeyRevokedError) Error() string {
+	return fmt.Sprintf("dkg: group key revoked: %v", e.reason)
+}
+
+func (e KeyRevokedError) Code() ErrorCode {
+	return CodeKeyUnusable
+}
+
+type RotationInProgressError struct {
+	epoch uint64
+}
+
+func (e RotationInProgressError) Error() string {
+	return fmt.Sprintf("dkg: rotation to epoch %v in progress", e.epoch)
+}
+
+func (e RotationInProgressError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+type NoRotationError struct{}
+
+func (e NoRotationError) Error() string {
+	return "dkg: no rotation in progress"
+}
+
+func (e NoRotationError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+type GroupKeyMismatchError struct{}
+
+func (e GroupKeyMismatchError) Error() string {
+	return "dkg: group has another group key"
+}
+
+func (e GroupKeyMismatchError) Code() ErrorCode {
+	return CodeInvalidParameter
+}
+
+type GroupRetiredError struct {
+	epoch uint64
+}
+
+func (e GroupRetiredError) Error() string {
+	return fmt.Sprintf("dkg: group of epoch %v was retired", e.epoch)
+}
+
+func (e GroupRetiredError) Code() ErrorCode {
+	return CodeKeyUnusable
+}
+
+// EscrowRefusedError reports that an escrow provider's policy refused to
+// release the share of a participant, for the reason it wraps.
+type EscrowRefusedError struct {
+	id  *big.Int
+	err error
+}
+
+func (e EscrowRefusedError) Error() string {
+	return fmt.Sprintf("dkg: escrow refused to release the share of %v: %v", e.id, e.err)
+}
+
+func (e EscrowRefusedError) Code() ErrorCode {
+	return CodeKeyUnusable
+}
+
+func (e EscrowRefusedError) Unwrap() error {
+	return e.err
+}
+
+type InvalidEscrowedShareError struct {
+	id *big.Int
+}
+
+func (e InvalidEscrowedShareError) Error() string {
+	return fmt.Sprintf("dkg: share escrowed for %v doesn't match the group", e.id)
+}
+
+func (e InvalidEscrowedShareError) Code() ErrorCode {
+	return CodeVerificationFailed
+}
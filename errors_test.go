@@ -0,0 +1,70 @@
+package dkg
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestErrorTaxonomy(t *testing.T) {
+	t.Run("Codes", func(t *testing.T) {
+		cause := ShareVerificationError{big.NewInt(3), "commitment equation"}
+		wrapped := fmt.Errorf("ceremony: %w", InvalidShareError{big.NewInt(3), cause})
+		if CodeOf(wrapped) != CodeInvalidShare {
+			t.Errorf("Got code %v for a wrapped share error", CodeOf(wrapped))
+		}
+		if ids := ParticipantsOf(wrapped); len(ids) != 1 || ids[0].Int64() != 3 {
+			t.Errorf("Got participants %v for a wrapped share error", ids)
+		}
+		var verification ShareVerificationError
+		if !errors.As(wrapped, &verification) || verification != cause {
+			t.Errorf("Share error doesn't unwrap to its cause")
+		}
+		if CodeOf(errors.New("other")) != CodeUnclassified || ParticipantsOf(errors.New("other")) != nil {
+			t.Errorf("Classified a foreign error")
+		}
+
+		poly := ScalarPolynomial{big.NewInt(0)}
+		err := InvalidCurveScalarPolynomialError{elliptic.P256(), poly, poly.validate(elliptic.P256())}
+		var scalar InvalidCurveScalarError
+		if !errors.As(err, &scalar) || CodeOf(err) != CodeInvalidParameter {
+			t.Errorf("Polynomial error doesn't unwrap to its scalar errors")
+		}
+		if CodeOf(TimeoutError{}).String() != "timeout" {
+			t.Errorf("Unexpected name %v", CodeOf(TimeoutError{}))
+		}
+	})
+
+	t.Run("Faults", func(t *testing.T) {
+		sim, err := NewSimulator(elliptic.P256(), 4, 1, 200*time.Millisecond, []byte("faults"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sim.Misbehave(big.NewInt(1), CorruptShares(big.NewInt(2)))
+		sim.Misbehave(big.NewInt(4), InconsistentCommitments())
+		if _, err := sim.Run(); err != nil {
+			t.Fatal(err)
+		}
+		faults := sim.Runners()[1].Faults()
+		var codes []ErrorCode
+		var blamed []int64
+		for _, fault := range faults {
+			codes = append(codes, CodeOf(fault))
+			blamed = append(blamed, ParticipantsOf(fault)[0].Int64())
+		}
+		// node 1 can't tell the swapped commitments apart, the proof of
+		// knowledge fails everywhere
+		expected := []ErrorCode{CodeInvalidShare, CodeInvalidShare, CodeComplaint, CodeProtocolViolation}
+		if !reflect.DeepEqual(codes, expected) || !reflect.DeepEqual(blamed, []int64{1, 4, 4, 4}) {
+			t.Errorf("Got faults %v", faults)
+		}
+		var complaint ComplaintError
+		if !errors.As(faults[2], &complaint) || len(complaint.Accusers()) != 2 {
+			t.Errorf("Complaint fault doesn't name the accusers: %v", faults[2])
+		}
+	})
+}
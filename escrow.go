@@ -0,0 +1,264 @@
+package dkg
+
+import "crypto/ecdsa"
+import "crypto/rand"
+import "io"
+import "math/big"
+import "time"
+
+// EscrowProvider keeps sealed recovery material for key shares and
+// releases it under its policy, so that a lost participant's share can be
+// restored, and the group's secret key recovered from threshold+1 escrowed
+// shares with RecoverFromEscrow after a disaster. Implementations must be
+// safe for concurrent use.
+type EscrowProvider interface {
+	// Deposit escrows share, replacing what was escrowed for its ID.
+	Deposit(share *KeyShare) error
+	// Release returns the share escrowed for participant id once the
+	// provider's policy approves request, or EscrowRefusedError.
+	Release(id *big.Int, request EscrowRequest) (*KeyShare, error)
+}
+
+// EscrowRequest asks an EscrowProvider to release escrowed shares.
+// Providers record who asked and why with their decision.
+type EscrowRequest struct {
+	Requester string
+	Reason    string
+	// Recipient is the identity of the machine recovering the shares, to
+	// whose key operators release their shards.
+	Recipient Identity
+}
+
+func (r EscrowRequest) recipientKey() (ecdsa.PublicKey, bool) {
+	if r.Recipient == nil {
+		return ecdsa.PublicKey{}, false
+	}
+	key, ok := r.Recipient.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return ecdsa.PublicKey{}, false
+	}
+	return *key, true
+}
+
+// EscrowOperator is one of the operators of an OperatorEscrow, holding a
+// shard of every escrowed share. It reviews each request, and releases its
+// shard of backup to the recipient's key with ReleaseShard or refuses.
+type EscrowOperator interface {
+	Custodian() Custodian
+	Approve(backup *InsuranceBackup, index *big.Int, request EscrowRequest) (ReleasedShard, error)
+}
+
+// LocalOperator is an EscrowOperator whose identity is at hand, such as in
+// an HSM of the operator's, approving the requests its policy accepts.
+type LocalOperator struct {
+	Name     string
+	Identity Identity
+	// Policy decides on requests; nil approves all of them.
+	Policy func(request EscrowRequest) error
+	// Random is crypto/rand's Reader if nil.
+	Random io.Reader
+}
+
+func (o *LocalOperator) Custodian() Custodian {
+	return Custodian{Name: o.Name, Key: *o.Identity.Public().(*ecdsa.PublicKey)}
+}
+
+func (o *LocalOperator) Approve(backup *InsuranceBackup, index *big.Int, request EscrowRequest) (ReleasedShard, error) {
+	recipient, ok := request.recipientKey()
+	if !ok {
+		return ReleasedShard{}, MissingRoundParameterError{"escrow", "Recipient"}
+	}
+	if o.Policy != nil {
+		if err := o.Policy(request); err != nil {
+			return ReleasedShard{}, err
+		}
+	}
+	random := o.Random
+	if random == nil {
+		random = rand.Reader
+	}
+	return backup.ReleaseShard(index, o.Identity, recipient, random)
+}
+
+// OperatorEscrow splits each escrowed share among its operators as an
+// InsuranceBackup, kept in storage under the share's ID. A share is
+// released once required operators approved the request, to the
+// recipient, the only place it is reassembled.
+type OperatorEscrow struct {
+	operators []EscrowOperator
+	required  int
+	storage   Storage
+	random    io.Reader
+}
+
+func NewOperatorEscrow(operators []EscrowOperator, required int, storage Storage, random io.Reader) (*OperatorEscrow, error) {
+	if required < 1 || required > len(operators) {
+		return nil, InvalidThresholdError{required - 1, len(operators)}
+	}
+	return &OperatorEscrow{operators, required, storage, random}, nil
+}
+
+func (e *OperatorEscrow) Deposit(share *KeyShare) error {
+	custodians := make([]Custodian, len(e.operators))
+	for i, o := range e.operators {
+		custodians[i] = o.Custodian()
+	}
+	backup, err := share.Insure(custodians, e.required, e.random)
+	if err != nil {
+		return err
+	}
+	b, err := backup.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return e.storage.SaveState(share.ID.String(), b)
+}
+
+func (e *OperatorEscrow) Release(id *big.Int, request EscrowRequest) (*KeyShare, error) {
+	data, err := e.storage.LoadState(id.String())
+	if err != nil {
+		return nil, err
+	}
+	var backup InsuranceBackup
+	if err := backup.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	if len(backup.Shards) != len(e.operators) {
+		return nil, InvalidInsuranceShardError{nil}
+	}
+	var released []ReleasedShard
+	for i, o := range e.operators {
+		if len(released) == e.required {
+			break
+		}
+		if r, err := o.Approve(&backup, backup.Shards[i].Index, request); err == nil {
+			released = append(released, r)
+		}
+	}
+	if len(released) < e.required {
+		err := EscrowRefusedError{id, InsufficientSharesError{len(released), e.required}}
+		return nil, recordRelease(e.storage, id, request, err)
+	}
+	share, err := backup.RecoverReleased(request.Recipient, released)
+	if err != nil {
+		return nil, err
+	}
+	// no share leaves the escrow unrecorded
+	if err := recordRelease(e.storage, id, request, nil); err != nil {
+		share.Zeroize()
+		return nil, err
+	}
+	return share, nil
+}
+
+// EscrowApprover runs the approval workflow of a KMSEscrow, such as a
+// ticket that two officers must sign off: Approve returns once request was
+// approved for the share of id, or why it wasn't.
+type EscrowApprover interface {
+	Approve(id *big.Int, request EscrowRequest) error
+}
+
+// KMSEscrow seals each escrowed share under a key held by a KMS, through
+// the Sealer wrapping its calls, and keeps it in storage under the share's
+// ID. A share is released once the approver approved the request.
+type KMSEscrow struct {
+	sealer   Sealer
+	approver EscrowApprover
+	storage  Storage
+}
+
+func NewKMSEscrow(sealer Sealer, approver EscrowApprover, storage Storage) *KMSEscrow {
+	return &KMSEscrow{sealer, approver, storage}
+}
+
+func (e *KMSEscrow) Deposit(share *KeyShare) error {
+	plaintext, err := share.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	defer clear(plaintext)
+	sealed, err := e.sealer.Seal(plaintext)
+	if err != nil {
+		return err
+	}
+	return e.storage.SaveState(share.ID.String(), sealed)
+}
+
+func (e *KMSEscrow) Release(id *big.Int, request EscrowRequest) (*KeyShare, error) {
+	sealed, err := e.storage.LoadState(id.String())
+	if err != nil {
+		return nil, err
+	}
+	if err := e.approver.Approve(id, request); err != nil {
+		return nil, recordRelease(e.storage, id, request, EscrowRefusedError{id, err})
+	}
+	plaintext, err := e.sealer.Open(sealed)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(plaintext)
+	share := new(KeyShare)
+	if err := share.UnmarshalBinary(plaintext); err != nil {
+		return nil, err
+	}
+	if err := recordRelease(e.storage, id, request, nil); err != nil {
+		share.Zeroize()
+		return nil, err
+	}
+	return share, nil
+}
+
+// recordRelease appends the decision on request to the transcript of id's
+// escrow, returning refused, or the error appending it.
+func recordRelease(storage Storage, id *big.Int, request EscrowRequest, refused error) error {
+	entry := encodeBinary(func(w *TranscriptWriter) {
+		w.WriteTag("dkg/escrow-release")
+		w.WriteUint(uint64(time.Now().Unix()))
+		w.WriteBytes([]byte(request.Requester))
+		w.WriteBytes([]byte(request.Reason))
+		if refused == nil {
+			w.WriteTag("released")
+		} else {
+			w.WriteTag("refused")
+			w.WriteBytes([]byte(refused.Error()))
+		}
+	})
+	if err := storage.AppendTranscript(id.String(), entry); err != nil {
+		return err
+	}
+	return refused
+}
+
+// RecoverFromEscrow recovers the group's secret key after a disaster, as
+// RecoverSecret does from the participants' shares: it releases the shares
+// of ids escrowed with escrow under request, one after the other, until
+// group.Threshold+1 of them check against the group's public coefficients.
+// Shares escrow refuses to release are skipped.
+func RecoverFromEscrow(escrow EscrowProvider, group GroupKey, ids []*big.Int, request EscrowRequest) (*big.Int, error) {
+	curve := group.PublicKey.Curve
+	var shares []DealtShare
+	defer func() {
+		for _, s := range shares {
+			zeroize(s.Share)
+		}
+	}()
+	for _, id := range ids {
+		if len(shares) > group.Threshold {
+			break
+		}
+		share, err := escrow.Release(id, request)
+		if err != nil {
+			continue
+		}
+		dealt := DealtShare{share.ID, share.Share}
+		if share.Epoch != group.Epoch || share.ID.Cmp(id) != 0 || !VerifyDealtShare(curve, dealt, group.PublicCoefficients) {
+			share.Zeroize()
+			return nil, InvalidEscrowedShareError{id}
+		}
+		shares = append(shares, dealt)
+	}
+	if len(shares) <= group.Threshold {
+		return nil, InsufficientSharesError{len(shares), group.Threshold + 1}
+	}
+	return RecoverSecret(curve, group.Threshold, shares)
+}
@@ -0,0 +1,124 @@
+package dkg
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+// approverForTesting approves the requests of its approved requesters.
+type approverForTesting map[string]bool
+
+func (a approverForTesting) Approve(id *big.Int, request EscrowRequest) error {
+	if !a[request.Requester] {
+		return errors.New("not approved")
+	}
+	return nil
+}
+
+func TestEscrow(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+	shares := runCeremonyForTesting(t, nodes, participants)
+	checkCeremonyResultsForTesting(t, shares)
+	group := shares[0].Group()
+	ids := make([]*big.Int, len(shares))
+	for i, share := range shares {
+		ids[i] = share.ID
+	}
+
+	machine, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := EscrowRequest{Requester: "board", Reason: "data center lost", Recipient: SoftwareIdentity(machine)}
+	refused := errors.New("refused")
+	var operators []EscrowOperator
+	for _, name := range []string{"alice", "bob", "carol"} {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		operator := &LocalOperator{Name: name, Identity: SoftwareIdentity(key)}
+		if name == "bob" {
+			operator.Policy = func(EscrowRequest) error { return refused }
+		}
+		operators = append(operators, operator)
+	}
+	if _, err := NewOperatorEscrow(operators, 4, NewMemoryStorage(), rand.Reader); reflect.TypeOf(err) != reflect.TypeOf(InvalidThresholdError{}) {
+		t.Errorf("Got unexpected error requiring more operators than there are: %v", err)
+	}
+	local, err := NewOperatorEscrow(operators, 2, NewMemoryStorage(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		name   string
+		escrow EscrowProvider
+		// a request the escrow refuses
+		refused EscrowRequest
+	}{
+		{"Operators", local, EscrowRequest{Requester: "board", Reason: "no recipient"}},
+		{"KMS", NewKMSEscrow(newAEADSealerForTesting(t, 1), approverForTesting{"board": true}, NewMemoryStorage()),
+			EscrowRequest{Requester: "intruder", Recipient: SoftwareIdentity(machine)}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			for _, share := range shares {
+				if err := c.escrow.Deposit(share); err != nil {
+					t.Fatalf("Could not escrow the share of %v: %v", share.ID, err)
+				}
+			}
+			released, err := c.escrow.Release(ids[1], request)
+			if err != nil {
+				t.Fatalf("Could not release the share of %v: %v", ids[1], err)
+			}
+			if released.ID.Cmp(ids[1]) != 0 || released.Share.Cmp(shares[1].Share) != 0 {
+				t.Errorf("Released another share")
+			}
+			if _, err := c.escrow.Release(ids[1], c.refused); reflect.TypeOf(err) != reflect.TypeOf(EscrowRefusedError{}) {
+				t.Errorf("Got unexpected error for a refused request: %v", err)
+			}
+
+			secret, err := RecoverFromEscrow(c.escrow, group, ids, request)
+			if err != nil {
+				t.Fatalf("Could not recover the group's key: %v", err)
+			}
+			x, y := group.PublicKey.Curve.ScalarBaseMult(secret.Bytes())
+			if x.Cmp(group.PublicKey.X) != 0 || y.Cmp(group.PublicKey.Y) != 0 {
+				t.Errorf("Recovered another key")
+			}
+			if _, err := RecoverFromEscrow(c.escrow, group, ids, c.refused); !reflect.DeepEqual(err, InsufficientSharesError{0, 2}) {
+				t.Errorf("Got unexpected error recovering on a refused request: %v", err)
+			}
+		})
+	}
+
+	t.Run("Too many refusals", func(t *testing.T) {
+		strict, err := NewOperatorEscrow(operators, 3, NewMemoryStorage(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := strict.Deposit(shares[0]); err != nil {
+			t.Fatal(err)
+		}
+		_, err = strict.Release(ids[0], request)
+		if !reflect.DeepEqual(err, EscrowRefusedError{ids[0], InsufficientSharesError{2, 3}}) {
+			t.Errorf("Got unexpected error with an operator refusing: %v", err)
+		}
+		if transcript, _ := strict.storage.Transcript(ids[0].String()); len(transcript) != 1 {
+			t.Errorf("Recorded %v decisions", len(transcript))
+		}
+	})
+
+	t.Run("Another group", func(t *testing.T) {
+		other := group
+		other.Epoch++
+		if _, err := RecoverFromEscrow(local, other, ids, request); !reflect.DeepEqual(err, InvalidEscrowedShareError{ids[0]}) {
+			t.Errorf("Got unexpected error for shares of another epoch: %v", err)
+		}
+	})
+}
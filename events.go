@@ -0,0 +1,64 @@
+package dkg
+
+import "math/big"
+
+// Event is a step of a ceremony, reported to the runner's subscribers:
+// PhaseStarted, ShareReceived, ComplaintFiled, ParticipantDisqualified or
+// CeremonyComplete.
+type Event interface {
+	event()
+}
+
+// PhaseStarted reports that the ceremony entered Phase.
+type PhaseStarted struct {
+	Phase Phase
+}
+
+// ShareReceived reports that the shares dealt by From arrived and could be
+// decrypted. They are verified in the complaint phase.
+type ShareReceived struct {
+	From *big.Int
+}
+
+// ComplaintFiled reports a valid complaint of Accuser, possibly the node
+// itself, against the dealer Accused.
+type ComplaintFiled struct {
+	Accuser *big.Int
+	Accused *big.Int
+}
+
+// ParticipantDisqualified reports that ID's shares are left out of the
+// group key, for Reason: a ComplaintError, a ProtocolViolationError for an
+// invalid proof of knowledge, or a TimeoutError for missing dealing
+// messages.
+type ParticipantDisqualified struct {
+	ID     *big.Int
+	Reason error
+}
+
+// CeremonyComplete reports the outcome of the ceremony: the key share, or
+// the error the ceremony failed with.
+type CeremonyComplete struct {
+	Share *KeyShare
+	Err   error
+}
+
+func (PhaseStarted) event()            {}
+func (ShareReceived) event()           {}
+func (ComplaintFiled) event()          {}
+func (ParticipantDisqualified) event() {}
+func (CeremonyComplete) event()        {}
+
+// Subscribe has the runner call f with the events of its ceremony, in the
+// order they happen, from the goroutine running the ceremony, which f must
+// not hold up. It must be called before Run.
+func (r *ProtocolRunner) Subscribe(f func(Event)) {
+	r.subscribers = append(r.subscribers, f)
+}
+
+func (r *ProtocolRunner) emit(e Event) {
+	r.logEvent(e)
+	for _, f := range r.subscribers {
+		f(e)
+	}
+}
@@ -0,0 +1,74 @@
+package dkg
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestEvents(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 4, 1)
+	network := NewMemoryNetwork()
+	var runners []*ProtocolRunner
+	for _, node := range nodes {
+		var transport Transport = network.Transport(node.ID())
+		defer transport.Close()
+		if node.ID().Int64() == 4 {
+			transport = misbehavingTransport{transport, []MisbehaviorPolicy{InconsistentCommitments()}}
+		}
+		set, _ := NewParticipantSet(node.curve, node.Threshold(), participants)
+		runner, err := NewProtocolRunner(node, set, transport)
+		if err != nil {
+			t.Fatal(err)
+		}
+		runners = append(runners, runner)
+	}
+	var events []Event
+	runners[1].Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+	var wg sync.WaitGroup
+	for _, runner := range runners {
+		wg.Add(1)
+		go func(r *ProtocolRunner) {
+			defer wg.Done()
+			r.Run()
+		}(runner)
+	}
+	wg.Wait()
+
+	var phases []Phase
+	var received, accusers []int64
+	var disqualified []ParticipantDisqualified
+	for _, e := range events {
+		switch e := e.(type) {
+		case PhaseStarted:
+			phases = append(phases, e.Phase)
+		case ShareReceived:
+			received = append(received, e.From.Int64())
+		case ComplaintFiled:
+			if e.Accused.Int64() != 4 {
+				t.Errorf("Complaint against %v", e.Accused)
+			}
+			accusers = append(accusers, e.Accuser.Int64())
+		case ParticipantDisqualified:
+			disqualified = append(disqualified, e)
+		}
+	}
+	if !reflect.DeepEqual(phases, []Phase{PhaseDealing, PhaseComplaining, PhaseJustifying, PhaseExtracting, PhaseContesting, PhaseFinished}) {
+		t.Errorf("Got phases %v", phases)
+	}
+	if len(received) != 3 {
+		t.Errorf("Got shares from %v", received)
+	}
+	if len(accusers) != 2 {
+		t.Errorf("Got complaints of %v", accusers)
+	}
+	if len(disqualified) != 1 || disqualified[0].ID.Int64() != 4 || CodeOf(disqualified[0].Reason) != CodeComplaint {
+		t.Errorf("Got disqualifications %v", disqualified)
+	}
+	share, _ := runners[1].Result()
+	if last, ok := events[len(events)-1].(CeremonyComplete); !ok || last.Share != share || last.Err != nil {
+		t.Errorf("Ceremony ended with %v", events[len(events)-1])
+	}
+}
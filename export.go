@@ -0,0 +1,206 @@
+package dkg
+
+import "crypto/aes"
+import "crypto/cipher"
+import "crypto/elliptic"
+import "crypto/rand"
+import "crypto/x509/pkix"
+import "encoding/asn1"
+import "encoding/hex"
+import "encoding/pem"
+import "io"
+
+// Exports of the group key and of key shares in formats other software
+// reads: PKIX and SEC1 public keys, Ethereum addresses and encrypted
+// PKCS#8 private keys.
+
+var (
+	oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+var curveOIDs = map[string]asn1.ObjectIdentifier{
+	"P-224":     {1, 3, 132, 0, 33},
+	"P-256":     {1, 2, 840, 10045, 3, 1, 7},
+	"P-384":     {1, 3, 132, 0, 34},
+	"P-521":     {1, 3, 132, 0, 35},
+	"secp256k1": {1, 3, 132, 0, 10},
+}
+
+// curveAlgorithm identifies EC keys on curve, which must have a standard
+// name.
+func curveAlgorithm(curve elliptic.Curve, format string) (pkix.AlgorithmIdentifier, error) {
+	oid, ok := curveOIDs[curve.Params().Name]
+	if !ok {
+		return pkix.AlgorithmIdentifier{}, UnsupportedCurveError{curve, format}
+	}
+	params, err := asn1.Marshal(oid)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, err
+	}
+	return pkix.AlgorithmIdentifier{Algorithm: oidPublicKeyECDSA, Parameters: asn1.RawValue{FullBytes: params}}, nil
+}
+
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// MarshalPKIX encodes the group's public key as a DER SubjectPublicKeyInfo.
+// Unlike x509.MarshalPKIXPublicKey, it also supports secp256k1.
+func (g GroupKey) MarshalPKIX() ([]byte, error) {
+	curve := g.PublicKey.Curve
+	algorithm, err := curveAlgorithm(curve, "PKIX")
+	if err != nil {
+		return nil, err
+	}
+	point := elliptic.Marshal(curve, g.PublicKey.X, g.PublicKey.Y)
+	return asn1.Marshal(subjectPublicKeyInfo{algorithm, asn1.BitString{Bytes: point, BitLength: 8 * len(point)}})
+}
+
+// MarshalPEM encodes the group's public key as a PEM "PUBLIC KEY" block.
+func (g GroupKey) MarshalPEM() ([]byte, error) {
+	der, err := g.MarshalPKIX()
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// MarshalCompressed encodes the group's public key as a compressed SEC1
+// point.
+func (g GroupKey) MarshalCompressed() []byte {
+	return elliptic.MarshalCompressed(g.PublicKey.Curve, g.PublicKey.X, g.PublicKey.Y)
+}
+
+// EthereumAddress returns the Ethereum address of a secp256k1 group key,
+// checksummed as EIP-55 specifies.
+func (g GroupKey) EthereumAddress() (string, error) {
+	curve := g.PublicKey.Curve
+	if curve.Params().Name != "secp256k1" {
+		return "", UnsupportedCurveError{curve, "Ethereum"}
+	}
+	point := elliptic.Marshal(curve, g.PublicKey.X, g.PublicKey.Y)
+	address := []byte(hex.EncodeToString(keccak256(point[1:])[12:]))
+	checksum := keccak256(address)
+	for i, c := range address {
+		if c >= 'a' && checksum[i/2]>>(4*(1-i%2))&0xf >= 8 {
+			address[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(address), nil
+}
+
+type ecPrivateKey struct {
+	Version    int
+	PrivateKey []byte
+	PublicKey  asn1.BitString `asn1:"optional,explicit,tag:1"`
+}
+
+type privateKeyInfo struct {
+	Version    int
+	Algorithm  pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+type pbkdf2Params struct {
+	Salt       []byte
+	Iterations int
+	KeyLength  int
+	PRF        pkix.AlgorithmIdentifier
+}
+
+type pbes2Params struct {
+	KeyDerivation pkix.AlgorithmIdentifier
+	Encryption    pkix.AlgorithmIdentifier
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// MarshalPKCS8 encodes the share as an EC private key, with the public
+// share as its public key, in a DER PKCS#8 EncryptedPrivateKeyInfo that
+// passphrase seals with PBES2: PBKDF2-HMAC-SHA256 and AES-256-CBC, as
+// OpenSSL reads. Only the secret is exported; the share's ID, the group key
+// and the other participants' public shares are public and go separately.
+func (s *KeyShare) MarshalPKCS8(passphrase []byte) ([]byte, error) {
+	if err := s.usable(); err != nil {
+		return nil, err
+	}
+	if s.inMemory {
+		return nil, InMemoryOnlyError{"key share"}
+	}
+	curve := s.PublicKey.Curve
+	algorithm, err := curveAlgorithm(curve, "PKCS#8")
+	if err != nil {
+		return nil, err
+	}
+	public := s.PublicShare(s.ID)
+	point := elliptic.Marshal(curve, public.X, public.Y)
+	secret := scalarBytes(curve, s.Share)
+	defer clear(secret)
+	key, err := asn1.Marshal(ecPrivateKey{1, secret, asn1.BitString{Bytes: point, BitLength: 8 * len(point)}})
+	if err != nil {
+		return nil, err
+	}
+	defer clear(key)
+	plaintext, err := asn1.Marshal(privateKeyInfo{0, algorithm, key})
+	if err != nil {
+		return nil, err
+	}
+	defer clear(plaintext)
+
+	salt := make([]byte, sealSaltSize)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+	kdf, err := asn1.Marshal(pbkdf2Params{salt, pbkdf2Iterations, 32, pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue}})
+	if err != nil {
+		return nil, err
+	}
+	ivParam, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdf}},
+		pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParam}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	block, err := passphraseBlock(passphrase, salt, pbkdf2Iterations)
+	if err != nil {
+		return nil, err
+	}
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	padded := append(append([]byte(nil), plaintext...), make([]byte, padding)...)
+	defer clear(padded)
+	for i := len(plaintext); i < len(padded); i++ {
+		padded[i] = byte(padding)
+	}
+	ciphertext := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		ciphertext,
+	})
+}
+
+// MarshalPKCS8PEM is MarshalPKCS8 in a PEM "ENCRYPTED PRIVATE KEY" block.
+func (s *KeyShare) MarshalPKCS8PEM(passphrase []byte) ([]byte, error) {
+	der, err := s.MarshalPKCS8(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der}), nil
+}
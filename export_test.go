@@ -0,0 +1,137 @@
+package dkg
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/mikalv/dkg/edwards25519"
+	"github.com/mikalv/dkg/secp256k1"
+)
+
+func TestKeccak256(t *testing.T) {
+	for _, test := range []struct{ in, out string }{
+		{"", "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"},
+		{"The quick brown fox jumps over the lazy dog", "4d741b6f1eb29cb2a9b9911c82f56fa8d73b04959d3d9d222895df6c0b28aa15"},
+	} {
+		if out := hex.EncodeToString(keccak256([]byte(test.in))); out != test.out {
+			t.Errorf("Keccak-256 of %q is %v, expected %v", test.in, out, test.out)
+		}
+	}
+}
+
+func TestExportGroupKey(t *testing.T) {
+	// the address of secret key 1
+	curve := secp256k1.S256()
+	g := GroupKey{PublicKey: ecdsa.PublicKey{Curve: curve, X: curve.Params().Gx, Y: curve.Params().Gy}}
+	if address, err := g.EthereumAddress(); err != nil || address != "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf" {
+		t.Errorf("Got unexpected Ethereum address %v (%v)", address, err)
+	}
+	der, err := g.MarshalPKIX()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	var oid asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(der, &spki); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := asn1.Unmarshal(spki.Algorithm.Parameters.FullBytes, &oid); err != nil || !oid.Equal(asn1.ObjectIdentifier{1, 3, 132, 0, 10}) {
+		t.Errorf("Got unexpected curve %v (%v)", oid, err)
+	}
+	if x, y := elliptic.Unmarshal(curve, spki.PublicKey.Bytes); x.Cmp(g.PublicKey.X) != 0 || y.Cmp(g.PublicKey.Y) != 0 {
+		t.Errorf("PKIX encoding holds another key")
+	}
+	if compressed := g.MarshalCompressed(); compressed[0] != byte(2+g.PublicKey.Y.Bit(0)) || !bytes.Equal(compressed[1:], g.PublicKey.X.FillBytes(make([]byte, 32))) {
+		t.Errorf("Got unexpected compressed encoding %x", compressed)
+	}
+
+	nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+	results := runCeremonyForTesting(t, nodes, participants)
+	checkCeremonyResultsForTesting(t, results)
+	group := results[0].Group()
+	expected, err := x509.MarshalPKIXPublicKey(&group.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if der, err := group.MarshalPKIX(); err != nil || !bytes.Equal(der, expected) {
+		t.Errorf("PKIX encoding differs from crypto/x509's (%v)", err)
+	}
+	if _, err := group.EthereumAddress(); !reflect.DeepEqual(err, UnsupportedCurveError{group.PublicKey.Curve, "Ethereum"}) {
+		t.Errorf("Got unexpected error for a P-256 address: %v", err)
+	}
+	ed := edwards25519.Curve()
+	if _, err := (GroupKey{PublicKey: ecdsa.PublicKey{Curve: ed, X: ed.Params().Gx, Y: ed.Params().Gy}}).MarshalPEM(); !reflect.DeepEqual(err, UnsupportedCurveError{ed, "PKIX"}) {
+		t.Errorf("Got unexpected error for an edwards25519 key: %v", err)
+	}
+}
+
+func TestExportKeyShare(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+	results := runCeremonyForTesting(t, nodes, participants)
+	checkCeremonyResultsForTesting(t, results)
+	passphrase := []byte("correct horse battery staple")
+	der, err := results[0].MarshalPKCS8(passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// decrypt with PBES2 as other software does
+	var info struct {
+		Algorithm     pkix.AlgorithmIdentifier
+		EncryptedData []byte
+	}
+	var params struct {
+		KeyDerivation pkix.AlgorithmIdentifier
+		Encryption    pkix.AlgorithmIdentifier
+	}
+	var kdf struct {
+		Salt       []byte
+		Iterations int
+		KeyLength  int
+		PRF        pkix.AlgorithmIdentifier
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := asn1.Unmarshal(params.KeyDerivation.Parameters.FullBytes, &kdf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := asn1.Unmarshal(params.Encryption.Parameters.FullBytes, &iv); err != nil {
+		t.Fatal(err)
+	}
+	block, err := passphraseBlock(passphrase, kdf.Salt, kdf.Iterations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, info.EncryptedData)
+	plaintext = plaintext[:len(plaintext)-int(plaintext[len(plaintext)-1])]
+	key, err := x509.ParsePKCS8PrivateKey(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public := results[0].PublicShare(results[0].ID)
+	if k := key.(*ecdsa.PrivateKey); k.D.Cmp(results[0].Share) != 0 || k.X.Cmp(public.X) != 0 {
+		t.Errorf("PKCS#8 encoding holds another key")
+	}
+
+	if _, err := (&KeyShare{PublicKey: ecdsa.PublicKey{Curve: edwards25519.Curve()}, Share: big.NewInt(1)}).MarshalPKCS8(passphrase); CodeOf(err) != CodeInvalidParameter {
+		t.Errorf("Got unexpected error for an edwards25519 share: %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package dkg
+
+import "math/big"
+import "sync"
+import "time"
+
+const fanoutRetries = 3
+
+// FanoutTransport broadcasts by sending to every peer in parallel. A
+// broadcast returns once quorum peers accepted the message, or with an
+// error once the timeout passed; peers that failed are retried in the
+// background, with backoff, and slow ones are left to finish.
+type FanoutTransport struct {
+	Transport
+	peers   []*big.Int
+	quorum  int
+	timeout time.Duration
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewFanoutTransport wraps transport to broadcast to peers, which should not
+// include the sender itself.
+func NewFanoutTransport(transport Transport, peers []*big.Int, quorum int, timeout time.Duration) *FanoutTransport {
+	return &FanoutTransport{
+		Transport: transport,
+		peers:     append([]*big.Int(nil), peers...),
+		quorum:    quorum,
+		timeout:   timeout,
+		closed:    make(chan struct{}),
+	}
+}
+
+func (t *FanoutTransport) Broadcast(m Message) error {
+	acks := make(chan bool, len(t.peers))
+	for _, peer := range t.peers {
+		t.wg.Add(1)
+		go func(peer *big.Int) {
+			defer t.wg.Done()
+			t.deliver(peer, m, acks)
+		}(peer)
+	}
+
+	timer := time.NewTimer(t.timeout)
+	defer timer.Stop()
+	acked := 0
+	for answered := 0; acked < t.quorum && answered < len(t.peers); answered++ {
+		select {
+		case ok := <-acks:
+			if ok {
+				acked++
+			}
+		case <-timer.C:
+			return BroadcastQuorumError{acked, t.quorum}
+		}
+	}
+	if acked < t.quorum {
+		return BroadcastQuorumError{acked, t.quorum}
+	}
+	return nil
+}
+
+// deliver reports the first attempt on acks, then keeps retrying a failed
+// send until it succeeds, the retries run out or the transport is closed.
+func (t *FanoutTransport) deliver(peer *big.Int, m Message, acks chan<- bool) {
+	err := t.Transport.Send(peer, m)
+	acks <- err == nil
+	backoff := t.timeout
+	for i := 0; err != nil && i < fanoutRetries; i++ {
+		select {
+		case <-t.closed:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		err = t.Transport.Send(peer, m)
+	}
+}
+
+// Close stops the retries, closes the underlying transport and waits for
+// the background sends to finish.
+func (t *FanoutTransport) Close() error {
+	t.closeOnce.Do(func() { close(t.closed) })
+	err := t.Transport.Close()
+	t.wg.Wait()
+	return err
+}
@@ -0,0 +1,66 @@
+package dkg
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyTransport delays sends to slow and fails the first send to each of
+// failing.
+type flakyTransport struct {
+	Transport
+	slow    string
+	delay   time.Duration
+	mu      sync.Mutex
+	failing map[string]bool
+}
+
+func (t *flakyTransport) Send(to *big.Int, m Message) error {
+	if to.String() == t.slow {
+		time.Sleep(t.delay)
+	}
+	t.mu.Lock()
+	fail := t.failing[to.String()]
+	delete(t.failing, to.String())
+	t.mu.Unlock()
+	if fail {
+		return errors.New("connection refused")
+	}
+	return t.Transport.Send(to, m)
+}
+
+func TestFanoutTransport(t *testing.T) {
+	ids := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4)}
+	net := NewMemoryNetwork()
+	transports := make([]Transport, len(ids))
+	for i, id := range ids {
+		transports[i] = net.Transport(id)
+		defer transports[i].Close()
+	}
+	flaky := &flakyTransport{Transport: transports[0], slow: "4", delay: time.Second, failing: map[string]bool{"3": true}}
+	fanout := NewFanoutTransport(flaky, ids[1:], 1, 50*time.Millisecond)
+
+	start := time.Now()
+	if err := fanout.Broadcast(Message{ComplaintsMessage, ids[0], nil, Complaints{}}); err != nil {
+		t.Fatalf("Broadcast failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Broadcast waited %v for the slowest peer", elapsed)
+	}
+	for i, tr := range transports[1:] {
+		if _, ok := receiveWithin(t, tr, 2*time.Second); !ok {
+			t.Errorf("Broadcast never reached %v", ids[i+1])
+		}
+	}
+
+	strict := NewFanoutTransport(&flakyTransport{Transport: transports[0], failing: map[string]bool{"2": true, "3": true, "4": true}}, ids[1:], 3, 50*time.Millisecond)
+	if err := strict.Broadcast(Message{ComplaintsMessage, ids[0], nil, Complaints{}}); reflect.TypeOf(err) != reflect.TypeOf(BroadcastQuorumError{}) {
+		t.Errorf("Got unexpected error for a broadcast short of its quorum: %v", err)
+	}
+	strict.Close()
+	fanout.Close()
+}
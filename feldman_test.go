@@ -0,0 +1,87 @@
+package dkg
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha512"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFeldmanVSS(t *testing.T) {
+	curve, _, _, _, zkParam, _, _, _, _, _ := getValidNodeParamsForTesting(t)
+	const size, threshold = 4, 1
+	nodes := make([]*Node, size)
+	participants := make([]Participant, size)
+	for i := range nodes {
+		key, _ := ecdsa.GenerateKey(curve, rand.Reader)
+		id := big.NewInt(int64(i + 1))
+		node, err := NewNodeWithOptions(
+			WithCurve(curve), WithHash(sha512.New512_256()), WithZKParam(zkParam), WithTimeout(200*time.Millisecond),
+			WithID(id), WithKey(*key), WithThreshold(threshold), WithFeldmanVSS(),
+		)
+		if err != nil {
+			t.Fatalf("Could not create node %v: %v", id, err)
+		}
+		nodes[i] = node
+		participants[i] = Participant{id, key.PublicKey}
+	}
+
+	if !reflect.DeepEqual(nodes[0].VerificationPoints(), nodes[0].PublicCoefficients()) {
+		t.Errorf("Verification points aren't the public coefficients")
+	}
+	proof, err := nodes[0].ProveSecretKnowledge(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !nodes[1].VerifySecretKnowledge(nodes[0].ID(), nodes[0].VerificationPoints(), proof) {
+		t.Errorf("Schnorr proof didn't verify")
+	}
+	proof.Response2 = big.NewInt(1)
+	if nodes[1].VerifySecretKnowledge(nodes[0].ID(), nodes[0].VerificationPoints(), proof) {
+		t.Errorf("Proof with a second response verified")
+	}
+
+	results := runCeremonyForTesting(t, nodes, participants)
+	for i, result := range results {
+		if result == nil || len(result.Qualified) != size {
+			t.Errorf("Node %v did not finish with all dealers qualified", nodes[i].ID())
+		}
+	}
+	checkCeremonyResultsForTesting(t, results)
+
+	t.Run("Pedersen shares", func(t *testing.T) {
+		pedersen, _ := getCeremonyNodesForTesting(t, 2, threshold)
+		share1 := pedersen[0].secretPoly1.evaluate(nodes[0].ID(), curve.Params().N)
+		share2 := pedersen[0].secretPoly2.evaluate(nodes[0].ID(), curve.Params().N)
+		err := nodes[0].VerifyShare(pedersen[0].ID(), share1, share2, pedersen[0].VerificationPoints())
+		if !reflect.DeepEqual(err, ShareVerificationError{pedersen[0].ID(), "second share range"}) {
+			t.Errorf("Got unexpected error for a Pedersen share: %v", err)
+		}
+	})
+
+	t.Run("Save", func(t *testing.T) {
+		saved, err := SaveNode(nodes[0], nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadNode(saved, nil, sha512.New512_256())
+		if err != nil {
+			t.Fatalf("Could not load node: %v", err)
+		}
+		if !loaded.feldman || !reflect.DeepEqual(loaded.VerificationPoints(), nodes[0].VerificationPoints()) {
+			t.Errorf("Loaded node lost its mode")
+		}
+	})
+
+	t.Run("Second polynomial", func(t *testing.T) {
+		key, _ := ecdsa.GenerateKey(curve, rand.Reader)
+		_, err := NewNode(curve, sha512.New512_256(), nil, nil, zkParam, time.Second, big.NewInt(1), *key,
+			randomPolynomialForTesting(t, curve, threshold), randomPolynomialForTesting(t, curve, threshold), WithFeldmanVSS())
+		if reflect.TypeOf(err) != reflect.TypeOf(InvalidCurveScalarPolynomialError{}) {
+			t.Errorf("Got unexpected error for a second polynomial: %v", err)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package dkg
+
+import "crypto/elliptic"
+import "math/big"
+
+// fixedBaseWindow is the bit width of the windows of a fixedBaseTable.
+const fixedBaseWindow = 4
+
+// fixedBaseTable holds the multiples d * 2^(w*i) * B of a base point B for
+// every w-bit window i of a scalar and digit d, so that multiplying B takes
+// one addition per window and no doubling. Lookups depend on the scalar's
+// digits, like the math/big arithmetic of the curves that benefit.
+type fixedBaseTable struct {
+	curve   elliptic.Curve
+	windows []PointTuple // digit d at d-1
+}
+
+func newFixedBaseTable(curve elliptic.Curve, x, y *big.Int) *fixedBaseTable {
+	t := &fixedBaseTable{curve: curve}
+	bits := curve.Params().N.BitLen()
+	for i := 0; i < bits; i += fixedBaseWindow {
+		window := make(PointTuple, 1<<fixedBaseWindow-1)
+		window[0].X, window[0].Y = x, y
+		for d := 1; d < len(window); d++ {
+			window[d].X, window[d].Y = curve.Add(window[d-1].X, window[d-1].Y, x, y)
+		}
+		t.windows = append(t.windows, window)
+		// the base of the next window is 2^w times this one's
+		for j := 0; j < fixedBaseWindow; j++ {
+			x, y = curve.Double(x, y)
+		}
+	}
+	return t
+}
+
+// mult returns k * B for a scalar k < N.
+func (t *fixedBaseTable) mult(k *big.Int) (x, y *big.Int) {
+	x, y = new(big.Int), new(big.Int)
+	found := false
+	for i, window := range t.windows {
+		d := 0
+		for j := fixedBaseWindow - 1; j >= 0; j-- {
+			d = d<<1 | int(k.Bit(i*fixedBaseWindow+j))
+		}
+		if d == 0 {
+			continue
+		}
+		p := window[d-1]
+		if !found {
+			x, y, found = p.X, p.Y, true
+			continue
+		}
+		x, y = t.curve.Add(x, y, p.X, p.Y)
+	}
+	if !found {
+		// the identity, however the curve represents it
+		return t.curve.ScalarBaseMult(nil)
+	}
+	return x, y
+}
+
+// generatorTables are the fixed-base tables of a ceremony's generators G
+// and G2, G2 only with Pedersen VSS.
+type generatorTables struct {
+	g, g2 *fixedBaseTable
+}
+
+func newGeneratorTables(curve elliptic.Curve, g2x, g2y *big.Int) *generatorTables {
+	params := curve.Params()
+	t := &generatorTables{g: newFixedBaseTable(curve, params.Gx, params.Gy)}
+	if g2x != nil {
+		t.g2 = newFixedBaseTable(curve, g2x, g2y)
+	}
+	return t
+}
+
+// baseMult returns k * G.
+func (p ceremonyParams) baseMult(k *big.Int) (x, y *big.Int) {
+	if p.tables != nil {
+		return p.tables.g.mult(k)
+	}
+	return p.curve.ScalarBaseMult(scalarBytes(p.curve, k))
+}
+
+// g2Mult returns k * G2.
+func (p ceremonyParams) g2Mult(k *big.Int) (x, y *big.Int) {
+	if p.tables != nil && p.tables.g2 != nil {
+		return p.tables.g2.mult(k)
+	}
+	return p.curve.ScalarMult(p.g2x, p.g2y, scalarBytes(p.curve, k))
+}
@@ -0,0 +1,89 @@
+package dkg
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha512"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/mikalv/dkg/secp256k1"
+)
+
+func getPrecomputedNodesForTesting(tb testing.TB, curve elliptic.Curve, threshold int) (plain, precomputed *Node) {
+	k, _ := randomScalar(curve.Params().N, rand.Reader)
+	g2x, g2y := curve.ScalarBaseMult(k.Bytes())
+	config := NodeConfig{
+		Curve:     curve,
+		Hash:      sha512.New512_256(),
+		G2X:       g2x,
+		G2Y:       g2y,
+		ZKParam:   big.NewInt(1),
+		ID:        big.NewInt(1),
+		Threshold: threshold,
+	}
+	plain, err := NewNodeWithOptions(WithConfig(config))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	config.SecretPoly1, config.SecretPoly2 = plain.secretPoly1, plain.secretPoly2
+	precomputed, err = NewNodeWithOptions(WithConfig(config), WithPrecomputedTables())
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return plain, precomputed
+}
+
+func TestFixedBaseTable(t *testing.T) {
+	for _, curve := range []elliptic.Curve{elliptic.P256(), secp256k1.S256()} {
+		params := curve.Params()
+		table := newFixedBaseTable(curve, params.Gx, params.Gy)
+		nMinusOne := new(big.Int).Sub(params.N, one)
+		for _, k := range []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(16), nMinusOne} {
+			x, y := table.mult(k)
+			ex, ey := curve.ScalarBaseMult(k.Bytes())
+			if x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+				t.Errorf("%v: %v * G differs", params.Name, k)
+			}
+		}
+		for i := 0; i < 8; i++ {
+			k, _ := randomScalar(params.N, rand.Reader)
+			x, y := table.mult(k)
+			ex, ey := curve.ScalarBaseMult(k.Bytes())
+			if x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+				t.Errorf("%v: %v * G differs", params.Name, k)
+			}
+		}
+
+		plain, precomputed := getPrecomputedNodesForTesting(t, curve, 3)
+		if !reflect.DeepEqual(plain.VerificationPoints(), precomputed.VerificationPoints()) {
+			t.Errorf("%v: Verification points differ", params.Name)
+		}
+		x, y := plain.PublicKeyPart()
+		px, py := precomputed.PublicKeyPart()
+		if x.Cmp(px) != 0 || y.Cmp(py) != 0 {
+			t.Errorf("%v: Public key parts differ", params.Name)
+		}
+		shares, _ := plain.SecretShareFor(big.NewInt(2))
+		if err := precomputed.params().verifyShareFor(plain.ID(), big.NewInt(2), shares, plain.VerificationPoints()); err != nil {
+			t.Errorf("%v: Share doesn't verify with tables: %v", params.Name, err)
+		}
+	}
+}
+
+func BenchmarkVerificationPointsTables(b *testing.B) {
+	plain, precomputed := getPrecomputedNodesForTesting(b, secp256k1.S256(), 30)
+	b.Run("Plain", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			plain.invalidateCommitments()
+			plain.VerificationPoints()
+		}
+	})
+	b.Run("Precomputed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			precomputed.invalidateCommitments()
+			precomputed.VerificationPoints()
+		}
+	})
+}
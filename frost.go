@@ -0,0 +1,392 @@
+package dkg
+
+import "crypto/ecdsa"
+import "crypto/ed25519"
+import "crypto/elliptic"
+import "crypto/sha256"
+import "crypto/sha512"
+import "io"
+import "math/big"
+import "sort"
+
+import "github.com/mikalv/dkg/edwards25519"
+
+// FROSTNonces are a signer's secret nonces for one FROST signature, the
+// hiding and binding nonces of Komlo and Goldberg's two-round threshold
+// Schnorr signatures. Signing consumes them: signing twice with the same
+// nonces would reveal the key share.
+//
+// This is not the FROST of RFC 9591: nonces and binding factors are derived
+// with this package's transcript hashes rather than the ciphersuites' H1,
+// H3, H4 and H5, so signers can't be mixed with RFC 9591 implementations in
+// one session. The signatures they make together are plain Ed25519 and
+// BIP-340 signatures all the same.
+type FROSTNonces struct {
+	id              *big.Int
+	hiding, binding *big.Int
+	commitment      FROSTCommitment
+}
+
+// FROSTCommitment is a signer's commitment to its nonces, published in the
+// first round to the other signers of the message.
+type FROSTCommitment struct {
+	ID                 *big.Int
+	HidingX, HidingY   *big.Int
+	BindingX, BindingY *big.Int
+}
+
+type frostNonceStatement struct {
+	random []byte
+	curve  elliptic.Curve
+	share  *big.Int
+}
+
+func (s frostNonceStatement) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/frost-nonce")
+	w.WriteBytes(s.random)
+	w.WriteScalar(s.curve, s.share)
+}
+
+// NewFROSTNonces draws a signer's nonces for one signature, hedged with its
+// key share against a weak random source, and returns them with their
+// commitment for the first round.
+func NewFROSTNonces(key *KeyShare, random io.Reader) (*FROSTNonces, FROSTCommitment, error) {
+	if err := key.permits(UseSigning); err != nil {
+		return nil, FROSTCommitment{}, err
+	}
+	curve := key.PublicKey.Curve
+	if _, err := schnorrSchemeFor(curve); err != nil {
+		return nil, FROSTCommitment{}, err
+	}
+	n := curve.Params().N
+	nonce := func() (*big.Int, error) {
+		b := make([]byte, 32)
+		if _, err := io.ReadFull(random, b); err != nil {
+			return nil, err
+		}
+		k := new(big.Int).SetBytes(HashOf(sha512.New(), frostNonceStatement{b, curve, key.Share}))
+		return k.Mod(k, n), nil
+	}
+	nonces := &FROSTNonces{id: key.ID}
+	var err error
+	if nonces.hiding, err = nonce(); err != nil {
+		return nil, FROSTCommitment{}, err
+	}
+	if nonces.binding, err = nonce(); err != nil {
+		return nil, FROSTCommitment{}, err
+	}
+	c := FROSTCommitment{ID: key.ID}
+	c.HidingX, c.HidingY = curve.ScalarBaseMult(scalarBytes(curve, nonces.hiding))
+	c.BindingX, c.BindingY = curve.ScalarBaseMult(scalarBytes(curve, nonces.binding))
+	nonces.commitment = c
+	return nonces, c, nil
+}
+
+// Zeroize wipes unused nonces, which can't sign afterwards.
+func (n *FROSTNonces) Zeroize() {
+	zeroize(n.hiding, n.binding)
+	n.hiding, n.binding = nil, nil
+}
+
+// schnorrSchemeFor returns the standard Schnorr signature scheme on curve:
+// Ed25519 on edwards25519 and BIP-340 on secp256k1.
+func schnorrSchemeFor(curve elliptic.Curve) (string, error) {
+	switch curve.Params().Name {
+	case "edwards25519":
+		return "Ed25519", nil
+	case "secp256k1":
+		return "BIP-340", nil
+	}
+	return "", UnsupportedCurveError{curve, "Schnorr signature"}
+}
+
+type frostBindingStatement struct {
+	curve       string
+	keyX, keyY  *big.Int
+	message     []byte
+	commitments []FROSTCommitment
+	id          *big.Int
+}
+
+func (s frostBindingStatement) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/frost-binding")
+	w.WriteBytes([]byte(s.curve))
+	w.WriteInt(s.keyX)
+	w.WriteInt(s.keyY)
+	w.WriteBytes(s.message)
+	w.WriteUint(uint64(len(s.commitments)))
+	for _, c := range s.commitments {
+		w.WriteInt(c.ID)
+		w.WriteInt(c.HidingX)
+		w.WriteInt(c.HidingY)
+		w.WriteInt(c.BindingX)
+		w.WriteInt(c.BindingY)
+	}
+	w.WriteInt(s.id)
+}
+
+// frostSession is what the signers of a message derive from their
+// commitments: binding factors, the group commitment R and the challenge.
+type frostSession struct {
+	curve       elliptic.Curve
+	scheme      string
+	commitments []FROSTCommitment // by ID
+	ids         []*big.Int
+	rhos        []*big.Int
+	rx, ry      *big.Int
+	challenge   *big.Int
+	// BIP-340 signs for the group key and R with even Y coordinates
+	negateKey, negateNonces bool
+}
+
+func newFROSTSession(key ecdsa.PublicKey, threshold int, commitments []FROSTCommitment, message []byte) (*frostSession, error) {
+	curve := key.Curve
+	scheme, err := schnorrSchemeFor(curve)
+	if err != nil {
+		return nil, err
+	}
+	n := curve.Params().N
+	ids := make([]*big.Int, len(commitments))
+	for i, c := range commitments {
+		ids[i] = c.ID
+	}
+	if err := validateIDs(curve, threshold, ids); err != nil {
+		return nil, err
+	}
+	s := &frostSession{curve: curve, scheme: scheme}
+	s.commitments = append([]FROSTCommitment(nil), commitments...)
+	sort.Slice(s.commitments, func(i, j int) bool {
+		return new(big.Int).Mod(s.commitments[i].ID, n).Cmp(new(big.Int).Mod(s.commitments[j].ID, n)) < 0
+	})
+	for _, c := range s.commitments {
+		if !isValidPoint(curve, c.HidingX, c.HidingY) || !isValidPoint(curve, c.BindingX, c.BindingY) {
+			return nil, InvalidSigningNoncesError{c.ID}
+		}
+		s.ids = append(s.ids, c.ID)
+	}
+
+	var points PointTuple
+	var scalars []*big.Int
+	for _, c := range s.commitments {
+		rho := new(big.Int).SetBytes(HashOf(sha512.New(), frostBindingStatement{curve.Params().Name, key.X, key.Y, message, s.commitments, c.ID}))
+		s.rhos = append(s.rhos, rho.Mod(rho, n))
+		points = append(points, struct{ X, Y *big.Int }{c.HidingX, c.HidingY}, struct{ X, Y *big.Int }{c.BindingX, c.BindingY})
+		scalars = append(scalars, big.NewInt(1), rho)
+	}
+	s.rx, s.ry = multiScalarMult(curve, points, scalars)
+	if isIdentity(curve, s.rx, s.ry) {
+		return nil, InvalidSignatureError{}
+	}
+	if scheme == "BIP-340" {
+		s.negateKey, s.negateNonces = key.Y.Bit(0) == 1, s.ry.Bit(0) == 1
+	}
+	s.challenge = schnorrChallenge(curve, scheme, s.rx, s.ry, key.X, key.Y, message)
+	return s, nil
+}
+
+// schnorrChallenge returns the challenge of scheme for the commitment R, the
+// public key and the message.
+func schnorrChallenge(curve elliptic.Curve, scheme string, rx, ry, kx, ky *big.Int, message []byte) *big.Int {
+	n := curve.Params().N
+	if scheme == "Ed25519" {
+		h := sha512.New()
+		h.Write(edwards25519.Encode(rx, ry))
+		h.Write(edwards25519.Encode(kx, ky))
+		h.Write(message)
+		digest := h.Sum(nil)
+		reverse(digest)
+		c := new(big.Int).SetBytes(digest)
+		return c.Mod(c, n)
+	}
+	tag := sha256.Sum256([]byte("BIP0340/challenge"))
+	h := sha256.New()
+	h.Write(tag[:])
+	h.Write(tag[:])
+	h.Write(rx.FillBytes(make([]byte, 32)))
+	h.Write(kx.FillBytes(make([]byte, 32)))
+	h.Write(message)
+	c := new(big.Int).SetBytes(h.Sum(nil))
+	return c.Mod(c, n)
+}
+
+func reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
+
+// negate returns -(x, y) on a short Weierstrass curve.
+func negate(curve elliptic.Curve, x, y *big.Int) (*big.Int, *big.Int) {
+	p := curve.Params().P
+	return x, new(big.Int).Mod(new(big.Int).Sub(p, y), p)
+}
+
+// index returns the position of signer id in the session.
+func (s *frostSession) index(id *big.Int) int {
+	n := s.curve.Params().N
+	for i, c := range s.commitments {
+		if new(big.Int).Mod(c.ID, n).Cmp(new(big.Int).Mod(id, n)) == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// FROSTSignatureShare returns the signer's share of the signature of
+// message by the signers that published commitments, given its own nonces,
+// which it consumes.
+func FROSTSignatureShare(key *KeyShare, nonces *FROSTNonces, commitments []FROSTCommitment, message []byte) (PartialSignature, error) {
+	if err := key.use(UseSigning); err != nil {
+		return PartialSignature{}, err
+	}
+	if nonces == nil || nonces.hiding == nil {
+		return PartialSignature{}, NoncesReusedError{key.ID}
+	}
+	s, err := newFROSTSession(key.PublicKey, key.Threshold, commitments, message)
+	if err != nil {
+		return PartialSignature{}, err
+	}
+	i := s.index(key.ID)
+	if i < 0 || nonces.id.Cmp(key.ID) != 0 || !sameCommitment(s.commitments[i], nonces.commitment) {
+		return PartialSignature{}, InvalidSigningNoncesError{key.ID}
+	}
+	lambda, err := LagrangeCoefficient(s.curve, key.ID, s.ids)
+	if err != nil {
+		return PartialSignature{}, err
+	}
+	f := scalarFieldFor(s.curve.Params().N)
+	defer nonces.Zeroize()
+
+	// z_i = d_i + e_i * rho_i + lambda_i * c * s_i, with signs for BIP-340
+	z := f.fromBig(nonces.hiding)
+	defer clear(z)
+	f.mulAdd(z, nonces.binding, s.rhos[i])
+	zero := f.element()
+	if s.negateNonces {
+		f.sub(z, zero, z)
+	}
+	x := f.fromBig(key.Share)
+	defer clear(x)
+	f.mul(x, x, f.fromBig(new(big.Int).Mul(lambda, s.challenge)))
+	if s.negateKey {
+		f.sub(x, zero, x)
+	}
+	f.add(z, z, x)
+	return PartialSignature{key.ID, key.Epoch, f.toBig(z)}, nil
+}
+
+func sameCommitment(a, b FROSTCommitment) bool {
+	return a.HidingX.Cmp(b.HidingX) == 0 && a.HidingY.Cmp(b.HidingY) == 0 &&
+		a.BindingX.Cmp(b.BindingX) == 0 && a.BindingY.Cmp(b.BindingY) == 0
+}
+
+// verifyShare checks z_i * G == D_i + rho_i * E_i + lambda_i * c * Y_i,
+// with signs for BIP-340, Y_i being the signer's public share.
+func (s *frostSession) verifyShare(group GroupKey, i int, z *big.Int) bool {
+	curve := s.curve
+	if !isNormalizedScalar(z, curve.Params().N) {
+		return false
+	}
+	c := s.commitments[i]
+	lambda, err := LagrangeCoefficient(curve, c.ID, s.ids)
+	if err != nil {
+		return false
+	}
+	rx, ry := multiScalarMult(curve, PointTuple{{c.HidingX, c.HidingY}, {c.BindingX, c.BindingY}}, []*big.Int{big.NewInt(1), s.rhos[i]})
+	if s.negateNonces {
+		rx, ry = negate(curve, rx, ry)
+	}
+	yx, yy := evaluateCommitments(curve, group.PublicCoefficients, c.ID)
+	yx, yy = curve.ScalarMult(yx, yy, scalarBytes(curve, new(big.Int).Mod(new(big.Int).Mul(lambda, s.challenge), curve.Params().N)))
+	if s.negateKey {
+		yx, yy = negate(curve, yx, yy)
+	}
+	ex, ey := curve.Add(rx, ry, yx, yy)
+	zx, zy := curve.ScalarBaseMult(scalarBytes(curve, z))
+	return zx.Cmp(ex) == 0 && zy.Cmp(ey) == 0
+}
+
+// CombineFROSTSignature checks the signature shares of the signers that
+// published commitments and sums them up into a signature of message by
+// the group key: an Ed25519 signature on edwards25519, verifiable with
+// crypto/ed25519, and a BIP-340 signature on secp256k1. A bad share is
+// reported with its signer.
+func CombineFROSTSignature(group GroupKey, commitments []FROSTCommitment, partials []PartialSignature, message []byte) ([]byte, error) {
+	s, err := newFROSTSession(group.PublicKey, group.Threshold, commitments, message)
+	if err != nil {
+		return nil, err
+	}
+	n := s.curve.Params().N
+	zs := make([]*big.Int, len(s.commitments))
+	for _, partial := range partials {
+		if partial.Epoch != group.Epoch {
+			return nil, MixedEpochError{group.Epoch, partial.Epoch}
+		}
+		if partial.ID == nil {
+			continue
+		}
+		if i := s.index(partial.ID); i >= 0 && zs[i] == nil {
+			if !s.verifyShare(group, i, partial.Share) {
+				return nil, InvalidPartialSignatureError{partial.ID}
+			}
+			zs[i] = partial.Share
+		}
+	}
+	z := new(big.Int)
+	found := 0
+	for _, zi := range zs {
+		if zi != nil {
+			z.Add(z, zi)
+			found++
+		}
+	}
+	if found < len(zs) {
+		return nil, InsufficientSharesError{found, len(zs)}
+	}
+	z.Mod(z, n)
+
+	var sig []byte
+	if s.scheme == "Ed25519" {
+		sig = append(edwards25519.Encode(s.rx, s.ry), z.FillBytes(make([]byte, 32))...)
+		reverse(sig[32:])
+	} else {
+		sig = append(s.rx.FillBytes(make([]byte, 32)), z.FillBytes(make([]byte, 32))...)
+	}
+	if !VerifySchnorrSignature(group.PublicKey, message, sig) {
+		return nil, InvalidSignatureError{}
+	}
+	return sig, nil
+}
+
+// VerifySchnorrSignature checks a Schnorr signature of message by key, an
+// Ed25519 signature on edwards25519 and a BIP-340 signature on secp256k1.
+func VerifySchnorrSignature(key ecdsa.PublicKey, message, sig []byte) bool {
+	if key.Curve == nil || !isValidPoint(key.Curve, key.X, key.Y) {
+		return false
+	}
+	curve := key.Curve
+	scheme, err := schnorrSchemeFor(curve)
+	if err != nil || len(sig) != 64 {
+		return false
+	}
+	if scheme == "Ed25519" {
+		return ed25519.Verify(edwards25519.Encode(key.X, key.Y), message, sig)
+	}
+
+	params := curve.Params()
+	r, z := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	if r.Cmp(params.P) >= 0 || z.Cmp(params.N) >= 0 {
+		return false
+	}
+	kx, ky := key.X, key.Y
+	if ky.Bit(0) == 1 {
+		kx, ky = negate(curve, kx, ky)
+	}
+	// R = z * G - e * P must have an even Y coordinate and X coordinate r
+	e := schnorrChallenge(curve, scheme, r, nil, kx, ky, message)
+	ex, ey := curve.ScalarMult(kx, ky, scalarBytes(curve, e))
+	ex, ey = negate(curve, ex, ey)
+	zx, zy := curve.ScalarBaseMult(scalarBytes(curve, z))
+	rx, ry := curve.Add(zx, zy, ex, ey)
+	return !isIdentity(curve, rx, ry) && ry.Bit(0) == 0 && rx.Cmp(r) == 0
+}
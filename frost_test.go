@@ -0,0 +1,119 @@
+package dkg
+
+import (
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mikalv/dkg/edwards25519"
+	"github.com/mikalv/dkg/secp256k1"
+)
+
+// verifyBIP340ForTesting follows BIP-340's reference verification, from the
+// x-only public key.
+func verifyBIP340ForTesting(pub, message, sig []byte) bool {
+	curve := secp256k1.S256()
+	p, n := curve.Params().P, curve.Params().N
+	px := new(big.Int).SetBytes(pub)
+	c := new(big.Int).Exp(px, big.NewInt(3), p)
+	c.Add(c, big.NewInt(7))
+	py := new(big.Int).Exp(c, new(big.Int).Rsh(new(big.Int).Add(p, one), 2), p)
+	if new(big.Int).Exp(py, big.NewInt(2), p).Cmp(c.Mod(c, p)) != 0 {
+		return false
+	}
+	if py.Bit(0) == 1 {
+		py.Sub(p, py)
+	}
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	if r.Cmp(p) >= 0 || s.Cmp(n) >= 0 {
+		return false
+	}
+	tag := sha256.Sum256([]byte("BIP0340/challenge"))
+	e := sha256.Sum256(append(append(append(append(tag[:], tag[:]...), sig[:32]...), pub...), message...))
+	k := new(big.Int).Mod(new(big.Int).SetBytes(e[:]), n)
+	sx, sy := curve.ScalarBaseMult(s.Bytes())
+	ex, ey := curve.ScalarMult(px, py, new(big.Int).Sub(n, k).Bytes())
+	rx, ry := curve.Add(sx, sy, ex, ey)
+	return ry.Bit(0) == 0 && rx.Cmp(r) == 0
+}
+
+func TestFROST(t *testing.T) {
+	const size, threshold = 3, 1
+	message := []byte("FROST")
+	for _, curve := range []elliptic.Curve{edwards25519.Curve(), secp256k1.S256()} {
+		t.Run(curve.Params().Name, func(t *testing.T) {
+			k, _ := randomScalar(curve.Params().N, rand.Reader)
+			g2x, g2y := curve.ScalarBaseMult(k.Bytes())
+			nodes, participants := getCeremonyNodesOnCurveForTesting(t, curve, g2x, g2y, size, threshold)
+			for _, node := range nodes {
+				// the math/big curves are slow under the race detector
+				node.timeout = 10 * time.Second
+			}
+			keys := runCeremonyForTesting(t, nodes, participants)
+			checkCeremonyResultsForTesting(t, keys)
+			group := keys[0].Group()
+
+			sign := func(signers []*KeyShare) ([]FROSTCommitment, []PartialSignature) {
+				nonces := make([]*FROSTNonces, len(signers))
+				commitments := make([]FROSTCommitment, len(signers))
+				for i, key := range signers {
+					var err error
+					if nonces[i], commitments[i], err = NewFROSTNonces(key, rand.Reader); err != nil {
+						t.Fatal(err)
+					}
+				}
+				partials := make([]PartialSignature, len(signers))
+				for i, key := range signers {
+					var err error
+					if partials[i], err = FROSTSignatureShare(key, nonces[i], commitments, message); err != nil {
+						t.Fatalf("Signer %v failed: %v", key.ID, err)
+					}
+					if _, err := FROSTSignatureShare(key, nonces[i], commitments, message); !reflect.DeepEqual(err, NoncesReusedError{key.ID}) {
+						t.Errorf("Signed with used nonces: %v", err)
+					}
+				}
+				return commitments, partials
+			}
+
+			for _, signers := range [][]*KeyShare{keys[:threshold+1], keys[1:], keys} {
+				commitments, partials := sign(signers)
+				sig, err := CombineFROSTSignature(group, commitments, partials, message)
+				if err != nil {
+					t.Fatalf("Could not combine %v shares: %v", len(signers), err)
+				}
+				if !VerifySchnorrSignature(group.PublicKey, message, sig) || VerifySchnorrSignature(group.PublicKey, []byte("other"), sig) {
+					t.Errorf("Signature doesn't verify")
+				}
+				var standard bool
+				if curve == edwards25519.Curve() {
+					standard = ed25519.Verify(edwards25519.Encode(group.PublicKey.X, group.PublicKey.Y), message, sig)
+				} else {
+					standard = verifyBIP340ForTesting(group.PublicKey.X.FillBytes(make([]byte, 32)), message, sig)
+				}
+				if !standard {
+					t.Errorf("Signature of %v signers fails the standard verifier", len(signers))
+				}
+			}
+
+			commitments, partials := sign(keys[:threshold+1])
+			partials[1].Share = new(big.Int).Add(partials[1].Share, one)
+			if _, err := CombineFROSTSignature(group, commitments, partials, message); !reflect.DeepEqual(err, InvalidPartialSignatureError{partials[1].ID}) {
+				t.Errorf("Got unexpected error for a bad share: %v", err)
+			}
+			if _, err := CombineFROSTSignature(group, commitments[:threshold], partials[:threshold], message); reflect.TypeOf(err) != reflect.TypeOf(InvalidThresholdError{}) {
+				t.Errorf("Got unexpected error for too few signers: %v", err)
+			}
+		})
+	}
+
+	keys, _ := getCeremonyNodesForTesting(t, 3, 1)
+	share := &KeyShare{ID: keys[0].ID(), PublicKey: keys[0].key.PublicKey, Share: big.NewInt(1)}
+	if _, _, err := NewFROSTNonces(share, rand.Reader); reflect.TypeOf(err) != reflect.TypeOf(UnsupportedCurveError{}) {
+		t.Errorf("Got unexpected error on P-256: %v", err)
+	}
+}
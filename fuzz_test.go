@@ -0,0 +1,97 @@
+package dkg
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/mikalv/dkg/edwards25519"
+	"github.com/mikalv/dkg/secp256k1"
+)
+
+var fuzzCurves = []elliptic.Curve{elliptic.P256(), secp256k1.S256(), edwards25519.Curve()}
+
+func FuzzParseVerificationPoints(f *testing.F) {
+	for _, curve := range fuzzCurves {
+		x, y := curve.ScalarBaseMult([]byte{7})
+		seed, _ := PointTuple{{x, y}, {curve.Params().Gx, curve.Params().Gy}}.MarshalBinary()
+		f.Add(seed)
+	}
+	f.Add([]byte{wireVersion})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, curve := range fuzzCurves {
+			pts, err := ParseVerificationPoints(curve, data)
+			if err != nil {
+				continue
+			}
+			if encoded, _ := pts.MarshalBinary(); !bytes.Equal(encoded, data) {
+				t.Errorf("%v points don't encode back to their input", curve.Params().Name)
+			}
+		}
+	})
+}
+
+func FuzzParseShareEnvelope(f *testing.F) {
+	for _, curve := range fuzzCurves[:2] {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			f.Fatal(err)
+		}
+		ciphertext, err := encryptShares(curve, &key.PublicKey, big.NewInt(1), big.NewInt(2), SecretShares{big.NewInt(3), big.NewInt(4), curve}, rand.Reader)
+		if err != nil {
+			f.Fatal(err)
+		}
+		seed, _ := EncryptedShares{ciphertext}.MarshalBinary()
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, curve := range fuzzCurves {
+			if e, err := ParseShareEnvelope(curve, curve, data); err == nil && len(e.Ciphertext) == 0 {
+				t.Errorf("Parsed an empty %v envelope", curve.Params().Name)
+			}
+		}
+	})
+}
+
+func FuzzParseComplaint(f *testing.F) {
+	seed, _ := Complaints{[]*big.Int{big.NewInt(1), big.NewInt(3)}, []byte{0x30, 0x06}}.MarshalBinary()
+	f.Add(seed)
+	empty, _ := Complaints{nil, []byte{0x30}}.MarshalBinary()
+	f.Add(empty)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, curve := range fuzzCurves {
+			c, err := ParseComplaint(curve, data)
+			if err != nil {
+				continue
+			}
+			if encoded, _ := c.MarshalBinary(); !bytes.Equal(encoded, data) {
+				t.Errorf("Complaints don't encode back to their input")
+			}
+		}
+	})
+}
+
+func FuzzMessage(f *testing.F) {
+	for _, m := range goldenMessagesForTesting() {
+		seed, _ := m.MarshalBinary()
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var m Message
+		if m.UnmarshalBinary(data) != nil {
+			return
+		}
+		if encoded, _ := m.MarshalBinary(); !bytes.Equal(encoded, data) {
+			t.Errorf("Message %+v doesn't encode back to its input", m)
+		}
+		j, err := m.MarshalJSON()
+		if err != nil {
+			return
+		}
+		var decoded Message
+		decoded.UnmarshalJSON(j)
+	})
+}
@@ -0,0 +1,90 @@
+package dkg
+
+import "crypto/elliptic"
+import "crypto/sha256"
+import "math/big"
+
+// DefaultGeneratorDomain is the domain separator of the second generator
+// nodes and observers derive when given none.
+const DefaultGeneratorDomain = "github.com/mikalv/dkg/v1"
+
+// Curves whose equation isn't the y^2 = x^3 - 3x + B of crypto/elliptic
+// implement this for DeriveSecondGenerator: Lift returns a point determined
+// by the field element t, if there is one.
+type liftingCurve interface {
+	Lift(t *big.Int) (x, y *big.Int, ok bool)
+}
+
+// lift returns the point with x coordinate t and even y on a curve of
+// crypto/elliptic, or defers to the curve's Lift.
+func lift(curve elliptic.Curve, t *big.Int) (x, y *big.Int, ok bool) {
+	if c, ok := curve.(liftingCurve); ok {
+		return c.Lift(t)
+	}
+	p := curve.Params().P
+	// y^2 = x^3 - 3x + B
+	y2 := new(big.Int).Mul(t, t)
+	y2.Mul(y2, t)
+	y2.Sub(y2, new(big.Int).Lsh(t, 1))
+	y2.Sub(y2, t)
+	y2.Add(y2, curve.Params().B)
+	if y = new(big.Int).ModSqrt(y2.Mod(y2, p), p); y == nil {
+		return nil, nil, false
+	}
+	if y.Bit(0) == 1 {
+		y.Sub(p, y)
+	}
+	return new(big.Int).Set(t), y, curve.IsOnCurve(t, y)
+}
+
+type generatorStatement struct {
+	curve   string
+	domain  []byte
+	attempt uint64
+	block   uint64
+}
+
+func (s generatorStatement) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/second-generator")
+	w.WriteBytes([]byte(s.curve))
+	w.WriteBytes(s.domain)
+	w.WriteUint(s.attempt)
+	w.WriteUint(s.block)
+}
+
+// maxGeneratorAttempts bounds the try-and-increment loop; about half the
+// field elements lift to a point, so running out means the curve isn't
+// supported.
+const maxGeneratorAttempts = 256
+
+// DeriveSecondGenerator hashes domain to a point of the prime-order subgroup
+// of curve, by try-and-increment: SHA-256 of the curve name, domain and a
+// counter, expanded to 128 bits more than the field and reduced, is lifted
+// to the curve and multiplied by the cofactor, until that gives a valid
+// point. Anyone can repeat the derivation, and nobody knows its discrete
+// logarithm to the base point, which makes it a second generator for
+// Pedersen VSS with nothing up anyone's sleeve. Participants must agree on
+// the domain, which should name the deployment.
+func DeriveSecondGenerator(curve elliptic.Curve, domain []byte) (x, y *big.Int, err error) {
+	params := curve.Params()
+	size := (params.P.BitLen()+7)/8 + 16
+	h := cofactor(curve)
+	for attempt := uint64(0); attempt < maxGeneratorAttempts; attempt++ {
+		var expanded []byte
+		for block := uint64(0); len(expanded) < size; block++ {
+			expanded = append(expanded, HashOf(sha256.New(), generatorStatement{params.Name, domain, attempt, block})...)
+		}
+		t := new(big.Int).SetBytes(expanded[:size])
+		x, y, ok := lift(curve, t.Mod(t, params.P))
+		if !ok {
+			continue
+		}
+		if h.Cmp(one) > 0 {
+			x, y = curve.ScalarMult(x, y, h.Bytes())
+		}
+		if isValidPoint(curve, x, y) {
+			return x, y, nil
+		}
+	}
+	return nil, nil, GeneratorDerivationError{curve}
+}
@@ -0,0 +1,51 @@
+package dkg
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha512"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/mikalv/dkg/edwards25519"
+	"github.com/mikalv/dkg/secp256k1"
+)
+
+func TestDeriveSecondGenerator(t *testing.T) {
+	for _, curve := range []elliptic.Curve{elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521(), secp256k1.S256(), edwards25519.Curve()} {
+		name := curve.Params().Name
+		x, y, err := DeriveSecondGenerator(curve, []byte("test"))
+		if err != nil {
+			t.Errorf("%v: %v", name, err)
+			continue
+		}
+		if !isValidPoint(curve, x, y) {
+			t.Errorf("%v: derived an invalid point", name)
+		}
+		if ax, ay, _ := DeriveSecondGenerator(curve, []byte("test")); ax.Cmp(x) != 0 || ay.Cmp(y) != 0 {
+			t.Errorf("%v: derivation isn't deterministic", name)
+		}
+		if bx, by, _ := DeriveSecondGenerator(curve, []byte("other")); bx.Cmp(x) == 0 && by.Cmp(y) == 0 {
+			t.Errorf("%v: domains derived the same point", name)
+		}
+		if x.Cmp(curve.Params().Gx) == 0 && y.Cmp(curve.Params().Gy) == 0 {
+			t.Errorf("%v: derived the base point", name)
+		}
+
+		key, _ := ecdsa.GenerateKey(curve, rand.Reader)
+		node, err := NewNodeWithOptions(
+			WithCurve(curve), WithHash(sha512.New512_256()), WithZKParam(big.NewInt(1)), WithTimeout(time.Second),
+			WithID(big.NewInt(1)), WithKey(*key), WithThreshold(1),
+		)
+		if err != nil {
+			t.Errorf("%v: could not create a node with a derived generator: %v", name, err)
+			continue
+		}
+		dx, dy, _ := DeriveSecondGenerator(curve, []byte(DefaultGeneratorDomain))
+		if node.g2x.Cmp(dx) != 0 || node.g2y.Cmp(dy) != 0 {
+			t.Errorf("%v: node didn't use the default generator", name)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package dkg
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+func canonicalEncodingForTesting(v Hashable) []byte {
+	return HashOf(&bufferHash{}, v)
+}
+
+// checkGolden compares b to the hex encoded golden file name, or rewrites
+// the file with -update.
+func checkGolden(t *testing.T, name string, b []byte) {
+	path := filepath.Join("testdata", "golden", name+".hex")
+	if *updateGolden {
+		if err := os.WriteFile(path, []byte(hex.EncodeToString(b)+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	golden := readGolden(t, name)
+	if !bytes.Equal(b, golden) {
+		t.Errorf("Encoding of %v changed:\n got  %x\n want %x", name, b, golden)
+	}
+}
+
+func readGolden(t *testing.T, name string) []byte {
+	data, err := os.ReadFile(filepath.Join("testdata", "golden", name+".hex"))
+	if err != nil {
+		t.Fatalf("Could not read golden file: %v", err)
+	}
+	b, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatalf("Could not decode golden file %v: %v", name, err)
+	}
+	return b
+}
+
+// goldenMessagesForTesting returns fixed messages of every type, over P-256.
+func goldenMessagesForTesting() map[string]Message {
+	curve := elliptic.P256()
+	point := func(k int64) struct{ X, Y *big.Int } {
+		x, y := curve.ScalarBaseMult(big.NewInt(k).Bytes())
+		return struct{ X, Y *big.Int }{x, y}
+	}
+	from, to := big.NewInt(1), big.NewInt(2)
+	return map[string]Message{
+		"verification-points": {VerificationPointsMessage, from, nil, PointTuple{point(2), point(3)}},
+		"secret-shares":       {SecretSharesMessage, from, to, EncryptedShares{[]byte("ciphertext")}},
+		"complaints":          {ComplaintsMessage, from, nil, Complaints{[]*big.Int{big.NewInt(3), big.NewInt(4)}, []byte("signature")}},
+		"justification": {JustificationMessage, from, nil, Justification{[]RevealedShares{
+			{big.NewInt(3), SecretShares{big.NewInt(5), big.NewInt(6), curve}},
+		}}},
+		"public-coefficients": {PublicCoefficientsMessage, from, nil, PointTuple{point(7), point(8)}},
+		"secret-knowledge": {SecretKnowledgeMessage, from, nil, SecretKnowledgeProof{
+			point(9).X, point(9).Y, big.NewInt(10), big.NewInt(11), curve,
+		}},
+		"hello": {HelloMessage, from, to, Hello{0x0102030405060708, true}},
+		"retry": {RetryMessage, from, to, Retry{PhaseComplaining, 2}},
+		"qualified-set": {QualifiedSetMessage, from, nil, QualifiedSet{
+			[]*big.Int{big.NewInt(1), big.NewInt(3)}, []byte("signature"),
+		}},
+		"coefficient-complaints": {CoefficientComplaintsMessage, from, nil, Exposure{[]ExposedShares{
+			{big.NewInt(3), SecretShares{big.NewInt(12), big.NewInt(13), curve}},
+		}}},
+		"reconstruction": {ReconstructionMessage, from, nil, Exposure{[]ExposedShares{
+			{big.NewInt(3), SecretShares{big.NewInt(14), big.NewInt(15), curve}},
+			{big.NewInt(4), SecretShares{big.NewInt(16), big.NewInt(17), curve}},
+		}}},
+	}
+}
+
+func TestGoldenTranscripts(t *testing.T) {
+	for name, m := range goldenMessagesForTesting() {
+		checkGolden(t, "transcript-"+name, canonicalEncodingForTesting(m))
+	}
+	checkGolden(t, "transcript-secret-shares-payload",
+		canonicalEncodingForTesting(SecretShares{big.NewInt(5), big.NewInt(6), elliptic.P256()}))
+}
+
+func TestGoldenBinaryMessages(t *testing.T) {
+	for name, m := range goldenMessagesForTesting() {
+		b, err := m.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Could not encode %v: %v", name, err)
+		}
+		checkGolden(t, "binary-"+name, b)
+
+		var decoded Message
+		if err := decoded.UnmarshalBinary(readGolden(t, "binary-"+name)); err != nil {
+			t.Fatalf("Could not decode golden %v: %v", name, err)
+		}
+		if !reflect.DeepEqual(decoded, m) {
+			t.Errorf("Golden %v decoded to %+v, expected %+v", name, decoded, m)
+		}
+	}
+}
+
+func TestGoldenJSONMessages(t *testing.T) {
+	for name, m := range goldenMessagesForTesting() {
+		b, err := json.Marshal(m)
+		if err != nil {
+			t.Fatalf("Could not encode %v: %v", name, err)
+		}
+		checkGolden(t, "json-"+name, b)
+
+		var decoded Message
+		if err := json.Unmarshal(readGolden(t, "json-"+name), &decoded); err != nil {
+			t.Fatalf("Could not decode golden %v: %v", name, err)
+		}
+		if !reflect.DeepEqual(decoded, m) {
+			t.Errorf("Golden %v decoded to %+v, expected %+v", name, decoded, m)
+		}
+	}
+}
+
+// gob assigns type IDs in the order types are first seen by the process, so
+// its output isn't byte-for-byte stable; golden gob messages are only
+// checked to still decode.
+func TestGoldenGobMessages(t *testing.T) {
+	for name, m := range goldenMessagesForTesting() {
+		if *updateGolden {
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(m); err != nil {
+				t.Fatalf("Could not encode %v: %v", name, err)
+			}
+			checkGolden(t, "gob-"+name, buf.Bytes())
+		}
+
+		var decoded Message
+		if err := gob.NewDecoder(bytes.NewReader(readGolden(t, "gob-"+name))).Decode(&decoded); err != nil {
+			t.Fatalf("Could not decode golden %v: %v", name, err)
+		}
+		if !reflect.DeepEqual(decoded, m) {
+			t.Errorf("Golden %v decoded to %+v, expected %+v", name, decoded, m)
+		}
+	}
+}
+
+func TestGoldenScalars(t *testing.T) {
+	curve := elliptic.P256()
+	k, _ := new(big.Int).SetString("c0ffee", 16)
+	b, err := EncodeScalar(curve, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "scalar-p256", b)
+
+	decoded, err := DecodeScalar(curve, readGolden(t, "scalar-p256"))
+	if err != nil || decoded.Cmp(k) != 0 {
+		t.Errorf("Golden scalar decoded to %v (%v), expected %v", decoded, err, k)
+	}
+}
+
+func TestGoldenShareCiphertext(t *testing.T) {
+	curve := elliptic.P256()
+	d, _ := new(big.Int).SetString("1234567890abcdef", 16)
+	key := &ecdsa.PrivateKey{D: d}
+	key.PublicKey.Curve = curve
+	key.PublicKey.X, key.PublicKey.Y = curve.ScalarBaseMult(d.Bytes())
+	from, to := big.NewInt(1), big.NewInt(2)
+	shares := SecretShares{big.NewInt(5), big.NewInt(6), curve}
+
+	// encryption is randomized, so the golden ciphertext is only checked to
+	// still decrypt
+	if *updateGolden {
+		ciphertext, err := encryptShares(curve, &key.PublicKey, from, to, shares, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkGolden(t, "share-ciphertext", ciphertext)
+	}
+	decrypted, err := decryptShares(curve, SoftwareIdentity(key), from, to, readGolden(t, "share-ciphertext"))
+	if err != nil {
+		t.Fatalf("Could not decrypt golden ciphertext: %v", err)
+	}
+	if !reflect.DeepEqual(decrypted, shares) {
+		t.Errorf("Golden ciphertext decrypted to %v, expected %v", decrypted, shares)
+	}
+}
@@ -0,0 +1,232 @@
+package dkg
+
+import "crypto/elliptic"
+import "math/big"
+import "sync"
+import "time"
+
+// GuardLimits bound what a GuardTransport lets through from each sender.
+type GuardLimits struct {
+	// MaxSize is the largest binary encoding of a message, in bytes.
+	MaxSize int
+	// Rate is the number of messages per second a sender may keep up, and
+	// Burst the number it may send at once.
+	Rate  float64
+	Burst int
+}
+
+// DefaultGuardLimits allows for the largest messages of a ceremony among n
+// participants on curve, and several times the messages a participant
+// sends in a ceremony at once.
+func DefaultGuardLimits(curve elliptic.Curve, n int) GuardLimits {
+	// a justification revealing two scalars for every other participant
+	// is the largest message, with room for envelopes
+	scalar := 4 + ScalarSize(curve)
+	return GuardLimits{
+		MaxSize: 4096 + n*(3*scalar+16),
+		Rate:    10,
+		Burst:   8 * n,
+	}
+}
+
+// GuardTransport drops inbound messages before they cost a node any
+// expensive verification: messages of unknown senders, larger than the
+// limit or beyond the sender's rate, and messages whose payloads are
+// malformed, such as points off the curve or outside its prime-order
+// subgroup and polynomials of the wrong degree. Beneath an
+// EnvelopeTransport, it saves the signature checks too, but the senders it
+// rate-limits are unauthenticated, so that a forger can use up another
+// sender's allowance; above, it only limits authenticated senders.
+type GuardTransport struct {
+	transport    Transport
+	curve        elliptic.Curve
+	threshold    int
+	participants map[string]bool
+	limits       GuardLimits
+	inbox        *mailbox
+
+	mu       sync.Mutex
+	buckets  map[string]*tokenBucket
+	rejected map[string]*guardRejections
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type guardRejections struct {
+	count int
+	last  error
+}
+
+func NewGuardTransport(participants *ParticipantSet, limits GuardLimits, transport Transport) *GuardTransport {
+	t := &GuardTransport{
+		transport:    transport,
+		curve:        participants.curve,
+		threshold:    participants.threshold,
+		participants: make(map[string]bool),
+		limits:       limits,
+		inbox:        newMailbox(),
+		buckets:      make(map[string]*tokenBucket),
+		rejected:     make(map[string]*guardRejections),
+	}
+	for _, p := range participants.participants {
+		t.participants[t.key(p.ID)] = true
+	}
+	go t.run()
+	return t
+}
+
+func (t *GuardTransport) key(id *big.Int) string {
+	return new(big.Int).Mod(id, t.curve.Params().N).String()
+}
+
+func (t *GuardTransport) run() {
+	defer t.inbox.close()
+	for m := range t.transport.Receive() {
+		if m.From == nil || !t.participants[t.key(m.From)] {
+			continue
+		}
+		if err := t.check(m); err != nil {
+			t.reject(m.From, err)
+			continue
+		}
+		t.inbox.put(m)
+	}
+}
+
+// check applies the limits to a message of a known sender, cheapest first.
+func (t *GuardTransport) check(m Message) error {
+	if !t.allow(m.From) {
+		return RateLimitedError{m.From}
+	}
+	if size := len(marshalBinary(m)); size > t.limits.MaxSize {
+		return MessageTooLargeError{m.From, size, t.limits.MaxSize}
+	}
+	payload := m.Payload
+	if e, ok := payload.(envelopePayload); ok {
+		payload = e.Payload
+	}
+	if !t.wellFormed(m.Type, payload) {
+		return ProtocolViolationError{m.From, InvalidPayloadError{m.Type}}
+	}
+	return nil
+}
+
+// allow takes a token from the sender's bucket, if there is one.
+func (t *GuardTransport) allow(from *big.Int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	key := t.key(from)
+	b, ok := t.buckets[key]
+	if !ok {
+		b = &tokenBucket{float64(t.limits.Burst), now}
+		t.buckets[key] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * t.limits.Rate
+	if b.tokens > float64(t.limits.Burst) {
+		b.tokens = float64(t.limits.Burst)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// wellFormed checks a payload's type and shape, and its points against the
+// curve.
+func (t *GuardTransport) wellFormed(mType MessageType, payload Hashable) bool {
+	n := t.curve.Params().N
+	scalar := func(k *big.Int) bool { return k != nil && k.Sign() >= 0 && k.Cmp(n) < 0 }
+	switch p := payload.(type) {
+	case PointTuple:
+		if mType != VerificationPointsMessage && mType != PublicCoefficientsMessage || len(p) != t.threshold+1 {
+			return false
+		}
+		for _, pt := range p {
+			if pt.X == nil || pt.Y == nil || !isValidPoint(t.curve, pt.X, pt.Y) {
+				return false
+			}
+		}
+		return true
+	case SecretKnowledgeProof:
+		return mType == SecretKnowledgeMessage && p.CommitX != nil && p.CommitY != nil &&
+			isValidPoint(t.curve, p.CommitX, p.CommitY) && scalar(p.Response1) && scalar(p.Response2)
+	case EncryptedShares:
+		return mType == SecretSharesMessage
+	case Complaints:
+		return mType == ComplaintsMessage && len(p.Accused) <= len(t.participants)
+	case Justification:
+		if mType != JustificationMessage || len(p.Revealed) > len(t.participants) {
+			return false
+		}
+		for _, rs := range p.Revealed {
+			if rs.Recipient == nil || !scalar(rs.Share1) || !scalar(rs.Share2) {
+				return false
+			}
+		}
+		return true
+	case Hello:
+		return mType == HelloMessage
+	case Retry:
+		return mType == RetryMessage
+	case QualifiedSet:
+		return mType == QualifiedSetMessage && len(p.Qualified) <= len(t.participants)
+	case Exposure:
+		if mType != CoefficientComplaintsMessage && mType != ReconstructionMessage || len(p.Exposed) > len(t.participants) {
+			return false
+		}
+		for _, es := range p.Exposed {
+			if es.Dealer == nil || !scalar(es.Share1) || !scalar(es.Share2) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func (t *GuardTransport) reject(from *big.Int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r, ok := t.rejected[t.key(from)]
+	if !ok {
+		r = &guardRejections{}
+		t.rejected[t.key(from)] = r
+	}
+	r.count++
+	r.last = err
+}
+
+// Rejected returns the number of messages of participant id dropped so
+// far, and why the last one was.
+func (t *GuardTransport) Rejected(id *big.Int) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if r, ok := t.rejected[t.key(id)]; ok {
+		return r.count, r.last
+	}
+	return 0, nil
+}
+
+func (t *GuardTransport) Send(to *big.Int, m Message) error {
+	return t.transport.Send(to, m)
+}
+
+func (t *GuardTransport) Broadcast(m Message) error {
+	return t.transport.Broadcast(m)
+}
+
+func (t *GuardTransport) Receive() <-chan Message {
+	return t.inbox.out
+}
+
+func (t *GuardTransport) Close() error {
+	err := t.transport.Close()
+	t.inbox.close()
+	return err
+}
@@ -0,0 +1,93 @@
+package dkg
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGuardTransport(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 4, 2)
+	set, err := NewParticipantSet(nodes[0].curve, 2, participants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	limits := DefaultGuardLimits(set.curve, set.Len())
+
+	t.Run("Ceremony", func(t *testing.T) {
+		guard := func(n *Node, t Transport) Transport {
+			return NewGuardTransport(set, limits, t)
+		}
+		envelope := func(n *Node, t Transport) Transport {
+			return NewEnvelopeTransport(n, set, "guarded", t)
+		}
+		checkCeremonyResultsForTesting(t, runCeremonyForTesting(t, nodes, participants, guard, envelope))
+	})
+
+	t.Run("Garbage", func(t *testing.T) {
+		network := NewMemoryNetwork()
+		limits := limits
+		limits.Rate, limits.Burst = 0.001, 5
+		guarded := NewGuardTransport(set, limits, network.Transport(nodes[0].ID()))
+		defer guarded.Close()
+		sender := network.Transport(nodes[1].ID())
+		defer sender.Close()
+		from := nodes[1].ID()
+
+		curve := set.curve
+		point := struct{ X, Y *big.Int }{curve.Params().Gx, curve.Params().Gy}
+		offCurve := struct{ X, Y *big.Int }{curve.Params().Gx, big.NewInt(1)}
+		valid := Message{VerificationPointsMessage, from, nil, PointTuple{point, point, point}}
+		large := Message{SecretSharesMessage, from, nil, EncryptedShares{make([]byte, limits.MaxSize)}}
+		for _, test := range []struct {
+			name string
+			m    Message
+			err  error
+		}{
+			{"Short polynomial", Message{VerificationPointsMessage, from, nil, PointTuple{point, point}}, ProtocolViolationError{from, InvalidPayloadError{VerificationPointsMessage}}},
+			{"Point off the curve", Message{PublicCoefficientsMessage, from, nil, PointTuple{point, offCurve, point}}, ProtocolViolationError{from, InvalidPayloadError{PublicCoefficientsMessage}}},
+			{"Mismatched payload", Message{ComplaintsMessage, from, nil, Hello{}}, ProtocolViolationError{from, InvalidPayloadError{ComplaintsMessage}}},
+			{"Too large", large, MessageTooLargeError{from, len(marshalBinary(large)), limits.MaxSize}},
+			{"Valid", valid, nil},
+			{"Rate limited", valid, RateLimitedError{from}},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				before, _ := guarded.Rejected(from)
+				if err := sender.Broadcast(test.m); err != nil {
+					t.Fatal(err)
+				}
+				if test.err == nil {
+					select {
+					case m := <-guarded.Receive():
+						if !reflect.DeepEqual(m, test.m) {
+							t.Errorf("Got unexpected message %v", m)
+						}
+					case <-time.After(time.Second):
+						t.Fatal("Valid message was dropped")
+					}
+					return
+				}
+				deadline := time.Now().Add(time.Second)
+				for {
+					count, err := guarded.Rejected(from)
+					if count > before {
+						if !reflect.DeepEqual(err, test.err) {
+							t.Errorf("Got unexpected rejection: %v", err)
+						}
+						return
+					}
+					if time.Now().After(deadline) {
+						t.Fatal("Message wasn't rejected")
+					}
+					time.Sleep(time.Millisecond)
+				}
+			})
+		}
+		select {
+		case m := <-guarded.Receive():
+			t.Errorf("Got dropped message %v", m)
+		default:
+		}
+	})
+}
@@ -0,0 +1,213 @@
+package dkg
+
+import "crypto/elliptic"
+import "encoding/binary"
+import "hash"
+import "math/big"
+
+// Hashable is implemented by the structures of this package which have a
+// canonical byte encoding: fixed field order, every field length-prefixed.
+type Hashable interface {
+	writeCanonical(w *TranscriptWriter)
+}
+
+// HashOf returns the digest of the canonical encoding of v under h. The hash
+// is reset before use.
+func HashOf(h hash.Hash, v Hashable) []byte {
+	w := NewTranscriptWriter(h)
+	w.Write(v)
+	return w.Sum()
+}
+
+// DomainSeparatedHash returns h with every transcript it hashes prefixed by
+// domain, so that the transcripts of different applications, deployments or
+// sessions can't be confused. Within a domain, the transcripts of different
+// protocol contexts are told apart by the tag each starts with, such as
+// "dkg/secret-knowledge".
+func DomainSeparatedHash(h hash.Hash, domain string) hash.Hash {
+	d := &domainHash{h, domain}
+	d.Reset()
+	return d
+}
+
+type domainHash struct {
+	hash.Hash
+	domain string
+}
+
+func (d *domainHash) Reset() {
+	w := NewTranscriptWriter(d.Hash)
+	w.WriteTag("dkg/domain")
+	w.WriteTag(d.domain)
+}
+
+// scalarStatement is block of the expansion of v by HashToScalar.
+type scalarStatement struct {
+	v     Hashable
+	block uint64
+}
+
+func (s scalarStatement) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/hash-to-scalar")
+	w.WriteUint(s.block)
+	w.Write(s.v)
+}
+
+// HashToScalar hashes v to a scalar mod n: digests of v with a block
+// counter, expanded to 128 bits more than n and reduced, so that the result
+// is close to uniform whatever the digest size of h.
+func HashToScalar(h hash.Hash, n *big.Int, v Hashable) *big.Int {
+	size := (n.BitLen()+7)/8 + 16
+	var expanded []byte
+	for block := uint64(0); len(expanded) < size; block++ {
+		expanded = append(expanded, HashOf(h, scalarStatement{v, block})...)
+	}
+	k := new(big.Int).SetBytes(expanded[:size])
+	return k.Mod(k, n)
+}
+
+// TranscriptWriter streams length-prefixed fields into a hash, so that
+// large structures are hashed without first being encoded into memory.
+type TranscriptWriter struct {
+	h hash.Hash
+	// the curve of the points to compress, in binary encodings only
+	compress elliptic.Curve
+}
+
+// NewTranscriptWriter resets h and returns a writer feeding it.
+func NewTranscriptWriter(h hash.Hash) *TranscriptWriter {
+	h.Reset()
+	return &TranscriptWriter{h: h}
+}
+
+func (w *TranscriptWriter) WriteBytes(b []byte) {
+	var prefix [4]byte
+	binary.BigEndian.PutUint32(prefix[:], uint32(len(b)))
+	w.h.Write(prefix[:])
+	w.h.Write(b)
+}
+
+func (w *TranscriptWriter) WriteTag(tag string) {
+	w.WriteBytes([]byte(tag))
+}
+
+func (w *TranscriptWriter) WriteUint(x uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], x)
+	w.WriteBytes(b[:])
+}
+
+// nil is encoded like zero, the identity point convention of crypto/elliptic
+func (w *TranscriptWriter) WriteInt(x *big.Int) {
+	if x == nil {
+		w.WriteBytes(nil)
+		return
+	}
+	w.WriteBytes(x.Bytes())
+}
+
+func (w *TranscriptWriter) Write(v Hashable) {
+	v.writeCanonical(w)
+}
+
+func (w *TranscriptWriter) Sum() []byte {
+	return w.h.Sum(nil)
+}
+
+func (pts PointTuple) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/points")
+	w.WriteUint(uint64(len(pts)))
+	if w.compress != nil {
+		w.writeCompressedPoints(pts)
+		return
+	}
+	for _, pt := range pts {
+		w.WriteInt(pt.X)
+		w.WriteInt(pt.Y)
+	}
+}
+
+func (m Message) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/message")
+	w.WriteUint(uint64(m.Type))
+	w.WriteInt(m.From)
+	w.WriteInt(m.To)
+	if m.Payload == nil {
+		w.WriteTag("")
+		return
+	}
+	w.Write(m.Payload)
+}
+
+func (s SecretShares) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/shares")
+	writeCurveName(w, s.Curve)
+	w.WriteScalar(s.Curve, s.Share1)
+	w.WriteScalar(s.Curve, s.Share2)
+}
+
+func (e EncryptedShares) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/encrypted-shares")
+	w.WriteBytes(e.Ciphertext)
+}
+
+func (c Complaints) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/complaints")
+	w.WriteUint(uint64(len(c.Accused)))
+	for _, id := range c.Accused {
+		w.WriteInt(id)
+	}
+	w.WriteBytes(c.Signature)
+}
+
+func (p SecretKnowledgeProof) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/secret-knowledge-proof")
+	writeCurveName(w, p.Curve)
+	w.WriteInt(p.CommitX)
+	w.WriteInt(p.CommitY)
+	w.WriteScalar(p.Curve, p.Response1)
+	w.WriteScalar(p.Curve, p.Response2)
+}
+
+func (h Hello) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/hello")
+	w.WriteUint(h.Nonce)
+	if h.Echo {
+		w.WriteUint(1)
+	} else {
+		w.WriteUint(0)
+	}
+}
+
+func (q QualifiedSet) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/qualified-set")
+	w.WriteUint(uint64(len(q.Qualified)))
+	for _, id := range q.Qualified {
+		w.WriteInt(id)
+	}
+	w.WriteBytes(q.Signature)
+}
+
+func (e Exposure) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/exposure")
+	w.WriteUint(uint64(len(e.Exposed)))
+	for _, es := range e.Exposed {
+		w.WriteInt(es.Dealer)
+		w.Write(es.SecretShares)
+	}
+}
+
+func (r Retry) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/retry")
+	w.WriteUint(uint64(r.Phase))
+	w.WriteUint(r.Attempt)
+}
+
+func (j Justification) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/justification")
+	w.WriteUint(uint64(len(j.Revealed)))
+	for _, rs := range j.Revealed {
+		w.WriteInt(rs.Recipient)
+		w.Write(rs.SecretShares)
+	}
+}
@@ -0,0 +1,143 @@
+package dkg
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"math/big"
+	"testing"
+)
+
+func TestHashOf(t *testing.T) {
+	h := sha256.New()
+
+	pts := PointTuple{{big.NewInt(1), big.NewInt(23)}}
+	if !bytes.Equal(HashOf(h, pts), HashOf(h, pts)) {
+		t.Errorf("Hash of %v is not deterministic", pts)
+	}
+
+	distinct := []Hashable{
+		PointTuple{},
+		PointTuple{{big.NewInt(1), big.NewInt(23)}},
+		PointTuple{{big.NewInt(12), big.NewInt(3)}},
+		PointTuple{{big.NewInt(1), big.NewInt(23)}, {big.NewInt(1), big.NewInt(23)}},
+		Message{},
+	}
+	seen := make(map[string]Hashable)
+	for _, v := range distinct {
+		digest := string(HashOf(h, v))
+		if prev, ok := seen[digest]; ok {
+			t.Errorf("Hash collision between %v and %v", prev, v)
+		}
+		seen[digest] = v
+	}
+}
+
+func TestTranscriptWriter(t *testing.T) {
+	pts := PointTuple{{big.NewInt(1), big.NewInt(23)}, {big.NewInt(45), big.NewInt(6)}}
+
+	w := NewTranscriptWriter(sha256.New())
+	w.Write(pts)
+	w.WriteTag("suffix")
+	streamed := w.Sum()
+
+	// the same fields written out by hand
+	var buf bytes.Buffer
+	field := func(b []byte) {
+		buf.Write([]byte{0, 0, 0, byte(len(b))})
+		buf.Write(b)
+	}
+	field([]byte("dkg/points"))
+	field([]byte{0, 0, 0, 0, 0, 0, 0, 2})
+	field([]byte{1})
+	field([]byte{23})
+	field([]byte{45})
+	field([]byte{6})
+	field([]byte("suffix"))
+	expected := sha256.Sum256(buf.Bytes())
+
+	if !bytes.Equal(streamed, expected[:]) {
+		t.Errorf("Streamed transcript hash %x != %x", streamed, expected)
+	}
+}
+
+func TestDomainSeparatedHash(t *testing.T) {
+	pts := PointTuple{{big.NewInt(1), big.NewInt(23)}}
+	h := DomainSeparatedHash(sha256.New(), "app/ceremony-1")
+	digest := HashOf(h, pts)
+	if !bytes.Equal(HashOf(h, pts), digest) {
+		t.Errorf("Domain is lost on reset")
+	}
+	for _, other := range []hash.Hash{sha256.New(), DomainSeparatedHash(sha256.New(), "app/ceremony-2")} {
+		if bytes.Equal(HashOf(other, pts), digest) {
+			t.Errorf("Digests of different domains collide")
+		}
+	}
+
+	w := NewTranscriptWriter(sha256.New())
+	w.WriteTag("dkg/domain")
+	w.WriteTag("app/ceremony-1")
+	w.Write(pts)
+	if !bytes.Equal(w.Sum(), digest) {
+		t.Errorf("Domain isn't the prefix of the transcript")
+	}
+
+	n := big.NewInt(1009)
+	k := HashToScalar(h, n, pts)
+	if k.Sign() < 0 || k.Cmp(n) >= 0 || k.Cmp(HashToScalar(h, n, pts)) != 0 {
+		t.Errorf("Bad scalar %v mod %v", k, n)
+	}
+
+	nodes, _ := getCeremonyNodesForTesting(t, 2, 2)
+	for _, node := range nodes {
+		node.hash = DomainSeparatedHash(sha512.New512_256(), "app/ceremony-1")
+	}
+	proof, err := nodes[0].ProveSecretKnowledge(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !nodes[1].VerifySecretKnowledge(nodes[0].ID(), nodes[0].VerificationPoints(), proof) {
+		t.Errorf("Proof doesn't verify in its domain")
+	}
+	nodes[1].hash = DomainSeparatedHash(sha512.New512_256(), "app/ceremony-2")
+	if nodes[1].VerifySecretKnowledge(nodes[0].ID(), nodes[0].VerificationPoints(), proof) {
+		t.Errorf("Proof verifies in another domain")
+	}
+}
+
+func TestHashDomainOption(t *testing.T) {
+	pts := PointTuple{{big.NewInt(1), big.NewInt(23)}}
+	expected := HashOf(DomainSeparatedHash(sha256.New(), "app/ceremony-1"), pts)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := []NodeOption{WithCurve(elliptic.P256()), WithZKParam(big.NewInt(3)), WithID(big.NewInt(1)), WithKey(*key), WithThreshold(1)}
+	orders := [][]NodeOption{
+		{WithHashDomain("app/ceremony-1"), WithHash(sha256.New())},
+		{WithHash(sha256.New()), WithHashDomain("app/ceremony-1")},
+	}
+	for i, opts := range orders {
+		node, err := NewNodeWithOptions(append(base, opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(HashOf(node.hash, pts), expected) {
+			t.Errorf("Options in order %v don't separate the hash", i)
+		}
+	}
+
+	_, participants := getCeremonyNodesForTesting(t, 2, 1)
+	set, _ := NewParticipantSet(elliptic.P256(), 1, participants)
+	o, err := NewObserver(elliptic.P256(), sha256.New(), nil, nil, big.NewInt(3), set, WithHashDomain("app/ceremony-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(HashOf(o.params.hash, pts), expected) {
+		t.Errorf("Observer doesn't separate the hash")
+	}
+}
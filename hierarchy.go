@@ -0,0 +1,193 @@
+package dkg
+
+import "crypto/elliptic"
+import "io"
+import "math/big"
+
+// Organization is a participant of a two-level threshold structure. It
+// takes part in the ceremony as a single participant and splits its key
+// share among its nodes, any Threshold+1 of which act for it.
+type Organization struct {
+	ID        *big.Int
+	Threshold int
+	Nodes     []*big.Int
+}
+
+// HierarchicalStructure shares a key among organizations, of which any
+// threshold+1, each through more than its threshold of nodes, can use it,
+// such as 2 of 3 organizations each needing 3 of 5 nodes. The organizations
+// run the ceremony with that threshold, then each splits its key share
+// with Split.
+type HierarchicalStructure struct {
+	curve     elliptic.Curve
+	threshold int
+	orgs      []Organization
+}
+
+// HierarchicalID names a node of an organization.
+type HierarchicalID struct {
+	Organization *big.Int
+	Node         *big.Int
+}
+
+// HierarchicalShare is a node's share of its organization's key share.
+type HierarchicalShare struct {
+	Organization *big.Int
+	DealtShare
+}
+
+// NewHierarchicalStructure checks the organizations' IDs like
+// NewParticipantSet does, and the IDs of each organization's nodes against
+// its threshold.
+func NewHierarchicalStructure(curve elliptic.Curve, threshold int, orgs []Organization) (*HierarchicalStructure, error) {
+	ids := make([]*big.Int, len(orgs))
+	for i, org := range orgs {
+		ids[i] = org.ID
+		if err := validateIDs(curve, org.Threshold, org.Nodes); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateIDs(curve, threshold, ids); err != nil {
+		return nil, err
+	}
+	return &HierarchicalStructure{curve, threshold, append([]Organization(nil), orgs...)}, nil
+}
+
+func (h *HierarchicalStructure) organization(id *big.Int) (Organization, bool) {
+	for _, org := range h.orgs {
+		if h.key(org.ID) == h.key(id) {
+			return org, true
+		}
+	}
+	return Organization{}, false
+}
+
+// Split deals an organization's key share among its nodes. The returned
+// Feldman commitments start with the organization's public share, for the
+// nodes to check their shares with VerifyDealtShare. The organization
+// should discard its key share afterwards.
+func (h *HierarchicalStructure) Split(share *KeyShare, random io.Reader) ([]HierarchicalShare, PointTuple, error) {
+	if err := share.usable(); err != nil {
+		return nil, nil, err
+	}
+	org, ok := h.organization(share.ID)
+	if !ok {
+		return nil, nil, UnknownParticipantError{share.ID}
+	}
+	dealt, commitments, err := dealSecret(h.curve, share.Share, org.Threshold, org.Nodes, random)
+	if err != nil {
+		return nil, nil, err
+	}
+	shares := make([]HierarchicalShare, len(dealt))
+	for i, s := range dealt {
+		shares[i] = HierarchicalShare{org.ID, s}
+	}
+	return shares, commitments, nil
+}
+
+// signing groups the signers by organization, keeping the organizations
+// with enough signing nodes, and checks that there are enough of those.
+func (h *HierarchicalStructure) signing(signers []HierarchicalID) ([]*big.Int, map[string][]*big.Int, error) {
+	n := h.curve.Params().N
+	nodes := make(map[string][]*big.Int)
+	seen := make(map[string]bool)
+	for _, s := range signers {
+		if s.Organization == nil || s.Node == nil {
+			return nil, nil, InvalidParticipantIDError{s.Node}
+		}
+		org, ok := h.organization(s.Organization)
+		if !ok {
+			return nil, nil, UnknownParticipantError{s.Organization}
+		}
+		key := h.key(org.ID)
+		node := new(big.Int).Mod(s.Node, n)
+		known := false
+		for _, id := range org.Nodes {
+			known = known || new(big.Int).Mod(id, n).Cmp(node) == 0
+		}
+		if !known {
+			return nil, nil, UnknownParticipantError{s.Node}
+		}
+		if !seen[key+"/"+node.String()] {
+			seen[key+"/"+node.String()] = true
+			nodes[key] = append(nodes[key], node)
+		}
+	}
+	var orgs []*big.Int
+	for _, org := range h.orgs {
+		key := new(big.Int).Mod(org.ID, n)
+		if len(nodes[key.String()]) > org.Threshold {
+			orgs = append(orgs, key)
+		}
+	}
+	if len(orgs) <= h.threshold {
+		return nil, nil, InsufficientSharesError{len(orgs), h.threshold + 1}
+	}
+	return orgs, nodes, nil
+}
+
+// Coefficient returns the weight of id's share when the signers
+// interpolate the group's secret: the Lagrange coefficient of its
+// organization among the signing organizations times that of the node
+// among its organization's signers. Organizations with too few signers are
+// left out.
+func (h *HierarchicalStructure) Coefficient(id HierarchicalID, signers []HierarchicalID) (*big.Int, error) {
+	orgs, nodes, err := h.signing(append([]HierarchicalID{id}, signers...))
+	if err != nil {
+		return nil, err
+	}
+	c, ok := h.coefficient(id, orgs, nodes)
+	if !ok {
+		org, _ := h.organization(id.Organization)
+		return nil, InsufficientSharesError{len(nodes[h.key(org.ID)]), org.Threshold + 1}
+	}
+	return c, nil
+}
+
+func (h *HierarchicalStructure) key(id *big.Int) string {
+	return new(big.Int).Mod(id, h.curve.Params().N).String()
+}
+
+// coefficient returns the weight of id's share given the result of
+// signing, if its organization signs.
+func (h *HierarchicalStructure) coefficient(id HierarchicalID, orgs []*big.Int, nodes map[string][]*big.Int) (*big.Int, bool) {
+	n := h.curve.Params().N
+	org := new(big.Int).Mod(id.Organization, n)
+	for _, o := range orgs {
+		if o.Cmp(org) == 0 {
+			c := lagrangeCoefficient(org, orgs, n)
+			c.Mul(c, lagrangeCoefficient(new(big.Int).Mod(id.Node, n), nodes[org.String()], n))
+			return c.Mod(c, n), true
+		}
+	}
+	return nil, false
+}
+
+// Recover interpolates the group's secret from the nodes' shares, for
+// disaster recovery.
+func (h *HierarchicalStructure) Recover(shares []HierarchicalShare) (*big.Int, error) {
+	signers := make([]HierarchicalID, len(shares))
+	for i, s := range shares {
+		if s.Share == nil {
+			return nil, InvalidParticipantIDError{s.ID}
+		}
+		signers[i] = HierarchicalID{s.Organization, s.ID}
+	}
+	orgs, nodes, err := h.signing(signers)
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]bool)
+	secret := new(big.Int)
+	for i, s := range shares {
+		key := h.key(s.Organization) + "/" + h.key(s.ID)
+		if used[key] {
+			continue
+		}
+		used[key] = true
+		if c, ok := h.coefficient(signers[i], orgs, nodes); ok {
+			secret.Add(secret, c.Mul(c, s.Share))
+		}
+	}
+	return secret.Mod(secret, h.curve.Params().N), nil
+}
@@ -0,0 +1,78 @@
+package dkg
+
+import (
+	"crypto/rand"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestHierarchicalStructure(t *testing.T) {
+	// 2 of 3 organizations, each needing 3 of 5 nodes
+	nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+	results := runCeremonyForTesting(t, nodes, participants)
+	checkCeremonyResultsForTesting(t, results)
+	curve := results[0].PublicKey.Curve
+
+	var orgs []Organization
+	for _, p := range participants {
+		var ids []*big.Int
+		for j := 1; j <= 5; j++ {
+			ids = append(ids, big.NewInt(int64(10*j)))
+		}
+		orgs = append(orgs, Organization{p.ID, 2, ids})
+	}
+	h, err := NewHierarchicalStructure(curve, 1, orgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares := make(map[string][]HierarchicalShare)
+	for _, result := range results {
+		split, commitments, err := h.Split(result, rand.Reader)
+		if err != nil {
+			t.Fatalf("Organization %v could not split its share: %v", result.ID, err)
+		}
+		public := result.PublicShare(result.ID)
+		if commitments[0].X.Cmp(public.X) != 0 || commitments[0].Y.Cmp(public.Y) != 0 {
+			t.Errorf("Commitments of organization %v don't match its public share", result.ID)
+		}
+		for _, s := range split {
+			if !VerifyDealtShare(curve, s.DealtShare, commitments) {
+				t.Errorf("Share of node %v of organization %v doesn't verify", s.ID, result.ID)
+			}
+		}
+		shares[result.ID.String()] = split
+	}
+
+	// organization 3 has too few nodes to count
+	signing := append(append(append([]HierarchicalShare(nil), shares["1"][:3]...), shares["2"][2:]...), shares["3"][:2]...)
+	secret, err := h.Recover(signing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x, _ := curve.ScalarBaseMult(secret.Bytes()); x.Cmp(results[0].PublicKey.X) != 0 {
+		t.Errorf("Recovered another secret")
+	}
+
+	var signers []HierarchicalID
+	for _, s := range signing {
+		signers = append(signers, HierarchicalID{s.Organization, s.ID})
+	}
+	sum := new(big.Int)
+	for _, s := range signing[:6] {
+		c, err := h.Coefficient(HierarchicalID{s.Organization, s.ID}, signers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum.Add(sum, c.Mul(c, s.Share))
+	}
+	if sum.Mod(sum, curve.Params().N).Cmp(secret) != 0 {
+		t.Errorf("Coefficients interpolate another secret")
+	}
+	if _, err := h.Coefficient(signers[6], signers); !reflect.DeepEqual(err, InsufficientSharesError{2, 3}) {
+		t.Errorf("Got unexpected error for a node of a short organization: %v", err)
+	}
+	if _, err := h.Recover(append(shares["1"][:3], shares["3"][:2]...)); !reflect.DeepEqual(err, InsufficientSharesError{1, 2}) {
+		t.Errorf("Got unexpected error for a single organization: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package dkg
+
+import "crypto"
+import "crypto/ecdsa"
+import "io"
+import "math/big"
+
+// Identity is a node's identity key held outside the process, such as in a
+// PKCS#11 token, a cloud KMS or a TPM. The node signs its complaints with
+// Sign, passing the digest of its hash and crypto.Hash(0) as options, and
+// decrypts the shares dealt to it with ECDH, which devices offer as raw EC
+// Diffie-Hellman key derivation (CKM_ECDH1_DERIVE, TPM2_ECDH_ZGen). Public
+// must return an *ecdsa.PublicKey.
+type Identity interface {
+	crypto.Signer
+	// ECDH returns the x-coordinate of d * (x, y), d being the private key.
+	ECDH(x, y *big.Int) (*big.Int, error)
+}
+
+// SoftwareIdentity wraps an identity key held in process memory.
+func SoftwareIdentity(key *ecdsa.PrivateKey) Identity {
+	return softwareIdentity{key}
+}
+
+type softwareIdentity struct {
+	key *ecdsa.PrivateKey
+}
+
+func (s softwareIdentity) Public() crypto.PublicKey {
+	return &s.key.PublicKey
+}
+
+// Sign signs deterministically, ignoring random, so that simulated
+// transcripts are reproducible.
+func (s softwareIdentity) Sign(random io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return signDeterministic(s.key, digest)
+}
+
+func (s softwareIdentity) ECDH(x, y *big.Int) (*big.Int, error) {
+	if err := ValidatePoint(s.key.Curve, x, y); err != nil {
+		return nil, err
+	}
+	sx, _ := s.key.Curve.ScalarMult(x, y, scalarBytes(s.key.Curve, s.key.D))
+	return sx, nil
+}
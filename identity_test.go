@@ -0,0 +1,74 @@
+package dkg
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/sha512"
+	"io"
+	"math/big"
+	"sync/atomic"
+	"testing"
+)
+
+// tokenForTesting stands in for a hardware token, counting its operations.
+type tokenForTesting struct {
+	key        *ecdsa.PrivateKey
+	signs, dhs atomic.Int32
+}
+
+func (t *tokenForTesting) Public() crypto.PublicKey {
+	return &t.key.PublicKey
+}
+
+func (t *tokenForTesting) Sign(random io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	t.signs.Add(1)
+	return ecdsa.SignASN1(random, t.key, digest)
+}
+
+func (t *tokenForTesting) ECDH(x, y *big.Int) (*big.Int, error) {
+	t.dhs.Add(1)
+	sx, _ := t.key.Curve.ScalarMult(x, y, t.key.D.Bytes())
+	return sx, nil
+}
+
+func TestNodeWithIdentity(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+	software := nodes[0]
+	token := &tokenForTesting{key: &software.key}
+	node, err := NewNodeWithOptions(
+		WithCurve(software.curve), WithHash(sha512.New512_256()), WithGenerator2(software.g2x, software.g2y),
+		WithZKParam(software.zkParam), WithTimeout(software.timeout), WithID(software.id),
+		WithIdentity(token), WithPolynomials(software.secretPoly1, software.secretPoly2),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.key.D != nil {
+		t.Errorf("Node holds the private key of its token")
+	}
+	nodes[0] = node
+
+	results := runCeremonyForTesting(t, nodes, participants)
+	for i, result := range results {
+		if result == nil || len(result.Qualified) != len(nodes) {
+			t.Errorf("Node %v did not finish with all dealers qualified", nodes[i].ID())
+		}
+	}
+	checkCeremonyResultsForTesting(t, results)
+	if token.signs.Load() != 1 || token.dhs.Load() != int32(len(nodes)-1) {
+		t.Errorf("Token signed %v times and did %v key agreements", token.signs.Load(), token.dhs.Load())
+	}
+
+	if _, err := SaveNode(node, nil); err == nil {
+		t.Errorf("Saved a node with an external identity")
+	}
+	other := *token.key
+	other.PublicKey.X = big.NewInt(1)
+	if _, err := NewNodeWithOptions(
+		WithCurve(software.curve), WithHash(sha512.New512_256()), WithGenerator2(software.g2x, software.g2y),
+		WithZKParam(software.zkParam), WithTimeout(software.timeout), WithID(software.id),
+		WithIdentity(&tokenForTesting{key: &other}), WithPolynomials(software.secretPoly1, software.secretPoly2),
+	); err == nil {
+		t.Errorf("Accepted an identity with an invalid public key")
+	}
+}
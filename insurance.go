@@ -0,0 +1,308 @@
+package dkg
+
+import "crypto/ecdsa"
+import "crypto/sha256"
+import "io"
+import "math/big"
+
+// Custodian holds a shard of an insurance backup, such as a lawyer, an HSM
+// or an offline vault. Instructions, for instance whom to hand the shard to
+// and on which conditions, are stored alongside the shard and bound to it.
+type Custodian struct {
+	Name         string
+	Key          ecdsa.PublicKey
+	Instructions string
+}
+
+// InsuranceShard is a custodian's part of an InsuranceBackup, encrypted to
+// its key.
+type InsuranceShard struct {
+	Custodian  Custodian
+	Index      *big.Int
+	Ciphertext []byte
+}
+
+// InsuranceBackup is a key share's secret split among custodians, any
+// len(Commitments) of whom recover the share. The public part of the share
+// is kept in the clear. Commitments are the Feldman commitments of the
+// split, their first point the share's public share, so that each custodian
+// can check its shard against the group key, and the shards can be checked
+// before they are combined.
+type InsuranceBackup struct {
+	Group       GroupKey
+	ID          *big.Int
+	Qualified   []*big.Int
+	Commitments PointTuple
+	Shards      []InsuranceShard
+}
+
+// Insure splits the share's secret into shards for custodians, of which
+// required recover it.
+func (s *KeyShare) Insure(custodians []Custodian, required int, random io.Reader) (*InsuranceBackup, error) {
+	if err := s.usable(); err != nil {
+		return nil, err
+	}
+	curve := s.PublicKey.Curve
+	ids := make([]*big.Int, len(custodians))
+	for i := range ids {
+		ids[i] = big.NewInt(int64(i + 1))
+	}
+	shards, commitments, err := dealSecret(curve, s.Share, required-1, ids, random)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, shard := range shards {
+			zeroize(shard.Share)
+		}
+	}()
+
+	b := &InsuranceBackup{
+		Group:       s.Group(),
+		ID:          s.ID,
+		Qualified:   s.Qualified,
+		Commitments: commitments,
+	}
+	for i, c := range custodians {
+		if c.Key.Curve == nil || !isValidPoint(c.Key.Curve, c.Key.X, c.Key.Y) {
+			return nil, InvalidCurvePointError{c.Key.Curve, c.Key.X, c.Key.Y}
+		}
+		plaintext := scalarBytes(curve, shards[i].Share)
+		ciphertext, err := sealTo(&c.Key, shareEncryptionLabel, plaintext, b.shardAD(c, ids[i]), random)
+		clear(plaintext)
+		if err != nil {
+			return nil, err
+		}
+		b.Shards = append(b.Shards, InsuranceShard{c, ids[i], ciphertext})
+	}
+	return b, nil
+}
+
+// shardAD binds a shard to the backed up share, its index and custodian.
+func (b *InsuranceBackup) shardAD(c Custodian, index *big.Int) []byte {
+	w := NewTranscriptWriter(sha256.New())
+	w.WriteTag("dkg/insurance-shard")
+	w.WriteTag(b.Group.PublicKey.Curve.Params().Name)
+	w.WriteInt(b.Group.PublicKey.X)
+	w.WriteInt(b.Group.PublicKey.Y)
+	w.WriteUint(b.Group.Epoch)
+	w.WriteInt(b.ID)
+	w.Write(b.Commitments)
+	w.WriteInt(index)
+	w.WriteBytes([]byte(c.Name))
+	w.WriteBytes([]byte(c.Instructions))
+	return w.Sum()
+}
+
+// Verify checks that the commitments split the backed up share.
+func (b *InsuranceBackup) Verify() error {
+	curve := b.Group.PublicKey.Curve
+	if curve == nil || len(b.Commitments) == 0 || len(b.Commitments) > len(b.Shards) ||
+		len(b.Group.PublicCoefficients) == 0 || !validCommitments(curve, b.Commitments) {
+		return InvalidInsuranceShardError{nil}
+	}
+	px, py := evaluateCommitments(curve, b.Group.PublicCoefficients, b.ID)
+	if b.Commitments[0].X.Cmp(px) != 0 || b.Commitments[0].Y.Cmp(py) != 0 {
+		return InvalidInsuranceShardError{nil}
+	}
+	return nil
+}
+
+// OpenShard decrypts the shard with the given index with the custodian's
+// identity and checks it against the commitments.
+func (b *InsuranceBackup) OpenShard(index *big.Int, identity Identity) (DealtShare, error) {
+	if err := b.Verify(); err != nil {
+		return DealtShare{}, err
+	}
+	curve := b.Group.PublicKey.Curve
+	for _, shard := range b.Shards {
+		if shard.Index.Cmp(index) != 0 {
+			continue
+		}
+		plaintext, err := openWith(identity, shareEncryptionLabel, shard.Ciphertext, b.shardAD(shard.Custodian, shard.Index))
+		defer clear(plaintext)
+		if err != nil {
+			return DealtShare{}, err
+		}
+		k, err := DecodeScalar(curve, plaintext)
+		if err != nil {
+			return DealtShare{}, err
+		}
+		share := DealtShare{new(big.Int).Set(index), k}
+		if !VerifyDealtShare(curve, share, b.Commitments) {
+			return DealtShare{}, InvalidInsuranceShardError{index}
+		}
+		return share, nil
+	}
+	return DealtShare{}, InvalidInsuranceShardError{index}
+}
+
+// Recover reassembles the key share from opened shards.
+func (b *InsuranceBackup) Recover(shards []DealtShare) (*KeyShare, error) {
+	if err := b.Verify(); err != nil {
+		return nil, err
+	}
+	curve := b.Group.PublicKey.Curve
+	for _, shard := range shards {
+		if !VerifyDealtShare(curve, shard, b.Commitments) {
+			return nil, InvalidInsuranceShardError{shard.ID}
+		}
+	}
+	secret, err := RecoverSecret(curve, len(b.Commitments)-1, shards)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyShare{
+		ID:                 b.ID,
+		Epoch:              b.Group.Epoch,
+		Threshold:          b.Group.Threshold,
+		Qualified:          b.Qualified,
+		PublicKey:          b.Group.PublicKey,
+		PublicCoefficients: b.Group.PublicCoefficients,
+		Share:              secret,
+	}, nil
+}
+
+// ReleasedShard is a shard its custodian released to the machine that
+// replaces the share's lost one, encrypted to that machine's key.
+type ReleasedShard struct {
+	Index      *big.Int
+	Ciphertext []byte
+}
+
+// ReleaseShard opens the shard with the given index with the custodian's
+// identity, like OpenShard, and seals it to the replacement machine's key,
+// so that the shard is only ever in the clear at its custodian and at the
+// machine recovering the share.
+func (b *InsuranceBackup) ReleaseShard(index *big.Int, identity Identity, recipient ecdsa.PublicKey, random io.Reader) (ReleasedShard, error) {
+	if recipient.Curve == nil || !isValidPoint(recipient.Curve, recipient.X, recipient.Y) {
+		return ReleasedShard{}, InvalidCurvePointError{recipient.Curve, recipient.X, recipient.Y}
+	}
+	shard, err := b.OpenShard(index, identity)
+	if err != nil {
+		return ReleasedShard{}, err
+	}
+	defer zeroize(shard.Share)
+	plaintext := scalarBytes(b.Group.PublicKey.Curve, shard.Share)
+	defer clear(plaintext)
+	ciphertext, err := sealTo(&recipient, shareEncryptionLabel, plaintext, b.releaseAD(shard.ID), random)
+	if err != nil {
+		return ReleasedShard{}, err
+	}
+	return ReleasedShard{shard.ID, ciphertext}, nil
+}
+
+func (b *InsuranceBackup) releaseAD(index *big.Int) []byte {
+	w := NewTranscriptWriter(sha256.New())
+	w.WriteTag("dkg/insurance-release")
+	w.WriteBytes(b.shardAD(Custodian{}, index))
+	return w.Sum()
+}
+
+// RecoverReleased reassembles the key share on the replacement machine,
+// holding identity, from the shards released to it.
+func (b *InsuranceBackup) RecoverReleased(identity Identity, released []ReleasedShard) (*KeyShare, error) {
+	curve := b.Group.PublicKey.Curve
+	if curve == nil {
+		return nil, InvalidInsuranceShardError{nil}
+	}
+	shards := make([]DealtShare, 0, len(released))
+	defer func() {
+		for _, shard := range shards {
+			zeroize(shard.Share)
+		}
+	}()
+	for _, r := range released {
+		if r.Index == nil {
+			return nil, InvalidInsuranceShardError{nil}
+		}
+		plaintext, err := openWith(identity, shareEncryptionLabel, r.Ciphertext, b.releaseAD(r.Index))
+		if err != nil {
+			return nil, InvalidInsuranceShardError{r.Index}
+		}
+		k, err := DecodeScalar(curve, plaintext)
+		clear(plaintext)
+		if err != nil {
+			return nil, InvalidInsuranceShardError{r.Index}
+		}
+		shards = append(shards, DealtShare{new(big.Int).Set(r.Index), k})
+	}
+	return b.Recover(shards)
+}
+
+func (b *InsuranceBackup) MarshalBinary() ([]byte, error) {
+	curve := b.Group.PublicKey.Curve
+	if curve == nil {
+		return nil, InvalidEncodingError{"insurance backup without a curve"}
+	}
+	return encodeBinary(func(w *TranscriptWriter) {
+		w.WriteTag("dkg/insurance-backup")
+		w.WriteTag(curve.Params().Name)
+		w.WriteInt(b.ID)
+		w.WriteUint(b.Group.Epoch)
+		w.WriteUint(uint64(b.Group.Threshold))
+		w.WriteUint(uint64(len(b.Qualified)))
+		for _, id := range b.Qualified {
+			w.WriteInt(id)
+		}
+		w.Write(b.Group.PublicCoefficients)
+		w.Write(b.Commitments)
+		w.WriteUint(uint64(len(b.Shards)))
+		for _, shard := range b.Shards {
+			c := shard.Custodian
+			w.WriteBytes([]byte(c.Name))
+			w.WriteTag(c.Key.Curve.Params().Name)
+			w.WriteInt(c.Key.X)
+			w.WriteInt(c.Key.Y)
+			w.WriteBytes([]byte(c.Instructions))
+			w.WriteInt(shard.Index)
+			w.WriteBytes(shard.Ciphertext)
+		}
+	}), nil
+}
+
+// UnmarshalBinary decodes a backup, checking it with Verify.
+func (b *InsuranceBackup) UnmarshalBinary(data []byte) error {
+	var out InsuranceBackup
+	err := unmarshalBinary(data, func(r *transcriptReader) {
+		r.expectTag("dkg/insurance-backup")
+		curve := r.readCurve()
+		out.Group.PublicKey.Curve = curve
+		out.ID = r.readInt()
+		out.Group.Epoch = r.readUint()
+		out.Group.Threshold = int(r.readUint())
+		for n := r.readCount(); len(out.Qualified) < n; {
+			out.Qualified = append(out.Qualified, r.readInt())
+		}
+		r.expectTag("dkg/points")
+		out.Group.PublicCoefficients = r.readPoints()
+		r.expectTag("dkg/points")
+		out.Commitments = r.readPoints()
+		for n := r.readCount(); len(out.Shards) < n; {
+			var shard InsuranceShard
+			shard.Custodian.Name = string(r.readBytes())
+			shard.Custodian.Key.Curve = r.readCurve()
+			shard.Custodian.Key.X, shard.Custodian.Key.Y = r.readInt(), r.readInt()
+			shard.Custodian.Instructions = string(r.readBytes())
+			shard.Index = r.readInt()
+			shard.Ciphertext = r.readBytes()
+			out.Shards = append(out.Shards, shard)
+		}
+		if r.err != nil {
+			return
+		}
+		if len(out.Group.PublicCoefficients) != out.Group.Threshold+1 || !validCommitments(curve, out.Group.PublicCoefficients) {
+			r.fail("invalid public coefficients")
+			return
+		}
+		out.Group.PublicKey.X, out.Group.PublicKey.Y = out.Group.PublicCoefficients[0].X, out.Group.PublicCoefficients[0].Y
+	})
+	if err != nil {
+		return err
+	}
+	if err := out.Verify(); err != nil {
+		return err
+	}
+	*b = out
+	return nil
+}
@@ -0,0 +1,139 @@
+package dkg
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/mikalv/dkg/secp256k1"
+)
+
+func TestInsuranceBackup(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+	results := runCeremonyForTesting(t, nodes, participants)
+	checkCeremonyResultsForTesting(t, results)
+	share := results[0]
+
+	// custodians need not use the ceremony's curve
+	var custodians []Custodian
+	var identities []Identity
+	for i, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), secp256k1.S256()} {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		custodians = append(custodians, Custodian{
+			Name:         []string{"lawyer", "hsm", "vault"}[i],
+			Key:          key.PublicKey,
+			Instructions: "release to the board on a signed request",
+		})
+		identities = append(identities, SoftwareIdentity(key))
+	}
+
+	insured, err := share.Insure(custodians, 2, rand.Reader)
+	if err != nil {
+		t.Fatalf("Could not insure share: %v", err)
+	}
+	data, err := insured.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var backup InsuranceBackup
+	if err := backup.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Could not decode backup: %v", err)
+	}
+
+	var shards []DealtShare
+	for _, i := range []int{0, 2} {
+		shard, err := backup.OpenShard(backup.Shards[i].Index, identities[i])
+		if err != nil {
+			t.Fatalf("Custodian %v could not open its shard: %v", custodians[i].Name, err)
+		}
+		shards = append(shards, shard)
+	}
+	recovered, err := backup.Recover(shards)
+	if err != nil {
+		t.Fatalf("Could not recover share: %v", err)
+	}
+	if recovered.Share.Cmp(share.Share) != 0 || !reflect.DeepEqual(recovered.Group(), share.Group()) {
+		t.Errorf("Recovered another share")
+	}
+
+	if _, err := backup.Recover(shards[:1]); reflect.TypeOf(err) != reflect.TypeOf(InsufficientSharesError{}) {
+		t.Errorf("Got unexpected error recovering from one shard: %v", err)
+	}
+	if _, err := backup.OpenShard(backup.Shards[0].Index, identities[1]); err == nil {
+		t.Errorf("Opened a shard with another custodian's key")
+	}
+	altered := backup
+	altered.Shards = append([]InsuranceShard(nil), backup.Shards...)
+	altered.Shards[0].Custodian.Instructions = "release to anyone"
+	if _, err := altered.OpenShard(altered.Shards[0].Index, identities[0]); err == nil {
+		t.Errorf("Opened a shard with altered instructions")
+	}
+	forged := shards[1]
+	forged.Share = new(big.Int).Add(forged.Share, one)
+	if _, err := backup.Recover([]DealtShare{shards[0], forged}); !reflect.DeepEqual(err, InvalidInsuranceShardError{forged.ID}) {
+		t.Errorf("Got unexpected error recovering from a forged shard: %v", err)
+	}
+	other := backup
+	other.ID = results[1].ID
+	if err := other.Verify(); err == nil {
+		t.Errorf("Backup verified for another share")
+	}
+}
+
+func TestInsuranceRelease(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+	results := runCeremonyForTesting(t, nodes, participants)
+	share := results[0]
+
+	var custodians []Custodian
+	var identities []Identity
+	for _, name := range []string{"alice", "bob", "carol"} {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		custodians = append(custodians, Custodian{Name: name, Key: key.PublicKey})
+		identities = append(identities, SoftwareIdentity(key))
+	}
+	backup, err := share.Insure(custodians, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the machine holding the share is lost; its replacement has a new key
+	machine, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var released []ReleasedShard
+	for _, i := range []int{1, 2} {
+		r, err := backup.ReleaseShard(backup.Shards[i].Index, identities[i], machine.PublicKey, rand.Reader)
+		if err != nil {
+			t.Fatalf("Custodian %v could not release its shard: %v", custodians[i].Name, err)
+		}
+		released = append(released, r)
+	}
+	recovered, err := backup.RecoverReleased(SoftwareIdentity(machine), released)
+	if err != nil {
+		t.Fatalf("Could not recover share: %v", err)
+	}
+	if recovered.Share.Cmp(share.Share) != 0 || recovered.ID.Cmp(share.ID) != 0 {
+		t.Errorf("Recovered another share")
+	}
+
+	// a release can't be replayed as another shard, nor opened elsewhere
+	swapped := []ReleasedShard{released[0], {released[0].Index, released[1].Ciphertext}}
+	if _, err := backup.RecoverReleased(SoftwareIdentity(machine), swapped); !reflect.DeepEqual(err, InvalidInsuranceShardError{released[0].Index}) {
+		t.Errorf("Got unexpected error recovering from a mislabeled shard: %v", err)
+	}
+	other, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if _, err := backup.RecoverReleased(SoftwareIdentity(other), released); reflect.TypeOf(err) != reflect.TypeOf(InvalidInsuranceShardError{}) {
+		t.Errorf("Got unexpected error recovering on another machine: %v", err)
+	}
+}
@@ -0,0 +1,534 @@
+package dkg
+
+import "crypto/elliptic"
+import "encoding/json"
+import "math/big"
+import "strings"
+
+// JSON encodings spell integers as minimal lowercase hex strings, so that
+// they survive parsers which read JSON numbers as doubles.
+
+func curveToJSON(curve elliptic.Curve) string {
+	if curve == nil {
+		return ""
+	}
+	return curve.Params().Name
+}
+
+func intToJSON(x *big.Int) string {
+	if x == nil {
+		return ""
+	}
+	return x.Text(16)
+}
+
+// jsonDecoder converts hex strings back to integers, keeping the first
+// error.
+type jsonDecoder struct {
+	err error
+}
+
+func (d *jsonDecoder) fail(reason string) {
+	if d.err == nil {
+		d.err = InvalidEncodingError{reason}
+	}
+}
+
+func (d *jsonDecoder) int(s string) *big.Int {
+	if s == "" || s != strings.ToLower(s) || (len(s) > 1 && s[0] == '0') {
+		d.fail("invalid hex integer " + s)
+		return nil
+	}
+	x, ok := new(big.Int).SetString(s, 16)
+	if !ok || x.Sign() < 0 {
+		d.fail("invalid hex integer " + s)
+		return nil
+	}
+	return x
+}
+
+// curve looks up a curve by name.
+func (d *jsonDecoder) curve(name string) elliptic.Curve {
+	curve, ok := CurveByName(name)
+	if !ok {
+		d.fail("unknown curve " + name)
+	}
+	return curve
+}
+
+// scalar decodes a scalar of curve, which must be below its order.
+func (d *jsonDecoder) scalar(curve elliptic.Curve, s string) *big.Int {
+	k := d.int(s)
+	if k == nil || curve == nil {
+		return nil
+	}
+	if k.Cmp(curve.Params().N) >= 0 {
+		d.fail("scalar out of range")
+		return nil
+	}
+	return k
+}
+
+// id decodes an optional participant ID.
+func (d *jsonDecoder) id(s string) *big.Int {
+	if s == "" {
+		return nil
+	}
+	return d.int(s)
+}
+
+func (d *jsonDecoder) ints(ss []string) []*big.Int {
+	var xs []*big.Int
+	for _, s := range ss {
+		xs = append(xs, d.int(s))
+	}
+	return xs
+}
+
+func (d *jsonDecoder) unmarshal(data []byte, v interface{}) {
+	if d.err == nil {
+		d.err = json.Unmarshal(data, v)
+	}
+}
+
+type jsonPoint struct {
+	X string `json:"x"`
+	Y string `json:"y"`
+}
+
+func (pts PointTuple) MarshalJSON() ([]byte, error) {
+	out := make([]jsonPoint, len(pts))
+	for i, pt := range pts {
+		out[i] = jsonPoint{intToJSON(pt.X), intToJSON(pt.Y)}
+	}
+	return json.Marshal(out)
+}
+
+func (pts *PointTuple) UnmarshalJSON(data []byte) error {
+	var in []jsonPoint
+	d := &jsonDecoder{}
+	d.unmarshal(data, &in)
+	var out PointTuple
+	for _, pt := range in {
+		out = append(out, struct{ X, Y *big.Int }{d.int(pt.X), d.int(pt.Y)})
+	}
+	if d.err == nil {
+		*pts = out
+	}
+	return d.err
+}
+
+type jsonShares struct {
+	Curve  string `json:"curve"`
+	Share1 string `json:"share1"`
+	Share2 string `json:"share2"`
+}
+
+func sharesToJSON(s SecretShares) jsonShares {
+	return jsonShares{curveToJSON(s.Curve), intToJSON(s.Share1), intToJSON(s.Share2)}
+}
+
+func (d *jsonDecoder) shares(in jsonShares) SecretShares {
+	curve := d.curve(in.Curve)
+	return SecretShares{d.scalar(curve, in.Share1), d.scalar(curve, in.Share2), curve}
+}
+
+func (s SecretShares) MarshalJSON() ([]byte, error) {
+	return json.Marshal(sharesToJSON(s))
+}
+
+func (s *SecretShares) UnmarshalJSON(data []byte) error {
+	var in jsonShares
+	d := &jsonDecoder{}
+	d.unmarshal(data, &in)
+	out := d.shares(in)
+	if d.err == nil {
+		*s = out
+	}
+	return d.err
+}
+
+type jsonEncryptedShares struct {
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func (e EncryptedShares) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonEncryptedShares(e))
+}
+
+func (e *EncryptedShares) UnmarshalJSON(data []byte) error {
+	var in jsonEncryptedShares
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*e = EncryptedShares(in)
+	return nil
+}
+
+type jsonComplaints struct {
+	Accused   []string `json:"accused"`
+	Signature []byte   `json:"signature"`
+}
+
+func (c Complaints) MarshalJSON() ([]byte, error) {
+	out := jsonComplaints{Accused: []string{}, Signature: c.Signature}
+	for _, id := range c.Accused {
+		out.Accused = append(out.Accused, intToJSON(id))
+	}
+	return json.Marshal(out)
+}
+
+func (c *Complaints) UnmarshalJSON(data []byte) error {
+	var in jsonComplaints
+	d := &jsonDecoder{}
+	d.unmarshal(data, &in)
+	out := Complaints{d.ints(in.Accused), in.Signature}
+	if d.err == nil {
+		*c = out
+	}
+	return d.err
+}
+
+type jsonRevealedShares struct {
+	Recipient string `json:"recipient"`
+	jsonShares
+}
+
+type jsonJustification struct {
+	Revealed []jsonRevealedShares `json:"revealed"`
+}
+
+func (j Justification) MarshalJSON() ([]byte, error) {
+	out := jsonJustification{Revealed: []jsonRevealedShares{}}
+	for _, rs := range j.Revealed {
+		out.Revealed = append(out.Revealed, jsonRevealedShares{
+			intToJSON(rs.Recipient),
+			sharesToJSON(rs.SecretShares),
+		})
+	}
+	return json.Marshal(out)
+}
+
+func (j *Justification) UnmarshalJSON(data []byte) error {
+	var in jsonJustification
+	d := &jsonDecoder{}
+	d.unmarshal(data, &in)
+	var out Justification
+	for _, rs := range in.Revealed {
+		out.Revealed = append(out.Revealed, RevealedShares{
+			d.int(rs.Recipient),
+			d.shares(rs.jsonShares),
+		})
+	}
+	if d.err == nil {
+		*j = out
+	}
+	return d.err
+}
+
+type jsonExposedShares struct {
+	Dealer string `json:"dealer"`
+	jsonShares
+}
+
+type jsonExposure struct {
+	Exposed []jsonExposedShares `json:"exposed"`
+}
+
+func (e Exposure) MarshalJSON() ([]byte, error) {
+	out := jsonExposure{Exposed: []jsonExposedShares{}}
+	for _, es := range e.Exposed {
+		out.Exposed = append(out.Exposed, jsonExposedShares{
+			intToJSON(es.Dealer),
+			sharesToJSON(es.SecretShares),
+		})
+	}
+	return json.Marshal(out)
+}
+
+func (e *Exposure) UnmarshalJSON(data []byte) error {
+	var in jsonExposure
+	d := &jsonDecoder{}
+	d.unmarshal(data, &in)
+	var out Exposure
+	for _, es := range in.Exposed {
+		out.Exposed = append(out.Exposed, ExposedShares{
+			d.int(es.Dealer),
+			d.shares(es.jsonShares),
+		})
+	}
+	if d.err == nil {
+		*e = out
+	}
+	return d.err
+}
+
+type jsonKnowledgeProof struct {
+	Curve     string `json:"curve"`
+	CommitX   string `json:"commitX"`
+	CommitY   string `json:"commitY"`
+	Response1 string `json:"response1"`
+	Response2 string `json:"response2"`
+}
+
+func (p SecretKnowledgeProof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonKnowledgeProof{
+		curveToJSON(p.Curve),
+		intToJSON(p.CommitX), intToJSON(p.CommitY),
+		intToJSON(p.Response1), intToJSON(p.Response2),
+	})
+}
+
+func (p *SecretKnowledgeProof) UnmarshalJSON(data []byte) error {
+	var in jsonKnowledgeProof
+	d := &jsonDecoder{}
+	d.unmarshal(data, &in)
+	curve := d.curve(in.Curve)
+	out := SecretKnowledgeProof{
+		d.int(in.CommitX), d.int(in.CommitY),
+		d.scalar(curve, in.Response1), d.scalar(curve, in.Response2),
+		curve,
+	}
+	if d.err == nil {
+		*p = out
+	}
+	return d.err
+}
+
+type jsonHello struct {
+	Nonce string `json:"nonce"`
+	Echo  bool   `json:"echo,omitempty"`
+}
+
+func (h Hello) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonHello{intToJSON(new(big.Int).SetUint64(h.Nonce)), h.Echo})
+}
+
+func (h *Hello) UnmarshalJSON(data []byte) error {
+	var in jsonHello
+	d := &jsonDecoder{}
+	d.unmarshal(data, &in)
+	nonce := d.int(in.Nonce)
+	if d.err == nil && !nonce.IsUint64() {
+		d.fail("nonce out of range")
+	}
+	if d.err == nil {
+		*h = Hello{nonce.Uint64(), in.Echo}
+	}
+	return d.err
+}
+
+type jsonQualifiedSet struct {
+	Qualified []string `json:"qualified"`
+	Signature []byte   `json:"signature"`
+}
+
+func (q QualifiedSet) MarshalJSON() ([]byte, error) {
+	out := jsonQualifiedSet{Qualified: []string{}, Signature: q.Signature}
+	for _, id := range q.Qualified {
+		out.Qualified = append(out.Qualified, intToJSON(id))
+	}
+	return json.Marshal(out)
+}
+
+func (q *QualifiedSet) UnmarshalJSON(data []byte) error {
+	var in jsonQualifiedSet
+	d := &jsonDecoder{}
+	d.unmarshal(data, &in)
+	out := QualifiedSet{d.ints(in.Qualified), in.Signature}
+	if d.err == nil {
+		*q = out
+	}
+	return d.err
+}
+
+type jsonRetry struct {
+	Phase   string `json:"phase"`
+	Attempt uint64 `json:"attempt"`
+}
+
+func (r Retry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonRetry{r.Phase.String(), r.Attempt})
+}
+
+func (r *Retry) UnmarshalJSON(data []byte) error {
+	var in jsonRetry
+	d := &jsonDecoder{}
+	d.unmarshal(data, &in)
+	phase := phaseFromJSON(in.Phase)
+	if d.err == nil && phase < 0 {
+		d.fail("unknown phase " + in.Phase)
+	}
+	if d.err == nil {
+		*r = Retry{phase, in.Attempt}
+	}
+	return d.err
+}
+
+// phaseFromJSON returns the phase of the given name, or -1.
+func phaseFromJSON(name string) Phase {
+	for p, n := range phaseNames {
+		if name == n {
+			return Phase(p)
+		}
+	}
+	return -1
+}
+
+var messageTypeJSON = []string{
+	VerificationPointsMessage:    "verification-points",
+	SecretSharesMessage:          "secret-shares",
+	ComplaintsMessage:            "complaints",
+	JustificationMessage:         "justification",
+	PublicCoefficientsMessage:    "public-coefficients",
+	SecretKnowledgeMessage:       "secret-knowledge",
+	HelloMessage:                 "hello",
+	RetryMessage:                 "retry",
+	QualifiedSetMessage:          "qualified-set",
+	CoefficientComplaintsMessage: "coefficient-complaints",
+	ReconstructionMessage:        "reconstruction",
+}
+
+type jsonMessage struct {
+	Type      string          `json:"type"`
+	From      string          `json:"from,omitempty"`
+	To        string          `json:"to,omitempty"`
+	Session   string          `json:"session,omitempty"`
+	Envelope  *jsonEnvelope   `json:"envelope,omitempty"`
+	Broadcast *jsonBroadcast  `json:"broadcast,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+}
+
+// jsonEnvelope holds the fields of an envelope, which wraps the payload
+// inside the session tag.
+type jsonEnvelope struct {
+	Session   string `json:"session"`
+	Sequence  uint64 `json:"sequence"`
+	Phase     string `json:"phase"`
+	Signature []byte `json:"signature"`
+}
+
+// jsonBroadcast holds the fields of an echo broadcast step, which wraps the
+// payload inside the envelope.
+type jsonBroadcast struct {
+	Step   string `json:"step"`
+	Origin string `json:"origin"`
+}
+
+// MarshalJSON encodes m with its type spelled out and the payload as a
+// nested object, for logging and for participants not written in Go.
+func (m Message) MarshalJSON() ([]byte, error) {
+	if m.Type < 0 || int(m.Type) >= len(messageTypeJSON) {
+		return nil, InvalidEncodingError{"unknown message type " + m.Type.String()}
+	}
+	out := jsonMessage{Type: messageTypeJSON[m.Type], From: intToJSON(m.From), To: intToJSON(m.To)}
+	payload := m.Payload
+	if session, ok := payload.(sessionPayload); ok {
+		out.Session, payload = session.Session, session.Payload
+	}
+	if e, ok := payload.(envelopePayload); ok {
+		out.Envelope = &jsonEnvelope{e.Session, e.Sequence, e.Phase.String(), e.Signature}
+		payload = e.Payload
+	}
+	if b, ok := payload.(broadcastPayload); ok {
+		if b.Step < 0 || int(b.Step) >= len(broadcastStepNames) {
+			return nil, InvalidEncodingError{"unknown broadcast step"}
+		}
+		out.Broadcast = &jsonBroadcast{broadcastStepNames[b.Step], intToJSON(b.Origin)}
+		payload = b.Payload
+	}
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		out.Payload = b
+	}
+	return json.Marshal(out)
+}
+
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var in jsonMessage
+	d := &jsonDecoder{}
+	d.unmarshal(data, &in)
+	if d.err != nil {
+		return d.err
+	}
+
+	out := Message{Type: -1, From: d.id(in.From), To: d.id(in.To)}
+	for t, name := range messageTypeJSON {
+		if in.Type == name {
+			out.Type = MessageType(t)
+		}
+	}
+	if out.Type < 0 {
+		return InvalidEncodingError{"unknown message type " + in.Type}
+	}
+
+	if in.Payload != nil && string(in.Payload) != "null" {
+		switch out.Type {
+		case VerificationPointsMessage, PublicCoefficientsMessage:
+			var pts PointTuple
+			d.unmarshal(in.Payload, &pts)
+			out.Payload = pts
+		case SecretSharesMessage:
+			var e EncryptedShares
+			d.unmarshal(in.Payload, &e)
+			out.Payload = e
+		case ComplaintsMessage:
+			var c Complaints
+			d.unmarshal(in.Payload, &c)
+			out.Payload = c
+		case JustificationMessage:
+			var j Justification
+			d.unmarshal(in.Payload, &j)
+			out.Payload = j
+		case SecretKnowledgeMessage:
+			var p SecretKnowledgeProof
+			d.unmarshal(in.Payload, &p)
+			out.Payload = p
+		case HelloMessage:
+			var h Hello
+			d.unmarshal(in.Payload, &h)
+			out.Payload = h
+		case RetryMessage:
+			var r Retry
+			d.unmarshal(in.Payload, &r)
+			out.Payload = r
+		case QualifiedSetMessage:
+			var q QualifiedSet
+			d.unmarshal(in.Payload, &q)
+			out.Payload = q
+		case CoefficientComplaintsMessage, ReconstructionMessage:
+			var e Exposure
+			d.unmarshal(in.Payload, &e)
+			out.Payload = e
+		}
+	}
+	if b := in.Broadcast; b != nil {
+		step := broadcastStep(-1)
+		for s, name := range broadcastStepNames {
+			if b.Step == name {
+				step = broadcastStep(s)
+			}
+		}
+		if step < 0 {
+			return InvalidEncodingError{"unknown broadcast step " + b.Step}
+		}
+		out.Payload = broadcastPayload{step, d.int(b.Origin), out.Payload}
+	}
+	if e := in.Envelope; e != nil {
+		phase := phaseFromJSON(e.Phase)
+		if phase < 0 {
+			return InvalidEncodingError{"unknown phase " + e.Phase}
+		}
+		out.Payload = envelopePayload{e.Session, e.Sequence, phase, e.Signature, out.Payload}
+	}
+	if in.Session != "" {
+		out.Payload = sessionPayload{in.Session, out.Payload}
+	}
+	if d.err == nil {
+		*m = out
+	}
+	return d.err
+}
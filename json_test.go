@@ -0,0 +1,53 @@
+package dkg
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestJSONEncoding(t *testing.T) {
+	messages := []Message{
+		{SecretSharesMessage, big.NewInt(1), big.NewInt(2), nil},
+		{ComplaintsMessage, big.NewInt(1), nil, Complaints{Signature: []byte("signature")}},
+		{VerificationPointsMessage, big.NewInt(1), nil, sessionPayload{"0/P-256", PointTuple{{big.NewInt(1), big.NewInt(2)}}}},
+		{SecretSharesMessage, big.NewInt(1), big.NewInt(2), sessionPayload{"0/P-256", envelopePayload{"ceremony", 3, PhaseDealing, []byte("signature"), EncryptedShares{[]byte("ciphertext")}}}},
+		{ComplaintsMessage, big.NewInt(2), big.NewInt(3), envelopePayload{"ceremony", 4, PhaseComplaining, []byte("signature"), broadcastPayload{broadcastReady, big.NewInt(1), Complaints{Signature: []byte("signature")}}}},
+	}
+	for _, m := range goldenMessagesForTesting() {
+		messages = append(messages, m)
+	}
+	for _, m := range messages {
+		b, err := json.Marshal(m)
+		if err != nil {
+			t.Fatalf("Could not encode %+v: %v", m, err)
+		}
+		var decoded Message
+		if err := json.Unmarshal(b, &decoded); err != nil {
+			t.Errorf("Could not decode %s: %v", b, err)
+			continue
+		}
+		if !reflect.DeepEqual(decoded, m) {
+			t.Errorf("%s decoded to %+v, expected %+v", b, decoded, m)
+		}
+	}
+
+	invalid := []string{
+		`{"type":"greeting","from":"1"}`,
+		`{"type":"complaints","from":"01"}`,
+		`{"type":"complaints","from":"1","payload":{"accused":["-3"]}}`,
+		`{"type":"complaints","from":"1","payload":{"accused":["A"]}}`,
+		`{"type":"public-coefficients","from":"1","payload":[{"x":"1"}]}`,
+		`{"type":"justification","from":"1","payload":{"revealed":[{"recipient":"3","curve":"P-256","share1":"zz","share2":"1"}]}}`,
+		`{"type":"justification","from":"1","payload":{"revealed":[{"recipient":"3","curve":"P-0","share1":"1","share2":"1"}]}}`,
+		`{"type":"justification","from":"1","payload":{"revealed":[{"recipient":"3","curve":"P-256","share1":"ffffffff00000000ffffffffffffffffbce6faada7179e84f3b9cac2fc632551","share2":"1"}]}}`,
+		`{"type":"secret-knowledge","from":"1","payload":{"curve":"P-256","commitX":"1","commitY":"1","response1":"1","response2":"ffffffff00000000ffffffffffffffffbce6faada7179e84f3b9cac2fc632551"}}`,
+	}
+	for _, s := range invalid {
+		var m Message
+		if err := json.Unmarshal([]byte(s), &m); reflect.TypeOf(err) != reflect.TypeOf(InvalidEncodingError{}) {
+			t.Errorf("Got unexpected error decoding %s: %v", s, err)
+		}
+	}
+}
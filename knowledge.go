@@ -0,0 +1,140 @@
+package dkg
+
+import "crypto/elliptic"
+import "io"
+import "math/big"
+
+// SecretKnowledgeProof is the payload of a SecretKnowledgeMessage: a
+// non-interactive Schnorr-style proof that the dealer knows the constant
+// terms a, b of its polynomials behind its first verification point
+// C = a * G + b * G2. Without it a dealer could pick its verification points
+// after seeing everyone else's, and bias the group key.
+type SecretKnowledgeProof struct {
+	CommitX, CommitY     *big.Int
+	Response1, Response2 *big.Int
+	Curve                elliptic.Curve
+}
+
+// knowledgeStatement is what the Fiat-Shamir challenge of a proof is
+// derived from, bound to the node's zkParam and the dealer's ID.
+type knowledgeStatement struct {
+	zkParam          *big.Int
+	dealer           *big.Int
+	cx, cy           *big.Int
+	commitX, commitY *big.Int
+}
+
+func (s knowledgeStatement) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/secret-knowledge")
+	w.WriteInt(s.zkParam)
+	w.WriteInt(s.dealer)
+	w.WriteInt(s.cx)
+	w.WriteInt(s.cy)
+	w.WriteInt(s.commitX)
+	w.WriteInt(s.commitY)
+}
+
+func (p ceremonyParams) knowledgeChallenge(dealer, cx, cy, commitX, commitY *big.Int) *big.Int {
+	digest := HashOf(p.hash, knowledgeStatement{p.zkParam, dealer, cx, cy, commitX, commitY})
+	c := new(big.Int).SetBytes(digest)
+	return c.Mod(c, p.curve.Params().N)
+}
+
+// ProveSecretKnowledge proves that the node knows the constant terms of its
+// secret polynomials, with the challenge derived with the node's hash.
+func (n *Node) ProveSecretKnowledge(random io.Reader) (SecretKnowledgeProof, error) {
+	prover, err := n.BeginSecretKnowledgeProof(random)
+	if err != nil {
+		return SecretKnowledgeProof{}, err
+	}
+	vpts := n.VerificationPoints()
+	return prover.Respond(n.params().knowledgeChallenge(n.id, vpts[0].X, vpts[0].Y, prover.CommitX, prover.CommitY))
+}
+
+// KnowledgeProver is a proof of knowledge of a node's secrets in progress,
+// committed to T = r * G + s * G2 and waiting for its challenge.
+type KnowledgeProver struct {
+	CommitX, CommitY *big.Int
+
+	node *Node
+	r, s *big.Int
+}
+
+// BeginSecretKnowledgeProof commits to the randomness of a proof whose
+// challenge comes from elsewhere, see ChallengeSource.
+func (n *Node) BeginSecretKnowledgeProof(random io.Reader) (*KnowledgeProver, error) {
+	curve := n.curve
+	N := curve.Params().N
+	r, err := randomScalar(N, random)
+	if err != nil {
+		return nil, err
+	}
+	// with Feldman VSS the proof is a plain Schnorr proof, with s = 0
+	s := new(big.Int)
+	if !n.feldman {
+		if s, err = randomScalar(N, random); err != nil {
+			zeroize(r)
+			return nil, err
+		}
+	}
+	tx, ty := n.params().commit(r, s)
+	return &KnowledgeProver{tx, ty, n, r, s}, nil
+}
+
+// Respond completes the proof with challenge c. A prover answers one
+// challenge only: two answers reveal the secrets.
+func (p *KnowledgeProver) Respond(c *big.Int) (SecretKnowledgeProof, error) {
+	if p.r == nil {
+		return SecretKnowledgeProof{}, ProverReusedError{}
+	}
+	defer func() {
+		zeroize(p.r, p.s)
+		p.r, p.s = nil, nil
+	}()
+	n := p.node
+	f := scalarFieldFor(n.curve.Params().N)
+	z1, z2 := f.fromBig(p.r), f.fromBig(p.s)
+	defer clear(z1)
+	defer clear(z2)
+	f.mulAdd(z1, c, n.secretPoly1[0])
+	if !n.feldman {
+		f.mulAdd(z2, c, n.secretPoly2[0])
+	}
+	return SecretKnowledgeProof{p.CommitX, p.CommitY, f.toBig(z1), f.toBig(z2), n.curve}, nil
+}
+
+// VerifySecretKnowledge checks dealer's proof for its verification points
+// vpts: z1 * G + z2 * G2 == T + c * C
+func (n *Node) VerifySecretKnowledge(dealer *big.Int, vpts PointTuple, proof SecretKnowledgeProof) bool {
+	return n.params().verifySecretKnowledge(dealer, vpts, proof)
+}
+
+func (p ceremonyParams) verifySecretKnowledge(dealer *big.Int, vpts PointTuple, proof SecretKnowledgeProof) bool {
+	if len(vpts) == 0 || proof.CommitX == nil {
+		return false
+	}
+	c := p.knowledgeChallenge(dealer, vpts[0].X, vpts[0].Y, proof.CommitX, proof.CommitY)
+	return p.verifySecretKnowledgeChallenge(vpts, proof, c)
+}
+
+// VerifySecretKnowledgeChallenge checks an interactive proof for the
+// verification points vpts, answering challenge c.
+func (n *Node) VerifySecretKnowledgeChallenge(vpts PointTuple, proof SecretKnowledgeProof, c *big.Int) bool {
+	return n.params().verifySecretKnowledgeChallenge(vpts, proof, c)
+}
+
+func (p ceremonyParams) verifySecretKnowledgeChallenge(vpts PointTuple, proof SecretKnowledgeProof, c *big.Int) bool {
+	curve := p.curve
+	N := curve.Params().N
+	if len(vpts) == 0 || !isValidPoint(curve, vpts[0].X, vpts[0].Y) ||
+		!isValidPoint(curve, proof.CommitX, proof.CommitY) ||
+		!isNormalizedScalar(proof.Response1, N) || !isNormalizedScalar(proof.Response2, N) ||
+		!isNormalizedScalar(c, N) || p.feldman() && proof.Response2.Sign() != 0 {
+		return false
+	}
+
+	lx, ly := p.commit(proof.Response1, proof.Response2)
+	cx, cy := curve.ScalarMult(vpts[0].X, vpts[0].Y, scalarBytes(curve, c))
+	rx, ry := curve.Add(proof.CommitX, proof.CommitY, cx, cy)
+	return lx.Cmp(rx) == 0 && ly.Cmp(ry) == 0
+}
@@ -0,0 +1,66 @@
+package dkg
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestSecretKnowledgeProof(t *testing.T) {
+	nodes, _ := getCeremonyNodesForTesting(t, 2, 2)
+	prover, verifier := nodes[0], nodes[1]
+	vpts := prover.VerificationPoints()
+
+	proof, err := prover.ProveSecretKnowledge(rand.Reader)
+	if err != nil {
+		t.Fatalf("Could not prove secret knowledge: %v", err)
+	}
+	if !verifier.VerifySecretKnowledge(prover.ID(), vpts, proof) {
+		t.Fatalf("Valid proof didn't verify")
+	}
+
+	bad := []struct {
+		description string
+		dealer      *big.Int
+		vpts        PointTuple
+		proof       SecretKnowledgeProof
+	}{
+		{"other dealer", verifier.ID(), vpts, proof},
+		{"other verification points", prover.ID(), verifier.VerificationPoints(), proof},
+		{"no verification points", prover.ID(), nil, proof},
+		{"tampered response", prover.ID(), vpts, SecretKnowledgeProof{
+			proof.CommitX, proof.CommitY, new(big.Int).Add(proof.Response1, one), proof.Response2, proof.Curve,
+		}},
+		{"unnormalized response", prover.ID(), vpts, SecretKnowledgeProof{
+			proof.CommitX, proof.CommitY, proof.Response1, new(big.Int).Add(proof.Response2, prover.curve.Params().N), proof.Curve,
+		}},
+		{"invalid commitment", prover.ID(), vpts, SecretKnowledgeProof{
+			proof.CommitX, new(big.Int).Add(proof.CommitY, one), proof.Response1, proof.Response2, proof.Curve,
+		}},
+	}
+	for _, b := range bad {
+		if verifier.VerifySecretKnowledge(b.dealer, b.vpts, b.proof) {
+			t.Errorf("Proof with %v verified", b.description)
+		}
+	}
+
+	t.Run("Dealer without valid proof is disqualified", func(t *testing.T) {
+		nodes, participants := getCeremonyNodesForTesting(t, 5, 2)
+		dealer := nodes[0].ID()
+		results := runCeremonyForTesting(t, nodes, participants,
+			tamperWith(dealer, SecretKnowledgeMessage, func(_ *big.Int, payload Hashable) Hashable {
+				proof := payload.(SecretKnowledgeProof)
+				proof.Response2 = new(big.Int).Add(proof.Response2, one)
+				return proof
+			}))
+		for i, result := range results[1:] {
+			if result == nil {
+				t.Fatalf("Node %v did not finish", nodes[i+1].ID())
+			}
+			if len(result.Qualified) != len(nodes)-1 {
+				t.Errorf("Node %v qualified %v", nodes[i+1].ID(), result.Qualified)
+			}
+		}
+		checkCeremonyResultsForTesting(t, results[1:])
+	})
+}
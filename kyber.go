@@ -0,0 +1,73 @@
+package dkg
+
+import "crypto/ecdsa"
+import "math"
+import "math/big"
+
+import "github.com/mikalv/dkg/edwards25519"
+
+// KyberDistKeyShare is a key share in the encoding of the DKG packages of
+// dedis/kyber on their Ed25519 suite, such as the DistKeyShare of
+// share/dkg/pedersen: the commitments to the group's polynomial as RFC 8032
+// point encodings, the index I of the holder among the participants' public
+// keys, and its share, evaluated at x = I+1, as a little-endian scalar. A
+// committee mixing both implementations agrees on participant IDs I+1.
+type KyberDistKeyShare struct {
+	Commits [][]byte
+	I       int
+	V       []byte
+}
+
+// ExportKyberShare encodes a key share on edwards25519 for kyber.
+func ExportKyberShare(s *KeyShare) (KyberDistKeyShare, error) {
+	curve := s.PublicKey.Curve
+	if curve != edwards25519.Curve() {
+		return KyberDistKeyShare{}, UnsupportedCurveError{curve, "kyber"}
+	}
+	if err := s.usable(); err != nil {
+		return KyberDistKeyShare{}, err
+	}
+	if s.ID.Sign() <= 0 || !s.ID.IsInt64() || s.ID.Int64() > math.MaxInt32 {
+		return KyberDistKeyShare{}, InvalidParticipantIDError{s.ID}
+	}
+	k := KyberDistKeyShare{I: int(s.ID.Int64() - 1), V: s.Share.FillBytes(make([]byte, 32))}
+	reverse(k.V)
+	for _, c := range s.PublicCoefficients {
+		k.Commits = append(k.Commits, edwards25519.Encode(c.X, c.Y))
+	}
+	return k, nil
+}
+
+// ImportKyberShare decodes a key share of a kyber ceremony, checking it
+// against the commitments. Kyber's shares don't name the qualified
+// dealers.
+func ImportKyberShare(k KyberDistKeyShare) (*KeyShare, error) {
+	curve := edwards25519.Curve()
+	if len(k.Commits) == 0 || k.I < 0 || len(k.V) != 32 {
+		return nil, InvalidEncodingError{"malformed kyber key share"}
+	}
+	commitments := make(PointTuple, len(k.Commits))
+	for i, b := range k.Commits {
+		x, y, err := edwards25519.Decode(b)
+		if err != nil || !isValidPoint(curve, x, y) {
+			return nil, InvalidEncodingError{"invalid kyber commitment"}
+		}
+		commitments[i].X, commitments[i].Y = x, y
+	}
+	le := append([]byte(nil), k.V...)
+	reverse(le)
+	share := new(big.Int).SetBytes(le)
+	clear(le)
+	id := big.NewInt(int64(k.I) + 1)
+	if !VerifyDealtShare(curve, DealtShare{id, share}, commitments) {
+		zeroize(share)
+		return nil, InvalidEncodingError{"kyber share doesn't match its commitments"}
+	}
+	return &KeyShare{
+		ID:                 id,
+		Threshold:          len(commitments) - 1,
+		PublicKey:          ecdsa.PublicKey{Curve: curve, X: commitments[0].X, Y: commitments[0].Y},
+		PublicCoefficients: commitments,
+		Share:              share,
+	}, nil
+}
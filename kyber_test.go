@@ -0,0 +1,75 @@
+package dkg
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/mikalv/dkg/edwards25519"
+)
+
+func TestKyberShare(t *testing.T) {
+	const size, threshold = 4, 2
+	curve := edwards25519.Curve()
+
+	// an Ed25519 key, whose encoding kyber shares
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := sha512.Sum512(priv.Seed())
+	h[0] &= 248
+	h[31] &= 127
+	h[31] |= 64
+	reverse(h[:32])
+	d := new(big.Int).Mod(new(big.Int).SetBytes(h[:32]), curve.Params().N)
+	key := &ecdsa.PrivateKey{D: d}
+	key.Curve = curve
+	key.X, key.Y = curve.ScalarBaseMult(d.Bytes())
+
+	participants := make([]Participant, size)
+	for i := range participants {
+		identity, _ := ecdsa.GenerateKey(curve, rand.Reader)
+		participants[i] = Participant{big.NewInt(int64(i + 1)), identity.PublicKey}
+	}
+	set, err := NewParticipantSet(curve, threshold, participants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares, err := ImportPrivateKey(key, set, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, share := range shares {
+		k, err := ExportKyberShare(share)
+		if err != nil {
+			t.Fatalf("Could not export share of %v: %v", share.ID, err)
+		}
+		if k.I != i || len(k.Commits) != threshold+1 || !bytes.Equal(k.Commits[0], pub) {
+			t.Errorf("Share of %v exported as index %v with %v commitments", share.ID, k.I, len(k.Commits))
+		}
+		imported, err := ImportKyberShare(k)
+		if err != nil {
+			t.Fatalf("Could not import share of %v: %v", share.ID, err)
+		}
+		if !reflect.DeepEqual(imported.Group(), share.Group()) || imported.Share.Cmp(share.Share) != 0 || imported.ID.Cmp(share.ID) != 0 {
+			t.Errorf("Share of %v changed in a round trip", share.ID)
+		}
+
+		k.V[0] ^= 1
+		if _, err := ImportKyberShare(k); reflect.TypeOf(err) != reflect.TypeOf(InvalidEncodingError{}) {
+			t.Errorf("Got unexpected error importing a tampered share: %v", err)
+		}
+	}
+
+	p256, _ := getCeremonyNodesForTesting(t, 3, 1)
+	if _, err := ExportKyberShare(&KeyShare{ID: p256[0].ID(), PublicKey: p256[0].key.PublicKey, Share: one}); reflect.TypeOf(err) != reflect.TypeOf(UnsupportedCurveError{}) {
+		t.Errorf("Got unexpected error exporting a P-256 share: %v", err)
+	}
+}
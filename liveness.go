@@ -0,0 +1,85 @@
+package dkg
+
+// LivenessPolicy says what a runner does when participants miss the
+// deadline of a phase. Whatever the policy, a dealer that misses dealing
+// is only left out once the others confirm it: its missing shares count as
+// complaints, which every participant sees, and it is disqualified unless
+// it justifies them.
+type LivenessPolicy int
+
+const (
+	// ContinueWithQuorum leaves the missing participants out of the phase
+	// and carries on as long as more than threshold participants met every
+	// deadline after dealing so far, enough to use the key. Later phases
+	// don't wait for the left out participants to contest or reconstruct,
+	// and the public coefficients of qualified dealers that went silent
+	// after dealing are reconstructed from the others' shares. It is the
+	// default.
+	ContinueWithQuorum LivenessPolicy = iota
+	// AbortOnMissing aborts the ceremony with the phase's TimeoutError, for
+	// the operators to restart it, possibly without the missing
+	// participants.
+	AbortOnMissing
+	// RetryNRounds asks the missing participants for their messages again,
+	// like RetryRounds, then aborts like AbortOnMissing.
+	RetryNRounds
+)
+
+func (p LivenessPolicy) String() string {
+	switch p {
+	case ContinueWithQuorum:
+		return "continue-with-quorum"
+	case AbortOnMissing:
+		return "abort-on-missing"
+	case RetryNRounds:
+		return "retry-n-rounds"
+	}
+	return "unknown"
+}
+
+// Liveness sets the runner's liveness policy; rounds is the number of
+// retries of RetryNRounds and ignored otherwise. All participants must use
+// the same policy. It must be called before Run.
+func (r *ProtocolRunner) Liveness(policy LivenessPolicy, rounds int) {
+	r.liveness = policy
+	if policy == RetryNRounds {
+		r.retries = rounds
+	}
+}
+
+// missed applies the liveness policy to the participants a phase timed out
+// waiting for.
+func (r *ProtocolRunner) missed(err TimeoutError) error {
+	if r.liveness != ContinueWithQuorum {
+		return err
+	}
+	if err.phase == PhaseDealing {
+		// dealers whose messages got lost to this node alone complain and
+		// justify like the others; silent ones miss complaining too
+		return nil
+	}
+	for _, id := range err.missing {
+		r.byID[r.key(id)].lazy = true
+	}
+	live := 0
+	for _, p := range r.participants {
+		if !p.lazy {
+			live++
+		}
+	}
+	if live <= r.node.Threshold() {
+		return QuorumLostError{err, live, r.node.Threshold() + 1}
+	}
+	return nil
+}
+
+// live returns the participants that met every deadline so far.
+func (r *ProtocolRunner) live() []*participant {
+	var live []*participant
+	for _, p := range r.participants {
+		if !p.lazy {
+			live = append(live, p)
+		}
+	}
+	return live
+}
@@ -0,0 +1,127 @@
+package dkg
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// mutedTransport only sends messages of the given types.
+type mutedTransport struct {
+	Transport
+	types []MessageType
+}
+
+func (t mutedTransport) sends(mType MessageType) bool {
+	for _, sent := range t.types {
+		if sent == mType {
+			return true
+		}
+	}
+	return false
+}
+
+func (t mutedTransport) Send(to *big.Int, m Message) error {
+	if !t.sends(m.Type) {
+		return nil
+	}
+	return t.Transport.Send(to, m)
+}
+
+func (t mutedTransport) Broadcast(m Message) error {
+	if !t.sends(m.Type) {
+		return nil
+	}
+	return t.Transport.Broadcast(m)
+}
+
+func TestLiveness(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		policy    LivenessPolicy
+		threshold int
+		silent    int
+		dealt     bool // the silent ones go silent after dealing
+		err       func(silent ...*Node) error
+	}{
+		{"Continue with quorum", ContinueWithQuorum, 1, 1, false, nil},
+		{"Silent after dealing", ContinueWithQuorum, 1, 1, true, nil},
+		{"Abort on missing", AbortOnMissing, 1, 1, false, func(silent ...*Node) error {
+			return TimeoutError{PhaseDealing, []*big.Int{silent[0].ID()}, nil}
+		}},
+		{"Retry n rounds", RetryNRounds, 1, 1, false, func(silent ...*Node) error {
+			return TimeoutError{PhaseDealing, []*big.Int{silent[0].ID()}, nil}
+		}},
+		{"Quorum lost", ContinueWithQuorum, 2, 2, false, func(silent ...*Node) error {
+			return QuorumLostError{TimeoutError{PhaseComplaining, []*big.Int{silent[0].ID(), silent[1].ID()}, nil}, 2, 3}
+		}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			nodes, participants := getCeremonyNodesForTesting(t, 4, test.threshold)
+			network := NewMemoryNetwork()
+			runners := make([]*ProtocolRunner, len(nodes)-test.silent)
+			for i := range runners {
+				node := nodes[i]
+				transport := network.Transport(node.ID())
+				defer transport.Close()
+				set, _ := NewParticipantSet(node.curve, node.Threshold(), participants)
+				runners[i], _ = NewProtocolRunner(node, set, transport)
+				runners[i].Liveness(test.policy, 1)
+			}
+			var wg sync.WaitGroup
+			for _, node := range nodes[len(runners):] {
+				if !test.dealt {
+					break
+				}
+				transport := network.Transport(node.ID())
+				defer transport.Close()
+				set, _ := NewParticipantSet(node.curve, node.Threshold(), participants)
+				r, _ := NewProtocolRunner(node, set, mutedTransport{transport, dealingTypes})
+				wg.Add(1)
+				go func(r *ProtocolRunner) {
+					defer wg.Done()
+					r.Run()
+				}(r)
+			}
+			for _, r := range runners {
+				wg.Add(1)
+				go func(r *ProtocolRunner) {
+					defer wg.Done()
+					r.Run()
+				}(r)
+			}
+			wg.Wait()
+
+			var results []*KeyShare
+			for i, r := range runners {
+				result, err := r.Result()
+				if test.err == nil {
+					if err != nil {
+						t.Fatalf("Node %v failed: %v", nodes[i].ID(), err)
+					}
+					results = append(results, result)
+					continue
+				}
+				if !reflect.DeepEqual(err, test.err(nodes[len(runners):]...)) {
+					t.Errorf("Node %v got unexpected error: %v", nodes[i].ID(), err)
+				}
+				var timeout TimeoutError
+				if !errors.As(err, &timeout) || CodeOf(err) == CodeUnclassified {
+					t.Errorf("Node %v failed without a timeout: %v", nodes[i].ID(), err)
+				}
+			}
+			if test.err == nil {
+				checkCeremonyResultsForTesting(t, results)
+				qualified := len(nodes) - test.silent
+				if test.dealt {
+					qualified = len(nodes)
+				}
+				if len(results[0].Qualified) != qualified {
+					t.Errorf("Qualified %v", results[0].Qualified)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package dkg
+
+import "context"
+import "log/slog"
+import "math/big"
+
+// Runners log their ceremonies to the logger of their node, if it has one,
+// or to the one passed to Log: phases and events at the info level, faults
+// of other participants as warnings, and messages at the debug level. Each
+// record carries the node's ID, the phase and, for runners opened by a
+// SessionManager, the session. Records hold IDs, phases, message types and
+// errors, never payloads; the types holding secrets log as redacted values,
+// should a caller log them.
+
+// Log has the runner log its ceremony to logger, rather than to its node's
+// logger. It must be called before Run.
+func (r *ProtocolRunner) Log(logger *slog.Logger) {
+	r.logger = logger.With("node", r.node.id.String())
+}
+
+// Log has the engine log its ceremony to logger. It must be called before
+// Start.
+func (e *Engine) Log(logger *slog.Logger) {
+	e.r.Log(logger)
+}
+
+func (r *ProtocolRunner) log(level slog.Level, msg string, attrs ...slog.Attr) {
+	if r.logger == nil || !r.logger.Enabled(context.Background(), level) {
+		return
+	}
+	attrs = append(attrs, slog.String("phase", r.Phase().String()))
+	r.logger.LogAttrs(context.Background(), level, msg, attrs...)
+}
+
+func peerAttr(key string, id *big.Int) slog.Attr {
+	return slog.String(key, id.String())
+}
+
+func peersAttr(key string, ids []*big.Int) slog.Attr {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = id.String()
+	}
+	return slog.Any(key, s)
+}
+
+func (r *ProtocolRunner) logEvent(e Event) {
+	switch e := e.(type) {
+	case PhaseStarted:
+		r.log(slog.LevelInfo, "phase started")
+	case ShareReceived:
+		r.log(slog.LevelDebug, "share received", peerAttr("peer", e.From))
+	case ComplaintFiled:
+		r.log(slog.LevelInfo, "complaint filed", peerAttr("accuser", e.Accuser), peerAttr("accused", e.Accused))
+	case ParticipantDisqualified:
+		r.log(slog.LevelWarn, "participant disqualified", peerAttr("peer", e.ID), slog.Any("reason", e.Reason))
+	case CeremonyComplete:
+		if e.Err != nil {
+			r.log(slog.LevelError, "ceremony failed", slog.Any("error", e.Err), slog.String("code", CodeOf(e.Err).String()))
+		} else {
+			r.log(slog.LevelInfo, "ceremony finished", slog.Int("qualified", len(e.Share.Qualified)))
+		}
+	}
+}
+
+func (r *ProtocolRunner) logFault(err error) {
+	attrs := []slog.Attr{slog.Any("error", err)}
+	if e, ok := err.(interface{ Participants() []*big.Int }); ok {
+		attrs = append(attrs, peersAttr("peers", e.Participants()))
+	}
+	r.log(slog.LevelWarn, "fault", attrs...)
+}
+
+// received counts and logs a message before receiving it.
+func (r *ProtocolRunner) received(m Message) {
+	r.instrumentation.MessageReceived(m.Type)
+	if m.From != nil {
+		r.count(m, m.From, false)
+		r.log(slog.LevelDebug, "message received", slog.String("type", m.Type.String()), peerAttr("peer", m.From))
+	}
+	r.receive(m)
+}
+
+// sent counts and logs a message about to be sent.
+func (r *ProtocolRunner) sent(m Message) {
+	r.instrumentation.MessageSent(m.Type)
+	attrs := []slog.Attr{slog.String("type", m.Type.String())}
+	if m.To != nil {
+		attrs = append(attrs, peerAttr("peer", m.To))
+		r.count(m, m.To, true)
+	} else {
+		for _, p := range r.participants {
+			if p != r.self {
+				r.count(m, p.id, true)
+			}
+		}
+	}
+	r.log(slog.LevelDebug, "message sent", attrs...)
+}
+
+func (s *KeyShare) LogValue() slog.Value {
+	return slog.GroupValue(
+		peerAttr("id", s.ID),
+		slog.Uint64("epoch", s.Epoch),
+		slog.Int("threshold", s.Threshold),
+		slog.String("share", "REDACTED"),
+	)
+}
+
+func (s DealtShare) LogValue() slog.Value {
+	return slog.GroupValue(peerAttr("id", s.ID), slog.String("share", "REDACTED"))
+}
+
+func (SecretShares) LogValue() slog.Value {
+	return slog.StringValue("REDACTED")
+}
+
+func (ScalarPolynomial) LogValue() slog.Value {
+	return slog.StringValue("REDACTED")
+}
+
+func (n *Node) LogValue() slog.Value {
+	return slog.GroupValue(peerAttr("id", n.id))
+}
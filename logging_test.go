@@ -0,0 +1,66 @@
+package dkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLogging(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+	network := NewMemoryNetwork()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	runners := make([]*ProtocolRunner, len(nodes))
+	for i, node := range nodes {
+		transport := network.Transport(node.ID())
+		defer transport.Close()
+		set, _ := NewParticipantSet(node.curve, node.Threshold(), participants)
+		runners[i], _ = NewProtocolRunner(node, set, transport)
+		runners[i].Log(logger)
+	}
+	var wg sync.WaitGroup
+	for _, r := range runners {
+		wg.Add(1)
+		go func(r *ProtocolRunner) {
+			defer wg.Done()
+			r.Run()
+		}(r)
+	}
+	wg.Wait()
+
+	result, err := runners[0].Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("share", "share", result, "node", nodes[0], "poly", nodes[0].secretPoly1)
+	messages := make(map[string]int)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid record %q: %v", line, err)
+		}
+		messages[record["msg"].(string)]++
+		if record["msg"] != "share" && (record["node"] == nil || record["phase"] == nil) {
+			t.Errorf("Record without context: %v", line)
+		}
+	}
+	if messages["phase started"] != 5*len(nodes) || messages["ceremony finished"] != len(nodes) || messages["message sent"] == 0 || messages["message received"] == 0 {
+		t.Errorf("Got unexpected records %v", messages)
+	}
+
+	secrets := []string{result.Share.String()}
+	for _, node := range nodes {
+		for _, c := range append(node.secretPoly1, node.secretPoly2...) {
+			secrets = append(secrets, c.String())
+		}
+	}
+	for _, secret := range secrets {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("Secret %v logged", secret)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !(linux || darwin)
+
+package dkg
+
+import "errors"
+import "math/big"
+
+// lockMemory fails where the pages of the Go heap can't be locked in RAM.
+func lockMemory(xs ...*big.Int) error {
+	return MemoryLockError{errors.New("unsupported platform")}
+}
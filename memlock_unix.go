@@ -0,0 +1,25 @@
+//go:build linux || darwin
+
+package dkg
+
+import "math/big"
+import "math/bits"
+import "syscall"
+import "unsafe"
+
+// lockMemory locks the pages holding the words of secret integers in RAM,
+// so that they are never written to swap. The Go heap doesn't move, so the
+// words stay there; the pages stay locked for the life of the process.
+func lockMemory(xs ...*big.Int) error {
+	for _, x := range xs {
+		if x == nil || len(x.Bits()) == 0 {
+			continue
+		}
+		words := x.Bits()
+		b := unsafe.Slice((*byte)(unsafe.Pointer(&words[0])), cap(words)*bits.UintSize/8)
+		if err := syscall.Mlock(b); err != nil {
+			return MemoryLockError{err}
+		}
+	}
+	return nil
+}
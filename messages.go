@@ -1,11 +1,123 @@
 package dkg
 
+import "crypto/elliptic"
+import "fmt"
+import "math/big"
+
 type MessageType int
 
 const (
-	A MessageType = iota
+	VerificationPointsMessage MessageType = iota
+	SecretSharesMessage
+	ComplaintsMessage
+	JustificationMessage
+	PublicCoefficientsMessage
+	SecretKnowledgeMessage
+	HelloMessage
+	RetryMessage
+	QualifiedSetMessage
+	CoefficientComplaintsMessage
+	ReconstructionMessage
 )
 
+var messageTypeNames = []string{
+	"verification points",
+	"secret shares",
+	"complaints",
+	"justification",
+	"public coefficients",
+	"secret knowledge",
+	"hello",
+	"retry",
+	"qualified set",
+	"coefficient complaints",
+	"reconstruction",
+}
+
+func (t MessageType) String() string {
+	if t < 0 || int(t) >= len(messageTypeNames) {
+		return fmt.Sprintf("MessageType(%d)", int(t))
+	}
+	return messageTypeNames[t]
+}
+
+// Message is addressed to To, or broadcast when To is nil.
 type Message struct {
-	mType MessageType
+	Type    MessageType
+	From    *big.Int
+	To      *big.Int
+	Payload Hashable
+}
+
+// SecretShares are the evaluations of a dealer's two secret polynomials at
+// the recipient's ID, scalars of Curve.
+type SecretShares struct {
+	Share1, Share2 *big.Int
+	Curve          elliptic.Curve
+}
+
+// EncryptedShares is the payload of a SecretSharesMessage: SecretShares
+// encrypted to the recipient's identity key, so that they may travel over
+// broadcast channels.
+type EncryptedShares struct {
+	Ciphertext []byte
+}
+
+// Complaints is the payload of a ComplaintsMessage, listing the dealers
+// whose shares to the sender were missing or didn't verify, signed with the
+// sender's identity key. Every participant sends one, possibly empty.
+type Complaints struct {
+	Accused   []*big.Int
+	Signature []byte
+}
+
+type RevealedShares struct {
+	Recipient *big.Int
+	SecretShares
+}
+
+// Hello is the payload of a HelloMessage, exchanged before the ceremony to
+// measure round trip times: a participant answers a hello with an echo of
+// its nonce.
+type Hello struct {
+	Nonce uint64
+	Echo  bool
+}
+
+// Retry is the payload of a RetryMessage, asking a participant whose
+// messages of Phase didn't arrive to send them again. Attempt numbers the
+// retries of the phase.
+type Retry struct {
+	Phase   Phase
+	Attempt uint64
+}
+
+// QualifiedSet is the payload of a QualifiedSetMessage: the dealers the
+// sender qualified, sorted, signed with the sender's identity key.
+type QualifiedSet struct {
+	Qualified []*big.Int
+	Signature []byte
+}
+
+// ExposedShares are the secret shares Dealer dealt to the participant
+// revealing them.
+type ExposedShares struct {
+	Dealer *big.Int
+	SecretShares
+}
+
+// Exposure is the payload of a CoefficientComplaintsMessage, in which a
+// participant reveals the shares of the qualified dealers whose public
+// coefficients were missing or didn't match them, and of a
+// ReconstructionMessage, in which it reveals its shares of the dealers it
+// finds are to be reconstructed, once anyone contested. Every participant
+// sends one of each, possibly empty.
+type Exposure struct {
+	Exposed []ExposedShares
+}
+
+// Justification is the payload of a JustificationMessage, in which an
+// accused dealer reveals the shares it dealt to its accusers.
+type Justification struct {
+	Revealed []RevealedShares
 }
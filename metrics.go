@@ -0,0 +1,323 @@
+package dkg
+
+import "context"
+import "fmt"
+import "io"
+import "math/big"
+import "net/http"
+import "sort"
+import "strconv"
+import "strings"
+import "sync"
+import "time"
+
+// Instrumentation receives the events of ceremonies for monitoring. Its
+// methods are called from the runners' goroutines, concurrently when it is
+// shared among runners, and should return quickly.
+type Instrumentation interface {
+	// PhaseDone reports the time spent in a phase, the last one included
+	// when the ceremony aborted in it.
+	PhaseDone(phase Phase, d time.Duration)
+	MessageSent(t MessageType)
+	MessageReceived(t MessageType)
+	// ComplaintRaised reports that the node complained about a dealer.
+	ComplaintRaised(accused *big.Int)
+	// CeremonyDone reports the outcome of a ceremony, nil for success.
+	CeremonyDone(err error)
+}
+
+// Tracer starts the spans of a ceremony: one named "dkg.ceremony", and one
+// per phase within it, named after the phase, such as "dkg.dealing". It
+// follows the shape of OpenTelemetry's trace.Tracer, which a few lines
+// adapt to it.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a traced operation, ended with its error, if any.
+type Span interface {
+	End(err error)
+}
+
+// TrafficInstrumentation is an Instrumentation that also receives the
+// bytes a node exchanged with each peer, by phase. Runners report them to
+// instrumentations that implement it.
+type TrafficInstrumentation interface {
+	Instrumentation
+	// BytesSent reports n bytes sent to peer in phase; broadcasts count
+	// once for each peer.
+	BytesSent(peer *big.Int, phase Phase, n int)
+	BytesReceived(peer *big.Int, phase Phase, n int)
+}
+
+// Traffic is the bytes a node exchanged with a peer in a phase, messages
+// counted in their binary encoding.
+type Traffic struct {
+	Peer     *big.Int
+	Phase    Phase
+	Sent     uint64
+	Received uint64
+}
+
+type trafficKey struct {
+	peer  string
+	phase Phase
+}
+
+type nopInstrumentation struct{}
+
+func (nopInstrumentation) PhaseDone(Phase, time.Duration) {}
+func (nopInstrumentation) MessageSent(MessageType)        {}
+func (nopInstrumentation) MessageReceived(MessageType)    {}
+func (nopInstrumentation) ComplaintRaised(*big.Int)       {}
+func (nopInstrumentation) CeremonyDone(error)             {}
+
+// Instrument has the runner report its ceremony to i. It must be called
+// before Run.
+func (r *ProtocolRunner) Instrument(i Instrumentation) {
+	r.instrumentation = i
+}
+
+// Trace has the runner trace its ceremony with t, the ceremony span a child
+// of the span in the context passed to RunContext, if any. It must be
+// called before Run.
+func (r *ProtocolRunner) Trace(t Tracer) {
+	r.tracer = t
+}
+
+// Traffic returns the bytes the node exchanged with each peer so far, by
+// phase, in the order of the participants and phases. Messages count in
+// the phase that processes them; hellos and retry requests in the phase
+// the node was in.
+func (r *ProtocolRunner) Traffic() []Traffic {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var traffic []Traffic
+	for _, p := range r.participants {
+		for phase := PhaseIdle; phase <= PhaseAborted; phase++ {
+			if t, ok := r.traffic[trafficKey{r.key(p.id), phase}]; ok {
+				traffic = append(traffic, *t)
+			}
+		}
+	}
+	return traffic
+}
+
+// count adds the size of m, exchanged with peer, to the traffic. Messages
+// from unknown senders aren't counted, so that they can't grow it.
+func (r *ProtocolRunner) count(m Message, peer *big.Int, sent bool) {
+	p, ok := r.byID[r.key(peer)]
+	if !ok {
+		return
+	}
+	b, err := m.MarshalBinary()
+	if err != nil {
+		return
+	}
+	phase := messagePhase(r.checker.spec, m.Type)
+	r.mu.Lock()
+	if phase == PhaseAborted {
+		phase = r.checker.Phase()
+	}
+	key := trafficKey{r.key(p.id), phase}
+	t, ok := r.traffic[key]
+	if !ok {
+		t = &Traffic{Peer: p.id, Phase: phase}
+		r.traffic[key] = t
+	}
+	if sent {
+		t.Sent += uint64(len(b))
+	} else {
+		t.Received += uint64(len(b))
+	}
+	r.mu.Unlock()
+
+	if i, ok := r.instrumentation.(TrafficInstrumentation); ok {
+		if sent {
+			i.BytesSent(p.id, phase, len(b))
+		} else {
+			i.BytesReceived(p.id, phase, len(b))
+		}
+	}
+}
+
+// phaseStarted announces phase and starts timing and tracing it.
+func (r *ProtocolRunner) phaseStarted(phase Phase) {
+	r.phaseStart = time.Now()
+	r.emit(PhaseStarted{phase})
+	if r.tracer != nil && phase != PhaseFinished {
+		_, r.span = r.tracer.Start(r.ctx, "dkg."+phase.String())
+	}
+}
+
+// phaseDone reports the end of phase, which ended with err.
+func (r *ProtocolRunner) phaseDone(phase Phase, err error) {
+	if phase == PhaseIdle || phase == PhaseFinished || r.phaseStart.IsZero() {
+		return
+	}
+	r.instrumentation.PhaseDone(phase, time.Since(r.phaseStart))
+	if r.span != nil {
+		r.span.End(err)
+		r.span = nil
+	}
+}
+
+// PrometheusMetrics is an Instrumentation that serves its counts in the
+// Prometheus text exposition format. One PrometheusMetrics may instrument
+// any number of runners.
+type PrometheusMetrics struct {
+	mu         sync.Mutex
+	durations  map[Phase]time.Duration
+	phases     map[Phase]int
+	sent       map[MessageType]int
+	received   map[MessageType]int
+	complaints map[string]int
+	succeeded  int
+	failed     map[ErrorCode]int
+	bytesSent  map[trafficKey]uint64
+	bytesRecvd map[trafficKey]uint64
+}
+
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{
+		durations:  make(map[Phase]time.Duration),
+		phases:     make(map[Phase]int),
+		sent:       make(map[MessageType]int),
+		received:   make(map[MessageType]int),
+		complaints: make(map[string]int),
+		failed:     make(map[ErrorCode]int),
+		bytesSent:  make(map[trafficKey]uint64),
+		bytesRecvd: make(map[trafficKey]uint64),
+	}
+}
+
+func (m *PrometheusMetrics) PhaseDone(phase Phase, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.durations[phase] += d
+	m.phases[phase]++
+}
+
+func (m *PrometheusMetrics) MessageSent(t MessageType) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent[t]++
+}
+
+func (m *PrometheusMetrics) MessageReceived(t MessageType) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.received[t]++
+}
+
+func (m *PrometheusMetrics) BytesSent(peer *big.Int, phase Phase, n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bytesSent[trafficKey{peer.String(), phase}] += uint64(n)
+}
+
+func (m *PrometheusMetrics) BytesReceived(peer *big.Int, phase Phase, n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bytesRecvd[trafficKey{peer.String(), phase}] += uint64(n)
+}
+
+func (m *PrometheusMetrics) ComplaintRaised(accused *big.Int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.complaints[accused.String()]++
+}
+
+func (m *PrometheusMetrics) CeremonyDone(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err == nil {
+		m.succeeded++
+	} else {
+		m.failed[CodeOf(err)]++
+	}
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	var b strings.Builder
+	header := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %v %v\n# TYPE %v %v\n", name, help, name, kind)
+	}
+
+	header("dkg_phase_duration_seconds", "summary", "Time spent in the phases of ceremonies.")
+	for phase := PhaseDealing; phase < PhaseFinished; phase++ {
+		if n, ok := m.phases[phase]; ok {
+			seconds := strconv.FormatFloat(m.durations[phase].Seconds(), 'g', -1, 64)
+			fmt.Fprintf(&b, "dkg_phase_duration_seconds_sum{phase=%q} %v\n", phase.String(), seconds)
+			fmt.Fprintf(&b, "dkg_phase_duration_seconds_count{phase=%q} %v\n", phase.String(), n)
+		}
+	}
+	for _, counts := range []struct {
+		name, help string
+		counts     map[MessageType]int
+	}{
+		{"dkg_messages_sent_total", "Messages sent, broadcasts counted once.", m.sent},
+		{"dkg_messages_received_total", "Messages received.", m.received},
+	} {
+		header(counts.name, "counter", counts.help)
+		for t := range MessageType(len(messageTypeNames)) {
+			if n, ok := counts.counts[t]; ok {
+				fmt.Fprintf(&b, "%v{type=%q} %v\n", counts.name, t.String(), n)
+			}
+		}
+	}
+	for _, counts := range []struct {
+		name, help string
+		counts     map[trafficKey]uint64
+	}{
+		{"dkg_bytes_sent_total", "Bytes sent, by peer and phase.", m.bytesSent},
+		{"dkg_bytes_received_total", "Bytes received, by peer and phase.", m.bytesRecvd},
+	} {
+		header(counts.name, "counter", counts.help)
+		var keys []trafficKey
+		for key := range counts.counts {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].peer != keys[j].peer {
+				return keys[i].peer < keys[j].peer
+			}
+			return keys[i].phase < keys[j].phase
+		})
+		for _, key := range keys {
+			fmt.Fprintf(&b, "%v{peer=%q,phase=%q} %v\n", counts.name, key.peer, key.phase.String(), counts.counts[key])
+		}
+	}
+	header("dkg_complaints_total", "counter", "Complaints raised, by accused dealer.")
+	var accused []string
+	for id := range m.complaints {
+		accused = append(accused, id)
+	}
+	sort.Strings(accused)
+	for _, id := range accused {
+		fmt.Fprintf(&b, "dkg_complaints_total{accused=%q} %v\n", id, m.complaints[id])
+	}
+	header("dkg_ceremonies_succeeded_total", "counter", "Ceremonies that produced a key share.")
+	fmt.Fprintf(&b, "dkg_ceremonies_succeeded_total %v\n", m.succeeded)
+	header("dkg_ceremonies_failed_total", "counter", "Ceremonies that failed, by error code.")
+	var codes []ErrorCode
+	for code := range m.failed {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	for _, code := range codes {
+		fmt.Fprintf(&b, "dkg_ceremonies_failed_total{code=%q} %v\n", code.String(), m.failed[code])
+	}
+	m.mu.Unlock()
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// ServeHTTP serves the metrics for scraping.
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.WriteTo(w)
+}
@@ -0,0 +1,130 @@
+package dkg
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []string
+}
+
+type recordedSpan struct {
+	tracer *recordingTracer
+	name   string
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, recordedSpan{t, name}
+}
+
+func (s recordedSpan) End(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.tracer.spans = append(s.tracer.spans, s.name)
+}
+
+func TestInstrumentation(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+	network := NewMemoryNetwork()
+	metrics := NewPrometheusMetrics()
+	tracer := &recordingTracer{}
+	var runners []*ProtocolRunner
+	for i, node := range nodes {
+		transport := network.Transport(node.ID())
+		defer transport.Close()
+		set, _ := NewParticipantSet(node.curve, node.Threshold(), participants)
+		runner, err := NewProtocolRunner(node, set, transport)
+		if err != nil {
+			t.Fatal(err)
+		}
+		runner.Instrument(metrics)
+		if i == 0 {
+			runner.Trace(tracer)
+		}
+		runners = append(runners, runner)
+	}
+	var wg sync.WaitGroup
+	for _, runner := range runners {
+		wg.Add(1)
+		go func(r *ProtocolRunner) {
+			defer wg.Done()
+			if err := r.Run(); err != nil {
+				t.Errorf("Ceremony failed: %v", err)
+			}
+		}(runner)
+	}
+	wg.Wait()
+
+	// nobody complained, so there were no justification and reconstruction
+	// phases
+	expected := []string{"dkg.dealing", "dkg.complaining", "dkg.extracting", "dkg.contesting", "dkg.ceremony"}
+	if strings.Join(tracer.spans, " ") != strings.Join(expected, " ") {
+		t.Errorf("Got spans %v", tracer.spans)
+	}
+
+	w := httptest.NewRecorder()
+	metrics.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+	for _, line := range []string{
+		`dkg_phase_duration_seconds_count{phase="dealing"} 3`,
+		`dkg_phase_duration_seconds_count{phase="extracting"} 3`,
+		`dkg_messages_sent_total{type="secret shares"} 6`,
+		`dkg_messages_sent_total{type="complaints"} 3`,
+		`dkg_messages_received_total{type="secret shares"} 6`,
+		`dkg_ceremonies_succeeded_total 3`,
+		`# TYPE dkg_bytes_sent_total counter`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Metrics lack %v:\n%v", line, body)
+		}
+	}
+	if strings.Contains(body, `phase="justifying"`) || strings.Contains(body, "dkg_complaints_total{") {
+		t.Errorf("Metrics report complaints:\n%v", body)
+	}
+
+	// what a node sent a peer in a phase is what the peer received from it
+	traffic := func(r *ProtocolRunner, peer *big.Int, phase Phase) Traffic {
+		for _, c := range r.Traffic() {
+			if c.Peer.Cmp(peer) == 0 && c.Phase == phase {
+				return c
+			}
+		}
+		return Traffic{}
+	}
+	for i, r := range runners {
+		for j, peer := range runners {
+			if i == j {
+				continue
+			}
+			sent := traffic(r, nodes[j].ID(), PhaseDealing).Sent
+			received := traffic(peer, nodes[i].ID(), PhaseDealing).Received
+			if sent == 0 || sent != received {
+				t.Errorf("Node %v sent %v bytes to %v, which received %v", nodes[i].ID(), sent, nodes[j].ID(), received)
+			}
+			line := fmt.Sprintf(`dkg_bytes_sent_total{peer="%v",phase="dealing"} `, nodes[j].ID())
+			if !strings.Contains(body, line) {
+				t.Errorf("Metrics lack %v:\n%v", line, body)
+			}
+		}
+	}
+
+	metrics.CeremonyDone(TimeoutError{})
+	metrics.ComplaintRaised(nodes[1].ID())
+	var b strings.Builder
+	metrics.WriteTo(&b)
+	for _, line := range []string{
+		`dkg_ceremonies_failed_total{code="timeout"} 1`,
+		`dkg_complaints_total{accused="` + nodes[1].ID().String() + `"} 1`,
+	} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("Metrics lack %v:\n%v", line, b.String())
+		}
+	}
+}
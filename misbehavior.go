@@ -0,0 +1,165 @@
+package dkg
+
+import "math/big"
+import "time"
+
+// MisbehaviorPolicy makes a simulated node deviate from the protocol. It is
+// called with every message the node sends, to is nil for broadcasts, and
+// returns what to send instead: nothing to drop the message, altered copies
+// to corrupt it, differing copies to individual recipients to equivocate.
+type MisbehaviorPolicy func(to *big.Int, m Message) []Delivery
+
+// Delivery is a message a misbehaving node sends, to To or to everyone when
+// To is nil, after Delay.
+type Delivery struct {
+	To      *big.Int
+	Message Message
+	Delay   time.Duration
+}
+
+// CorruptShares corrupts the encrypted shares to victims, so that they
+// don't decrypt.
+func CorruptShares(victims ...*big.Int) MisbehaviorPolicy {
+	return func(to *big.Int, m Message) []Delivery {
+		if encrypted, ok := m.Payload.(EncryptedShares); ok && to != nil && containsID(victims, to) {
+			ciphertext := append([]byte(nil), encrypted.Ciphertext...)
+			ciphertext[len(ciphertext)-1] ^= 1
+			m.Payload = EncryptedShares{ciphertext}
+		}
+		return []Delivery{{to, m, 0}}
+	}
+}
+
+// InconsistentCommitments broadcasts verification points that match none
+// of the shares the node deals.
+func InconsistentCommitments() MisbehaviorPolicy {
+	return func(to *big.Int, m Message) []Delivery {
+		if m.Type == VerificationPointsMessage {
+			m.Payload = swapPoints(m.Payload)
+		}
+		return []Delivery{{to, m, 0}}
+	}
+}
+
+// InconsistentCoefficients broadcasts public coefficients that match none
+// of the shares the node dealt.
+func InconsistentCoefficients() MisbehaviorPolicy {
+	return func(to *big.Int, m Message) []Delivery {
+		if m.Type == PublicCoefficientsMessage {
+			m.Payload = swapPoints(m.Payload)
+		}
+		return []Delivery{{to, m, 0}}
+	}
+}
+
+// Equivocate sends broadcasts of type t to peers one by one, with the
+// points of point tuples swapped for the peers in liedTo.
+func Equivocate(t MessageType, peers []*big.Int, liedTo ...*big.Int) MisbehaviorPolicy {
+	return func(to *big.Int, m Message) []Delivery {
+		if m.Type != t || to != nil {
+			return []Delivery{{to, m, 0}}
+		}
+		var deliveries []Delivery
+		for _, peer := range peers {
+			sent := m
+			sent.To = peer
+			if containsID(liedTo, peer) {
+				sent.Payload = swapPoints(m.Payload)
+			}
+			deliveries = append(deliveries, Delivery{peer, sent, 0})
+		}
+		return deliveries
+	}
+}
+
+// Delay holds back messages of the given types by d.
+func Delay(d time.Duration, types ...MessageType) MisbehaviorPolicy {
+	return func(to *big.Int, m Message) []Delivery {
+		for _, t := range types {
+			if m.Type == t {
+				return []Delivery{{to, m, d}}
+			}
+		}
+		return []Delivery{{to, m, 0}}
+	}
+}
+
+// Drop withholds messages of the given types.
+func Drop(types ...MessageType) MisbehaviorPolicy {
+	return Delay(-1, types...)
+}
+
+func swapPoints(payload Hashable) Hashable {
+	pts, ok := payload.(PointTuple)
+	if !ok || len(pts) < 2 {
+		return payload
+	}
+	pts = append(PointTuple(nil), pts...)
+	pts[0], pts[1] = pts[1], pts[0]
+	return pts
+}
+
+func containsID(ids []*big.Int, id *big.Int) bool {
+	for _, x := range ids {
+		if x.Cmp(id) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// misbehavingTransport applies policies to outgoing messages.
+type misbehavingTransport struct {
+	Transport
+	policies []MisbehaviorPolicy
+}
+
+func (t misbehavingTransport) Send(to *big.Int, m Message) error {
+	return t.apply(to, m)
+}
+
+func (t misbehavingTransport) Broadcast(m Message) error {
+	return t.apply(nil, m)
+}
+
+func (t misbehavingTransport) apply(to *big.Int, m Message) error {
+	deliveries := []Delivery{{to, m, 0}}
+	for _, policy := range t.policies {
+		var next []Delivery
+		for _, d := range deliveries {
+			for _, nd := range policy(d.To, d.Message) {
+				if d.Delay < 0 || nd.Delay < 0 {
+					nd.Delay = -1
+				} else {
+					nd.Delay += d.Delay
+				}
+				next = append(next, nd)
+			}
+		}
+		deliveries = next
+	}
+
+	var firstErr error
+	for _, d := range deliveries {
+		switch {
+		case d.Delay < 0:
+		case d.Delay > 0:
+			go func(d Delivery) {
+				time.Sleep(d.Delay)
+				t.deliver(d)
+			}(d)
+		default:
+			if err := t.deliver(d); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func (t misbehavingTransport) deliver(d Delivery) error {
+	if d.To == nil {
+		return t.Transport.Broadcast(d.Message)
+	}
+	return t.Transport.Send(d.To, d.Message)
+}
@@ -0,0 +1,56 @@
+package dkg
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestMisbehaviorPolicies(t *testing.T) {
+	const size, threshold = 5, 2
+	ids := make([]*big.Int, size)
+	for i := range ids {
+		ids[i] = big.NewInt(int64(i + 1))
+	}
+	dealer := ids[0]
+
+	policies := []struct {
+		description string
+		policies    []MisbehaviorPolicy
+		qualified   bool
+	}{
+		{"corrupt share, justified", []MisbehaviorPolicy{CorruptShares(ids[1])}, true},
+		{"corrupt shares, unjustified", []MisbehaviorPolicy{CorruptShares(ids[1]), Drop(JustificationMessage)}, false},
+		{"too many corrupt shares", []MisbehaviorPolicy{CorruptShares(ids[1:]...)}, false},
+		{"inconsistent commitments", []MisbehaviorPolicy{InconsistentCommitments()}, false},
+		{"equivocation", []MisbehaviorPolicy{Equivocate(VerificationPointsMessage, ids[1:], ids[1:4]...)}, false},
+		{"missing proof", []MisbehaviorPolicy{Drop(SecretKnowledgeMessage)}, false},
+		{"delayed commitments", []MisbehaviorPolicy{Delay(50*time.Millisecond, VerificationPointsMessage)}, true},
+		{"withheld coefficients", []MisbehaviorPolicy{Drop(PublicCoefficientsMessage)}, true},
+		{"inconsistent coefficients", []MisbehaviorPolicy{InconsistentCoefficients()}, true},
+	}
+	for _, p := range policies {
+		sim, err := NewSimulator(elliptic.P256(), size, threshold, 500*time.Millisecond, []byte(p.description))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sim.Misbehave(dealer, p.policies...)
+		results, err := sim.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		honest := results[1:]
+		for i, result := range honest {
+			if result == nil {
+				t.Fatalf("%v: node %v did not finish", p.description, ids[i+1])
+			}
+			qualified := containsID(result.Qualified, dealer)
+			if qualified != p.qualified {
+				t.Errorf("%v: node %v qualified %v", p.description, ids[i+1], result.Qualified)
+			}
+		}
+		checkCeremonyResultsForTesting(t, honest)
+	}
+}
@@ -0,0 +1,131 @@
+package dkg
+
+import "bytes"
+import "crypto/sha256"
+import "math/big"
+import "strings"
+
+// Mnemonics write a key share as words of the BIP-39 English wordlist, for
+// backups on paper or steel. The words encode 11 bits each: a version byte,
+// the share's ID, prefixed with its length, the secret share and a
+// checksum of four bytes, padded with zero bits. The group key and the
+// epoch are public and not part of the mnemonic; restoring a share checks
+// it against the group's public coefficients.
+
+const mnemonicVersion = 1
+
+var mnemonicWordlist = strings.Fields(mnemonicWords)
+
+// mnemonicIndex maps each word and its first four letters to its index.
+var mnemonicIndex = func() map[string]int {
+	index := make(map[string]int, 2*len(mnemonicWordlist))
+	for i, word := range mnemonicWordlist {
+		index[word] = i
+		if len(word) > 4 {
+			index[word[:4]] = i
+		}
+	}
+	return index
+}()
+
+func mnemonicChecksum(data []byte) []byte {
+	w := NewTranscriptWriter(sha256.New())
+	w.WriteTag("dkg/mnemonic")
+	w.WriteBytes(data)
+	return w.Sum()[:4]
+}
+
+// Mnemonic returns the share's ID and secret share as words separated by
+// spaces. Anyone holding them holds the share.
+func (s *KeyShare) Mnemonic() (string, error) {
+	if err := s.usable(); err != nil {
+		return "", err
+	}
+	if s.inMemory {
+		return "", InMemoryOnlyError{"key share"}
+	}
+	id := s.ID.Bytes()
+	if len(id) > 255 {
+		return "", InvalidParticipantIDError{s.ID}
+	}
+	data := []byte{mnemonicVersion, byte(len(id))}
+	data = append(data, id...)
+	secret := scalarBytes(s.PublicKey.Curve, s.Share)
+	data = append(data, secret...)
+	clear(secret)
+	data = append(data, mnemonicChecksum(data)...)
+	defer clear(data)
+
+	n := (8*len(data) + 10) / 11
+	bits := new(big.Int).SetBytes(data)
+	bits.Lsh(bits, uint(11*n-8*len(data)))
+	defer zeroize(bits)
+	words := make([]string, n)
+	for i := n - 1; i >= 0; i-- {
+		words[i] = mnemonicWordlist[bits.Uint64()&0x7ff]
+		bits.Rsh(bits, 11)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// ParseShareMnemonic restores the key share of group that mnemonic
+// encodes. Words may be abbreviated to their first four letters. The
+// share's qualified dealers are not restored.
+func ParseShareMnemonic(group GroupKey, mnemonic string) (*KeyShare, error) {
+	curve := group.PublicKey.Curve
+	words := strings.Fields(strings.ToLower(mnemonic))
+	bits := new(big.Int)
+	defer zeroize(bits)
+	for _, word := range words {
+		i, ok := mnemonicIndex[word]
+		if !ok {
+			return nil, InvalidMnemonicError{"unknown word " + word}
+		}
+		bits.Lsh(bits, 11)
+		bits.Or(bits, big.NewInt(int64(i)))
+	}
+	if len(words) < 2 {
+		return nil, InvalidMnemonicError{"too few words"}
+	}
+
+	// the length of the ID, in the second byte, gives the length of the rest
+	idLength := int(new(big.Int).Rsh(bits, uint(11*len(words)-16)).Uint64() & 0xff)
+	length := 2 + idLength + ScalarSize(curve) + 4
+	if (8*length+10)/11 != len(words) {
+		return nil, InvalidMnemonicError{"wrong number of words"}
+	}
+	padding := 11*len(words) - 8*length
+	for i := 0; i < padding; i++ {
+		if bits.Bit(i) != 0 {
+			return nil, InvalidMnemonicError{"nonzero padding"}
+		}
+	}
+	data := new(big.Int).Rsh(bits, uint(padding)).FillBytes(make([]byte, length))
+	defer clear(data)
+	body, checksum := data[:length-4], data[length-4:]
+	if !bytes.Equal(checksum, mnemonicChecksum(body)) {
+		return nil, InvalidMnemonicError{"checksum mismatch"}
+	}
+	if body[0] != mnemonicVersion {
+		return nil, InvalidMnemonicError{"unsupported version"}
+	}
+
+	id := new(big.Int).SetBytes(body[2 : 2+idLength])
+	if id.Sign() == 0 {
+		return nil, InvalidParticipantIDError{id}
+	}
+	share := &KeyShare{
+		ID:                 id,
+		Epoch:              group.Epoch,
+		Threshold:          group.Threshold,
+		PublicKey:          group.PublicKey,
+		PublicCoefficients: group.PublicCoefficients,
+		Share:              new(big.Int).SetBytes(body[2+idLength:]),
+	}
+	if !isNormalizedScalar(share.Share, curve.Params().N) ||
+		!VerifyDealtShare(curve, DealtShare{id, share.Share}, group.PublicCoefficients) {
+		zeroize(share.Share)
+		return nil, ShareVerificationError{id, "mnemonic share"}
+	}
+	return share, nil
+}
@@ -0,0 +1,80 @@
+package dkg
+
+import (
+	"errors"
+	"hash/crc32"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMnemonicWordlist(t *testing.T) {
+	// the checksum of BIP-39's english.txt
+	list := strings.Join(mnemonicWordlist, "\n") + "\n"
+	if len(mnemonicWordlist) != 2048 || crc32.ChecksumIEEE([]byte(list)) != 0xc1dbd296 {
+		t.Errorf("Wordlist differs from BIP-39's")
+	}
+	keys := 0
+	for _, word := range mnemonicWordlist {
+		if keys++; len(word) > 4 {
+			keys++
+		}
+	}
+	if len(mnemonicIndex) != keys {
+		t.Errorf("Words are not unique in their first four letters")
+	}
+}
+
+func TestMnemonic(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+	results := runCeremonyForTesting(t, nodes, participants)
+	share := results[1]
+	mnemonic, err := share.Mnemonic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := strings.Fields(mnemonic)
+	if len(words) != 29 {
+		t.Errorf("Got %v words", len(words))
+	}
+
+	restored, err := ParseShareMnemonic(share.Group(), mnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := *share
+	expected.Qualified = nil
+	if !reflect.DeepEqual(restored, &expected) {
+		t.Errorf("Restored %+v, expected %+v", restored, expected)
+	}
+
+	abbreviated := make([]string, len(words))
+	for i, word := range words {
+		abbreviated[i] = strings.ToUpper(word[:min(len(word), 4)])
+	}
+	if restored, err := ParseShareMnemonic(share.Group(), strings.Join(abbreviated, "\n")); err != nil || restored.Share.Cmp(share.Share) != 0 {
+		t.Errorf("Abbreviated mnemonic restored %v (%v)", restored, err)
+	}
+
+	altered := append([]string(nil), words...)
+	altered[5] = mnemonicWordlist[(mnemonicIndex[words[5]]+1)%len(mnemonicWordlist)]
+	for _, test := range []struct {
+		name     string
+		mnemonic string
+	}{
+		{"Unknown word", strings.Replace(mnemonic, words[3], "dkg", 1)},
+		{"Altered word", strings.Join(altered, " ")},
+		{"Missing word", strings.Join(words[:len(words)-1], " ")},
+		{"Empty", ""},
+	} {
+		if _, err := ParseShareMnemonic(share.Group(), test.mnemonic); !errors.As(err, new(InvalidMnemonicError)) {
+			t.Errorf("%v: got unexpected error %v", test.name, err)
+		}
+	}
+
+	nodes, participants = getCeremonyNodesForTesting(t, 3, 1)
+	other := runCeremonyForTesting(t, nodes, participants)[1]
+	if _, err := ParseShareMnemonic(other.Group(), mnemonic); !errors.As(err, new(ShareVerificationError)) {
+		t.Errorf("Share of another group restored: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package dkg
+
+import "context"
+import "strconv"
+import "sync"
+
+// MultiCeremony runs one ceremony per node, typically each over a different
+// curve, among the same participants and over one shared transport.
+type MultiCeremony struct {
+	mux     *TransportMux
+	runners []*ProtocolRunner
+}
+
+// NewMultiCeremony prepares the ceremonies of nodes, which all stand for
+// the same participant.
+func NewMultiCeremony(nodes []*Node, participants []Participant, transport Transport) (*MultiCeremony, error) {
+	mux := NewTransportMux(transport)
+	c := &MultiCeremony{mux: mux}
+	for i, node := range nodes {
+		session := strconv.Itoa(i) + "/" + node.curve.Params().Name
+		runner, err := c.newRunner(node, participants, mux.Session(session))
+		if err != nil {
+			mux.closeSessions()
+			return nil, err
+		}
+		c.runners = append(c.runners, runner)
+	}
+	return c, nil
+}
+
+func (c *MultiCeremony) newRunner(node *Node, participants []Participant, transport Transport) (*ProtocolRunner, error) {
+	set, err := NewParticipantSet(node.curve, node.Threshold(), participants)
+	if err != nil {
+		return nil, err
+	}
+	return NewProtocolRunner(node, set, transport)
+}
+
+// Runners returns the runners of the ceremonies, in the order of the nodes.
+func (c *MultiCeremony) Runners() []*ProtocolRunner {
+	return c.runners
+}
+
+// Run executes all ceremonies concurrently and returns the first error.
+func (c *MultiCeremony) Run() error {
+	return c.RunContext(context.Background())
+}
+
+// RunContext runs the ceremonies like Run, aborting all of them once ctx is
+// done.
+func (c *MultiCeremony) RunContext(ctx context.Context) error {
+	errs := make([]error, len(c.runners))
+	var wg sync.WaitGroup
+	for i, runner := range c.runners {
+		wg.Add(1)
+		go func(i int, r *ProtocolRunner) {
+			defer wg.Done()
+			errs[i] = r.RunContext(ctx)
+		}(i, runner)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Results returns the key shares of all ceremonies, in the order of the
+// nodes, or the first error.
+func (c *MultiCeremony) Results() ([]*KeyShare, error) {
+	results := make([]*KeyShare, len(c.runners))
+	for i, runner := range c.runners {
+		result, err := runner.Result()
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Close closes the shared transport.
+func (c *MultiCeremony) Close() error {
+	return c.mux.Close()
+}
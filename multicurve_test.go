@@ -0,0 +1,88 @@
+package dkg
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha512"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mikalv/dkg/secp256k1"
+)
+
+func TestMultiCeremony(t *testing.T) {
+	const size, threshold = 4, 1
+	curves := []elliptic.Curve{elliptic.P256(), secp256k1.S256()}
+	_, _, _, _, zkParam, _, _, _, _, _ := getValidNodeParamsForTesting(t)
+
+	keys := make([]*ecdsa.PrivateKey, size)
+	participants := make([]Participant, size)
+	for i := range keys {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = key
+		participants[i] = Participant{big.NewInt(int64(i + 1)), key.PublicKey}
+	}
+
+	g2s := make([][2]*big.Int, len(curves))
+	for j, curve := range curves {
+		k, _ := randomScalar(curve.Params().N, rand.Reader)
+		g2s[j][0], g2s[j][1] = curve.ScalarBaseMult(k.Bytes())
+	}
+
+	network := NewMemoryNetwork()
+	ceremonies := make([]*MultiCeremony, size)
+	for i, p := range participants {
+		nodes := make([]*Node, len(curves))
+		for j, curve := range curves {
+			node, err := NewNode(
+				curve, sha512.New512_256(), g2s[j][0], g2s[j][1], zkParam, 5*time.Second,
+				p.ID, *keys[i],
+				randomPolynomialForTesting(t, curve, threshold),
+				randomPolynomialForTesting(t, curve, threshold),
+			)
+			if err != nil {
+				t.Fatalf("Could not create node %v on %v: %v", p.ID, curve.Params().Name, err)
+			}
+			nodes[j] = node
+		}
+		c, err := NewMultiCeremony(nodes, participants, network.Transport(p.ID))
+		if err != nil {
+			t.Fatalf("Could not create ceremonies for %v: %v", p.ID, err)
+		}
+		defer c.Close()
+		ceremonies[i] = c
+	}
+
+	var wg sync.WaitGroup
+	for _, c := range ceremonies {
+		wg.Add(1)
+		go func(c *MultiCeremony) {
+			defer wg.Done()
+			if err := c.Run(); err != nil {
+				t.Errorf("Ceremonies failed: %v", err)
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	for j, curve := range curves {
+		results := make([]*KeyShare, size)
+		for i, c := range ceremonies {
+			shares, err := c.Results()
+			if err != nil {
+				t.Fatalf("No results for %v: %v", participants[i].ID, err)
+			}
+			results[i] = shares[j]
+			if shares[j].PublicKey.Curve != curve {
+				t.Errorf("Result %v of %v is over %v", j, participants[i].ID, shares[j].PublicKey.Curve.Params().Name)
+			}
+		}
+		checkCeremonyResultsForTesting(t, results)
+	}
+}
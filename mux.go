@@ -0,0 +1,119 @@
+package dkg
+
+import "encoding/gob"
+import "math/big"
+import "sync"
+
+func init() {
+	gob.RegisterName("dkg.sessionPayload", sessionPayload{})
+}
+
+// sessionPayload tags a message's payload with the session it belongs to.
+type sessionPayload struct {
+	Session string
+	Payload Hashable
+}
+
+func (s sessionPayload) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/session")
+	w.WriteBytes([]byte(s.Session))
+	if s.Payload == nil {
+		w.WriteTag("")
+		return
+	}
+	w.Write(s.Payload)
+}
+
+// TransportMux runs several named sessions, such as concurrent ceremonies,
+// over one transport.
+type TransportMux struct {
+	transport Transport
+
+	mu       sync.Mutex
+	sessions map[string]*mailbox
+	closed   bool
+}
+
+func NewTransportMux(transport Transport) *TransportMux {
+	mux := &TransportMux{transport: transport, sessions: make(map[string]*mailbox)}
+	go mux.run()
+	return mux
+}
+
+// Session returns the transport of the named session. Messages received for
+// a session before it is opened are kept for it.
+func (mux *TransportMux) Session(name string) Transport {
+	return &sessionTransport{mux, name, mux.mailbox(name)}
+}
+
+// Close closes the underlying transport and all sessions.
+func (mux *TransportMux) Close() error {
+	err := mux.transport.Close()
+	mux.closeSessions()
+	return err
+}
+
+func (mux *TransportMux) mailbox(name string) *mailbox {
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+	mb, ok := mux.sessions[name]
+	if !ok {
+		mb = newMailbox()
+		if mux.closed {
+			mb.close()
+		}
+		mux.sessions[name] = mb
+	}
+	return mb
+}
+
+func (mux *TransportMux) closeSessions() {
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+	mux.closed = true
+	for _, mb := range mux.sessions {
+		mb.close()
+	}
+}
+
+func (mux *TransportMux) run() {
+	defer mux.closeSessions()
+	for m := range mux.transport.Receive() {
+		// untagged messages belong to no session
+		payload, ok := m.Payload.(sessionPayload)
+		if !ok {
+			continue
+		}
+		m.Payload = payload.Payload
+		mux.mailbox(payload.Session).put(m)
+	}
+}
+
+type sessionTransport struct {
+	mux   *TransportMux
+	name  string
+	inbox *mailbox
+}
+
+func (t *sessionTransport) tag(m Message) Message {
+	m.Payload = sessionPayload{t.name, m.Payload}
+	return m
+}
+
+func (t *sessionTransport) Send(to *big.Int, m Message) error {
+	return t.mux.transport.Send(to, t.tag(m))
+}
+
+func (t *sessionTransport) Broadcast(m Message) error {
+	return t.mux.transport.Broadcast(t.tag(m))
+}
+
+func (t *sessionTransport) Receive() <-chan Message {
+	return t.inbox.out
+}
+
+// Close stops the session's deliveries, the underlying transport stays open.
+func (t *sessionTransport) Close() error {
+	t.inbox.close()
+	return nil
+}
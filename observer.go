@@ -0,0 +1,363 @@
+package dkg
+
+import "context"
+import "crypto/ecdsa"
+import "crypto/elliptic"
+import "hash"
+import "math/big"
+import "sort"
+
+// Observer follows a ceremony's broadcast traffic without taking part in
+// it, for auditors witnessing a key ceremony. It verifies the messages as
+// they arrive, determines the qualified dealers and the group key like the
+// participants do, and attests to the outcome. It holds no shares, and
+// can't tell whether dealers' encrypted shares were valid; that is what
+// complaints are for.
+//
+// The participants' transport must deliver broadcasts to the observer, for
+// instance by registering it on the network under an ID of its own.
+type Observer struct {
+	params       ceremonyParams
+	participants []*observed
+	byID         map[string]*observed
+}
+
+type observed struct {
+	id  *big.Int
+	key ecdsa.PublicKey
+
+	received           map[MessageType]Message
+	verificationPoints PointTuple
+	knowledgeProof     *SecretKnowledgeProof
+	complaints         *Complaints
+	justification      *Justification
+	publicCoefficients PointTuple
+	contest            *Exposure
+	reconstruction     *Exposure
+}
+
+// Attestation is an observer's signed statement of a ceremony's outcome.
+// Transcript is the digest of the broadcasts the observer based it on.
+type Attestation struct {
+	PublicKey  ecdsa.PublicKey
+	Qualified  []*big.Int
+	Transcript []byte
+	Signature  []byte
+}
+
+type attestationStatement Attestation
+
+func (a attestationStatement) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/attestation")
+	w.WriteTag(a.PublicKey.Curve.Params().Name)
+	w.WriteInt(a.PublicKey.X)
+	w.WriteInt(a.PublicKey.Y)
+	w.WriteUint(uint64(len(a.Qualified)))
+	for _, id := range a.Qualified {
+		w.WriteInt(id)
+	}
+	w.WriteBytes(a.Transcript)
+}
+
+// NewObserver prepares observing the ceremony among participants with the
+// given public parameters and node options, which must match the
+// participants' nodes. Like NewNodeWithOptions, it derives a nil second generator.
+func NewObserver(curve elliptic.Curve, hash hash.Hash, g2x, g2y, zkParam *big.Int, participants *ParticipantSet, opts ...NodeOption) (*Observer, error) {
+	if participants.curve != curve {
+		return nil, CurveMismatchError{curve, participants.curve}
+	}
+	config := applyNodeOptions(NodeConfig{Hash: hash}, opts)
+	hash = config.Hash
+	if config.Feldman {
+		g2x, g2y = nil, nil
+	} else {
+		if g2x == nil && g2y == nil {
+			var err error
+			if g2x, g2y, err = DeriveSecondGenerator(curve, []byte(DefaultGeneratorDomain)); err != nil {
+				return nil, err
+			}
+		}
+		if !isValidPoint(curve, g2x, g2y) {
+			return nil, InvalidCurvePointError{curve, g2x, g2y}
+		}
+	}
+	o := &Observer{
+		params: ceremonyParams{curve, hash, g2x, g2y, zkParam, participants.threshold, nil},
+		byID:   make(map[string]*observed),
+	}
+	for _, p := range participants.participants {
+		state := &observed{id: p.ID, key: p.Key, received: make(map[MessageType]Message)}
+		o.participants = append(o.participants, state)
+		o.byID[o.key(p.ID)] = state
+	}
+	return o, nil
+}
+
+func (o *Observer) key(id *big.Int) string {
+	return new(big.Int).Mod(id, o.params.curve.Params().N).String()
+}
+
+// Observe records a broadcast message. Like participants, the observer only
+// counts the first message of each type per sender, and ignores invalid
+// ones.
+func (o *Observer) Observe(m Message) {
+	if m.From == nil || m.To != nil || m.Type == HelloMessage {
+		return
+	}
+	p, ok := o.byID[o.key(m.From)]
+	if !ok {
+		return
+	}
+	if _, ok := p.received[m.Type]; ok {
+		return
+	}
+	p.received[m.Type] = m
+
+	switch m.Type {
+	case VerificationPointsMessage:
+		if vpts, ok := m.Payload.(PointTuple); ok && o.validPoints(vpts) {
+			p.verificationPoints = vpts
+		}
+	case SecretKnowledgeMessage:
+		if proof, ok := m.Payload.(SecretKnowledgeProof); ok {
+			p.knowledgeProof = &proof
+		}
+	case ComplaintsMessage:
+		complaints, ok := m.Payload.(Complaints)
+		if ok && VerifyComplaints(o.params.hash, Participant{p.id, p.key}, complaints) {
+			p.complaints = &complaints
+		}
+	case JustificationMessage:
+		if justification, ok := m.Payload.(Justification); ok {
+			p.justification = &justification
+		}
+	case PublicCoefficientsMessage:
+		if pts, ok := m.Payload.(PointTuple); ok && o.validPoints(pts) {
+			p.publicCoefficients = pts
+		}
+	case CoefficientComplaintsMessage:
+		if contest, ok := m.Payload.(Exposure); ok {
+			p.contest = &contest
+		}
+	case ReconstructionMessage:
+		if reconstruction, ok := m.Payload.(Exposure); ok {
+			p.reconstruction = &reconstruction
+		}
+	}
+}
+
+func (o *Observer) validPoints(pts PointTuple) bool {
+	return len(pts) == o.params.threshold+1 && validCommitments(o.params.curve, pts)
+}
+
+// Watch observes the messages arriving on transport until every
+// participant complained and contested, and, if any contested, revealed
+// the shares to reconstruct coefficients with, or ctx is done.
+func (o *Observer) Watch(ctx context.Context, transport Transport) error {
+	for !o.complete() {
+		select {
+		case m, ok := <-transport.Receive():
+			if !ok {
+				return nil
+			}
+			o.Observe(m)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (o *Observer) complete() bool {
+	for _, p := range o.participants {
+		if _, ok := p.received[ComplaintsMessage]; !ok {
+			return false
+		}
+	}
+	for _, p := range o.participants {
+		if _, ok := p.received[CoefficientComplaintsMessage]; !ok {
+			return false
+		}
+	}
+	contested := false
+	for _, p := range o.participants {
+		contested = contested || p.contest != nil && len(p.contest.Exposed) > 0
+	}
+	if !contested {
+		return true
+	}
+	exposed := o.exposed(o.qualified())
+	for _, p := range o.participants {
+		if _, ok := p.received[ReconstructionMessage]; !ok && !exposed[p] {
+			return false
+		}
+	}
+	return true
+}
+
+// exposed returns the qualified dealers whose public coefficients the
+// participants reconstruct: those missing them, and those contested with
+// shares that verify against their verification points but don't match
+// their coefficients.
+func (o *Observer) exposed(qualified []*observed) map[*observed]bool {
+	exposed := make(map[*observed]bool)
+	for _, p := range qualified {
+		exposed[p] = p.publicCoefficients == nil
+	}
+	for _, accuser := range o.participants {
+		if accuser.contest == nil {
+			continue
+		}
+		for _, es := range accuser.contest.Exposed {
+			dealer, ok := o.byID[o.key(es.Dealer)]
+			if !ok || dealer == accuser || exposed[dealer] {
+				continue
+			}
+			if _, qualified := exposed[dealer]; qualified {
+				exposed[dealer] = o.params.contests(dealer.id, dealer.verificationPoints, dealer.publicCoefficients, accuser.id, es.SecretShares)
+			}
+		}
+	}
+	for p, ok := range exposed {
+		if !ok {
+			delete(exposed, p)
+		}
+	}
+	return exposed
+}
+
+// reconstructed returns dealer's public coefficients interpolated from the
+// shares the participants revealed.
+func (o *Observer) reconstructed(dealer *observed) (PointTuple, error) {
+	var revealed []RevealedShares
+	for _, p := range o.participants {
+		for _, exposure := range []*Exposure{p.contest, p.reconstruction} {
+			if exposure == nil {
+				continue
+			}
+			for _, es := range exposure.Exposed {
+				if o.key(es.Dealer) == o.key(dealer.id) {
+					revealed = append(revealed, RevealedShares{p.id, es.SecretShares})
+				}
+			}
+		}
+	}
+	return o.params.reconstructCoefficients(dealer.id, dealer.verificationPoints, revealed)
+}
+
+// qualified returns the dealers the participants qualify, by the rules of
+// ProtocolRunner.
+func (o *Observer) qualified() []*observed {
+	accusations := make(map[string][]*observed)
+	for _, accuser := range o.participants {
+		if accuser.complaints == nil {
+			continue
+		}
+		for _, id := range accuser.complaints.Accused {
+			if dealer, ok := o.byID[o.key(id)]; ok && dealer != accuser {
+				accusations[o.key(id)] = append(accusations[o.key(id)], accuser)
+			}
+		}
+	}
+
+	var qualified []*observed
+	for _, p := range o.participants {
+		if p.verificationPoints == nil || p.knowledgeProof == nil ||
+			!o.params.verifySecretKnowledge(p.id, p.verificationPoints, *p.knowledgeProof) {
+			continue
+		}
+		if accusers, ok := accusations[o.key(p.id)]; ok && !o.justified(p, accusers) {
+			continue
+		}
+		qualified = append(qualified, p)
+	}
+	return qualified
+}
+
+func (o *Observer) justified(dealer *observed, accusers []*observed) bool {
+	if len(accusers) > o.params.threshold || dealer.justification == nil {
+		return false
+	}
+	revealed := make(map[string]SecretShares)
+	for _, rs := range dealer.justification.Revealed {
+		revealed[o.key(rs.Recipient)] = rs.SecretShares
+	}
+	for _, accuser := range accusers {
+		shares, ok := revealed[o.key(accuser.id)]
+		if !ok || o.params.verifyShareFor(dealer.id, accuser.id, shares, dealer.verificationPoints) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// transcript returns the digest of the counted broadcasts, in participant
+// and message type order.
+func (o *Observer) transcript() []byte {
+	w := NewTranscriptWriter(o.params.hash)
+	w.WriteTag("dkg/observed-transcript")
+	for _, p := range o.participants {
+		types := make([]int, 0, len(p.received))
+		for t := range p.received {
+			types = append(types, int(t))
+		}
+		sort.Ints(types)
+		w.WriteUint(uint64(len(types)))
+		for _, t := range types {
+			w.Write(p.received[MessageType(t)])
+		}
+	}
+	return w.Sum()
+}
+
+// Attest returns the observed outcome of the ceremony, signed with key.
+func (o *Observer) Attest(key *ecdsa.PrivateKey) (Attestation, error) {
+	a, err := o.outcome()
+	if err != nil {
+		return Attestation{}, err
+	}
+	sig, err := signDeterministic(key, HashOf(o.params.hash, attestationStatement(a)))
+	if err != nil {
+		return Attestation{}, err
+	}
+	a.Signature = sig
+	return a, nil
+}
+
+// outcome returns the observed outcome of the ceremony, unsigned.
+func (o *Observer) outcome() (Attestation, error) {
+	curve := o.params.curve
+	qualified := o.qualified()
+	if len(qualified) == 0 {
+		return Attestation{}, NoQualifiedDealersError{}
+	}
+	exposed := o.exposed(qualified)
+	a := Attestation{PublicKey: ecdsa.PublicKey{Curve: curve}}
+	for i, p := range qualified {
+		coefficients := p.publicCoefficients
+		if exposed[p] {
+			var err error
+			if coefficients, err = o.reconstructed(p); err != nil {
+				return Attestation{}, err
+			}
+		}
+		c := coefficients[0]
+		if i == 0 {
+			a.PublicKey.X, a.PublicKey.Y = c.X, c.Y
+		} else {
+			a.PublicKey.X, a.PublicKey.Y = curve.Add(a.PublicKey.X, a.PublicKey.Y, c.X, c.Y)
+		}
+		a.Qualified = append(a.Qualified, p.id)
+	}
+	sort.Slice(a.Qualified, func(i, j int) bool { return a.Qualified[i].Cmp(a.Qualified[j]) < 0 })
+	a.Transcript = o.transcript()
+	return a, nil
+}
+
+// VerifyAttestation checks that a was signed by the observer's key.
+func VerifyAttestation(h hash.Hash, observer ecdsa.PublicKey, a Attestation) bool {
+	if a.PublicKey.Curve == nil || observer.Curve == nil {
+		return false
+	}
+	return ecdsa.VerifyASN1(&observer, HashOf(h, attestationStatement(a)), a.Signature)
+}
@@ -0,0 +1,83 @@
+package dkg
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha512"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// copyingTransport also broadcasts through a second transport.
+type copyingTransport struct {
+	Transport
+	copy Transport
+}
+
+func (t copyingTransport) Broadcast(m Message) error {
+	t.copy.Broadcast(m)
+	return t.Transport.Broadcast(m)
+}
+
+func TestObserver(t *testing.T) {
+	curve, _, g2x, g2y, zkParam, _, _, _, _, _ := getValidNodeParamsForTesting(t)
+	nodes, participants := getCeremonyNodesForTesting(t, 5, 2)
+	set, _ := NewParticipantSet(curve, 2, participants)
+	observer, err := NewObserver(curve, sha512.New512_256(), g2x, g2y, zkParam, set)
+	if err != nil {
+		t.Fatalf("Could not create observer: %v", err)
+	}
+
+	observed := NewMemoryNetwork()
+	watching := observed.Transport(big.NewInt(100))
+	defer watching.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	watched := make(chan error)
+	go func() { watched <- observer.Watch(ctx, watching) }()
+
+	dealer, victim := nodes[0].ID(), nodes[1].ID()
+	results := runCeremonyForTesting(t, nodes, participants,
+		func(n *Node, t Transport) Transport {
+			return copyingTransport{t, observed.Transport(n.ID())}
+		},
+		tamperWith(dealer, SecretSharesMessage, corruptShareTo(victim)),
+		tamperWith(dealer, JustificationMessage, func(_ *big.Int, payload Hashable) Hashable {
+			j := payload.(Justification)
+			j.Revealed = append([]RevealedShares(nil), j.Revealed...)
+			for i := range j.Revealed {
+				j.Revealed[i].Share2 = new(big.Int).Add(j.Revealed[i].Share2, one)
+			}
+			return j
+		}),
+		tamperWith(nodes[2].ID(), PublicCoefficientsMessage, func(_ *big.Int, payload Hashable) Hashable {
+			return swapPoints(payload)
+		}))
+	if err := <-watched; err != nil {
+		t.Fatalf("Observer didn't see the ceremony complete: %v", err)
+	}
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	attestation, err := observer.Attest(key)
+	if err != nil {
+		t.Fatalf("Could not attest: %v", err)
+	}
+	result := results[1]
+	if !reflect.DeepEqual(attestation.Qualified, result.Qualified) {
+		t.Errorf("Observer qualified %v, participants %v", attestation.Qualified, result.Qualified)
+	}
+	if attestation.PublicKey.X.Cmp(result.PublicKey.X) != 0 || attestation.PublicKey.Y.Cmp(result.PublicKey.Y) != 0 {
+		t.Errorf("Observer and participants disagree on the group key")
+	}
+	if !VerifyAttestation(sha512.New512_256(), key.PublicKey, attestation) {
+		t.Errorf("Attestation doesn't verify")
+	}
+	attestation.Qualified = attestation.Qualified[1:]
+	if VerifyAttestation(sha512.New512_256(), key.PublicKey, attestation) {
+		t.Errorf("Altered attestation verifies")
+	}
+}
@@ -0,0 +1,164 @@
+package dkg
+
+import "crypto/ecdsa"
+import "crypto/elliptic"
+import "hash"
+import "io"
+import "log/slog"
+import "math/big"
+import "time"
+
+// The With options set the fields of a NodeConfig one by one, so that
+// NewNodeWithOptions can build a node from options alone and callers only
+// name the parameters they set.
+
+func WithCurve(curve elliptic.Curve) NodeOption {
+	return func(c *NodeConfig) {
+		c.Curve = curve
+	}
+}
+
+func WithHash(hash hash.Hash) NodeOption {
+	return func(c *NodeConfig) {
+		c.Hash = hash
+	}
+}
+
+// WithGenerator2 sets the second generator of Pedersen VSS, by default
+// derived from DefaultGeneratorDomain.
+func WithGenerator2(x, y *big.Int) NodeOption {
+	return func(c *NodeConfig) {
+		c.G2X, c.G2Y = x, y
+	}
+}
+
+func WithZKParam(zkParam *big.Int) NodeOption {
+	return func(c *NodeConfig) {
+		c.ZKParam = zkParam
+	}
+}
+
+func WithTimeout(timeout time.Duration) NodeOption {
+	return func(c *NodeConfig) {
+		c.Timeout = timeout
+	}
+}
+
+func WithID(id *big.Int) NodeOption {
+	return func(c *NodeConfig) {
+		c.ID = id
+	}
+}
+
+// WithKey sets the node's identity key.
+func WithKey(key ecdsa.PrivateKey) NodeOption {
+	return func(c *NodeConfig) {
+		c.Key = key
+	}
+}
+
+// WithIdentity has the node sign and decrypt with identity, such as a key
+// in an HSM, instead of a key of its own. Such nodes can't be saved with
+// SaveNode.
+func WithIdentity(identity Identity) NodeOption {
+	return func(c *NodeConfig) {
+		c.Identity = identity
+	}
+}
+
+// WithThreshold has the node sample secret polynomials of degree
+// threshold.
+func WithThreshold(threshold int) NodeOption {
+	return func(c *NodeConfig) {
+		c.Threshold = threshold
+	}
+}
+
+// WithPolynomials sets the node's secret polynomials; poly2 is empty with
+// Feldman VSS.
+func WithPolynomials(poly1, poly2 ScalarPolynomial) NodeOption {
+	return func(c *NodeConfig) {
+		c.SecretPoly1, c.SecretPoly2 = poly1, poly2
+	}
+}
+
+// WithFeldmanVSS has the node deal with Feldman VSS, committing to its
+// single polynomial SecretPoly1 directly, rather than Pedersen VSS: a
+// Joint-Feldman DKG, cheaper and without the second generator, which is
+// ignored, and the second polynomial, which must be empty. Its verification
+// points reveal the dealers' public keys while dealing, which lets a
+// participant that deals last bias the group key. All participants must use
+// the same mode.
+func WithFeldmanVSS() NodeOption {
+	return func(c *NodeConfig) {
+		c.Feldman = true
+	}
+}
+
+// WithPrecomputedTables has the node precompute tables of multiples of the
+// generators, which speed up its scalar multiplications with them several
+// times on curves whose arithmetic uses math/big, such as those of the
+// secp256k1 and edwards25519 packages, for ceremonies with large
+// thresholds. The curves of crypto/elliptic are faster without.
+func WithPrecomputedTables() NodeOption {
+	return func(c *NodeConfig) {
+		c.Precompute = true
+	}
+}
+
+// WithHashDomain has the node hash its transcripts with
+// DomainSeparatedHash under domain, such as the name of the application
+// and ceremony, so that its proofs and complaints are worthless elsewhere.
+// It applies to the hash of WithHash wherever either option comes. All
+// participants and observers must use the same domain, and LoadNode must be
+// passed the separated hash.
+func WithHashDomain(domain string) NodeOption {
+	return func(c *NodeConfig) {
+		c.Domain = domain
+	}
+}
+
+// WithEntropy has the node draw its secret polynomials, nonces and
+// signature randomness from random, such as a hardware entropy source, or a
+// seeded stream for reproducible tests, instead of crypto/rand. Its
+// protocol runners default to it too.
+func WithEntropy(random io.Reader) NodeOption {
+	return func(c *NodeConfig) {
+		c.Random = random
+	}
+}
+
+// WithInMemoryOnly guarantees that the node's secrets never reach disk, for
+// one-shot ceremonies such as setting up the keys of other systems: its
+// secret polynomials, identity key and key shares are locked in RAM, so
+// they are never swapped out, and neither the node nor its key shares can
+// be saved, exported or written down as mnemonics, nor its ceremonies
+// snapshotted to resume them. Creating the node fails where the secrets
+// can't be locked, such as on platforms other than Linux and macOS or
+// beyond RLIMIT_MEMLOCK.
+func WithInMemoryOnly() NodeOption {
+	return func(c *NodeConfig) {
+		c.InMemoryOnly = true
+	}
+}
+
+// WithLogger has the node's protocol runners log to logger.
+func WithLogger(logger *slog.Logger) NodeOption {
+	return func(c *NodeConfig) {
+		c.Logger = logger
+	}
+}
+
+// WithConfig sets every parameter from config, replacing what earlier
+// options set, so it goes first.
+func WithConfig(config NodeConfig) NodeOption {
+	return func(c *NodeConfig) {
+		*c = config
+	}
+}
+
+// NewNodeWithOptions returns the node configured by opts, applied in order
+// to an empty NodeConfig.
+func NewNodeWithOptions(opts ...NodeOption) (*Node, error) {
+	return newNode(applyNodeOptions(NodeConfig{}, opts))
+}
@@ -0,0 +1,75 @@
+package dkg
+
+import "sync/atomic"
+
+type OverflowPolicy int
+
+const (
+	// BlockOnOverflow makes Push wait until the consumer catches up.
+	BlockOnOverflow OverflowPolicy = iota
+	// DropOldestOnOverflow discards the oldest queued message to make room,
+	// reporting it to the outbox's drop handler.
+	DropOldestOnOverflow
+)
+
+const defaultOutboxCapacity = 256
+
+// Outbox is a bounded queue of outgoing messages between the protocol and
+// a transport, so that a slow transport applies backpressure instead of
+// letting the queue grow without limit.
+type Outbox struct {
+	queue   chan Message
+	policy  OverflowPolicy
+	onDrop  func(Message)
+	dropped uint64
+}
+
+func NewOutbox(capacity int, policy OverflowPolicy, onDrop func(Message)) *Outbox {
+	if capacity <= 0 {
+		capacity = defaultOutboxCapacity
+	}
+	return &Outbox{make(chan Message, capacity), policy, onDrop, 0}
+}
+
+func (o *Outbox) Push(m Message) {
+	if o.policy != DropOldestOnOverflow {
+		o.queue <- m
+		return
+	}
+	for {
+		select {
+		case o.queue <- m:
+			return
+		default:
+		}
+		select {
+		case old := <-o.queue:
+			atomic.AddUint64(&o.dropped, 1)
+			if o.onDrop != nil {
+				o.onDrop(old)
+			}
+		default:
+		}
+	}
+}
+
+// Messages is drained by the transport side of the outbox.
+func (o *Outbox) Messages() <-chan Message {
+	return o.queue
+}
+
+func (o *Outbox) Close() {
+	close(o.queue)
+}
+
+func (o *Outbox) Depth() int {
+	return len(o.queue)
+}
+
+func (o *Outbox) Capacity() int {
+	return cap(o.queue)
+}
+
+func (o *Outbox) Dropped() uint64 {
+	return atomic.LoadUint64(&o.dropped)
+}
@@ -0,0 +1,47 @@
+package dkg
+
+import "testing"
+
+func TestOutbox(t *testing.T) {
+	t.Run("Block", func(t *testing.T) {
+		o := NewOutbox(2, BlockOnOverflow, nil)
+		o.Push(Message{Type: VerificationPointsMessage})
+		o.Push(Message{Type: SecretSharesMessage})
+		if o.Depth() != 2 || o.Capacity() != 2 {
+			t.Errorf("Got depth %v, capacity %v", o.Depth(), o.Capacity())
+		}
+
+		pushed := make(chan bool)
+		go func() {
+			o.Push(Message{Type: ComplaintsMessage})
+			close(pushed)
+		}()
+		if m := <-o.Messages(); m.Type != VerificationPointsMessage {
+			t.Errorf("Got unexpected first message %v", m.Type)
+		}
+		<-pushed
+		if o.Dropped() != 0 || o.Depth() != 2 {
+			t.Errorf("Blocking outbox dropped %v messages, depth %v", o.Dropped(), o.Depth())
+		}
+	})
+
+	t.Run("Drop oldest", func(t *testing.T) {
+		var dropped []Message
+		o := NewOutbox(2, DropOldestOnOverflow, func(m Message) { dropped = append(dropped, m) })
+		o.Push(Message{Type: VerificationPointsMessage})
+		o.Push(Message{Type: SecretSharesMessage})
+		o.Push(Message{Type: ComplaintsMessage})
+
+		if o.Dropped() != 1 || len(dropped) != 1 || dropped[0].Type != VerificationPointsMessage {
+			t.Errorf("Expected oldest message to be dropped, got %v", dropped)
+		}
+		o.Close()
+		var got []MessageType
+		for m := range o.Messages() {
+			got = append(got, m.Type)
+		}
+		if len(got) != 2 || got[0] != SecretSharesMessage || got[1] != ComplaintsMessage {
+			t.Errorf("Got unexpected queued messages %v", got)
+		}
+	})
+}
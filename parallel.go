@@ -0,0 +1,58 @@
+package dkg
+
+import "io"
+import "runtime"
+import "sync"
+
+// Parallelism has the runner verify the proofs and shares of different
+// dealers on up to workers goroutines; workers <= 0 stands for
+// runtime.GOMAXPROCS. By default, it verifies them one after the other. It
+// must be called before Run.
+func (r *ProtocolRunner) Parallelism(workers int) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	r.workers = workers
+}
+
+// parallelFor calls f for 0 <= i < n on up to workers goroutines and
+// returns once all calls did.
+func parallelFor(workers, n int, f func(i int)) {
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			f(i)
+		}
+		return
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				f(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
+// lockedReader serializes reads of a random source shared by workers.
+type lockedReader struct {
+	mu sync.Mutex
+	r  io.Reader
+}
+
+func (l *lockedReader) Read(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Read(p)
+}
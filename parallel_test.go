@@ -0,0 +1,53 @@
+package dkg
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParallelFor(t *testing.T) {
+	for _, workers := range []int{0, 1, 3, 100} {
+		var calls [10]int32
+		parallelFor(workers, len(calls), func(i int) {
+			atomic.AddInt32(&calls[i], 1)
+		})
+		for i, c := range calls {
+			if c != 1 {
+				t.Errorf("%v workers: f(%v) called %v times", workers, i, c)
+			}
+		}
+	}
+}
+
+func TestParallelism(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 5, 2)
+	network := NewMemoryNetwork()
+	runners := make([]*ProtocolRunner, len(nodes))
+	for i, node := range nodes {
+		transport := network.Transport(node.ID())
+		defer transport.Close()
+		set, _ := NewParticipantSet(node.curve, node.Threshold(), participants)
+		runners[i], _ = NewProtocolRunner(node, set, transport)
+		runners[i].Parallelism(i % 3)
+	}
+	var wg sync.WaitGroup
+	for _, r := range runners {
+		wg.Add(1)
+		go func(r *ProtocolRunner) {
+			defer wg.Done()
+			r.Run()
+		}(r)
+	}
+	wg.Wait()
+
+	results := make([]*KeyShare, len(runners))
+	for i, r := range runners {
+		result, err := r.Result()
+		if err != nil {
+			t.Fatalf("Node %v failed: %v", nodes[i].ID(), err)
+		}
+		results[i] = result
+	}
+	checkCeremonyResultsForTesting(t, results)
+}
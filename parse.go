@@ -0,0 +1,71 @@
+package dkg
+
+import "crypto/elliptic"
+import "math/big"
+
+// The Parse functions decode payloads received from the network and check
+// them against the ceremony's curve, so that nothing malformed reaches the
+// protocol's arithmetic. They never panic, whatever their input.
+
+// ParseVerificationPoints decodes a binary PointTuple of valid points of
+// curve.
+func ParseVerificationPoints(curve elliptic.Curve, data []byte) (PointTuple, error) {
+	var pts PointTuple
+	if err := pts.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	if len(pts) == 0 {
+		return nil, InvalidEncodingError{"no verification points"}
+	}
+	for _, pt := range pts {
+		if !isValidPoint(curve, pt.X, pt.Y) {
+			return nil, InvalidCurvePointError{curve, pt.X, pt.Y}
+		}
+	}
+	return pts, nil
+}
+
+// ParseShareEnvelope decodes binary EncryptedShares of a ceremony over
+// curve, sealed to an identity key on keyCurve: an ephemeral point of
+// keyCurve, a nonce and the sealed shares.
+func ParseShareEnvelope(curve, keyCurve elliptic.Curve, data []byte) (EncryptedShares, error) {
+	var e EncryptedShares
+	if err := e.UnmarshalBinary(data); err != nil {
+		return EncryptedShares{}, err
+	}
+	pointLen := 1 + 2*((keyCurve.Params().BitSize+7)/8)
+	const nonceLen, tagLen = 12, 16
+	if len(e.Ciphertext) != pointLen+nonceLen+2*ScalarSize(curve)+tagLen {
+		return EncryptedShares{}, InvalidEncodingError{"share envelope of the wrong size"}
+	}
+	x, y := elliptic.Unmarshal(keyCurve, e.Ciphertext[:pointLen])
+	if x == nil || !isValidPoint(keyCurve, x, y) {
+		return EncryptedShares{}, InvalidCurvePointError{keyCurve, x, y}
+	}
+	return e, nil
+}
+
+// ParseComplaint decodes binary Complaints against distinct participants,
+// nonzero mod N, with a signature.
+func ParseComplaint(curve elliptic.Curve, data []byte) (Complaints, error) {
+	var c Complaints
+	if err := c.UnmarshalBinary(data); err != nil {
+		return Complaints{}, err
+	}
+	n := curve.Params().N
+	seen := make(map[string]bool)
+	for _, id := range c.Accused {
+		key := new(big.Int).Mod(id, n)
+		if key.Sign() == 0 {
+			return Complaints{}, InvalidParticipantIDError{id}
+		}
+		if seen[key.String()] {
+			return Complaints{}, DuplicateParticipantIDError{id}
+		}
+		seen[key.String()] = true
+	}
+	if len(c.Signature) == 0 {
+		return Complaints{}, InvalidEncodingError{"unsigned complaints"}
+	}
+	return c, nil
+}
@@ -0,0 +1,126 @@
+package dkg
+
+import "crypto/ecdsa"
+import "crypto/elliptic"
+import "crypto/sha256"
+import "math/big"
+
+type Participant struct {
+	ID  *big.Int
+	Key ecdsa.PublicKey
+}
+
+// ParticipantSet is the roster of a ceremony over curve: the participants,
+// identified by their IDs and identity keys, and the threshold of the key
+// they generate. Any threshold+1 of them can use the key.
+type ParticipantSet struct {
+	curve        elliptic.Curve
+	threshold    int
+	participants []Participant
+}
+
+// NewParticipantSet checks that there are more than threshold participants
+// with valid identity keys and distinct IDs, nonzero mod N.
+func NewParticipantSet(curve elliptic.Curve, threshold int, participants []Participant) (*ParticipantSet, error) {
+	ids := make([]*big.Int, len(participants))
+	for i, p := range participants {
+		ids[i] = p.ID
+	}
+	if err := validateIDs(curve, threshold, ids); err != nil {
+		return nil, err
+	}
+	for _, p := range participants {
+		if p.Key.Curve == nil || !isValidPoint(p.Key.Curve, p.Key.X, p.Key.Y) {
+			return nil, InvalidParticipantKeyError{p.ID}
+		}
+	}
+	return &ParticipantSet{curve, threshold, append([]Participant(nil), participants...)}, nil
+}
+
+type participantIDStatement struct {
+	curve    string
+	keyCurve string
+	x, y     *big.Int
+	block    uint64
+}
+
+func (s participantIDStatement) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/participant-id")
+	w.WriteBytes([]byte(s.curve))
+	w.WriteBytes([]byte(s.keyCurve))
+	w.WriteInt(s.x)
+	w.WriteInt(s.y)
+	w.WriteUint(s.block)
+}
+
+// DeriveParticipantID returns the canonical ID of the participant with
+// identity key key in ceremonies over curve: SHA-256 of both curves' names
+// and the key, expanded to 128 bits more than N and reduced mod N. Distinct
+// keys collide with negligible probability, which NewParticipantSet still
+// checks, like an ID of zero, which this rejects.
+func DeriveParticipantID(curve elliptic.Curve, key ecdsa.PublicKey) (*big.Int, error) {
+	if key.Curve == nil || !isValidPoint(key.Curve, key.X, key.Y) {
+		return nil, InvalidParticipantKeyError{nil}
+	}
+	n := curve.Params().N
+	size := (n.BitLen()+7)/8 + 16
+	var expanded []byte
+	for block := uint64(0); len(expanded) < size; block++ {
+		expanded = append(expanded, HashOf(sha256.New(), participantIDStatement{curve.Params().Name, key.Curve.Params().Name, key.X, key.Y, block})...)
+	}
+	id := new(big.Int).SetBytes(expanded[:size])
+	if id.Mod(id, n).Sign() == 0 {
+		return nil, InvalidParticipantIDError{id}
+	}
+	return id, nil
+}
+
+// NewParticipantSetFromKeys is NewParticipantSet with the participants'
+// IDs derived from their identity keys by DeriveParticipantID, in the order
+// of keys.
+func NewParticipantSetFromKeys(curve elliptic.Curve, threshold int, keys []ecdsa.PublicKey) (*ParticipantSet, error) {
+	participants := make([]Participant, len(keys))
+	for i, key := range keys {
+		id, err := DeriveParticipantID(curve, key)
+		if err != nil {
+			return nil, err
+		}
+		participants[i] = Participant{id, key}
+	}
+	return NewParticipantSet(curve, threshold, participants)
+}
+
+func (s *ParticipantSet) Curve() elliptic.Curve {
+	return s.curve
+}
+
+func (s *ParticipantSet) Threshold() int {
+	return s.threshold
+}
+
+func (s *ParticipantSet) Len() int {
+	return len(s.participants)
+}
+
+func (s *ParticipantSet) Participants() []Participant {
+	return append([]Participant(nil), s.participants...)
+}
+
+func (s *ParticipantSet) IDs() []*big.Int {
+	ids := make([]*big.Int, len(s.participants))
+	for i, p := range s.participants {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+// Participant returns the participant with ID id mod N.
+func (s *ParticipantSet) Participant(id *big.Int) (Participant, bool) {
+	n := s.curve.Params().N
+	for _, p := range s.participants {
+		if new(big.Int).Mod(p.ID, n).Cmp(new(big.Int).Mod(id, n)) == 0 {
+			return p, true
+		}
+	}
+	return Participant{}, false
+}
@@ -0,0 +1,81 @@
+package dkg
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestParticipantSet(t *testing.T) {
+	curve := elliptic.P256()
+	_, participants := getCeremonyNodesForTesting(t, 3, 1)
+	n := curve.Params().N
+
+	invalid := []struct {
+		desc         string
+		threshold    int
+		participants []Participant
+		err          error
+	}{
+		{"threshold not below the number of participants", 3, participants, InvalidThresholdError{}},
+		{"negative threshold", -1, participants, InvalidThresholdError{}},
+		{"zero ID", 1, append(participants[1:], Participant{big.NewInt(0), participants[0].Key}), InvalidParticipantIDError{}},
+		{"negative ID", 1, append(participants[1:], Participant{big.NewInt(-4), participants[0].Key}), InvalidParticipantIDError{}},
+		{"negative ID equal to another mod N", 1, append(participants[1:], Participant{new(big.Int).Sub(participants[1].ID, n), participants[0].Key}), InvalidParticipantIDError{}},
+		{"ID equal to N", 1, append(participants[1:], Participant{n, participants[0].Key}), InvalidParticipantIDError{}},
+		{"duplicate ID", 1, append(participants[1:], Participant{new(big.Int).Add(n, participants[1].ID), participants[0].Key}), DuplicateParticipantIDError{}},
+		{"missing identity key", 1, append(participants[1:], Participant{big.NewInt(4), ecdsa.PublicKey{}}), InvalidParticipantKeyError{}},
+		{"identity key off the curve", 1, append(participants[1:], Participant{big.NewInt(4), ecdsa.PublicKey{Curve: curve, X: big.NewInt(1), Y: big.NewInt(1)}}), InvalidParticipantKeyError{}},
+	}
+	for _, c := range invalid {
+		roster := append([]Participant(nil), c.participants...)
+		if _, err := NewParticipantSet(curve, c.threshold, roster); reflect.TypeOf(err) != reflect.TypeOf(c.err) {
+			t.Errorf("Got unexpected error for %v: %v", c.desc, err)
+		}
+	}
+
+	set, err := NewParticipantSet(curve, 1, participants)
+	if err != nil {
+		t.Fatalf("Could not create participant set: %v", err)
+	}
+	if set.Len() != 3 || set.Threshold() != 1 || len(set.IDs()) != 3 {
+		t.Errorf("Got unexpected set of %v participants with threshold %v", set.Len(), set.Threshold())
+	}
+	if p, ok := set.Participant(new(big.Int).Add(n, big.NewInt(2))); !ok || p.ID.Cmp(big.NewInt(2)) != 0 {
+		t.Errorf("Participant 2 not found by an ID equal mod N")
+	}
+	if _, ok := set.Participant(big.NewInt(4)); ok {
+		t.Errorf("Found participant 4 in a set of 3")
+	}
+}
+
+func TestParticipantSetFromKeys(t *testing.T) {
+	curve := elliptic.P256()
+	_, participants := getCeremonyNodesForTesting(t, 3, 1)
+	keys := make([]ecdsa.PublicKey, len(participants))
+	for i, p := range participants {
+		keys[i] = p.Key
+	}
+	set, err := NewParticipantSetFromKeys(curve, 1, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range set.Participants() {
+		id, err := DeriveParticipantID(curve, keys[i])
+		if err != nil || p.ID.Cmp(id) != 0 || p.ID.Cmp(curve.Params().N) >= 0 || !reflect.DeepEqual(p.Key, keys[i]) {
+			t.Errorf("Participant %v: %v", i, err)
+		}
+	}
+	if id, _ := DeriveParticipantID(curve, keys[0]); id.Cmp(set.IDs()[1]) == 0 {
+		t.Errorf("Distinct keys got the same ID")
+	}
+
+	if _, err := NewParticipantSetFromKeys(curve, 1, append(keys, keys[0])); reflect.TypeOf(err) != reflect.TypeOf(DuplicateParticipantIDError{}) {
+		t.Errorf("Got unexpected error for a duplicate key: %v", err)
+	}
+	if _, err := NewParticipantSetFromKeys(curve, 1, append(keys, ecdsa.PublicKey{})); reflect.TypeOf(err) != reflect.TypeOf(InvalidParticipantKeyError{}) {
+		t.Errorf("Got unexpected error for a missing key: %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package dkg
+
+import "math/big"
+import "sync"
+
+// PeerNetwork is the byte-level interface of a peer-to-peer network a
+// PeerTransport runs over, such as a libp2p host with a pubsub topic for
+// the ceremony and a stream protocol for direct messages. Peers are named
+// by their network IDs, which the network authenticates.
+type PeerNetwork interface {
+	// Publish sends data to every peer of the ceremony.
+	Publish(data []byte) error
+	// SendTo sends data to the peer with network ID peer.
+	SendTo(peer string, data []byte) error
+	// Receive yields what peers published or sent, with their network IDs.
+	Receive() <-chan PeerData
+	Close() error
+}
+
+// PeerData is data received from the peer with network ID Peer.
+type PeerData struct {
+	Peer string
+	Data []byte
+}
+
+// PeerTransport adapts a PeerNetwork to a Transport, mapping participant
+// IDs to network IDs, given as the Addr of each Peer, and exchanging
+// binary-encoded messages. It drops messages whose sender isn't the
+// participant of the peer that delivered them and direct messages for
+// others. Messages relayed by a pubsub mesh are only as authentic as the
+// mesh's own signatures; wrap the transport in an EnvelopeTransport to sign
+// them with the participants' identity keys.
+type PeerTransport struct {
+	id      *big.Int
+	network PeerNetwork
+	inbox   *mailbox
+
+	mu           sync.Mutex
+	peers        map[string]string // network IDs by participant ID
+	participants map[string]string // participant IDs by network ID
+}
+
+func NewPeerTransport(id *big.Int, network PeerNetwork, peers ...Peer) *PeerTransport {
+	t := &PeerTransport{
+		id:           new(big.Int).Set(id),
+		network:      network,
+		inbox:        newMailbox(),
+		peers:        make(map[string]string),
+		participants: make(map[string]string),
+	}
+	for _, peer := range peers {
+		t.AddPeer(peer)
+	}
+	go t.run()
+	return t
+}
+
+func (t *PeerTransport) AddPeer(peer Peer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.peers[peer.ID.String()] = peer.Addr
+	t.participants[peer.Addr] = peer.ID.String()
+}
+
+func (t *PeerTransport) run() {
+	defer t.inbox.close()
+	for d := range t.network.Receive() {
+		var m Message
+		if m.UnmarshalBinary(d.Data) != nil || m.From == nil {
+			continue
+		}
+		t.mu.Lock()
+		from, ok := t.participants[d.Peer]
+		t.mu.Unlock()
+		if !ok || from != m.From.String() || from == t.id.String() {
+			continue
+		}
+		if m.To != nil && m.To.Cmp(t.id) != 0 {
+			continue
+		}
+		t.inbox.put(m)
+	}
+}
+
+func (t *PeerTransport) Send(to *big.Int, m Message) error {
+	t.mu.Lock()
+	peer, ok := t.peers[to.String()]
+	t.mu.Unlock()
+	if !ok {
+		return UnknownParticipantError{to}
+	}
+	return t.network.SendTo(peer, marshalBinary(m))
+}
+
+func (t *PeerTransport) Broadcast(m Message) error {
+	return t.network.Publish(marshalBinary(m))
+}
+
+func (t *PeerTransport) Receive() <-chan Message {
+	return t.inbox.out
+}
+
+func (t *PeerTransport) Close() error {
+	err := t.network.Close()
+	t.inbox.close()
+	return err
+}
@@ -0,0 +1,435 @@
+package dkg
+
+import "bytes"
+import "crypto/aes"
+import "crypto/cipher"
+import "crypto/elliptic"
+import "crypto/pbkdf2"
+import "crypto/sha256"
+import "hash"
+import "io"
+import "math/big"
+import "time"
+
+import "golang.org/x/crypto/argon2"
+
+// Persisted state is encoded like messages: a version byte followed by
+// tagged, length-prefixed fields. Secrets can be sealed with a passphrase.
+
+// The Argon2id parameters of sealing, the second recommended option of
+// RFC 9106: 3 passes over 64 MiB with 4 lanes.
+const (
+	sealTime    = 3
+	sealMemory  = 64 * 1024
+	sealThreads = 4
+)
+
+// Bounds on the parameters of sealed data, so that opening it can't be made
+// to take unbounded time or memory.
+const (
+	maxSealTime   = 16
+	maxSealMemory = 1 << 21
+)
+
+// pbkdf2Iterations is the PBKDF2-HMAC-SHA256 iteration count of exported
+// private keys.
+const pbkdf2Iterations = 600000
+const maxSealIterations = 1 << 24
+const sealSaltSize = 16
+
+// SealWithPassphrase encrypts plaintext with AES-256-GCM under a key derived
+// from passphrase with Argon2id, drawing the salt and nonce from random,
+// such as the entropy source of the node whose secrets it seals.
+func SealWithPassphrase(plaintext, passphrase []byte, random io.Reader) ([]byte, error) {
+	salt := make([]byte, sealSaltSize)
+	if _, err := io.ReadFull(random, salt); err != nil {
+		return nil, err
+	}
+	key := argon2.IDKey(passphrase, salt, sealTime, sealMemory, sealThreads, 32)
+	aead, err := keyCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(random, nonce); err != nil {
+		return nil, err
+	}
+	ciphertext := aead.Seal(nil, nonce, plaintext, nil)
+	return encodeBinary(func(w *TranscriptWriter) {
+		w.WriteTag("dkg/sealed-argon2id")
+		w.WriteUint(sealTime)
+		w.WriteUint(sealMemory)
+		w.WriteUint(sealThreads)
+		w.WriteBytes(salt)
+		w.WriteBytes(nonce)
+		w.WriteBytes(ciphertext)
+	}), nil
+}
+
+// OpenWithPassphrase decrypts data sealed by SealWithPassphrase, or by
+// earlier versions, which derived the key with PBKDF2-HMAC-SHA256.
+func OpenWithPassphrase(data, passphrase []byte) ([]byte, error) {
+	var key, salt, nonce, ciphertext []byte
+	err := unmarshalBinary(data, func(r *transcriptReader) {
+		switch r.readTag() {
+		case "dkg/sealed-argon2id":
+			passes, memory, threads := r.readUint(), r.readUint(), r.readUint()
+			salt, nonce, ciphertext = r.readBytes(), r.readBytes(), r.readBytes()
+			if r.err != nil || passes < 1 || passes > maxSealTime || memory > maxSealMemory ||
+				threads < 1 || threads > 255 || memory < 8*threads || len(salt) != sealSaltSize {
+				r.fail("invalid sealing parameters")
+				return
+			}
+			key = argon2.IDKey(passphrase, salt, uint32(passes), uint32(memory), uint8(threads), 32)
+		case "dkg/sealed":
+			iterations := r.readUint()
+			salt, nonce, ciphertext = r.readBytes(), r.readBytes(), r.readBytes()
+			if r.err != nil || iterations < 1 || iterations > maxSealIterations || len(salt) != sealSaltSize {
+				r.fail("invalid sealing parameters")
+				return
+			}
+			var err error
+			if key, err = pbkdf2.Key(sha256.New, string(passphrase), salt, int(iterations), 32); err != nil {
+				r.fail(err.Error())
+			}
+		default:
+			r.fail("not sealed data")
+		}
+	})
+	if err != nil {
+		clear(key)
+		return nil, SealedDataError{}
+	}
+	aead, err := keyCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, SealedDataError{}
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, SealedDataError{}
+	}
+	return plaintext, nil
+}
+
+// Sealer seals secrets for storage and opens them again, such as with a
+// passphrase or under a key held by a KMS, whose encrypt and decrypt calls
+// it wraps.
+type Sealer interface {
+	Seal(plaintext []byte) ([]byte, error)
+	Open(sealed []byte) ([]byte, error)
+}
+
+// passphraseSealer seals with SealWithPassphrase.
+type passphraseSealer struct {
+	passphrase []byte
+	random     io.Reader
+}
+
+// PassphraseSealer returns a Sealer sealing with passphrase by
+// SealWithPassphrase, drawing from random.
+func PassphraseSealer(passphrase []byte, random io.Reader) Sealer {
+	return passphraseSealer{passphrase, random}
+}
+
+func (s passphraseSealer) Seal(plaintext []byte) ([]byte, error) {
+	return SealWithPassphrase(plaintext, s.passphrase, s.random)
+}
+
+func (s passphraseSealer) Open(sealed []byte) ([]byte, error) {
+	return OpenWithPassphrase(sealed, s.passphrase)
+}
+
+// Rewrap opens sealed with from and seals the plaintext again with to, such
+// as when rotating a passphrase or a KMS key. The plaintext only exists in
+// memory, and is wiped before returning. A nil Sealer stands for data
+// stored unsealed.
+func Rewrap(sealed []byte, from, to Sealer) ([]byte, error) {
+	plaintext := sealed
+	if from != nil {
+		var err error
+		if plaintext, err = from.Open(sealed); err != nil {
+			return nil, err
+		}
+		defer clear(plaintext)
+	}
+	if to == nil {
+		return bytes.Clone(plaintext), nil
+	}
+	return to.Seal(plaintext)
+}
+
+// keyCipher returns AES-256-GCM under key, and zeroes key.
+func keyCipher(key []byte) (cipher.AEAD, error) {
+	defer clear(key)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// passphraseBlock returns AES-256 under a key derived from passphrase with
+// PBKDF2-HMAC-SHA256.
+func passphraseBlock(passphrase, salt []byte, iterations int) (cipher.Block, error) {
+	key, err := pbkdf2.Key(sha256.New, string(passphrase), salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(key)
+	return aes.NewCipher(key)
+}
+
+func (r *transcriptReader) readCurve() elliptic.Curve {
+	name := r.readTag()
+	curve, ok := CurveByName(name)
+	if !ok {
+		r.fail("unknown curve " + name)
+	}
+	return curve
+}
+
+func (r *transcriptReader) readPolynomial(curve elliptic.Curve) ScalarPolynomial {
+	var poly ScalarPolynomial
+	for n := r.readCount(); len(poly) < n; {
+		poly = append(poly, r.readScalar(curve))
+	}
+	return poly
+}
+
+func writePolynomial(w *TranscriptWriter, curve elliptic.Curve, poly ScalarPolynomial) {
+	w.WriteUint(uint64(len(poly)))
+	for _, c := range poly {
+		w.WriteScalar(curve, c)
+	}
+}
+
+// SaveNode encodes the complete state of n, secrets included, sealed with
+// passphrase unless it is nil. The curves of the node and of its identity
+// key must be registered.
+func SaveNode(n *Node, passphrase []byte) ([]byte, error) {
+	if n.inMemory {
+		return nil, InMemoryOnlyError{"node"}
+	}
+	if n.key.D == nil {
+		return nil, InvalidEncodingError{"node with an external identity key"}
+	}
+	data := encodeBinary(func(w *TranscriptWriter) {
+		if n.feldman {
+			w.WriteTag("dkg/feldman-node-state")
+			w.WriteTag(n.curve.Params().Name)
+		} else {
+			w.WriteTag("dkg/node-state")
+			w.WriteTag(n.curve.Params().Name)
+			w.WriteInt(n.g2x)
+			w.WriteInt(n.g2y)
+		}
+		w.WriteInt(n.zkParam)
+		w.WriteUint(uint64(n.timeout))
+		w.WriteInt(n.id)
+		w.WriteTag(n.key.Curve.Params().Name)
+		w.WriteScalar(n.key.Curve, n.key.D)
+		writePolynomial(w, n.curve, n.secretPoly1)
+		if !n.feldman {
+			writePolynomial(w, n.curve, n.secretPoly2)
+		}
+	})
+	if passphrase == nil {
+		return data, nil
+	}
+	defer clear(data)
+	return SealWithPassphrase(data, passphrase, n.random)
+}
+
+// LoadNode restores a node saved by SaveNode, with the same passphrase.
+// The node's hash isn't saved and is passed in again.
+func LoadNode(data, passphrase []byte, hash hash.Hash) (*Node, error) {
+	if passphrase != nil {
+		var err error
+		if data, err = OpenWithPassphrase(data, passphrase); err != nil {
+			return nil, err
+		}
+	}
+
+	config := NodeConfig{Hash: hash}
+	err := unmarshalBinary(data, func(r *transcriptReader) {
+		switch r.readTag() {
+		case "dkg/node-state":
+			config.Curve = r.readCurve()
+			config.G2X, config.G2Y = r.readInt(), r.readInt()
+		case "dkg/feldman-node-state":
+			config.Curve = r.readCurve()
+			config.Feldman = true
+		default:
+			r.fail("not a node state")
+			return
+		}
+		config.ZKParam = r.readInt()
+		config.Timeout = time.Duration(r.readUint())
+		config.ID = r.readInt()
+		config.Key.Curve = r.readCurve()
+		config.Key.D = r.readScalar(config.Key.Curve)
+		config.SecretPoly1 = r.readPolynomial(config.Curve)
+		if !config.Feldman {
+			config.SecretPoly2 = r.readPolynomial(config.Curve)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	key := &config.Key
+	if key.D.Sign() == 0 {
+		return nil, InvalidCurveScalarError{key.Curve, key.D}
+	}
+	key.X, key.Y = key.Curve.ScalarBaseMult(scalarBytes(key.Curve, key.D))
+	if config.SecretPoly1 == nil {
+		config.SecretPoly1 = ScalarPolynomial{}
+	}
+	return NewNodeWithOptions(WithConfig(config))
+}
+
+// MarshalBinary encodes the key share, secret included. Seal it with
+// SealWithPassphrase before storing it.
+func (s *KeyShare) MarshalBinary() ([]byte, error) {
+	if s.inMemory {
+		return nil, InMemoryOnlyError{"key share"}
+	}
+	curve := s.PublicKey.Curve
+	if curve == nil {
+		return nil, InvalidEncodingError{"key share without a curve"}
+	}
+	return encodeBinary(func(w *TranscriptWriter) {
+		w.WriteTag("dkg/key-share")
+		w.WriteTag(curve.Params().Name)
+		w.WriteInt(s.ID)
+		w.WriteUint(s.Epoch)
+		w.WriteUint(uint64(s.Threshold))
+		w.WriteUint(uint64(len(s.Qualified)))
+		for _, id := range s.Qualified {
+			w.WriteInt(id)
+		}
+		w.Write(s.PublicCoefficients)
+		if s.Share == nil {
+			// destroyed
+			w.WriteScalar(curve, new(big.Int))
+		} else {
+			w.WriteScalar(curve, s.Share)
+		}
+		if s.Revoked == nil {
+			w.WriteUint(0)
+		} else {
+			w.WriteUint(1)
+			s.Revoked.writeRevocation(w)
+		}
+		if s.Watermarked == nil {
+			w.WriteUint(0)
+		} else {
+			w.WriteUint(1)
+			s.Watermarked.writeWatermark(w)
+		}
+		if s.Usage != nil {
+			s.Usage.writeUsage(w)
+		}
+	}), nil
+}
+
+// UnmarshalBinary decodes a key share, checking its points and scalars
+// against its curve and its share against its public coefficients.
+func (s *KeyShare) UnmarshalBinary(data []byte) error {
+	var out KeyShare
+	err := unmarshalBinary(data, func(r *transcriptReader) {
+		r.expectTag("dkg/key-share")
+		curve := r.readCurve()
+		out.PublicKey.Curve = curve
+		out.ID = r.readInt()
+		out.Epoch = r.readUint()
+		out.Threshold = int(r.readUint())
+		for n := r.readCount(); len(out.Qualified) < n; {
+			out.Qualified = append(out.Qualified, r.readInt())
+		}
+		r.expectTag("dkg/points")
+		out.PublicCoefficients = r.readPoints()
+		out.Share = r.readScalar(curve)
+		if revoked := r.readUint(); revoked == 1 {
+			rev := r.readRevocation()
+			out.Revoked = &rev
+		} else if revoked != 0 {
+			r.fail("invalid revocation flag")
+		}
+		if watermarked := r.readUint(); watermarked == 1 {
+			mark := r.readWatermark()
+			out.Watermarked = &mark
+		} else if watermarked != 0 {
+			r.fail("invalid watermark flag")
+		}
+		// shares stored before usages end here
+		if len(r.b) > 0 {
+			out.Usage = r.readUsage()
+		}
+		if r.err != nil {
+			return
+		}
+		if len(out.PublicCoefficients) != out.Threshold+1 || !validCommitments(curve, out.PublicCoefficients) {
+			r.fail("invalid public coefficients")
+			return
+		}
+		out.PublicKey.X, out.PublicKey.Y = out.PublicCoefficients[0].X, out.PublicCoefficients[0].Y
+		if out.Revoked != nil && out.Share.Sign() == 0 {
+			// destroyed
+			out.Share = nil
+		} else if !VerifyDealtShare(curve, DealtShare{out.ID, out.Share}, out.PublicCoefficients) {
+			r.fail("share doesn't match the public coefficients")
+		}
+	})
+	if err != nil {
+		return err
+	}
+	*s = out
+	return nil
+}
+
+// RunnerState is the progress of a ceremony: when it started, the
+// messages accepted so far and the calibrated timeouts, if any. A node that
+// restarts mid-ceremony resumes from it with ResumeProtocolRunner.
+type RunnerState struct {
+	Start    time.Time
+	Received []Message
+	Timeouts []time.Duration
+}
+
+func (s RunnerState) MarshalBinary() ([]byte, error) {
+	return encodeBinary(func(w *TranscriptWriter) {
+		w.WriteTag("dkg/runner-state")
+		w.WriteUint(uint64(s.Start.UnixNano()))
+		w.WriteUint(uint64(len(s.Received)))
+		for _, m := range s.Received {
+			w.Write(m)
+		}
+		w.WriteUint(uint64(len(s.Timeouts)))
+		for _, d := range s.Timeouts {
+			w.WriteUint(uint64(d))
+		}
+	}), nil
+}
+
+func (s *RunnerState) UnmarshalBinary(data []byte) error {
+	var out RunnerState
+	err := unmarshalBinary(data, func(r *transcriptReader) {
+		r.expectTag("dkg/runner-state")
+		out.Start = time.Unix(0, int64(r.readUint()))
+		for n := r.readCount(); len(out.Received) < n; {
+			r.expectTag("dkg/message")
+			out.Received = append(out.Received, r.readMessage())
+		}
+		for n := r.readCount(); len(out.Timeouts) < n; {
+			out.Timeouts = append(out.Timeouts, time.Duration(r.readUint()))
+		}
+	})
+	if err != nil {
+		return err
+	}
+	*s = out
+	return nil
+}
@@ -0,0 +1,257 @@
+package dkg
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"math/big"
+	mrand "math/rand/v2"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSealWithPassphrase(t *testing.T) {
+	plaintext := []byte("node state")
+	sealed, err := SealWithPassphrase(plaintext, []byte("correct horse"), rand.Reader)
+	if err != nil {
+		t.Fatalf("Could not seal: %v", err)
+	}
+	opened, err := OpenWithPassphrase(sealed, []byte("correct horse"))
+	if err != nil || string(opened) != string(plaintext) {
+		t.Errorf("Opened %q (%v), expected %q", opened, err, plaintext)
+	}
+
+	if _, err := OpenWithPassphrase(sealed, []byte("battery staple")); reflect.TypeOf(err) != reflect.TypeOf(SealedDataError{}) {
+		t.Errorf("Got unexpected error opening with the wrong passphrase: %v", err)
+	}
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := OpenWithPassphrase(tampered, []byte("correct horse")); reflect.TypeOf(err) != reflect.TypeOf(SealedDataError{}) {
+		t.Errorf("Got unexpected error opening tampered data: %v", err)
+	}
+
+	// the salt and nonce come from the given source
+	seeded := func() []byte {
+		sealed, err := SealWithPassphrase(plaintext, []byte("correct horse"), mrand.NewChaCha8([32]byte{1}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sealed
+	}
+	if !bytes.Equal(seeded(), seeded()) {
+		t.Errorf("Sealing didn't draw from the given source")
+	}
+
+	// data sealed under PBKDF2 by earlier versions still opens
+	salt, nonce := make([]byte, sealSaltSize), make([]byte, 12)
+	block, _ := passphraseBlock([]byte("correct horse"), salt, 1000)
+	aead, _ := cipher.NewGCM(block)
+	legacy := encodeBinary(func(w *TranscriptWriter) {
+		w.WriteTag("dkg/sealed")
+		w.WriteUint(1000)
+		w.WriteBytes(salt)
+		w.WriteBytes(nonce)
+		w.WriteBytes(aead.Seal(nil, nonce, plaintext, nil))
+	})
+	if opened, err := OpenWithPassphrase(legacy, []byte("correct horse")); err != nil || string(opened) != string(plaintext) {
+		t.Errorf("Opened legacy data as %q (%v)", opened, err)
+	}
+}
+
+func TestSaveNode(t *testing.T) {
+	curve, hash, g2x, g2y, zkParam, timeout, id, key, poly1, poly2 := getValidNodeParamsForTesting(t)
+	node, err := NewNode(curve, hash, g2x, g2y, zkParam, timeout, id, key, poly1, poly2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, passphrase := range [][]byte{nil, []byte("passphrase")} {
+		data, err := SaveNode(node, passphrase)
+		if err != nil {
+			t.Fatalf("Could not save node: %v", err)
+		}
+		loaded, err := LoadNode(data, passphrase, sha512.New512_256())
+		if err != nil {
+			t.Fatalf("Could not load node: %v", err)
+		}
+		if loaded.ID().Cmp(node.ID()) != 0 || loaded.timeout != node.timeout ||
+			!reflect.DeepEqual(loaded.key, node.key) ||
+			!reflect.DeepEqual(loaded.VerificationPoints(), node.VerificationPoints()) {
+			t.Errorf("Loaded node differs from the saved one")
+		}
+	}
+
+	sealed, _ := SaveNode(node, []byte("passphrase"))
+	if _, err := LoadNode(sealed, nil, sha512.New512_256()); reflect.TypeOf(err) != reflect.TypeOf(InvalidEncodingError{}) {
+		t.Errorf("Got unexpected error loading sealed state without a passphrase: %v", err)
+	}
+}
+
+func TestKeyShareEncoding(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+	results := runCeremonyForTesting(t, nodes, participants)
+	share := results[0]
+	if share == nil {
+		t.Fatalf("Node %v did not finish", nodes[0].ID())
+	}
+
+	data, err := share.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Could not encode key share: %v", err)
+	}
+	var decoded KeyShare
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Could not decode key share: %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, share) {
+		t.Errorf("Key share decoded to %+v, expected %+v", decoded, share)
+	}
+
+	other := *share
+	other.Share = results[1].Share
+	data, _ = other.MarshalBinary()
+	if err := decoded.UnmarshalBinary(data); reflect.TypeOf(err) != reflect.TypeOf(InvalidEncodingError{}) {
+		t.Errorf("Got unexpected error decoding a share not matching its coefficients: %v", err)
+	}
+}
+
+func TestResumeProtocolRunner(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 4, 1)
+	network := NewMemoryNetwork()
+	runners := make([]*ProtocolRunner, len(nodes))
+	for i, node := range nodes {
+		transport := network.Transport(node.ID())
+		defer transport.Close()
+		set, _ := NewParticipantSet(node.curve, node.Threshold(), participants)
+		runners[i], _ = NewProtocolRunner(node, set, transport)
+	}
+	done := make(chan error, len(runners))
+	for _, r := range runners {
+		go func(r *ProtocolRunner) { done <- r.Run() }(r)
+	}
+	for range runners {
+		if err := <-done; err != nil {
+			t.Fatalf("Ceremony failed: %v", err)
+		}
+	}
+	expected, _ := runners[0].Result()
+
+	// the node restarts and replays the ceremony alone from its saved state
+	data, err := runners[0].State().MarshalBinary()
+	if err != nil {
+		t.Fatalf("Could not encode runner state: %v", err)
+	}
+	var state RunnerState
+	if err := state.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Could not decode runner state: %v", err)
+	}
+	saved, _ := SaveNode(nodes[0], nil)
+	node, err := LoadNode(saved, nil, sha512.New512_256())
+	if err != nil {
+		t.Fatal(err)
+	}
+	set, _ := NewParticipantSet(node.curve, node.Threshold(), participants)
+	transport := NewMemoryNetwork().Transport(node.ID())
+	defer transport.Close()
+	resumed, err := ResumeProtocolRunner(node, set, transport, state)
+	if err != nil {
+		t.Fatalf("Could not resume: %v", err)
+	}
+	if err := resumed.Run(); err != nil {
+		t.Fatalf("Resumed ceremony failed: %v", err)
+	}
+	result, _ := resumed.Result()
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Resumed ceremony ended with %+v, expected %+v", result, expected)
+	}
+}
+
+func TestInMemoryOnly(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("secrets can't be locked in memory on " + runtime.GOOS)
+	}
+	plain, participants := getCeremonyNodesForTesting(t, 3, 1)
+	nodes := make([]*Node, len(plain))
+	for i, n := range plain {
+		node, err := NewNodeWithOptions(
+			WithCurve(n.curve), WithHash(sha512.New512_256()), WithGenerator2(n.g2x, n.g2y), WithZKParam(n.zkParam),
+			WithTimeout(n.timeout), WithID(n.id), WithKey(n.key), WithPolynomials(n.secretPoly1, n.secretPoly2),
+			WithInMemoryOnly(),
+		)
+		if err != nil {
+			t.Fatalf("Could not create in-memory only node: %v", err)
+		}
+		nodes[i] = node
+	}
+	set, err := NewParticipantSet(nodes[0].curve, 1, participants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	network := NewMemoryNetwork()
+	runners := make([]*ProtocolRunner, len(nodes))
+	for i, node := range nodes {
+		transport := network.Transport(node.ID())
+		defer transport.Close()
+		if runners[i], err = NewProtocolRunner(node, set, transport); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var wg sync.WaitGroup
+	for _, r := range runners {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Run()
+		}()
+	}
+	wg.Wait()
+	share, err := runners[0].Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status, err := os.ReadFile("/proc/self/status"); err == nil {
+		for _, line := range strings.Split(string(status), "\n") {
+			if fields := strings.Fields(line); len(fields) > 1 && fields[0] == "VmLck:" && fields[1] == "0" {
+				t.Errorf("No memory locked")
+			}
+		}
+	}
+	if state := runners[0].State(); len(state.Received) != 0 {
+		t.Errorf("Journaled %v messages", len(state.Received))
+	}
+	var inMemory InMemoryOnlyError
+	if _, err := ResumeProtocolRunner(nodes[0], set, network.Transport(nodes[0].ID()), RunnerState{}); !errors.As(err, &inMemory) {
+		t.Errorf("Got unexpected error resuming: %v", err)
+	}
+	if _, err := SaveNode(nodes[0], []byte("passphrase")); !errors.As(err, &inMemory) {
+		t.Errorf("Got unexpected error saving the node: %v", err)
+	}
+	if _, err := share.MarshalBinary(); !errors.As(err, &inMemory) {
+		t.Errorf("Got unexpected error encoding the share: %v", err)
+	}
+	if _, err := share.MarshalPKCS8([]byte("passphrase")); !errors.As(err, &inMemory) {
+		t.Errorf("Got unexpected error exporting the share: %v", err)
+	}
+	if _, err := share.Mnemonic(); !errors.As(err, &inMemory) {
+		t.Errorf("Got unexpected error writing the share down: %v", err)
+	}
+
+	// nor does a session manager store its shares
+	storage := NewMemoryStorage()
+	manager := NewSessionManager(network.Transport(big.NewInt(9)))
+	defer manager.Close()
+	manager.UseStorage(storage, nil)
+	if err := manager.saveKeyShare("in-memory", share, rand.Reader); !errors.As(err, &inMemory) {
+		t.Errorf("Got unexpected error storing the share: %v", err)
+	}
+	if sessions, _ := storage.ListSessions(); len(sessions) != 0 {
+		t.Errorf("Stored sessions %v", sessions)
+	}
+}
@@ -0,0 +1,98 @@
+package dkg
+
+import "crypto/elliptic"
+import "math/big"
+
+var one = big.NewInt(1)
+
+// Curves whose group order is not prime implement this to have subgroup
+// membership enforced on every point. The curves of crypto/elliptic all have
+// cofactor 1.
+type cofactorCurve interface {
+	Cofactor() *big.Int
+}
+
+// Curves whose identity element is not represented as (0, 0), the
+// crypto/elliptic convention, implement this.
+type identityCurve interface {
+	IsIdentity(x, y *big.Int) bool
+}
+
+func cofactor(curve elliptic.Curve) *big.Int {
+	if c, ok := curve.(cofactorCurve); ok {
+		return c.Cofactor()
+	}
+	return one
+}
+
+func isIdentity(curve elliptic.Curve, x, y *big.Int) bool {
+	if c, ok := curve.(identityCurve); ok {
+		return c.IsIdentity(x, y)
+	}
+	return x.Sign() == 0 && y.Sign() == 0
+}
+
+// ValidatePoint checks that (x, y) is a point every function of the package
+// accepts from outside: both coordinates present and normalized, on curve,
+// not the identity, and of the prime-order subgroup on curves with a
+// cofactor, such as edwards25519. Points decoded with elliptic.Unmarshal,
+// which returns nil coordinates for invalid input, must still be checked.
+func ValidatePoint(curve elliptic.Curve, x, y *big.Int) error {
+	if !isValidPoint(curve, x, y) {
+		return InvalidCurvePointError{curve, x, y}
+	}
+	return nil
+}
+
+// isValidPoint reports whether (x, y) is a normalized, non-identity point of
+// the prime-order subgroup of curve.
+func isValidPoint(curve elliptic.Curve, x, y *big.Int) bool {
+	p := curve.Params().P
+	if !isNormalizedScalar(x, p) || !isNormalizedScalar(y, p) ||
+		!curve.IsOnCurve(x, y) || isIdentity(curve, x, y) {
+		return false
+	}
+
+	if h := cofactor(curve); h.Cmp(one) > 0 {
+		// points of small order are annihilated by the cofactor
+		if hx, hy := curve.ScalarMult(x, y, h.Bytes()); isIdentity(curve, hx, hy) {
+			return false
+		}
+		// anything else must have order N
+		if nx, ny := curve.ScalarMult(x, y, curve.Params().N.Bytes()); !isIdentity(curve, nx, ny) {
+			return false
+		}
+	}
+	return true
+}
+
+// Curves with a faster way to sum many scalar multiplications than
+// ScalarMult implement this, such as with Pippenger's method. The scalars
+// are public.
+type multiScalarCurve interface {
+	MultiScalarMult(xs, ys []*big.Int, ks [][]byte) (x, y *big.Int)
+}
+
+// multiScalarMult returns the sum of ks[i] * points[i].
+func multiScalarMult(curve elliptic.Curve, points PointTuple, ks []*big.Int) (x, y *big.Int) {
+	scalars := make([][]byte, len(ks))
+	for i, k := range ks {
+		scalars[i] = scalarBytes(curve, k)
+	}
+	if c, ok := curve.(multiScalarCurve); ok {
+		xs, ys := make([]*big.Int, len(points)), make([]*big.Int, len(points))
+		for i, p := range points {
+			xs[i], ys[i] = p.X, p.Y
+		}
+		return c.MultiScalarMult(xs, ys, scalars)
+	}
+	for i, p := range points {
+		px, py := curve.ScalarMult(p.X, p.Y, scalars[i])
+		if i == 0 {
+			x, y = px, py
+			continue
+		}
+		x, y = curve.Add(x, y, px, py)
+	}
+	return x, y
+}
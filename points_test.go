@@ -0,0 +1,110 @@
+package dkg
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/mikalv/dkg/dkgtest"
+	"github.com/mikalv/dkg/edwards25519"
+)
+
+// y^2 = x^3 - 3x + 25 over GF(1019) has 1004 = 4 * 251 points
+type cofactor4Curve struct {
+	*elliptic.CurveParams
+}
+
+func (cofactor4Curve) Cofactor() *big.Int {
+	return big.NewInt(4)
+}
+
+func newCofactor4Curve() cofactor4Curve {
+	return cofactor4Curve{&elliptic.CurveParams{
+		P:       big.NewInt(1019),
+		N:       big.NewInt(251),
+		B:       big.NewInt(25),
+		Gx:      big.NewInt(501),
+		Gy:      big.NewInt(300),
+		BitSize: 10,
+		Name:    "cofactor-4 test curve",
+	}}
+}
+
+func TestSubgroupChecks(t *testing.T) {
+	curve := newCofactor4Curve()
+
+	points := []struct {
+		x, y  int64
+		valid bool
+		desc  string
+	}{
+		{501, 300, true, "generator of the order 251 subgroup"},
+		{0, 5, false, "point of order 1004"},
+		{616, 406, false, "point of order 4"},
+		{562, 0, false, "point of order 2"},
+		{0, 0, false, "identity"},
+		{1, 1, false, "point not on the curve"},
+	}
+	for _, pt := range points {
+		x, y := big.NewInt(pt.x), big.NewInt(pt.y)
+		if isValidPoint(curve, x, y) != pt.valid {
+			t.Errorf("Expected validity %v for %v (%v, %v)", pt.valid, pt.desc, x, y)
+		}
+	}
+
+	for _, c := range []elliptic.Curve{elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		if !isValidPoint(c, c.Params().Gx, c.Params().Gy) {
+			t.Errorf("Base point of %v rejected", c.Params().Name)
+		}
+	}
+
+	ed := edwards25519.Curve()
+	params := ed.Params()
+	minusOne := new(big.Int).Sub(params.P, big.NewInt(1))
+	mixedX, mixedY := ed.Add(params.Gx, params.Gy, big.NewInt(0), minusOne)
+	edPoints := []struct {
+		x, y  *big.Int
+		valid bool
+		desc  string
+	}{
+		{params.Gx, params.Gy, true, "edwards25519 base point"},
+		{big.NewInt(0), big.NewInt(1), false, "edwards25519 identity"},
+		{big.NewInt(0), minusOne, false, "edwards25519 point of order 2"},
+		{mixedX, mixedY, false, "edwards25519 point of order 2N"},
+	}
+	for _, pt := range edPoints {
+		if isValidPoint(ed, pt.x, pt.y) != pt.valid {
+			t.Errorf("Expected validity %v for %v (%v, %v)", pt.valid, pt.desc, pt.x, pt.y)
+		}
+	}
+}
+
+func TestValidatePoint(t *testing.T) {
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384()} {
+		params := curve.Params()
+		if err := ValidatePoint(curve, params.Gx, params.Gy); err != nil {
+			t.Errorf("Base point of %v rejected: %v", params.Name, err)
+		}
+		for _, pt := range dkgtest.MaliciousPoints(curve) {
+			if err := ValidatePoint(curve, pt.X, pt.Y); !reflect.DeepEqual(err, InvalidCurvePointError{curve, pt.X, pt.Y}) {
+				t.Errorf("%v: got unexpected error for %v: %v", params.Name, pt.Description, err)
+			}
+		}
+		// elliptic.Unmarshal returns nil coordinates for bad encodings
+		x, y := elliptic.Unmarshal(curve, []byte{4, 1, 2})
+		if err := ValidatePoint(curve, x, y); err == nil {
+			t.Errorf("%v: accepted a failed decoding", params.Name)
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SoftwareIdentity(key).ECDH(big.NewInt(1), big.NewInt(1)); reflect.TypeOf(err) != reflect.TypeOf(InvalidCurvePointError{}) {
+		t.Errorf("Got unexpected error for ECDH with an invalid point: %v", err)
+	}
+}
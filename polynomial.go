@@ -0,0 +1,108 @@
+package dkg
+
+import "crypto/elliptic"
+import "crypto/rand"
+import "io"
+import "math/big"
+
+// evaluate returns p(x) mod n, in constant time with respect to the
+// coefficients.
+func (p ScalarPolynomial) evaluate(x, n *big.Int) *big.Int {
+	f := scalarFieldFor(n)
+	xm := f.fromBig(x)
+	result, c := f.element(), f.element()
+	for i := len(p) - 1; i >= 0; i-- {
+		f.setBig(c, p[i])
+		f.mul(result, result, xm)
+		f.add(result, result, c)
+	}
+	clear(c)
+	out := f.toBig(result)
+	clear(result)
+	return out
+}
+
+// GenerateScalarPolynomial returns a polynomial of degree threshold with
+// coefficients drawn uniformly from [1, N) of curve.
+func GenerateScalarPolynomial(curve elliptic.Curve, threshold int, random io.Reader) (ScalarPolynomial, error) {
+	if threshold < 0 {
+		return nil, InvalidThresholdError{threshold, 0}
+	}
+	poly := make(ScalarPolynomial, threshold+1)
+	for i := range poly {
+		c, err := randomScalar(curve.Params().N, random)
+		if err != nil {
+			return nil, err
+		}
+		poly[i] = c
+	}
+	return poly, nil
+}
+
+// randomScalar returns a uniformly random scalar in [1, n).
+func randomScalar(n *big.Int, random io.Reader) (*big.Int, error) {
+	k, err := rand.Int(random, new(big.Int).Sub(n, one))
+	if err != nil {
+		return nil, err
+	}
+	return k.Add(k, one), nil
+}
+
+// lagrangeCoefficient returns the coefficient of the share at x for
+// interpolating the polynomial through the points at xs at zero, mod n.
+func lagrangeCoefficient(x *big.Int, xs []*big.Int, n *big.Int) *big.Int {
+	return lagrangeCoefficientAt(x, new(big.Int), xs, n)
+}
+
+// lagrangeCoefficientAt is lagrangeCoefficient interpolating at at.
+func lagrangeCoefficientAt(x, at *big.Int, xs []*big.Int, n *big.Int) *big.Int {
+	num, den := big.NewInt(1), big.NewInt(1)
+	for _, xj := range xs {
+		if xj.Cmp(x) == 0 {
+			continue
+		}
+		num.Mul(num, new(big.Int).Sub(xj, at))
+		num.Mod(num, n)
+		diff := new(big.Int).Sub(xj, x)
+		den.Mul(den, diff)
+		den.Mod(den, n)
+	}
+	return num.Mul(num, den.ModInverse(den, n)).Mod(num, n)
+}
+
+// interpolatePolynomial returns the polynomial of degree len(xs)-1 through
+// the points (xs[i], ys[i]), mod n. The xs must be distinct.
+func interpolatePolynomial(xs, ys []*big.Int, n *big.Int) ScalarPolynomial {
+	poly := make(ScalarPolynomial, len(xs))
+	for i := range poly {
+		poly[i] = new(big.Int)
+	}
+	basis := make([]*big.Int, len(xs))
+	for i, xi := range xs {
+		// the basis polynomial of xi, prod (x - xj) / (xi - xj) over j != i
+		for k := range basis {
+			basis[k] = new(big.Int)
+		}
+		basis[0].SetInt64(1)
+		den, deg := big.NewInt(1), 0
+		for j, xj := range xs {
+			if j == i {
+				continue
+			}
+			deg++
+			for k := deg; k > 0; k-- {
+				basis[k].Sub(basis[k-1], new(big.Int).Mul(basis[k], xj))
+				basis[k].Mod(basis[k], n)
+			}
+			basis[0].Mul(basis[0], xj).Neg(basis[0]).Mod(basis[0], n)
+			den.Mul(den, new(big.Int).Sub(xi, xj)).Mod(den, n)
+		}
+		scale := den.ModInverse(den, n)
+		scale.Mul(scale, ys[i]).Mod(scale, n)
+		for k, b := range basis {
+			poly[k].Add(poly[k], b.Mul(b, scale)).Mod(poly[k], n)
+		}
+	}
+	zeroize(basis...)
+	return poly
+}
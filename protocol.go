@@ -0,0 +1,1111 @@
+package dkg
+
+import "context"
+import "crypto/ecdsa"
+import "errors"
+import "io"
+import "log/slog"
+import "math/big"
+import "sort"
+import "sync"
+import "time"
+
+// KeyShare is a node's output of a successful ceremony. Epoch counts the
+// refreshes and reshares the key went through, the initial ceremony is
+// epoch 0.
+type KeyShare struct {
+	ID        *big.Int
+	Epoch     uint64
+	Threshold int
+	Qualified []*big.Int
+	PublicKey ecdsa.PublicKey
+	// sums of the qualified dealers' public coefficients; evaluated at a
+	// participant's ID they give that participant's public share
+	PublicCoefficients PointTuple
+	Share              *big.Int
+	// set once the group key is revoked; Share is nil if it was destroyed
+	Revoked *Revocation
+	// set on copies of the share, see Watermark
+	Watermarked *Watermark
+	// restricts the share's use if set
+	Usage *KeyUsage
+
+	// dealt to an in-memory only node, see WithInMemoryOnly
+	inMemory bool
+}
+
+// GroupKey is the public outcome of a ceremony, the same for all
+// participants.
+type GroupKey struct {
+	Epoch              uint64
+	Threshold          int
+	PublicKey          ecdsa.PublicKey
+	PublicCoefficients PointTuple
+}
+
+func (s *KeyShare) Group() GroupKey {
+	return GroupKey{s.Epoch, s.Threshold, s.PublicKey, s.PublicCoefficients}
+}
+
+type participant struct {
+	id  *big.Int
+	key ecdsa.PublicKey
+
+	verificationPoints PointTuple
+	knowledgeProof     *SecretKnowledgeProof
+	secretShare1       *big.Int
+	secretShare2       *big.Int
+	shareErr           error
+	complaints         *Complaints
+	justification      *Justification
+	publicCoefficients PointTuple
+	qualifiedSet       *QualifiedSet
+	contest            *Exposure
+	reconstruction     *Exposure
+	rtt                time.Duration
+	lazy               bool // missed a deadline
+
+	received map[MessageType]bool
+}
+
+// ProtocolRunner drives a node through one Pedersen DKG ceremony among a
+// fixed set of participants:
+//
+//   - dealing: broadcast verification points and a proof of knowledge of
+//     the secret behind them, send each participant its secret shares;
+//     dealers whose proofs don't verify are disqualified
+//   - complaining: broadcast signed complaints against the dealers whose
+//     shares didn't verify
+//   - justifying: accused dealers reveal the disputed shares; those failing
+//     to are disqualified
+//   - extracting: qualified dealers reveal their public coefficients, from
+//     which the group public key is assembled
+//   - contesting: participants reveal the shares of the dealers whose
+//     public coefficients were missing or didn't match them
+//   - reconstructing: if anyone contested, participants reveal their
+//     shares of the dealers they find exposed, and the coefficients of the
+//     dealers they agree on are interpolated from them instead
+//
+// Phase i ends when every expected message arrived, or at the latest i
+// timeouts of the node after the ceremony started, unless the timeouts are
+// calibrated with CalibrateTimeouts. What happens to the participants
+// still missing then is up to the Liveness policy. The ceremony aborts when
+// its context is done.
+type ProtocolRunner struct {
+	node      *Node
+	transport Transport
+	checker   *ConformanceChecker
+	random    io.Reader
+
+	self         *participant
+	participants []*participant
+	byID         map[string]*participant
+	pending      []Message
+	qualified    []*participant
+	start        time.Time
+	ctx          context.Context
+
+	policy    *TimeoutPolicy
+	handshake time.Duration
+	nonce     uint64
+	pinged    time.Time
+	retries   int
+	liveness  LivenessPolicy
+	workers   int
+	served    map[string]uint64
+	agreement AgreementPolicy
+	// an Engine's runner collects its messages for the caller to send
+	stepped  bool
+	outgoing []Message
+
+	instrumentation Instrumentation
+	logger          *slog.Logger
+	tracer          Tracer
+	ceremony        Span
+	span            Span // of the current phase
+	phaseStart      time.Time
+	subscribers     []func(Event)
+
+	mu       sync.Mutex
+	journal  []Message
+	timeouts []time.Duration // per phase, once calibrated
+	result   *KeyShare
+	err      error
+	faults   []error
+	traffic  map[trafficKey]*Traffic
+}
+
+// NewProtocolRunner prepares a ceremony for node among participants, which
+// must include the node itself and match the node's curve and threshold.
+func NewProtocolRunner(node *Node, participants *ParticipantSet, transport Transport) (*ProtocolRunner, error) {
+	if participants.curve != node.curve {
+		return nil, CurveMismatchError{node.curve, participants.curve}
+	}
+	if participants.threshold != node.Threshold() {
+		return nil, InvalidThresholdError{node.Threshold(), participants.Len()}
+	}
+
+	r := &ProtocolRunner{
+		node:      node,
+		transport: transport,
+		checker:   NewConformanceChecker(),
+		random:    node.random,
+		byID:      make(map[string]*participant),
+		served:    make(map[string]uint64),
+		traffic:   make(map[trafficKey]*Traffic),
+
+		instrumentation: nopInstrumentation{},
+		logger:          node.logger,
+	}
+	for _, p := range participants.participants {
+		state := &participant{id: p.ID, key: p.Key, received: make(map[MessageType]bool)}
+		r.participants = append(r.participants, state)
+		r.byID[r.key(p.ID)] = state
+	}
+	self, ok := r.byID[r.key(node.id)]
+	if !ok {
+		return nil, UnknownParticipantError{node.id}
+	}
+	r.self = self
+	return r, nil
+}
+
+// ResumeProtocolRunner prepares the ceremony of a node that restarted
+// mid-ceremony, from the state its previous runner was in. The node replays
+// the messages it received and sends its own messages again; the other
+// participants ignore the duplicates.
+func ResumeProtocolRunner(node *Node, participants *ParticipantSet, transport Transport, state RunnerState) (*ProtocolRunner, error) {
+	if node.inMemory {
+		return nil, InMemoryOnlyError{"ceremony"}
+	}
+	r, err := NewProtocolRunner(node, participants, transport)
+	if err != nil {
+		return nil, err
+	}
+	r.start = state.Start
+	r.timeouts = append([]time.Duration(nil), state.Timeouts...)
+	r.pending = append([]Message(nil), state.Received...)
+	return r, nil
+}
+
+// State returns the runner's progress, to be persisted for resuming the
+// ceremony after a restart. The runners of in-memory only nodes keep no
+// journal, so their state holds no messages.
+func (r *ProtocolRunner) State() RunnerState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return RunnerState{r.start, append([]Message(nil), r.journal...), append([]time.Duration(nil), r.timeouts...)}
+}
+
+// key identifies participants by their ID mod N, the point their shares
+// are evaluated at.
+func (r *ProtocolRunner) key(id *big.Int) string {
+	return new(big.Int).Mod(id, r.node.curve.Params().N).String()
+}
+
+func (r *ProtocolRunner) Phase() Phase {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.checker.Phase()
+}
+
+// Result returns the node's key share once the ceremony finished.
+func (r *ProtocolRunner) Result() (*KeyShare, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.result == nil {
+		return nil, CeremonyIncompleteError{r.checker.Phase()}
+	}
+	return r.result, nil
+}
+
+// ParticipantPublicShares returns the public shares of all participants,
+// in order, once the ceremony finished: the directory against which their
+// partial signatures, decryptions and VRF evaluations verify one by one.
+// Disqualified participants have shares of the group key too.
+func (r *ProtocolRunner) ParticipantPublicShares() ([]PublicShare, error) {
+	result, err := r.Result()
+	if err != nil {
+		return nil, err
+	}
+	group := result.Group()
+	shares := make([]PublicShare, len(r.participants))
+	for i, p := range r.participants {
+		shares[i] = group.PublicShare(p.id)
+	}
+	return shares, nil
+}
+
+// Run executes the ceremony, returning when it finished or was aborted.
+func (r *ProtocolRunner) Run() error {
+	return r.RunContext(context.Background())
+}
+
+// RunContext executes the ceremony like Run, aborting it with ctx's error
+// once ctx is done. A deadline of ctx cuts the phase it falls into short.
+func (r *ProtocolRunner) RunContext(ctx context.Context) error {
+	r.ctx = ctx
+	if r.tracer != nil {
+		r.ctx, r.ceremony = r.tracer.Start(ctx, "dkg.ceremony")
+	}
+	stop := make(chan struct{})
+	pumped := make(chan struct{})
+	go func() {
+		r.pump(stop)
+		close(pumped)
+	}()
+
+	err := r.calibrate()
+	if err == nil {
+		r.mu.Lock()
+		if r.start.IsZero() {
+			r.start = time.Now()
+		}
+		r.mu.Unlock()
+		err = r.run()
+	}
+	r.wipe()
+
+	close(stop)
+	<-pumped
+	r.flush()
+
+	r.mu.Lock()
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = r.timedOut(err)
+	}
+	phase := r.checker.Phase()
+	if err != nil {
+		r.err = err
+		r.checker.Transition(PhaseAborted)
+	}
+	result := r.result
+	r.mu.Unlock()
+
+	r.phaseDone(phase, err)
+	r.instrumentation.CeremonyDone(err)
+	r.emit(CeremonyComplete{result, err})
+	if r.ceremony != nil {
+		r.ceremony.End(err)
+	}
+	return err
+}
+
+// Faults returns the failures the runner blamed on other participants so
+// far, in the order it noticed them: InvalidShareError, ComplaintError,
+// TimeoutError and ProtocolViolationError values. Participants left out of
+// the group key are among them, but not every fault disqualifies.
+func (r *ProtocolRunner) Faults() []error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]error(nil), r.faults...)
+}
+
+func (r *ProtocolRunner) fault(err error) {
+	r.logFault(err)
+	r.mu.Lock()
+	r.faults = append(r.faults, err)
+	r.mu.Unlock()
+}
+
+// timedOut wraps the error of a context whose deadline aborted the
+// ceremony in the TimeoutError of the phase it cut short. r.mu is held.
+func (r *ProtocolRunner) timedOut(err error) error {
+	phase := r.checker.Phase()
+	for i := len(r.faults) - 1; i >= 0; i-- {
+		if t, ok := r.faults[i].(TimeoutError); ok && t.phase == phase {
+			t.err = err
+			return t
+		}
+	}
+	return TimeoutError{phase, nil, err}
+}
+
+func (r *ProtocolRunner) run() error {
+	if err := r.deal(); err != nil {
+		return err
+	}
+	if err := r.awaitPhase(r.participants, dealingTypes...); err != nil {
+		return err
+	}
+	if err := r.complain(); err != nil {
+		return err
+	}
+	if err := r.awaitPhase(r.participants, ComplaintsMessage); err != nil {
+		return err
+	}
+	accusations := r.accusations()
+	disqualified := make(map[string]error)
+	if len(accusations) > 0 {
+		dealers, err := r.justify(accusations)
+		if err != nil {
+			return err
+		}
+		if err := r.awaitPhase(dealers, JustificationMessage); err != nil {
+			return err
+		}
+		disqualified = r.judge(dealers, accusations)
+	}
+	self, err := r.qualify(disqualified)
+	if err != nil {
+		return err
+	}
+	if err := r.extract(self); err != nil {
+		return err
+	}
+	if err := r.awaitPhase(r.qualified, PublicCoefficientsMessage); err != nil {
+		return err
+	}
+	if r.agreement != NoAgreement {
+		if err := r.awaitPhase(r.participants, QualifiedSetMessage); err != nil {
+			return err
+		}
+	}
+	if err := r.contest(); err != nil {
+		return err
+	}
+	if err := r.awaitPhase(r.live(), CoefficientComplaintsMessage); err != nil {
+		return err
+	}
+	if r.contested() {
+		exposed := r.exposed()
+		if err := r.reconstruct(exposed); err != nil {
+			return err
+		}
+		if err := r.awaitPhase(r.live(), ReconstructionMessage); err != nil {
+			return err
+		}
+		exposed, err := r.agreeOnExposed(exposed)
+		if err != nil {
+			return err
+		}
+		if err := r.recoverCoefficients(exposed); err != nil {
+			return err
+		}
+	}
+	last := r.Phase()
+	if err := r.finish(); err != nil {
+		return err
+	}
+	r.linger(last)
+	return nil
+}
+
+// dealingTypes are the messages every dealer sends while dealing.
+var dealingTypes = []MessageType{VerificationPointsMessage, SecretSharesMessage, SecretKnowledgeMessage}
+
+// qualify disqualifies the dealers whose proofs of knowledge don't verify,
+// besides those already disqualified, and reports whether the node itself
+// is.
+func (r *ProtocolRunner) qualify(disqualified map[string]error) (bool, error) {
+	known := r.verifyAllKnowledge()
+	for i, p := range r.participants {
+		if known[i] {
+			continue
+		}
+		var reason error = TimeoutError{PhaseDealing, []*big.Int{p.id}, nil}
+		if p.verificationPoints != nil && p.knowledgeProof != nil {
+			reason = ProtocolViolationError{p.id, InvalidPayloadError{SecretKnowledgeMessage}}
+			r.fault(reason)
+		}
+		if _, ok := disqualified[r.key(p.id)]; !ok {
+			disqualified[r.key(p.id)] = reason
+		}
+	}
+	for _, p := range r.participants {
+		if reason, ok := disqualified[r.key(p.id)]; ok {
+			r.emit(ParticipantDisqualified{p.id, reason})
+		} else {
+			r.qualified = append(r.qualified, p)
+		}
+	}
+	if len(r.qualified) == 0 {
+		return false, NoQualifiedDealersError{}
+	}
+	_, self := disqualified[r.key(r.self.id)]
+	return self, nil
+}
+
+func (r *ProtocolRunner) deal() error {
+	n := r.node
+	if err := r.transition(PhaseDealing); err != nil {
+		return err
+	}
+	if err := n.claimPolynomials(r.participants); err != nil {
+		return err
+	}
+	r.self.verificationPoints = n.VerificationPoints()
+	r.send(nil, VerificationPointsMessage, r.self.verificationPoints)
+	proof, err := n.ProveSecretKnowledge(r.random)
+	if err != nil {
+		return err
+	}
+	r.self.knowledgeProof = &proof
+	r.send(nil, SecretKnowledgeMessage, proof)
+	for _, p := range r.participants {
+		if p == r.self {
+			shares := n.sharesFor(p.id)
+			p.secretShare1, p.secretShare2 = shares.Share1, shares.Share2
+			continue
+		}
+		ciphertext, err := r.EncryptShareFor(p.id)
+		if err != nil {
+			return err
+		}
+		r.send(p.id, SecretSharesMessage, EncryptedShares{ciphertext})
+	}
+	return nil
+}
+
+// complain broadcasts this node's signed complaints against the dealers
+// whose shares don't verify.
+func (r *ProtocolRunner) complain() error {
+	if err := r.transition(PhaseComplaining); err != nil {
+		return err
+	}
+	errs, err := r.verifyAllShares()
+	if err != nil {
+		return err
+	}
+	var accused []*big.Int
+	for _, p := range r.participants {
+		if err := errs[p]; err != nil {
+			accused = append(accused, p.id)
+			r.instrumentation.ComplaintRaised(p.id)
+			r.emit(ComplaintFiled{r.self.id, p.id})
+			if p.received[SecretSharesMessage] {
+				r.fault(InvalidShareError{p.id, err})
+			}
+		}
+	}
+	complaints, err := r.node.signComplaints(accused)
+	if err != nil {
+		return err
+	}
+	r.self.complaints = &complaints
+	r.send(nil, ComplaintsMessage, complaints)
+	return nil
+}
+
+// accusations returns the accusers of every accused dealer, from the
+// complaints with valid signatures, once complaining is over.
+func (r *ProtocolRunner) accusations() map[string][]*participant {
+	accusations := make(map[string][]*participant)
+	for _, accuser := range r.participants {
+		if accuser.complaints == nil {
+			continue
+		}
+		for _, id := range accuser.complaints.Accused {
+			if dealer, ok := r.byID[r.key(id)]; ok && dealer != accuser {
+				accusations[r.key(id)] = append(accusations[r.key(id)], accuser)
+			}
+		}
+	}
+	return accusations
+}
+
+// justify reveals the shares this node's accusers dispute, if any, and
+// returns the accused dealers.
+func (r *ProtocolRunner) justify(accusations map[string][]*participant) ([]*participant, error) {
+	n := r.node
+	if err := r.transition(PhaseJustifying); err != nil {
+		return nil, err
+	}
+
+	var dealers []*participant
+	for _, p := range r.participants {
+		if _, ok := accusations[r.key(p.id)]; ok {
+			dealers = append(dealers, p)
+		}
+	}
+
+	if accusers, ok := accusations[r.key(r.self.id)]; ok {
+		justification := &Justification{}
+		for _, accuser := range accusers {
+			justification.Revealed = append(justification.Revealed, RevealedShares{accuser.id, n.sharesFor(accuser.id)})
+		}
+		r.self.justification = justification
+		r.send(nil, JustificationMessage, *justification)
+	}
+	return dealers, nil
+}
+
+// judge returns the accused dealers to disqualify: those with more than
+// threshold accusers, and those whose revealed shares are missing or don't
+// verify. Valid revealed shares replace the ones this node complained
+// about.
+func (r *ProtocolRunner) judge(dealers []*participant, accusations map[string][]*participant) map[string]error {
+	disqualified := make(map[string]error)
+	for _, dealer := range dealers {
+		accusers := accusations[r.key(dealer.id)]
+		ids := make([]*big.Int, len(accusers))
+		for i, accuser := range accusers {
+			ids[i] = accuser.id
+		}
+		if len(accusers) > r.node.Threshold() || dealer.justification == nil {
+			disqualified[r.key(dealer.id)] = ComplaintError{dealer.id, ids, nil}
+			r.fault(disqualified[r.key(dealer.id)])
+			continue
+		}
+		revealed := make(map[string]SecretShares)
+		for _, rs := range dealer.justification.Revealed {
+			revealed[r.key(rs.Recipient)] = rs.SecretShares
+		}
+		for _, accuser := range accusers {
+			shares, ok := revealed[r.key(accuser.id)]
+			var err error = ShareVerificationError{dealer.id, "revealed share presence"}
+			if ok {
+				err = r.verifySharesFor(dealer, accuser.id, shares)
+			}
+			if err != nil {
+				disqualified[r.key(dealer.id)] = ComplaintError{dealer.id, ids, err}
+				r.fault(disqualified[r.key(dealer.id)])
+				break
+			}
+			if accuser == r.self {
+				// copied, the journal holds on to the justification
+				zeroize(dealer.secretShare1, dealer.secretShare2)
+				dealer.secretShare1 = new(big.Int).Set(shares.Share1)
+				dealer.secretShare2 = new(big.Int).Set(shares.Share2)
+			}
+		}
+	}
+	return disqualified
+}
+
+// extract has the node reveal its public coefficients, unless it is
+// disqualified.
+func (r *ProtocolRunner) extract(disqualified bool) error {
+	if err := r.transition(PhaseExtracting); err != nil {
+		return err
+	}
+	// disqualified dealers keep quiet, their shares are not used
+	if !disqualified {
+		r.self.publicCoefficients = r.node.PublicCoefficients()
+		r.send(nil, PublicCoefficientsMessage, r.self.publicCoefficients)
+	}
+	if r.agreement != NoAgreement {
+		view, err := r.node.signQualifiedSet(r.qualifiedIDs())
+		if err != nil {
+			return err
+		}
+		r.self.qualifiedSet = &view
+		r.send(nil, QualifiedSetMessage, view)
+	}
+	return nil
+}
+
+// contest reveals the shares dealt to this node by the qualified dealers
+// whose public coefficients are missing or don't match them, once the
+// participants agree on the qualified dealers.
+func (r *ProtocolRunner) contest() error {
+	if err := r.agree(); err != nil {
+		return err
+	}
+	if err := r.transition(PhaseContesting); err != nil {
+		return err
+	}
+	params := r.node.params()
+	contest := &Exposure{}
+	for _, p := range r.qualified {
+		if p != r.self && p.secretShare1 != nil && !params.matchesCoefficients(p.publicCoefficients, r.node.id, p.secretShare1) {
+			contest.Exposed = append(contest.Exposed, r.exposedShares(p))
+		}
+	}
+	r.self.contest = contest
+	r.send(nil, CoefficientComplaintsMessage, *contest)
+	return nil
+}
+
+// contested reports whether any participant, this node included,
+// contested a dealer. All participants that received the same contests
+// then go on reconstructing, so that none waits for the others' shares in
+// vain.
+func (r *ProtocolRunner) contested() bool {
+	for _, p := range r.participants {
+		if p.contest != nil && len(p.contest.Exposed) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// exposed returns the qualified dealers whose public coefficients this
+// node finds are to be reconstructed, once contesting is over: those whose
+// coefficients it misses or that don't match its shares, such as the
+// dealers it left out as lazy while extracting, and those contested with
+// shares that verify against their verification points but don't match
+// their coefficients either.
+func (r *ProtocolRunner) exposed() []*participant {
+	params := r.node.params()
+	contested := make(map[*participant]bool)
+	for _, accuser := range r.participants {
+		if accuser == r.self || accuser.contest == nil {
+			continue
+		}
+		for _, es := range accuser.contest.Exposed {
+			dealer, ok := r.byID[r.key(es.Dealer)]
+			if !ok || dealer == accuser || contested[dealer] {
+				continue
+			}
+			if r.verifySharesFor(dealer, accuser.id, es.SecretShares) != nil {
+				r.fault(ProtocolViolationError{accuser.id, InvalidPayloadError{CoefficientComplaintsMessage}})
+				continue
+			}
+			contested[dealer] = !params.matchesCoefficients(dealer.publicCoefficients, accuser.id, es.Share1)
+		}
+	}
+	var exposed []*participant
+	for _, p := range r.qualified {
+		if p == r.self {
+			continue
+		}
+		if contested[p] || !params.matchesCoefficients(p.publicCoefficients, r.node.id, p.secretShare1) {
+			r.fault(ExtractionError{p.id})
+			exposed = append(exposed, p)
+		}
+	}
+	return exposed
+}
+
+// reconstruct reveals the shares dealt to this node by the dealers it
+// finds exposed, which is its view of the dealers to reconstruct, even if
+// there are none.
+func (r *ProtocolRunner) reconstruct(exposed []*participant) error {
+	if err := r.transition(PhaseReconstructing); err != nil {
+		return err
+	}
+	reconstruction := &Exposure{}
+	for _, p := range exposed {
+		if p.secretShare1 != nil {
+			reconstruction.Exposed = append(reconstruction.Exposed, r.exposedShares(p))
+		}
+	}
+	r.self.reconstruction = reconstruction
+	r.send(nil, ReconstructionMessage, *reconstruction)
+	return nil
+}
+
+// recoverCoefficients replaces the public coefficients of the exposed
+// dealers with the ones interpolated from the shares the participants
+// revealed, this node's included.
+func (r *ProtocolRunner) recoverCoefficients(exposed []*participant) error {
+	params := r.node.params()
+	for _, dealer := range exposed {
+		var revealed []RevealedShares
+		if dealer.secretShare1 != nil {
+			revealed = append(revealed, RevealedShares{r.node.id, SecretShares{dealer.secretShare1, dealer.secretShare2, r.node.curve}})
+		}
+		for _, p := range r.participants {
+			for _, exposure := range []*Exposure{p.contest, p.reconstruction} {
+				if p == r.self || exposure == nil {
+					continue
+				}
+				for _, es := range exposure.Exposed {
+					if r.key(es.Dealer) == r.key(dealer.id) {
+						revealed = append(revealed, RevealedShares{p.id, es.SecretShares})
+					}
+				}
+			}
+		}
+		coefficients, err := params.reconstructCoefficients(dealer.id, dealer.verificationPoints, revealed)
+		if err != nil {
+			return err
+		}
+		dealer.publicCoefficients = coefficients
+	}
+	return nil
+}
+
+// exposedShares copies the shares p dealt to this node, for revealing.
+func (r *ProtocolRunner) exposedShares(p *participant) ExposedShares {
+	return ExposedShares{p.id, SecretShares{
+		new(big.Int).Set(p.secretShare1), new(big.Int).Set(p.secretShare2), r.node.curve,
+	}}
+}
+
+// finish assembles the result from the qualified dealers' public
+// coefficients.
+func (r *ProtocolRunner) finish() error {
+	result, err := r.assemble()
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.result = result
+	r.mu.Unlock()
+	return r.transition(PhaseFinished)
+}
+
+func (r *ProtocolRunner) transition(to Phase) error {
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	from := r.checker.Phase()
+	err := r.checker.Transition(to)
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	r.phaseDone(from, nil)
+	r.phaseStarted(to)
+
+	pending := r.pending
+	r.pending = nil
+	for _, m := range pending {
+		r.receive(m)
+	}
+	return nil
+}
+
+func (r *ProtocolRunner) send(to *big.Int, t MessageType, payload Hashable) {
+	m := Message{t, r.node.id, to, payload}
+	if r.stepped {
+		r.outgoing = append(r.outgoing, m)
+		return
+	}
+	r.node.outbox.Push(m)
+}
+
+func (r *ProtocolRunner) deliver(m Message) {
+	r.sent(m)
+	// transport errors surface as missing messages and complaints on the
+	// receiving side
+	if m.To == nil {
+		r.transport.Broadcast(m)
+	} else {
+		r.transport.Send(m.To, m)
+	}
+}
+
+func (r *ProtocolRunner) pump(stop <-chan struct{}) {
+	for {
+		select {
+		case m := <-r.node.outbox.Messages():
+			r.deliver(m)
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (r *ProtocolRunner) flush() {
+	for {
+		select {
+		case m := <-r.node.outbox.Messages():
+			r.deliver(m)
+		default:
+			return
+		}
+	}
+}
+
+// missing returns the IDs of the participants in ps other than the node
+// itself that didn't send messages of all types ts yet.
+func (r *ProtocolRunner) missing(ps []*participant, ts ...MessageType) []*big.Int {
+	var ids []*big.Int
+	for _, p := range ps {
+		for _, t := range ts {
+			if p != r.self && !p.received[t] {
+				ids = append(ids, p.id)
+				break
+			}
+		}
+	}
+	return ids
+}
+
+// await processes incoming messages until every participant in ps other
+// than the node itself sent messages of all types ts, or deadline passed,
+// and reports whether they did.
+func (r *ProtocolRunner) await(deadline time.Time, ps []*participant, ts ...MessageType) bool {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	for len(r.missing(ps, ts...)) > 0 {
+		select {
+		case m, ok := <-r.transport.Receive():
+			if !ok {
+				return false
+			}
+			r.received(m)
+		case <-timer.C:
+			return false
+		case <-r.ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// receive handles messages of the current phase, holds on to messages of
+// later phases and drops the rest.
+func (r *ProtocolRunner) receive(m Message) {
+	if m.From == nil {
+		return
+	}
+	p, ok := r.byID[r.key(m.From)]
+	if !ok || p == r.self {
+		return
+	}
+	switch m.Type {
+	case HelloMessage:
+		r.hello(p, m)
+		return
+	case RetryMessage:
+		r.retry(p, m)
+		return
+	}
+
+	r.mu.Lock()
+	current := r.checker.Phase()
+	accepted := r.checker.Accept(m.Type) == nil
+	r.mu.Unlock()
+	if !accepted {
+		switch phase := messagePhase(r.checker.spec, m.Type); {
+		case phase == PhaseAborted:
+			r.fault(ProtocolViolationError{p.id, UnexpectedMessageError{current, m.Type}})
+		case phase > current:
+			r.pending = append(r.pending, m)
+		}
+		return
+	}
+
+	// only the first message of each type counts, invalid ones leave the
+	// sender without a say in the phase
+	if p.received[m.Type] {
+		return
+	}
+	p.received[m.Type] = true
+	if !r.node.inMemory {
+		r.mu.Lock()
+		r.journal = append(r.journal, m)
+		r.mu.Unlock()
+	}
+
+	valid := false
+	switch m.Type {
+	case VerificationPointsMessage:
+		if vpts, ok := m.Payload.(PointTuple); ok && r.validPoints(vpts) {
+			p.verificationPoints, valid = vpts, true
+		}
+	case SecretKnowledgeMessage:
+		if proof, ok := m.Payload.(SecretKnowledgeProof); ok {
+			p.knowledgeProof, valid = &proof, true
+		}
+	case SecretSharesMessage:
+		encrypted, ok := m.Payload.(EncryptedShares)
+		if !ok {
+			break
+		}
+		// undecryptable shares are a complaint, not a violation
+		valid = true
+		if shares, err := r.DecryptShareFrom(p.id, encrypted.Ciphertext); err == nil {
+			p.secretShare1, p.secretShare2 = shares.Share1, shares.Share2
+			r.emit(ShareReceived{p.id})
+		} else {
+			p.shareErr = err
+		}
+	case ComplaintsMessage:
+		complaints, ok := m.Payload.(Complaints)
+		if ok && VerifyComplaints(r.node.hash, Participant{p.id, p.key}, complaints) {
+			p.complaints, valid = &complaints, true
+			for _, id := range complaints.Accused {
+				r.emit(ComplaintFiled{p.id, id})
+			}
+		}
+	case JustificationMessage:
+		if justification, ok := m.Payload.(Justification); ok {
+			p.justification, valid = &justification, true
+		}
+	case PublicCoefficientsMessage:
+		if pts, ok := m.Payload.(PointTuple); ok && r.validPoints(pts) {
+			p.publicCoefficients, valid = pts, true
+		}
+	case QualifiedSetMessage:
+		view, ok := m.Payload.(QualifiedSet)
+		if ok && VerifyQualifiedSet(r.node.hash, Participant{p.id, p.key}, view) {
+			p.qualifiedSet, valid = &view, true
+		}
+	case CoefficientComplaintsMessage:
+		if contest, ok := m.Payload.(Exposure); ok {
+			p.contest, valid = &contest, true
+		}
+	case ReconstructionMessage:
+		if reconstruction, ok := m.Payload.(Exposure); ok {
+			p.reconstruction, valid = &reconstruction, true
+		}
+	}
+	if !valid {
+		r.fault(ProtocolViolationError{p.id, InvalidPayloadError{m.Type}})
+	}
+}
+
+// EncryptShareFor returns the node's shares for participant id, encrypted
+// to that participant's identity key.
+func (r *ProtocolRunner) EncryptShareFor(id *big.Int) ([]byte, error) {
+	p, ok := r.byID[r.key(id)]
+	if !ok {
+		return nil, UnknownParticipantError{id}
+	}
+	n := r.node
+	shares := n.sharesFor(p.id)
+	defer shares.zeroize()
+	return encryptShares(n.curve, &p.key, n.id, p.id, shares, r.random)
+}
+
+// DecryptShareFrom decrypts the shares participant id encrypted for this
+// node.
+func (r *ProtocolRunner) DecryptShareFrom(id *big.Int, ciphertext []byte) (SecretShares, error) {
+	p, ok := r.byID[r.key(id)]
+	if !ok {
+		return SecretShares{}, UnknownParticipantError{id}
+	}
+	return decryptShares(r.node.curve, r.node.identity, p.id, r.node.id, ciphertext)
+}
+
+func (r *ProtocolRunner) validPoints(pts PointTuple) bool {
+	if len(pts) != r.node.Threshold()+1 {
+		return false
+	}
+	for _, pt := range pts {
+		if !isValidPoint(r.node.curve, pt.X, pt.Y) {
+			return false
+		}
+	}
+	return true
+}
+
+// verifyAllKnowledge checks every participant's proof of knowledge of the
+// secret behind its verification points. The challenges share the node's
+// hash and are computed first, the rest on the runner's workers.
+func (r *ProtocolRunner) verifyAllKnowledge() []bool {
+	params := r.node.params()
+	challenges := make([]*big.Int, len(r.participants))
+	for i, p := range r.participants {
+		vpts, proof := p.verificationPoints, p.knowledgeProof
+		if len(vpts) == 0 || proof == nil || proof.CommitX == nil {
+			continue
+		}
+		challenges[i] = params.knowledgeChallenge(p.id, vpts[0].X, vpts[0].Y, proof.CommitX, proof.CommitY)
+	}
+	known := make([]bool, len(r.participants))
+	parallelFor(r.workers, len(r.participants), func(i int) {
+		if p := r.participants[i]; challenges[i] != nil {
+			known[i] = params.verifySecretKnowledgeChallenge(p.verificationPoints, *p.knowledgeProof, challenges[i])
+		}
+	})
+	return known
+}
+
+// wipe erases the shares dealt to this node, once the ceremony is over.
+func (r *ProtocolRunner) wipe() {
+	for _, p := range r.participants {
+		zeroize(p.secretShare1, p.secretShare2)
+	}
+}
+
+// verifyShares checks the shares dealt by p to this node against p's
+// verification points.
+func (r *ProtocolRunner) verifyShares(p *participant) error {
+	if p.secretShare1 == nil {
+		if p.shareErr != nil {
+			return p.shareErr
+		}
+		return ShareVerificationError{p.id, "presence"}
+	}
+	return r.verifySharesFor(p, r.node.id, SecretShares{p.secretShare1, p.secretShare2, r.node.curve})
+}
+
+// verifyAllShares checks the shares dealt to this node by every
+// participant, in one batch per worker.
+func (r *ProtocolRunner) verifyAllShares() (map[*participant]error, error) {
+	errs := make(map[*participant]error)
+	var received []ReceivedShares
+	var dealers []*participant
+	for _, p := range r.participants {
+		if p.secretShare1 == nil {
+			errs[p] = r.verifyShares(p)
+			continue
+		}
+		received = append(received, ReceivedShares{p.id, SecretShares{p.secretShare1, p.secretShare2, r.node.curve}, p.verificationPoints})
+		dealers = append(dealers, p)
+	}
+	workers := max(min(r.workers, len(received)), 1)
+	random := r.random
+	if workers > 1 {
+		random = &lockedReader{r: random}
+	}
+	batches := make([][]error, workers)
+	failures := make([]error, workers)
+	params := r.node.params()
+	parallelFor(workers, workers, func(w int) {
+		chunk := received[w*len(received)/workers : (w+1)*len(received)/workers]
+		batches[w], failures[w] = params.verifySharesBatchFor(r.node.id, chunk, random)
+	})
+	for w, batch := range batches {
+		if failures[w] != nil {
+			return nil, failures[w]
+		}
+		offset := w * len(received) / workers
+		for i, err := range batch {
+			errs[dealers[offset+i]] = err
+		}
+	}
+	return errs, nil
+}
+
+// verifySharesFor checks shares dealt by p to id against p's verification
+// points.
+func (r *ProtocolRunner) verifySharesFor(p *participant, id *big.Int, shares SecretShares) error {
+	return r.node.params().verifyShareFor(p.id, id, shares, p.verificationPoints)
+}
+
+// assemble checks the qualified dealers' public coefficients, as revealed
+// or reconstructed, against the shares they dealt and sums both up.
+func (r *ProtocolRunner) assemble() (*KeyShare, error) {
+	curve := r.node.curve
+	n := curve.Params().N
+
+	f := scalarFieldFor(n)
+	share := f.element()
+	defer clear(share)
+	coefficients := make(PointTuple, r.node.Threshold()+1)
+	qualified := make([]*big.Int, len(r.qualified))
+	params := r.node.params()
+	for i, p := range r.qualified {
+		if !params.matchesCoefficients(p.publicCoefficients, r.node.id, p.secretShare1) {
+			return nil, ExtractionError{p.id}
+		}
+
+		f.addBig(share, p.secretShare1)
+		for k, pt := range p.publicCoefficients {
+			if i == 0 {
+				coefficients[k].X, coefficients[k].Y = pt.X, pt.Y
+			} else {
+				coefficients[k].X, coefficients[k].Y = curve.Add(coefficients[k].X, coefficients[k].Y, pt.X, pt.Y)
+			}
+		}
+		qualified[i] = p.id
+	}
+	sort.Slice(qualified, func(i, j int) bool { return qualified[i].Cmp(qualified[j]) < 0 })
+
+	result := &KeyShare{
+		ID:                 r.node.id,
+		Threshold:          r.node.Threshold(),
+		Qualified:          qualified,
+		PublicKey:          ecdsa.PublicKey{Curve: curve, X: coefficients[0].X, Y: coefficients[0].Y},
+		PublicCoefficients: coefficients,
+		Share:              f.toBig(share),
+		inMemory:           r.node.inMemory,
+	}
+	if result.inMemory {
+		if err := lockMemory(result.Share); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
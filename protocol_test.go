@@ -0,0 +1,302 @@
+package dkg
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"math/big"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mikalv/dkg/edwards25519"
+	"github.com/mikalv/dkg/secp256k1"
+)
+
+func randomPolynomialForTesting(t *testing.T, curve elliptic.Curve, threshold int) ScalarPolynomial {
+	poly, err := GenerateScalarPolynomial(curve, threshold, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return poly
+}
+
+func getCeremonyNodesForTesting(t *testing.T, size, threshold int) ([]*Node, []Participant) {
+	curve, _, g2x, g2y, _, _, _, _, _, _ := getValidNodeParamsForTesting(t)
+	return getCeremonyNodesOnCurveForTesting(t, curve, g2x, g2y, size, threshold)
+}
+
+func getCeremonyNodesOnCurveForTesting(t *testing.T, curve elliptic.Curve, g2x, g2y *big.Int, size, threshold int) ([]*Node, []Participant) {
+	_, _, _, _, zkParam, _, _, _, _, _ := getValidNodeParamsForTesting(t)
+
+	nodes := make([]*Node, size)
+	participants := make([]Participant, size)
+	for i := range nodes {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id := big.NewInt(int64(i + 1))
+		node, err := NewNode(
+			curve, sha512.New512_256(), g2x, g2y, zkParam, 200*time.Millisecond,
+			id, *key,
+			randomPolynomialForTesting(t, curve, threshold),
+			randomPolynomialForTesting(t, curve, threshold),
+		)
+		if err != nil {
+			t.Fatalf("Could not create node %v: %v", id, err)
+		}
+		nodes[i] = node
+		participants[i] = Participant{id, key.PublicKey}
+	}
+	return nodes, participants
+}
+
+// runCeremonyForTesting runs the nodes' ceremonies concurrently over an
+// in-memory network and returns their results, nil for failed runs. Each
+// node's transport is passed through wrap, if given.
+func runCeremonyForTesting(t *testing.T, nodes []*Node, participants []Participant, wrap ...func(*Node, Transport) Transport) []*KeyShare {
+	network := NewMemoryNetwork()
+	runners := make([]*ProtocolRunner, len(nodes))
+	for i, node := range nodes {
+		transport := network.Transport(node.ID())
+		defer transport.Close()
+		for _, w := range wrap {
+			transport = w(node, transport)
+		}
+		set, err := NewParticipantSet(node.curve, node.Threshold(), participants)
+		if err != nil {
+			t.Fatalf("Invalid participants: %v", err)
+		}
+		runner, err := NewProtocolRunner(node, set, transport)
+		if err != nil {
+			t.Fatalf("Could not create runner for %v: %v", node.ID(), err)
+		}
+		runners[i] = runner
+	}
+
+	var wg sync.WaitGroup
+	for _, runner := range runners {
+		wg.Add(1)
+		go func(r *ProtocolRunner) {
+			defer wg.Done()
+			r.Run()
+		}(runner)
+	}
+	wg.Wait()
+
+	results := make([]*KeyShare, len(runners))
+	for i, runner := range runners {
+		results[i], _ = runner.Result()
+	}
+	return results
+}
+
+func checkCeremonyResultsForTesting(t *testing.T, results []*KeyShare) {
+	var first *KeyShare
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		if first == nil {
+			first = result
+			continue
+		}
+		if result.PublicKey.X.Cmp(first.PublicKey.X) != 0 || result.PublicKey.Y.Cmp(first.PublicKey.Y) != 0 {
+			t.Errorf("Nodes %v and %v disagree on the group key", first.ID, result.ID)
+		}
+		if len(result.Qualified) != len(first.Qualified) {
+			t.Errorf("Nodes %v and %v disagree on the qualified set: %v != %v", first.ID, result.ID, first.Qualified, result.Qualified)
+		}
+	}
+	if first == nil {
+		t.Fatalf("No node finished the ceremony")
+	}
+
+	curve := first.PublicKey.Curve
+	n := curve.Params().N
+	var quorum []*KeyShare
+	for _, result := range results {
+		if result != nil && len(quorum) <= first.Threshold {
+			quorum = append(quorum, result)
+		}
+	}
+	if len(quorum) <= first.Threshold {
+		t.Fatalf("Only %v nodes finished the ceremony", len(quorum))
+	}
+	xs := make([]*big.Int, len(quorum))
+	for i, share := range quorum {
+		xs[i] = share.ID
+	}
+	secret := new(big.Int)
+	for _, share := range quorum {
+		secret.Add(secret, new(big.Int).Mul(lagrangeCoefficient(share.ID, xs, n), share.Share))
+	}
+	secret.Mod(secret, n)
+	x, y := curve.ScalarBaseMult(secret.Bytes())
+	if x.Cmp(first.PublicKey.X) != 0 || y.Cmp(first.PublicKey.Y) != 0 {
+		t.Errorf("Shares of %v don't reconstruct the group key", xs)
+	}
+}
+
+func TestProtocolRunner(t *testing.T) {
+	t.Run("All honest", func(t *testing.T) {
+		nodes, participants := getCeremonyNodesForTesting(t, 5, 2)
+		results := runCeremonyForTesting(t, nodes, participants)
+		for i, result := range results {
+			if result == nil {
+				t.Errorf("Node %v did not finish", nodes[i].ID())
+			} else if len(result.Qualified) != len(nodes) {
+				t.Errorf("Node %v qualified only %v", nodes[i].ID(), result.Qualified)
+			}
+		}
+		checkCeremonyResultsForTesting(t, results)
+	})
+
+	t.Run("Absent participant", func(t *testing.T) {
+		nodes, participants := getCeremonyNodesForTesting(t, 5, 2)
+		results := runCeremonyForTesting(t, nodes[:4], participants)
+		for i, result := range results {
+			if result == nil {
+				t.Errorf("Node %v did not finish", nodes[i].ID())
+			} else if len(result.Qualified) != 4 {
+				t.Errorf("Node %v qualified %v", nodes[i].ID(), result.Qualified)
+			}
+		}
+		checkCeremonyResultsForTesting(t, results)
+	})
+
+	t.Run("secp256k1", func(t *testing.T) {
+		curve := secp256k1.S256()
+		// a g2 with known discrete log is fine for testing
+		k, _ := randomScalar(curve.Params().N, rand.Reader)
+		g2x, g2y := curve.ScalarBaseMult(k.Bytes())
+
+		nodes, participants := getCeremonyNodesOnCurveForTesting(t, curve, g2x, g2y, 4, 1)
+		// the math/big arithmetic is slow, especially under the race detector
+		for _, node := range nodes {
+			node.timeout = 5 * time.Second
+		}
+		results := runCeremonyForTesting(t, nodes, participants)
+		for i, result := range results {
+			if result == nil {
+				t.Errorf("Node %v did not finish", nodes[i].ID())
+			}
+		}
+		checkCeremonyResultsForTesting(t, results)
+	})
+
+	t.Run("edwards25519", func(t *testing.T) {
+		curve := edwards25519.Curve()
+		k, _ := randomScalar(curve.Params().N, rand.Reader)
+		g2x, g2y := curve.ScalarBaseMult(k.Bytes())
+
+		nodes, participants := getCeremonyNodesOnCurveForTesting(t, curve, g2x, g2y, 4, 1)
+		for _, node := range nodes {
+			node.timeout = 5 * time.Second
+		}
+		results := runCeremonyForTesting(t, nodes, participants)
+		for i, result := range results {
+			if result == nil {
+				t.Errorf("Node %v did not finish", nodes[i].ID())
+			}
+		}
+		checkCeremonyResultsForTesting(t, results)
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+		node := nodes[0]
+		node.timeout = time.Minute
+		set, _ := NewParticipantSet(node.curve, node.Threshold(), participants)
+		transport := NewMemoryNetwork().Transport(node.ID())
+		defer transport.Close()
+		runner, err := NewProtocolRunner(node, set, transport)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the other participants never show up
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		err = runner.RunContext(ctx)
+		if !errors.Is(err, context.DeadlineExceeded) || CodeOf(err) != CodeTimeout {
+			t.Errorf("Got unexpected error from canceled ceremony: %v", err)
+		}
+		if missing := ParticipantsOf(err); len(missing) != 2 {
+			t.Errorf("Timeout blamed %v", missing)
+		}
+		if runner.Phase() != PhaseAborted {
+			t.Errorf("Canceled ceremony ended in %v phase", runner.Phase())
+		}
+	})
+
+	t.Run("Invalid roster", func(t *testing.T) {
+		nodes, participants := getCeremonyNodesForTesting(t, 3, 2)
+		node := nodes[0]
+		transport := NewMemoryNetwork().Transport(node.ID())
+		if _, err := NewParticipantSet(node.curve, 2, participants[:2]); reflect.TypeOf(err) != reflect.TypeOf(InvalidThresholdError{}) {
+			t.Errorf("Got unexpected error for fewer participants than the threshold requires: %v", err)
+		}
+		set, _ := NewParticipantSet(node.curve, 1, participants)
+		if _, err := NewProtocolRunner(node, set, transport); reflect.TypeOf(err) != reflect.TypeOf(InvalidThresholdError{}) {
+			t.Errorf("Got unexpected error for a roster with another threshold: %v", err)
+		}
+		set, _ = NewParticipantSet(secp256k1.S256(), 2, participants)
+		if _, err := NewProtocolRunner(node, set, transport); reflect.TypeOf(err) != reflect.TypeOf(CurveMismatchError{}) {
+			t.Errorf("Got unexpected error for a roster over another curve: %v", err)
+		}
+		others := append(participants[1:], Participant{big.NewInt(4), participants[0].Key})
+		set, _ = NewParticipantSet(node.curve, 2, others)
+		if _, err := NewProtocolRunner(node, set, transport); reflect.TypeOf(err) != reflect.TypeOf(UnknownParticipantError{}) {
+			t.Errorf("Got unexpected error for a node missing from the roster: %v", err)
+		}
+	})
+}
+
+func TestParticipantPublicShares(t *testing.T) {
+	sim, err := NewSimulator(elliptic.P256(), 4, 1, 500*time.Millisecond, []byte("dkg public shares test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the silent dealer is disqualified, but holds a share of the group key
+	sim.Misbehave(big.NewInt(4), Drop(VerificationPointsMessage))
+	results, err := sim.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[3] == nil || len(results[0].Qualified) != 3 {
+		t.Fatalf("Silent dealer finished: %v, qualified dealers: %v", results[3] != nil, results[0].Qualified)
+	}
+	shares, err := sim.Runners()[0].ParticipantPublicShares()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shares) != len(sim.Participants) {
+		t.Fatalf("Got %v public shares for %v participants", len(shares), len(sim.Participants))
+	}
+	curve := elliptic.P256()
+	for i, share := range shares {
+		if share.ID.Cmp(sim.Participants[i].ID) != 0 {
+			t.Errorf("Public share %v is of %v", i, share.ID)
+		}
+		x, y := curve.ScalarBaseMult(results[i].Share.Bytes())
+		if x.Cmp(share.X) != 0 || y.Cmp(share.Y) != 0 {
+			t.Errorf("Public share of %v doesn't match its share", share.ID)
+		}
+		if !reflect.DeepEqual(share, results[0].Group().PublicShare(share.ID)) {
+			t.Errorf("Public share of %v differs from the group key's", share.ID)
+		}
+	}
+
+	idle, _ := NewSimulator(elliptic.P256(), 2, 1, time.Second, []byte("idle"))
+	set, _ := NewParticipantSet(curve, 1, idle.Participants)
+	runner, _ := NewProtocolRunner(idle.Nodes[0], set, NewMemoryNetwork().Transport(idle.Nodes[0].ID()))
+	if _, err := runner.ParticipantPublicShares(); !reflect.DeepEqual(err, CeremonyIncompleteError{PhaseIdle}) {
+		t.Errorf("Got unexpected error before the ceremony: %v", err)
+	}
+}
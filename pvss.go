@@ -0,0 +1,468 @@
+package dkg
+
+import "crypto/ecdsa"
+import "crypto/elliptic"
+import "crypto/sha256"
+import "io"
+import "math/big"
+import "sort"
+
+// Publicly verifiable secret sharing runs a ceremony over a public channel,
+// such as a bulletin board, without a complaint round. Every participant
+// posts a dealing of DealPVSS: Feldman commitments to a random polynomial
+// and everyone's share of it, encrypted to their identity keys with proofs
+// that the ciphertexts hold the shares the commitments promise. Anyone can
+// check a dealing with VerifyPVSSDealing, and the valid dealings of distinct
+// participants make up the group key. Everyone must combine the same
+// dealings, such as those posted before a deadline, in any order.
+//
+// The identity keys must be on the ceremony's curve: shares are encrypted
+// bit by bit with ElGamal in the exponent, bit b as R = r * G and
+// C = b * G + r * P for the recipient's identity key P.
+
+// PVSSDealing is a dealer's contribution to a publicly verifiable ceremony,
+// with the shares in the order of the participant set.
+type PVSSDealing struct {
+	Curve       elliptic.Curve
+	Dealer      *big.Int
+	Commitments PointTuple
+	Shares      []PVSSShare
+}
+
+// PVSSShare is the share of participant To, encrypted bit by bit, least
+// significant first. Challenge and Response prove that
+// sum(2^j * C_j) - S = r * P for r * G = sum(2^j * R_j), S being the
+// participant's public share: that the bits are those of its share.
+type PVSSShare struct {
+	To                  *big.Int
+	Bits                []PVSSBit
+	Challenge, Response *big.Int
+}
+
+// PVSSBit is an encrypted bit with a proof that it is 0 or 1: that one of
+// (R, C) and (R, C - G) is r * (G, P), the other proof being simulated.
+type PVSSBit struct {
+	RX, RY, CX, CY *big.Int
+	C0, Z0, C1, Z1 *big.Int
+}
+
+// DealPVSS deals a random secret of dealer's among participants.
+func DealPVSS(participants *ParticipantSet, dealer *big.Int, random io.Reader) (*PVSSDealing, error) {
+	curve := participants.curve
+	if err := checkPVSSParticipants(participants); err != nil {
+		return nil, err
+	}
+	if _, ok := participants.Participant(dealer); !ok {
+		return nil, UnknownParticipantError{dealer}
+	}
+	secret, err := randomScalar(curve.Params().N, random)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroize(secret)
+	shares, commitments, err := dealSecret(curve, secret, participants.threshold, participants.IDs(), random)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, s := range shares {
+			zeroize(s.Share)
+		}
+	}()
+
+	d := &PVSSDealing{Curve: curve, Dealer: dealer, Commitments: commitments, Shares: make([]PVSSShare, len(shares))}
+	for i, p := range participants.participants {
+		if d.Shares[i], err = encryptPVSSShare(curve, dealer, p, shares[i].Share, random); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+func checkPVSSParticipants(participants *ParticipantSet) error {
+	for _, p := range participants.participants {
+		if p.Key.Curve != participants.curve {
+			return CurveMismatchError{participants.curve, p.Key.Curve}
+		}
+	}
+	return nil
+}
+
+func encryptPVSSShare(curve elliptic.Curve, dealer *big.Int, to Participant, share *big.Int, random io.Reader) (PVSSShare, error) {
+	n := curve.Params().N
+	px, py := to.Key.X, to.Key.Y
+	out := PVSSShare{To: to.ID, Bits: make([]PVSSBit, n.BitLen())}
+	// r = sum(2^j * r_j)
+	r := new(big.Int)
+	defer zeroize(r)
+	for j := len(out.Bits) - 1; j >= 0; j-- {
+		rj, err := randomScalar(n, random)
+		if err != nil {
+			return PVSSShare{}, err
+		}
+		r.Lsh(r, 1).Add(r, rj).Mod(r, n)
+		out.Bits[j], err = encryptPVSSBit(curve, dealer, to, j, share.Bit(j), rj, random)
+		zeroize(rj)
+		if err != nil {
+			return PVSSShare{}, err
+		}
+	}
+
+	w, err := randomScalar(n, random)
+	if err != nil {
+		return PVSSShare{}, err
+	}
+	defer zeroize(w)
+	rx, ry := curve.ScalarBaseMult(scalarBytes(curve, r))
+	dx, dy := curve.ScalarMult(px, py, scalarBytes(curve, r))
+	ax, ay := curve.ScalarBaseMult(scalarBytes(curve, w))
+	bx, by := curve.ScalarMult(px, py, scalarBytes(curve, w))
+	c := pvssChallenge(curve, "dkg/pvss-share", dealer, to, 0, rx, ry, dx, dy, ax, ay, bx, by)
+	z := new(big.Int).Mul(c, r)
+	out.Challenge, out.Response = c, z.Add(z, w).Mod(z, n)
+	return out, nil
+}
+
+// encryptPVSSBit encrypts bit b with randomness r, proving with the
+// Cramer-Damgård-Schoenmakers OR composition that it encrypts 0 or 1.
+func encryptPVSSBit(curve elliptic.Curve, dealer *big.Int, to Participant, j int, b uint, r *big.Int, random io.Reader) (PVSSBit, error) {
+	n := curve.Params().N
+	px, py := to.Key.X, to.Key.Y
+	var bit PVSSBit
+	bit.RX, bit.RY = curve.ScalarBaseMult(scalarBytes(curve, r))
+	bit.CX, bit.CY = curve.ScalarMult(px, py, scalarBytes(curve, r))
+	if b == 1 {
+		gx, gy := curve.Params().Gx, curve.Params().Gy
+		bit.CX, bit.CY = curve.Add(bit.CX, bit.CY, gx, gy)
+	}
+
+	w, err := randomScalar(n, random)
+	if err != nil {
+		return PVSSBit{}, err
+	}
+	defer zeroize(w)
+	fakeC, err := randomScalar(n, random)
+	if err != nil {
+		return PVSSBit{}, err
+	}
+	fakeZ, err := randomScalar(n, random)
+	if err != nil {
+		return PVSSBit{}, err
+	}
+
+	var a, bb [2]struct{ X, Y *big.Int }
+	a[b].X, a[b].Y = curve.ScalarBaseMult(scalarBytes(curve, w))
+	bb[b].X, bb[b].Y = curve.ScalarMult(px, py, scalarBytes(curve, w))
+	a[1-b].X, a[1-b].Y, bb[1-b].X, bb[1-b].Y = bit.recommit(curve, px, py, 1-b, fakeC, fakeZ)
+
+	c := pvssChallenge(curve, "dkg/pvss-bit", dealer, to, j,
+		bit.RX, bit.RY, bit.CX, bit.CY, a[0].X, a[0].Y, bb[0].X, bb[0].Y, a[1].X, a[1].Y, bb[1].X, bb[1].Y)
+	realC := new(big.Int).Sub(c, fakeC)
+	realC.Mod(realC, n)
+	realZ := new(big.Int).Mul(realC, r)
+	realZ.Add(realZ, w).Mod(realZ, n)
+	if b == 0 {
+		bit.C0, bit.Z0, bit.C1, bit.Z1 = realC, realZ, fakeC, fakeZ
+	} else {
+		bit.C0, bit.Z0, bit.C1, bit.Z1 = fakeC, fakeZ, realC, realZ
+	}
+	return bit, nil
+}
+
+// recommit returns the commitments z * G - c * R and z * P - c * (C - b * G)
+// of branch b of the bit's proof.
+func (bit PVSSBit) recommit(curve elliptic.Curve, px, py *big.Int, b uint, c, z *big.Int) (ax, ay, bx, by *big.Int) {
+	dx, dy := bit.CX, bit.CY
+	if b == 1 {
+		dx, dy = subtractPoint(curve, dx, dy, one, curve.Params().Gx, curve.Params().Gy)
+	}
+	ax, ay = curve.ScalarBaseMult(scalarBytes(curve, z))
+	ax, ay = subtractPoint(curve, ax, ay, c, bit.RX, bit.RY)
+	bx, by = curve.ScalarMult(px, py, scalarBytes(curve, z))
+	bx, by = subtractPoint(curve, bx, by, c, dx, dy)
+	return ax, ay, bx, by
+}
+
+// subtractPoint returns (x1, y1) - k * (x2, y2), on any curve.
+func subtractPoint(curve elliptic.Curve, x1, y1, k, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	n := curve.Params().N
+	neg := new(big.Int).Sub(n, new(big.Int).Mod(k, n))
+	x, y := curve.ScalarMult(x2, y2, scalarBytes(curve, neg.Mod(neg, n)))
+	return curve.Add(x1, y1, x, y)
+}
+
+func pvssChallenge(curve elliptic.Curve, tag string, dealer *big.Int, to Participant, j int, points ...*big.Int) *big.Int {
+	w := NewTranscriptWriter(sha256.New())
+	w.WriteTag(tag)
+	w.WriteTag(curve.Params().Name)
+	w.WriteInt(dealer)
+	w.WriteInt(to.ID)
+	w.WriteInt(to.Key.X)
+	w.WriteInt(to.Key.Y)
+	w.WriteUint(uint64(j))
+	for _, x := range points {
+		w.WriteInt(x)
+	}
+	c := new(big.Int).SetBytes(w.Sum())
+	return c.Mod(c, curve.Params().N)
+}
+
+// VerifyPVSSDealing checks that dealing is a participant's and that every
+// participant can decrypt its share of the committed polynomial.
+func VerifyPVSSDealing(participants *ParticipantSet, dealing *PVSSDealing) error {
+	curve := participants.curve
+	if err := checkPVSSParticipants(participants); err != nil {
+		return err
+	}
+	if _, ok := participants.Participant(dealing.Dealer); !ok {
+		return UnknownParticipantError{dealing.Dealer}
+	}
+	if len(dealing.Commitments) != participants.threshold+1 || !validCommitments(curve, dealing.Commitments) ||
+		len(dealing.Shares) != len(participants.participants) {
+		return InvalidPVSSDealingError{dealing.Dealer}
+	}
+	// the sums are cheap to check, the bits' proofs aren't
+	for i, p := range participants.participants {
+		if s := dealing.Shares[i]; s.To == nil || s.To.Cmp(p.ID) != 0 || !verifyPVSSSum(curve, dealing, p, s) {
+			return InvalidPVSSDealingError{dealing.Dealer}
+		}
+	}
+	for i, p := range participants.participants {
+		for j, bit := range dealing.Shares[i].Bits {
+			if !verifyPVSSBit(curve, dealing.Dealer, p, j, bit) {
+				return InvalidPVSSDealingError{dealing.Dealer}
+			}
+		}
+	}
+	return nil
+}
+
+// verifyPVSSSum checks the proof that the bits of share, if they are bits,
+// are those of the participant's share.
+func verifyPVSSSum(curve elliptic.Curve, dealing *PVSSDealing, to Participant, share PVSSShare) bool {
+	n := curve.Params().N
+	px, py := to.Key.X, to.Key.Y
+	if len(share.Bits) != n.BitLen() || !isNormalizedScalar(share.Challenge, n) || !isNormalizedScalar(share.Response, n) {
+		return false
+	}
+	var rx, ry, cx, cy *big.Int
+	for j := len(share.Bits) - 1; j >= 0; j-- {
+		bit := share.Bits[j]
+		if !isValidPoint(curve, bit.RX, bit.RY) || !isValidPoint(curve, bit.CX, bit.CY) {
+			return false
+		}
+		if rx == nil {
+			rx, ry, cx, cy = bit.RX, bit.RY, bit.CX, bit.CY
+			continue
+		}
+		rx, ry = curve.Double(rx, ry)
+		rx, ry = curve.Add(rx, ry, bit.RX, bit.RY)
+		cx, cy = curve.Double(cx, cy)
+		cx, cy = curve.Add(cx, cy, bit.CX, bit.CY)
+	}
+	sx, sy := evaluateCommitments(curve, dealing.Commitments, to.ID)
+	dx, dy := subtractPoint(curve, cx, cy, one, sx, sy)
+	if !isValidPoint(curve, rx, ry) || !isValidPoint(curve, dx, dy) {
+		return false
+	}
+
+	c, z := share.Challenge, share.Response
+	ax, ay := curve.ScalarBaseMult(scalarBytes(curve, z))
+	ax, ay = subtractPoint(curve, ax, ay, c, rx, ry)
+	bx, by := curve.ScalarMult(px, py, scalarBytes(curve, z))
+	bx, by = subtractPoint(curve, bx, by, c, dx, dy)
+	return pvssChallenge(curve, "dkg/pvss-share", dealing.Dealer, to, 0, rx, ry, dx, dy, ax, ay, bx, by).Cmp(c) == 0
+}
+
+func verifyPVSSBit(curve elliptic.Curve, dealer *big.Int, to Participant, j int, bit PVSSBit) bool {
+	n := curve.Params().N
+	if !isValidPoint(curve, bit.RX, bit.RY) || !isValidPoint(curve, bit.CX, bit.CY) {
+		return false
+	}
+	for _, x := range []*big.Int{bit.C0, bit.Z0, bit.C1, bit.Z1} {
+		if !isNormalizedScalar(x, n) {
+			return false
+		}
+	}
+	px, py := to.Key.X, to.Key.Y
+	a0x, a0y, b0x, b0y := bit.recommit(curve, px, py, 0, bit.C0, bit.Z0)
+	a1x, a1y, b1x, b1y := bit.recommit(curve, px, py, 1, bit.C1, bit.Z1)
+	c := pvssChallenge(curve, "dkg/pvss-bit", dealer, to, j,
+		bit.RX, bit.RY, bit.CX, bit.CY, a0x, a0y, b0x, b0y, a1x, a1y, b1x, b1y)
+	sum := new(big.Int).Add(bit.C0, bit.C1)
+	return sum.Mod(sum, n).Cmp(c) == 0
+}
+
+// DecryptPVSSShare decrypts the share of participant id, holding identity,
+// in a dealing among participants, checking it against the commitments.
+func DecryptPVSSShare(participants *ParticipantSet, id *big.Int, identity Identity, dealing *PVSSDealing) (*big.Int, error) {
+	curve := participants.curve
+	n := curve.Params().N
+	var share *PVSSShare
+	for i, p := range participants.participants {
+		if p.ID.Cmp(id) == 0 && i < len(dealing.Shares) {
+			share = &dealing.Shares[i]
+		}
+	}
+	if share == nil || len(share.Bits) != n.BitLen() || len(dealing.Commitments) == 0 {
+		return nil, InvalidPVSSDealingError{dealing.Dealer}
+	}
+
+	s := new(big.Int)
+	for j := len(share.Bits) - 1; j >= 0; j-- {
+		bit := share.Bits[j]
+		if !isValidPoint(curve, bit.RX, bit.RY) {
+			zeroize(s)
+			return nil, InvalidPVSSDealingError{dealing.Dealer}
+		}
+		// C = x * R for a 0 bit and C = G + x * R for a 1 bit
+		x, err := identity.ECDH(bit.RX, bit.RY)
+		if err != nil {
+			zeroize(s)
+			return nil, err
+		}
+		s.Lsh(s, 1)
+		if x.Cmp(bit.CX) != 0 {
+			s.SetBit(s, 0, 1)
+		}
+		zeroize(x)
+	}
+	s.Mod(s, n)
+	if !VerifyDealtShare(curve, DealtShare{id, s}, dealing.Commitments) {
+		zeroize(s)
+		return nil, InvalidPVSSDealingError{dealing.Dealer}
+	}
+	return s, nil
+}
+
+// qualifyPVSSDealings returns the valid dealings of distinct participants,
+// by dealer, of which there must be more than the threshold.
+func qualifyPVSSDealings(participants *ParticipantSet, dealings []*PVSSDealing) ([]*PVSSDealing, error) {
+	n := participants.curve.Params().N
+	seen := make(map[string]bool)
+	var qualified []*PVSSDealing
+	for _, d := range dealings {
+		if d.Dealer == nil || seen[new(big.Int).Mod(d.Dealer, n).String()] || VerifyPVSSDealing(participants, d) != nil {
+			continue
+		}
+		seen[new(big.Int).Mod(d.Dealer, n).String()] = true
+		qualified = append(qualified, d)
+	}
+	if len(qualified) <= participants.threshold {
+		return nil, InvalidThresholdError{participants.threshold, len(qualified)}
+	}
+	sort.Slice(qualified, func(i, j int) bool { return qualified[i].Dealer.Cmp(qualified[j].Dealer) < 0 })
+	return qualified, nil
+}
+
+func pvssGroupKey(curve elliptic.Curve, threshold int, qualified []*PVSSDealing) GroupKey {
+	coefficients := make(PointTuple, threshold+1)
+	for i, d := range qualified {
+		for k, c := range d.Commitments {
+			if i == 0 {
+				coefficients[k].X, coefficients[k].Y = c.X, c.Y
+			} else {
+				coefficients[k].X, coefficients[k].Y = curve.Add(coefficients[k].X, coefficients[k].Y, c.X, c.Y)
+			}
+		}
+	}
+	key := ecdsa.PublicKey{Curve: curve, X: coefficients[0].X, Y: coefficients[0].Y}
+	return GroupKey{0, threshold, key, coefficients}
+}
+
+// PVSSGroupKey returns the group key of a publicly verifiable ceremony, for
+// observers, skipping invalid dealings.
+func PVSSGroupKey(participants *ParticipantSet, dealings []*PVSSDealing) (GroupKey, error) {
+	qualified, err := qualifyPVSSDealings(participants, dealings)
+	if err != nil {
+		return GroupKey{}, err
+	}
+	return pvssGroupKey(participants.curve, participants.threshold, qualified), nil
+}
+
+// CombinePVSSDealings returns the key share of participant id, holding
+// identity, from the dealings of a publicly verifiable ceremony, skipping
+// invalid dealings.
+func CombinePVSSDealings(participants *ParticipantSet, id *big.Int, identity Identity, dealings []*PVSSDealing) (*KeyShare, error) {
+	curve := participants.curve
+	n := curve.Params().N
+	if _, ok := participants.Participant(id); !ok {
+		return nil, UnknownParticipantError{id}
+	}
+	qualified, err := qualifyPVSSDealings(participants, dealings)
+	if err != nil {
+		return nil, err
+	}
+
+	f := scalarFieldFor(n)
+	share := f.element()
+	defer clear(share)
+	dealers := make([]*big.Int, len(qualified))
+	for i, d := range qualified {
+		s, err := DecryptPVSSShare(participants, id, identity, d)
+		if err != nil {
+			return nil, err
+		}
+		f.addBig(share, s)
+		zeroize(s)
+		dealers[i] = d.Dealer
+	}
+	group := pvssGroupKey(curve, participants.threshold, qualified)
+	return &KeyShare{
+		ID:                 id,
+		Threshold:          group.Threshold,
+		Qualified:          dealers,
+		PublicKey:          group.PublicKey,
+		PublicCoefficients: group.PublicCoefficients,
+		Share:              f.toBig(share),
+	}, nil
+}
+
+func (d PVSSDealing) MarshalBinary() ([]byte, error) {
+	if d.Curve == nil {
+		return nil, InvalidEncodingError{"PVSS dealing without a curve"}
+	}
+	return encodeBinary(func(w *TranscriptWriter) {
+		w.WriteTag("dkg/pvss-dealing")
+		w.WriteTag(d.Curve.Params().Name)
+		w.WriteInt(d.Dealer)
+		w.Write(d.Commitments)
+		w.WriteUint(uint64(len(d.Shares)))
+		for _, s := range d.Shares {
+			w.WriteInt(s.To)
+			w.WriteUint(uint64(len(s.Bits)))
+			for _, b := range s.Bits {
+				for _, x := range []*big.Int{b.RX, b.RY, b.CX, b.CY} {
+					w.WriteInt(x)
+				}
+				for _, k := range []*big.Int{b.C0, b.Z0, b.C1, b.Z1} {
+					w.WriteScalar(d.Curve, k)
+				}
+			}
+			w.WriteScalar(d.Curve, s.Challenge)
+			w.WriteScalar(d.Curve, s.Response)
+		}
+	}), nil
+}
+
+func (d *PVSSDealing) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, func(r *transcriptReader) {
+		r.expectTag("dkg/pvss-dealing")
+		curve := r.readCurve()
+		out := PVSSDealing{Curve: curve, Dealer: r.readInt()}
+		r.expectTag("dkg/points")
+		out.Commitments = r.readPoints()
+		for n := r.readCount(); len(out.Shares) < n; {
+			s := PVSSShare{To: r.readInt()}
+			for n := r.readCount(); len(s.Bits) < n; {
+				s.Bits = append(s.Bits, PVSSBit{
+					r.readInt(), r.readInt(), r.readInt(), r.readInt(),
+					r.readScalar(curve), r.readScalar(curve), r.readScalar(curve), r.readScalar(curve),
+				})
+			}
+			s.Challenge, s.Response = r.readScalar(curve), r.readScalar(curve)
+			out.Shares = append(out.Shares, s)
+		}
+		*d = out
+	})
+}
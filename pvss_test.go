@@ -0,0 +1,84 @@
+package dkg
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestPVSS(t *testing.T) {
+	const size, threshold = 3, 1
+	curve := elliptic.P256()
+	identities := make([]Identity, size)
+	participants := make([]Participant, size)
+	for i := range participants {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		identities[i] = SoftwareIdentity(key)
+		participants[i] = Participant{big.NewInt(int64(i + 1)), key.PublicKey}
+	}
+	set, err := NewParticipantSet(curve, threshold, participants)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dealings := make([]*PVSSDealing, size)
+	for i, p := range participants {
+		if dealings[i], err = DealPVSS(set, p.ID, rand.Reader); err != nil {
+			t.Fatalf("Could not deal for %v: %v", p.ID, err)
+		}
+	}
+	b, _ := dealings[0].MarshalBinary()
+	var decoded PVSSDealing
+	if err := decoded.UnmarshalBinary(b); err != nil || !reflect.DeepEqual(&decoded, dealings[0]) {
+		t.Fatalf("Dealing decoded to %+v (%v)", decoded, err)
+	}
+
+	// a dealer encrypting a share other than the committed one is caught
+	bad := *dealings[1]
+	bad.Shares = append([]PVSSShare(nil), bad.Shares...)
+	if bad.Shares[2], err = encryptPVSSShare(curve, bad.Dealer, participants[2], big.NewInt(42), rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyPVSSDealing(set, &bad); !reflect.DeepEqual(err, InvalidPVSSDealingError{bad.Dealer}) {
+		t.Errorf("Got unexpected error for a wrong share: %v", err)
+	}
+	flipped := *dealings[1]
+	flipped.Shares = append([]PVSSShare(nil), flipped.Shares...)
+	flipped.Shares[0].Bits = append([]PVSSBit(nil), flipped.Shares[0].Bits...)
+	flipped.Shares[0].Bits[3].C0, flipped.Shares[0].Bits[3].C1 = flipped.Shares[0].Bits[3].C1, flipped.Shares[0].Bits[3].C0
+	if err := VerifyPVSSDealing(set, &flipped); !reflect.DeepEqual(err, InvalidPVSSDealingError{flipped.Dealer}) {
+		t.Errorf("Got unexpected error for a tampered bit proof: %v", err)
+	}
+
+	// the invalid dealing is skipped, the duplicate ignored
+	posted := []*PVSSDealing{dealings[2], &bad, dealings[0], &decoded}
+	results := make([]*KeyShare, threshold+1)
+	for i, p := range participants[:threshold+1] {
+		if results[i], err = CombinePVSSDealings(set, p.ID, identities[i], posted); err != nil {
+			t.Fatalf("Participant %v could not combine dealings: %v", p.ID, err)
+		}
+		if !reflect.DeepEqual(results[i].Qualified, []*big.Int{participants[0].ID, participants[2].ID}) {
+			t.Errorf("Participant %v qualified %v", p.ID, results[i].Qualified)
+		}
+	}
+	checkCeremonyResultsForTesting(t, results)
+	group, err := PVSSGroupKey(set, posted)
+	if err != nil || !reflect.DeepEqual(group, results[0].Group()) {
+		t.Errorf("Observed group key %+v, expected %+v (%v)", group, results[0].Group(), err)
+	}
+
+	if _, err := PVSSGroupKey(set, []*PVSSDealing{dealings[0], &bad}); reflect.TypeOf(err) != reflect.TypeOf(InvalidThresholdError{}) {
+		t.Errorf("Got unexpected error with too few dealings: %v", err)
+	}
+	other, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	mixed, _ := NewParticipantSet(curve, threshold, append(participants[:2:2], Participant{big.NewInt(3), other.PublicKey}))
+	if _, err := DealPVSS(mixed, participants[0].ID, rand.Reader); reflect.TypeOf(err) != reflect.TypeOf(CurveMismatchError{}) {
+		t.Errorf("Got unexpected error for an identity key on another curve: %v", err)
+	}
+}
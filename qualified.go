@@ -0,0 +1,226 @@
+package dkg
+
+import "crypto"
+import "crypto/ecdsa"
+import "hash"
+import "math/big"
+import "sort"
+import "strings"
+
+// AgreementPolicy says whether participants check that they agree on the
+// qualified dealers, and on the dealers whose public coefficients they
+// reconstruct, before finishing. Participants that disqualified or
+// reconstructed different dealers, such as after losing messages the
+// others got or being sent different coefficients by a dealer, would
+// otherwise silently end up with different group keys.
+type AgreementPolicy int
+
+const (
+	// NoAgreement trusts that all participants qualified the same dealers,
+	// and has each reconstruct the dealers it finds exposed. It is the
+	// default.
+	NoAgreement AgreementPolicy = iota
+	// AbortOnDivergence has every participant broadcast its signed view of
+	// the qualified dealers while extracting, and abort with
+	// QualifiedSetMismatchError if any view differs from its own. Likewise,
+	// it aborts with ExposedSetMismatchError if any participant reveals
+	// shares of other dealers to reconstruct than it does.
+	AbortOnDivergence
+	// MajorityView exchanges views like AbortOnDivergence, but has a
+	// participant adopt the view of more than half of all participants, if
+	// there is one and it has the shares and public coefficients of its
+	// dealers, and abort otherwise. It reconstructs the dealers more than
+	// half of all participants reveal shares of, unless that leaves out a
+	// dealer whose coefficients don't match the participant's own shares.
+	MajorityView
+)
+
+func (p AgreementPolicy) String() string {
+	switch p {
+	case NoAgreement:
+		return "no-agreement"
+	case AbortOnDivergence:
+		return "abort-on-divergence"
+	case MajorityView:
+		return "majority-view"
+	}
+	return "unknown"
+}
+
+// AgreeOnQualified sets the runner's agreement policy. All participants
+// must use the same policy. It must be called before Run.
+func (r *ProtocolRunner) AgreeOnQualified(policy AgreementPolicy) {
+	r.agreement = policy
+}
+
+// qualifiedSetBody is the part of a QualifiedSet covered by the signature.
+type qualifiedSetBody struct {
+	sender    *big.Int
+	qualified []*big.Int
+}
+
+func (q qualifiedSetBody) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/qualified-set-body")
+	w.WriteInt(q.sender)
+	w.WriteUint(uint64(len(q.qualified)))
+	for _, id := range q.qualified {
+		w.WriteInt(id)
+	}
+}
+
+func (n *Node) signQualifiedSet(qualified []*big.Int) (QualifiedSet, error) {
+	digest := HashOf(n.hash, qualifiedSetBody{n.id, qualified})
+	sig, err := n.identity.Sign(n.random, digest, crypto.Hash(0))
+	if err != nil {
+		return QualifiedSet{}, err
+	}
+	return QualifiedSet{qualified, sig}, nil
+}
+
+// VerifyQualifiedSet checks that q was signed by sender.
+func VerifyQualifiedSet(h hash.Hash, sender Participant, q QualifiedSet) bool {
+	if sender.Key.Curve == nil || sender.Key.X == nil {
+		return false
+	}
+	digest := HashOf(h, qualifiedSetBody{sender.ID, q.Qualified})
+	return ecdsa.VerifyASN1(&sender.Key, digest, q.Signature)
+}
+
+// qualifiedIDs returns the IDs of the qualified dealers, sorted.
+func (r *ProtocolRunner) qualifiedIDs() []*big.Int {
+	ids := make([]*big.Int, len(r.qualified))
+	for i, p := range r.qualified {
+		ids[i] = p.id
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Cmp(ids[j]) < 0 })
+	return ids
+}
+
+// viewKey identifies a view of the qualified dealers.
+func (r *ProtocolRunner) viewKey(ids []*big.Int) string {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = r.key(id)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+// agree compares the views of the qualified dealers the other participants
+// sent with this node's, resolving divergence per the agreement policy.
+// Participants whose views are missing don't count either way.
+func (r *ProtocolRunner) agree() error {
+	if r.agreement == NoAgreement {
+		return nil
+	}
+	own := r.viewKey(r.qualifiedIDs())
+	votes := map[string]int{own: 1}
+	views := map[string][]*big.Int{}
+	var diverging []*big.Int
+	for _, p := range r.participants {
+		if p == r.self || p.qualifiedSet == nil {
+			continue
+		}
+		key := r.viewKey(p.qualifiedSet.Qualified)
+		votes[key]++
+		views[key] = p.qualifiedSet.Qualified
+		if key != own {
+			diverging = append(diverging, p.id)
+		}
+	}
+	if len(diverging) == 0 {
+		return nil
+	}
+	r.fault(QualifiedSetMismatchError{diverging})
+	if r.agreement != MajorityView {
+		return QualifiedSetMismatchError{diverging}
+	}
+
+	majority := ""
+	for key, n := range votes {
+		if 2*n > len(r.participants) {
+			majority = key
+		}
+	}
+	if majority == own {
+		return nil
+	}
+	if majority == "" {
+		return QualifiedSetMismatchError{diverging}
+	}
+	var qualified []*participant
+	for _, id := range views[majority] {
+		p, ok := r.byID[r.key(id)]
+		if !ok || p.secretShare1 == nil || p.publicCoefficients == nil {
+			return QualifiedSetMismatchError{diverging}
+		}
+		qualified = append(qualified, p)
+	}
+	r.qualified = qualified
+	return nil
+}
+
+// agreeOnExposed returns the dealers to reconstruct, comparing the views
+// the other participants revealed shares in with this node's view exposed,
+// and resolving divergence per the agreement policy. A dealer's view of
+// itself doesn't count, as it never reveals its own shares, and neither do
+// missing views.
+func (r *ProtocolRunner) agreeOnExposed(exposed []*participant) ([]*participant, error) {
+	own := make(map[*participant]bool)
+	for _, dealer := range exposed {
+		own[dealer] = true
+	}
+	views := map[*participant]map[*participant]bool{r.self: own}
+	for _, p := range r.participants {
+		if p == r.self || p.reconstruction == nil {
+			continue
+		}
+		views[p] = make(map[*participant]bool)
+		for _, es := range p.reconstruction.Exposed {
+			if dealer, ok := r.byID[r.key(es.Dealer)]; ok {
+				views[p][dealer] = true
+			}
+		}
+	}
+	if r.agreement == NoAgreement {
+		return exposed, nil
+	}
+
+	var diverging []*big.Int
+	for _, p := range r.participants {
+		if p == r.self || views[p] == nil {
+			continue
+		}
+		for _, dealer := range r.qualified {
+			if dealer != p && dealer != r.self && views[p][dealer] != own[dealer] {
+				diverging = append(diverging, p.id)
+				break
+			}
+		}
+	}
+	if len(diverging) == 0 {
+		return exposed, nil
+	}
+	r.fault(ExposedSetMismatchError{diverging})
+	if r.agreement != MajorityView {
+		return nil, ExposedSetMismatchError{diverging}
+	}
+
+	exposed = nil
+	for _, dealer := range r.qualified {
+		votes := 0
+		for p, view := range views {
+			if p != dealer && view[dealer] {
+				votes++
+			}
+		}
+		switch {
+		case 2*votes > len(r.participants):
+			exposed = append(exposed, dealer)
+		case own[dealer]:
+			// its coefficients don't match this node's shares
+			return nil, ExposedSetMismatchError{diverging}
+		}
+	}
+	return exposed, nil
+}
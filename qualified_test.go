@@ -0,0 +1,212 @@
+package dkg
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+)
+
+// withholdingTransport delivers the broadcasts of one type to every
+// participant but victim.
+type withholdingTransport struct {
+	Transport
+	mType  MessageType
+	victim *big.Int
+	others []*big.Int
+}
+
+func (t withholdingTransport) Broadcast(m Message) error {
+	if m.Type != t.mType {
+		return t.Transport.Broadcast(m)
+	}
+	for _, id := range t.others {
+		if id.Cmp(t.victim) != 0 {
+			t.Transport.Send(id, m)
+		}
+	}
+	return nil
+}
+
+func TestAgreeOnQualified(t *testing.T) {
+	// the victim complains about the dealer and misses its justification,
+	// disqualifying it while the others keep it
+	run := func(t *testing.T, policy AgreementPolicy, diverge bool) ([]*Node, []*ProtocolRunner) {
+		nodes, participants := getCeremonyNodesForTesting(t, 5, 2)
+		victim := nodes[1].ID()
+		network := NewMemoryNetwork()
+		runners := make([]*ProtocolRunner, len(nodes))
+		for i, node := range nodes {
+			var transport Transport = network.Transport(node.ID())
+			defer transport.Close()
+			if diverge && i == 0 {
+				var others []*big.Int
+				for _, p := range participants[1:] {
+					others = append(others, p.ID)
+				}
+				transport = tamperingTransport{transport, SecretSharesMessage, corruptShareTo(victim)}
+				transport = withholdingTransport{transport, JustificationMessage, victim, others}
+			}
+			set, _ := NewParticipantSet(node.curve, node.Threshold(), participants)
+			runners[i], _ = NewProtocolRunner(node, set, transport)
+			runners[i].AgreeOnQualified(policy)
+		}
+		var wg sync.WaitGroup
+		for _, r := range runners {
+			wg.Add(1)
+			go func(r *ProtocolRunner) {
+				defer wg.Done()
+				r.Run()
+			}(r)
+		}
+		wg.Wait()
+		return nodes, runners
+	}
+
+	for _, policy := range []AgreementPolicy{AbortOnDivergence, MajorityView} {
+		t.Run(policy.String(), func(t *testing.T) {
+			_, runners := run(t, policy, false)
+			var results []*KeyShare
+			for _, r := range runners {
+				result, err := r.Result()
+				if err != nil {
+					t.Fatalf("Ceremony failed: %v", err)
+				}
+				results = append(results, result)
+			}
+			checkCeremonyResultsForTesting(t, results)
+		})
+	}
+
+	t.Run("Abort on divergence", func(t *testing.T) {
+		nodes, runners := run(t, AbortOnDivergence, true)
+		for i, r := range runners {
+			_, err := r.Result()
+			var mismatch QualifiedSetMismatchError
+			if !errors.As(err, &mismatch) {
+				t.Errorf("Node %v got unexpected error: %v", nodes[i].ID(), err)
+			}
+		}
+	})
+
+	t.Run("Majority view", func(t *testing.T) {
+		nodes, runners := run(t, MajorityView, true)
+		var results []*KeyShare
+		for i, r := range runners {
+			result, err := r.Result()
+			if i == 1 {
+				var mismatch QualifiedSetMismatchError
+				if !errors.As(err, &mismatch) || mismatch.Participants()[0].Cmp(nodes[0].ID()) != 0 {
+					t.Errorf("Victim got unexpected error: %v", err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("Node %v failed: %v", nodes[i].ID(), err)
+			}
+			results = append(results, result)
+		}
+		checkCeremonyResultsForTesting(t, results)
+		if len(results[0].Qualified) != len(nodes) {
+			t.Errorf("Qualified %v", results[0].Qualified)
+		}
+	})
+}
+
+func TestAgreeOnExposed(t *testing.T) {
+	// the dealer sends the victim public coefficients that don't match its
+	// shares, and the others ones that do
+	run := func(t *testing.T, policy AgreementPolicy) ([]*Node, []*ProtocolRunner) {
+		nodes, participants := getCeremonyNodesForTesting(t, 5, 2)
+		victim := nodes[1].ID()
+		var others []*big.Int
+		for _, p := range participants[1:] {
+			others = append(others, p.ID)
+		}
+		network := NewMemoryNetwork()
+		runners := make([]*ProtocolRunner, len(nodes))
+		for i, node := range nodes {
+			var transport Transport = network.Transport(node.ID())
+			defer transport.Close()
+			if i == 0 {
+				transport = misbehavingTransport{transport, []MisbehaviorPolicy{Equivocate(PublicCoefficientsMessage, others, victim)}}
+			}
+			set, _ := NewParticipantSet(node.curve, node.Threshold(), participants)
+			runners[i], _ = NewProtocolRunner(node, set, transport)
+			runners[i].AgreeOnQualified(policy)
+		}
+		var wg sync.WaitGroup
+		for _, r := range runners {
+			wg.Add(1)
+			go func(r *ProtocolRunner) {
+				defer wg.Done()
+				r.Run()
+			}(r)
+		}
+		wg.Wait()
+		return nodes, runners
+	}
+
+	policies := []struct {
+		policy AgreementPolicy
+		// whether the victim fails with ExposedSetMismatchError rather than
+		// ExtractionError, and whether the others do too
+		mismatch, abort bool
+	}{
+		{NoAgreement, false, false},
+		{AbortOnDivergence, true, true},
+		{MajorityView, true, false},
+	}
+	for _, p := range policies {
+		t.Run(p.policy.String(), func(t *testing.T) {
+			nodes, runners := run(t, p.policy)
+			var results []*KeyShare
+			for i, r := range runners[1:] {
+				id := nodes[i+1].ID()
+				result, err := r.Result()
+				if i > 0 && !p.abort {
+					if err != nil {
+						t.Fatalf("Node %v failed: %v", id, err)
+					}
+					results = append(results, result)
+					continue
+				}
+				var mismatch ExposedSetMismatchError
+				var extraction ExtractionError
+				if p.mismatch && !errors.As(err, &mismatch) || !p.mismatch && !errors.As(err, &extraction) {
+					t.Errorf("Node %v got unexpected error: %v", id, err)
+				}
+				// the others revealed their views rather than leave it
+				// waiting for their shares
+				for _, fault := range r.Faults() {
+					var timeout TimeoutError
+					if errors.As(fault, &timeout) {
+						t.Errorf("Node %v timed out: %v", id, fault)
+					}
+				}
+			}
+			if !p.abort {
+				checkCeremonyResultsForTesting(t, results)
+			}
+		})
+	}
+}
+
+func TestVerifyQualifiedSet(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+	ids := []*big.Int{participants[0].ID, participants[2].ID}
+	view, err := nodes[0].signQualifiedSet(ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyQualifiedSet(nodes[0].hash, participants[0], view) {
+		t.Fatalf("Valid qualified set rejected")
+	}
+	if VerifyQualifiedSet(nodes[0].hash, participants[1], view) {
+		t.Errorf("Qualified set accepted from another sender")
+	}
+	view.Qualified = ids[:1]
+	if VerifyQualifiedSet(nodes[0].hash, participants[0], view) {
+		t.Errorf("Altered qualified set accepted")
+	}
+}
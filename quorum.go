@@ -0,0 +1,167 @@
+package dkg
+
+import "crypto/elliptic"
+import "math/big"
+import "sort"
+import "strings"
+import "sync"
+
+// QuorumContext holds the Lagrange coefficients of a fixed set of signers,
+// computed once and reused for every signature the set makes, instead of
+// once per signature.
+type QuorumContext struct {
+	curve        elliptic.Curve
+	ids          []*big.Int
+	coefficients map[string]*big.Int
+}
+
+// NewQuorumContext computes the coefficients of the signers ids.
+func NewQuorumContext(curve elliptic.Curve, ids []*big.Int) (*QuorumContext, error) {
+	if err := validateIDs(curve, 0, ids); err != nil {
+		return nil, err
+	}
+	n := curve.Params().N
+	q := &QuorumContext{curve: curve, coefficients: make(map[string]*big.Int)}
+	for _, id := range ids {
+		q.ids = append(q.ids, new(big.Int).Mod(id, n))
+	}
+	sort.Slice(q.ids, func(i, j int) bool { return q.ids[i].Cmp(q.ids[j]) < 0 })
+	for _, x := range q.ids {
+		q.coefficients[x.String()] = lagrangeCoefficient(x, q.ids, n)
+	}
+	return q, nil
+}
+
+// IDs returns the signers, sorted.
+func (q *QuorumContext) IDs() []*big.Int {
+	return append([]*big.Int(nil), q.ids...)
+}
+
+// Coefficient returns the weight of id's share, like LagrangeCoefficient.
+func (q *QuorumContext) Coefficient(id *big.Int) (*big.Int, error) {
+	l, ok := q.coefficients[new(big.Int).Mod(id, q.curve.Params().N).String()]
+	if !ok {
+		return nil, UnknownParticipantError{id}
+	}
+	return new(big.Int).Set(l), nil
+}
+
+// Includes reports whether id is one of the signers.
+func (q *QuorumContext) Includes(id *big.Int) bool {
+	_, ok := q.coefficients[new(big.Int).Mod(id, q.curve.Params().N).String()]
+	return ok
+}
+
+// interpolate is interpolateShares for exactly the shares of the signers.
+func (q *QuorumContext) interpolate(n *big.Int, shares []DealtShare, degree int) (*big.Int, error) {
+	if len(q.ids) != degree+1 {
+		return nil, QuorumMismatchError{len(q.ids), degree + 1}
+	}
+	seen := make(map[string]bool)
+	result := new(big.Int)
+	for _, share := range shares {
+		if share.ID == nil || share.Share == nil {
+			continue
+		}
+		x := new(big.Int).Mod(share.ID, n).String()
+		l, ok := q.coefficients[x]
+		if !ok || seen[x] {
+			continue
+		}
+		seen[x] = true
+		result.Add(result, new(big.Int).Mul(l, share.Share))
+	}
+	if len(seen) != len(q.ids) {
+		return nil, InsufficientSharesError{len(seen), len(q.ids)}
+	}
+	return result.Mod(result, n), nil
+}
+
+// SignatureShare is SignatureShare with the product shares of the signers.
+func (q *QuorumContext) SignatureShare(key *KeyShare, nonces *SigningNonces, products []DealtShare, digest []byte) (PartialSignature, error) {
+	return signatureShare(key, nonces, products, digest, q.interpolate)
+}
+
+// CombineSignature is CombineSignature with the signature shares of the
+// signers.
+func (q *QuorumContext) CombineSignature(key *KeyShare, nonces *SigningNonces, partials []PartialSignature, digest []byte) (r, s *big.Int, err error) {
+	return combineSignature(key, nonces, partials, digest, q.interpolate)
+}
+
+// QuorumCache keeps the contexts of the signer sets in use. Contexts are
+// dropped when the roster changes: Invalidate drops those including a
+// participant that left, Reset all of them, such as after a reshare.
+type QuorumCache struct {
+	curve elliptic.Curve
+
+	mu       sync.Mutex
+	contexts map[string]*QuorumContext
+}
+
+func NewQuorumCache(curve elliptic.Curve) *QuorumCache {
+	return &QuorumCache{curve: curve, contexts: make(map[string]*QuorumContext)}
+}
+
+// Context returns the context of the signers ids, computing it on first
+// use.
+func (c *QuorumCache) Context(ids []*big.Int) (*QuorumContext, error) {
+	q, err := c.lookup(ids)
+	if q != nil || err != nil {
+		return q, err
+	}
+	if q, err = NewQuorumContext(c.curve, ids); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.contexts[quorumKey(q.ids)] = q
+	return q, nil
+}
+
+func (c *QuorumCache) lookup(ids []*big.Int) (*QuorumContext, error) {
+	if err := validateIDs(c.curve, 0, ids); err != nil {
+		return nil, err
+	}
+	n := c.curve.Params().N
+	xs := make([]*big.Int, len(ids))
+	for i, id := range ids {
+		xs[i] = new(big.Int).Mod(id, n)
+	}
+	sort.Slice(xs, func(i, j int) bool { return xs[i].Cmp(xs[j]) < 0 })
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.contexts[quorumKey(xs)], nil
+}
+
+func quorumKey(sorted []*big.Int) string {
+	keys := make([]string, len(sorted))
+	for i, x := range sorted {
+		keys[i] = x.Text(16)
+	}
+	return strings.Join(keys, ",")
+}
+
+// Invalidate drops the contexts of the signer sets including id.
+func (c *QuorumCache) Invalidate(id *big.Int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, q := range c.contexts {
+		if q.Includes(id) {
+			delete(c.contexts, key)
+		}
+	}
+}
+
+// Reset drops all contexts.
+func (c *QuorumCache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.contexts = make(map[string]*QuorumContext)
+}
+
+// Len returns the number of cached contexts.
+func (c *QuorumCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.contexts)
+}
@@ -0,0 +1,93 @@
+package dkg
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestQuorumContext(t *testing.T) {
+	curve := elliptic.P256()
+	ids := []*big.Int{big.NewInt(5), big.NewInt(2), big.NewInt(9)}
+	q, err := NewQuorumContext(curve, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids {
+		expected, _ := LagrangeCoefficient(curve, id, ids)
+		if l, err := q.Coefficient(id); err != nil || l.Cmp(expected) != 0 {
+			t.Errorf("Coefficient of %v is %v, expected %v", id, l, expected)
+		}
+	}
+	if _, err := q.Coefficient(big.NewInt(3)); !reflect.DeepEqual(err, UnknownParticipantError{big.NewInt(3)}) {
+		t.Errorf("Got unexpected error for an outsider: %v", err)
+	}
+
+	t.Run("Signing", func(t *testing.T) {
+		const size, threshold = 4, 1
+		keys, nonces := runSigningCeremoniesForTesting(t, size, threshold)
+		signers := keys[1:]
+		var signerIDs []*big.Int
+		for _, key := range signers {
+			signerIDs = append(signerIDs, key.ID)
+		}
+		q, err := NewQuorumContext(keys[0].PublicKey.Curve, signerIDs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		digest := sha256.Sum256([]byte("quorum"))
+		products := make([]DealtShare, len(signers))
+		for i, key := range signers {
+			products[i], _ = ProductShare(key, nonces[i+1])
+		}
+		partials := make([]PartialSignature, len(signers))
+		for i, key := range signers {
+			if partials[i], err = q.SignatureShare(key, nonces[i+1], products, digest[:]); err != nil {
+				t.Fatalf("Could not compute signature share of %v: %v", key.ID, err)
+			}
+		}
+		r, s, err := q.CombineSignature(signers[0], nonces[1], partials, digest[:])
+		if err != nil {
+			t.Fatalf("Could not combine signature: %v", err)
+		}
+		if !ecdsa.Verify(&signers[0].PublicKey, digest[:], r, s) {
+			t.Errorf("Signature didn't verify")
+		}
+		if _, _, err := q.CombineSignature(signers[0], nonces[1], partials[1:], digest[:]); reflect.TypeOf(err) != reflect.TypeOf(InsufficientSharesError{}) {
+			t.Errorf("Got unexpected error for a missing signer: %v", err)
+		}
+		small, _ := NewQuorumContext(keys[0].PublicKey.Curve, signerIDs[1:])
+		if _, _, err := small.CombineSignature(signers[0], nonces[1], partials, digest[:]); !reflect.DeepEqual(err, QuorumMismatchError{2, 3}) {
+			t.Errorf("Got unexpected error for a quorum of the wrong size: %v", err)
+		}
+	})
+
+	t.Run("Cache", func(t *testing.T) {
+		c := NewQuorumCache(curve)
+		first, err := c.Context(ids)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again, _ := c.Context([]*big.Int{ids[2], ids[0], ids[1]}); again != first {
+			t.Errorf("Reordered quorum wasn't cached")
+		}
+		other, _ := c.Context([]*big.Int{big.NewInt(1), big.NewInt(2)})
+		c.Invalidate(big.NewInt(9))
+		if c.Len() != 1 {
+			t.Errorf("Got %v contexts after invalidating one", c.Len())
+		}
+		if again, _ := c.Context([]*big.Int{big.NewInt(2), big.NewInt(1)}); again != other {
+			t.Errorf("Unaffected quorum was dropped")
+		}
+		c.Reset()
+		if c.Len() != 0 {
+			t.Errorf("Got %v contexts after a reset", c.Len())
+		}
+		if _, err := c.Context([]*big.Int{ids[0], ids[0]}); reflect.TypeOf(err) != reflect.TypeOf(DuplicateParticipantIDError{}) {
+			t.Errorf("Got unexpected error for duplicate signers: %v", err)
+		}
+	})
+}
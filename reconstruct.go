@@ -0,0 +1,147 @@
+package dkg
+
+import "crypto/elliptic"
+import "math/big"
+
+// PublicShare is a participant's share in the exponent, ID's share times G.
+type PublicShare struct {
+	ID   *big.Int
+	X, Y *big.Int
+}
+
+// RecoverSecret interpolates the secret shared with threshold from at least
+// threshold+1 shares with distinct IDs, such as the shares of a ceremony's
+// participants, for disaster recovery. Shares beyond the first threshold+1
+// usable ones are ignored.
+func RecoverSecret(curve elliptic.Curve, threshold int, shares []DealtShare) (*big.Int, error) {
+	if threshold < 0 {
+		return nil, InvalidThresholdError{threshold, len(shares)}
+	}
+	return interpolateShares(curve.Params().N, shares, threshold)
+}
+
+// RecoverPublicPoint interpolates the point shared with threshold in the
+// exponent, such as the group key from participants' public shares, from at
+// least threshold+1 of them.
+func RecoverPublicPoint(curve elliptic.Curve, threshold int, shares []PublicShare) (x, y *big.Int, err error) {
+	if threshold < 0 {
+		return nil, nil, InvalidThresholdError{threshold, len(shares)}
+	}
+	n := curve.Params().N
+	seen := make(map[string]bool)
+	var used []PublicShare
+	var xs []*big.Int
+	for _, share := range shares {
+		if share.ID == nil || !isValidPoint(curve, share.X, share.Y) || len(xs) > threshold {
+			continue
+		}
+		id := new(big.Int).Mod(share.ID, n)
+		if id.Sign() == 0 || seen[id.String()] {
+			continue
+		}
+		seen[id.String()] = true
+		used = append(used, share)
+		xs = append(xs, id)
+	}
+	if len(xs) <= threshold {
+		return nil, nil, InsufficientSharesError{len(xs), threshold + 1}
+	}
+
+	for i, share := range used {
+		l := lagrangeCoefficient(xs[i], xs, n)
+		px, py := curve.ScalarMult(share.X, share.Y, scalarBytes(curve, l))
+		if i == 0 {
+			x, y = px, py
+		} else {
+			x, y = curve.Add(x, y, px, py)
+		}
+	}
+	return x, y, nil
+}
+
+// LagrangeCoefficient returns the weight of id's share when interpolating
+// at zero from the shares of ids, for signers to evaluate their part of a
+// threshold operation locally: the sum of the weighted shares of ids is the
+// secret.
+func LagrangeCoefficient(curve elliptic.Curve, id *big.Int, ids []*big.Int) (*big.Int, error) {
+	if err := validateIDs(curve, 0, ids); err != nil {
+		return nil, err
+	}
+	n := curve.Params().N
+	x := new(big.Int).Mod(id, n)
+	xs := make([]*big.Int, len(ids))
+	found := false
+	for i, xi := range ids {
+		xs[i] = new(big.Int).Mod(xi, n)
+		found = found || xs[i].Cmp(x) == 0
+	}
+	if !found {
+		return nil, UnknownParticipantError{id}
+	}
+	return lagrangeCoefficient(x, xs, n), nil
+}
+
+// PublicShare returns participant id's public share, from the public
+// coefficients.
+func (s *KeyShare) PublicShare(id *big.Int) PublicShare {
+	return s.Group().PublicShare(id)
+}
+
+// PublicShare returns participant id's public share in the group, against
+// which its partial signatures, decryptions and VRF evaluations verify.
+func (g GroupKey) PublicShare(id *big.Int) PublicShare {
+	x, y := evaluateCommitments(g.PublicKey.Curve, g.PublicCoefficients, id)
+	return PublicShare{id, x, y}
+}
+
+// matchesCoefficients reports whether share, dealt to id, matches the
+// dealer's public coefficients.
+func (p ceremonyParams) matchesCoefficients(coefficients PointTuple, id, share *big.Int) bool {
+	if coefficients == nil || share == nil {
+		return false
+	}
+	sx, sy := p.baseMult(share)
+	ex, ey := evaluateCommitments(p.curve, coefficients, id)
+	return sx.Cmp(ex) == 0 && sy.Cmp(ey) == 0
+}
+
+// contests reports whether the shares revealed by id are a valid complaint
+// against the dealer's public coefficients: they verify against the
+// dealer's verification points, but don't match the coefficients.
+func (p ceremonyParams) contests(dealer *big.Int, vpts, coefficients PointTuple, id *big.Int, shares SecretShares) bool {
+	return p.verifyShareFor(dealer, id, shares, vpts) == nil && !p.matchesCoefficients(coefficients, id, shares.Share1)
+}
+
+// reconstructCoefficients interpolates the public coefficients of a dealer
+// that withheld or corrupted them from the first threshold+1 of the shares
+// revealed by their recipients that verify against the dealer's
+// verification points.
+func (p ceremonyParams) reconstructCoefficients(dealer *big.Int, vpts PointTuple, revealed []RevealedShares) (PointTuple, error) {
+	n := p.curve.Params().N
+	seen := make(map[string]bool)
+	var xs, ys []*big.Int
+	for _, rs := range revealed {
+		if len(xs) > p.threshold {
+			break
+		}
+		if rs.Recipient == nil {
+			continue
+		}
+		x := new(big.Int).Mod(rs.Recipient, n)
+		if seen[x.String()] || p.verifyShareFor(dealer, rs.Recipient, rs.SecretShares, vpts) != nil {
+			continue
+		}
+		seen[x.String()] = true
+		xs, ys = append(xs, x), append(ys, rs.Share1)
+	}
+	if len(xs) <= p.threshold {
+		return nil, ExtractionError{dealer}
+	}
+	poly := interpolatePolynomial(xs, ys, n)
+	defer zeroize(poly...)
+	coefficients := make(PointTuple, len(poly))
+	for k, c := range poly {
+		coefficients[k].X, coefficients[k].Y = p.baseMult(c)
+	}
+	return coefficients, nil
+}
@@ -0,0 +1,71 @@
+package dkg
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	curve := elliptic.P256()
+	const threshold = 2
+	secret, _ := randomScalar(curve.Params().N, rand.Reader)
+	ids := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4)}
+	shares, commitments, err := dealSecret(curve, secret, threshold, ids, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := &KeyShare{Threshold: threshold, PublicCoefficients: commitments}
+	key.PublicKey.Curve = curve
+	key.PublicKey.X, key.PublicKey.Y = commitments[0].X, commitments[0].Y
+
+	for _, subset := range [][]DealtShare{shares, shares[1:], {shares[3], shares[0], shares[0], shares[2]}} {
+		recovered, err := RecoverSecret(curve, threshold, subset)
+		if err != nil {
+			t.Fatalf("Could not recover secret: %v", err)
+		}
+		if recovered.Cmp(secret) != 0 {
+			t.Errorf("Recovered %x, expected %x", recovered, secret)
+		}
+	}
+	if _, err := RecoverSecret(curve, threshold, []DealtShare{shares[0], shares[1], shares[1]}); reflect.TypeOf(err) != reflect.TypeOf(InsufficientSharesError{}) {
+		t.Errorf("Got unexpected error recovering from too few distinct shares: %v", err)
+	}
+
+	public := make([]PublicShare, len(ids))
+	for i, id := range ids {
+		public[i] = key.PublicShare(id)
+	}
+	x, y, err := RecoverPublicPoint(curve, threshold, public[1:])
+	if err != nil {
+		t.Fatalf("Could not recover public point: %v", err)
+	}
+	if x.Cmp(key.PublicKey.X) != 0 || y.Cmp(key.PublicKey.Y) != 0 {
+		t.Errorf("Recovered public point isn't the public key")
+	}
+	if _, _, err := RecoverPublicPoint(curve, threshold, public[:2]); reflect.TypeOf(err) != reflect.TypeOf(InsufficientSharesError{}) {
+		t.Errorf("Got unexpected error recovering from too few public shares: %v", err)
+	}
+
+	// the weighted shares of any threshold+1 signers sum up to the secret
+	signers := ids[1:]
+	sum := new(big.Int)
+	for _, share := range shares[1:] {
+		l, err := LagrangeCoefficient(curve, share.ID, signers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum.Add(sum, l.Mul(l, share.Share))
+	}
+	if sum.Mod(sum, curve.Params().N).Cmp(secret) != 0 {
+		t.Errorf("Weighted shares don't sum up to the secret")
+	}
+	if _, err := LagrangeCoefficient(curve, ids[0], signers); reflect.TypeOf(err) != reflect.TypeOf(UnknownParticipantError{}) {
+		t.Errorf("Got unexpected error for a non-signer: %v", err)
+	}
+	if _, err := LagrangeCoefficient(curve, ids[0], []*big.Int{ids[0], ids[0]}); reflect.TypeOf(err) != reflect.TypeOf(DuplicateParticipantIDError{}) {
+		t.Errorf("Got unexpected error for duplicate signers: %v", err)
+	}
+}
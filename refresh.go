@@ -0,0 +1,53 @@
+package dkg
+
+// RefreshShare re-randomizes share, proactively: the shares of the
+// participants change while the group key stays the same, so that shares
+// stolen before a refresh are useless together with shares stolen after it.
+//
+// update is the participant's result of a ceremony with threshold one less
+// than share's, among the same participants. Its shares w_i of a random
+// polynomial w turn into shares i * w_i of x * w(x), a random polynomial of
+// the key's degree with zero constant term, which every participant adds to
+// its share. Participants must then erase their old shares.
+func RefreshShare(share, update *KeyShare) (*KeyShare, error) {
+	curve := share.PublicKey.Curve
+	n := curve.Params().N
+	if err := share.usable(); err != nil {
+		return nil, err
+	}
+	delta, err := RefreshDelta(share, update)
+	if err != nil {
+		return nil, err
+	}
+	if update.ID.Cmp(share.ID) != 0 {
+		return nil, UnknownParticipantError{update.ID}
+	}
+	// the commitments to x * w(x) are those to w shifted up by one degree
+	group, err := share.Group().ApplyDelta(delta)
+	if err != nil {
+		return nil, err
+	}
+
+	f := scalarFieldFor(n)
+	refreshed := f.fromBig(share.Share)
+	defer clear(refreshed)
+	f.mulAdd(refreshed, share.ID, update.Share)
+
+	result := &KeyShare{
+		ID:                 share.ID,
+		Epoch:              group.Epoch,
+		Threshold:          share.Threshold,
+		Qualified:          share.Qualified,
+		PublicKey:          share.PublicKey,
+		PublicCoefficients: group.PublicCoefficients,
+		Share:              f.toBig(refreshed),
+		Usage:              share.Usage,
+		inMemory:           share.inMemory,
+	}
+	if result.inMemory {
+		if err := lockMemory(result.Share); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
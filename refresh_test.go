@@ -0,0 +1,102 @@
+package dkg
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestRefreshShare(t *testing.T) {
+	const size, threshold = 5, 2
+	run := func(threshold int) []*KeyShare {
+		nodes, participants := getCeremonyNodesForTesting(t, size, threshold)
+		results := runCeremonyForTesting(t, nodes, participants)
+		for i, result := range results {
+			if result == nil {
+				t.Fatalf("Node %v did not finish", nodes[i].ID())
+			}
+		}
+		return results
+	}
+	shares, updates := run(threshold), run(threshold-1)
+
+	refreshed := make([]*KeyShare, size)
+	for i := range shares {
+		r, err := RefreshShare(shares[i], updates[i])
+		if err != nil {
+			t.Fatalf("Could not refresh share of %v: %v", shares[i].ID, err)
+		}
+		refreshed[i] = r
+
+		if r.Epoch != shares[i].Epoch+1 {
+			t.Errorf("Refreshed share of %v is in epoch %v", r.ID, r.Epoch)
+		}
+		if r.Share.Cmp(shares[i].Share) == 0 {
+			t.Errorf("Share of %v didn't change", r.ID)
+		}
+		if !VerifyDealtShare(r.PublicKey.Curve, DealtShare{r.ID, r.Share}, r.PublicCoefficients) {
+			t.Errorf("Refreshed share of %v doesn't match the refreshed public coefficients", r.ID)
+		}
+	}
+	checkCeremonyResultsForTesting(t, refreshed)
+
+	t.Run("Delta", func(t *testing.T) {
+		curve := shares[0].PublicKey.Curve
+		delta, err := RefreshDelta(shares[0], updates[0])
+		if err != nil {
+			t.Fatalf("Could not compute delta: %v", err)
+		}
+		b, _ := delta.MarshalBinary()
+		var decoded CommitmentDelta
+		if err := decoded.UnmarshalBinary(b); err != nil || !reflect.DeepEqual(decoded, delta) {
+			t.Fatalf("Delta decoded to %+v (%v)", decoded, err)
+		}
+
+		group, err := shares[0].Group().ApplyDelta(decoded)
+		if err != nil {
+			t.Fatalf("Could not apply delta: %v", err)
+		}
+		if !reflect.DeepEqual(group, refreshed[0].Group()) {
+			t.Errorf("Delta led to %+v, expected %+v", group, refreshed[0].Group())
+		}
+		if _, err := group.ApplyDelta(delta); reflect.TypeOf(err) != reflect.TypeOf(MixedEpochError{}) {
+			t.Errorf("Got unexpected error applying a delta twice: %v", err)
+		}
+
+		for i, r := range refreshed {
+			px, py := evaluateCommitments(curve, shares[i].PublicCoefficients, r.ID)
+			if !VerifyRefreshedShare(curve, px, py, DealtShare{r.ID, r.Share}, delta) {
+				t.Errorf("Refreshed share of %v doesn't verify against the delta", r.ID)
+			}
+			if VerifyRefreshedShare(curve, px, py, DealtShare{r.ID, shares[i].Share}, delta) {
+				t.Errorf("Old share of %v verifies against the delta", r.ID)
+			}
+		}
+	})
+	if refreshed[0].PublicKey.X.Cmp(shares[0].PublicKey.X) != 0 || refreshed[0].PublicKey.Y.Cmp(shares[0].PublicKey.Y) != 0 {
+		t.Errorf("Group key changed")
+	}
+
+	// old and new shares don't mix
+	mixed := append([]*KeyShare{shares[0]}, refreshed[1:threshold+1]...)
+	n := shares[0].PublicKey.Curve.Params().N
+	xs := make([]*big.Int, len(mixed))
+	for i, s := range mixed {
+		xs[i] = s.ID
+	}
+	secret := new(big.Int)
+	for _, s := range mixed {
+		secret.Add(secret, new(big.Int).Mul(lagrangeCoefficient(s.ID, xs, n), s.Share))
+	}
+	x, _ := shares[0].PublicKey.Curve.ScalarBaseMult(secret.Mod(secret, n).Bytes())
+	if x.Cmp(shares[0].PublicKey.X) == 0 {
+		t.Errorf("Old and refreshed shares reconstruct the key together")
+	}
+
+	if _, err := RefreshShare(shares[0], shares[0]); reflect.TypeOf(err) != reflect.TypeOf(InvalidThresholdError{}) {
+		t.Errorf("Got unexpected error refreshing with an update of the same threshold: %v", err)
+	}
+	if _, err := RefreshShare(shares[0], updates[1]); reflect.TypeOf(err) != reflect.TypeOf(UnknownParticipantError{}) {
+		t.Errorf("Got unexpected error refreshing with another participant's update: %v", err)
+	}
+}
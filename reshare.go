@@ -0,0 +1,113 @@
+package dkg
+
+import "crypto/elliptic"
+import "io"
+import "math/big"
+import "sort"
+
+// Resharing hands a key over to a new set of participants, possibly with a
+// different threshold, without changing the group key. At least threshold+1
+// holders of the current shares each deal their share to the new
+// participants with DealReshare. Every new participant combines the dealings
+// it received with CombineReshares; the new shares are those of the sum of
+// the dealt polynomials weighted by the Lagrange coefficients of the
+// dealers.
+
+// ReshareDealing is what a dealer sends a new participant: the dealer's ID,
+// the participant's share of the dealer's share and the commitments to the
+// dealer's polynomial.
+type ReshareDealing struct {
+	Dealer      *big.Int
+	Share       *big.Int
+	Commitments PointTuple
+}
+
+// DealReshare splits share for the new participants ids, of which any
+// threshold+1 will hold the key. It returns each participant's dealing, in
+// the order of ids.
+func DealReshare(share *KeyShare, threshold int, ids []*big.Int, random io.Reader) ([]ReshareDealing, error) {
+	if err := share.usable(); err != nil {
+		return nil, err
+	}
+	shares, commitments, err := dealSecret(share.PublicKey.Curve, share.Share, threshold, ids, random)
+	if err != nil {
+		return nil, err
+	}
+	dealings := make([]ReshareDealing, len(shares))
+	for i, s := range shares {
+		dealings[i] = ReshareDealing{share.ID, s.Share, commitments}
+	}
+	return dealings, nil
+}
+
+// CombineReshares returns the share of new participant id, of threshold,
+// from the dealings of more than group.Threshold current holders. All new
+// participants must combine dealings from the same dealers: when a dealing
+// is invalid, InvalidReshareDealingError names its dealer, who is to be
+// excluded by everyone.
+func CombineReshares(group GroupKey, id *big.Int, threshold int, dealings []ReshareDealing) (*KeyShare, error) {
+	curve := group.PublicKey.Curve
+	n := curve.Params().N
+
+	if !validParticipantID(id, n) {
+		return nil, InvalidParticipantIDError{id}
+	}
+	dealers := make([]*big.Int, len(dealings))
+	for i, d := range dealings {
+		dealers[i] = d.Dealer
+	}
+	if err := validateIDs(curve, group.Threshold, dealers); err != nil {
+		return nil, err
+	}
+
+	for _, d := range dealings {
+		if len(d.Commitments) != threshold+1 || !validCommitments(curve, d.Commitments) {
+			return nil, InvalidReshareDealingError{d.Dealer}
+		}
+		// the dealer must have dealt its current share
+		ex, ey := evaluateCommitments(curve, group.PublicCoefficients, d.Dealer)
+		if d.Commitments[0].X.Cmp(ex) != 0 || d.Commitments[0].Y.Cmp(ey) != 0 {
+			return nil, InvalidReshareDealingError{d.Dealer}
+		}
+		if !VerifyDealtShare(curve, DealtShare{id, d.Share}, d.Commitments) {
+			return nil, InvalidReshareDealingError{d.Dealer}
+		}
+	}
+
+	f := scalarFieldFor(n)
+	share := f.element()
+	defer clear(share)
+	coefficients := make(PointTuple, threshold+1)
+	for i, d := range dealings {
+		l := lagrangeCoefficient(d.Dealer, dealers, n)
+		f.mulAdd(share, l, d.Share)
+		for k, c := range d.Commitments {
+			x, y := curve.ScalarMult(c.X, c.Y, scalarBytes(curve, l))
+			if i == 0 {
+				coefficients[k].X, coefficients[k].Y = x, y
+			} else {
+				coefficients[k].X, coefficients[k].Y = curve.Add(coefficients[k].X, coefficients[k].Y, x, y)
+			}
+		}
+	}
+	sort.Slice(dealers, func(i, j int) bool { return dealers[i].Cmp(dealers[j]) < 0 })
+
+	return &KeyShare{
+		ID:                 id,
+		Epoch:              group.Epoch + 1,
+		Threshold:          threshold,
+		Qualified:          dealers,
+		PublicKey:          group.PublicKey,
+		PublicCoefficients: coefficients,
+		Share:              f.toBig(share),
+	}, nil
+}
+
+func validCommitments(curve elliptic.Curve, commitments PointTuple) bool {
+	for _, c := range commitments {
+		if !isValidPoint(curve, c.X, c.Y) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package dkg
+
+import (
+	"crypto/rand"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestReshare(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 5, 2)
+	shares := runCeremonyForTesting(t, nodes, participants)
+	for i, share := range shares {
+		if share == nil {
+			t.Fatalf("Node %v did not finish", nodes[i].ID())
+		}
+	}
+	group := shares[0].Group()
+
+	// the last three of the old holders hand the key to seven new
+	// participants with threshold 3
+	newIDs := []*big.Int{big.NewInt(6), big.NewInt(7), big.NewInt(8), big.NewInt(9), big.NewInt(10), big.NewInt(11), big.NewInt(12)}
+	dealers := shares[2:]
+	dealings := make([][]ReshareDealing, len(newIDs))
+	for _, dealer := range dealers {
+		dealt, err := DealReshare(dealer, 3, newIDs, rand.Reader)
+		if err != nil {
+			t.Fatalf("Could not deal share of %v: %v", dealer.ID, err)
+		}
+		for j := range newIDs {
+			dealings[j] = append(dealings[j], dealt[j])
+		}
+	}
+
+	reshared := make([]*KeyShare, len(newIDs))
+	for j, id := range newIDs {
+		share, err := CombineReshares(group, id, 3, dealings[j])
+		if err != nil {
+			t.Fatalf("Could not combine dealings for %v: %v", id, err)
+		}
+		if share.Epoch != group.Epoch+1 || share.Threshold != 3 {
+			t.Errorf("Got share of epoch %v and threshold %v", share.Epoch, share.Threshold)
+		}
+		if !VerifyDealtShare(share.PublicKey.Curve, DealtShare{id, share.Share}, share.PublicCoefficients) {
+			t.Errorf("Share of %v doesn't match the new public coefficients", id)
+		}
+		reshared[j] = share
+	}
+	checkCeremonyResultsForTesting(t, reshared)
+	if reshared[0].PublicKey.X.Cmp(group.PublicKey.X) != 0 {
+		t.Errorf("Group key changed")
+	}
+
+	t.Run("Invalid dealings", func(t *testing.T) {
+		tampered := append([]ReshareDealing(nil), dealings[0]...)
+		tampered[1].Share = new(big.Int).Add(tampered[1].Share, one)
+		if _, err := CombineReshares(group, newIDs[0], 3, tampered); !reflect.DeepEqual(err, InvalidReshareDealingError{dealers[1].ID}) {
+			t.Errorf("Got unexpected error for a tampered share: %v", err)
+		}
+
+		// a dealer dealing another secret than its share
+		other := *dealers[0]
+		other.Share = new(big.Int).Add(other.Share, one)
+		dealt, _ := DealReshare(&other, 3, newIDs, rand.Reader)
+		substituted := append([]ReshareDealing{dealt[0]}, dealings[0][1:]...)
+		if _, err := CombineReshares(group, newIDs[0], 3, substituted); !reflect.DeepEqual(err, InvalidReshareDealingError{dealers[0].ID}) {
+			t.Errorf("Got unexpected error for a dealing of another secret: %v", err)
+		}
+
+		if _, err := CombineReshares(group, newIDs[0], 2, dealings[0]); reflect.TypeOf(err) != reflect.TypeOf(InvalidReshareDealingError{}) {
+			t.Errorf("Got unexpected error for a threshold the dealings don't have: %v", err)
+		}
+		if _, err := CombineReshares(group, newIDs[0], 3, dealings[0][:2]); reflect.TypeOf(err) != reflect.TypeOf(InvalidThresholdError{}) {
+			t.Errorf("Got unexpected error for too few dealers: %v", err)
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package dkg
+
+import "time"
+
+// RetryRounds has the runner ask the participants whose messages of a phase
+// are still missing after a timeout to send them again, up to retries
+// times, rather than leave them out of the phase right away. Each retry is
+// scoped to its phase: the results of earlier phases stand, and secret
+// shares are sent again under fresh encryption nonces. Messages that
+// arrived but were invalid are not asked for again. Phases then last up to
+// retries+1 timeouts, and a node that finished waits out the extraction
+// phase to answer requests; all participants must use the same number of
+// retries. It must be called before Run.
+func (r *ProtocolRunner) RetryRounds(retries int) {
+	r.retries = retries
+}
+
+// awaitPhase awaits the messages of types ts of the current phase from ps,
+// asking for the missing ones again after each timeout, and applies the
+// liveness policy to the participants still missing at the deadline.
+func (r *ProtocolRunner) awaitPhase(ps []*participant, ts ...MessageType) error {
+	phase := r.Phase()
+	deadline := r.deadline(phase)
+	for attempt := 1; attempt <= r.retries; attempt++ {
+		end := deadline.Add(-time.Duration(r.retries-attempt+1) * r.timeout(phase))
+		if r.await(end, ps, ts...) {
+			return nil
+		}
+		if r.ctx.Err() != nil {
+			break
+		}
+		for _, p := range ps {
+			for _, t := range ts {
+				if p != r.self && !p.received[t] {
+					r.send(p.id, RetryMessage, Retry{phase, uint64(attempt)})
+					break
+				}
+			}
+		}
+	}
+	if r.await(deadline, ps, ts...) {
+		return nil
+	}
+	missing := r.missing(ps, ts...)
+	err := TimeoutError{phase, missing, r.ctx.Err()}
+	r.fault(err)
+	if r.ctx.Err() != nil {
+		return nil
+	}
+	return r.missed(err)
+}
+
+// linger answers retry requests of the participants that still miss this
+// node's messages of the last phase before finishing, until that phase is
+// over.
+func (r *ProtocolRunner) linger(last Phase) {
+	if r.retries == 0 {
+		return
+	}
+	timer := time.NewTimer(time.Until(r.deadline(last)))
+	defer timer.Stop()
+	for {
+		select {
+		case m, ok := <-r.transport.Receive():
+			if !ok {
+				return
+			}
+			r.received(m)
+		case <-timer.C:
+			return
+		case <-r.ctx.Done():
+			return
+		}
+	}
+}
+
+// retry sends this node's messages of the requested phase to p again, once
+// per attempt, if the node got to send them.
+func (r *ProtocolRunner) retry(p *participant, m Message) {
+	req, ok := m.Payload.(Retry)
+	if !ok || req.Phase < PhaseDealing || req.Phase > r.Phase() {
+		return
+	}
+	key := r.key(p.id) + "/" + req.Phase.String()
+	if req.Attempt <= r.served[key] || req.Attempt > uint64(r.retries) {
+		return
+	}
+	r.served[key] = req.Attempt
+
+	self := r.self
+	switch req.Phase {
+	case PhaseDealing:
+		if self.verificationPoints == nil || self.knowledgeProof == nil {
+			return
+		}
+		r.send(p.id, VerificationPointsMessage, self.verificationPoints)
+		r.send(p.id, SecretKnowledgeMessage, *self.knowledgeProof)
+		if ciphertext, err := r.EncryptShareFor(p.id); err == nil {
+			r.send(p.id, SecretSharesMessage, EncryptedShares{ciphertext})
+		}
+	case PhaseComplaining:
+		if self.complaints != nil {
+			r.send(p.id, ComplaintsMessage, *self.complaints)
+		}
+	case PhaseJustifying:
+		if self.justification != nil {
+			r.send(p.id, JustificationMessage, *self.justification)
+		}
+	case PhaseExtracting:
+		if self.publicCoefficients != nil {
+			r.send(p.id, PublicCoefficientsMessage, self.publicCoefficients)
+		}
+		if self.qualifiedSet != nil {
+			r.send(p.id, QualifiedSetMessage, *self.qualifiedSet)
+		}
+	case PhaseContesting:
+		if self.contest != nil {
+			r.send(p.id, CoefficientComplaintsMessage, *self.contest)
+		}
+	case PhaseReconstructing:
+		if self.reconstruction != nil {
+			r.send(p.id, ReconstructionMessage, *self.reconstruction)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package dkg
+
+import (
+	"sync"
+	"testing"
+)
+
+// lossyTransport drops the first broadcast of one message type.
+type lossyTransport struct {
+	Transport
+	mType MessageType
+	once  sync.Once
+}
+
+func (t *lossyTransport) Broadcast(m Message) error {
+	drop := false
+	if m.Type == t.mType {
+		t.once.Do(func() { drop = true })
+	}
+	if drop {
+		return nil
+	}
+	return t.Transport.Broadcast(m)
+}
+
+func TestRetryRounds(t *testing.T) {
+	for _, mType := range []MessageType{VerificationPointsMessage, ComplaintsMessage, PublicCoefficientsMessage} {
+		t.Run(mType.String(), func(t *testing.T) {
+			nodes, participants := getCeremonyNodesForTesting(t, 4, 1)
+			dealer := nodes[0].ID()
+			network := NewMemoryNetwork()
+			runners := make([]*ProtocolRunner, len(nodes))
+			for i, node := range nodes {
+				transport := network.Transport(node.ID())
+				defer transport.Close()
+				if node.ID().Cmp(dealer) == 0 {
+					transport = &lossyTransport{Transport: transport, mType: mType}
+				}
+				set, _ := NewParticipantSet(node.curve, node.Threshold(), participants)
+				runners[i], _ = NewProtocolRunner(node, set, transport)
+				runners[i].RetryRounds(1)
+			}
+			var wg sync.WaitGroup
+			for _, r := range runners {
+				wg.Add(1)
+				go func(r *ProtocolRunner) {
+					defer wg.Done()
+					r.Run()
+				}(r)
+			}
+			wg.Wait()
+
+			results := make([]*KeyShare, len(runners))
+			for i, r := range runners {
+				result, err := r.Result()
+				if err != nil {
+					t.Fatalf("Node %v failed: %v", nodes[i].ID(), err)
+				}
+				if len(result.Qualified) != len(nodes) {
+					t.Errorf("Node %v qualified %v", nodes[i].ID(), result.Qualified)
+				}
+				results[i] = result
+			}
+			checkCeremonyResultsForTesting(t, results)
+		})
+	}
+}
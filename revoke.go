@@ -0,0 +1,107 @@
+package dkg
+
+import "crypto/ecdsa"
+import "hash"
+import "math/big"
+
+// Revocation is a statement that a group key must no longer be used, signed
+// with the key itself by a quorum of its holders, as with CombineSignature
+// over RevocationDigest. It is self-contained, for distribution to relying
+// parties.
+type Revocation struct {
+	PublicKey ecdsa.PublicKey
+	Reason    string
+	R, S      *big.Int
+}
+
+type revocationStatement struct {
+	key    ecdsa.PublicKey
+	reason string
+}
+
+func (s revocationStatement) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/revocation-statement")
+	w.WriteTag(s.key.Curve.Params().Name)
+	w.WriteInt(s.key.X)
+	w.WriteInt(s.key.Y)
+	w.WriteBytes([]byte(s.reason))
+}
+
+// RevocationDigest returns the digest the holders of key sign to revoke it.
+func RevocationDigest(h hash.Hash, key ecdsa.PublicKey, reason string) []byte {
+	return HashOf(h, revocationStatement{key, reason})
+}
+
+// VerifyRevocation checks the signature of rev under the revoked key.
+func VerifyRevocation(h hash.Hash, rev Revocation) bool {
+	if rev.PublicKey.Curve == nil || rev.R == nil || rev.S == nil {
+		return false
+	}
+	return ecdsa.Verify(&rev.PublicKey, RevocationDigest(h, rev.PublicKey, rev.Reason), rev.R, rev.S)
+}
+
+// Revoke tombstones the share after checking rev: the share no longer
+// signs, refreshes or reshares. With destroy, the secret share is erased as
+// well.
+func (s *KeyShare) Revoke(h hash.Hash, rev Revocation, destroy bool) error {
+	key := rev.PublicKey
+	if key.Curve != s.PublicKey.Curve || key.X.Cmp(s.PublicKey.X) != 0 || key.Y.Cmp(s.PublicKey.Y) != 0 ||
+		!VerifyRevocation(h, rev) {
+		return InvalidRevocationError{}
+	}
+	s.Revoked = &rev
+	if destroy {
+		s.Zeroize()
+	}
+	return nil
+}
+
+// usable refuses revoked shares.
+func (s *KeyShare) usable() error {
+	if s.Revoked != nil {
+		return KeyRevokedError{s.Revoked.Reason}
+	}
+	if s.Share == nil {
+		return ShareDestroyedError{}
+	}
+	return nil
+}
+
+func (rev Revocation) writeRevocation(w *TranscriptWriter) {
+	w.WriteTag("dkg/revocation")
+	w.WriteTag(rev.PublicKey.Curve.Params().Name)
+	w.WriteInt(rev.PublicKey.X)
+	w.WriteInt(rev.PublicKey.Y)
+	w.WriteBytes([]byte(rev.Reason))
+	w.WriteScalar(rev.PublicKey.Curve, rev.R)
+	w.WriteScalar(rev.PublicKey.Curve, rev.S)
+}
+
+func (r *transcriptReader) readRevocation() Revocation {
+	var rev Revocation
+	r.expectTag("dkg/revocation")
+	curve := r.readCurve()
+	rev.PublicKey = ecdsa.PublicKey{Curve: curve, X: r.readInt(), Y: r.readInt()}
+	rev.Reason = string(r.readBytes())
+	rev.R, rev.S = r.readScalar(curve), r.readScalar(curve)
+	if r.err == nil && !isValidPoint(curve, rev.PublicKey.X, rev.PublicKey.Y) {
+		r.fail("invalid revoked key")
+	}
+	return rev
+}
+
+func (rev Revocation) MarshalBinary() ([]byte, error) {
+	if rev.PublicKey.Curve == nil {
+		return nil, InvalidEncodingError{"revocation without a curve"}
+	}
+	return encodeBinary(rev.writeRevocation), nil
+}
+
+func (rev *Revocation) UnmarshalBinary(data []byte) error {
+	var out Revocation
+	if err := unmarshalBinary(data, func(r *transcriptReader) { out = r.readRevocation() }); err != nil {
+		return err
+	}
+	*rev = out
+	return nil
+}
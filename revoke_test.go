@@ -0,0 +1,93 @@
+package dkg
+
+import (
+	"crypto/sha256"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func signForTesting(t *testing.T, keys []*KeyShare, nonces []*SigningNonces, digest []byte) (*big.Int, *big.Int) {
+	products := make([]DealtShare, len(keys))
+	for i := range keys {
+		product, err := ProductShare(keys[i], nonces[i])
+		if err != nil {
+			t.Fatalf("Could not compute product share of %v: %v", keys[i].ID, err)
+		}
+		products[i] = product
+	}
+	partials := make([]PartialSignature, len(keys))
+	for i := range keys {
+		partial, err := SignatureShare(keys[i], nonces[i], products, digest)
+		if err != nil {
+			t.Fatalf("Could not compute signature share of %v: %v", keys[i].ID, err)
+		}
+		partials[i] = partial
+	}
+	r, s, err := CombineSignature(keys[0], nonces[0], partials, digest)
+	if err != nil {
+		t.Fatalf("Could not combine signature: %v", err)
+	}
+	return r, s
+}
+
+func TestRevocation(t *testing.T) {
+	const size, threshold = 3, 1
+	keys, nonces := runSigningCeremoniesForTesting(t, size, threshold)
+	group := keys[0].PublicKey
+	digest := RevocationDigest(sha256.New(), group, "compromised")
+	r, s := signForTesting(t, keys, nonces, digest)
+	rev := Revocation{group, "compromised", r, s}
+
+	if !VerifyRevocation(sha256.New(), rev) {
+		t.Fatalf("Revocation doesn't verify")
+	}
+	forged := rev
+	forged.Reason = "superseded"
+	if VerifyRevocation(sha256.New(), forged) {
+		t.Errorf("Revocation with another reason verifies")
+	}
+	if err := keys[0].Revoke(sha256.New(), forged, false); reflect.TypeOf(err) != reflect.TypeOf(InvalidRevocationError{}) {
+		t.Errorf("Got unexpected error revoking with a forged revocation: %v", err)
+	}
+
+	b, err := rev.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Revocation
+	if err := decoded.UnmarshalBinary(b); err != nil || !reflect.DeepEqual(decoded, rev) {
+		t.Errorf("Revocation decoded to %+v (%v)", decoded, err)
+	}
+
+	if err := keys[0].Revoke(sha256.New(), rev, false); err != nil {
+		t.Fatalf("Could not revoke: %v", err)
+	}
+	if err := keys[1].Revoke(sha256.New(), rev, true); err != nil {
+		t.Fatalf("Could not revoke: %v", err)
+	}
+	if keys[1].Share != nil {
+		t.Errorf("Destroyed share is still there")
+	}
+	if _, err := ProductShare(keys[0], nonces[0]); reflect.TypeOf(err) != reflect.TypeOf(KeyRevokedError{}) {
+		t.Errorf("Got unexpected error signing with a revoked key: %v", err)
+	}
+	if _, err := DealReshare(keys[0], threshold, []*big.Int{big.NewInt(1), big.NewInt(2)}, nil); reflect.TypeOf(err) != reflect.TypeOf(KeyRevokedError{}) {
+		t.Errorf("Got unexpected error resharing a revoked key: %v", err)
+	}
+
+	// the tombstone survives persistence
+	for _, key := range keys[:2] {
+		b, err := key.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded KeyShare
+		if err := decoded.UnmarshalBinary(b); err != nil {
+			t.Fatalf("Could not decode revoked share: %v", err)
+		}
+		if !reflect.DeepEqual(&decoded, key) {
+			t.Errorf("Revoked share decoded to %+v, expected %+v", decoded, key)
+		}
+	}
+}
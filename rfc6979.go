@@ -0,0 +1,96 @@
+package dkg
+
+import "crypto/ecdsa"
+import "crypto/hmac"
+import "crypto/sha256"
+import "encoding/asn1"
+import "math/big"
+
+// signDeterministic returns an ASN.1 ECDSA signature of digest with the
+// nonce derived from the key and digest as in RFC 6979, with HMAC-SHA256.
+// Signatures are reproducible and independent of the quality of any random
+// source; crypto/ecdsa ignores caller-provided randomness.
+func signDeterministic(key *ecdsa.PrivateKey, digest []byte) ([]byte, error) {
+	curve := key.Curve
+	n := curve.Params().N
+	e := hashToInt(digest, curve)
+	nonces := newNonceGenerator(n, key.D, digest)
+	for {
+		k := nonces.next()
+		rx, _ := curve.ScalarBaseMult(scalarBytes(curve, k))
+		r := rx.Mod(rx, n)
+		if r.Sign() == 0 {
+			continue
+		}
+		// s = k^-1 * (e + r * d)
+		s := new(big.Int).Mul(r, key.D)
+		s.Add(s, e)
+		s.Mul(s, k.ModInverse(k, n))
+		s.Mod(s, n)
+		if s.Sign() == 0 {
+			continue
+		}
+		return asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	}
+}
+
+// nonceGenerator is the HMAC_DRBG of RFC 6979, section 3.2.
+type nonceGenerator struct {
+	n    *big.Int
+	k, v []byte
+}
+
+func newNonceGenerator(n, x *big.Int, digest []byte) *nonceGenerator {
+	g := &nonceGenerator{n: n, k: make([]byte, sha256.Size), v: make([]byte, sha256.Size)}
+	for i := range g.v {
+		g.v[i] = 1
+	}
+	// bits2octets(h1): bits2int(h1) mod q, as rlen/8 bytes
+	h := g.bits2int(digest)
+	if h.Cmp(n) >= 0 {
+		h.Sub(h, n)
+	}
+	seed := append(g.int2octets(x), g.int2octets(h)...)
+	g.k = g.mac(g.v, []byte{0}, seed)
+	g.v = g.mac(g.v)
+	g.k = g.mac(g.v, []byte{1}, seed)
+	g.v = g.mac(g.v)
+	return g
+}
+
+func (g *nonceGenerator) mac(data ...[]byte) []byte {
+	m := hmac.New(sha256.New, g.k)
+	for _, d := range data {
+		m.Write(d)
+	}
+	return m.Sum(nil)
+}
+
+func (g *nonceGenerator) bits2int(b []byte) *big.Int {
+	x := new(big.Int).SetBytes(b)
+	if excess := len(b)*8 - g.n.BitLen(); excess > 0 {
+		x.Rsh(x, uint(excess))
+	}
+	return x
+}
+
+func (g *nonceGenerator) int2octets(x *big.Int) []byte {
+	return x.FillBytes(make([]byte, (g.n.BitLen()+7)/8))
+}
+
+// next returns the next candidate nonce in [1, n).
+func (g *nonceGenerator) next() *big.Int {
+	for {
+		var t []byte
+		for len(t)*8 < g.n.BitLen() {
+			g.v = g.mac(g.v)
+			t = append(t, g.v...)
+		}
+		k := g.bits2int(t)
+		g.k = g.mac(g.v, []byte{0})
+		g.v = g.mac(g.v)
+		if k.Sign() > 0 && k.Cmp(g.n) < 0 {
+			return k
+		}
+	}
+}
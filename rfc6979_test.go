@@ -0,0 +1,39 @@
+package dkg
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+)
+
+func TestSignDeterministic(t *testing.T) {
+	// RFC 6979, appendix A.2.5: P-256, SHA-256, message "sample"
+	hexInt := func(s string) *big.Int {
+		x, _ := new(big.Int).SetString(s, 16)
+		return x
+	}
+	curve := elliptic.P256()
+	d := hexInt("c9afa9d845ba75166b5c215767b1d6934e50c3db36e89b127b8a622b120f6721")
+	x, y := curve.ScalarBaseMult(d.Bytes())
+	key := &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: curve, X: x, Y: y}, D: d}
+	digest := sha256.Sum256([]byte("sample"))
+
+	sig, err := signDeterministic(key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rs struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(sig, &rs); err != nil {
+		t.Fatal(err)
+	}
+	if rs.R.Cmp(hexInt("efd48b2aacb6a8fd1140dd9cd45e81d69d2c877b56aaf991c34d0ea84eaf3716")) != 0 ||
+		rs.S.Cmp(hexInt("f7cb1c942d657c41d436c7a1b6e29f65f3e900dbb9aff4064dc4ab2f843acda8")) != 0 {
+		t.Errorf("Got signature %x, %x", rs.R, rs.S)
+	}
+	if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], sig) {
+		t.Errorf("Signature doesn't verify")
+	}
+}
@@ -0,0 +1,150 @@
+package dkg
+
+import "context"
+import "sync"
+
+// Rotation routes the threshold operations of a group key while it is
+// reshared to a new set of holders, so that custody services rotate their
+// operators without a maintenance window. The coordinator starts every
+// operation through it: once the reshared group is staged, new operations
+// go to it while those already started finish with the old group, both
+// groups serving in parallel. Cutover then waits for the old group to
+// drain and retires it, after which its holders may destroy their shares.
+type Rotation struct {
+	mu      sync.Mutex
+	current GroupKey
+	// the group being drained, until Cutover
+	old      *GroupKey
+	inflight map[uint64]int
+	retired  map[uint64]bool
+	drained  chan struct{}
+}
+
+// RotationOperation is a threshold operation started through a Rotation,
+// with the group it was routed to: its partials are made with the shares
+// of Group.Epoch and verified against Group. Done must be called once it
+// finished or was abandoned.
+type RotationOperation struct {
+	Group GroupKey
+
+	rotation *Rotation
+	once     sync.Once
+}
+
+func NewRotation(group GroupKey) *Rotation {
+	return &Rotation{
+		current:  group,
+		inflight: make(map[uint64]int),
+		retired:  make(map[uint64]bool),
+	}
+}
+
+// Group returns the group new operations are routed to.
+func (r *Rotation) Group() GroupKey {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// GroupOf returns the group of epoch while it serves operations, for
+// verifying the partials of operations started on it.
+func (r *Rotation) GroupOf(epoch uint64) (GroupKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch {
+	case epoch == r.current.Epoch:
+		return r.current, nil
+	case r.old != nil && epoch == r.old.Epoch:
+		return *r.old, nil
+	case r.retired[epoch]:
+		return GroupKey{}, GroupRetiredError{epoch}
+	}
+	return GroupKey{}, MixedEpochError{r.current.Epoch, epoch}
+}
+
+// Stage routes new operations to next, the group reshared from the current
+// one with CombineReshares, while the operations in flight finish with the
+// current group. Only one rotation may be in progress.
+func (r *Rotation) Stage(next GroupKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.old != nil {
+		return RotationInProgressError{r.current.Epoch}
+	}
+	if !next.PublicKey.Equal(&r.current.PublicKey) {
+		return GroupKeyMismatchError{}
+	}
+	if next.Epoch != r.current.Epoch+1 {
+		return MixedEpochError{r.current.Epoch + 1, next.Epoch}
+	}
+	old := r.current
+	r.old, r.current = &old, next
+	r.drained = make(chan struct{})
+	if r.inflight[old.Epoch] == 0 {
+		close(r.drained)
+	}
+	return nil
+}
+
+// Begin starts an operation on the group new operations are routed to.
+func (r *Rotation) Begin() *RotationOperation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inflight[r.current.Epoch]++
+	return &RotationOperation{Group: r.current, rotation: r}
+}
+
+// Done ends the operation; calling it again does nothing.
+func (o *RotationOperation) Done() {
+	o.once.Do(func() {
+		r := o.rotation
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		epoch := o.Group.Epoch
+		if r.inflight[epoch]--; r.inflight[epoch] == 0 {
+			delete(r.inflight, epoch)
+			if r.old != nil && epoch == r.old.Epoch {
+				close(r.drained)
+			}
+		}
+	})
+}
+
+// Draining returns the number of operations the old group has in flight,
+// or 0 when no rotation is in progress.
+func (r *Rotation) Draining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.old == nil {
+		return 0
+	}
+	return r.inflight[r.old.Epoch]
+}
+
+// Cutover waits until the old group's operations finished, or ctx is done,
+// then retires the old group: GroupOf refuses its epoch from then on, and
+// the rotation is complete.
+func (r *Rotation) Cutover(ctx context.Context) error {
+	r.mu.Lock()
+	if r.old == nil {
+		r.mu.Unlock()
+		return NoRotationError{}
+	}
+	old, drained := r.old, r.drained
+	r.mu.Unlock()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retired[old.Epoch] = true
+	// unless a concurrent Cutover completed the rotation already
+	if r.old == old {
+		r.old = nil
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package dkg
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRotation(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+	shares := runCeremonyForTesting(t, nodes, participants)
+	checkCeremonyResultsForTesting(t, shares)
+	group := shares[0].Group()
+
+	newIDs := []*big.Int{big.NewInt(4), big.NewInt(5), big.NewInt(6)}
+	dealings := make([][]ReshareDealing, len(newIDs))
+	for _, dealer := range shares[:2] {
+		dealt, err := DealReshare(dealer, 1, newIDs, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := range newIDs {
+			dealings[j] = append(dealings[j], dealt[j])
+		}
+	}
+	reshared := make([]*KeyShare, len(newIDs))
+	for j, id := range newIDs {
+		share, err := CombineReshares(group, id, 1, dealings[j])
+		if err != nil {
+			t.Fatal(err)
+		}
+		reshared[j] = share
+	}
+
+	// evaluate has the holders of keys make partials for op and checks them
+	// against the group the rotation knows for its epoch.
+	evaluate := func(t *testing.T, r *Rotation, op *RotationOperation, keys []*KeyShare) {
+		t.Helper()
+		for _, key := range keys {
+			partial, err := VRFScheme.PartialSign(key, nil, []byte("input"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			group, err := r.GroupOf(op.Group.Epoch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := VRFScheme.VerifyPartial(group, nil, []byte("input"), partial); err != nil {
+				t.Errorf("Partial of %v does not verify: %v", key.ID, err)
+			}
+		}
+	}
+
+	r := NewRotation(group)
+	if err := r.Cutover(context.Background()); !reflect.DeepEqual(err, NoRotationError{}) {
+		t.Errorf("Got unexpected error cutting over without a rotation: %v", err)
+	}
+	inflight := r.Begin()
+	if err := r.Stage(reshared[0].Group()); err != nil {
+		t.Fatalf("Could not stage the reshared group: %v", err)
+	}
+	if err := r.Stage(reshared[0].Group()); !reflect.DeepEqual(err, RotationInProgressError{group.Epoch + 1}) {
+		t.Errorf("Got unexpected error staging twice: %v", err)
+	}
+
+	// both groups serve until the cutover
+	routed := r.Begin()
+	if routed.Group.Epoch != group.Epoch+1 || r.Draining() != 1 {
+		t.Fatalf("Routed an operation to epoch %v with %v draining", routed.Group.Epoch, r.Draining())
+	}
+	evaluate(t, r, inflight, shares)
+	evaluate(t, r, routed, reshared)
+	routed.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := r.Cutover(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Cut over with an operation in flight: %v", err)
+	}
+	done := make(chan error)
+	go func() { done <- r.Cutover(context.Background()) }()
+	inflight.Done()
+	inflight.Done()
+	if err := <-done; err != nil {
+		t.Fatalf("Could not cut over: %v", err)
+	}
+	if _, err := r.GroupOf(group.Epoch); !reflect.DeepEqual(err, GroupRetiredError{group.Epoch}) {
+		t.Errorf("Got unexpected error for the retired group: %v", err)
+	}
+	if r.Group().Epoch != group.Epoch+1 || r.Draining() != 0 {
+		t.Errorf("Rotation did not complete")
+	}
+
+	t.Run("Another key", func(t *testing.T) {
+		nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+		other := runCeremonyForTesting(t, nodes, participants)[0].Group()
+		other.Epoch = r.Group().Epoch + 1
+		if err := r.Stage(other); !reflect.DeepEqual(err, GroupKeyMismatchError{}) {
+			t.Errorf("Got unexpected error for another group key: %v", err)
+		}
+		if err := r.Stage(group); !reflect.DeepEqual(err, MixedEpochError{group.Epoch + 2, group.Epoch}) {
+			t.Errorf("Got unexpected error for an earlier epoch: %v", err)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package dkg
+
+import "crypto/elliptic"
+import "math/big"
+
+// ScalarSize is the length in bytes of encoded scalars for curve, the byte
+// length of the curve order.
+func ScalarSize(curve elliptic.Curve) int {
+	return (curve.Params().N.BitLen() + 7) / 8
+}
+
+// EncodeScalar returns the fixed-width big-endian encoding of k, which must
+// be normalized: 0 <= k < N.
+func EncodeScalar(curve elliptic.Curve, k *big.Int) ([]byte, error) {
+	if !isNormalizedScalar(k, curve.Params().N) {
+		return nil, InvalidCurveScalarError{curve, k}
+	}
+	return scalarBytes(curve, k), nil
+}
+
+// DecodeScalar parses an encoding produced by EncodeScalar, rejecting inputs
+// of the wrong length and values outside of [0, N).
+func DecodeScalar(curve elliptic.Curve, b []byte) (*big.Int, error) {
+	if len(b) != ScalarSize(curve) {
+		return nil, InvalidScalarEncodingError{curve, len(b)}
+	}
+	k := new(big.Int).SetBytes(b)
+	if !isNormalizedScalar(k, curve.Params().N) {
+		return nil, InvalidCurveScalarError{curve, k}
+	}
+	return k, nil
+}
+
+// WriteScalar writes k, a scalar of curve, encoded with EncodeScalar. A
+// scalar out of range, or without a curve, is written as an empty field,
+// which no decoder accepts.
+func (w *TranscriptWriter) WriteScalar(curve elliptic.Curve, k *big.Int) {
+	if curve == nil {
+		w.WriteBytes(nil)
+		return
+	}
+	b, err := EncodeScalar(curve, k)
+	if err != nil {
+		b = nil
+	}
+	w.WriteBytes(b)
+	clear(b)
+}
+
+// writeCurveName writes the name of the curve the following scalars belong
+// to, or an empty tag, which no reader accepts, without one.
+func writeCurveName(w *TranscriptWriter, curve elliptic.Curve) {
+	if curve == nil {
+		w.WriteTag("")
+		return
+	}
+	w.WriteTag(curve.Params().Name)
+}
+
+// readScalar reads a scalar of curve written by WriteScalar.
+func (r *transcriptReader) readScalar(curve elliptic.Curve) *big.Int {
+	b := r.readBytes()
+	if r.err != nil || curve == nil {
+		return nil
+	}
+	k, err := DecodeScalar(curve, b)
+	if err != nil {
+		r.fail("invalid scalar")
+		return nil
+	}
+	return k
+}
+
+func scalarBytes(curve elliptic.Curve, k *big.Int) []byte {
+	return k.FillBytes(make([]byte, ScalarSize(curve)))
+}
@@ -0,0 +1,50 @@
+package dkg
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestScalarEncoding(t *testing.T) {
+	curve := elliptic.P256()
+	n := curve.Params().N
+
+	for _, k := range []*big.Int{big.NewInt(0), big.NewInt(1), new(big.Int).Sub(n, big.NewInt(1))} {
+		b, err := EncodeScalar(curve, k)
+		if err != nil {
+			t.Errorf("Could not encode scalar %v: %v", k, err)
+			continue
+		}
+		if len(b) != 32 {
+			t.Errorf("Encoding of %v has length %v", k, len(b))
+		}
+		if d, err := DecodeScalar(curve, b); err != nil || d.Cmp(k) != 0 {
+			t.Errorf("Scalar %v round-tripped to %v (%v)", k, d, err)
+		}
+	}
+
+	if _, err := EncodeScalar(curve, n); reflect.TypeOf(err) != reflect.TypeOf(InvalidCurveScalarError{}) {
+		t.Errorf("Got unexpected error encoding curve order: %v", err)
+	}
+	if _, err := EncodeScalar(curve, big.NewInt(-1)); reflect.TypeOf(err) != reflect.TypeOf(InvalidCurveScalarError{}) {
+		t.Errorf("Got unexpected error encoding negative scalar: %v", err)
+	}
+
+	badEncodings := []struct {
+		b   []byte
+		err error
+	}{
+		{[]byte{1}, InvalidScalarEncodingError{}},
+		{make([]byte, 33), InvalidScalarEncodingError{}},
+		{n.Bytes(), InvalidCurveScalarError{}},
+		{bytes.Repeat([]byte{0xff}, 32), InvalidCurveScalarError{}},
+	}
+	for _, bad := range badEncodings {
+		if _, err := DecodeScalar(curve, bad.b); reflect.TypeOf(err) != reflect.TypeOf(bad.err) {
+			t.Errorf("Got unexpected error decoding %x: %v", bad.b, err)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package dkg
+
+import "math/big"
+import "math/bits"
+import "sync"
+
+// scalarField does arithmetic modulo an odd curve order in constant time
+// with respect to the values: elements are fixed-width little-endian limbs
+// in the Montgomery domain, and no branch or memory access depends on them.
+// Secret scalars go through it instead of math/big; conversions from and to
+// *big.Int still depend on the value's length, not on its bits.
+type scalarField struct {
+	order  *big.Int
+	n      []uint64
+	n0inv  uint64 // -n^-1 mod 2^64
+	r2     []uint64
+	one    []uint64
+	nBytes int
+}
+
+var scalarFields sync.Map
+
+// maxLimbs is the limb count of the longest order of the supported curves,
+// P-521's: temporaries of up to maxLimbs limbs are kept on the stack.
+const maxLimbs = 9
+
+// scratch returns buf[:l], or a new slice if buf is too short.
+func scratch(buf []uint64, l int) []uint64 {
+	if l > len(buf) {
+		return make([]uint64, l)
+	}
+	return buf[:l]
+}
+
+func scalarFieldFor(n *big.Int) *scalarField {
+	key := n.String()
+	if f, ok := scalarFields.Load(key); ok {
+		return f.(*scalarField)
+	}
+	f := newScalarField(n)
+	scalarFields.Store(key, f)
+	return f
+}
+
+func newScalarField(n *big.Int) *scalarField {
+	limbs := (n.BitLen() + 63) / 64
+	f := &scalarField{order: new(big.Int).Set(n), nBytes: (n.BitLen() + 7) / 8}
+	f.n = f.limbsOf(n, limbs)
+
+	word := new(big.Int).Lsh(one, 64)
+	inv := new(big.Int).ModInverse(new(big.Int).Mod(n, word), word)
+	f.n0inv = -inv.Uint64()
+
+	r2 := new(big.Int).Lsh(one, uint(128*limbs))
+	f.r2 = f.limbsOf(r2.Mod(r2, n), limbs)
+	f.one = make([]uint64, limbs)
+	f.one[0] = 1
+	return f
+}
+
+func (f *scalarField) limbsOf(x *big.Int, limbs int) []uint64 {
+	z := make([]uint64, limbs)
+	setLimbs(z, x)
+	return z
+}
+
+// setLimbs sets z to x < 2^(64 len(z)).
+func setLimbs(z []uint64, x *big.Int) {
+	var buf [8 * maxLimbs]byte
+	var b []byte
+	if len(z) > maxLimbs {
+		b = make([]byte, 8*len(z))
+	} else {
+		b = buf[:8*len(z)]
+	}
+	x.FillBytes(b)
+	for i := range z {
+		z[i] = 0
+		for _, c := range b[len(b)-8*(i+1) : len(b)-8*i] {
+			z[i] = z[i]<<8 | uint64(c)
+		}
+	}
+	clear(b)
+}
+
+func (f *scalarField) element() []uint64 {
+	return make([]uint64, len(f.n))
+}
+
+// fromBig returns x mod n in Montgomery form. Reducing x is only constant
+// time if it is already normalized.
+func (f *scalarField) fromBig(x *big.Int) []uint64 {
+	z := f.element()
+	f.setBig(z, x)
+	return z
+}
+
+// setBig sets z to x mod n in Montgomery form, like fromBig.
+func (f *scalarField) setBig(z []uint64, x *big.Int) {
+	if x.Sign() < 0 || x.Cmp(f.order) >= 0 {
+		x = new(big.Int).Mod(x, f.order)
+	}
+	setLimbs(z, x)
+	f.mul(z, z, f.r2)
+}
+
+// toBig returns a out of Montgomery form.
+func (f *scalarField) toBig(a []uint64) *big.Int {
+	z := f.element()
+	f.mul(z, a, f.one)
+	x := f.big(z)
+	clear(z)
+	return x
+}
+
+func (f *scalarField) big(a []uint64) *big.Int {
+	b := make([]byte, 8*len(a))
+	for i, w := range a {
+		for j := 0; j < 8; j++ {
+			b[len(b)-8*i-1-j] = byte(w >> (8 * j))
+		}
+	}
+	x := new(big.Int).SetBytes(b)
+	clear(b)
+	return x
+}
+
+// addBig sets z = z + a mod n.
+func (f *scalarField) addBig(z []uint64, a *big.Int) {
+	am := f.fromBig(a)
+	f.add(z, z, am)
+	clear(am)
+}
+
+// mulAdd sets z = z + a * b mod n.
+func (f *scalarField) mulAdd(z []uint64, a, b *big.Int) {
+	am, bm := f.fromBig(a), f.fromBig(b)
+	f.mul(am, am, bm)
+	f.add(z, z, am)
+	clear(am)
+	clear(bm)
+}
+
+// add sets z = a + b mod n.
+func (f *scalarField) add(z, a, b []uint64) {
+	var buf [maxLimbs]uint64
+	t := scratch(buf[:], len(f.n))
+	var carry uint64
+	for i := range t {
+		t[i], carry = bits.Add64(a[i], b[i], carry)
+	}
+	f.reduceOnce(z, t, carry)
+	clear(t)
+}
+
+// sub sets z = a - b mod n.
+func (f *scalarField) sub(z, a, b []uint64) {
+	var buf [maxLimbs]uint64
+	t := scratch(buf[:], len(f.n))
+	var borrow uint64
+	for i := range t {
+		t[i], borrow = bits.Sub64(a[i], b[i], borrow)
+	}
+	// add n back if the subtraction wrapped around
+	mask := -borrow
+	var carry uint64
+	for i := range z {
+		z[i], carry = bits.Add64(t[i], f.n[i]&mask, carry)
+	}
+	clear(t)
+}
+
+// mul sets z = a * b / R mod n, by CIOS Montgomery multiplication.
+func (f *scalarField) mul(z, a, b []uint64) {
+	l := len(f.n)
+	var buf [maxLimbs + 2]uint64
+	t := scratch(buf[:], l+2)
+	for i := 0; i < l; i++ {
+		var c uint64
+		for j := 0; j < l; j++ {
+			hi, lo := bits.Mul64(a[j], b[i])
+			var carry uint64
+			lo, carry = bits.Add64(lo, t[j], 0)
+			hi += carry
+			lo, carry = bits.Add64(lo, c, 0)
+			hi += carry
+			t[j], c = lo, hi
+		}
+		var carry uint64
+		t[l], carry = bits.Add64(t[l], c, 0)
+		t[l+1] = carry
+
+		m := t[0] * f.n0inv
+		hi, lo := bits.Mul64(m, f.n[0])
+		_, carry = bits.Add64(lo, t[0], 0)
+		c = hi + carry
+		for j := 1; j < l; j++ {
+			hi, lo := bits.Mul64(m, f.n[j])
+			lo, carry = bits.Add64(lo, t[j], 0)
+			hi += carry
+			lo, carry = bits.Add64(lo, c, 0)
+			hi += carry
+			t[j-1], c = lo, hi
+		}
+		t[l-1], carry = bits.Add64(t[l], c, 0)
+		t[l] = t[l+1] + carry
+	}
+	f.reduceOnce(z, t[:l], t[l])
+	clear(t)
+}
+
+// reduceOnce sets z = t mod n for t = hi * 2^(64l) + t < 2n.
+func (f *scalarField) reduceOnce(z, t []uint64, hi uint64) {
+	var buf [maxLimbs]uint64
+	u := scratch(buf[:], len(f.n))
+	var borrow uint64
+	for i := range u {
+		u[i], borrow = bits.Sub64(t[i], f.n[i], borrow)
+	}
+	// keep t - n unless it wrapped around without t overflowing
+	keep := -(hi | (borrow ^ 1))
+	for i := range z {
+		z[i] = u[i]&keep | t[i]&^keep
+	}
+	clear(u)
+}
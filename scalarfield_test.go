@@ -0,0 +1,68 @@
+package dkg
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/mikalv/dkg/edwards25519"
+	"github.com/mikalv/dkg/secp256k1"
+)
+
+func TestScalarField(t *testing.T) {
+	for _, curve := range []elliptic.Curve{elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521(), secp256k1.S256(), edwards25519.Curve()} {
+		n := curve.Params().N
+		f := scalarFieldFor(n)
+		values := []*big.Int{big.NewInt(0), big.NewInt(1), new(big.Int).Sub(n, one), new(big.Int).Rsh(n, 1)}
+		for i := 0; i < 8; i++ {
+			k, _ := rand.Int(rand.Reader, n)
+			values = append(values, k)
+		}
+
+		for _, a := range values {
+			if x := f.toBig(f.fromBig(a)); x.Cmp(a) != 0 {
+				t.Errorf("%v: %x converted to %x", curve.Params().Name, a, x)
+			}
+			for _, b := range values {
+				am, bm, z := f.fromBig(a), f.fromBig(b), f.element()
+				f.add(z, am, bm)
+				if x, want := f.toBig(z), new(big.Int).Add(a, b); x.Cmp(want.Mod(want, n)) != 0 {
+					t.Errorf("%v: %x + %x = %x, expected %x", curve.Params().Name, a, b, x, want)
+				}
+				f.sub(z, am, bm)
+				if x, want := f.toBig(z), new(big.Int).Sub(a, b); x.Cmp(want.Mod(want, n)) != 0 {
+					t.Errorf("%v: %x - %x = %x, expected %x", curve.Params().Name, a, b, x, want)
+				}
+				f.mul(z, am, bm)
+				if x, want := f.toBig(z), new(big.Int).Mul(a, b); x.Cmp(want.Mod(want, n)) != 0 {
+					t.Errorf("%v: %x * %x = %x, expected %x", curve.Params().Name, a, b, x, want)
+				}
+			}
+		}
+
+		poly, _ := GenerateScalarPolynomial(curve, 3, rand.Reader)
+		x, _ := rand.Int(rand.Reader, n)
+		want := new(big.Int)
+		for i := len(poly) - 1; i >= 0; i-- {
+			want.Mul(want, x).Add(want, poly[i]).Mod(want, n)
+		}
+		if got := poly.evaluate(x, n); got.Cmp(want) != 0 {
+			t.Errorf("%v: polynomial evaluated to %x, expected %x", curve.Params().Name, got, want)
+		}
+	}
+}
+
+func BenchmarkPolynomialEvaluate(b *testing.B) {
+	curve := elliptic.P256()
+	for _, threshold := range []int{15, 127} {
+		poly, _ := GenerateScalarPolynomial(curve, threshold, rand.Reader)
+		b.Run(fmt.Sprintf("t=%v", threshold), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				poly.evaluate(big.NewInt(int64(i%256+1)), curve.Params().N)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package dkg
+
+import "context"
+import "fmt"
+import "math/rand/v2"
+import "sync"
+import "time"
+
+// RefreshScheduler refreshes a participant's key share proactively, with
+// RefreshShare, in a ceremony among the group's participants every
+// Interval. Rounds start on multiples of Interval since the Unix epoch, so
+// that participants with roughly synchronized clocks agree on them without
+// talking, each delayed by a random jitter of up to Jitter to spread the
+// load. The participants of a round meet in a session named after the
+// group key and the epoch, waiting for each other in a handshake of up to
+// Jitter plus the node's timeout, and abort the update ceremony if any of
+// them misses a phase: a participant left out would keep a share that no
+// longer fits the others'.
+type RefreshScheduler struct {
+	Interval time.Duration
+	Jitter   time.Duration
+	// Before, if set, is called with the share before each round; an error
+	// skips the round.
+	Before func(share *KeyShare) error
+	// After, if set, is called after each round with the share before it,
+	// the refreshed share and the error that failed the round, if any.
+	After func(old, refreshed *KeyShare, err error)
+
+	manager      *SessionManager
+	config       NodeConfig
+	participants []Participant
+
+	mu      sync.Mutex
+	share   *KeyShare
+	attempt int
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewRefreshScheduler refreshes share over manager's sessions, among
+// participants, the group's participants. The nodes of the update
+// ceremonies are made from config, with share's ID and the threshold and
+// polynomials set for each round.
+func NewRefreshScheduler(manager *SessionManager, config NodeConfig, participants []Participant, share *KeyShare) *RefreshScheduler {
+	return &RefreshScheduler{
+		manager:      manager,
+		config:       config,
+		participants: participants,
+		share:        share,
+	}
+}
+
+// Share returns the current key share.
+func (s *RefreshScheduler) Share() *KeyShare {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.share
+}
+
+// Start schedules the refreshes until Stop is called. The settings and
+// hooks must not change afterwards.
+func (s *RefreshScheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel, s.done = cancel, make(chan struct{})
+	go s.run(ctx, s.done)
+}
+
+// Stop cancels the scheduled refreshes, and the one running if any, and
+// waits for them to end.
+func (s *RefreshScheduler) Stop() {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel = nil
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+func (s *RefreshScheduler) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	for {
+		next := time.Now().Truncate(s.Interval).Add(s.Interval)
+		if s.Jitter > 0 {
+			next = next.Add(rand.N(s.Jitter))
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		old := s.Share()
+		refreshed, err := s.refresh(ctx, old)
+		if ctx.Err() != nil {
+			return
+		}
+		s.mu.Lock()
+		if err == nil {
+			s.share, s.attempt = refreshed, 0
+		} else {
+			s.attempt++
+		}
+		s.mu.Unlock()
+		if s.After != nil {
+			s.After(old, refreshed, err)
+		}
+	}
+}
+
+// refresh runs a round. Failed rounds of an epoch are retried in sessions
+// of their own, numbered by attempt.
+func (s *RefreshScheduler) refresh(ctx context.Context, share *KeyShare) (*KeyShare, error) {
+	if s.Before != nil {
+		if err := s.Before(share); err != nil {
+			return nil, err
+		}
+	}
+	config := s.config
+	config.ID = share.ID
+	config.Threshold = share.Threshold - 1
+	config.SecretPoly1, config.SecretPoly2 = nil, nil
+	node, err := NewNodeWithOptions(WithConfig(config))
+	if err != nil {
+		return nil, err
+	}
+	participants, err := NewParticipantSet(config.Curve, config.Threshold, s.participants)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	session := fmt.Sprintf("refresh/%s/%d/%d", Fingerprint(share.PublicKey), share.Epoch+1, s.attempt)
+	s.mu.Unlock()
+	runner, err := s.manager.Open(session, node, participants)
+	if err != nil {
+		return nil, err
+	}
+	defer s.manager.End(session)
+	runner.CalibrateTimeouts(TimeoutPolicy{}, s.Jitter+config.Timeout)
+	runner.Liveness(AbortOnMissing, 0)
+	if err := runner.RunContext(ctx); err != nil {
+		return nil, err
+	}
+	update, err := runner.Result()
+	if err != nil {
+		return nil, err
+	}
+	return RefreshShare(share, update)
+}
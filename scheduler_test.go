@@ -0,0 +1,66 @@
+package dkg
+
+import (
+	"crypto/sha512"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRefreshScheduler(t *testing.T) {
+	const size, threshold, rounds = 4, 2, 2
+	nodes, participants := getCeremonyNodesForTesting(t, size, threshold)
+	shares := runCeremonyForTesting(t, nodes, participants)
+	_, _, g2x, g2y, zkParam, _, _, _, _, _ := getValidNodeParamsForTesting(t)
+
+	network := NewMemoryNetwork()
+	var wg sync.WaitGroup
+	refreshed := make([]*KeyShare, size)
+	schedulers := make([]*RefreshScheduler, size)
+	for i, node := range nodes {
+		if shares[i] == nil {
+			t.Fatalf("Node %v did not finish", node.ID())
+		}
+		manager := NewSessionManager(network.Transport(node.ID()))
+		defer manager.Close()
+		config := NodeConfig{
+			Curve: node.curve, Hash: sha512.New512_256(), G2X: g2x, G2Y: g2y, ZKParam: zkParam,
+			Timeout: 200 * time.Millisecond, Key: node.key,
+		}
+		s := NewRefreshScheduler(manager, config, participants, shares[i])
+		s.Interval, s.Jitter = 300*time.Millisecond, 100*time.Millisecond
+		wg.Add(1)
+		s.Before = func(share *KeyShare) error {
+			if share != s.Share() {
+				t.Errorf("Before got share %v of epoch %v", share.ID, share.Epoch)
+			}
+			return nil
+		}
+		s.After = func(old, share *KeyShare, err error) {
+			if err != nil {
+				t.Errorf("Could not refresh share of %v: %v", old.ID, err)
+			} else if share.Epoch == rounds {
+				refreshed[i] = share
+				wg.Done()
+			}
+		}
+		schedulers[i] = s
+	}
+	for _, s := range schedulers {
+		s.Start()
+	}
+	wg.Wait()
+	for _, s := range schedulers {
+		s.Stop()
+	}
+
+	for i := range refreshed {
+		if refreshed[i].Share.Cmp(shares[i].Share) == 0 {
+			t.Errorf("Share of %v didn't change", refreshed[i].ID)
+		}
+	}
+	checkCeremonyResultsForTesting(t, refreshed)
+	if refreshed[0].PublicKey.X.Cmp(shares[0].PublicKey.X) != 0 || refreshed[0].PublicKey.Y.Cmp(shares[0].PublicKey.Y) != 0 {
+		t.Errorf("Group key changed")
+	}
+}
@@ -0,0 +1,325 @@
+package dkg
+
+import "crypto/rand"
+import "encoding/asn1"
+import "io"
+import "math/big"
+import "sort"
+import "sync"
+
+// ThresholdScheme is a threshold operation with the key shares of a
+// ceremony, so that callers can drive signing, decryption and VRF
+// evaluation alike, and pick the scheme by name from the registry:
+//
+//   - Keygen adapts a key share from a ceremony to the scheme, checking
+//     that the scheme can use it, and returns the group key its partials
+//     verify against
+//   - PartialSign returns the participant's partial of the operation on
+//     message
+//   - VerifyPartial checks a participant's partial against the group key
+//   - Aggregate combines threshold+1 partials, or 2t+1 for ECDSA, into the
+//     outcome, which it checks against the group key
+//
+// What the participants agreed on ahead of the operation, such as nonces,
+// is passed in a SchemeRound.
+type ThresholdScheme interface {
+	Name() string
+	Keygen(share *KeyShare) (GroupKey, error)
+	PartialSign(key *KeyShare, round *SchemeRound, message []byte) (Partial, error)
+	VerifyPartial(group GroupKey, round *SchemeRound, message []byte, partial Partial) error
+	Aggregate(group GroupKey, round *SchemeRound, message []byte, partials []Partial) ([]byte, error)
+}
+
+// Partial is a participant's share of a threshold operation: a
+// PartialSignature, DecryptionShare or VRFPartial.
+type Partial interface {
+	Signer() *big.Int
+}
+
+func (p PartialSignature) Signer() *big.Int {
+	return p.ID
+}
+
+func (s DecryptionShare) Signer() *big.Int {
+	return s.ID
+}
+
+func (p VRFPartial) Signer() *big.Int {
+	return p.ID
+}
+
+// SchemeRound holds what the participants of one operation agreed on
+// before it, as far as its scheme needs it; it may be nil for the others.
+type SchemeRound struct {
+	// ECDSA: the participant's nonces, and the product shares of the
+	// signers for PartialSign; Aggregate only needs the nonces' public keys
+	Nonces   *SigningNonces
+	Products []DealtShare
+	// FROST: the participant's nonces for PartialSign, and the signers'
+	// commitments
+	FROSTNonces *FROSTNonces
+	Commitments []FROSTCommitment
+	// ElGamal: the associated data the ciphertext was sealed with
+	AD []byte
+	// the randomness of the proofs of ElGamal and VRF partials,
+	// crypto/rand's Reader if nil
+	Random io.Reader
+}
+
+func (r *SchemeRound) orEmpty() *SchemeRound {
+	if r == nil {
+		return &SchemeRound{}
+	}
+	return r
+}
+
+func (r *SchemeRound) random() io.Reader {
+	if r.Random == nil {
+		return rand.Reader
+	}
+	return r.Random
+}
+
+var schemesMu sync.RWMutex
+var schemes = map[string]ThresholdScheme{}
+
+func init() {
+	for _, s := range []ThresholdScheme{ECDSAScheme, FROSTScheme, ElGamalScheme, VRFScheme} {
+		RegisterScheme(s)
+	}
+}
+
+// RegisterScheme makes scheme known by its name, replacing any scheme of
+// that name.
+func RegisterScheme(scheme ThresholdScheme) {
+	schemesMu.Lock()
+	defer schemesMu.Unlock()
+	schemes[scheme.Name()] = scheme
+}
+
+// SchemeByName returns the registered scheme of the given name.
+func SchemeByName(name string) (ThresholdScheme, bool) {
+	schemesMu.RLock()
+	defer schemesMu.RUnlock()
+	scheme, ok := schemes[name]
+	return scheme, ok
+}
+
+// Schemes returns the names of the registered schemes, sorted.
+func Schemes() []string {
+	schemesMu.RLock()
+	defer schemesMu.RUnlock()
+	names := make([]string, 0, len(schemes))
+	for name := range schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// The schemes built on the operations of this package, registered by
+// their names.
+var (
+	// ECDSAScheme signs digests with threshold ECDSA, SignatureShare and
+	// CombineSignature, into ASN.1 signatures. Its partials can't be
+	// checked on their own: VerifyPartial only checks their form, and a bad
+	// one makes Aggregate fail.
+	ECDSAScheme ThresholdScheme = ecdsaScheme{}
+	// FROSTScheme signs messages with FROST, into Ed25519 signatures on
+	// edwards25519 and BIP-340 signatures on secp256k1.
+	FROSTScheme ThresholdScheme = frostScheme{}
+	// ElGamalScheme decrypts ciphertexts of EncryptToGroup, the message,
+	// into their plaintext.
+	ElGamalScheme ThresholdScheme = elGamalScheme{}
+	// VRFScheme evaluates the group's VRF on the message, into the binary
+	// encoding of the VRFOutput.
+	VRFScheme ThresholdScheme = vrfScheme{}
+)
+
+// adapt checks that the share can be used for op and returns its group.
+func adapt(share *KeyShare, op KeyOperation) (GroupKey, error) {
+	if err := share.usable(); err != nil {
+		return GroupKey{}, err
+	}
+	if err := share.permits(op); err != nil {
+		return GroupKey{}, err
+	}
+	return share.Group(), nil
+}
+
+type ecdsaScheme struct{}
+
+func (ecdsaScheme) Name() string {
+	return "ecdsa"
+}
+
+func (ecdsaScheme) Keygen(share *KeyShare) (GroupKey, error) {
+	return adapt(share, UseSigning)
+}
+
+func (ecdsaScheme) PartialSign(key *KeyShare, round *SchemeRound, message []byte) (Partial, error) {
+	round = round.orEmpty()
+	return SignatureShare(key, round.Nonces, round.Products, message)
+}
+
+func (ecdsaScheme) VerifyPartial(group GroupKey, round *SchemeRound, message []byte, partial Partial) error {
+	p, ok := partial.(PartialSignature)
+	if !ok {
+		return SchemeMismatchError{"ecdsa"}
+	}
+	if p.Epoch != group.Epoch {
+		return MixedEpochError{group.Epoch, p.Epoch}
+	}
+	n := group.PublicKey.Curve.Params().N
+	if !validParticipantID(p.ID, n) {
+		return InvalidParticipantIDError{p.ID}
+	}
+	if !isNormalizedScalar(p.Share, n) {
+		return InvalidPartialSignatureError{p.ID}
+	}
+	return nil
+}
+
+func (ecdsaScheme) Aggregate(group GroupKey, round *SchemeRound, message []byte, partials []Partial) ([]byte, error) {
+	round = round.orEmpty()
+	if round.Nonces == nil || round.Nonces.K == nil {
+		return nil, MissingRoundParameterError{"ecdsa", "Nonces"}
+	}
+	ps := make([]PartialSignature, len(partials))
+	for i, partial := range partials {
+		p, ok := partial.(PartialSignature)
+		if !ok {
+			return nil, SchemeMismatchError{"ecdsa"}
+		}
+		ps[i] = p
+	}
+	r, s, err := combineGroupSignature(group, round.Nonces, ps, message, interpolateShares)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(struct{ R, S *big.Int }{r, s})
+}
+
+type frostScheme struct{}
+
+func (frostScheme) Name() string {
+	return "frost"
+}
+
+func (frostScheme) Keygen(share *KeyShare) (GroupKey, error) {
+	if _, err := schnorrSchemeFor(share.PublicKey.Curve); err != nil {
+		return GroupKey{}, err
+	}
+	return adapt(share, UseSigning)
+}
+
+func (frostScheme) PartialSign(key *KeyShare, round *SchemeRound, message []byte) (Partial, error) {
+	round = round.orEmpty()
+	return FROSTSignatureShare(key, round.FROSTNonces, round.Commitments, message)
+}
+
+func (frostScheme) VerifyPartial(group GroupKey, round *SchemeRound, message []byte, partial Partial) error {
+	p, ok := partial.(PartialSignature)
+	if !ok {
+		return SchemeMismatchError{"frost"}
+	}
+	if p.Epoch != group.Epoch {
+		return MixedEpochError{group.Epoch, p.Epoch}
+	}
+	s, err := newFROSTSession(group.PublicKey, group.Threshold, round.orEmpty().Commitments, message)
+	if err != nil {
+		return err
+	}
+	i := -1
+	if p.ID != nil {
+		i = s.index(p.ID)
+	}
+	if i < 0 || !s.verifyShare(group, i, p.Share) {
+		return InvalidPartialSignatureError{p.ID}
+	}
+	return nil
+}
+
+func (frostScheme) Aggregate(group GroupKey, round *SchemeRound, message []byte, partials []Partial) ([]byte, error) {
+	ps := make([]PartialSignature, len(partials))
+	for i, partial := range partials {
+		p, ok := partial.(PartialSignature)
+		if !ok {
+			return nil, SchemeMismatchError{"frost"}
+		}
+		ps[i] = p
+	}
+	return CombineFROSTSignature(group, round.orEmpty().Commitments, ps, message)
+}
+
+type elGamalScheme struct{}
+
+func (elGamalScheme) Name() string {
+	return "elgamal"
+}
+
+func (elGamalScheme) Keygen(share *KeyShare) (GroupKey, error) {
+	return adapt(share, UseDecryption)
+}
+
+func (elGamalScheme) PartialSign(key *KeyShare, round *SchemeRound, message []byte) (Partial, error) {
+	return PartialDecrypt(key, message, round.orEmpty().random())
+}
+
+func (elGamalScheme) VerifyPartial(group GroupKey, round *SchemeRound, message []byte, partial Partial) error {
+	share, ok := partial.(DecryptionShare)
+	if !ok {
+		return SchemeMismatchError{"elgamal"}
+	}
+	return VerifyDecryptionShare(group, message, share)
+}
+
+func (elGamalScheme) Aggregate(group GroupKey, round *SchemeRound, message []byte, partials []Partial) ([]byte, error) {
+	shares := make([]DecryptionShare, len(partials))
+	for i, partial := range partials {
+		share, ok := partial.(DecryptionShare)
+		if !ok {
+			return nil, SchemeMismatchError{"elgamal"}
+		}
+		shares[i] = share
+	}
+	return CombineDecryption(group, message, round.orEmpty().AD, shares)
+}
+
+type vrfScheme struct{}
+
+func (vrfScheme) Name() string {
+	return "vrf"
+}
+
+func (vrfScheme) Keygen(share *KeyShare) (GroupKey, error) {
+	return adapt(share, UseVRF)
+}
+
+func (vrfScheme) PartialSign(key *KeyShare, round *SchemeRound, message []byte) (Partial, error) {
+	return EvaluateVRF(key, message, round.orEmpty().random())
+}
+
+func (vrfScheme) VerifyPartial(group GroupKey, round *SchemeRound, message []byte, partial Partial) error {
+	p, ok := partial.(VRFPartial)
+	if !ok {
+		return SchemeMismatchError{"vrf"}
+	}
+	return VerifyVRFPartial(group, message, p)
+}
+
+func (vrfScheme) Aggregate(group GroupKey, round *SchemeRound, message []byte, partials []Partial) ([]byte, error) {
+	ps := make([]VRFPartial, len(partials))
+	for i, partial := range partials {
+		p, ok := partial.(VRFPartial)
+		if !ok {
+			return nil, SchemeMismatchError{"vrf"}
+		}
+		ps[i] = p
+	}
+	output, err := CombineVRF(group, message, ps)
+	if err != nil {
+		return nil, err
+	}
+	return output.MarshalBinary()
+}
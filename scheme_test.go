@@ -0,0 +1,150 @@
+package dkg
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mikalv/dkg/secp256k1"
+)
+
+// runSchemeForTesting has the signers make their partials of message with
+// scheme, checks them and aggregates them.
+func runSchemeForTesting(t *testing.T, scheme ThresholdScheme, signers []*KeyShare, rounds []*SchemeRound, message []byte) []byte {
+	t.Helper()
+	group, err := scheme.Keygen(signers[0])
+	if err != nil {
+		t.Fatalf("Could not adapt the key share: %v", err)
+	}
+	partials := make([]Partial, len(signers))
+	for i, key := range signers {
+		if partials[i], err = scheme.PartialSign(key, rounds[i], message); err != nil {
+			t.Fatalf("Signer %v failed: %v", key.ID, err)
+		}
+		if partials[i].Signer().Cmp(key.ID) != 0 {
+			t.Errorf("Partial of %v is of %v", key.ID, partials[i].Signer())
+		}
+		if err := scheme.VerifyPartial(group, rounds[0], message, partials[i]); err != nil {
+			t.Errorf("Partial of %v does not verify: %v", key.ID, err)
+		}
+	}
+	outcome, err := scheme.Aggregate(group, rounds[0], message, partials)
+	if err != nil {
+		t.Fatalf("Could not aggregate: %v", err)
+	}
+	return outcome
+}
+
+func TestThresholdSchemes(t *testing.T) {
+	if names := Schemes(); !reflect.DeepEqual(names, []string{"ecdsa", "elgamal", "frost", "vrf"}) {
+		t.Errorf("Got schemes %v", names)
+	}
+	if _, ok := SchemeByName("bls"); ok {
+		t.Errorf("Found an unknown scheme")
+	}
+
+	nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+	keys := runCeremonyForTesting(t, nodes, participants)
+	checkCeremonyResultsForTesting(t, keys)
+	group := keys[0].Group()
+	none := make([]*SchemeRound, len(keys))
+
+	t.Run("ElGamal", func(t *testing.T) {
+		scheme, _ := SchemeByName("elgamal")
+		ciphertext, err := EncryptToGroup(group, []byte("secret"), []byte("ad"), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rounds := []*SchemeRound{{AD: []byte("ad")}, nil, nil}
+		if plaintext := runSchemeForTesting(t, scheme, keys, rounds, ciphertext); string(plaintext) != "secret" {
+			t.Errorf("Decrypted %q", plaintext)
+		}
+	})
+
+	t.Run("VRF", func(t *testing.T) {
+		scheme, _ := SchemeByName("vrf")
+		var output VRFOutput
+		if err := output.UnmarshalBinary(runSchemeForTesting(t, scheme, keys, none, []byte("input"))); err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyVRF(group, &output); err != nil || !bytes.Equal(output.Input, []byte("input")) {
+			t.Errorf("VRF output does not verify: %v", err)
+		}
+	})
+
+	t.Run("ECDSA", func(t *testing.T) {
+		const size, threshold = 3, 1
+		keys, nonces := runSigningCeremoniesForTesting(t, size, threshold)
+		digest := sha256.Sum256([]byte("scheme"))
+		products := make([]DealtShare, size)
+		for i := range keys {
+			product, err := ProductShare(keys[i], nonces[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			products[i] = product
+		}
+		rounds := make([]*SchemeRound, size)
+		for i := range rounds {
+			rounds[i] = &SchemeRound{Nonces: nonces[i], Products: products}
+		}
+		sig := runSchemeForTesting(t, ECDSAScheme, keys, rounds, digest[:])
+		if !ecdsa.VerifyASN1(&keys[0].PublicKey, digest[:], sig) {
+			t.Errorf("Signature does not verify")
+		}
+		if _, err := ECDSAScheme.Aggregate(keys[0].Group(), nil, digest[:], nil); !reflect.DeepEqual(err, MissingRoundParameterError{"ecdsa", "Nonces"}) {
+			t.Errorf("Got unexpected error aggregating without nonces: %v", err)
+		}
+	})
+
+	t.Run("FROST", func(t *testing.T) {
+		if _, err := FROSTScheme.Keygen(keys[0]); !reflect.DeepEqual(err, UnsupportedCurveError{keys[0].PublicKey.Curve, "Schnorr signature"}) {
+			t.Errorf("Got unexpected error adapting a P-256 share: %v", err)
+		}
+		curve := secp256k1.S256()
+		k, _ := randomScalar(curve.Params().N, rand.Reader)
+		g2x, g2y := curve.ScalarBaseMult(k.Bytes())
+		nodes, participants := getCeremonyNodesOnCurveForTesting(t, curve, g2x, g2y, 3, 1)
+		for _, node := range nodes {
+			// the math/big curves are slow under the race detector
+			node.timeout = 10 * time.Second
+		}
+		keys := runCeremonyForTesting(t, nodes, participants)
+		checkCeremonyResultsForTesting(t, keys)
+		rounds := make([]*SchemeRound, len(keys))
+		commitments := make([]FROSTCommitment, len(keys))
+		for i, key := range keys {
+			nonces, commitment, err := NewFROSTNonces(key, rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rounds[i], commitments[i] = &SchemeRound{FROSTNonces: nonces}, commitment
+		}
+		for _, round := range rounds {
+			round.Commitments = commitments
+		}
+		sig := runSchemeForTesting(t, FROSTScheme, keys, rounds, []byte("message"))
+		if !VerifySchnorrSignature(keys[0].PublicKey, []byte("message"), sig) {
+			t.Errorf("Signature does not verify")
+		}
+		forged := PartialSignature{keys[1].ID, keys[1].Epoch, big.NewInt(1)}
+		if err := FROSTScheme.VerifyPartial(keys[0].Group(), rounds[0], []byte("message"), forged); !reflect.DeepEqual(err, InvalidPartialSignatureError{keys[1].ID}) {
+			t.Errorf("Got unexpected error for a forged partial: %v", err)
+		}
+	})
+
+	t.Run("Mismatched partial", func(t *testing.T) {
+		partial, err := VRFScheme.PartialSign(keys[0], nil, []byte("input"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ElGamalScheme.VerifyPartial(group, nil, nil, partial); !reflect.DeepEqual(err, SchemeMismatchError{"elgamal"}) {
+			t.Errorf("Got unexpected error for a VRF partial: %v", err)
+		}
+	})
+}
@@ -0,0 +1,258 @@
+// Package secp256k1 implements the secp256k1 curve of SEC 2, used by
+// Bitcoin and Ethereum, as a crypto/elliptic compatible curve.
+//
+// The arithmetic uses math/big and is not constant time.
+package secp256k1
+
+import "crypto/elliptic"
+import "math/big"
+import "math/bits"
+import "sync"
+
+// curve is y^2 = x^3 + 7. The generic implementation behind
+// elliptic.CurveParams assumes a = -3, so the arithmetic is done here, in
+// Jacobian coordinates with a = 0.
+type curve struct {
+	params *elliptic.CurveParams
+}
+
+var initOnce sync.Once
+var s256 curve
+
+func initS256() {
+	params := &elliptic.CurveParams{Name: "secp256k1", BitSize: 256}
+	params.P, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", 16)
+	params.N, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+	params.B = big.NewInt(7)
+	params.Gx, _ = new(big.Int).SetString("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", 16)
+	params.Gy, _ = new(big.Int).SetString("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8", 16)
+	s256 = curve{params}
+}
+
+// S256 returns the secp256k1 curve.
+func S256() elliptic.Curve {
+	initOnce.Do(initS256)
+	return s256
+}
+
+func (c curve) Params() *elliptic.CurveParams {
+	return c.params
+}
+
+func (c curve) IsOnCurve(x, y *big.Int) bool {
+	p := c.params.P
+	if x.Sign() < 0 || x.Cmp(p) >= 0 || y.Sign() < 0 || y.Cmp(p) >= 0 {
+		return false
+	}
+	y2 := new(big.Int).Mul(y, y)
+	y2.Mod(y2, p)
+	x3 := new(big.Int).Mul(x, x)
+	x3.Mul(x3, x)
+	x3.Add(x3, c.params.B)
+	x3.Mod(x3, p)
+	return x3.Cmp(y2) == 0
+}
+
+// Lift returns the point with x coordinate t and even y, if there is one.
+func (c curve) Lift(t *big.Int) (x, y *big.Int, ok bool) {
+	p := c.params.P
+	if t.Sign() < 0 || t.Cmp(p) >= 0 {
+		return nil, nil, false
+	}
+	y2 := new(big.Int).Mul(t, t)
+	y2.Mul(y2, t)
+	y2.Add(y2, c.params.B)
+	if y = new(big.Int).ModSqrt(y2.Mod(y2, p), p); y == nil {
+		return nil, nil, false
+	}
+	if y.Bit(0) == 1 {
+		y.Sub(p, y)
+	}
+	return new(big.Int).Set(t), y, true
+}
+
+// jacobian is the point (X/Z^2, Y/Z^3), the point at infinity when Z = 0.
+type jacobian struct {
+	x, y, z *big.Int
+}
+
+func (c curve) fromAffine(x, y *big.Int) jacobian {
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return jacobian{new(big.Int), new(big.Int), new(big.Int)}
+	}
+	return jacobian{new(big.Int).Set(x), new(big.Int).Set(y), big.NewInt(1)}
+}
+
+func (c curve) toAffine(a jacobian) (*big.Int, *big.Int) {
+	if a.z.Sign() == 0 {
+		return new(big.Int), new(big.Int)
+	}
+	p := c.params.P
+	zinv := new(big.Int).ModInverse(a.z, p)
+	zinv2 := new(big.Int).Mul(zinv, zinv)
+	x := new(big.Int).Mul(a.x, zinv2)
+	x.Mod(x, p)
+	y := new(big.Int).Mul(a.y, zinv2.Mul(zinv2, zinv))
+	y.Mod(y, p)
+	return x, y
+}
+
+// double uses dbl-2009-l.
+func (c curve) double(a jacobian) jacobian {
+	p := c.params.P
+	if a.z.Sign() == 0 || a.y.Sign() == 0 {
+		return jacobian{new(big.Int), new(big.Int), new(big.Int)}
+	}
+	A := new(big.Int).Mul(a.x, a.x)
+	B := new(big.Int).Mul(a.y, a.y)
+	C := new(big.Int).Mul(B, B)
+	D := new(big.Int).Add(a.x, B)
+	D.Mul(D, D)
+	D.Sub(D, A)
+	D.Sub(D, C)
+	D.Lsh(D, 1)
+	E := new(big.Int).Mul(A, big.NewInt(3))
+	F := new(big.Int).Mul(E, E)
+
+	x3 := new(big.Int).Sub(F, new(big.Int).Lsh(D, 1))
+	x3.Mod(x3, p)
+	y3 := new(big.Int).Sub(D, x3)
+	y3.Mul(y3, E)
+	y3.Sub(y3, C.Lsh(C, 3))
+	y3.Mod(y3, p)
+	z3 := new(big.Int).Mul(a.y, a.z)
+	z3.Lsh(z3, 1)
+	z3.Mod(z3, p)
+	return jacobian{x3, y3, z3}
+}
+
+// add uses add-2007-bl.
+func (c curve) add(a, b jacobian) jacobian {
+	p := c.params.P
+	if a.z.Sign() == 0 {
+		return b
+	}
+	if b.z.Sign() == 0 {
+		return a
+	}
+	z1z1 := new(big.Int).Mul(a.z, a.z)
+	z1z1.Mod(z1z1, p)
+	z2z2 := new(big.Int).Mul(b.z, b.z)
+	z2z2.Mod(z2z2, p)
+	u1 := new(big.Int).Mul(a.x, z2z2)
+	u1.Mod(u1, p)
+	u2 := new(big.Int).Mul(b.x, z1z1)
+	u2.Mod(u2, p)
+	s1 := new(big.Int).Mul(a.y, b.z)
+	s1.Mul(s1, z2z2)
+	s1.Mod(s1, p)
+	s2 := new(big.Int).Mul(b.y, a.z)
+	s2.Mul(s2, z1z1)
+	s2.Mod(s2, p)
+
+	h := new(big.Int).Sub(u2, u1)
+	h.Mod(h, p)
+	r := new(big.Int).Sub(s2, s1)
+	r.Mod(r, p)
+	if h.Sign() == 0 {
+		if r.Sign() == 0 {
+			return c.double(a)
+		}
+		return jacobian{new(big.Int), new(big.Int), new(big.Int)}
+	}
+	r.Lsh(r, 1)
+
+	i := new(big.Int).Lsh(h, 1)
+	i.Mul(i, i)
+	j := new(big.Int).Mul(h, i)
+	v := new(big.Int).Mul(u1, i)
+
+	x3 := new(big.Int).Mul(r, r)
+	x3.Sub(x3, j)
+	x3.Sub(x3, new(big.Int).Lsh(v, 1))
+	x3.Mod(x3, p)
+	y3 := new(big.Int).Sub(v, x3)
+	y3.Mul(y3, r)
+	y3.Sub(y3, s1.Mul(s1, j).Lsh(s1, 1))
+	y3.Mod(y3, p)
+	z3 := new(big.Int).Add(a.z, b.z)
+	z3.Mul(z3, z3)
+	z3.Sub(z3, z1z1)
+	z3.Sub(z3, z2z2)
+	z3.Mul(z3, h)
+	z3.Mod(z3, p)
+	return jacobian{x3, y3, z3}
+}
+
+func (c curve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	return c.toAffine(c.add(c.fromAffine(x1, y1), c.fromAffine(x2, y2)))
+}
+
+func (c curve) Double(x1, y1 *big.Int) (*big.Int, *big.Int) {
+	return c.toAffine(c.double(c.fromAffine(x1, y1)))
+}
+
+func (c curve) ScalarMult(x1, y1 *big.Int, k []byte) (*big.Int, *big.Int) {
+	base := c.fromAffine(x1, y1)
+	acc := jacobian{new(big.Int), new(big.Int), new(big.Int)}
+	for _, b := range k {
+		for bit := 7; bit >= 0; bit-- {
+			acc = c.double(acc)
+			if b>>uint(bit)&1 == 1 {
+				acc = c.add(acc, base)
+			}
+		}
+	}
+	return c.toAffine(acc)
+}
+
+func (c curve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	return c.ScalarMult(c.params.Gx, c.params.Gy, k)
+}
+
+// MultiScalarMult returns the sum of k[i] * (xs[i], ys[i]), the scalars
+// big-endian, with Pippenger's bucket method: far fewer additions than
+// summing the results of ScalarMult when there are many points.
+func (c curve) MultiScalarMult(xs, ys []*big.Int, ks [][]byte) (*big.Int, *big.Int) {
+	infinity := func() jacobian { return jacobian{new(big.Int), new(big.Int), new(big.Int)} }
+	points := make([]jacobian, len(xs))
+	scalars := make([]*big.Int, len(ks))
+	width := 0
+	for i := range xs {
+		points[i] = c.fromAffine(xs[i], ys[i])
+		scalars[i] = new(big.Int).SetBytes(ks[i])
+		width = max(width, scalars[i].BitLen())
+	}
+	w := max(bits.Len(uint(len(xs)))-3, 1)
+	acc := infinity()
+	for shift := (width - 1) / w * w; shift >= 0; shift -= w {
+		for i := 0; i < w; i++ {
+			acc = c.double(acc)
+		}
+		buckets := make([]jacobian, 1<<w)
+		for d := range buckets {
+			buckets[d] = infinity()
+		}
+		for i, k := range scalars {
+			if d := digit(k, shift, w); d > 0 {
+				buckets[d] = c.add(buckets[d], points[i])
+			}
+		}
+		running, sum := infinity(), infinity()
+		for d := len(buckets) - 1; d > 0; d-- {
+			running = c.add(running, buckets[d])
+			sum = c.add(sum, running)
+		}
+		acc = c.add(acc, sum)
+	}
+	return c.toAffine(acc)
+}
+
+// digit returns the w bits of k from shift on.
+func digit(k *big.Int, shift, w int) int {
+	d := 0
+	for i := w - 1; i >= 0; i-- {
+		d = d<<1 | int(k.Bit(shift+i))
+	}
+	return d
+}
@@ -0,0 +1,127 @@
+package secp256k1
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+)
+
+func hexInt(t *testing.T, s string) *big.Int {
+	x, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		t.Fatalf("Bad hex %v", s)
+	}
+	return x
+}
+
+func TestScalarBaseMult(t *testing.T) {
+	curve := S256()
+	vectors := []struct {
+		k, x, y string
+	}{
+		{"1",
+			"79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+			"483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"},
+		{"2",
+			"c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5",
+			"1ae168fea63dc339a3c58419466ceaeef7f632653266d0e1236431a950cfe52a"},
+		{"3",
+			"f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9",
+			"388f7b0f632de8140fe337e62a37f3566500a99934c2231b6cb9fd7584b8e672"},
+		{"fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364140",
+			"79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+			"b7c52588d95c3b9aa25b0403f1eef75702e84bb7597aabe663b82f6f04ef2777"},
+	}
+	for _, v := range vectors {
+		x, y := curve.ScalarBaseMult(hexInt(t, v.k).Bytes())
+		if x.Cmp(hexInt(t, v.x)) != 0 || y.Cmp(hexInt(t, v.y)) != 0 {
+			t.Errorf("%v * G = (%x, %x)", v.k, x, y)
+		}
+		if !curve.IsOnCurve(x, y) {
+			t.Errorf("%v * G not on curve", v.k)
+		}
+	}
+
+	if x, y := curve.ScalarBaseMult(curve.Params().N.Bytes()); x.Sign() != 0 || y.Sign() != 0 {
+		t.Errorf("N * G is not the identity")
+	}
+}
+
+func TestGroupLaw(t *testing.T) {
+	curve := S256()
+	n := curve.Params().N
+	a, _ := rand.Int(rand.Reader, n)
+	b, _ := rand.Int(rand.Reader, n)
+
+	ax, ay := curve.ScalarBaseMult(a.Bytes())
+	bx, by := curve.ScalarBaseMult(b.Bytes())
+	sum := new(big.Int).Add(a, b)
+	ex, ey := curve.ScalarBaseMult(sum.Mod(sum, n).Bytes())
+	if x, y := curve.Add(ax, ay, bx, by); x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+		t.Errorf("a * G + b * G != (a + b) * G")
+	}
+
+	double := new(big.Int).Lsh(a, 1)
+	ex, ey = curve.ScalarBaseMult(double.Mod(double, n).Bytes())
+	if x, y := curve.Double(ax, ay); x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+		t.Errorf("2 * (a * G) != (2a) * G")
+	}
+	if x, y := curve.Add(ax, ay, ax, ay); x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+		t.Errorf("a * G + a * G != (2a) * G")
+	}
+
+	product := new(big.Int).Mul(a, b)
+	ex, ey = curve.ScalarBaseMult(product.Mod(product, n).Bytes())
+	if x, y := curve.ScalarMult(ax, ay, b.Bytes()); x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+		t.Errorf("b * (a * G) != (ab) * G")
+	}
+
+	negY := new(big.Int).Sub(curve.Params().P, ay)
+	if x, y := curve.Add(ax, ay, ax, negY); x.Sign() != 0 || y.Sign() != 0 {
+		t.Errorf("P + (-P) is not the identity")
+	}
+	if x, y := curve.Add(ax, ay, new(big.Int), new(big.Int)); x.Cmp(ax) != 0 || y.Cmp(ay) != 0 {
+		t.Errorf("P + identity != P")
+	}
+}
+
+func TestECDSA(t *testing.T) {
+	key, err := ecdsa.GenerateKey(S256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Could not generate key: %v", err)
+	}
+	digest := sha256.Sum256([]byte("threshold wallet"))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("Could not sign: %v", err)
+	}
+	if !ecdsa.Verify(&key.PublicKey, digest[:], r, s) {
+		t.Errorf("Signature doesn't verify")
+	}
+}
+
+func TestMultiScalarMult(t *testing.T) {
+	curve := S256()
+	n := curve.Params().N
+	var xs, ys []*big.Int
+	var ks [][]byte
+	ex, ey := new(big.Int), new(big.Int)
+	for i := 0; i < 20; i++ {
+		a, _ := rand.Int(rand.Reader, n)
+		k, _ := rand.Int(rand.Reader, n)
+		if i%5 == 0 {
+			k.SetInt64(int64(i))
+		}
+		x, y := curve.ScalarBaseMult(a.Bytes())
+		xs, ys, ks = append(xs, x), append(ys, y), append(ks, k.Bytes())
+		px, py := curve.ScalarMult(x, y, k.Bytes())
+		ex, ey = curve.Add(ex, ey, px, py)
+	}
+	if x, y := curve.(interface {
+		MultiScalarMult(xs, ys []*big.Int, ks [][]byte) (*big.Int, *big.Int)
+	}).MultiScalarMult(xs, ys, ks); x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+		t.Errorf("Multi-scalar multiplication differs from the sum of products")
+	}
+}
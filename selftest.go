@@ -0,0 +1,95 @@
+package dkg
+
+import "bytes"
+import "crypto/elliptic"
+import "crypto/sha256"
+import "crypto/sha512"
+import "encoding/hex"
+import "hash"
+import "math/big"
+import "sort"
+
+// curveKATs are SHA-256 digests of x || y of 0x1234567 * G on the built-in
+// curves.
+var curveKATs = map[string]string{
+	"P-224":        "39fd2db3b6f84abcf9d7284609bd7401998f47c263b05a8d313a925774de2377",
+	"P-256":        "0f8a212d4f085087c3547aae01e8f656e297a664b1373995bd4e554efb036244",
+	"P-384":        "1ed02bbb173690d00963c9f9af2156d33022402b22592cc85dfe851a8caa5b13",
+	"P-521":        "0b6f608be14a386b7cb1db276e6da342beb7820e79fd3d82e75e87d2d9a981ca",
+	"secp256k1":    "dba5936b9e7481de6b5daf68c8899975602489a64eab58838d50b766490555cd",
+	"edwards25519": "d150db0a9d5ee2b80b0979ebd287909f8ad4aa3aa00958403aee9262c884882b",
+}
+
+// hashKATs are the digests of "abc" from FIPS 180-4.
+var hashKATs = []struct {
+	name   string
+	hash   func() hash.Hash
+	digest string
+}{
+	{"SHA-256", sha256.New, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+	{"SHA-512/256", sha512.New512_256, "53048e2681941ef99b2e29b76b4c7dabe4c2d0c634fc6d46e0e2f13107e7af23"},
+}
+
+// SelfTest runs known-answer and consistency tests of the hash functions
+// and every registered curve, to be run before taking part in ceremonies.
+// Curves registered without a known answer only get the consistency tests.
+func SelfTest() error {
+	for _, kat := range hashKATs {
+		h := kat.hash()
+		h.Write([]byte("abc"))
+		if hex.EncodeToString(h.Sum(nil)) != kat.digest {
+			return SelfTestError{kat.name, "known answer"}
+		}
+	}
+
+	curvesMu.RLock()
+	names := make([]string, 0, len(curves))
+	for name := range curves {
+		names = append(names, name)
+	}
+	curvesMu.RUnlock()
+	sort.Strings(names)
+	for _, name := range names {
+		curve, _ := CurveByName(name)
+		if check := selfTestCurve(curve); check != "" {
+			return SelfTestError{name, check}
+		}
+	}
+	return nil
+}
+
+// selfTestCurve returns the first check curve fails, or "".
+func selfTestCurve(curve elliptic.Curve) string {
+	params := curve.Params()
+	if !isValidPoint(curve, params.Gx, params.Gy) {
+		return "base point"
+	}
+
+	k := big.NewInt(0x1234567)
+	kx, ky := curve.ScalarBaseMult(k.Bytes())
+	if kat, ok := curveKATs[params.Name]; ok {
+		h := sha256.New()
+		h.Write(kx.Bytes())
+		h.Write(ky.Bytes())
+		if want, _ := hex.DecodeString(kat); !bytes.Equal(h.Sum(nil), want) {
+			return "known answer"
+		}
+	}
+	if x, y := curve.ScalarMult(params.Gx, params.Gy, k.Bytes()); x.Cmp(kx) != 0 || y.Cmp(ky) != 0 {
+		return "scalar multiplication"
+	}
+
+	// (k + 1) * G == k * G + G
+	x1, y1 := curve.ScalarBaseMult(new(big.Int).Add(k, one).Bytes())
+	x2, y2 := curve.Add(kx, ky, params.Gx, params.Gy)
+	if x1.Cmp(x2) != 0 || y1.Cmp(y2) != 0 {
+		return "group law"
+	}
+
+	// (N - 1) * G + G is the identity
+	nx, ny := curve.ScalarBaseMult(new(big.Int).Sub(params.N, one).Bytes())
+	if x, y := curve.Add(nx, ny, params.Gx, params.Gy); !isIdentity(curve, x, y) {
+		return "group order"
+	}
+	return ""
+}
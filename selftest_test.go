@@ -0,0 +1,40 @@
+package dkg
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+// brokenCurve is P-256 with a faulty variable-base scalar multiplication.
+type brokenCurve struct {
+	elliptic.Curve
+}
+
+func (c brokenCurve) Params() *elliptic.CurveParams {
+	params := *c.Curve.Params()
+	params.Name = "broken P-256"
+	return &params
+}
+
+func (c brokenCurve) ScalarMult(x, y *big.Int, k []byte) (*big.Int, *big.Int) {
+	return c.Curve.ScalarMult(x, y, append(k, 0))
+}
+
+func TestSelfTest(t *testing.T) {
+	if err := SelfTest(); err != nil {
+		t.Fatalf("Self-test failed: %v", err)
+	}
+
+	broken := brokenCurve{elliptic.P256()}
+	RegisterCurve(broken)
+	defer func() {
+		curvesMu.Lock()
+		delete(curves, broken.Params().Name)
+		curvesMu.Unlock()
+	}()
+	if err := SelfTest(); !reflect.DeepEqual(err, SelfTestError{"broken P-256", "scalar multiplication"}) {
+		t.Errorf("Got unexpected error for a broken curve: %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package dkg
+
+import "io"
+import "sort"
+import "sync"
+
+// SessionManager runs concurrent ceremonies over one transport, each under
+// a session name unique to it and agreed on by its participants, and routes
+// the received messages to their sessions. Each ceremony has its own node
+// and runner.
+type SessionManager struct {
+	mux *TransportMux
+
+	mu         sync.Mutex
+	sessions   map[string]*managedSession
+	ended      map[string]bool
+	storage    Storage
+	passphrase []byte
+}
+
+type managedSession struct {
+	runner    *ProtocolRunner
+	transport Transport
+}
+
+func NewSessionManager(transport Transport) *SessionManager {
+	return &SessionManager{
+		mux:      NewTransportMux(transport),
+		sessions: make(map[string]*managedSession),
+		ended:    make(map[string]bool),
+	}
+}
+
+// UseStorage has the manager record the broadcasts of the sessions it opens
+// from now on in storage, as their transcripts, and save the key share of
+// each session as its state when it ends, sealed with passphrase unless it
+// is nil. Sessions found in storage can't be opened again, not even after a
+// restart; LoadSessionKeyShare reads their key shares back.
+func (m *SessionManager) UseStorage(storage Storage, passphrase []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.storage, m.passphrase = storage, passphrase
+}
+
+// Rewrap re-seals the key shares in the manager's storage with passphrase,
+// drawing from random, and seals those of the sessions ending from now on
+// with it, as RewrapStorage does. Sessions keep running meanwhile; those
+// ending wait for it.
+func (m *SessionManager) Rewrap(passphrase []byte, random io.Reader) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var from, to Sealer
+	if m.passphrase != nil {
+		from = PassphraseSealer(m.passphrase, random)
+	}
+	if passphrase != nil {
+		to = PassphraseSealer(passphrase, random)
+	}
+	if m.storage != nil {
+		if err := RewrapStorage(m.storage, from, to); err != nil {
+			return err
+		}
+	}
+	m.passphrase = passphrase
+	return nil
+}
+
+// Open prepares the ceremony of node among participants as session. Session
+// names can't be reused, not even once the session ended.
+func (m *SessionManager) Open(session string, node *Node, participants *ParticipantSet) (*ProtocolRunner, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessions[session]; ok || m.ended[session] {
+		return nil, DuplicateSessionError{session}
+	}
+	var transport Transport = m.mux.Session(session)
+	if m.storage != nil {
+		if stored, err := m.stored(session); err != nil || stored {
+			transport.Close()
+			if err == nil {
+				err = DuplicateSessionError{session}
+			}
+			return nil, err
+		}
+		transport = newTranscriptTransport(m.storage, session, transport)
+	}
+	runner, err := NewProtocolRunner(node, participants, transport)
+	if err != nil {
+		transport.Close()
+		m.ended[session] = true
+		return nil, err
+	}
+	if runner.logger != nil {
+		runner.logger = runner.logger.With("session", session)
+	}
+	m.sessions[session] = &managedSession{runner, transport}
+	return runner, nil
+}
+
+// Runner returns the runner of an open session.
+func (m *SessionManager) Runner(session string) (*ProtocolRunner, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[session]
+	if !ok {
+		return nil, false
+	}
+	return s.runner, true
+}
+
+// Sessions returns the names of the open sessions, sorted.
+func (m *SessionManager) Sessions() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.sessions))
+	for name := range m.sessions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// End closes a session once its ceremony is over; messages that still
+// arrive for it are dropped. The runner keeps its result.
+func (m *SessionManager) End(session string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[session]
+	if !ok {
+		return UnknownSessionError{session}
+	}
+	delete(m.sessions, session)
+	m.ended[session] = true
+	err := s.transport.Close()
+	if result, rerr := s.runner.Result(); m.storage != nil && rerr == nil {
+		if serr := m.saveKeyShare(session, result, s.runner.random); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+func (m *SessionManager) stored(session string) (bool, error) {
+	if _, err := m.storage.LoadState(session); err == nil {
+		return true, nil
+	} else if _, ok := err.(UnknownSessionError); !ok {
+		return false, err
+	}
+	transcript, err := m.storage.Transcript(session)
+	return len(transcript) > 0, err
+}
+
+func (m *SessionManager) saveKeyShare(session string, share *KeyShare, random io.Reader) error {
+	plaintext, err := share.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	defer clear(plaintext)
+	state := plaintext
+	if m.passphrase != nil {
+		if state, err = SealWithPassphrase(plaintext, m.passphrase, random); err != nil {
+			return err
+		}
+	}
+	return m.storage.SaveState(session, state)
+}
+
+// Close closes the shared transport and with it all sessions.
+func (m *SessionManager) Close() error {
+	return m.mux.Close()
+}
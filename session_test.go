@@ -0,0 +1,81 @@
+package dkg
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSessionManager(t *testing.T) {
+	const size = 4
+	ceremonies := map[string]int{"key-a": 1, "key-b": 2, "key-c": 1}
+	nodes := make(map[string][]*Node)
+	participants := make(map[string][]Participant)
+	for session, threshold := range ceremonies {
+		nodes[session], participants[session] = getCeremonyNodesForTesting(t, size, threshold)
+	}
+
+	network := NewMemoryNetwork()
+	managers := make([]*SessionManager, size)
+	var runners []*ProtocolRunner
+	for i := range managers {
+		m := NewSessionManager(network.Transport(nodes["key-a"][i].ID()))
+		defer m.Close()
+		for session := range ceremonies {
+			node := nodes[session][i]
+			set, _ := NewParticipantSet(node.curve, node.Threshold(), participants[session])
+			runner, err := m.Open(session, node, set)
+			if err != nil {
+				t.Fatalf("Could not open %v: %v", session, err)
+			}
+			runners = append(runners, runner)
+		}
+		managers[i] = m
+	}
+	if sessions := managers[0].Sessions(); !reflect.DeepEqual(sessions, []string{"key-a", "key-b", "key-c"}) {
+		t.Errorf("Got sessions %v", sessions)
+	}
+
+	var wg sync.WaitGroup
+	for _, r := range runners {
+		wg.Add(1)
+		go func(r *ProtocolRunner) {
+			defer wg.Done()
+			if err := r.Run(); err != nil {
+				t.Errorf("Ceremony failed: %v", err)
+			}
+		}(r)
+	}
+	wg.Wait()
+
+	for session, threshold := range ceremonies {
+		results := make([]*KeyShare, size)
+		for i, m := range managers {
+			r, ok := m.Runner(session)
+			if !ok {
+				t.Fatalf("Session %v not open", session)
+			}
+			results[i], _ = r.Result()
+			if results[i] != nil && results[i].Threshold != threshold {
+				t.Errorf("Session %v has threshold %v", session, results[i].Threshold)
+			}
+		}
+		checkCeremonyResultsForTesting(t, results)
+	}
+
+	m := managers[0]
+	if err := m.End("key-a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.End("key-a"); !reflect.DeepEqual(err, UnknownSessionError{"key-a"}) {
+		t.Errorf("Got unexpected error ending a session twice: %v", err)
+	}
+	node := nodes["key-a"][0]
+	set, _ := NewParticipantSet(node.curve, node.Threshold(), participants["key-a"])
+	if _, err := m.Open("key-a", node, set); !reflect.DeepEqual(err, DuplicateSessionError{"key-a"}) {
+		t.Errorf("Got unexpected error reusing an ended session: %v", err)
+	}
+	if _, err := m.Open("key-b", node, set); !reflect.DeepEqual(err, DuplicateSessionError{"key-b"}) {
+		t.Errorf("Got unexpected error reopening a session: %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package dkg
+
+import "crypto/ecdsa"
+import "crypto/elliptic"
+import "math/big"
+
+// SigningNonces hold a participant's share of the randomness of one
+// threshold ECDSA signature, in the style of Gennaro et al.'s robust
+// threshold DSS. They are the results of four ceremonies among the
+// participants of the signing key, run ahead of time:
+//
+//   - K shares the signature nonce k, with the key's threshold t
+//   - A shares a mask a, with threshold t
+//   - ZeroK and ZeroS, with threshold 2t-1, are turned into sharings of
+//     zero that randomize the published degree 2t products
+//
+// Signing needs 2t+1 participants. Nonces must be used for one signature
+// only: reusing them reveals the key. SignatureShare consumes them.
+type SigningNonces struct {
+	K, A         *KeyShare
+	ZeroK, ZeroS *KeyShare
+}
+
+// Zeroize wipes the secret shares of unused nonces, which can't sign
+// afterwards. Their public keys are kept for CombineSignature.
+func (n *SigningNonces) Zeroize() {
+	for _, share := range []*KeyShare{n.K, n.A, n.ZeroK, n.ZeroS} {
+		if share != nil {
+			share.Zeroize()
+		}
+	}
+}
+
+func (n *SigningNonces) validate(key *KeyShare) error {
+	if n == nil {
+		return InvalidSigningNoncesError{key.ID}
+	}
+	t := key.Threshold
+	for _, share := range []struct {
+		s         *KeyShare
+		threshold int
+	}{{n.K, t}, {n.A, t}, {n.ZeroK, 2*t - 1}, {n.ZeroS, 2*t - 1}} {
+		if share.s == nil ||
+			share.s.Threshold != share.threshold ||
+			share.s.ID.Cmp(key.ID) != 0 ||
+			share.s.PublicKey.Curve != key.PublicKey.Curve {
+			return InvalidSigningNoncesError{key.ID}
+		}
+	}
+	for _, share := range []*KeyShare{n.K, n.A, n.ZeroK, n.ZeroS} {
+		if share.Share == nil {
+			return NoncesReusedError{key.ID}
+		}
+	}
+	return nil
+}
+
+// addZeroShare adds to z the participant's share of the degree 2t sharing
+// of zero x * g(x), from its share of g of degree 2t-1.
+func addZeroShare(f *scalarField, z []uint64, key *KeyShare, g *KeyShare) {
+	f.mulAdd(z, key.ID, g.Share)
+}
+
+// ProductShare returns the participant's share of k * a, to publish to the
+// other signers.
+func ProductShare(key *KeyShare, nonces *SigningNonces) (DealtShare, error) {
+	if err := key.permits(UseSigning); err != nil {
+		return DealtShare{}, err
+	}
+	if err := nonces.validate(key); err != nil {
+		return DealtShare{}, err
+	}
+	f := scalarFieldFor(key.PublicKey.Curve.Params().N)
+	u := f.element()
+	defer clear(u)
+	f.mulAdd(u, nonces.K.Share, nonces.A.Share)
+	addZeroShare(f, u, key, nonces.ZeroK)
+	return DealtShare{key.ID, f.toBig(u)}, nil
+}
+
+// PartialSignature is a signer's share of a threshold signature, made with
+// the key share of the given epoch.
+type PartialSignature struct {
+	ID    *big.Int
+	Epoch uint64
+	Share *big.Int
+}
+
+// SignatureShare returns the participant's share of the signature of
+// digest, given the product shares of 2t+1 signers and its own nonces,
+// which it consumes.
+func SignatureShare(key *KeyShare, nonces *SigningNonces, products []DealtShare, digest []byte) (PartialSignature, error) {
+	return signatureShare(key, nonces, products, digest, interpolateShares)
+}
+
+// interpolator returns the value at zero of the polynomial of the given
+// degree through shares.
+type interpolator func(n *big.Int, shares []DealtShare, degree int) (*big.Int, error)
+
+func signatureShare(key *KeyShare, nonces *SigningNonces, products []DealtShare, digest []byte, interpolate interpolator) (PartialSignature, error) {
+	if err := key.use(UseSigning); err != nil {
+		return PartialSignature{}, err
+	}
+	if err := nonces.validate(key); err != nil {
+		return PartialSignature{}, err
+	}
+	defer nonces.Zeroize()
+	curve := key.PublicKey.Curve
+	n := curve.Params().N
+
+	u, err := interpolate(n, products, 2*key.Threshold)
+	if err != nil {
+		return PartialSignature{}, err
+	}
+	uinv := new(big.Int).ModInverse(u, n)
+	if uinv == nil {
+		return PartialSignature{}, InvalidSigningNoncesError{key.ID}
+	}
+	r := new(big.Int).Mod(nonces.K.PublicKey.X, n)
+
+	// s_i = u^-1 * a_i * (z + r * x_i), so that s = k^-1 * (z + r * x)
+	f := scalarFieldFor(n)
+	s := f.fromBig(hashToInt(digest, curve))
+	defer clear(s)
+	f.mulAdd(s, r, key.Share)
+	a := f.fromBig(nonces.A.Share)
+	defer clear(a)
+	f.mul(s, s, a)
+	f.mul(s, s, f.fromBig(uinv))
+	addZeroShare(f, s, key, nonces.ZeroS)
+	return PartialSignature{key.ID, key.Epoch, f.toBig(s)}, nil
+}
+
+// CombineSignature combines the signature shares of 2t+1 signers into an
+// ECDSA signature of digest and checks it against the group key. Shares made
+// in another epoch than key's are rejected, a bad share from any signer
+// makes the signature fail to verify.
+func CombineSignature(key *KeyShare, nonces *SigningNonces, partials []PartialSignature, digest []byte) (r, s *big.Int, err error) {
+	return combineSignature(key, nonces, partials, digest, interpolateShares)
+}
+
+func combineSignature(key *KeyShare, nonces *SigningNonces, partials []PartialSignature, digest []byte, interpolate interpolator) (r, s *big.Int, err error) {
+	if err := key.usable(); err != nil {
+		return nil, nil, err
+	}
+	return combineGroupSignature(key.Group(), nonces, partials, digest, interpolate)
+}
+
+// combineGroupSignature is CombineSignature for anyone holding the group
+// key and the public keys of the nonces.
+func combineGroupSignature(group GroupKey, nonces *SigningNonces, partials []PartialSignature, digest []byte, interpolate interpolator) (r, s *big.Int, err error) {
+	n := group.PublicKey.Curve.Params().N
+	shares := make([]DealtShare, len(partials))
+	for i, partial := range partials {
+		if partial.Epoch != group.Epoch {
+			return nil, nil, MixedEpochError{group.Epoch, partial.Epoch}
+		}
+		shares[i] = DealtShare{partial.ID, partial.Share}
+	}
+	s, err = interpolate(n, shares, 2*group.Threshold)
+	if err != nil {
+		return nil, nil, err
+	}
+	r = new(big.Int).Mod(nonces.K.PublicKey.X, n)
+	if r.Sign() == 0 || s.Sign() == 0 || !ecdsa.Verify(&group.PublicKey, digest, r, s) {
+		return nil, nil, InvalidSignatureError{}
+	}
+	return r, s, nil
+}
+
+// interpolateShares returns the value at zero of the polynomial of the
+// given degree through the first degree+1 shares with distinct IDs.
+func interpolateShares(n *big.Int, shares []DealtShare, degree int) (*big.Int, error) {
+	seen := make(map[string]bool)
+	var xs []*big.Int
+	var ys []*big.Int
+	for _, share := range shares {
+		if share.ID == nil || share.Share == nil || len(xs) > degree {
+			continue
+		}
+		x := new(big.Int).Mod(share.ID, n)
+		if x.Sign() == 0 || seen[x.String()] {
+			continue
+		}
+		seen[x.String()] = true
+		xs = append(xs, x)
+		ys = append(ys, share.Share)
+	}
+	if len(xs) <= degree {
+		return nil, InsufficientSharesError{len(xs), degree + 1}
+	}
+
+	result := new(big.Int)
+	for i, x := range xs {
+		result.Add(result, new(big.Int).Mul(lagrangeCoefficient(x, xs, n), ys[i]))
+	}
+	return result.Mod(result, n), nil
+}
+
+// hashToInt converts a digest to an integer as ECDSA does, keeping its
+// leftmost bits up to the length of the group order.
+func hashToInt(digest []byte, curve elliptic.Curve) *big.Int {
+	orderBits := curve.Params().N.BitLen()
+	orderBytes := (orderBits + 7) / 8
+	if len(digest) > orderBytes {
+		digest = digest[:orderBytes]
+	}
+	z := new(big.Int).SetBytes(digest)
+	if excess := len(digest)*8 - orderBits; excess > 0 {
+		z.Rsh(z, uint(excess))
+	}
+	return z
+}
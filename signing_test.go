@@ -0,0 +1,115 @@
+package dkg
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+// runSigningCeremoniesForTesting returns the key shares and signing nonces of
+// size participants for a key of the given threshold.
+func runSigningCeremoniesForTesting(t *testing.T, size, threshold int) ([]*KeyShare, []*SigningNonces) {
+	run := func(threshold int) []*KeyShare {
+		nodes, participants := getCeremonyNodesForTesting(t, size, threshold)
+		results := runCeremonyForTesting(t, nodes, participants)
+		for i, result := range results {
+			if result == nil {
+				t.Fatalf("Node %v did not finish", nodes[i].ID())
+			}
+		}
+		return results
+	}
+	keys, ks, as := run(threshold), run(threshold), run(threshold)
+	zks, zss := run(2*threshold-1), run(2*threshold-1)
+
+	nonces := make([]*SigningNonces, size)
+	for i := range nonces {
+		nonces[i] = &SigningNonces{ks[i], as[i], zks[i], zss[i]}
+	}
+	return keys, nonces
+}
+
+func TestThresholdECDSA(t *testing.T) {
+	const size, threshold = 5, 2
+	keys, nonces := runSigningCeremoniesForTesting(t, size, threshold)
+	digest := sha256.Sum256([]byte("threshold ECDSA"))
+
+	products := make([]DealtShare, size)
+	for i := range keys {
+		product, err := ProductShare(keys[i], nonces[i])
+		if err != nil {
+			t.Fatalf("Could not compute product share of %v: %v", keys[i].ID, err)
+		}
+		products[i] = product
+	}
+	shares := make([]PartialSignature, size)
+	for i := range keys {
+		share, err := SignatureShare(keys[i], nonces[i], products, digest[:])
+		if err != nil {
+			t.Fatalf("Could not compute signature share of %v: %v", keys[i].ID, err)
+		}
+		shares[i] = share
+	}
+
+	t.Run("Signers", func(t *testing.T) {
+		for _, signers := range [][]PartialSignature{shares, shares[:2*threshold+1], shares[size-2*threshold-1:]} {
+			r, s, err := CombineSignature(keys[0], nonces[0], signers, digest[:])
+			if err != nil {
+				t.Fatalf("Could not combine %v shares: %v", len(signers), err)
+			}
+			if !ecdsa.Verify(&keys[0].PublicKey, digest[:], r, s) {
+				t.Errorf("Signature does not verify")
+			}
+		}
+	})
+
+	t.Run("Too few signers", func(t *testing.T) {
+		_, _, err := CombineSignature(keys[0], nonces[0], shares[:2*threshold], digest[:])
+		if reflect.TypeOf(err) != reflect.TypeOf(InsufficientSharesError{}) {
+			t.Errorf("Got unexpected error combining %v shares: %v", 2*threshold, err)
+		}
+	})
+
+	t.Run("Bad share", func(t *testing.T) {
+		bad := append([]PartialSignature(nil), shares...)
+		bad[0] = PartialSignature{bad[0].ID, bad[0].Epoch, new(big.Int).Add(bad[0].Share, one)}
+		_, _, err := CombineSignature(keys[0], nonces[0], bad, digest[:])
+		if reflect.TypeOf(err) != reflect.TypeOf(InvalidSignatureError{}) {
+			t.Errorf("Got unexpected error combining a bad share: %v", err)
+		}
+	})
+
+	t.Run("Mixed epochs", func(t *testing.T) {
+		mixed := append([]PartialSignature(nil), shares...)
+		mixed[1].Epoch++
+		_, _, err := CombineSignature(keys[0], nonces[0], mixed, digest[:])
+		if reflect.TypeOf(err) != reflect.TypeOf(MixedEpochError{}) {
+			t.Errorf("Got unexpected error combining shares of mixed epochs: %v", err)
+		}
+	})
+
+	t.Run("Spent nonces", func(t *testing.T) {
+		if nonces[0].K.Share != nil || nonces[0].A.Share != nil {
+			t.Errorf("Signing did not wipe the nonces")
+		}
+		if _, err := SignatureShare(keys[0], nonces[0], products, digest[:]); !reflect.DeepEqual(err, NoncesReusedError{keys[0].ID}) {
+			t.Errorf("Got unexpected error signing with spent nonces: %v", err)
+		}
+		if _, err := ProductShare(keys[0], nonces[0]); !reflect.DeepEqual(err, NoncesReusedError{keys[0].ID}) {
+			t.Errorf("Got unexpected error for the product share of spent nonces: %v", err)
+		}
+	})
+
+	t.Run("Mismatched nonces", func(t *testing.T) {
+		swapped := *nonces[0]
+		swapped.K, swapped.ZeroK = swapped.ZeroK, swapped.K
+		if _, err := ProductShare(keys[0], &swapped); reflect.TypeOf(err) != reflect.TypeOf(InvalidSigningNoncesError{}) {
+			t.Errorf("Got unexpected error for mismatched nonces: %v", err)
+		}
+		if _, err := ProductShare(keys[0], nonces[1]); reflect.TypeOf(err) != reflect.TypeOf(InvalidSigningNoncesError{}) {
+			t.Errorf("Got unexpected error for another participant's nonces: %v", err)
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package dkg
+
+import "crypto/ecdsa"
+import "crypto/elliptic"
+import "crypto/sha256"
+import "crypto/sha512"
+import "io"
+import "math/big"
+import "math/rand/v2"
+import "sync"
+import "time"
+
+// Simulator runs a ceremony among in-process nodes, with all randomness
+// drawn from streams derived from a seed: the same seed yields the same
+// identity keys, secrets and messages, for regression tests and comparisons
+// with other implementations. Its second generator has a known discrete log,
+// so it is for testing only.
+type Simulator struct {
+	Nodes        []*Node
+	Participants []Participant
+
+	seed     []byte
+	policies map[string][]MisbehaviorPolicy
+	runners  []*ProtocolRunner
+	recorded []*recordingTransport
+}
+
+// NewSimulator prepares size nodes with IDs 1 to size for a ceremony with
+// threshold on curve. Phases time out after timeout.
+func NewSimulator(curve elliptic.Curve, size, threshold int, timeout time.Duration, seed []byte) (*Simulator, error) {
+	s := &Simulator{seed: seed, policies: make(map[string][]MisbehaviorPolicy)}
+	setup := s.stream("setup")
+	n := curve.Params().N
+
+	g2, err := randomScalar(n, setup)
+	if err != nil {
+		return nil, err
+	}
+	g2x, g2y := curve.ScalarBaseMult(scalarBytes(curve, g2))
+	zkParam, err := randomScalar(n, setup)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < size; i++ {
+		id := big.NewInt(int64(i + 1))
+		d, err := randomScalar(n, setup)
+		if err != nil {
+			return nil, err
+		}
+		x, y := curve.ScalarBaseMult(scalarBytes(curve, d))
+		key := ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: curve, X: x, Y: y}, D: d}
+		poly1, err := GenerateScalarPolynomial(curve, threshold, setup)
+		if err != nil {
+			return nil, err
+		}
+		poly2, err := GenerateScalarPolynomial(curve, threshold, setup)
+		if err != nil {
+			return nil, err
+		}
+		node, err := NewNodeWithOptions(WithConfig(NodeConfig{
+			Curve:       curve,
+			Hash:        sha512.New512_256(),
+			G2X:         g2x,
+			G2Y:         g2y,
+			ZKParam:     zkParam,
+			Timeout:     timeout,
+			ID:          id,
+			Key:         key,
+			SecretPoly1: poly1,
+			SecretPoly2: poly2,
+		}))
+		if err != nil {
+			return nil, err
+		}
+		s.Nodes = append(s.Nodes, node)
+		s.Participants = append(s.Participants, Participant{id, key.PublicKey})
+	}
+	return s, nil
+}
+
+// Misbehave makes node id follow policies, applied in order, in the next
+// runs.
+func (s *Simulator) Misbehave(id *big.Int, policies ...MisbehaviorPolicy) {
+	s.policies[id.String()] = append(s.policies[id.String()], policies...)
+}
+
+// stream returns the random stream labeled label.
+func (s *Simulator) stream(label string) io.Reader {
+	h := sha256.New()
+	h.Write([]byte("dkg/simulator"))
+	h.Write(s.seed)
+	h.Write([]byte(label))
+	var seed [32]byte
+	copy(seed[:], h.Sum(nil))
+	return rand.NewChaCha8(seed)
+}
+
+// Run runs the ceremony over an in-memory network, each node's transport
+// passed through wrap if given, and returns the nodes' results, nil for
+// failed runs.
+func (s *Simulator) Run(wrap ...func(*Node, Transport) Transport) ([]*KeyShare, error) {
+	network := NewMemoryNetwork()
+	s.runners = make([]*ProtocolRunner, len(s.Nodes))
+	s.recorded = make([]*recordingTransport, len(s.Nodes))
+	for i, node := range s.Nodes {
+		recorder := &recordingTransport{Transport: network.Transport(node.ID())}
+		defer recorder.Close()
+		s.recorded[i] = recorder
+		var transport Transport = recorder
+		if policies, ok := s.policies[node.ID().String()]; ok {
+			transport = misbehavingTransport{transport, policies}
+		}
+		for _, w := range wrap {
+			transport = w(node, transport)
+		}
+		set, err := NewParticipantSet(node.curve, node.Threshold(), s.Participants)
+		if err != nil {
+			return nil, err
+		}
+		runner, err := NewProtocolRunner(node, set, transport)
+		if err != nil {
+			return nil, err
+		}
+		runner.random = s.stream("node " + node.ID().String())
+		s.runners[i] = runner
+	}
+
+	var wg sync.WaitGroup
+	for _, runner := range s.runners {
+		wg.Add(1)
+		go func(r *ProtocolRunner) {
+			defer wg.Done()
+			r.Run()
+		}(runner)
+	}
+	wg.Wait()
+
+	results := make([]*KeyShare, len(s.runners))
+	for i, runner := range s.runners {
+		results[i], _ = runner.Result()
+	}
+	return results, nil
+}
+
+// Runners returns the runners of the last Run.
+func (s *Simulator) Runners() []*ProtocolRunner {
+	return s.runners
+}
+
+// Transcript returns the messages the nodes sent in the last Run, node by
+// node in ID order, each node's in the order it sent them.
+func (s *Simulator) Transcript() []Message {
+	var transcript []Message
+	for _, r := range s.recorded {
+		r.mu.Lock()
+		transcript = append(transcript, r.sent...)
+		r.mu.Unlock()
+	}
+	return transcript
+}
+
+// TranscriptHash returns the SHA-256 digest of the canonical encodings of
+// the transcript.
+func (s *Simulator) TranscriptHash() []byte {
+	w := NewTranscriptWriter(sha256.New())
+	w.WriteTag("dkg/simulator-transcript")
+	transcript := s.Transcript()
+	w.WriteUint(uint64(len(transcript)))
+	for _, m := range transcript {
+		w.Write(m)
+	}
+	return w.Sum()
+}
+
+// recordingTransport records the messages sent through it.
+type recordingTransport struct {
+	Transport
+	mu   sync.Mutex
+	sent []Message
+}
+
+func (t *recordingTransport) Send(to *big.Int, m Message) error {
+	t.mu.Lock()
+	t.sent = append(t.sent, m)
+	t.mu.Unlock()
+	return t.Transport.Send(to, m)
+}
+
+func (t *recordingTransport) Broadcast(m Message) error {
+	t.mu.Lock()
+	t.sent = append(t.sent, m)
+	t.mu.Unlock()
+	return t.Transport.Broadcast(m)
+}
@@ -0,0 +1,65 @@
+package dkg
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func runSimulatorForTesting(t *testing.T, seed string) (*Simulator, []*KeyShare) {
+	sim, err := NewSimulator(elliptic.P256(), 4, 1, 5*time.Second, []byte(seed))
+	if err != nil {
+		t.Fatalf("Could not create simulator: %v", err)
+	}
+	results, err := sim.Run()
+	if err != nil {
+		t.Fatalf("Could not run simulator: %v", err)
+	}
+	for i, result := range results {
+		if result == nil {
+			t.Fatalf("Node %v did not finish", sim.Nodes[i].ID())
+		}
+	}
+	return sim, results
+}
+
+func TestSimulator(t *testing.T) {
+	sim, results := runSimulatorForTesting(t, "dkg simulator test vector")
+	checkCeremonyResultsForTesting(t, results)
+	checkGolden(t, "simulator-transcript", sim.TranscriptHash())
+
+	again, againResults := runSimulatorForTesting(t, "dkg simulator test vector")
+	if !bytes.Equal(sim.TranscriptHash(), again.TranscriptHash()) {
+		t.Errorf("Runs with the same seed have different transcripts")
+	}
+	if results[0].PublicKey.X.Cmp(againResults[0].PublicKey.X) != 0 {
+		t.Errorf("Runs with the same seed have different group keys")
+	}
+
+	other, _ := runSimulatorForTesting(t, "another seed")
+	if bytes.Equal(sim.TranscriptHash(), other.TranscriptHash()) {
+		t.Errorf("Runs with different seeds have the same transcript")
+	}
+}
+
+// BenchmarkCeremony runs whole ceremonies of growing committees, threshold
+// about half their size. Large committees take minutes per run: select
+// them with -bench, e.g. -bench 'Ceremony/n=256' -benchtime 1x.
+func BenchmarkCeremony(b *testing.B) {
+	for _, size := range []int{4, 16, 64, 256} {
+		b.Run(fmt.Sprintf("n=%v", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				sim, err := NewSimulator(elliptic.P256(), size, (size-1)/2, time.Hour, []byte("dkg ceremony benchmark"))
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := sim.Run(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
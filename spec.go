@@ -0,0 +1,119 @@
+package dkg
+
+import "fmt"
+
+type Phase int
+
+const (
+	PhaseIdle Phase = iota
+	PhaseDealing
+	PhaseComplaining
+	PhaseJustifying
+	PhaseExtracting
+	PhaseContesting
+	PhaseReconstructing
+	PhaseFinished
+	PhaseAborted
+)
+
+var phaseNames = []string{
+	"idle",
+	"dealing",
+	"complaining",
+	"justifying",
+	"extracting",
+	"contesting",
+	"reconstructing",
+	"finished",
+	"aborted",
+}
+
+func (p Phase) String() string {
+	if p < 0 || int(p) >= len(phaseNames) {
+		return fmt.Sprintf("Phase(%d)", int(p))
+	}
+	return phaseNames[p]
+}
+
+// PhaseSpec describes one state of the protocol: the messages processed
+// while in it and the phases it may be left for.
+type PhaseSpec struct {
+	Phase   Phase
+	Accepts []MessageType
+	Next    []Phase
+}
+
+// ProtocolSpec returns the protocol state machine, indexed by phase.
+// PhaseFinished and PhaseAborted are terminal.
+func ProtocolSpec() []PhaseSpec {
+	return []PhaseSpec{
+		{PhaseIdle, nil, []Phase{PhaseDealing, PhaseAborted}},
+		{PhaseDealing,
+			[]MessageType{VerificationPointsMessage, SecretSharesMessage, SecretKnowledgeMessage},
+			[]Phase{PhaseComplaining, PhaseAborted}},
+		{PhaseComplaining,
+			[]MessageType{ComplaintsMessage},
+			[]Phase{PhaseJustifying, PhaseExtracting, PhaseAborted}},
+		{PhaseJustifying,
+			[]MessageType{JustificationMessage},
+			[]Phase{PhaseExtracting, PhaseAborted}},
+		{PhaseExtracting,
+			[]MessageType{PublicCoefficientsMessage, QualifiedSetMessage},
+			[]Phase{PhaseContesting, PhaseAborted}},
+		{PhaseContesting,
+			[]MessageType{CoefficientComplaintsMessage},
+			[]Phase{PhaseReconstructing, PhaseFinished, PhaseAborted}},
+		{PhaseReconstructing,
+			[]MessageType{ReconstructionMessage},
+			[]Phase{PhaseFinished, PhaseAborted}},
+		{PhaseFinished, nil, nil},
+		{PhaseAborted, nil, nil},
+	}
+}
+
+// ConformanceChecker tracks a protocol run against ProtocolSpec and
+// rejects illegal transitions and messages that are not expected in the
+// current phase.
+type ConformanceChecker struct {
+	spec  []PhaseSpec
+	phase Phase
+}
+
+func NewConformanceChecker() *ConformanceChecker {
+	return &ConformanceChecker{ProtocolSpec(), PhaseIdle}
+}
+
+func (c *ConformanceChecker) Phase() Phase {
+	return c.phase
+}
+
+func (c *ConformanceChecker) Transition(to Phase) error {
+	for _, next := range c.spec[c.phase].Next {
+		if next == to {
+			c.phase = to
+			return nil
+		}
+	}
+	return IllegalTransitionError{c.phase, to}
+}
+
+func (c *ConformanceChecker) Accept(t MessageType) error {
+	for _, accepted := range c.spec[c.phase].Accepts {
+		if accepted == t {
+			return nil
+		}
+	}
+	return UnexpectedMessageError{c.phase, t}
+}
+
+// messagePhase returns the phase in which messages of type t are processed.
+func messagePhase(spec []PhaseSpec, t MessageType) Phase {
+	for _, s := range spec {
+		for _, accepted := range s.Accepts {
+			if accepted == t {
+				return s.Phase
+			}
+		}
+	}
+	return PhaseAborted
+}
@@ -0,0 +1,76 @@
+package dkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProtocolSpec(t *testing.T) {
+	spec := ProtocolSpec()
+	for i, s := range spec {
+		if s.Phase != Phase(i) {
+			t.Fatalf("Spec entry %v describes phase %v", i, s.Phase)
+		}
+	}
+
+	t.Run("Every path terminates", func(t *testing.T) {
+		reached := make(map[Phase]bool)
+		var walk func(path []Phase)
+		walk = func(path []Phase) {
+			last := path[len(path)-1]
+			reached[last] = true
+			if len(spec[last].Next) == 0 {
+				if last != PhaseFinished && last != PhaseAborted {
+					t.Errorf("Path %v ends in non-terminal phase", path)
+				}
+				return
+			}
+			for _, next := range spec[last].Next {
+				for _, p := range path {
+					if p == next {
+						t.Fatalf("Path %v loops back to %v", path, next)
+					}
+				}
+				walk(append(append([]Phase(nil), path...), next))
+			}
+		}
+		walk([]Phase{PhaseIdle})
+		for _, s := range spec {
+			if !reached[s.Phase] {
+				t.Errorf("Phase %v is unreachable", s.Phase)
+			}
+		}
+	})
+
+	t.Run("Checker follows spec", func(t *testing.T) {
+		for _, from := range spec {
+			for _, to := range spec {
+				c := &ConformanceChecker{spec, from.Phase}
+				legal := false
+				for _, next := range from.Next {
+					legal = legal || next == to.Phase
+				}
+				err := c.Transition(to.Phase)
+				if legal && (err != nil || c.Phase() != to.Phase) {
+					t.Errorf("Rejected legal transition %v -> %v: %v", from.Phase, to.Phase, err)
+				} else if !legal && reflect.TypeOf(err) != reflect.TypeOf(IllegalTransitionError{}) {
+					t.Errorf("Got unexpected result for illegal transition %v -> %v: %v", from.Phase, to.Phase, err)
+				}
+			}
+		}
+	})
+
+	t.Run("Messages", func(t *testing.T) {
+		c := NewConformanceChecker()
+		if err := c.Accept(VerificationPointsMessage); err == nil {
+			t.Errorf("Accepted %v while idle", VerificationPointsMessage)
+		}
+		c.Transition(PhaseDealing)
+		if err := c.Accept(SecretSharesMessage); err != nil {
+			t.Errorf("Rejected %v while dealing: %v", SecretSharesMessage, err)
+		}
+		if err := c.Accept(ComplaintsMessage); reflect.TypeOf(err) != reflect.TypeOf(UnexpectedMessageError{}) {
+			t.Errorf("Got unexpected result for %v while dealing: %v", ComplaintsMessage, err)
+		}
+	})
+}
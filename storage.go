@@ -0,0 +1,334 @@
+package dkg
+
+import "bytes"
+import "encoding/binary"
+import "encoding/hex"
+import "errors"
+import "io"
+import "io/fs"
+import "math/big"
+import "os"
+import "path/filepath"
+import "sort"
+import "strings"
+import "sync"
+
+// Storage keeps what a long-running daemon must not lose across restarts,
+// by session: its latest state, such as a sealed key share, and an
+// append-only transcript. Implementations must be safe for concurrent use.
+type Storage interface {
+	// SaveState replaces the state of session, atomically.
+	SaveState(session string, state []byte) error
+	// LoadState returns the state of session, or UnknownSessionError.
+	LoadState(session string) ([]byte, error)
+	// AppendTranscript appends entry to the transcript of session once it
+	// is durable.
+	AppendTranscript(session string, entry []byte) error
+	// Transcript returns the entries of session's transcript, in order.
+	Transcript(session string) ([][]byte, error)
+	// ListSessions returns the sessions with a state or a transcript,
+	// sorted.
+	ListSessions() ([]string, error)
+}
+
+// MemoryStorage is a Storage in process memory, for tests and for daemons
+// whose durability lies elsewhere.
+type MemoryStorage struct {
+	mu          sync.Mutex
+	states      map[string][]byte
+	transcripts map[string][][]byte
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{states: make(map[string][]byte), transcripts: make(map[string][][]byte)}
+}
+
+func (s *MemoryStorage) SaveState(session string, state []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[session] = bytes.Clone(state)
+	return nil
+}
+
+func (s *MemoryStorage) LoadState(session string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[session]
+	if !ok {
+		return nil, UnknownSessionError{session}
+	}
+	return bytes.Clone(state), nil
+}
+
+func (s *MemoryStorage) AppendTranscript(session string, entry []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transcripts[session] = append(s.transcripts[session], bytes.Clone(entry))
+	return nil
+}
+
+func (s *MemoryStorage) Transcript(session string) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([][]byte, len(s.transcripts[session]))
+	for i, e := range s.transcripts[session] {
+		entries[i] = bytes.Clone(e)
+	}
+	return entries, nil
+}
+
+func (s *MemoryStorage) ListSessions() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seen := make(map[string]bool)
+	for session := range s.states {
+		seen[session] = true
+	}
+	for session := range s.transcripts {
+		seen[session] = true
+	}
+	return sortedKeys(seen), nil
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// DirStorage is a Storage in a directory of files, two per session named
+// after its hex-encoded name: the state, replaced by renaming a synced
+// temporary file over it, and the transcript, of length-prefixed entries
+// synced as they are appended. A transcript entry torn by a crash is
+// dropped when reading.
+type DirStorage struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// OpenDirStorage uses dir, creating it if needed, readable by the user
+// only.
+func OpenDirStorage(dir string) (*DirStorage, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &DirStorage{dir: dir}, nil
+}
+
+func (s *DirStorage) path(session, ext string) string {
+	return filepath.Join(s.dir, hex.EncodeToString([]byte(session))+ext)
+}
+
+func (s *DirStorage) SaveState(session string, state []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.CreateTemp(s.dir, ".state-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(state); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), s.path(session, ".state")); err != nil {
+		return err
+	}
+	return s.syncDir()
+}
+
+// syncDir makes renames and new files in the directory durable.
+func (s *DirStorage) syncDir() error {
+	d, err := os.Open(s.dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func (s *DirStorage) LoadState(session string) ([]byte, error) {
+	state, err := os.ReadFile(s.path(session, ".state"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, UnknownSessionError{session}
+	}
+	return state, err
+}
+
+func (s *DirStorage) AppendTranscript(session string, entry []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := s.path(session, ".log")
+	_, err := os.Stat(path)
+	created := errors.Is(err, fs.ErrNotExist)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	frame := binary.BigEndian.AppendUint32(nil, uint32(len(entry)))
+	if _, err := f.Write(append(frame, entry...)); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if created {
+		return s.syncDir()
+	}
+	return nil
+}
+
+func (s *DirStorage) Transcript(session string) ([][]byte, error) {
+	data, err := os.ReadFile(s.path(session, ".log"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries [][]byte
+	r := bytes.NewReader(data)
+	for {
+		entry, err := readFrame(r)
+		if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func (s *DirStorage) ListSessions() ([]string, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	for _, f := range files {
+		name, ok := strings.CutSuffix(f.Name(), ".state")
+		if !ok {
+			name, ok = strings.CutSuffix(f.Name(), ".log")
+		}
+		if session, err := hex.DecodeString(name); ok && err == nil {
+			seen[string(session)] = true
+		}
+	}
+	return sortedKeys(seen), nil
+}
+
+// LoadSessionKeyShare returns the key share a SessionManager saved in
+// storage when session ended, opening it with passphrase unless it is nil.
+func LoadSessionKeyShare(storage Storage, session string, passphrase []byte) (*KeyShare, error) {
+	state, err := storage.LoadState(session)
+	if err != nil {
+		return nil, err
+	}
+	if passphrase != nil {
+		if state, err = OpenWithPassphrase(state, passphrase); err != nil {
+			return nil, err
+		}
+	}
+	defer clear(state)
+	share := new(KeyShare)
+	if err := share.UnmarshalBinary(state); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// RewrapStorage re-seals the states of all sessions in storage, opened with
+// from, with to, each replaced atomically, so that a daemon using storage
+// keeps running; see Rewrap. States that to already opens are skipped, so
+// that a rewrap that failed midway can be run again.
+func RewrapStorage(storage Storage, from, to Sealer) error {
+	sessions, err := storage.ListSessions()
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		state, err := storage.LoadState(session)
+		if _, ok := err.(UnknownSessionError); ok {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if to != nil {
+			if done, err := to.Open(state); err == nil {
+				clear(done)
+				continue
+			}
+		}
+		rewrapped, err := Rewrap(state, from, to)
+		if err != nil {
+			return err
+		}
+		if err := storage.SaveState(session, rewrapped); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transcriptTransport appends the broadcasts a session sends and receives
+// to its transcript in storage. A broadcast it can't record isn't sent; one
+// received is delivered anyway.
+type transcriptTransport struct {
+	storage   Storage
+	session   string
+	transport Transport
+	inbox     *mailbox
+}
+
+func newTranscriptTransport(storage Storage, session string, transport Transport) *transcriptTransport {
+	t := &transcriptTransport{storage, session, transport, newMailbox()}
+	go t.run()
+	return t
+}
+
+func (t *transcriptTransport) run() {
+	defer t.inbox.close()
+	for m := range t.transport.Receive() {
+		if m.To == nil {
+			t.storage.AppendTranscript(t.session, marshalBinary(m))
+		}
+		t.inbox.put(m)
+	}
+}
+
+func (t *transcriptTransport) Send(to *big.Int, m Message) error {
+	return t.transport.Send(to, m)
+}
+
+func (t *transcriptTransport) Broadcast(m Message) error {
+	if err := t.storage.AppendTranscript(t.session, marshalBinary(m)); err != nil {
+		return err
+	}
+	return t.transport.Broadcast(m)
+}
+
+func (t *transcriptTransport) Receive() <-chan Message {
+	return t.inbox.out
+}
+
+func (t *transcriptTransport) Close() error {
+	err := t.transport.Close()
+	t.inbox.close()
+	return err
+}
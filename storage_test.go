@@ -0,0 +1,224 @@
+package dkg
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestStorage(t *testing.T) {
+	dir, err := OpenDirStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, storage := range map[string]Storage{"Memory": NewMemoryStorage(), "Dir": dir} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := storage.LoadState("a"); !reflect.DeepEqual(err, UnknownSessionError{"a"}) {
+				t.Errorf("Got unexpected error loading a missing state: %v", err)
+			}
+			for _, state := range []string{"first", "second"} {
+				if err := storage.SaveState("a", []byte(state)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if state, err := storage.LoadState("a"); err != nil || string(state) != "second" {
+				t.Errorf("Loaded state %q (%v)", state, err)
+			}
+
+			entries := [][]byte{[]byte("one"), {}, []byte("three")}
+			for _, e := range entries {
+				if err := storage.AppendTranscript("b/c", e); err != nil {
+					t.Fatal(err)
+				}
+			}
+			transcript, err := storage.Transcript("b/c")
+			if err != nil || len(transcript) != len(entries) {
+				t.Fatalf("Read transcript %q (%v)", transcript, err)
+			}
+			for i := range entries {
+				if !bytes.Equal(transcript[i], entries[i]) {
+					t.Errorf("Entry %v is %q, expected %q", i, transcript[i], entries[i])
+				}
+			}
+			if sessions, err := storage.ListSessions(); err != nil || !reflect.DeepEqual(sessions, []string{"a", "b/c"}) {
+				t.Errorf("Listed sessions %v (%v)", sessions, err)
+			}
+		})
+	}
+
+	// a torn entry is dropped
+	f, err := os.OpenFile(dir.path("b/c", ".log"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0, 0, 0, 9, 'f', 'o'})
+	f.Close()
+	if transcript, err := dir.Transcript("b/c"); err != nil || len(transcript) != 3 {
+		t.Errorf("Read %v entries after a torn append (%v)", len(transcript), err)
+	}
+}
+
+func TestSessionManagerStorage(t *testing.T) {
+	const size, threshold = 3, 1
+	passphrase := []byte("passphrase")
+	nodes, participants := getCeremonyNodesForTesting(t, size, threshold)
+	network := NewMemoryNetwork()
+	managers := make([]*SessionManager, size)
+	storages := make([]Storage, size)
+	runners := make([]*ProtocolRunner, size)
+	for i, node := range nodes {
+		var err error
+		if storages[i], err = OpenDirStorage(t.TempDir()); err != nil {
+			t.Fatal(err)
+		}
+		managers[i] = NewSessionManager(network.Transport(node.ID()))
+		defer managers[i].Close()
+		managers[i].UseStorage(storages[i], passphrase)
+		set, _ := NewParticipantSet(node.curve, threshold, participants)
+		if runners[i], err = managers[i].Open("key", node, set); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var wg sync.WaitGroup
+	for _, r := range runners {
+		wg.Add(1)
+		go func(r *ProtocolRunner) {
+			defer wg.Done()
+			r.Run()
+		}(r)
+	}
+	wg.Wait()
+
+	for i, m := range managers {
+		result, err := runners[i].Result()
+		if err != nil {
+			t.Fatalf("Node %v failed: %v", nodes[i].ID(), err)
+		}
+		if err := m.End("key"); err != nil {
+			t.Fatalf("Could not end the session: %v", err)
+		}
+		share, err := LoadSessionKeyShare(storages[i], "key", passphrase)
+		if err != nil || !reflect.DeepEqual(share, result) {
+			t.Errorf("Loaded key share %+v, expected %+v (%v)", share, result, err)
+		}
+		transcript, err := storages[i].Transcript("key")
+		if err != nil || len(transcript) == 0 {
+			t.Fatalf("No transcript (%v)", err)
+		}
+		for _, entry := range transcript {
+			var m Message
+			if err := m.UnmarshalBinary(entry); err != nil || m.To != nil {
+				t.Errorf("Transcript entry %+v isn't a broadcast (%v)", m, err)
+			}
+		}
+	}
+
+	// after a restart
+	m := NewSessionManager(NewMemoryNetwork().Transport(nodes[0].ID()))
+	defer m.Close()
+	m.UseStorage(storages[0], passphrase)
+	set, _ := NewParticipantSet(nodes[0].curve, threshold, participants)
+	if _, err := m.Open("key", nodes[0], set); !reflect.DeepEqual(err, DuplicateSessionError{"key"}) {
+		t.Errorf("Got unexpected error reopening a stored session: %v", err)
+	}
+
+	newPassphrase := []byte("new passphrase")
+	if err := m.Rewrap(newPassphrase, rand.Reader); err != nil {
+		t.Fatalf("Could not rewrap the stored shares: %v", err)
+	}
+	if _, err := LoadSessionKeyShare(storages[0], "key", passphrase); err == nil {
+		t.Error("Loaded a rewrapped share with the old passphrase")
+	}
+	if _, err := LoadSessionKeyShare(storages[0], "key", newPassphrase); err != nil {
+		t.Errorf("Could not load a rewrapped share: %v", err)
+	}
+}
+
+// aeadSealerForTesting seals with AES-GCM under a fixed key, standing in for
+// a KMS.
+type aeadSealerForTesting struct {
+	aead cipher.AEAD
+}
+
+func newAEADSealerForTesting(t *testing.T, key byte) aeadSealerForTesting {
+	block, err := aes.NewCipher(bytes.Repeat([]byte{key}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return aeadSealerForTesting{aead}
+}
+
+func (s aeadSealerForTesting) Seal(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return s.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (s aeadSealerForTesting) Open(sealed []byte) ([]byte, error) {
+	if len(sealed) < s.aead.NonceSize() {
+		return nil, SealedDataError{}
+	}
+	n := s.aead.NonceSize()
+	return s.aead.Open(nil, sealed[:n], sealed[n:], nil)
+}
+
+func TestRewrapStorage(t *testing.T) {
+	storage := NewMemoryStorage()
+	passphrase := PassphraseSealer([]byte("passphrase"), rand.Reader)
+	kms := newAEADSealerForTesting(t, 1)
+	states := map[string][]byte{"a": []byte("state a"), "b": []byte("state b")}
+	for session, state := range states {
+		sealed, err := passphrase.Seal(state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := storage.SaveState(session, sealed); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a session with only a transcript
+	if err := storage.AppendTranscript("c", []byte("entry")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		name     string
+		from, to Sealer
+	}{
+		{"To KMS", passphrase, kms},
+		{"Again", passphrase, kms},
+		{"Rotate KMS key", kms, newAEADSealerForTesting(t, 2)},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if err := RewrapStorage(storage, c.from, c.to); err != nil {
+				t.Fatalf("Could not rewrap: %v", err)
+			}
+			for session, expected := range states {
+				sealed, err := storage.LoadState(session)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if bytes.Contains(sealed, expected) {
+					t.Errorf("State %v is stored in the clear", session)
+				}
+				if state, err := c.to.Open(sealed); err != nil || !bytes.Equal(state, expected) {
+					t.Errorf("Opened state %v as %q, expected %q (%v)", session, state, expected, err)
+				}
+			}
+		})
+	}
+	if err := RewrapStorage(storage, passphrase, newAEADSealerForTesting(t, 3)); err == nil {
+		t.Error("Rewrapped states sealed with another key")
+	}
+}
@@ -0,0 +1,92 @@
+package dkg
+
+import "crypto/ecdsa"
+import "crypto/elliptic"
+import "crypto/sha256"
+import "encoding/hex"
+import "math/big"
+import "sort"
+import "strconv"
+import "strings"
+
+// Summary describes the outcome of a ceremony for operational records. Its
+// String form is canonical: one "key: value" field per line, participants
+// in ID order, numbers in decimal and binary values in lowercase hex, so
+// that summaries of the same outcome are byte-for-byte equal.
+type Summary struct {
+	Curve        string
+	Threshold    int
+	Epoch        uint64
+	Participants []SummaryParticipant
+	PublicKey    string
+	Fingerprint  string
+}
+
+// SummaryParticipant is a participant's identity key fingerprint and
+// whether its dealing was used.
+type SummaryParticipant struct {
+	ID          *big.Int
+	Fingerprint string
+	Qualified   bool
+}
+
+// Fingerprint returns the SHA-256 digest of key's uncompressed encoding, in
+// hex.
+func Fingerprint(key ecdsa.PublicKey) string {
+	digest := sha256.Sum256(elliptic.Marshal(key.Curve, key.X, key.Y))
+	return hex.EncodeToString(digest[:])
+}
+
+// Summarize describes share's ceremony among participants.
+func Summarize(participants *ParticipantSet, share *KeyShare) Summary {
+	s := Summary{
+		Curve:       participants.curve.Params().Name,
+		Threshold:   share.Threshold,
+		Epoch:       share.Epoch,
+		PublicKey:   hex.EncodeToString(elliptic.Marshal(share.PublicKey.Curve, share.PublicKey.X, share.PublicKey.Y)),
+		Fingerprint: Fingerprint(share.PublicKey),
+	}
+	for _, p := range participants.participants {
+		s.Participants = append(s.Participants, SummaryParticipant{p.ID, Fingerprint(p.Key), containsID(share.Qualified, p.ID)})
+	}
+	sort.Slice(s.Participants, func(i, j int) bool { return s.Participants[i].ID.Cmp(s.Participants[j].ID) < 0 })
+	return s
+}
+
+// Summary describes the finished ceremony.
+func (r *ProtocolRunner) Summary() (Summary, error) {
+	share, err := r.Result()
+	if err != nil {
+		return Summary{}, err
+	}
+	set := &ParticipantSet{curve: r.node.curve, threshold: r.node.Threshold()}
+	for _, p := range r.participants {
+		set.participants = append(set.participants, Participant{p.id, p.key})
+	}
+	return Summarize(set, share), nil
+}
+
+func (s Summary) String() string {
+	var b strings.Builder
+	field := func(key, value string) {
+		b.WriteString(key)
+		b.WriteString(": ")
+		b.WriteString(value)
+		b.WriteString("\n")
+	}
+	field("format", "dkg-summary-v1")
+	field("curve", s.Curve)
+	field("threshold", strconv.Itoa(s.Threshold))
+	field("epoch", strconv.FormatUint(s.Epoch, 10))
+	field("participants", strconv.Itoa(len(s.Participants)))
+	for _, p := range s.Participants {
+		status := "qualified"
+		if !p.Qualified {
+			status = "disqualified"
+		}
+		field("participant", p.ID.String()+" "+p.Fingerprint+" "+status)
+	}
+	field("public-key", s.PublicKey)
+	field("fingerprint", s.Fingerprint)
+	return b.String()
+}
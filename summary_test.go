@@ -0,0 +1,51 @@
+package dkg
+
+import (
+	"crypto/elliptic"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummary(t *testing.T) {
+	sim, err := NewSimulator(elliptic.P256(), 4, 1, 500*time.Millisecond, []byte("summary"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim.Misbehave(sim.Nodes[3].ID(), InconsistentCommitments())
+	if _, err := sim.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := sim.Runners()[0].Summary()
+	if err != nil {
+		t.Fatalf("Could not summarize: %v", err)
+	}
+	for _, runner := range sim.Runners()[1:] {
+		s, err := runner.Summary()
+		if err != nil {
+			t.Fatalf("Could not summarize: %v", err)
+		}
+		if s.String() != first.String() {
+			t.Errorf("Summaries differ:\n%v\n%v", first, s)
+		}
+	}
+
+	lines := strings.Split(first.String(), "\n")
+	expected := []string{
+		"format: dkg-summary-v1",
+		"curve: P-256",
+		"threshold: 1",
+		"epoch: 0",
+		"participants: 4",
+		"participant: 1 " + Fingerprint(sim.Participants[0].Key) + " qualified",
+	}
+	for i, line := range expected {
+		if lines[i] != line {
+			t.Errorf("Got summary line %q, expected %q", lines[i], line)
+		}
+	}
+	if !strings.HasSuffix(lines[8], " disqualified") {
+		t.Errorf("Got summary line %q for the misbehaving participant", lines[8])
+	}
+}
@@ -0,0 +1,161 @@
+package dkg
+
+import "encoding/gob"
+import "math/big"
+import "net"
+import "sync"
+import "time"
+
+const tcpDialTimeout = 5 * time.Second
+
+type Peer struct {
+	ID   *big.Int
+	Addr string
+}
+
+// TCPTransport exchanges gob-encoded messages with peers over plain TCP
+// connections, dialed on first use. It provides neither confidentiality
+// nor peer authentication.
+type TCPTransport struct {
+	id       *big.Int
+	listener net.Listener
+	inbox    *mailbox
+
+	mu    sync.Mutex
+	peers map[string]string
+	conns map[string]*tcpConn
+}
+
+type tcpConn struct {
+	mu   sync.Mutex
+	conn net.Conn
+	enc  *gob.Encoder
+}
+
+// ListenTCP starts accepting connections for id on addr.
+func ListenTCP(id *big.Int, addr string, peers ...Peer) (*TCPTransport, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	t := &TCPTransport{
+		id:       new(big.Int).Set(id),
+		listener: listener,
+		inbox:    newMailbox(),
+		peers:    make(map[string]string),
+		conns:    make(map[string]*tcpConn),
+	}
+	for _, peer := range peers {
+		t.AddPeer(peer)
+	}
+	go t.accept()
+	return t, nil
+}
+
+func (t *TCPTransport) Addr() net.Addr {
+	return t.listener.Addr()
+}
+
+func (t *TCPTransport) AddPeer(peer Peer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.peers[peer.ID.String()] = peer.Addr
+}
+
+func (t *TCPTransport) accept() {
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+		go t.read(conn)
+	}
+}
+
+func (t *TCPTransport) read(conn net.Conn) {
+	defer conn.Close()
+	dec := gob.NewDecoder(conn)
+	for {
+		var m Message
+		if err := dec.Decode(&m); err != nil {
+			return
+		}
+		t.inbox.put(m)
+	}
+}
+
+func (t *TCPTransport) conn(to *big.Int) (*tcpConn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := to.String()
+	if c, ok := t.conns[key]; ok {
+		return c, nil
+	}
+	addr, ok := t.peers[key]
+	if !ok {
+		return nil, UnknownParticipantError{to}
+	}
+	conn, err := net.DialTimeout("tcp", addr, tcpDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &tcpConn{conn: conn, enc: gob.NewEncoder(conn)}
+	t.conns[key] = c
+	return c, nil
+}
+
+func (t *TCPTransport) Send(to *big.Int, m Message) error {
+	c, err := t.conn(to)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	err = c.enc.Encode(&m)
+	c.mu.Unlock()
+	if err != nil {
+		// drop the connection so the next send redials
+		t.mu.Lock()
+		if t.conns[to.String()] == c {
+			delete(t.conns, to.String())
+		}
+		t.mu.Unlock()
+		c.conn.Close()
+	}
+	return err
+}
+
+func (t *TCPTransport) Broadcast(m Message) error {
+	t.mu.Lock()
+	ids := make([]*big.Int, 0, len(t.peers))
+	for key := range t.peers {
+		id, _ := new(big.Int).SetString(key, 10)
+		if id.Cmp(t.id) != 0 {
+			ids = append(ids, id)
+		}
+	}
+	t.mu.Unlock()
+
+	var firstErr error
+	for _, id := range ids {
+		if err := t.Send(id, m); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (t *TCPTransport) Receive() <-chan Message {
+	return t.inbox.out
+}
+
+func (t *TCPTransport) Close() error {
+	err := t.listener.Close()
+	t.mu.Lock()
+	for key, c := range t.conns {
+		c.conn.Close()
+		delete(t.conns, key)
+	}
+	t.mu.Unlock()
+	t.inbox.close()
+	return err
+}
@@ -0,0 +1,378 @@
+package dkg
+
+import "bytes"
+import "crypto"
+import "crypto/rand"
+import "crypto/x509"
+import "crypto/x509/pkix"
+import "encoding/asn1"
+import "fmt"
+import "io"
+import "math/big"
+import "net/http"
+import "time"
+
+// TranscriptAnchor commits the digest of a ceremony's transcript to a
+// record outside the ceremony, such as a timestamp authority or a public
+// chain, so that relying parties can tell the transcript existed by a given
+// time and wasn't rewritten since. Anchor returns the receipt of digest,
+// of the kind Kind names; Verify checks such a receipt for digest and
+// returns the time it attests.
+type TranscriptAnchor interface {
+	Kind() string
+	Anchor(digest []byte) (AnchorReceipt, error)
+	Verify(digest []byte, receipt AnchorReceipt) (time.Time, error)
+}
+
+// AnchorReceipt is the proof a TranscriptAnchor returns, of the kind named
+// by Kind, such as "rfc3161".
+type AnchorReceipt struct {
+	Kind    string
+	Receipt []byte
+}
+
+// TimestampKind is the kind of the receipts of a TimestampAuthority.
+const TimestampKind = "rfc3161"
+
+// maxTimestampResponse bounds the responses read from timestamp authorities.
+const maxTimestampResponse = 1 << 20
+
+// TimestampAuthority anchors transcripts with an RFC 3161 timestamp
+// authority at URL. Its receipts are the timestamp tokens, CMS SignedData
+// over the digest and the time, which must carry the authority's
+// certificate, chaining up to Roots for time stamping. Client is
+// http.DefaultClient if nil, Random crypto/rand's Reader.
+type TimestampAuthority struct {
+	URL    string
+	Roots  *x509.CertPool
+	Client *http.Client
+	Random io.Reader
+}
+
+var (
+	oidSignedData      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidContentType     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSHA256          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidRSAEncryption   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidSHA256WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSHA384WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	oidSHA512WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidECDSAWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	oidECDSAWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+	oidEd25519         = asn1.ObjectIdentifier{1, 3, 101, 112}
+	oidECPublicKey     = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+)
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	Nonce          *big.Int `asn1:"optional"`
+	CertReq        bool     `asn1:"optional"`
+}
+
+type pkiStatusInfo struct {
+	Status int
+}
+
+type timeStampResp struct {
+	Status pkiStatusInfo
+	Token  asn1.RawValue `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type cmsAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+type tstAccuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time   `asn1:"generalized"`
+	Accuracy       tstAccuracy `asn1:"optional"`
+	Ordering       bool        `asn1:"optional"`
+	Nonce          *big.Int    `asn1:"optional"`
+}
+
+func (a *TimestampAuthority) Kind() string {
+	return TimestampKind
+}
+
+// Anchor requests a timestamp token for digest, a SHA-256 digest, and
+// checks it like Verify, and that it answers this request.
+func (a *TimestampAuthority) Anchor(digest []byte) (AnchorReceipt, error) {
+	random := a.Random
+	if random == nil {
+		random = rand.Reader
+	}
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(random, b); err != nil {
+		return AnchorReceipt{}, err
+	}
+	nonce := new(big.Int).SetBytes(b)
+	req, err := asn1.Marshal(timeStampReq{
+		Version:        1,
+		MessageImprint: messageImprint{pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue}, digest},
+		Nonce:          nonce,
+		CertReq:        true,
+	})
+	if err != nil {
+		return AnchorReceipt{}, err
+	}
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Post(a.URL, "application/timestamp-query", bytes.NewReader(req))
+	if err != nil {
+		return AnchorReceipt{}, TimestampRequestError{err}
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return AnchorReceipt{}, TimestampRequestError{fmt.Errorf("HTTP status %v", res.Status)}
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxTimestampResponse))
+	if err != nil {
+		return AnchorReceipt{}, TimestampRequestError{err}
+	}
+	var resp timeStampResp
+	if rest, err := asn1.Unmarshal(body, &resp); err != nil || len(rest) > 0 {
+		return AnchorReceipt{}, InvalidTimestampError{"malformed response"}
+	}
+	// granted, or granted with modifications
+	if resp.Status.Status > 1 {
+		return AnchorReceipt{}, TimestampRequestError{fmt.Errorf("refused with status %v", resp.Status.Status)}
+	}
+	receipt := AnchorReceipt{TimestampKind, resp.Token.FullBytes}
+	info, err := a.verify(digest, receipt)
+	if err != nil {
+		return AnchorReceipt{}, err
+	}
+	if info.Nonce == nil || info.Nonce.Cmp(nonce) != 0 {
+		return AnchorReceipt{}, InvalidTimestampError{"token for another request"}
+	}
+	return receipt, nil
+}
+
+// Verify checks that the receipt is a timestamp token for digest, signed by
+// a time stamping certificate chaining up to Roots, and returns the time
+// it attests.
+func (a *TimestampAuthority) Verify(digest []byte, receipt AnchorReceipt) (time.Time, error) {
+	info, err := a.verify(digest, receipt)
+	return info.GenTime, err
+}
+
+func (a *TimestampAuthority) verify(digest []byte, receipt AnchorReceipt) (tstInfo, error) {
+	if receipt.Kind != TimestampKind {
+		return tstInfo{}, InvalidTimestampError{"receipt of kind " + receipt.Kind}
+	}
+	var ci contentInfo
+	if rest, err := asn1.Unmarshal(receipt.Receipt, &ci); err != nil || len(rest) > 0 || !ci.ContentType.Equal(oidSignedData) {
+		return tstInfo{}, InvalidTimestampError{"malformed token"}
+	}
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil || !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) {
+		return tstInfo{}, InvalidTimestampError{"malformed signed data"}
+	}
+	content := sd.EncapContentInfo.EContent
+	var info tstInfo
+	if rest, err := asn1.Unmarshal(content, &info); err != nil || len(rest) > 0 {
+		return tstInfo{}, InvalidTimestampError{"malformed timestamp info"}
+	}
+	if !info.MessageImprint.HashAlgorithm.Algorithm.Equal(oidSHA256) || !bytes.Equal(info.MessageImprint.HashedMessage, digest) {
+		return tstInfo{}, InvalidTimestampError{"token for another digest"}
+	}
+	if len(sd.SignerInfos) != 1 {
+		return tstInfo{}, InvalidTimestampError{"not one signer"}
+	}
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil || len(certs) == 0 {
+		return tstInfo{}, InvalidTimestampError{"no certificates"}
+	}
+	si := sd.SignerInfos[0]
+	signer := signerCertificate(si.SID, certs)
+	if signer == nil {
+		return tstInfo{}, InvalidTimestampError{"no certificate for the signer"}
+	}
+	if err := verifySignerInfo(si, signer, content); err != nil {
+		return tstInfo{}, err
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range certs {
+		intermediates.AddCert(c)
+	}
+	_, err = signer.Verify(x509.VerifyOptions{
+		Roots:         a.Roots,
+		Intermediates: intermediates,
+		CurrentTime:   info.GenTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	})
+	if err != nil {
+		return tstInfo{}, InvalidTimestampError{err.Error()}
+	}
+	return info, nil
+}
+
+// signerCertificate returns the certificate sid identifies, by issuer and
+// serial number or by subject key identifier.
+func signerCertificate(sid asn1.RawValue, certs []*x509.Certificate) *x509.Certificate {
+	var ias issuerAndSerialNumber
+	isIAS := sid.Class == asn1.ClassUniversal && sid.Tag == asn1.TagSequence
+	if isIAS {
+		if _, err := asn1.Unmarshal(sid.FullBytes, &ias); err != nil {
+			return nil
+		}
+	}
+	for _, c := range certs {
+		if isIAS && bytes.Equal(c.RawIssuer, ias.Issuer.FullBytes) && c.SerialNumber.Cmp(ias.SerialNumber) == 0 {
+			return c
+		}
+		if sid.Class == asn1.ClassContextSpecific && sid.Tag == 0 && len(c.SubjectKeyId) > 0 && bytes.Equal(c.SubjectKeyId, sid.Bytes) {
+			return c
+		}
+	}
+	return nil
+}
+
+// verifySignerInfo checks that si signs content with cert's key, through
+// its signed attributes.
+func verifySignerInfo(si signerInfo, cert *x509.Certificate, content []byte) error {
+	hash, ok := digestAlgorithm(si.DigestAlgorithm.Algorithm)
+	if !ok {
+		return InvalidTimestampError{"unsupported digest algorithm"}
+	}
+	algorithm, ok := signatureAlgorithm(si.SignatureAlgorithm.Algorithm, hash)
+	if !ok {
+		return InvalidTimestampError{"unsupported signature algorithm"}
+	}
+	if len(si.SignedAttrs.FullBytes) == 0 {
+		return InvalidTimestampError{"no signed attributes"}
+	}
+	var contentType, messageDigest []byte
+	for rest := si.SignedAttrs.Bytes; len(rest) > 0; {
+		var attr cmsAttribute
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &attr); err != nil {
+			return InvalidTimestampError{"malformed signed attributes"}
+		}
+		switch {
+		case attr.Type.Equal(oidContentType):
+			contentType = attr.Values.Bytes
+		case attr.Type.Equal(oidMessageDigest):
+			messageDigest = attr.Values.Bytes
+		}
+	}
+	var ct asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(contentType, &ct); err != nil || !ct.Equal(oidTSTInfo) {
+		return InvalidTimestampError{"signed attributes of another content type"}
+	}
+	var md []byte
+	if _, err := asn1.Unmarshal(messageDigest, &md); err != nil {
+		return InvalidTimestampError{"no message digest"}
+	}
+	h := hash.New()
+	h.Write(content)
+	if !bytes.Equal(md, h.Sum(nil)) {
+		return InvalidTimestampError{"signed attributes of another content"}
+	}
+	// the attributes are signed as a SET, not with their implicit tag
+	signed := bytes.Clone(si.SignedAttrs.FullBytes)
+	signed[0] = 0x31
+	if err := cert.CheckSignature(algorithm, signed, si.Signature); err != nil {
+		return InvalidTimestampError{"invalid signature"}
+	}
+	return nil
+}
+
+func digestAlgorithm(oid asn1.ObjectIdentifier) (crypto.Hash, bool) {
+	switch {
+	case oid.Equal(oidSHA256):
+		return crypto.SHA256, true
+	case oid.Equal(oidSHA384):
+		return crypto.SHA384, true
+	case oid.Equal(oidSHA512):
+		return crypto.SHA512, true
+	}
+	return 0, false
+}
+
+// signatureAlgorithm returns the x509 algorithm of a CMS signature
+// algorithm with digest hash; CMS also names bare key algorithms.
+func signatureAlgorithm(oid asn1.ObjectIdentifier, hash crypto.Hash) (x509.SignatureAlgorithm, bool) {
+	for _, a := range []struct {
+		oid       asn1.ObjectIdentifier
+		hash      crypto.Hash
+		algorithm x509.SignatureAlgorithm
+	}{
+		{oidRSAEncryption, crypto.SHA256, x509.SHA256WithRSA},
+		{oidRSAEncryption, crypto.SHA384, x509.SHA384WithRSA},
+		{oidRSAEncryption, crypto.SHA512, x509.SHA512WithRSA},
+		{oidSHA256WithRSA, crypto.SHA256, x509.SHA256WithRSA},
+		{oidSHA384WithRSA, crypto.SHA384, x509.SHA384WithRSA},
+		{oidSHA512WithRSA, crypto.SHA512, x509.SHA512WithRSA},
+		{oidECPublicKey, crypto.SHA256, x509.ECDSAWithSHA256},
+		{oidECPublicKey, crypto.SHA384, x509.ECDSAWithSHA384},
+		{oidECPublicKey, crypto.SHA512, x509.ECDSAWithSHA512},
+		{oidECDSAWithSHA256, crypto.SHA256, x509.ECDSAWithSHA256},
+		{oidECDSAWithSHA384, crypto.SHA384, x509.ECDSAWithSHA384},
+		{oidECDSAWithSHA512, crypto.SHA512, x509.ECDSAWithSHA512},
+	} {
+		if oid.Equal(a.oid) && hash == a.hash {
+			return a.algorithm, true
+		}
+	}
+	if oid.Equal(oidEd25519) {
+		return x509.PureEd25519, true
+	}
+	return x509.UnknownSignatureAlgorithm, false
+}
@@ -0,0 +1,207 @@
+package dkg
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// timestampAuthorityForTesting is an RFC 3161 timestamp authority, with a
+// root certificate and a time stamping certificate it issued.
+type timestampAuthorityForTesting struct {
+	roots *x509.CertPool
+	cert  *x509.Certificate
+	key   *ecdsa.PrivateKey
+	now   time.Time
+	// for the next response, if set
+	nonce *big.Int
+}
+
+func newTimestampAuthorityForTesting(t *testing.T) *timestampAuthorityForTesting {
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	root := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "root"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, root, root, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root, err = x509.ParseCertificate(der); err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "tsa"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}
+	if der, err = x509.CreateCertificate(rand.Reader, template, root, &key.PublicKey, rootKey); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	return &timestampAuthorityForTesting{roots: roots, cert: cert, key: key, now: now}
+}
+
+func (a *timestampAuthorityForTesting) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var req timeStampReq
+	if _, err := asn1.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	nonce := req.Nonce
+	if a.nonce != nil {
+		nonce = a.nonce
+	}
+	token, err := a.token(req.MessageImprint, nonce)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp, _ := asn1.Marshal(timeStampResp{Token: asn1.RawValue{FullBytes: token}})
+	w.Header().Set("Content-Type", "application/timestamp-reply")
+	w.Write(resp)
+}
+
+// token returns a timestamp token for imprint, signed through signed
+// attributes like common authorities do.
+func (a *timestampAuthorityForTesting) token(imprint messageImprint, nonce *big.Int) ([]byte, error) {
+	info, err := asn1.Marshal(tstInfo{
+		Version:        1,
+		Policy:         asn1.ObjectIdentifier{1, 2, 3},
+		MessageImprint: imprint,
+		SerialNumber:   big.NewInt(7),
+		GenTime:        a.now,
+		Nonce:          nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(info)
+	contentType, _ := asn1.Marshal(oidTSTInfo)
+	messageDigest, _ := asn1.Marshal(digest[:])
+	var attrs []byte
+	for _, attr := range []cmsAttribute{
+		{oidContentType, asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: contentType}},
+		{oidMessageDigest, asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: messageDigest}},
+	} {
+		b, err := asn1.Marshal(attr)
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, b...)
+	}
+	set, _ := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: attrs})
+	attrsDigest := sha256.Sum256(set)
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, attrsDigest[:])
+	if err != nil {
+		return nil, err
+	}
+	sid, _ := asn1.Marshal(issuerAndSerialNumber{asn1.RawValue{FullBytes: a.cert.RawIssuer}, a.cert.SerialNumber})
+	sha256ID := pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue}
+	sd, err := asn1.Marshal(signedData{
+		Version:          3,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256ID},
+		EncapContentInfo: encapsulatedContentInfo{oidTSTInfo, info},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: a.cert.Raw},
+		SignerInfos: []signerInfo{{
+			Version:            1,
+			SID:                asn1.RawValue{FullBytes: sid},
+			DigestAlgorithm:    sha256ID,
+			SignedAttrs:        asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attrs},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256},
+			Signature:          sig,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{oidSignedData, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd}})
+}
+
+func TestTimestampAuthority(t *testing.T) {
+	tsa := newTimestampAuthorityForTesting(t)
+	server := httptest.NewServer(tsa)
+	defer server.Close()
+	_, participants := getCeremonyNodesForTesting(t, 3, 1)
+	bundle := CeremonyBundle{Curve: elliptic.P256(), ZKParam: big.NewInt(1), Threshold: 1, Participants: participants, Session: "anchored"}
+	anchor := &TimestampAuthority{URL: server.URL, Roots: tsa.roots}
+
+	if _, err := bundle.VerifyAnchor(anchor); err == nil {
+		t.Errorf("Verified a bundle without receipts")
+	}
+	if err := bundle.Anchor(anchor); err != nil {
+		t.Fatalf("Could not anchor the bundle: %v", err)
+	}
+	b, err := bundle.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded CeremonyBundle
+	if err := decoded.UnmarshalBinary(b); err != nil || !reflect.DeepEqual(decoded.Anchors, bundle.Anchors) {
+		t.Fatalf("Anchors changed in encoding: %v", err)
+	}
+	if at, err := decoded.VerifyAnchor(anchor); err != nil || !at.Equal(tsa.now) {
+		t.Errorf("Got time %v, expected %v (%v)", at, tsa.now, err)
+	}
+
+	t.Run("Rewritten transcript", func(t *testing.T) {
+		rewritten := decoded
+		rewritten.Session = "rewritten"
+		if _, err := rewritten.VerifyAnchor(anchor); err == nil {
+			t.Errorf("Verified the receipt of another transcript")
+		}
+	})
+	t.Run("Untrusted authority", func(t *testing.T) {
+		other := newTimestampAuthorityForTesting(t)
+		if _, err := decoded.VerifyAnchor(&TimestampAuthority{Roots: other.roots}); err == nil {
+			t.Errorf("Verified a receipt of an untrusted authority")
+		}
+	})
+	t.Run("Replayed token", func(t *testing.T) {
+		tsa.nonce = big.NewInt(1)
+		defer func() { tsa.nonce = nil }()
+		if err := bundle.Anchor(anchor); !reflect.DeepEqual(err, InvalidTimestampError{"token for another request"}) {
+			t.Errorf("Got unexpected error for a token answering another request: %v", err)
+		}
+	})
+	t.Run("Unreachable authority", func(t *testing.T) {
+		closed := httptest.NewServer(tsa)
+		closed.Close()
+		if err := bundle.Anchor(&TimestampAuthority{URL: closed.URL, Roots: tsa.roots}); CodeOf(err) != CodeTransport {
+			t.Errorf("Got unexpected error for an unreachable authority: %v", err)
+		}
+	})
+}
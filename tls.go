@@ -0,0 +1,364 @@
+package dkg
+
+import "crypto/ecdsa"
+import "crypto/rand"
+import "crypto/tls"
+import "crypto/x509"
+import "crypto/x509/pkix"
+import "encoding/binary"
+import "errors"
+import "io"
+import "math/big"
+import "net"
+import "sync"
+import "time"
+
+// maxFrameSize bounds the messages a TLSTransport reads, in bytes.
+const maxFrameSize = 1 << 24
+
+// tlsReconnectTimeout bounds how long a TLSTransport keeps redialing a
+// peer for a message.
+const tlsReconnectTimeout = 10 * time.Second
+
+// TLSTransport exchanges binary-encoded messages with peers over mutually
+// authenticated TLS 1.3 connections, dialed on first use and redialed with
+// backoff when they fail. Each node presents a self-signed certificate for
+// its identity key, and only accepts a peer presenting the identity key of
+// the participant it claims to be: messages whose sender isn't the
+// authenticated participant are dropped. The identity keys must be on a
+// curve crypto/x509 supports, such as P-256.
+type TLSTransport struct {
+	id           *big.Int
+	participants *ParticipantSet
+	listener     net.Listener
+	config       *tls.Config
+	inbox        *mailbox
+	compress     bool
+
+	mu     sync.Mutex
+	peers  map[string]string
+	conns  map[string]*tlsConn
+	closed bool
+}
+
+// tlsConn is a connection to a peer. It is in the transport's map while
+// being dialed, so that concurrent sends to the peer wait for the same
+// dial; dialed is closed once conn or err is set.
+type tlsConn struct {
+	dialed chan struct{}
+	err    error
+
+	mu   sync.Mutex
+	conn *tls.Conn
+}
+
+// ListenTLS starts accepting connections for node, a participant of
+// participants, on addr.
+func ListenTLS(node *Node, participants *ParticipantSet, addr string, peers ...Peer) (*TLSTransport, error) {
+	if _, ok := participants.Participant(node.id); !ok {
+		return nil, UnknownParticipantError{node.id}
+	}
+	cert, err := selfSignedCertificate(node)
+	if err != nil {
+		return nil, err
+	}
+	t := &TLSTransport{
+		id:           new(big.Int).Set(node.id),
+		participants: participants,
+		inbox:        newMailbox(),
+		peers:        make(map[string]string),
+		conns:        make(map[string]*tlsConn),
+	}
+	t.config = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS13,
+		ClientAuth:   tls.RequireAnyClientCert,
+		// peers are authenticated by their identity keys, not a CA
+		InsecureSkipVerify: true,
+		VerifyConnection:   t.verifyConnection,
+	}
+	listener, err := tls.Listen("tcp", addr, t.config)
+	if err != nil {
+		return nil, err
+	}
+	t.listener = listener
+	for _, peer := range peers {
+		t.AddPeer(peer)
+	}
+	go t.accept()
+	return t, nil
+}
+
+func selfSignedCertificate(node *Node) (tls.Certificate, error) {
+	serial, err := rand.Int(node.random, new(big.Int).Lsh(one, 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: node.id.String()},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * 365 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(node.random, template, template, node.identity.Public(), node.identity)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: node.identity}, nil
+}
+
+// verifyConnection checks that the peer presented the identity key of a
+// participant.
+func (t *TLSTransport) verifyConnection(cs tls.ConnectionState) error {
+	_, err := t.peerID(cs)
+	return err
+}
+
+// peerID returns the ID of the participant whose identity key the peer's
+// certificate holds.
+func (t *TLSTransport) peerID(cs tls.ConnectionState) (*big.Int, error) {
+	if len(cs.PeerCertificates) == 0 {
+		return nil, PeerAuthenticationError{}
+	}
+	key, ok := cs.PeerCertificates[0].PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, PeerAuthenticationError{}
+	}
+	for _, p := range t.participants.participants {
+		if p.Key.Curve.Params().Name == key.Curve.Params().Name && p.Key.X.Cmp(key.X) == 0 && p.Key.Y.Cmp(key.Y) == 0 {
+			return p.ID, nil
+		}
+	}
+	return nil, PeerAuthenticationError{}
+}
+
+func (t *TLSTransport) sameID(a, b *big.Int) bool {
+	n := t.participants.curve.Params().N
+	return new(big.Int).Mod(a, n).Cmp(new(big.Int).Mod(b, n)) == 0
+}
+
+func (t *TLSTransport) Addr() net.Addr {
+	return t.listener.Addr()
+}
+
+func (t *TLSTransport) AddPeer(peer Peer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.peers[peer.ID.String()] = peer.Addr
+}
+
+func (t *TLSTransport) accept() {
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+		go t.read(conn.(*tls.Conn))
+	}
+}
+
+// read hands the messages of an inbound connection to Receive, as long as
+// they come from the authenticated participant.
+func (t *TLSTransport) read(conn *tls.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(tcpDialTimeout))
+	if err := conn.Handshake(); err != nil {
+		return
+	}
+	conn.SetDeadline(time.Time{})
+	from, err := t.peerID(conn.ConnectionState())
+	if err != nil {
+		return
+	}
+	for {
+		frame, err := readFrame(conn)
+		if err != nil {
+			return
+		}
+		var m Message
+		if m.UnmarshalBinary(frame) != nil || m.From == nil || !t.sameID(m.From, from) {
+			continue
+		}
+		t.inbox.put(m)
+	}
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxFrameSize {
+		return nil, MessageTooLargeError{nil, int(n), maxFrameSize}
+	}
+	frame := make([]byte, n)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// conn returns the connection to participant to, dialing it if there is
+// none. The dial happens outside the transport's lock, so that an
+// unreachable peer doesn't hold up sends to the others.
+func (t *TLSTransport) conn(to *big.Int) (*tlsConn, error) {
+	key := to.String()
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil, net.ErrClosed
+	}
+	if c, ok := t.conns[key]; ok {
+		t.mu.Unlock()
+		<-c.dialed
+		if c.err != nil {
+			return nil, c.err
+		}
+		return c, nil
+	}
+	addr, ok := t.peers[key]
+	if !ok {
+		t.mu.Unlock()
+		return nil, UnknownParticipantError{to}
+	}
+	c := &tlsConn{dialed: make(chan struct{})}
+	t.conns[key] = c
+	t.mu.Unlock()
+
+	config := t.config.Clone()
+	config.VerifyConnection = func(cs tls.ConnectionState) error {
+		if id, err := t.peerID(cs); err != nil || !t.sameID(id, to) {
+			return PeerAuthenticationError{to}
+		}
+		return nil
+	}
+	dialer := &net.Dialer{Timeout: tcpDialTimeout}
+	c.conn, c.err = tls.DialWithDialer(dialer, "tcp", addr, config)
+
+	t.mu.Lock()
+	if c.err == nil && t.closed {
+		c.conn.Close()
+		c.err = net.ErrClosed
+	}
+	if c.err != nil && t.conns[key] == c {
+		delete(t.conns, key)
+	}
+	t.mu.Unlock()
+	close(c.dialed)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c, nil
+}
+
+// CompressPoints has the transport send the points of messages compressed,
+// which only peers of this version or later can decode. It must be called
+// before sending.
+func (t *TLSTransport) CompressPoints() {
+	t.compress = true
+}
+
+// Send writes m to the peer, redialing it with backoff for up to
+// tlsReconnectTimeout while the connection fails, unless the peer fails to
+// authenticate.
+func (t *TLSTransport) Send(to *big.Int, m Message) error {
+	frame := binary.BigEndian.AppendUint32(nil, 0)
+	if t.compress {
+		frame = append(frame, encodeCompressed(t.participants.curve, m.writeCanonical)...)
+	} else {
+		frame = append(frame, marshalBinary(m)...)
+	}
+	binary.BigEndian.PutUint32(frame, uint32(len(frame)-4))
+
+	deadline := time.Now().Add(tlsReconnectTimeout)
+	backoff := 50 * time.Millisecond
+	for {
+		err := t.write(to, frame)
+		var unknown UnknownParticipantError
+		var impostor PeerAuthenticationError
+		if err == nil || errors.Is(err, net.ErrClosed) || errors.As(err, &unknown) || errors.As(err, &impostor) ||
+			time.Now().Add(backoff).After(deadline) {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff = min(2*backoff, time.Second)
+	}
+}
+
+func (t *TLSTransport) write(to *big.Int, frame []byte) error {
+	c, err := t.conn(to)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	_, err = c.conn.Write(frame)
+	c.mu.Unlock()
+	if err != nil {
+		// drop the connection so the next attempt redials
+		t.mu.Lock()
+		if t.conns[to.String()] == c {
+			delete(t.conns, to.String())
+		}
+		t.mu.Unlock()
+		c.conn.Close()
+	}
+	return err
+}
+
+// Broadcast sends m to all peers at once, so that an unreachable peer
+// doesn't hold up the others.
+func (t *TLSTransport) Broadcast(m Message) error {
+	t.mu.Lock()
+	ids := make([]*big.Int, 0, len(t.peers))
+	for key := range t.peers {
+		id, _ := new(big.Int).SetString(key, 10)
+		if id.Cmp(t.id) != 0 {
+			ids = append(ids, id)
+		}
+	}
+	t.mu.Unlock()
+
+	errs := make([]error, len(ids))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = t.Send(id, m)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *TLSTransport) Receive() <-chan Message {
+	return t.inbox.out
+}
+
+func (t *TLSTransport) Close() error {
+	err := t.listener.Close()
+	t.mu.Lock()
+	t.closed = true
+	for key, c := range t.conns {
+		// connections being dialed are closed when their dial returns
+		select {
+		case <-c.dialed:
+			if c.err == nil {
+				c.conn.Close()
+			}
+		default:
+		}
+		delete(t.conns, key)
+	}
+	t.mu.Unlock()
+	t.inbox.close()
+	return err
+}
@@ -0,0 +1,143 @@
+package dkg
+
+import "encoding/gob"
+import "math/big"
+import "sync"
+
+// Transport delivers messages between the participants of a ceremony.
+// Broadcast reaches every participant except the sender.
+type Transport interface {
+	Send(to *big.Int, m Message) error
+	Broadcast(m Message) error
+	Receive() <-chan Message
+	Close() error
+}
+
+// payloads are registered under fixed names, independent of the import
+// path the package is built under
+func init() {
+	gob.RegisterName("dkg.pointTuple", PointTuple{})
+	gob.RegisterName("dkg.EncryptedShares", EncryptedShares{})
+	gob.RegisterName("dkg.Complaints", Complaints{})
+	gob.RegisterName("dkg.Justification", Justification{})
+	gob.RegisterName("dkg.SecretKnowledgeProof", SecretKnowledgeProof{})
+	gob.RegisterName("dkg.Hello", Hello{})
+	gob.RegisterName("dkg.Retry", Retry{})
+	gob.RegisterName("dkg.QualifiedSet", QualifiedSet{})
+	gob.RegisterName("dkg.Exposure", Exposure{})
+}
+
+// mailbox is an unbounded queue of received messages, so that delivery
+// never blocks on a slow receiver.
+type mailbox struct {
+	in   chan Message
+	out  chan Message
+	done chan struct{}
+	once sync.Once
+}
+
+func newMailbox() *mailbox {
+	mb := &mailbox{
+		in:   make(chan Message),
+		out:  make(chan Message),
+		done: make(chan struct{}),
+	}
+	go mb.run()
+	return mb
+}
+
+func (mb *mailbox) run() {
+	defer close(mb.out)
+	var queue []Message
+	for {
+		var out chan Message
+		var next Message
+		if len(queue) > 0 {
+			out, next = mb.out, queue[0]
+		}
+		select {
+		case m := <-mb.in:
+			queue = append(queue, m)
+		case out <- next:
+			queue = queue[1:]
+		case <-mb.done:
+			return
+		}
+	}
+}
+
+func (mb *mailbox) put(m Message) {
+	select {
+	case mb.in <- m:
+	case <-mb.done:
+	}
+}
+
+func (mb *mailbox) close() {
+	mb.once.Do(func() { close(mb.done) })
+}
+
+// MemoryNetwork connects in-process transports, for simulations and tests.
+type MemoryNetwork struct {
+	mu        sync.Mutex
+	endpoints map[string]*memoryTransport
+}
+
+func NewMemoryNetwork() *MemoryNetwork {
+	return &MemoryNetwork{endpoints: make(map[string]*memoryTransport)}
+}
+
+// Transport joins the network as id.
+func (net *MemoryNetwork) Transport(id *big.Int) Transport {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	t := &memoryTransport{net, new(big.Int).Set(id), newMailbox()}
+	net.endpoints[id.String()] = t
+	return t
+}
+
+type memoryTransport struct {
+	net   *MemoryNetwork
+	id    *big.Int
+	inbox *mailbox
+}
+
+func (t *memoryTransport) Send(to *big.Int, m Message) error {
+	t.net.mu.Lock()
+	peer, ok := t.net.endpoints[to.String()]
+	t.net.mu.Unlock()
+	if !ok {
+		return UnknownParticipantError{to}
+	}
+	peer.inbox.put(m)
+	return nil
+}
+
+func (t *memoryTransport) Broadcast(m Message) error {
+	t.net.mu.Lock()
+	peers := make([]*memoryTransport, 0, len(t.net.endpoints))
+	for _, peer := range t.net.endpoints {
+		if peer != t {
+			peers = append(peers, peer)
+		}
+	}
+	t.net.mu.Unlock()
+	for _, peer := range peers {
+		peer.inbox.put(m)
+	}
+	return nil
+}
+
+func (t *memoryTransport) Receive() <-chan Message {
+	return t.inbox.out
+}
+
+func (t *memoryTransport) Close() error {
+	t.net.mu.Lock()
+	if t.net.endpoints[t.id.String()] == t {
+		delete(t.net.endpoints, t.id.String())
+	}
+	t.net.mu.Unlock()
+	t.inbox.close()
+	return nil
+}
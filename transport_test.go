@@ -0,0 +1,226 @@
+package dkg
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func receiveWithin(t *testing.T, tr Transport, d time.Duration) (Message, bool) {
+	select {
+	case m := <-tr.Receive():
+		return m, true
+	case <-time.After(d):
+		return Message{}, false
+	}
+}
+
+func testTransports(t *testing.T, transports []Transport, ids []*big.Int) {
+	vpts := PointTuple{{big.NewInt(1), big.NewInt(2)}}
+
+	if err := transports[0].Send(ids[1], Message{VerificationPointsMessage, ids[0], ids[1], vpts}); err != nil {
+		t.Fatalf("Could not send: %v", err)
+	}
+	m, ok := receiveWithin(t, transports[1], time.Second)
+	if !ok {
+		t.Fatalf("Message sent to %v not received", ids[1])
+	}
+	if m.Type != VerificationPointsMessage || m.From.Cmp(ids[0]) != 0 || !reflect.DeepEqual(m.Payload, vpts) {
+		t.Errorf("Got unexpected message %+v", m)
+	}
+
+	if err := transports[1].Broadcast(Message{ComplaintsMessage, ids[1], nil, Complaints{}}); err != nil {
+		t.Fatalf("Could not broadcast: %v", err)
+	}
+	for i, tr := range transports {
+		m, ok := receiveWithin(t, tr, 100*time.Millisecond)
+		if i == 1 && ok {
+			t.Errorf("Broadcast delivered back to sender")
+		} else if i != 1 && (!ok || m.Type != ComplaintsMessage || !reflect.DeepEqual(m.Payload, Complaints{})) {
+			t.Errorf("Broadcast not delivered to %v: %+v", ids[i], m)
+		}
+	}
+
+	if err := transports[0].Send(big.NewInt(99), Message{}); reflect.TypeOf(err) != reflect.TypeOf(UnknownParticipantError{}) {
+		t.Errorf("Got unexpected error sending to unknown participant: %v", err)
+	}
+}
+
+func TestMemoryTransport(t *testing.T) {
+	ids := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	net := NewMemoryNetwork()
+	transports := make([]Transport, len(ids))
+	for i, id := range ids {
+		transports[i] = net.Transport(id)
+		defer transports[i].Close()
+	}
+	testTransports(t, transports, ids)
+}
+
+func TestTCPTransport(t *testing.T) {
+	ids := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	transports := make([]Transport, len(ids))
+	tcps := make([]*TCPTransport, len(ids))
+	for i, id := range ids {
+		tr, err := ListenTCP(id, "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Could not listen: %v", err)
+		}
+		defer tr.Close()
+		transports[i], tcps[i] = tr, tr
+	}
+	for _, tr := range tcps {
+		for j, peer := range tcps {
+			tr.AddPeer(Peer{ids[j], peer.Addr().String()})
+		}
+	}
+	testTransports(t, transports, ids)
+}
+
+func TestTLSTransport(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+	set, _ := NewParticipantSet(nodes[0].curve, 1, participants)
+	ids := set.IDs()
+	transports := make([]Transport, len(nodes))
+	tlss := make([]*TLSTransport, len(nodes))
+	for i, node := range nodes {
+		tr, err := ListenTLS(node, set, "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Could not listen: %v", err)
+		}
+		defer tr.Close()
+		transports[i], tlss[i] = tr, tr
+	}
+	for _, tr := range tlss {
+		for j, peer := range tlss {
+			tr.AddPeer(Peer{ids[j], peer.Addr().String()})
+		}
+	}
+	testTransports(t, transports, ids)
+
+	// a participant can't send as another one
+	forged := Message{ComplaintsMessage, ids[2], ids[0], Complaints{}}
+	if err := transports[1].Send(ids[0], forged); err != nil {
+		t.Fatalf("Could not send: %v", err)
+	}
+	if m, ok := receiveWithin(t, transports[0], 100*time.Millisecond); ok {
+		t.Errorf("Got forged message %+v", m)
+	}
+
+	// points may travel compressed
+	tlss[1].CompressPoints()
+	compressed := Message{VerificationPointsMessage, ids[1], ids[0], nodes[1].VerificationPoints()}
+	if err := transports[1].Send(ids[0], compressed); err != nil {
+		t.Fatalf("Could not send: %v", err)
+	}
+	if m, ok := receiveWithin(t, transports[0], time.Second); !ok || !reflect.DeepEqual(m, compressed) {
+		t.Errorf("Got %+v, expected %+v", m, compressed)
+	}
+
+	// a peer that never completes the handshake doesn't hold up the others
+	stalled, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not listen: %v", err)
+	}
+	defer stalled.Close()
+	tlss[1].AddPeer(Peer{ids[2], stalled.Addr().String()})
+	tlss[1].mu.Lock()
+	delete(tlss[1].conns, ids[2].String())
+	tlss[1].mu.Unlock()
+	go transports[1].Send(ids[2], Message{ComplaintsMessage, ids[1], ids[2], Complaints{}})
+	time.Sleep(50 * time.Millisecond)
+	sent := make(chan error, 1)
+	go func() { sent <- transports[1].Send(ids[0], Message{ComplaintsMessage, ids[1], ids[0], Complaints{}}) }()
+	select {
+	case err := <-sent:
+		if err != nil {
+			t.Errorf("Could not send: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Sending was held up by a stalled peer")
+	}
+	receiveWithin(t, transports[0], time.Second)
+
+	// nor can a node with the ID but not the identity key of a participant
+	impostors, _ := getCeremonyNodesForTesting(t, 2, 1)
+	impostor, err := ListenTLS(impostors[1], set, "127.0.0.1:0", Peer{ids[0], tlss[0].Addr().String()})
+	if err != nil {
+		t.Fatalf("Could not listen: %v", err)
+	}
+	defer impostor.Close()
+	impostor.Send(ids[0], Message{ComplaintsMessage, ids[1], ids[0], Complaints{}})
+	if m, ok := receiveWithin(t, transports[0], 100*time.Millisecond); ok {
+		t.Errorf("Got impostor's message %+v", m)
+	}
+	tlss[0].AddPeer(Peer{ids[1], impostor.Addr().String()})
+	tlss[0].mu.Lock()
+	delete(tlss[0].conns, ids[1].String())
+	tlss[0].mu.Unlock()
+	var authErr PeerAuthenticationError
+	if err := tlss[0].Send(ids[1], Message{ComplaintsMessage, ids[0], ids[1], Complaints{}}); !errors.As(err, &authErr) {
+		t.Errorf("Sent to impostor: %v", err)
+	}
+}
+
+// peerNetworkForTesting is a PeerNetwork among the members of hub, which
+// delivers published data back to the publisher, like pubsub.
+type peerNetworkForTesting struct {
+	hub   map[string]chan PeerData
+	name  string
+	close sync.Once
+}
+
+func (n *peerNetworkForTesting) Publish(data []byte) error {
+	for _, inbox := range n.hub {
+		inbox <- PeerData{n.name, data}
+	}
+	return nil
+}
+
+func (n *peerNetworkForTesting) SendTo(peer string, data []byte) error {
+	n.hub[peer] <- PeerData{n.name, data}
+	return nil
+}
+
+func (n *peerNetworkForTesting) Receive() <-chan PeerData {
+	return n.hub[n.name]
+}
+
+func (n *peerNetworkForTesting) Close() error {
+	n.close.Do(func() { close(n.hub[n.name]) })
+	return nil
+}
+
+func TestPeerTransport(t *testing.T) {
+	ids := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	hub := make(map[string]chan PeerData)
+	peers := make([]Peer, len(ids))
+	for i, id := range ids {
+		peers[i] = Peer{id, fmt.Sprintf("peer-%v", i)}
+		hub[peers[i].Addr] = make(chan PeerData, 16)
+	}
+	transports := make([]Transport, len(ids))
+	for i, id := range ids {
+		transports[i] = NewPeerTransport(id, &peerNetworkForTesting{hub: hub, name: peers[i].Addr}, peers...)
+		defer transports[i].Close()
+	}
+	testTransports(t, transports, ids)
+
+	// a participant can't send as another one, nor deliver to others
+	for _, m := range []Message{
+		{ComplaintsMessage, ids[2], nil, Complaints{}},
+		{ComplaintsMessage, ids[1], ids[2], Complaints{}},
+	} {
+		if err := transports[1].Broadcast(m); err != nil {
+			t.Fatalf("Could not broadcast: %v", err)
+		}
+		if m, ok := receiveWithin(t, transports[0], 100*time.Millisecond); ok {
+			t.Errorf("Got forged message %+v", m)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package dkg
+
+import "crypto/sha256"
+import "strings"
+import "sync"
+import "sync/atomic"
+import "time"
+
+// KeyOperation is an operation with a key share that a KeyUsage may allow.
+type KeyOperation uint
+
+const (
+	// UseSigning covers threshold ECDSA and FROST signature shares.
+	UseSigning KeyOperation = 1 << iota
+	// UseDecryption covers decryption shares of ciphertexts to the group.
+	UseDecryption
+	// UseVRF covers partial VRF evaluations.
+	UseVRF
+)
+
+func (op KeyOperation) String() string {
+	var names []string
+	for _, o := range []struct {
+		op   KeyOperation
+		name string
+	}{{UseSigning, "signing"}, {UseDecryption, "decryption"}, {UseVRF, "vrf"}} {
+		if op&o.op != 0 {
+			names = append(names, o.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
+
+// KeyUsage restricts a key share, once attached as its Usage, to some
+// operations, a number of uses and a period of validity. Signing,
+// decryption and VRF evaluation check it, and each call for a signature
+// share, a decryption share or a partial VRF evaluation counts as a use. The
+// usage is kept when the share is stored or refreshed and shared with the
+// children derived from it, whose uses count towards it; it is safe for
+// concurrent use.
+type KeyUsage struct {
+	// Operations allowed, all of them if zero.
+	Operations KeyOperation
+	// MaxUses, if nonzero, is the number of uses allowed.
+	MaxUses uint64
+	// NotAfter, if not zero, is the time the share expires.
+	NotAfter time.Time
+
+	uses atomic.Uint64
+}
+
+// NewKeyUsage returns a usage allowing operations, maxUses times and until
+// notAfter, with zero values meaning no restriction.
+func NewKeyUsage(operations KeyOperation, maxUses uint64, notAfter time.Time) *KeyUsage {
+	return &KeyUsage{Operations: operations, MaxUses: maxUses, NotAfter: notAfter}
+}
+
+// Uses returns the number of uses so far.
+func (u *KeyUsage) Uses() uint64 {
+	return u.uses.Load()
+}
+
+// permits checks that the share may be used for op, without counting a use.
+func (s *KeyShare) permits(op KeyOperation) error {
+	if err := s.usable(); err != nil {
+		return err
+	}
+	u := s.Usage
+	if u == nil {
+		return nil
+	}
+	switch {
+	case u.Operations != 0 && u.Operations&op == 0:
+		return KeyUsageError{op, "not permitted"}
+	case !u.NotAfter.IsZero() && time.Now().After(u.NotAfter):
+		return KeyUsageError{op, "share expired"}
+	case u.MaxUses != 0 && u.uses.Load() >= u.MaxUses:
+		return KeyUsageError{op, "uses exhausted"}
+	}
+	return nil
+}
+
+// use checks that the share may be used for op and counts the use.
+func (s *KeyShare) use(op KeyOperation) error {
+	if err := s.permits(op); err != nil {
+		return err
+	}
+	u := s.Usage
+	if u == nil {
+		return nil
+	}
+	for {
+		uses := u.uses.Load()
+		if u.MaxUses != 0 && uses >= u.MaxUses {
+			return KeyUsageError{op, "uses exhausted"}
+		}
+		if u.uses.CompareAndSwap(uses, uses+1) {
+			return nil
+		}
+	}
+}
+
+func (u *KeyUsage) writeUsage(w *TranscriptWriter) {
+	w.WriteTag("dkg/key-usage")
+	w.WriteUint(uint64(u.Operations))
+	w.WriteUint(u.MaxUses)
+	var notAfter uint64
+	if !u.NotAfter.IsZero() {
+		notAfter = uint64(u.NotAfter.UnixNano())
+	}
+	w.WriteUint(notAfter)
+	w.WriteUint(u.uses.Load())
+}
+
+func (r *transcriptReader) readUsage() *KeyUsage {
+	u := new(KeyUsage)
+	r.expectTag("dkg/key-usage")
+	u.Operations = KeyOperation(r.readUint())
+	u.MaxUses = r.readUint()
+	if notAfter := r.readUint(); notAfter != 0 {
+		u.NotAfter = time.Unix(0, int64(notAfter))
+	}
+	u.uses.Store(r.readUint())
+	return u
+}
+
+// dealing binds a node's secret polynomials to the first ceremony it deals
+// them in.
+type dealing struct {
+	mu           sync.Mutex
+	participants []byte
+}
+
+// claimPolynomials has the node refuse to deal its secret polynomials among
+// other participants than those of the ceremony it first dealt them in:
+// participants of both would hold more evaluations of them than the
+// threshold allows. Dealing again among the same participants, as a
+// resumed ceremony does, is allowed.
+func (n *Node) claimPolynomials(participants []*participant) error {
+	w := NewTranscriptWriter(sha256.New())
+	w.WriteTag("dkg/dealing")
+	w.WriteUint(uint64(len(participants)))
+	for _, p := range participants {
+		w.WriteInt(p.id)
+		w.WriteInt(p.key.X)
+		w.WriteInt(p.key.Y)
+	}
+	digest := w.Sum()
+	d := n.dealing
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.participants == nil {
+		d.participants = digest
+	} else if string(d.participants) != string(digest) {
+		return PolynomialsReusedError{n.id}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package dkg
+
+import (
+	"crypto/rand"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestKeyUsage(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+	results := runCeremonyForTesting(t, nodes, participants)
+	key := results[0]
+	ciphertext, err := EncryptToGroup(key.Group(), []byte("plaintext"), nil, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key.Usage = NewKeyUsage(UseDecryption, 2, time.Time{})
+	if _, err := EvaluateVRF(key, []byte("input"), rand.Reader); !reflect.DeepEqual(err, KeyUsageError{UseVRF, "not permitted"}) {
+		t.Errorf("Got unexpected error %v", err)
+	}
+	for range 2 {
+		if _, err := PartialDecrypt(key, ciphertext, rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = PartialDecrypt(key, ciphertext, rand.Reader)
+	if !reflect.DeepEqual(err, KeyUsageError{UseDecryption, "uses exhausted"}) || CodeOf(err) != CodeKeyUnusable {
+		t.Errorf("Got unexpected error %v", err)
+	}
+	if key.Usage.Uses() != 2 {
+		t.Errorf("Counted %v uses", key.Usage.Uses())
+	}
+
+	b, err := key.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored KeyShare
+	if err := stored.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Usage.Operations != UseDecryption || stored.Usage.MaxUses != 2 || stored.Usage.Uses() != 2 {
+		t.Errorf("Stored usage %+v", stored.Usage)
+	}
+	if child, err := key.DeriveChild([]uint32{1}); err != nil || child.Usage != key.Usage {
+		t.Errorf("Derived child has usage %v (%v)", child.Usage, err)
+	}
+
+	key.Usage = NewKeyUsage(0, 0, time.Now().Add(-time.Minute))
+	if _, err := PartialDecrypt(key, ciphertext, rand.Reader); !reflect.DeepEqual(err, KeyUsageError{UseDecryption, "share expired"}) {
+		t.Errorf("Got unexpected error %v", err)
+	}
+	key.Usage = nil
+	if _, err := EvaluateVRF(key, []byte("input"), rand.Reader); err != nil {
+		t.Errorf("Unrestricted share refused: %v", err)
+	}
+}
+
+func TestPolynomialsReused(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 4, 1)
+	first := runCeremonyForTesting(t, nodes[:3], participants[:3])
+	again := runCeremonyForTesting(t, nodes[:3], participants[:3])
+	if first[0] == nil || again[0] == nil || first[0].PublicKey.X.Cmp(again[0].PublicKey.X) != 0 {
+		t.Fatalf("Ceremony among the same participants failed")
+	}
+
+	set, _ := NewParticipantSet(nodes[0].curve, 1, participants)
+	runner, err := NewProtocolRunner(nodes[0], set, NewMemoryNetwork().Transport(nodes[0].ID()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.Run(); !errors.As(err, new(PolynomialsReusedError)) {
+		t.Errorf("Got unexpected error %v", err)
+	}
+}
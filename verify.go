@@ -0,0 +1,159 @@
+package dkg
+
+import "crypto/elliptic"
+import "hash"
+import "io"
+import "math/big"
+
+// VerifyShare checks the shares dealer dealt to this node against the
+// dealer's verification points, returning a ShareVerificationError naming
+// the failed check.
+func (n *Node) VerifyShare(dealer, share1, share2 *big.Int, vpts PointTuple) error {
+	return n.params().verifyShareFor(dealer, n.id, SecretShares{share1, share2, n.curve}, vpts)
+}
+
+// ceremonyParams are the public parameters of a ceremony, all a verifier of
+// its messages needs.
+type ceremonyParams struct {
+	curve     elliptic.Curve
+	hash      hash.Hash
+	g2x, g2y  *big.Int
+	zkParam   *big.Int
+	threshold int
+	tables    *generatorTables // if precomputed
+}
+
+func (n *Node) params() ceremonyParams {
+	return ceremonyParams{n.curve, n.hash, n.g2x, n.g2y, n.zkParam, n.Threshold(), n.tables}
+}
+
+// feldman reports whether the ceremony uses Feldman VSS, without a second
+// generator and second shares.
+func (p ceremonyParams) feldman() bool {
+	return p.g2x == nil
+}
+
+// commit returns s1 * G + s2 * G2, or s1 * G with Feldman VSS.
+func (p ceremonyParams) commit(s1, s2 *big.Int) (x, y *big.Int) {
+	ax, ay := p.baseMult(s1)
+	if p.feldman() {
+		return ax, ay
+	}
+	bx, by := p.g2Mult(s2)
+	return p.curve.Add(ax, ay, bx, by)
+}
+
+// verifyShareFor checks the shares dealer dealt to id:
+// s1 * G + s2 * G2 == sum(C_k * id^k), s2 being zero with Feldman VSS
+func (p ceremonyParams) verifyShareFor(dealer, id *big.Int, shares SecretShares, vpts PointTuple) error {
+	if err := p.checkShareFor(dealer, shares, vpts); err != nil {
+		return err
+	}
+	sx, sy := p.commit(shares.Share1, shares.Share2)
+	ex, ey := evaluateCommitments(p.curve, vpts, id)
+	if sx.Cmp(ex) != 0 || sy.Cmp(ey) != 0 {
+		return ShareVerificationError{dealer, "commitment equation"}
+	}
+	return nil
+}
+
+// checkShareFor does the checks of verifyShareFor short of the commitment
+// equation.
+func (p ceremonyParams) checkShareFor(dealer *big.Int, shares SecretShares, vpts PointTuple) error {
+	curve := p.curve
+	if len(vpts) != p.threshold+1 {
+		return ShareVerificationError{dealer, "verification point count"}
+	}
+	if !validCommitments(curve, vpts) {
+		return ShareVerificationError{dealer, "verification point validity"}
+	}
+	if !isNormalizedScalar(shares.Share1, curve.Params().N) {
+		return ShareVerificationError{dealer, "first share range"}
+	}
+	if !isNormalizedScalar(shares.Share2, curve.Params().N) || p.feldman() && shares.Share2.Sign() != 0 {
+		return ShareVerificationError{dealer, "second share range"}
+	}
+	return nil
+}
+
+// ReceivedShares are the shares a dealer dealt to this node, with the
+// dealer's verification points.
+type ReceivedShares struct {
+	Dealer *big.Int
+	SecretShares
+	VerificationPoints PointTuple
+}
+
+// VerifySharesBatch checks the shares of many dealers like VerifyShare, but
+// at once: a random linear combination of their commitment equations is
+// checked with a single multi-scalar multiplication, which the curves of
+// the secp256k1 and edwards25519 packages do faster than one scalar
+// multiplication per point. Only if the combination fails are the dealers
+// checked one by one, to find the culprits. It returns nil if all shares
+// verify, and else the dealers' errors in order, nil for valid shares.
+func (n *Node) VerifySharesBatch(received []ReceivedShares, random io.Reader) ([]error, error) {
+	return n.params().verifySharesBatchFor(n.id, received, random)
+}
+
+// batchWeightSize is the byte length of the random weights of a batch:
+// invalid shares pass with probability 2^-128.
+const batchWeightSize = 16
+
+func (p ceremonyParams) verifySharesBatchFor(id *big.Int, received []ReceivedShares, random io.Reader) ([]error, error) {
+	curve := p.curve
+	n := curve.Params().N
+	errs := make([]error, len(received))
+	failed := false
+	var batch []int
+	for i, r := range received {
+		if errs[i] = p.checkShareFor(r.Dealer, r.SecretShares, r.VerificationPoints); errs[i] != nil {
+			failed = true
+			continue
+		}
+		batch = append(batch, i)
+	}
+	if len(batch) == 0 {
+		return errs, nil
+	}
+
+	// sum_j w_j * (sum_k id^k * C_jk - s1_j * G - s2_j * G2) == 0
+	var points PointTuple
+	var scalars []*big.Int
+	s1, s2 := new(big.Int), new(big.Int)
+	x := new(big.Int).Mod(id, n)
+	weight := make([]byte, batchWeightSize)
+	for _, i := range batch {
+		if _, err := io.ReadFull(random, weight); err != nil {
+			return nil, err
+		}
+		w := new(big.Int).SetBytes(weight)
+		r := received[i]
+		s1.Add(s1, new(big.Int).Mul(w, r.Share1))
+		s2.Add(s2, new(big.Int).Mul(w, r.Share2))
+		for _, c := range r.VerificationPoints {
+			points = append(points, c)
+			scalars = append(scalars, new(big.Int).Set(w))
+			w.Mul(w, x).Mod(w, n)
+		}
+	}
+	params := curve.Params()
+	points = append(points, struct{ X, Y *big.Int }{params.Gx, params.Gy})
+	scalars = append(scalars, s1.Neg(s1).Mod(s1, n))
+	if !p.feldman() {
+		points = append(points, struct{ X, Y *big.Int }{p.g2x, p.g2y})
+		scalars = append(scalars, s2.Neg(s2).Mod(s2, n))
+	}
+	sx, sy := multiScalarMult(curve, points, scalars)
+	zeroize(s1, s2)
+	if isIdentity(curve, sx, sy) {
+		if !failed {
+			return nil, nil
+		}
+		return errs, nil
+	}
+	for _, i := range batch {
+		r := received[i]
+		errs[i] = p.verifyShareFor(r.Dealer, id, r.SecretShares, r.VerificationPoints)
+	}
+	return errs, nil
+}
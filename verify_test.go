@@ -0,0 +1,122 @@
+package dkg
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/mikalv/dkg/secp256k1"
+)
+
+func TestVerifyShare(t *testing.T) {
+	nodes, _ := getCeremonyNodesForTesting(t, 2, 2)
+	dealer, recipient := nodes[0], nodes[1]
+	n := dealer.curve.Params().N
+	vpts := dealer.VerificationPoints()
+	shares, err := dealer.SecretShareFor(recipient.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	share1, share2 := shares.Share1, shares.Share2
+
+	if err := recipient.VerifyShare(dealer.ID(), share1, share2, vpts); err != nil {
+		t.Fatalf("Valid shares don't verify: %v", err)
+	}
+
+	bad := []struct {
+		share1, share2 *big.Int
+		vpts           PointTuple
+		check          string
+	}{
+		{share1, share2, vpts[:2], "verification point count"},
+		{share1, share2, PointTuple{vpts[0], vpts[1], {new(big.Int), new(big.Int)}}, "verification point validity"},
+		{nil, share2, vpts, "first share range"},
+		{share1, n, vpts, "second share range"},
+		{share2, share1, vpts, "commitment equation"},
+		{share1, share2, recipient.VerificationPoints(), "commitment equation"},
+	}
+	for _, b := range bad {
+		err := recipient.VerifyShare(dealer.ID(), b.share1, b.share2, b.vpts)
+		if !reflect.DeepEqual(err, ShareVerificationError{dealer.ID(), b.check}) {
+			t.Errorf("Got unexpected error for a failing %v check: %v", b.check, err)
+		}
+	}
+}
+
+func TestSecretShareFor(t *testing.T) {
+	nodes, _ := getCeremonyNodesForTesting(t, 1, 2)
+	n := nodes[0].curve.Params().N
+	for _, id := range []*big.Int{nil, big.NewInt(0), n, new(big.Int).Neg(n)} {
+		if _, err := nodes[0].SecretShareFor(id); !reflect.DeepEqual(err, InvalidParticipantIDError{id}) {
+			t.Errorf("Got unexpected error for recipient %v: %v", id, err)
+		}
+	}
+	shares, err := nodes[0].SecretShareFor(new(big.Int).Add(n, one))
+	if err != nil || shares.Share1.Cmp(nodes[0].secretPoly1.evaluate(one, n)) != 0 {
+		t.Errorf("Shares for N+1 differ from those for 1: %v", err)
+	}
+}
+
+func TestVerifySharesBatch(t *testing.T) {
+	curve := secp256k1.S256()
+	k, _ := randomScalar(curve.Params().N, rand.Reader)
+	g2x, g2y := curve.ScalarBaseMult(k.Bytes())
+	p256, _ := getCeremonyNodesForTesting(t, 5, 3)
+	k1, _ := getCeremonyNodesOnCurveForTesting(t, curve, g2x, g2y, 5, 3)
+	for _, nodes := range [][]*Node{p256, k1} {
+		recipient := nodes[0]
+		var received []ReceivedShares
+		for _, dealer := range nodes {
+			shares, err := dealer.SecretShareFor(recipient.ID())
+			if err != nil {
+				t.Fatal(err)
+			}
+			received = append(received, ReceivedShares{dealer.ID(), shares, dealer.VerificationPoints()})
+		}
+		if errs, err := recipient.VerifySharesBatch(received, rand.Reader); errs != nil || err != nil {
+			t.Errorf("Valid shares don't verify: %v %v", errs, err)
+		}
+
+		received[2].Share1 = new(big.Int).Add(received[2].Share1, one)
+		received[4].VerificationPoints = received[4].VerificationPoints[:2]
+		errs, err := recipient.VerifySharesBatch(received, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []error{nil, nil, ShareVerificationError{nodes[2].ID(), "commitment equation"}, nil, ShareVerificationError{nodes[4].ID(), "verification point count"}}
+		if !reflect.DeepEqual(errs, expected) {
+			t.Errorf("Got unexpected errors on %v: %v", recipient.curve.Params().Name, errs)
+		}
+	}
+}
+
+func BenchmarkVerifySharesBatch(b *testing.B) {
+	for _, curve := range []elliptic.Curve{elliptic.P256(), secp256k1.S256()} {
+		for _, size := range []int{16, 64, 256} {
+			b.Run(fmt.Sprintf("%v/n=%v", curve.Params().Name, size), func(b *testing.B) {
+				threshold := (size - 1) / 2
+				received := make([]ReceivedShares, size)
+				for i := range received {
+					poly, _ := GenerateScalarPolynomial(curve, threshold, rand.Reader)
+					vpts := make(PointTuple, len(poly))
+					for k, c := range poly {
+						vpts[k].X, vpts[k].Y = curve.ScalarBaseMult(scalarBytes(curve, c))
+					}
+					share := poly.evaluate(big.NewInt(1), curve.Params().N)
+					received[i] = ReceivedShares{big.NewInt(int64(i + 1)), SecretShares{share, new(big.Int), curve}, vpts}
+				}
+				p := ceremonyParams{curve: curve, threshold: threshold}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if errs, err := p.verifySharesBatchFor(big.NewInt(1), received, rand.Reader); err != nil || errs != nil {
+						b.Fatal(errs, err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package dkg
+
+import "crypto/elliptic"
+import "crypto/sha256"
+import "crypto/sha512"
+import "io"
+import "math/big"
+
+// The threshold VRF of a group evaluates to s * H(input), s being the
+// group's secret and H hashing to the curve. Participants evaluate their
+// share, s_i * H(input), proving with a Chaum-Pedersen proof that they used
+// the share behind their public share; any threshold+1 valid partials
+// interpolate to the same point, so the output is unique and unpredictable
+// without threshold+1 participants, and verifiable against the group key.
+
+// VRFPartial is participant ID's evaluation of the VRF on an input, with
+// its proof.
+type VRFPartial struct {
+	ID                  *big.Int
+	X, Y                *big.Int
+	Challenge, Response *big.Int
+	// the group's curve, which the scalars are encoded for
+	Curve elliptic.Curve
+}
+
+// VRFOutput is the VRF's evaluation on Input, with the partials it was
+// combined from, which prove it.
+type VRFOutput struct {
+	Input    []byte
+	X, Y     *big.Int
+	Partials []VRFPartial
+}
+
+// Value returns the random value of the output, a SHA-256 digest of the
+// input and the evaluation.
+func (o *VRFOutput) Value() []byte {
+	w := NewTranscriptWriter(sha256.New())
+	w.WriteTag("dkg/vrf-value")
+	w.WriteBytes(o.Input)
+	w.WriteInt(o.X)
+	w.WriteInt(o.Y)
+	return w.Sum()
+}
+
+// vrfPoint hashes input to a point of curve.
+func vrfPoint(curve elliptic.Curve, input []byte) (x, y *big.Int, err error) {
+	return DeriveSecondGenerator(curve, append([]byte("dkg/vrf/"), input...))
+}
+
+// EvaluateVRF returns the participant's partial evaluation of the group's
+// VRF on input.
+func EvaluateVRF(key *KeyShare, input []byte, random io.Reader) (VRFPartial, error) {
+	if err := key.use(UseVRF); err != nil {
+		return VRFPartial{}, err
+	}
+	curve := key.PublicKey.Curve
+	hx, hy, err := vrfPoint(curve, input)
+	if err != nil {
+		return VRFPartial{}, err
+	}
+	ex, ey, c, z, err := proveDLEQ(curve, "dkg/vrf-proof", key.ID, key.Share, hx, hy, random)
+	if err != nil {
+		return VRFPartial{}, err
+	}
+	return VRFPartial{key.ID, ex, ey, c, z, curve}, nil
+}
+
+// VerifyVRFPartial checks a partial evaluation of group's VRF on input
+// against the participant's public share.
+func VerifyVRFPartial(group GroupKey, input []byte, p VRFPartial) error {
+	curve := group.PublicKey.Curve
+	hx, hy, err := vrfPoint(curve, input)
+	if err != nil {
+		return err
+	}
+	return verifyVRFPartial(group, hx, hy, p)
+}
+
+func verifyVRFPartial(group GroupKey, hx, hy *big.Int, p VRFPartial) error {
+	curve := group.PublicKey.Curve
+	n := curve.Params().N
+	if !validParticipantID(p.ID, n) {
+		return InvalidParticipantIDError{p.ID}
+	}
+	px, py := evaluateCommitments(curve, group.PublicCoefficients, p.ID)
+	if !verifyDLEQ(curve, "dkg/vrf-proof", p.ID, px, py, hx, hy, p.X, p.Y, p.Challenge, p.Response) {
+		return InvalidVRFPartialError{p.ID}
+	}
+	return nil
+}
+
+// CombineVRF verifies the partial evaluations of group's VRF on input and
+// interpolates the output from threshold+1 valid ones of distinct
+// participants, ignoring invalid partials and the rest.
+func CombineVRF(group GroupKey, input []byte, partials []VRFPartial) (*VRFOutput, error) {
+	curve := group.PublicKey.Curve
+	hx, hy, err := vrfPoint(curve, input)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var used []VRFPartial
+	var shares []PublicShare
+	for _, p := range partials {
+		if len(used) > group.Threshold {
+			break
+		}
+		if verifyVRFPartial(group, hx, hy, p) != nil {
+			continue
+		}
+		key := new(big.Int).Mod(p.ID, curve.Params().N).String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		used = append(used, p)
+		shares = append(shares, PublicShare{p.ID, p.X, p.Y})
+	}
+	x, y, err := RecoverPublicPoint(curve, group.Threshold, shares)
+	if err != nil {
+		return nil, err
+	}
+	return &VRFOutput{append([]byte(nil), input...), x, y, used}, nil
+}
+
+// VerifyVRF checks that output is the evaluation of group's VRF on its
+// input, proven by its partials.
+func VerifyVRF(group GroupKey, output *VRFOutput) error {
+	combined, err := CombineVRF(group, output.Input, output.Partials)
+	if err != nil {
+		return err
+	}
+	if output.X == nil || output.Y == nil || len(combined.Partials) != len(output.Partials) ||
+		combined.X.Cmp(output.X) != 0 || combined.Y.Cmp(output.Y) != 0 {
+		return InvalidVRFOutputError{}
+	}
+	return nil
+}
+
+// Select returns an index in [0, n) derived from the output, such as the
+// leader among n candidates: a 512-bit digest of the value reduced mod n,
+// uniform but for a negligible bias. It panics if n isn't positive.
+func (o *VRFOutput) Select(n int) int {
+	if n <= 0 {
+		panic("dkg: selecting among no candidates")
+	}
+	w := NewTranscriptWriter(sha512.New())
+	w.WriteTag("dkg/vrf-select")
+	w.WriteBytes(o.Value())
+	w.WriteUint(uint64(n))
+	x := new(big.Int).SetBytes(w.Sum())
+	return int(x.Mod(x, big.NewInt(int64(n))).Int64())
+}
+
+func (p VRFPartial) writeVRFPartial(w *TranscriptWriter) {
+	w.WriteTag(p.Curve.Params().Name)
+	w.WriteInt(p.ID)
+	w.WriteInt(p.X)
+	w.WriteInt(p.Y)
+	w.WriteScalar(p.Curve, p.Challenge)
+	w.WriteScalar(p.Curve, p.Response)
+}
+
+func (r *transcriptReader) readVRFPartial() VRFPartial {
+	curve := r.readCurve()
+	return VRFPartial{r.readInt(), r.readInt(), r.readInt(), r.readScalar(curve), r.readScalar(curve), curve}
+}
+
+func (p VRFPartial) MarshalBinary() ([]byte, error) {
+	if p.Curve == nil {
+		return nil, InvalidEncodingError{"VRF partial without a curve"}
+	}
+	return encodeBinary(func(w *TranscriptWriter) {
+		w.WriteTag("dkg/vrf-partial")
+		p.writeVRFPartial(w)
+	}), nil
+}
+
+func (p *VRFPartial) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, func(r *transcriptReader) {
+		r.expectTag("dkg/vrf-partial")
+		*p = r.readVRFPartial()
+	})
+}
+
+func (o VRFOutput) MarshalBinary() ([]byte, error) {
+	for _, p := range o.Partials {
+		if p.Curve == nil {
+			return nil, InvalidEncodingError{"VRF partial without a curve"}
+		}
+	}
+	return encodeBinary(func(w *TranscriptWriter) {
+		w.WriteTag("dkg/vrf-output")
+		w.WriteBytes(o.Input)
+		w.WriteInt(o.X)
+		w.WriteInt(o.Y)
+		w.WriteUint(uint64(len(o.Partials)))
+		for _, p := range o.Partials {
+			p.writeVRFPartial(w)
+		}
+	}), nil
+}
+
+func (o *VRFOutput) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, func(r *transcriptReader) {
+		r.expectTag("dkg/vrf-output")
+		out := VRFOutput{Input: r.readBytes(), X: r.readInt(), Y: r.readInt()}
+		for n := r.readCount(); len(out.Partials) < n; {
+			out.Partials = append(out.Partials, r.readVRFPartial())
+		}
+		*o = out
+	})
+}
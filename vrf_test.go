@@ -0,0 +1,103 @@
+package dkg
+
+import (
+	"crypto/rand"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestVRF(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 4, 1)
+	keys := runCeremonyForTesting(t, nodes, participants)
+	checkCeremonyResultsForTesting(t, keys)
+	group := keys[0].Group()
+	input := []byte("leader of slot 7")
+
+	partials := make([]VRFPartial, len(keys))
+	for i, key := range keys {
+		var err error
+		if partials[i], err = EvaluateVRF(key, input, rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyVRFPartial(group, input, partials[i]); err != nil {
+			t.Errorf("Partial of %v doesn't verify: %v", key.ID, err)
+		}
+	}
+	bad := partials[0]
+	bad.Response = new(big.Int).Add(bad.Response, one)
+	if err := VerifyVRFPartial(group, input, bad); !reflect.DeepEqual(err, InvalidVRFPartialError{bad.ID}) {
+		t.Errorf("Got unexpected error for a tampered partial: %v", err)
+	}
+	if err := VerifyVRFPartial(group, []byte("another input"), partials[0]); err == nil {
+		t.Errorf("Partial verified for another input")
+	}
+
+	first, err := CombineVRF(group, input, []VRFPartial{bad, partials[0], partials[0], partials[1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := CombineVRF(group, input, partials[2:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.X.Cmp(second.X) != 0 || !reflect.DeepEqual(first.Value(), second.Value()) {
+		t.Errorf("Different quorums gave different outputs")
+	}
+	if err := VerifyVRF(group, first); err != nil {
+		t.Errorf("Output doesn't verify: %v", err)
+	}
+	forged := *second
+	forged.X, forged.Y = first.Partials[0].X, first.Partials[0].Y
+	if err := VerifyVRF(group, &forged); !reflect.DeepEqual(err, InvalidVRFOutputError{}) {
+		t.Errorf("Got unexpected error for a forged output: %v", err)
+	}
+	if _, err := CombineVRF(group, input, []VRFPartial{bad, partials[1]}); reflect.TypeOf(err) != reflect.TypeOf(InsufficientSharesError{}) {
+		t.Errorf("Got unexpected error with too few valid partials: %v", err)
+	}
+}
+
+func TestVRFEncoding(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+	keys := runCeremonyForTesting(t, nodes, participants)
+	input := []byte("leader of slot 8")
+	var partials []VRFPartial
+	for _, key := range keys {
+		p, err := EvaluateVRF(key, input, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := p.MarshalBinary()
+		var decoded VRFPartial
+		if err := decoded.UnmarshalBinary(b); err != nil || !reflect.DeepEqual(decoded, p) {
+			t.Fatalf("Partial decoded to %+v (%v)", decoded, err)
+		}
+		partials = append(partials, decoded)
+	}
+	overRange := partials[0]
+	overRange.Response = new(big.Int).Add(overRange.Response, overRange.Curve.Params().N)
+	b, _ := overRange.MarshalBinary()
+	if err := new(VRFPartial).UnmarshalBinary(b); err == nil {
+		t.Errorf("Decoded a partial with an out of range response")
+	}
+	output, err := CombineVRF(keys[0].Group(), input, partials)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ = output.MarshalBinary()
+	var decoded VRFOutput
+	if err := decoded.UnmarshalBinary(b); err != nil || !reflect.DeepEqual(&decoded, output) {
+		t.Fatalf("Output decoded to %+v (%v)", decoded, err)
+	}
+	if err := VerifyVRF(keys[1].Group(), &decoded); err != nil {
+		t.Errorf("Decoded output doesn't verify: %v", err)
+	}
+
+	leader := output.Select(len(keys))
+	if leader < 0 || leader >= len(keys) || decoded.Select(len(keys)) != leader {
+		t.Errorf("Selected %v, then %v, among %v", leader, decoded.Select(len(keys)), len(keys))
+	}
+	if err := VerifyVRF(keys[0].Group(), &VRFOutput{Input: input, Partials: partials}); !reflect.DeepEqual(err, InvalidVRFOutputError{}) {
+		t.Errorf("Got unexpected error for an output without evaluation: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package dkg
+
+import "crypto/ecdsa"
+import "hash"
+import "io"
+import "math/big"
+
+const watermarkNonceSize = 16
+
+// Watermark marks a copy of a key share, such as an encrypted backup, with
+// random Nonce signed by Holder's identity key together with the share's
+// group key, ID and epoch. Holders keep a record of which nonce went into
+// which copy, so that a share recovered from a leaked copy names the
+// participant it leaked from and the copy itself.
+//
+// A holder can strip the watermark from its own share: it traces leaked
+// backups, not deliberate disclosure.
+type Watermark struct {
+	Holder    *big.Int
+	Nonce     []byte
+	Signature []byte
+}
+
+type watermarkStatement struct {
+	holder *big.Int
+	share  *KeyShare
+	nonce  []byte
+}
+
+func (s watermarkStatement) writeCanonical(w *TranscriptWriter) {
+	w.WriteTag("dkg/watermark-statement")
+	w.WriteInt(s.holder)
+	w.WriteTag(s.share.PublicKey.Curve.Params().Name)
+	w.WriteInt(s.share.PublicKey.X)
+	w.WriteInt(s.share.PublicKey.Y)
+	w.WriteInt(s.share.ID)
+	w.WriteUint(s.share.Epoch)
+	w.WriteBytes(s.nonce)
+}
+
+// Watermark marks the share as copied by holder, signing with holder's
+// identity key, and returns the mark, which replaces any earlier one.
+func (s *KeyShare) Watermark(h hash.Hash, holder *big.Int, key *ecdsa.PrivateKey, random io.Reader) (Watermark, error) {
+	nonce := make([]byte, watermarkNonceSize)
+	if _, err := io.ReadFull(random, nonce); err != nil {
+		return Watermark{}, err
+	}
+	sig, err := signDeterministic(key, HashOf(h, watermarkStatement{holder, s, nonce}))
+	if err != nil {
+		return Watermark{}, err
+	}
+	mark := Watermark{new(big.Int).Set(holder), nonce, sig}
+	s.Watermarked = &mark
+	return mark, nil
+}
+
+// VerifyWatermark checks that share carries a watermark by holder.
+func VerifyWatermark(h hash.Hash, holder Participant, share *KeyShare) bool {
+	mark := share.Watermarked
+	if mark == nil || holder.Key.Curve == nil || holder.Key.X == nil || mark.Holder.Cmp(holder.ID) != 0 {
+		return false
+	}
+	digest := HashOf(h, watermarkStatement{mark.Holder, share, mark.Nonce})
+	return ecdsa.VerifyASN1(&holder.Key, digest, mark.Signature)
+}
+
+// TraceWatermark returns the participant whose watermark share carries.
+func TraceWatermark(h hash.Hash, participants *ParticipantSet, share *KeyShare) (Participant, error) {
+	if share.Watermarked != nil {
+		if holder, ok := participants.Participant(share.Watermarked.Holder); ok && VerifyWatermark(h, holder, share) {
+			return holder, nil
+		}
+	}
+	return Participant{}, InvalidWatermarkError{}
+}
+
+func (m Watermark) writeWatermark(w *TranscriptWriter) {
+	w.WriteTag("dkg/watermark")
+	w.WriteInt(m.Holder)
+	w.WriteBytes(m.Nonce)
+	w.WriteBytes(m.Signature)
+}
+
+func (r *transcriptReader) readWatermark() Watermark {
+	var m Watermark
+	r.expectTag("dkg/watermark")
+	m.Holder = r.readInt()
+	m.Nonce = r.readBytes()
+	m.Signature = r.readBytes()
+	if r.err == nil && len(m.Nonce) != watermarkNonceSize {
+		r.fail("invalid watermark nonce")
+	}
+	return m
+}
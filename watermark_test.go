@@ -0,0 +1,64 @@
+package dkg
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestWatermark(t *testing.T) {
+	nodes, participants := getCeremonyNodesForTesting(t, 3, 1)
+	results := runCeremonyForTesting(t, nodes, participants)
+	checkCeremonyResultsForTesting(t, results)
+	set, _ := NewParticipantSet(nodes[0].curve, 1, participants)
+
+	share := results[0]
+	if _, err := share.Watermark(sha256.New(), nodes[0].id, &nodes[0].key, rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+
+	// the mark survives a backup
+	b, err := share.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var leaked KeyShare
+	if err := leaked.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(leaked.Watermarked, share.Watermarked) {
+		t.Errorf("Watermark decoded to %+v", leaked.Watermarked)
+	}
+	holder, err := TraceWatermark(sha256.New(), set, &leaked)
+	if err != nil || holder.ID.Cmp(nodes[0].id) != 0 {
+		t.Errorf("Traced leaked share to %v (%v)", holder.ID, err)
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		unmarked := *results[1]
+		framed := *share
+		framed.Watermarked = &Watermark{nodes[1].id, share.Watermarked.Nonce, share.Watermarked.Signature}
+		forged := *results[1]
+		forged.Watermark(sha256.New(), nodes[0].id, &nodes[1].key, rand.Reader)
+		moved := *share
+		moved.Epoch++
+		for _, test := range []struct {
+			description string
+			share       *KeyShare
+		}{
+			{"unmarked", &unmarked},
+			{"framed", &framed},
+			{"forged", &forged},
+			{"moved", &moved},
+		} {
+			if _, err := TraceWatermark(sha256.New(), set, test.share); reflect.TypeOf(err) != reflect.TypeOf(InvalidWatermarkError{}) {
+				t.Errorf("Got unexpected error tracing %v share: %v", test.description, err)
+			}
+		}
+		if VerifyWatermark(sha256.New(), Participant{big.NewInt(1), participants[1].Key}, share) {
+			t.Errorf("Watermark verifies under another key")
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package dkg
+
+import "crypto/elliptic"
+import "math/big"
+
+// MaxWeight bounds the weight of a participant of a WeightedParticipantSet.
+const MaxWeight = 1<<16 - 1
+
+// WeightedParticipant is an operator holding Weight shares of a key.
+type WeightedParticipant struct {
+	Participant
+	Weight int
+}
+
+// WeightedParticipantSet is the roster of a ceremony among operators of
+// different weights. Each operator takes part in the ceremony with a node
+// for each of its shares, at virtual IDs derived from its own ID, all with
+// its identity key. Any operators with more than threshold shares between
+// them can use the key, with RecoverSecret, or with LagrangeCoefficient over
+// the virtual IDs of SignerIDs.
+type WeightedParticipantSet struct {
+	set       *ParticipantSet
+	operators []WeightedParticipant
+}
+
+// VirtualID returns the ID of the k-th share, counting from 1, of the
+// operator with ID id: id * 2^16 + k.
+func VirtualID(id *big.Int, k int) *big.Int {
+	v := new(big.Int).Lsh(id, 16)
+	return v.Add(v, big.NewInt(int64(k)))
+}
+
+// NewWeightedParticipantSet checks the operators like NewParticipantSet, with
+// weights from 1 to MaxWeight, and threshold in shares.
+func NewWeightedParticipantSet(curve elliptic.Curve, threshold int, operators []WeightedParticipant) (*WeightedParticipantSet, error) {
+	var virtual []Participant
+	ids := make([]*big.Int, len(operators))
+	for i, op := range operators {
+		if op.ID == nil || op.ID.Sign() <= 0 {
+			return nil, InvalidParticipantIDError{op.ID}
+		}
+		if op.Weight < 1 || op.Weight > MaxWeight {
+			return nil, InvalidWeightError{op.ID, op.Weight}
+		}
+		ids[i] = op.ID
+		for k := 1; k <= op.Weight; k++ {
+			virtual = append(virtual, Participant{VirtualID(op.ID, k), op.Key})
+		}
+	}
+	if err := validateIDs(curve, 0, ids); err != nil {
+		return nil, err
+	}
+	set, err := NewParticipantSet(curve, threshold, virtual)
+	if err != nil {
+		return nil, err
+	}
+	return &WeightedParticipantSet{set, append([]WeightedParticipant(nil), operators...)}, nil
+}
+
+// Set returns the virtual participants, for the operators' nodes.
+func (s *WeightedParticipantSet) Set() *ParticipantSet {
+	return s.set
+}
+
+func (s *WeightedParticipantSet) Operators() []WeightedParticipant {
+	return append([]WeightedParticipant(nil), s.operators...)
+}
+
+func (s *WeightedParticipantSet) operator(id *big.Int) (WeightedParticipant, bool) {
+	for _, op := range s.operators {
+		if op.ID.Cmp(id) == 0 {
+			return op, true
+		}
+	}
+	return WeightedParticipant{}, false
+}
+
+// VirtualIDs returns the IDs of the operator's shares.
+func (s *WeightedParticipantSet) VirtualIDs(operator *big.Int) ([]*big.Int, error) {
+	op, ok := s.operator(operator)
+	if !ok {
+		return nil, UnknownParticipantError{operator}
+	}
+	ids := make([]*big.Int, op.Weight)
+	for k := range ids {
+		ids[k] = VirtualID(op.ID, k+1)
+	}
+	return ids, nil
+}
+
+// Operator returns the operator holding the share with virtual ID id.
+func (s *WeightedParticipantSet) Operator(id *big.Int) (WeightedParticipant, bool) {
+	op, ok := s.operator(new(big.Int).Rsh(id, 16))
+	k := new(big.Int).And(id, big.NewInt(MaxWeight)).Int64()
+	if !ok || k < 1 || k > int64(op.Weight) {
+		return WeightedParticipant{}, false
+	}
+	return op, true
+}
+
+// Weight returns the number of shares the distinct operators hold between
+// them.
+func (s *WeightedParticipantSet) Weight(operators []*big.Int) (int, error) {
+	weight := 0
+	seen := make(map[string]bool)
+	for _, id := range operators {
+		op, ok := s.operator(id)
+		if !ok {
+			return 0, UnknownParticipantError{id}
+		}
+		if !seen[op.ID.String()] {
+			seen[op.ID.String()] = true
+			weight += op.Weight
+		}
+	}
+	return weight, nil
+}
+
+// SignerIDs returns the virtual IDs of the operators' shares, to interpolate
+// over, once the operators hold more than threshold shares.
+func (s *WeightedParticipantSet) SignerIDs(operators []*big.Int) ([]*big.Int, error) {
+	weight, err := s.Weight(operators)
+	if err != nil {
+		return nil, err
+	}
+	if weight <= s.set.threshold {
+		return nil, InsufficientSharesError{weight, s.set.threshold + 1}
+	}
+	var ids []*big.Int
+	seen := make(map[string]bool)
+	for _, id := range operators {
+		if seen[id.String()] {
+			continue
+		}
+		seen[id.String()] = true
+		virtual, _ := s.VirtualIDs(id)
+		ids = append(ids, virtual...)
+	}
+	return ids, nil
+}
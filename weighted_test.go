@@ -0,0 +1,91 @@
+package dkg
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha512"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWeightedParticipantSet(t *testing.T) {
+	curve, _, g2x, g2y, zkParam, _, _, _, _, _ := getValidNodeParamsForTesting(t)
+	const threshold = 3
+	var operators []WeightedParticipant
+	keys := make(map[string]*ecdsa.PrivateKey)
+	for i, weight := range []int{3, 1, 2} {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id := big.NewInt(int64(i + 1))
+		keys[id.String()] = key
+		operators = append(operators, WeightedParticipant{Participant{id, key.PublicKey}, weight})
+	}
+	set, err := NewWeightedParticipantSet(curve, threshold, operators)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// each operator runs a node per share, with its identity key
+	var nodes []*Node
+	for _, p := range set.Set().Participants() {
+		op, ok := set.Operator(p.ID)
+		if !ok {
+			t.Fatalf("No operator for %v", p.ID)
+		}
+		node, err := NewNodeWithOptions(WithConfig(NodeConfig{
+			Curve:     curve,
+			Hash:      sha512.New512_256(),
+			G2X:       g2x,
+			G2Y:       g2y,
+			ZKParam:   zkParam,
+			Timeout:   200 * time.Millisecond,
+			ID:        p.ID,
+			Key:       *keys[op.ID.String()],
+			Threshold: threshold,
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, node)
+	}
+	results := runCeremonyForTesting(t, nodes, set.Set().Participants())
+	checkCeremonyResultsForTesting(t, results)
+	shares := make(map[string]*KeyShare)
+	for _, result := range results {
+		shares[result.ID.String()] = result
+	}
+
+	// operators 1 and 2 hold 4 shares, operators 2 and 3 only 3
+	ids, err := set.SignerIDs([]*big.Int{big.NewInt(1), big.NewInt(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := new(big.Int)
+	for _, id := range ids {
+		l, err := LagrangeCoefficient(curve, id, ids)
+		if err != nil {
+			t.Fatal(err)
+		}
+		secret.Add(secret, l.Mul(l, shares[id.String()].Share))
+	}
+	secret.Mod(secret, curve.Params().N)
+	if x, _ := curve.ScalarBaseMult(secret.Bytes()); x.Cmp(results[0].PublicKey.X) != 0 {
+		t.Errorf("Operators 1 and 2 interpolated another secret")
+	}
+	if _, err := set.SignerIDs([]*big.Int{big.NewInt(2), big.NewInt(3), big.NewInt(3)}); !reflect.DeepEqual(err, InsufficientSharesError{3, 4}) {
+		t.Errorf("Got unexpected error for operators 2 and 3: %v", err)
+	}
+
+	if _, ok := set.Operator(VirtualID(big.NewInt(2), 2)); ok {
+		t.Errorf("Found operator for a share beyond its weight")
+	}
+	bad := append([]WeightedParticipant(nil), operators...)
+	bad[1].Weight = 0
+	if _, err := NewWeightedParticipantSet(curve, threshold, bad); !reflect.DeepEqual(err, InvalidWeightError{big.NewInt(2), 0}) {
+		t.Errorf("Got unexpected error for weight 0: %v", err)
+	}
+}
@@ -0,0 +1,421 @@
+package dkg
+
+import "bytes"
+import "crypto/elliptic"
+import "encoding/binary"
+import "math/big"
+
+// wireVersion is the first byte of the binary encodings of messages and
+// payloads. The rest is the canonical encoding the value is hashed with, so
+// that a transcript of encoded messages can be checked without re-encoding,
+// unless the points are compressed.
+const wireVersion = 1
+
+// bufferHash is a hash.Hash whose sum is everything written to it.
+type bufferHash struct {
+	bytes.Buffer
+}
+
+func (h *bufferHash) Sum(b []byte) []byte { return append(b, h.Bytes()...) }
+func (h *bufferHash) Size() int           { return h.Len() }
+func (h *bufferHash) BlockSize() int      { return 1 }
+
+func marshalBinary(v Hashable) []byte {
+	return encodeBinary(v.writeCanonical)
+}
+
+func encodeBinary(write func(w *TranscriptWriter)) []byte {
+	h := &bufferHash{}
+	h.WriteByte(wireVersion)
+	write(&TranscriptWriter{h: h})
+	return h.Bytes()
+}
+
+// encodeCompressed is encodeBinary with the points of curve compressed.
+func encodeCompressed(curve elliptic.Curve, write func(w *TranscriptWriter)) []byte {
+	h := &bufferHash{}
+	h.WriteByte(wireVersionCompressed)
+	write(&TranscriptWriter{h, curve})
+	return h.Bytes()
+}
+
+// unmarshalBinary checks the version of data and has read consume the
+// rest, all of it.
+func unmarshalBinary(data []byte, read func(r *transcriptReader)) error {
+	if len(data) == 0 || data[0] != wireVersion && data[0] != wireVersionCompressed {
+		return InvalidEncodingError{"unsupported version"}
+	}
+	r := &transcriptReader{b: data[1:], compressed: data[0] == wireVersionCompressed}
+	read(r)
+	if len(r.b) > 0 {
+		r.fail("trailing data")
+	}
+	return r.err
+}
+
+// transcriptReader parses the fields written by a TranscriptWriter. The
+// first error sticks and makes all further reads return zero values.
+type transcriptReader struct {
+	b          []byte
+	err        error
+	compressed bool
+}
+
+func (r *transcriptReader) fail(reason string) {
+	if r.err == nil {
+		r.err = InvalidEncodingError{reason}
+		r.b = nil
+	}
+}
+
+func (r *transcriptReader) readBytes() []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.b) < 4 {
+		r.fail("truncated")
+		return nil
+	}
+	n := binary.BigEndian.Uint32(r.b)
+	if uint64(n) > uint64(len(r.b)-4) {
+		r.fail("truncated")
+		return nil
+	}
+	b := append([]byte(nil), r.b[4:4+n]...)
+	r.b = r.b[4+n:]
+	return b
+}
+
+func (r *transcriptReader) readTag() string {
+	return string(r.readBytes())
+}
+
+func (r *transcriptReader) expectTag(tag string) {
+	if r.readTag() != tag {
+		r.fail("expected " + tag)
+	}
+}
+
+func (r *transcriptReader) readUint() uint64 {
+	b := r.readBytes()
+	if len(b) != 8 {
+		r.fail("invalid integer")
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+// readCount reads the length of a list whose elements take at least 4
+// bytes each, bounding allocations by the size of the input.
+func (r *transcriptReader) readCount() int {
+	n := r.readUint()
+	if n > uint64(len(r.b)/4) {
+		r.fail("list longer than its encoding")
+		return 0
+	}
+	return int(n)
+}
+
+// readInt reads a minimally encoded non-negative integer.
+func (r *transcriptReader) readInt() *big.Int {
+	b := r.readBytes()
+	if len(b) > 0 && b[0] == 0 {
+		r.fail("non-minimal integer")
+	}
+	return new(big.Int).SetBytes(b)
+}
+
+// readID reads a participant ID, where zero stands for none.
+func (r *transcriptReader) readID() *big.Int {
+	id := r.readInt()
+	if id.Sign() == 0 {
+		return nil
+	}
+	return id
+}
+
+func (r *transcriptReader) readPoints() PointTuple {
+	n := r.readCount()
+	if r.compressed {
+		return r.readCompressedPoints(n)
+	}
+	var pts PointTuple
+	for len(pts) < n {
+		pts = append(pts, struct{ X, Y *big.Int }{r.readInt(), r.readInt()})
+	}
+	return pts
+}
+
+func (r *transcriptReader) readShares() SecretShares {
+	curve := r.readCurve()
+	return SecretShares{r.readScalar(curve), r.readScalar(curve), curve}
+}
+
+func (r *transcriptReader) readComplaints() Complaints {
+	var c Complaints
+	for n := r.readCount(); len(c.Accused) < n; {
+		c.Accused = append(c.Accused, r.readInt())
+	}
+	c.Signature = r.readBytes()
+	return c
+}
+
+func (r *transcriptReader) readJustification() Justification {
+	var j Justification
+	for n := r.readCount(); len(j.Revealed) < n; {
+		recipient := r.readInt()
+		r.expectTag("dkg/shares")
+		j.Revealed = append(j.Revealed, RevealedShares{recipient, r.readShares()})
+	}
+	return j
+}
+
+func (r *transcriptReader) readExposure() Exposure {
+	var e Exposure
+	for n := r.readCount(); len(e.Exposed) < n; {
+		dealer := r.readInt()
+		r.expectTag("dkg/shares")
+		e.Exposed = append(e.Exposed, ExposedShares{dealer, r.readShares()})
+	}
+	return e
+}
+
+func (r *transcriptReader) readKnowledgeProof() SecretKnowledgeProof {
+	curve := r.readCurve()
+	return SecretKnowledgeProof{r.readInt(), r.readInt(), r.readScalar(curve), r.readScalar(curve), curve}
+}
+
+// payloadTags are the tags of the payload each message type carries.
+var payloadTags = []string{
+	VerificationPointsMessage:    "dkg/points",
+	SecretSharesMessage:          "dkg/encrypted-shares",
+	ComplaintsMessage:            "dkg/complaints",
+	JustificationMessage:         "dkg/justification",
+	PublicCoefficientsMessage:    "dkg/points",
+	SecretKnowledgeMessage:       "dkg/secret-knowledge-proof",
+	HelloMessage:                 "dkg/hello",
+	RetryMessage:                 "dkg/retry",
+	QualifiedSetMessage:          "dkg/qualified-set",
+	CoefficientComplaintsMessage: "dkg/exposure",
+	ReconstructionMessage:        "dkg/exposure",
+}
+
+func (r *transcriptReader) readHello() Hello {
+	h := Hello{Nonce: r.readUint()}
+	switch r.readUint() {
+	case 0:
+	case 1:
+		h.Echo = true
+	default:
+		r.fail("invalid echo flag")
+	}
+	return h
+}
+
+func (r *transcriptReader) readRetry() Retry {
+	return Retry{Phase(r.readUint()), r.readUint()}
+}
+
+func (r *transcriptReader) readQualifiedSet() QualifiedSet {
+	var q QualifiedSet
+	for n := r.readCount(); len(q.Qualified) < n; {
+		q.Qualified = append(q.Qualified, r.readInt())
+	}
+	q.Signature = r.readBytes()
+	return q
+}
+
+func (r *transcriptReader) readMessage() Message {
+	var m Message
+	t := r.readUint()
+	if t >= uint64(len(payloadTags)) {
+		r.fail("unknown message type")
+		return m
+	}
+	m.Type = MessageType(t)
+	m.From = r.readID()
+	m.To = r.readID()
+	m.Payload = r.readPayload(m.Type)
+	return m
+}
+
+// readPayload reads the payload of a message of type t, possibly tagged with
+// a session, sealed in an envelope or relayed in an echo broadcast.
+func (r *transcriptReader) readPayload(t MessageType) Hashable {
+	switch tag := r.readTag(); tag {
+	case "":
+		return nil
+	case "dkg/session":
+		session := string(r.readBytes())
+		return sessionPayload{session, r.readPayload(t)}
+	case "dkg/envelope":
+		var e envelopePayload
+		e.Session = string(r.readBytes())
+		e.Sequence = r.readUint()
+		e.Phase = Phase(r.readUint())
+		e.Signature = r.readBytes()
+		e.Payload = r.readPayload(t)
+		return e
+	case "dkg/broadcast":
+		var b broadcastPayload
+		if b.Step = broadcastStep(r.readUint()); int(b.Step) >= len(broadcastStepNames) {
+			r.fail("unknown broadcast step")
+		}
+		b.Origin = r.readInt()
+		b.Payload = r.readPayload(t)
+		return b
+	case payloadTags[t]:
+		switch tag {
+		case "dkg/points":
+			return r.readPoints()
+		case "dkg/encrypted-shares":
+			return EncryptedShares{r.readBytes()}
+		case "dkg/complaints":
+			return r.readComplaints()
+		case "dkg/justification":
+			return r.readJustification()
+		case "dkg/secret-knowledge-proof":
+			return r.readKnowledgeProof()
+		case "dkg/hello":
+			return r.readHello()
+		case "dkg/retry":
+			return r.readRetry()
+		case "dkg/qualified-set":
+			return r.readQualifiedSet()
+		case "dkg/exposure":
+			return r.readExposure()
+		}
+	}
+	r.fail("payload doesn't match the message type")
+	return nil
+}
+
+// MarshalBinary returns the versioned canonical encoding of m. Scalars are
+// range-checked on decoding; points are checked against the curve by the
+// receiver.
+func (m Message) MarshalBinary() ([]byte, error) {
+	return marshalBinary(m), nil
+}
+
+func (m *Message) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, func(r *transcriptReader) {
+		r.expectTag("dkg/message")
+		*m = r.readMessage()
+	})
+}
+
+func (pts PointTuple) MarshalBinary() ([]byte, error) {
+	return marshalBinary(pts), nil
+}
+
+func (pts *PointTuple) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, func(r *transcriptReader) {
+		r.expectTag("dkg/points")
+		*pts = r.readPoints()
+	})
+}
+
+func (s SecretShares) MarshalBinary() ([]byte, error) {
+	if s.Curve == nil {
+		return nil, InvalidEncodingError{"shares without a curve"}
+	}
+	return marshalBinary(s), nil
+}
+
+func (s *SecretShares) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, func(r *transcriptReader) {
+		r.expectTag("dkg/shares")
+		*s = r.readShares()
+	})
+}
+
+func (e EncryptedShares) MarshalBinary() ([]byte, error) {
+	return marshalBinary(e), nil
+}
+
+func (e *EncryptedShares) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, func(r *transcriptReader) {
+		r.expectTag("dkg/encrypted-shares")
+		*e = EncryptedShares{r.readBytes()}
+	})
+}
+
+func (c Complaints) MarshalBinary() ([]byte, error) {
+	return marshalBinary(c), nil
+}
+
+func (c *Complaints) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, func(r *transcriptReader) {
+		r.expectTag("dkg/complaints")
+		*c = r.readComplaints()
+	})
+}
+
+func (j Justification) MarshalBinary() ([]byte, error) {
+	return marshalBinary(j), nil
+}
+
+func (j *Justification) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, func(r *transcriptReader) {
+		r.expectTag("dkg/justification")
+		*j = r.readJustification()
+	})
+}
+
+func (p SecretKnowledgeProof) MarshalBinary() ([]byte, error) {
+	if p.Curve == nil {
+		return nil, InvalidEncodingError{"proof without a curve"}
+	}
+	return marshalBinary(p), nil
+}
+
+func (p *SecretKnowledgeProof) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, func(r *transcriptReader) {
+		r.expectTag("dkg/secret-knowledge-proof")
+		*p = r.readKnowledgeProof()
+	})
+}
+
+func (h Hello) MarshalBinary() ([]byte, error) {
+	return marshalBinary(h), nil
+}
+
+func (h *Hello) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, func(r *transcriptReader) {
+		r.expectTag("dkg/hello")
+		*h = r.readHello()
+	})
+}
+
+func (q Retry) MarshalBinary() ([]byte, error) {
+	return marshalBinary(q), nil
+}
+
+func (q *Retry) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, func(r *transcriptReader) {
+		r.expectTag("dkg/retry")
+		*q = r.readRetry()
+	})
+}
+
+func (q QualifiedSet) MarshalBinary() ([]byte, error) {
+	return marshalBinary(q), nil
+}
+
+func (q *QualifiedSet) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, func(r *transcriptReader) {
+		r.expectTag("dkg/qualified-set")
+		*q = r.readQualifiedSet()
+	})
+}
+
+func (e Exposure) MarshalBinary() ([]byte, error) {
+	return marshalBinary(e), nil
+}
+
+func (e *Exposure) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, func(r *transcriptReader) {
+		r.expectTag("dkg/exposure")
+		*e = r.readExposure()
+	})
+}
@@ -0,0 +1,103 @@
+package dkg
+
+import (
+	"crypto/elliptic"
+	"encoding"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestBinaryEncoding(t *testing.T) {
+	values := []struct {
+		v       encoding.BinaryMarshaler
+		decoded encoding.BinaryUnmarshaler
+	}{
+		{PointTuple{{big.NewInt(1), big.NewInt(23)}}, &PointTuple{}},
+		{SecretShares{big.NewInt(5), big.NewInt(6), elliptic.P256()}, &SecretShares{}},
+		{EncryptedShares{[]byte("ciphertext")}, &EncryptedShares{}},
+		{Complaints{[]*big.Int{big.NewInt(3)}, []byte("signature")}, &Complaints{}},
+		{Justification{[]RevealedShares{{big.NewInt(3), SecretShares{big.NewInt(5), big.NewInt(6), elliptic.P384()}}}}, &Justification{}},
+		{SecretKnowledgeProof{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4), elliptic.P256()}, &SecretKnowledgeProof{}},
+		{Message{ComplaintsMessage, big.NewInt(1), nil, Complaints{}}, &Message{}},
+		{Message{SecretSharesMessage, big.NewInt(1), big.NewInt(2), nil}, &Message{}},
+		{Message{VerificationPointsMessage, big.NewInt(1), nil, sessionPayload{"0/P-256", PointTuple{{big.NewInt(1), big.NewInt(2)}}}}, &Message{}},
+		{Message{ComplaintsMessage, big.NewInt(1), nil, envelopePayload{"ceremony", 3, PhaseComplaining, []byte("signature"), Complaints{}}}, &Message{}},
+		{Message{ComplaintsMessage, big.NewInt(2), big.NewInt(3), broadcastPayload{broadcastEcho, big.NewInt(1), Complaints{}}}, &Message{}},
+	}
+	for _, v := range values {
+		b, err := v.v.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Could not encode %+v: %v", v.v, err)
+		}
+		if err := v.decoded.UnmarshalBinary(b); err != nil {
+			t.Errorf("Could not decode %+v: %v", v.v, err)
+			continue
+		}
+		if decoded := reflect.ValueOf(v.decoded).Elem().Interface(); !reflect.DeepEqual(decoded, v.v) {
+			t.Errorf("%+v decoded to %+v", v.v, decoded)
+		}
+	}
+}
+
+func TestBinaryDecodingRejects(t *testing.T) {
+	valid, _ := Message{ComplaintsMessage, big.NewInt(1), nil, Complaints{[]*big.Int{big.NewInt(3)}, nil}}.MarshalBinary()
+	mismatched, _ := Message{VerificationPointsMessage, big.NewInt(1), nil, Complaints{}}.MarshalBinary()
+	unknown, _ := Message{MessageType(99), big.NewInt(1), nil, nil}.MarshalBinary()
+	nonMinimal := append([]byte{wireVersion}, canonicalEncodingForTesting(PointTuple{{big.NewInt(5), big.NewInt(6)}})...)
+	// prefix 00000002 0005 instead of 00000001 05
+	nonMinimal = append(nonMinimal[:len(nonMinimal)-10], 0, 0, 0, 2, 0, 5, 0, 0, 0, 1, 6)
+	var hugeCount []byte
+	{
+		h := &bufferHash{}
+		h.WriteByte(wireVersion)
+		w := &TranscriptWriter{h: h}
+		w.WriteTag("dkg/points")
+		w.WriteUint(1 << 40)
+		hugeCount = h.Bytes()
+	}
+	// encodes scalars as given, skipping the range check of WriteScalar
+	encodeScalars := func(tag string, curve elliptic.Curve, points []*big.Int, scalars ...[]byte) []byte {
+		h := &bufferHash{}
+		h.WriteByte(wireVersion)
+		w := &TranscriptWriter{h: h}
+		w.WriteTag(tag)
+		w.WriteTag(curve.Params().Name)
+		for _, x := range points {
+			w.WriteInt(x)
+		}
+		for _, k := range scalars {
+			w.WriteBytes(k)
+		}
+		return h.Bytes()
+	}
+	curve := elliptic.P256()
+	n := curve.Params().N.FillBytes(make([]byte, 32))
+	six := big.NewInt(6).FillBytes(make([]byte, 32))
+
+	cases := []struct {
+		desc string
+		b    []byte
+		v    encoding.BinaryUnmarshaler
+	}{
+		{"empty input", nil, &Message{}},
+		{"unsupported version", append([]byte{wireVersionCompressed + 1}, valid[1:]...), &Message{}},
+		{"truncated message", valid[:len(valid)-3], &Message{}},
+		{"trailing data", append(append([]byte(nil), valid...), 0), &Message{}},
+		{"wrong type", valid, &PointTuple{}},
+		{"payload not matching the type", mismatched, &Message{}},
+		{"unknown message type", unknown, &Message{}},
+		{"non-minimal integer", nonMinimal, &PointTuple{}},
+		{"share out of range", encodeScalars("dkg/shares", curve, nil, n, six), &SecretShares{}},
+		{"share not fixed-width", encodeScalars("dkg/shares", curve, nil, six, []byte{6}), &SecretShares{}},
+		{"proof response out of range", encodeScalars("dkg/secret-knowledge-proof", curve, []*big.Int{one, one}, six, n), &SecretKnowledgeProof{}},
+		{"unknown curve", encodeScalars("dkg/shares", &elliptic.CurveParams{Name: "P-0"}, nil, six, six), &SecretShares{}},
+		{"list longer than its encoding", hugeCount, &PointTuple{}},
+	}
+	for _, c := range cases {
+		err := c.v.UnmarshalBinary(c.b)
+		if reflect.TypeOf(err) != reflect.TypeOf(InvalidEncodingError{}) {
+			t.Errorf("Got unexpected error decoding %v: %v", c.desc, err)
+		}
+	}
+}
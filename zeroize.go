@@ -0,0 +1,34 @@
+package dkg
+
+import "math/big"
+
+// zeroize overwrites the words of secret integers in place, as far as Go
+// allows: copies the runtime or math/big made earlier are out of reach.
+func zeroize(xs ...*big.Int) {
+	for _, x := range xs {
+		if x != nil {
+			clear(x.Bits())
+			x.SetInt64(0)
+		}
+	}
+}
+
+func (s SecretShares) zeroize() {
+	zeroize(s.Share1, s.Share2)
+}
+
+// Zeroize wipes the node's secret polynomials and identity key. The node
+// can no longer deal, sign complaints or decrypt shares, so call it once
+// its ceremony is over.
+func (n *Node) Zeroize() {
+	zeroize(n.secretPoly1...)
+	zeroize(n.secretPoly2...)
+	zeroize(n.key.D)
+	n.invalidateCommitments()
+}
+
+// Zeroize wipes the secret share. The share is unusable afterwards.
+func (s *KeyShare) Zeroize() {
+	zeroize(s.Share)
+	s.Share = nil
+}
@@ -0,0 +1,52 @@
+package dkg
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestZeroize(t *testing.T) {
+	t.Run("Ceremony", func(t *testing.T) {
+		sim, err := NewSimulator(elliptic.P256(), 3, 1, 500*time.Millisecond, []byte("zeroize"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		results, err := sim.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkCeremonyResultsForTesting(t, results)
+		for _, runner := range sim.Runners() {
+			for _, p := range runner.participants {
+				for _, share := range []*big.Int{p.secretShare1, p.secretShare2} {
+					if share != nil && share.Sign() != 0 {
+						t.Errorf("Node %v kept the shares of %v after the ceremony", runner.node.id, p.id)
+					}
+				}
+			}
+		}
+
+		node := sim.Nodes[0]
+		node.Zeroize()
+		for _, c := range append(append(ScalarPolynomial{node.key.D}, node.secretPoly1...), node.secretPoly2...) {
+			if c.Sign() != 0 {
+				t.Errorf("Node %v kept a secret", node.id)
+			}
+		}
+	})
+
+	t.Run("Key share", func(t *testing.T) {
+		keys, nonces := runSigningCeremoniesForTesting(t, 3, 1)
+		share := keys[0].Share
+		keys[0].Zeroize()
+		if keys[0].Share != nil || share.Sign() != 0 {
+			t.Errorf("Key share was not wiped")
+		}
+		if _, err := ProductShare(keys[0], nonces[0]); reflect.TypeOf(err) != reflect.TypeOf(ShareDestroyedError{}) {
+			t.Errorf("Got unexpected error using a wiped share: %v", err)
+		}
+	})
+}